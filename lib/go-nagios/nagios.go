@@ -33,6 +33,24 @@ const (
 	Critical Status = 2
 )
 
+// String returns the Nagios name of the status, e.g. "CRITICAL".
+func (s Status) String() string {
+	switch s {
+	case Ok:
+		return "OK"
+	case Warning:
+		return "WARNING"
+	case Critical:
+		return "CRITICAL"
+	}
+	return "UNKNOWN"
+}
+
+// MarshalText implements encoding.TextMarshaler, so statuses serialize by name.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
 func Exit(status Status, msg string) {
 	if msg != "" {
 		msg = strings.TrimRight(msg, "\n")
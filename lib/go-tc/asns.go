@@ -30,3 +30,9 @@ type ASN struct {
 	ID           int    `json:"id" db:"id"`
 	LastUpdated  Time   `json:"lastUpdated" db:"last_updated"`
 }
+
+// ASNResponse is the response to creating or updating a ASN.
+type ASNResponse struct {
+	Response ASN `json:"response"`
+	Alerts
+}
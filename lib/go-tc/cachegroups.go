@@ -24,13 +24,42 @@ type CacheGroupsResponse struct {
 	Response []CacheGroup `json:"response"`
 }
 
+// CacheGroupResponse is the response to creating or updating a cachegroup.
+type CacheGroupResponse struct {
+	Response CacheGroup `json:"response"`
+	Alerts
+}
+
 // CacheGroup contains information about a given Cachegroup in Traffic Ops.
 type CacheGroup struct {
-	Name        string  `json:"name"`
-	ShortName   string  `json:"shortName"`
-	Latitude    float64 `json:"latitude"`
-	Longitude   float64 `json:"longitude"`
-	ParentName  string  `json:"parentCachegroupName,omitempty"`
-	Type        string  `json:"typeName,omitempty"`
-	LastUpdated string  `json:"lastUpdated,omitempty"`
+	ID                          int     `json:"id,omitempty"`
+	Name                        string  `json:"name"`
+	ShortName                   string  `json:"shortName"`
+	Latitude                    float64 `json:"latitude"`
+	Longitude                   float64 `json:"longitude"`
+	ParentCachegroupID          *int    `json:"parentCachegroupId,omitempty"`
+	ParentName                  string  `json:"parentCachegroupName,omitempty"`
+	SecondaryParentCachegroupID *int    `json:"secondaryParentCachegroupId,omitempty"`
+	SecondaryParentName         string  `json:"secondaryParentCachegroupName,omitempty"`
+	TypeID                      int     `json:"typeId,omitempty"`
+	Type                        string  `json:"typeName,omitempty"`
+	LastUpdated                 string  `json:"lastUpdated,omitempty"`
+}
+
+// CacheGroupQueueUpdateRequest queues or dequeues updates on the servers of a cachegroup in a CDN. Action is "queue" or "dequeue".
+type CacheGroupQueueUpdateRequest struct {
+	Action string `json:"action"`
+	CDN    string `json:"cdn,omitempty"`
+	CDNID  int    `json:"cdnId,omitempty"`
+}
+
+// CacheGroupQueueUpdateResponse is the response to queueing updates on a cachegroup, with the host names of the servers whose updates were queued or dequeued.
+type CacheGroupQueueUpdateResponse struct {
+	Response struct {
+		ServerNames    []string `json:"serverNames"`
+		Action         string   `json:"action"`
+		CDN            string   `json:"cdn"`
+		CachegroupName string   `json:"cachegroupName"`
+		CachegroupID   string   `json:"cachegroupId"`
+	} `json:"response"`
 }
@@ -0,0 +1,38 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// CapabilitiesResponse is the response of GET /api/1.3/capabilities.
+type CapabilitiesResponse struct {
+	Response []Capability `json:"response"`
+}
+
+// CapabilityResponse is the response of creating or updating a capability.
+type CapabilityResponse struct {
+	Response Capability `json:"response"`
+	Alerts
+}
+
+// Capability is a named permission. Roles have capabilities, and api_capability rows map capabilities to the API routes they allow.
+type Capability struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	LastUpdated Time   `json:"lastUpdated"`
+}
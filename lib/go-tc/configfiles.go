@@ -0,0 +1,49 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// ATSConfigMetaData is the list of config files a cache needs, returned by /servers/:server/configfiles/ats.
+type ATSConfigMetaData struct {
+	Info        ATSConfigMetaDataInfo         `json:"info"`
+	ConfigFiles []ATSConfigMetaDataConfigFile `json:"configFiles"`
+}
+
+// ATSConfigMetaDataInfo is the cache and Traffic Ops a config file list is for.
+type ATSConfigMetaDataInfo struct {
+	ProfileID     int    `json:"profileId"`
+	ProfileName   string `json:"profileName"`
+	ToRevProxyURL string `json:"toRevProxyUrl,omitempty"`
+	ToURL         string `json:"toUrl"`
+	ServerIPv4    string `json:"serverIpv4"`
+	ServerName    string `json:"serverName"`
+	ServerID      int    `json:"serverId"`
+	CDNID         int    `json:"cdnId"`
+	CDNName       string `json:"cdnName"`
+	ServerTCPPort int    `json:"serverTcpPort"`
+}
+
+// ATSConfigMetaDataConfigFile is a config file a cache needs, where it goes on the cache, and where to get it. Files with a URL are fetched from the URL, rather than the Traffic Ops API URI.
+type ATSConfigMetaDataConfigFile struct {
+	FileNameOnDisk string `json:"fnameOnDisk,omitempty"`
+	Location       string `json:"location,omitempty"`
+	APIURI         string `json:"apiUri,omitempty"`
+	URL            string `json:"url,omitempty"`
+	Scope          string `json:"scope"`
+}
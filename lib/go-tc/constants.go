@@ -30,6 +30,9 @@ const Gzip = "gzip"
 const ContentType = "Content-Type"
 const ContentEncoding = "Content-Encoding"
 
+// TraceIDHeader is the request header with which clients send a trace ID, which Traffic Ops logs, to follow a single operation across services.
+const TraceIDHeader = "X-TC-Trace-ID"
+
 type AlertLevel int
 
 const (
@@ -19,6 +19,12 @@ package tc
  * under the License.
  */
 
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
 // CRConfig is JSON-serializable as the CRConfig used by Traffic Control.
 type CRConfig struct {
 	Config           CRConfigConfig                       `json:"config,omitempty"`
@@ -39,7 +45,7 @@ type CRConfigConfig struct {
 	DNSSecEnabled                              *string      `json:"dnssec.enabled,omitempty"`
 	DomainName                                 *string      `json:"domain_name,omitempty"`
 	FederationMappingPollingIntervalSeconds    *string      `json:"federationmapping.polling.interval,omitempty"`
-	FederationMappingPollingURL                *string      `json:"federationmapping.polling.url"`
+	FederationMappingPollingURL                *string      `json:"federationmapping.polling.url,omitempty"`
 	GeoLocationPollingInterval                 *string      `json:"geolocation.polling.interval,omitempty"`
 	GeoLocationPollingURL                      *string      `json:"geolocation.polling.url,omitempty"`
 	KeyStoreMaintenanceIntervalSeconds         *string      `json:"keystore.maintenance.interval,omitempty"`
@@ -51,6 +57,77 @@ type CRConfigConfig struct {
 	Weight                                     *string      `json:"weight,omitempty"`
 	ZoneManagerCacheMaintenanceIntervalSeconds *string      `json:"zonemanager.cache.maintenance.interval,omitempty"`
 	ZoneManagerThreadpoolScale                 *string      `json:"zonemanager.threadpool.scale,omitempty"`
+	// RequestHeaders is the LogRequestHeaders parameter, the headers Traffic Router logs for every request.
+	RequestHeaders []string `json:"requestHeaders,omitempty"`
+	// Parameters is any other CRConfig.json parameters, which don't have a field. They're serialized as members of the config object, alongside the fields, but never replace a field.
+	Parameters map[string]interface{} `json:"-"`
+}
+
+// crConfigConfigAlias has the fields of CRConfigConfig without its methods, so they can serialize it.
+type crConfigConfigAlias CRConfigConfig
+
+// MarshalJSON serializes the fields of the CRConfigConfig, and its Parameters as additional members.
+func (c CRConfigConfig) MarshalJSON() ([]byte, error) {
+	alias := crConfigConfigAlias(c)
+	if len(c.Parameters) == 0 {
+		return json.Marshal(alias)
+	}
+	bts, err := json.Marshal(alias)
+	if err != nil {
+		return nil, err
+	}
+	members := map[string]interface{}{}
+	if err := json.Unmarshal(bts, &members); err != nil {
+		return nil, err
+	}
+	for name, val := range c.Parameters {
+		if _, ok := members[name]; !ok {
+			members[name] = val
+		}
+	}
+	return json.Marshal(members)
+}
+
+// UnmarshalJSON deserializes the fields of the CRConfigConfig, and any other members into its Parameters.
+func (c *CRConfigConfig) UnmarshalJSON(data []byte) error {
+	alias := crConfigConfigAlias{}
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	members := map[string]interface{}{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for name, val := range members {
+		if _, ok := crConfigConfigFields[name]; ok {
+			continue
+		}
+		if alias.Parameters == nil {
+			alias.Parameters = map[string]interface{}{}
+		}
+		alias.Parameters[name] = val
+	}
+	*c = CRConfigConfig(alias)
+	return nil
+}
+
+// crConfigConfigFields is the JSON names of the fields of CRConfigConfig.
+var crConfigConfigFields = jsonFieldNames(reflect.TypeOf(CRConfigConfig{}))
+
+// jsonFieldNames returns the JSON names of the serialized fields of the given struct type.
+func jsonFieldNames(t reflect.Type) map[string]struct{} {
+	names := map[string]struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "-" || t.Field(i).PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		names[name] = struct{}{}
+	}
+	return names
 }
 
 type CRConfigTTL struct {
@@ -65,7 +142,7 @@ type CRConfigTTL struct {
 type CRConfigRouterStatus string
 
 type CRConfigRouter struct {
-	APIPort      *string               `json:"apiPort,omitempty"`
+	APIPort      *string               `json:"api.port,omitempty"`
 	FQDN         *string               `json:"fqdn,omitempty"`
 	HTTPSPort    *int                  `json:"httpsPort,omitempty"`
 	IP           *string               `json:"ip,omitempty"`
@@ -92,24 +169,33 @@ type CRConfigTrafficOpsServer struct {
 	Profile          *string               `json:"profile,omitempty"`
 	ServerStatus     *CRConfigServerStatus `json:"status,omitempty"`
 	ServerType       *string               `json:"type,omitempty"`
+	RoutingDisabled  int64                 `json:"routingDisabled"`
 	DeliveryServices map[string][]string   `json:"deliveryServices,omitempty"`
 }
 
 //TODO: drichardson - reconcile this with the DeliveryService struct in deliveryservices.go
 type CRConfigDeliveryService struct {
-	CoverageZoneOnly    *string                          `json:"coverageZoneOnly,omitempty"`
-	Dispersion          *CRConfigDispersion              `json:"dispersion,omitempty"`
-	Domains             []string                         `json:"domains,omitempty"`
-	GeoLocationProvider *string                          `json:"geoLocationProvider,omitempty"`
-	MatchSets           []MatchSet                       `json:"matchSets,omitempty"`
-	MissLocation        *CRConfigLatitudeLongitude       `json:"missLocation,omitempty"`
-	Protocol            *CRConfigDeliveryServiceProtocol `json:"protocol,omitempty"`
-	RegionalGeoBlocking *string                          `json:"regionalGeoBlocking,omitempty"`
-	ResponseHeaders     map[string]string                `json:"responseHeaders,omitempty"`
-	Soa                 *SOA                             `json:"soa,omitempty"`
-	SSLEnabled          *string                          `json:"sslEnabled,omitempty"`
-	TTL                 *int                             `json:"ttl,omitempty"`
-	TTLs                *CRConfigTTL                     `json:"ttls,omitempty"`
+	BypassDestination    map[string]*CRConfigBypassDestination `json:"bypassDestination,omitempty"`
+	CoverageZoneOnly     *string                               `json:"coverageZoneOnly,omitempty"`
+	Dispersion           *CRConfigDispersion                   `json:"dispersion,omitempty"`
+	Domains              []string                              `json:"domains,omitempty"`
+	GeoEnabled           []CRConfigGeoEnabled                  `json:"geoEnabled,omitempty"`
+	GeoLimitRedirectURL  *string                               `json:"geoLimitRedirectURL,omitempty"`
+	GeoLocationProvider  *string                               `json:"geolocationProvider,omitempty"`
+	IP6RoutingEnabled    *string                               `json:"ip6RoutingEnabled,omitempty"`
+	MatchSets            []*CRConfigMatchSet                   `json:"matchsets,omitempty"`
+	MaxDNSIPsForLocation *int                                  `json:"maxDnsIpsForLocation,omitempty"`
+	MissLocation         *CRConfigMissLocation                 `json:"missLocation,omitempty"`
+	Protocol             *CRConfigDeliveryServiceProtocol      `json:"protocol,omitempty"`
+	RegionalGeoBlocking  *string                               `json:"regionalGeoBlocking,omitempty"`
+	RequestHeaders       []string                              `json:"requestHeaders,omitempty"`
+	ResponseHeaders      map[string]string                     `json:"responseHeaders,omitempty"`
+	RoutingName          *string                               `json:"routingName,omitempty"`
+	Soa                  *SOA                                  `json:"soa,omitempty"`
+	SSLEnabled           *string                               `json:"sslEnabled,omitempty"`
+	StaticDNSEntries     []CRConfigStaticDNSEntry              `json:"staticDnsEntries,omitempty"`
+	TTL                  *int                                  `json:"ttl,omitempty"`
+	TTLs                 *CRConfigTTL                          `json:"ttls,omitempty"`
 }
 
 type CRConfigDispersion struct {
@@ -122,10 +208,49 @@ type CRConfigLatitudeLongitude struct {
 	Lon float64 `json:"longitude"`
 }
 
+// CRConfigMissLocation is the location Traffic Router gives clients of a delivery service it can't geolocate.
+type CRConfigMissLocation struct {
+	Lat *float64 `json:"lat,omitempty"`
+	Lon *float64 `json:"long,omitempty"`
+}
+
 type CRConfigDeliveryServiceProtocol struct {
-	AcceptHTTP      bool `json:"acceptHttp,string,omitempty"`
-	AcceptHTTPS     bool `json:"acceptHttps,string,omitempty"`
-	RedirectOnHTTPS bool `json:"redirectOnHttps,string,omitempty"`
+	// AcceptHTTP is only set, to false, for HTTPS-only delivery services. Traffic Router treats a missing acceptHttp as true.
+	AcceptHTTP      *bool `json:"acceptHttp,string,omitempty"`
+	AcceptHTTPS     bool  `json:"acceptHttps,string"`
+	RedirectToHTTPS bool  `json:"redirectToHttps,string"`
+}
+
+// CRConfigMatchSet is a delivery service match set. Unlike MatchSet, its JSON keys are those of the CRConfig.
+type CRConfigMatchSet struct {
+	Protocol  string              `json:"protocol"`
+	MatchList []CRConfigMatchList `json:"matchlist"`
+}
+
+type CRConfigMatchList struct {
+	Regex     string `json:"regex"`
+	MatchType string `json:"match-type"`
+}
+
+type CRConfigGeoEnabled struct {
+	CountryCode string `json:"countryCode"`
+}
+
+// CRConfigBypassDestination is the DNS or HTTP bypass destination of a delivery service. DNS bypasses use the IP, IP6, CName and TTL, HTTP bypasses the FQDN and Port.
+type CRConfigBypassDestination struct {
+	IP    *string `json:"ip,omitempty"`
+	IP6   *string `json:"ip6,omitempty"`
+	CName *string `json:"cname,omitempty"`
+	TTL   *int    `json:"ttl,omitempty"`
+	FQDN  *string `json:"fqdn,omitempty"`
+	Port  *string `json:"port,omitempty"`
+}
+
+type CRConfigStaticDNSEntry struct {
+	Name  string `json:"name"`
+	TTL   int    `json:"ttl"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
 }
 
 type CRConfigMonitor struct {
@@ -0,0 +1,253 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// CRConfigDiff is the structural difference between two CRConfigs, by section. The stats section isn't compared, because it changes with every snapshot.
+type CRConfigDiff struct {
+	Config           CRConfigSectionDiff `json:"config"`
+	ContentServers   CRConfigSectionDiff `json:"contentServers"`
+	ContentRouters   CRConfigSectionDiff `json:"contentRouters"`
+	DeliveryServices CRConfigSectionDiff `json:"deliveryServices"`
+	EdgeLocations    CRConfigSectionDiff `json:"edgeLocations"`
+	Monitors         CRConfigSectionDiff `json:"monitors"`
+}
+
+// CRConfigDiffResponse is the response of the Traffic Ops snapshot diff endpoint.
+type CRConfigDiffResponse struct {
+	Response CRConfigDiffSummary `json:"response"`
+}
+
+// CRConfigDiffSummary is the difference between a snapshot of a CDN, by default its current one, and the CRConfig a snapshot would create now. Snapshot is the date of the snapshot, and Version its version, or nil if the CDN has never been snapshotted.
+type CRConfigDiffSummary struct {
+	Diff     CRConfigDiff `json:"diff"`
+	Summary  string       `json:"summary"`
+	Snapshot *int64       `json:"snapshotDate"`
+	Version  *int64       `json:"snapshotVersion"`
+}
+
+// CRConfigSnapshotVersionsResponse is the response of the Traffic Ops snapshot versions endpoint.
+type CRConfigSnapshotVersionsResponse struct {
+	Response []CRConfigSnapshotVersion `json:"response"`
+}
+
+// CRConfigSnapshotVersion is a stored snapshot of a CDN. Date is the stats date of the snapshot's CRConfig.
+type CRConfigSnapshotVersion struct {
+	Version     int64  `json:"version"`
+	Date        *int64 `json:"date"`
+	LastUpdated Time   `json:"lastUpdated"`
+}
+
+// CRConfigSectionDiff is the keys added to, removed from, and modified in a CRConfig section. Each list is sorted by key.
+type CRConfigSectionDiff struct {
+	Added    []string           `json:"added"`
+	Removed  []string           `json:"removed"`
+	Modified []CRConfigModified `json:"modified"`
+}
+
+// CRConfigModified is a key which exists in both CRConfigs, with different values.
+type CRConfigModified struct {
+	Key     string                `json:"key"`
+	Changes []CRConfigFieldChange `json:"changes"`
+}
+
+// CRConfigFieldChange is a single changed field of a modified key. The Field is the dot-separated JSON path within the key's value, or empty if the value isn't an object. Old or New is nil if the field was added or removed.
+type CRConfigFieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// DiffCRConfig returns the difference between the old and new CRConfigs. Values are compared by their JSON, so any two CRConfigs which serialize the same are equal.
+func DiffCRConfig(old *CRConfig, new *CRConfig) (CRConfigDiff, error) {
+	if old == nil {
+		old = &CRConfig{}
+	}
+	if new == nil {
+		new = &CRConfig{}
+	}
+	diff := CRConfigDiff{}
+	sections := []struct {
+		diff *CRConfigSectionDiff
+		old  interface{}
+		new  interface{}
+	}{
+		{&diff.Config, old.Config, new.Config},
+		{&diff.ContentServers, old.ContentServers, new.ContentServers},
+		{&diff.ContentRouters, old.ContentRouters, new.ContentRouters},
+		{&diff.DeliveryServices, old.DeliveryServices, new.DeliveryServices},
+		{&diff.EdgeLocations, old.EdgeLocations, new.EdgeLocations},
+		{&diff.Monitors, old.Monitors, new.Monitors},
+	}
+	for _, section := range sections {
+		oldVals, err := toJSONMap(section.old)
+		if err != nil {
+			return CRConfigDiff{}, errors.New("converting old CRConfig: " + err.Error())
+		}
+		newVals, err := toJSONMap(section.new)
+		if err != nil {
+			return CRConfigDiff{}, errors.New("converting new CRConfig: " + err.Error())
+		}
+		*section.diff = diffSection(oldVals, newVals)
+	}
+	return diff, nil
+}
+
+// Empty returns whether the CRConfigs had no differences.
+func (d CRConfigDiff) Empty() bool {
+	for _, section := range d.sections() {
+		if !section.diff.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Empty returns whether the section had no differences.
+func (d CRConfigSectionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// Summary returns a human-readable summary of the differences, with a line per changed section, e.g. "contentServers: 1 added (edge3), 1 modified (edge1)".
+func (d CRConfigDiff) Summary() string {
+	if d.Empty() {
+		return "No changes"
+	}
+	lines := []string{}
+	for _, section := range d.sections() {
+		if section.diff.Empty() {
+			continue
+		}
+		modified := []string{}
+		for _, m := range section.diff.Modified {
+			modified = append(modified, m.Key)
+		}
+		changes := []string{}
+		for _, change := range []struct {
+			verb string
+			keys []string
+		}{{"added", section.diff.Added}, {"removed", section.diff.Removed}, {"modified", modified}} {
+			if len(change.keys) > 0 {
+				changes = append(changes, fmt.Sprintf("%d %s (%s)", len(change.keys), change.verb, strings.Join(change.keys, ", ")))
+			}
+		}
+		lines = append(lines, section.name+": "+strings.Join(changes, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type namedSectionDiff struct {
+	name string
+	diff CRConfigSectionDiff
+}
+
+func (d CRConfigDiff) sections() []namedSectionDiff {
+	return []namedSectionDiff{
+		{"config", d.Config},
+		{"contentServers", d.ContentServers},
+		{"contentRouters", d.ContentRouters},
+		{"deliveryServices", d.DeliveryServices},
+		{"edgeLocations", d.EdgeLocations},
+		{"monitors", d.Monitors},
+	}
+}
+
+// toJSONMap returns the generic JSON of a CRConfig section map, so values can be compared field-by-field regardless of their Go type.
+func toJSONMap(section interface{}) (map[string]interface{}, error) {
+	bts, err := json.Marshal(section)
+	if err != nil {
+		return nil, err
+	}
+	vals := map[string]interface{}{}
+	if err := json.Unmarshal(bts, &vals); err != nil {
+		return nil, err
+	}
+	return vals, nil // a nil section marshals as null, which unmarshals into an empty map
+}
+
+func diffSection(old map[string]interface{}, new map[string]interface{}) CRConfigSectionDiff {
+	diff := CRConfigSectionDiff{Added: []string{}, Removed: []string{}, Modified: []CRConfigModified{}}
+	for key, newVal := range new {
+		oldVal, ok := old[key]
+		if !ok {
+			diff.Added = append(diff.Added, key)
+			continue
+		}
+		if changes := diffValue("", oldVal, newVal); len(changes) > 0 {
+			diff.Modified = append(diff.Modified, CRConfigModified{Key: key, Changes: changes})
+		}
+	}
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Sort(crConfigModifiedByKey(diff.Modified))
+	return diff
+}
+
+type crConfigModifiedByKey []CRConfigModified
+
+func (s crConfigModifiedByKey) Len() int           { return len(s) }
+func (s crConfigModifiedByKey) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s crConfigModifiedByKey) Less(i, j int) bool { return s[i].Key < s[j].Key }
+
+// diffValue returns the changed fields between two generic JSON values, recursing into objects. Arrays are compared as a whole, because their elements have no identity to match.
+func diffValue(path string, old interface{}, new interface{}) []CRConfigFieldChange {
+	oldObj, oldIsObj := old.(map[string]interface{})
+	newObj, newIsObj := new.(map[string]interface{})
+	if !oldIsObj || !newIsObj {
+		if reflect.DeepEqual(old, new) {
+			return nil
+		}
+		return []CRConfigFieldChange{{Field: path, Old: old, New: new}}
+	}
+
+	keys := []string{}
+	for key := range oldObj {
+		keys = append(keys, key)
+	}
+	for key := range newObj {
+		if _, ok := oldObj[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := []CRConfigFieldChange{}
+	for _, key := range keys {
+		field := key
+		if path != "" {
+			field = path + "." + key
+		}
+		changes = append(changes, diffValue(field, oldObj[key], newObj[key])...)
+	}
+	return changes
+}
@@ -0,0 +1,125 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testDiffCRConfigs() (*CRConfig, *CRConfig) {
+	ip1 := "192.0.2.1"
+	ip2 := "192.0.2.2"
+	edge := "EDGE"
+	ttl := 30
+	newTTL := 60
+	domain := "cdn.example.net"
+	oldAdmin := "ops"
+	newAdmin := "noc"
+	geoURL := "http://example.net/geo"
+
+	old := &CRConfig{
+		Config: CRConfigConfig{DomainName: &domain, SOA: &SOA{Admin: &oldAdmin}},
+		ContentServers: map[string]CRConfigTrafficOpsServer{
+			"edge1": {Ip: &ip1, ServerType: &edge},
+			"edge2": {Ip: &ip2, ServerType: &edge},
+		},
+		DeliveryServices: map[string]CRConfigDeliveryService{"ds1": {TTL: &ttl, Domains: []string{"ds1.cdn.example.net"}}},
+		EdgeLocations:    map[string]CRConfigLatitudeLongitude{"east": {Lat: 1, Lon: 2}},
+	}
+	new := &CRConfig{
+		Config: CRConfigConfig{DomainName: &domain, SOA: &SOA{Admin: &newAdmin}, GeoLocationPollingURL: &geoURL},
+		ContentServers: map[string]CRConfigTrafficOpsServer{
+			"edge1": {Ip: &ip2, ServerType: &edge},
+			"edge3": {Ip: &ip1, ServerType: &edge},
+		},
+		DeliveryServices: map[string]CRConfigDeliveryService{"ds1": {TTL: &newTTL, Domains: []string{"ds1.cdn.example.net"}}},
+		EdgeLocations:    map[string]CRConfigLatitudeLongitude{"east": {Lat: 1, Lon: 2}},
+	}
+	return old, new
+}
+
+func TestDiffCRConfig(t *testing.T) {
+	old, new := testDiffCRConfigs()
+	diff, err := DiffCRConfig(old, new)
+	if err != nil {
+		t.Fatalf("DiffCRConfig expected: nil error, actual: %v", err)
+	}
+
+	expectedConfig := CRConfigSectionDiff{
+		Added:    []string{"geolocation.polling.url"},
+		Removed:  []string{},
+		Modified: []CRConfigModified{{Key: "soa", Changes: []CRConfigFieldChange{{Field: "admin", Old: "ops", New: "noc"}}}},
+	}
+	if !reflect.DeepEqual(expectedConfig, diff.Config) {
+		t.Errorf("DiffCRConfig config expected: %+v, actual: %+v", expectedConfig, diff.Config)
+	}
+
+	expectedServers := CRConfigSectionDiff{
+		Added:    []string{"edge3"},
+		Removed:  []string{"edge2"},
+		Modified: []CRConfigModified{{Key: "edge1", Changes: []CRConfigFieldChange{{Field: "ip", Old: "192.0.2.1", New: "192.0.2.2"}}}},
+	}
+	if !reflect.DeepEqual(expectedServers, diff.ContentServers) {
+		t.Errorf("DiffCRConfig contentServers expected: %+v, actual: %+v", expectedServers, diff.ContentServers)
+	}
+
+	expectedDSes := []CRConfigModified{{Key: "ds1", Changes: []CRConfigFieldChange{{Field: "ttl", Old: float64(30), New: float64(60)}}}}
+	if !reflect.DeepEqual(expectedDSes, diff.DeliveryServices.Modified) {
+		t.Errorf("DiffCRConfig deliveryServices expected: %+v, actual: %+v", expectedDSes, diff.DeliveryServices.Modified)
+	}
+
+	if !diff.EdgeLocations.Empty() || !diff.Monitors.Empty() || !diff.ContentRouters.Empty() {
+		t.Errorf("DiffCRConfig expected: unchanged sections empty, actual: %+v", diff)
+	}
+
+	expectedSummary := `config: 1 added (geolocation.polling.url), 1 modified (soa)
+contentServers: 1 added (edge3), 1 removed (edge2), 1 modified (edge1)
+deliveryServices: 1 modified (ds1)`
+	if actual := diff.Summary(); actual != expectedSummary {
+		t.Errorf("Summary expected: %v, actual: %v", expectedSummary, actual)
+	}
+}
+
+func TestDiffCRConfigEqual(t *testing.T) {
+	old, _ := testDiffCRConfigs()
+	diff, err := DiffCRConfig(old, old)
+	if err != nil {
+		t.Fatalf("DiffCRConfig expected: nil error, actual: %v", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("DiffCRConfig expected: empty, actual: %+v", diff)
+	}
+	if actual := diff.Summary(); actual != "No changes" {
+		t.Errorf("Summary expected: No changes, actual: %v", actual)
+	}
+}
+
+func TestDiffCRConfigNil(t *testing.T) {
+	_, new := testDiffCRConfigs()
+	diff, err := DiffCRConfig(nil, new)
+	if err != nil {
+		t.Fatalf("DiffCRConfig expected: nil error, actual: %v", err)
+	}
+	expected := []string{"edge1", "edge3"}
+	if !reflect.DeepEqual(expected, diff.ContentServers.Added) {
+		t.Errorf("DiffCRConfig expected: %v added, actual: %v", expected, diff.ContentServers.Added)
+	}
+}
@@ -0,0 +1,85 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCRConfigConfigParameters(t *testing.T) {
+	domain := "cdn.example.net"
+	cfg := CRConfigConfig{
+		DomainName: &domain,
+		Parameters: map[string]interface{}{"maxmind.default.override": "US;1,2", "domain_name": "ignored.example.net"},
+	}
+	bts, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("marshalling CRConfigConfig: %v", err)
+	}
+	actual := map[string]interface{}{}
+	if err := json.Unmarshal(bts, &actual); err != nil {
+		t.Fatalf("unmarshalling CRConfigConfig: %v", err)
+	}
+	expected := map[string]interface{}{"domain_name": domain, "maxmind.default.override": "US;1,2"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("CRConfigConfig JSON expected: %v, actual: %v", expected, actual)
+	}
+
+	roundTrip := CRConfigConfig{}
+	if err := json.Unmarshal(bts, &roundTrip); err != nil {
+		t.Fatalf("unmarshalling CRConfigConfig: %v", err)
+	}
+	if roundTrip.DomainName == nil || *roundTrip.DomainName != domain {
+		t.Errorf("CRConfigConfig domain_name expected: %v, actual: %v", domain, roundTrip.DomainName)
+	}
+	expectedParams := map[string]interface{}{"maxmind.default.override": "US;1,2"}
+	if !reflect.DeepEqual(expectedParams, roundTrip.Parameters) {
+		t.Errorf("CRConfigConfig parameters expected: %v, actual: %v", expectedParams, roundTrip.Parameters)
+	}
+}
+
+func TestCRConfigDeliveryServiceProtocol(t *testing.T) {
+	f := false
+	for _, tc := range []struct {
+		protocol CRConfigDeliveryServiceProtocol
+		json     string
+	}{
+		{CRConfigDeliveryServiceProtocol{}, `{"acceptHttps":"false","redirectToHttps":"false"}`},
+		{CRConfigDeliveryServiceProtocol{AcceptHTTP: &f, AcceptHTTPS: true}, `{"acceptHttp":"false","acceptHttps":"true","redirectToHttps":"false"}`},
+		{CRConfigDeliveryServiceProtocol{AcceptHTTPS: true, RedirectToHTTPS: true}, `{"acceptHttps":"true","redirectToHttps":"true"}`},
+	} {
+		bts, err := json.Marshal(tc.protocol)
+		if err != nil {
+			t.Fatalf("marshalling protocol: %v", err)
+		}
+		if string(bts) != tc.json {
+			t.Errorf("protocol JSON expected: %v, actual: %v", tc.json, string(bts))
+		}
+		actual := CRConfigDeliveryServiceProtocol{}
+		if err := json.Unmarshal(bts, &actual); err != nil {
+			t.Fatalf("unmarshalling protocol: %v", err)
+		}
+		if !reflect.DeepEqual(actual, tc.protocol) {
+			t.Errorf("protocol expected: %+v, actual: %+v", tc.protocol, actual)
+		}
+	}
+}
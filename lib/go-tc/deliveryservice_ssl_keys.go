@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 )
 
 // DeliveryServiceSSLKeysResponse ...
@@ -84,3 +85,76 @@ func (v *DeliveryServiceSSLKeys) UnmarshalJSON(b []byte) (err error) {
 	}
 	return err
 }
+
+// SSLCertBundleResponse is the response of /servers/:server/sslkeys.
+type SSLCertBundleResponse struct {
+	Response SSLCertBundle `json:"response"`
+}
+
+// SSLCertBundle is the certificates and keys of the HTTPS delivery services of a cache. Delivery services whose keys are missing or whose certificates don't verify are in Errors instead of Certificates.
+type SSLCertBundle struct {
+	Certificates []SSLCertificate      `json:"certificates"`
+	Errors       []SSLCertificateError `json:"errors"`
+}
+
+// SSLCertificate is the verified certificate chain and private key of a delivery service, in PEM format, and the names of their files on a cache.
+type SSLCertificate struct {
+	DeliveryService string    `json:"deliveryService"`
+	HostName        string    `json:"hostName"`
+	CertFileName    string    `json:"certFileName"`
+	KeyFileName     string    `json:"keyFileName"`
+	Cert            string    `json:"cert"`
+	Key             string    `json:"key"`
+	Expiration      time.Time `json:"expiration"`
+}
+
+// SSLCertificateError is why a delivery service's certificate isn't in an SSLCertBundle.
+type SSLCertificateError struct {
+	DeliveryService string `json:"deliveryService"`
+	Error           string `json:"error"`
+}
+
+// SSLCertExpirationsResponse is the response of /cdns/:name/sslkeys/expirations.
+type SSLCertExpirationsResponse struct {
+	Response []SSLCertExpiration `json:"response"`
+}
+
+// SSLCertExpiration is the expiration of a delivery service certificate. DaysLeft is negative if the certificate has expired.
+type SSLCertExpiration struct {
+	DeliveryService string    `json:"deliveryService"`
+	HostName        string    `json:"hostName"`
+	Expiration      time.Time `json:"expiration"`
+	DaysLeft        int       `json:"daysLeft"`
+}
+
+// DeliveryServiceSSLKeysReq is a request to add or generate the SSL keys of a delivery service. The keys are added with their Certificate, or generated from the certificate subject fields.
+type DeliveryServiceSSLKeysReq struct {
+	CDN             string                             `json:"cdn,omitempty"`
+	DeliveryService string                             `json:"deliveryservice"`
+	Key             string                             `json:"key"`
+	Version         int                                `json:"version"`
+	Hostname        string                             `json:"hostname"`
+	BusinessUnit    string                             `json:"businessUnit,omitempty"`
+	City            string                             `json:"city,omitempty"`
+	Organization    string                             `json:"organization,omitempty"`
+	Country         string                             `json:"country,omitempty"`
+	State           string                             `json:"state,omitempty"`
+	Certificate     *DeliveryServiceSSLKeysCertificate `json:"certificate,omitempty"`
+}
+
+// URLSigKeysResponse is the response to a request for the URL signing keys of a delivery service.
+type URLSigKeysResponse struct {
+	Response URLSigKeys `json:"response"`
+}
+
+// URLSigKeys is the URL signing keys of a delivery service, by name, key0 to key15.
+type URLSigKeys map[string]string
+
+// URISignerKeys is the URI signing keysets of a delivery service, by issuer. The body of the requests to add and update them, and the response of the request for them, is the keys themselves, not wrapped in a response.
+type URISignerKeys map[string]URISignerKeyset
+
+// URISignerKeyset is the JSON Web Keys of an issuer, and the key ID of the key currently used to sign.
+type URISignerKeyset struct {
+	RenewalKid *string           `json:"renewal_kid"`
+	Keys       []json.RawMessage `json:"keys"`
+}
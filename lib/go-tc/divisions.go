@@ -28,3 +28,9 @@ type Division struct {
 	LastUpdated Time `json:"lastUpdated" db:"last_updated"`
 	Name        string   `json:"name" db:"name"`
 }
+
+// DivisionResponse is the response to creating or updating a division.
+type DivisionResponse struct {
+	Response Division `json:"response"`
+	Alerts
+}
@@ -0,0 +1,166 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// DNSSECKeyStatusNew is the status of the newest key of a zone, which Traffic Router signs with once it's effective.
+const DNSSECKeyStatusNew = "new"
+
+// DNSSECKeyStatusExisting is the status of a key which has been replaced by regenerating keys, which Traffic Router keeps signing with until it expires.
+const DNSSECKeyStatusExisting = "existing"
+
+// DNSSECKeyStatusExpired is the status of a key which has been rolled over, which Traffic Router keeps publishing until the new key is effective.
+const DNSSECKeyStatusExpired = "expired"
+
+// DNSSECKeys is the DNSSEC keys of a CDN, as stored in Riak, by the CDN name and the XML IDs of its delivery services.
+type DNSSECKeys map[string]DNSSECKeySet
+
+// DNSSECKeySet is the zone signing and key signing keys of a zone, newest first.
+type DNSSECKeySet struct {
+	ZSK []DNSSECKey `json:"zsk"`
+	KSK []DNSSECKey `json:"ksk"`
+}
+
+// DNSSECKey is a DNSSEC key pair. Public is the base64 DNSKEY record, and Private the base64 private key in the BIND private-key format. Dates are Unix timestamps. Only the key signing keys of the CDN zone have a DSRecord.
+type DNSSECKey struct {
+	InceptionDateUnix  int64              `json:"inceptionDate"`
+	ExpirationDateUnix int64              `json:"expirationDate"`
+	EffectiveDateUnix  int64              `json:"effectiveDate"`
+	Name               string             `json:"name"`
+	TTL                int64              `json:"ttl"`
+	Status             string             `json:"status"`
+	Public             string             `json:"public"`
+	Private            string             `json:"private"`
+	DSRecord           *DNSSECKeyDSRecord `json:"dsRecord,omitempty"`
+}
+
+// UnmarshalJSON decodes a DNSSECKey, accepting dates and a ttl which are strings, as the Perl Traffic Ops stored them.
+func (k *DNSSECKey) UnmarshalJSON(b []byte) error {
+	type dnssecKeyAlias DNSSECKey
+	key := struct {
+		InceptionDateUnix  json.Number `json:"inceptionDate"`
+		ExpirationDateUnix json.Number `json:"expirationDate"`
+		EffectiveDateUnix  json.Number `json:"effectiveDate"`
+		TTL                json.Number `json:"ttl"`
+		*dnssecKeyAlias
+	}{dnssecKeyAlias: (*dnssecKeyAlias)(k)}
+	if err := json.Unmarshal(b, &key); err != nil {
+		return err
+	}
+	err := error(nil)
+	if k.InceptionDateUnix, err = jsonNumberInt64(key.InceptionDateUnix); err != nil {
+		return errors.New("inceptionDate must be an integer")
+	}
+	if k.ExpirationDateUnix, err = jsonNumberInt64(key.ExpirationDateUnix); err != nil {
+		return errors.New("expirationDate must be an integer")
+	}
+	if k.EffectiveDateUnix, err = jsonNumberInt64(key.EffectiveDateUnix); err != nil {
+		return errors.New("effectiveDate must be an integer")
+	}
+	if k.TTL, err = jsonNumberInt64(key.TTL); err != nil {
+		return errors.New("ttl must be an integer")
+	}
+	return nil
+}
+
+// DNSSECKeyDSRecord is the DS record of a key signing key, for the parent zone.
+type DNSSECKeyDSRecord struct {
+	Algorithm  int    `json:"algorithm"`
+	DigestType int    `json:"digestType"`
+	Digest     string `json:"digest"`
+}
+
+// DNSSECKeysResponse is the response of GET /api/1.2/cdns/name/{name}/dnsseckeys.
+type DNSSECKeysResponse struct {
+	Response DNSSECKeys `json:"response"`
+}
+
+// DNSSECKeysGenerateRequest is a request to generate new DNSSEC keys for the CDN named Key, whose domain is Name, and its delivery services. Keys become effective at EffectiveDateUnix, or immediately if it's 0. Algorithm is the DNSSEC algorithm mnemonic, such as RSASHA256 or ECDSAP256SHA256, and defaults to RSASHA1 with 2048 and 1024 bit keys, as the Perl Traffic Ops generated. Bits are ignored for ECDSA algorithms, whose sizes are fixed.
+type DNSSECKeysGenerateRequest struct {
+	Key               string `json:"key"`
+	Name              string `json:"name"`
+	TTL               int64  `json:"ttl"`
+	KSKExpirationDays int64  `json:"kskExpirationDays"`
+	ZSKExpirationDays int64  `json:"zskExpirationDays"`
+	EffectiveDateUnix int64  `json:"effectiveDate"`
+	Algorithm         string `json:"algorithm,omitempty"`
+	KSKBits           int    `json:"kskBits,omitempty"`
+	ZSKBits           int    `json:"zskBits,omitempty"`
+}
+
+// UnmarshalJSON decodes a DNSSECKeysGenerateRequest, accepting numbers which are strings, as the Perl Traffic Ops did.
+func (r *DNSSECKeysGenerateRequest) UnmarshalJSON(b []byte) error {
+	type generateRequestAlias DNSSECKeysGenerateRequest
+	req := struct {
+		TTL               json.Number `json:"ttl"`
+		KSKExpirationDays json.Number `json:"kskExpirationDays"`
+		ZSKExpirationDays json.Number `json:"zskExpirationDays"`
+		EffectiveDateUnix json.Number `json:"effectiveDate"`
+		*generateRequestAlias
+	}{generateRequestAlias: (*generateRequestAlias)(r)}
+	if err := json.Unmarshal(b, &req); err != nil {
+		return err
+	}
+	err := error(nil)
+	if r.TTL, err = jsonNumberInt64(req.TTL); err != nil {
+		return errors.New("ttl must be an integer")
+	}
+	if r.KSKExpirationDays, err = jsonNumberInt64(req.KSKExpirationDays); err != nil {
+		return errors.New("kskExpirationDays must be an integer")
+	}
+	if r.ZSKExpirationDays, err = jsonNumberInt64(req.ZSKExpirationDays); err != nil {
+		return errors.New("zskExpirationDays must be an integer")
+	}
+	if r.EffectiveDateUnix, err = jsonNumberInt64(req.EffectiveDateUnix); err != nil {
+		return errors.New("effectiveDate must be an integer")
+	}
+	return nil
+}
+
+// DNSSECDSRecordsResponse is the response of GET /api/1.3/cdns/name/{name}/dnsseckeys/ds.
+type DNSSECDSRecordsResponse struct {
+	Response []DNSSECDSRecord `json:"response"`
+}
+
+// DNSSECDSRecord is a DS record of a CDN's key signing key, to publish in the parent zone of the CDN domain. Record is the record in zone file format.
+type DNSSECDSRecord struct {
+	Name               string `json:"name"`
+	Status             string `json:"status"`
+	KeyTag             int    `json:"keyTag"`
+	Algorithm          int    `json:"algorithm"`
+	DigestType         int    `json:"digestType"`
+	Digest             string `json:"digest"`
+	EffectiveDateUnix  int64  `json:"effectiveDate"`
+	ExpirationDateUnix int64  `json:"expirationDate"`
+	Record             string `json:"record"`
+}
+
+// jsonNumberInt64 returns the integer value of n, or 0 if it's empty.
+func jsonNumberInt64(n json.Number) (int64, error) {
+	if n == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(string(n), 10, 64)
+}
@@ -0,0 +1,60 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDNSSECKeyUnmarshalJSON(t *testing.T) {
+	expected := DNSSECKey{InceptionDateUnix: 1500000000, ExpirationDateUnix: 1531536000, EffectiveDateUnix: 1500000060, Name: "cdn1.example.net.", TTL: 60, Status: DNSSECKeyStatusNew, Public: "cHVi", Private: "cHJpdg=="}
+	for _, body := range []string{
+		`{"inceptionDate": 1500000000, "expirationDate": 1531536000, "effectiveDate": "1500000060", "name": "cdn1.example.net.", "ttl": "60", "status": "new", "public": "cHVi", "private": "cHJpdg=="}`,
+		`{"inceptionDate": 1500000000, "expirationDate": 1531536000, "effectiveDate": 1500000060, "name": "cdn1.example.net.", "ttl": 60, "status": "new", "public": "cHVi", "private": "cHJpdg=="}`,
+	} {
+		actual := DNSSECKey{}
+		if err := json.Unmarshal([]byte(body), &actual); err != nil {
+			t.Errorf("DNSSECKey.UnmarshalJSON(%s) expected: nil error, actual: %v", body, err)
+		} else if actual != expected {
+			t.Errorf("DNSSECKey.UnmarshalJSON(%s) expected: %+v, actual: %+v", body, expected, actual)
+		}
+	}
+
+	actual := DNSSECKey{}
+	if err := json.Unmarshal([]byte(`{"ttl": "sixty"}`), &actual); err == nil {
+		t.Errorf("DNSSECKey.UnmarshalJSON non-numeric ttl expected: error, actual: nil")
+	}
+}
+
+func TestDNSSECKeysGenerateRequestUnmarshalJSON(t *testing.T) {
+	expected := DNSSECKeysGenerateRequest{Key: "cdn1", Name: "cdn1.example.net", TTL: 60, KSKExpirationDays: 365, ZSKExpirationDays: 90, Algorithm: "RSASHA256"}
+	for _, body := range []string{
+		`{"key": "cdn1", "name": "cdn1.example.net", "ttl": "60", "kskExpirationDays": "365", "zskExpirationDays": "90", "algorithm": "RSASHA256"}`,
+		`{"key": "cdn1", "name": "cdn1.example.net", "ttl": 60, "kskExpirationDays": 365, "zskExpirationDays": 90, "algorithm": "RSASHA256"}`,
+	} {
+		actual := DNSSECKeysGenerateRequest{}
+		if err := json.Unmarshal([]byte(body), &actual); err != nil {
+			t.Errorf("DNSSECKeysGenerateRequest.UnmarshalJSON(%s) expected: nil error, actual: %v", body, err)
+		} else if actual != expected {
+			t.Errorf("DNSSECKeysGenerateRequest.UnmarshalJSON(%s) expected: %+v, actual: %+v", body, expected, actual)
+		}
+	}
+}
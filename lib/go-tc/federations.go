@@ -0,0 +1,152 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// the type names of federation resolvers
+const FederationResolverTypeIPv4 = "RESOLVE4"
+const FederationResolverTypeIPv6 = "RESOLVE6"
+
+// FederationMappingsResponse is the response of /internal/api/1.3/federations, which Traffic Router polls.
+type FederationMappingsResponse struct {
+	Response []FederationMapping `json:"response"`
+}
+
+// FederationMapping is the federations of a delivery service, which Traffic Router uses to send clients of the federation resolvers to the federation CNAMEs.
+type FederationMapping struct {
+	DeliveryService string                      `json:"deliveryService"`
+	Mappings        []FederationResolverMapping `json:"mappings"`
+}
+
+// FederationResolverMapping is a federation, and the IPv4 and IPv6 networks of its resolvers.
+type FederationResolverMapping struct {
+	CName    string   `json:"cname"`
+	TTL      int      `json:"ttl"`
+	Resolve4 []string `json:"resolve4,omitempty"`
+	Resolve6 []string `json:"resolve6,omitempty"`
+}
+
+// CDNFederationsResponse is the response to a request for the federations of a CDN.
+type CDNFederationsResponse struct {
+	Response []CDNFederation `json:"response"`
+}
+
+// CDNFederationResponse is the response to creating or updating a federation.
+type CDNFederationResponse struct {
+	Response CDNFederation `json:"response"`
+	Alerts
+}
+
+// CDNFederation is a federation, which lets another CDN serve a delivery service to the clients of its resolvers. A federation is in a CDN through its delivery services, which are only listed with the CDN's federations.
+type CDNFederation struct {
+	ID              int                       `json:"id"`
+	CName           string                    `json:"cname"`
+	TTL             int                       `json:"ttl"`
+	Description     *string                   `json:"description"`
+	DeliveryService *CDNFederationDSReference `json:"deliveryService,omitempty"`
+}
+
+// CDNFederationDSReference is a delivery service of a federation.
+type CDNFederationDSReference struct {
+	ID    int    `json:"id"`
+	XMLID string `json:"xmlId"`
+}
+
+// FederationResolversResponse is the response to a request for federation resolvers.
+type FederationResolversResponse struct {
+	Response []FederationResolver `json:"response"`
+}
+
+// FederationResolverResponse is the response to creating a federation resolver.
+type FederationResolverResponse struct {
+	Response FederationResolver `json:"response"`
+	Alerts
+}
+
+// FederationResolver is an IP address or CIDR network of DNS resolvers, which get a federation's CNAME from Traffic Router.
+type FederationResolver struct {
+	ID        int    `json:"id"`
+	IPAddress string `json:"ipAddress"`
+	TypeID    int    `json:"typeId"`
+	Type      string `json:"type,omitempty"`
+}
+
+// AssignFederationResolversRequest assigns resolvers to a federation, replacing its current resolvers if Replace is true.
+type AssignFederationResolversRequest struct {
+	FedResolverIDs []int `json:"fedResolverIds"`
+	Replace        bool  `json:"replace"`
+}
+
+// AssignFederationResolversResponse is the response to assigning resolvers to a federation.
+type AssignFederationResolversResponse struct {
+	Response AssignFederationResolversRequest `json:"response"`
+	Alerts
+}
+
+// FederationDeliveryServicesResponse is the response to a request for the delivery services of a federation.
+type FederationDeliveryServicesResponse struct {
+	Response []FederationDeliveryService `json:"response"`
+}
+
+// FederationDeliveryService is a delivery service of a federation.
+type FederationDeliveryService struct {
+	ID    int    `json:"id"`
+	CDN   string `json:"cdn"`
+	Type  string `json:"type"`
+	XMLID string `json:"xmlId"`
+}
+
+// AssignFederationDSesRequest assigns delivery services to a federation, replacing its current delivery services if Replace is true.
+type AssignFederationDSesRequest struct {
+	DSIDs   []int `json:"dsIds"`
+	Replace bool  `json:"replace"`
+}
+
+// AssignFederationDSesResponse is the response to assigning delivery services to a federation.
+type AssignFederationDSesResponse struct {
+	Response AssignFederationDSesRequest `json:"response"`
+	Alerts
+}
+
+// FederationUsersResponse is the response to a request for the users of a federation.
+type FederationUsersResponse struct {
+	Response []FederationUser `json:"response"`
+}
+
+// FederationUser is a user of a federation, who can set the federation's resolvers.
+type FederationUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	FullName string `json:"fullName"`
+	Email    string `json:"email"`
+	Company  string `json:"company"`
+	Role     string `json:"role"`
+}
+
+// AssignFederationUsersRequest assigns users to a federation, replacing its current users if Replace is true.
+type AssignFederationUsersRequest struct {
+	UserIDs []int `json:"userIds"`
+	Replace bool  `json:"replace"`
+}
+
+// AssignFederationUsersResponse is the response to assigning users to a federation.
+type AssignFederationUsersResponse struct {
+	Response AssignFederationUsersRequest `json:"response"`
+	Alerts
+}
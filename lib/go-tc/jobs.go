@@ -0,0 +1,89 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// JobsResponse is the response to a request for invalidation jobs.
+type JobsResponse struct {
+	Response []Job `json:"response"`
+}
+
+// JobResponse is the response to creating or deleting an invalidation job.
+type JobResponse struct {
+	Response Job `json:"response"`
+	Alerts
+}
+
+// Job is a content invalidation job, which makes caches revalidate content matching AssetURL with the origin until its TTL, in Parameters, is over.
+type Job struct {
+	ID              int    `json:"id"`
+	AssetURL        string `json:"assetUrl"`
+	DeliveryService string `json:"deliveryService"`
+	Keyword         string `json:"keyword"`
+	Parameters      string `json:"parameters"`
+	StartTime       string `json:"startTime"`
+	CreatedBy       string `json:"createdBy"`
+}
+
+// JobStartTimeLayout is the format of the start time of invalidation job requests, in UTC.
+const JobStartTimeLayout = "2006-01-02 15:04:05"
+
+// InvalidationJobRequest is a request to invalidate the content of a delivery service matching Regex, a path regular expression on its origin, for TTL hours from StartTime. Jobs start a minute after StartTime, unless they're Urgent.
+type InvalidationJobRequest struct {
+	DSID      int    `json:"dsId"`
+	Regex     string `json:"regex"`
+	TTL       int    `json:"ttl"`
+	StartTime string `json:"startTime"`
+	Urgent    bool   `json:"urgent"`
+}
+
+// UnmarshalJSON decodes an InvalidationJobRequest, accepting a dsId or ttl which is a string, as the Perl Traffic Ops did.
+func (r *InvalidationJobRequest) UnmarshalJSON(b []byte) error {
+	type jobRequestAlias InvalidationJobRequest
+	req := struct {
+		DSID json.Number `json:"dsId"`
+		TTL  json.Number `json:"ttl"`
+		*jobRequestAlias
+	}{jobRequestAlias: (*jobRequestAlias)(r)}
+	if err := json.Unmarshal(b, &req); err != nil {
+		return err
+	}
+	err := error(nil)
+	if r.DSID, err = jsonNumberInt(req.DSID); err != nil {
+		return errors.New("dsId must be an integer")
+	}
+	if r.TTL, err = jsonNumberInt(req.TTL); err != nil {
+		return errors.New("ttl must be an integer")
+	}
+	return nil
+}
+
+// jsonNumberInt returns the integer value of n, or 0 if it's empty.
+func jsonNumberInt(n json.Number) (int, error) {
+	if n == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(string(n))
+}
@@ -0,0 +1,48 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInvalidationJobRequestUnmarshalJSON(t *testing.T) {
+	expected := InvalidationJobRequest{DSID: 9999, Regex: "/path/to/content.jpg", TTL: 54, StartTime: "2015-01-27 11:08:37", Urgent: true}
+	for _, body := range []string{
+		`{"dsId": "9999", "regex": "/path/to/content.jpg", "startTime": "2015-01-27 11:08:37", "ttl": "54", "urgent": true}`,
+		`{"dsId": 9999, "regex": "/path/to/content.jpg", "startTime": "2015-01-27 11:08:37", "ttl": 54, "urgent": true}`,
+	} {
+		actual := InvalidationJobRequest{}
+		if err := json.Unmarshal([]byte(body), &actual); err != nil {
+			t.Errorf("InvalidationJobRequest.UnmarshalJSON(%s) expected: nil error, actual: %v", body, err)
+		} else if actual != expected {
+			t.Errorf("InvalidationJobRequest.UnmarshalJSON(%s) expected: %+v, actual: %+v", body, expected, actual)
+		}
+	}
+
+	actual := InvalidationJobRequest{}
+	if err := json.Unmarshal([]byte(`{"regex": "/foo"}`), &actual); err != nil || actual != (InvalidationJobRequest{Regex: "/foo"}) {
+		t.Errorf("InvalidationJobRequest.UnmarshalJSON without dsId expected: %+v, actual: %+v %v", InvalidationJobRequest{Regex: "/foo"}, actual, err)
+	}
+	if err := json.Unmarshal([]byte(`{"dsId": "ds1"}`), &actual); err == nil {
+		t.Errorf("InvalidationJobRequest.UnmarshalJSON non-numeric dsId expected: error, actual: nil")
+	}
+}
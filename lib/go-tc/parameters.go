@@ -19,6 +19,12 @@ package tc
  * under the License.
  */
 
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
 // ParametersResponse ...
 type ParametersResponse struct {
 	Response []Parameter `json:"response"`
@@ -26,10 +32,66 @@ type ParametersResponse struct {
 
 // Parameter ...
 type Parameter struct {
-	ConfigFile  string   `json:"configFile" db:"config_file"`
-	ID          int      `json:"id" db:"id"`
-	LastUpdated Time `json:"lastUpdated" db:"last_updated"`
-	Name        string   `json:"name" db:"name"`
-	Secure      bool     `json:"secure" db:"secure"`
-	Value       string   `json:"value" db:"value"`
+	ConfigFile  string `json:"configFile" db:"config_file"`
+	ID          int    `json:"id" db:"id"`
+	LastUpdated Time   `json:"lastUpdated" db:"last_updated"`
+	Name        string `json:"name" db:"name"`
+	Secure      bool   `json:"secure" db:"secure"`
+	Value       string `json:"value" db:"value"`
+}
+
+// ParameterResponse is the response to creating parameters, with the created parameters.
+type ParameterResponse struct {
+	Response []Parameter `json:"response"`
+	Alerts
+}
+
+// ParameterUpdateResponse is the response to updating a parameter.
+type ParameterUpdateResponse struct {
+	Response Parameter `json:"response"`
+	Alerts
+}
+
+/*
+ * Traffic Ops Perl returns "secure" as its database value, 0 or 1,
+ * when parameters are created or updated, and as true or false
+ * everywhere else. In order to read both, a custom Unmarshal() is
+ * used, like DeliveryServiceSSLKeys.
+ */
+func (p *Parameter) UnmarshalJSON(b []byte) error {
+	type Alias Parameter
+	o := &struct {
+		Secure interface{} `json:"secure"`
+		*Alias
+	}{
+		Alias: (*Alias)(p),
+	}
+	if err := json.Unmarshal(b, &o); err != nil {
+		return err
+	}
+	switch t := o.Secure.(type) {
+	case nil:
+		p.Secure = false
+	case bool:
+		p.Secure = t
+	case float64:
+		p.Secure = t != 0
+	case string:
+		p.Secure = t == "1" || strings.ToLower(t) == "true"
+	default:
+		return fmt.Errorf("secure field is an unhandled type: %T", t)
+	}
+	return nil
+}
+
+// ProfileParameter assigns a parameter to a profile.
+type ProfileParameter struct {
+	ProfileID   int `json:"profileId"`
+	ParameterID int `json:"parameterId"`
+}
+
+// ProfileParametersResponse is the response to assigning parameters to profiles.
+type ProfileParametersResponse struct {
+	Response []ProfileParameter `json:"response"`
+	Alerts
 }
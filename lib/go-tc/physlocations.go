@@ -39,3 +39,9 @@ type PhysLocation struct {
 	State        string `json:"state" db:"state"`
 	Zip          string `json:"zip" db:"zip"`
 }
+
+// PhysLocationResponse is the response to creating or updating a physical location.
+type PhysLocationResponse struct {
+	Response PhysLocation `json:"response"`
+	Alerts
+}
@@ -24,10 +24,33 @@ type ProfilesResponse struct {
 	Response []Profile `json:"response"`
 }
 
+// ProfileResponse is the response to creating or updating a profile.
+type ProfileResponse struct {
+	Response Profile `json:"response"`
+	Alerts
+}
+
 // Profile ...
 type Profile struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	LastUpdated string `json:"lastUpdated"`
+	ID              int         `json:"id"`
+	Name            string      `json:"name"`
+	Description     string      `json:"description"`
+	CDNID           int         `json:"cdn,omitempty"`
+	CDNName         string      `json:"cdnName,omitempty"`
+	Type            string      `json:"type,omitempty"`
+	RoutingDisabled bool        `json:"routingDisabled"`
+	LastUpdated     string      `json:"lastUpdated"`
+	Parameters      []Parameter `json:"params,omitempty"`
+}
+
+// ProfileCopyResponse is the response to copying a profile, with the new profile's name and ID, and the name of the profile it was copied from.
+type ProfileCopyResponse struct {
+	Response struct {
+		ID              int    `json:"id"`
+		Name            string `json:"name"`
+		Description     string `json:"description"`
+		ProfileCopyFrom string `json:"profileCopyFrom"`
+		IDCopyFrom      int    `json:"idCopyFrom"`
+	} `json:"response"`
+	Alerts
 }
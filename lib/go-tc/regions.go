@@ -30,3 +30,9 @@ type Region struct {
 	LastUpdated  Time   `json:"lastUpdated" db:"last_updated"`
 	Name         string `json:"name" db:"name"`
 }
+
+// RegionResponse is the response to creating or updating a region.
+type RegionResponse struct {
+	Response Region `json:"response"`
+	Alerts
+}
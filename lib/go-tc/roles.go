@@ -0,0 +1,57 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// RolesResponse is the response of GET /api/1.3/roles.
+type RolesResponse struct {
+	Response []Role `json:"response"`
+}
+
+// RoleResponse is the response of creating or updating a role.
+type RoleResponse struct {
+	Response Role `json:"response"`
+	Alerts
+}
+
+// Role is a role users can have, with its priv level and the names of its capabilities.
+type Role struct {
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Description  *string  `json:"description"`
+	PrivLevel    int      `json:"privLevel"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// UserRolesResponse is the response of getting the roles of a user.
+type UserRolesResponse struct {
+	Response UserRoles `json:"response"`
+}
+
+// SetUserRolesResponse is the response of setting the roles of a user.
+type SetUserRolesResponse struct {
+	Response UserRoles `json:"response"`
+	Alerts
+}
+
+// UserRoles is the roles a user has in addition to their primary role. A user's capabilities are those of all their roles.
+type UserRoles struct {
+	UserID  int   `json:"userId"`
+	RoleIDs []int `json:"roleIds"`
+}
@@ -55,7 +55,7 @@ type Server struct {
 	IPAddress        string              `json:"ipAddress" db:"ip_address"`
 	IPGateway        string              `json:"ipGateway" db:"ip_gateway"`
 	IPNetmask        string              `json:"ipNetmask" db:"ip_netmask"`
-	LastUpdated      Time            `json:"lastUpdated" db:"last_updated"`
+	LastUpdated      Time                `json:"lastUpdated" db:"last_updated"`
 	MgmtIPAddress    string              `json:"mgmtIpAddress" db:"mgmt_ip_address"`
 	MgmtIPGateway    string              `json:"mgmtIpGateway" db:"mgmt_ip_gateway"`
 	MgmtIPNetmask    string              `json:"mgmtIpNetmask" db:"mgmt_ip_netmask"`
@@ -88,3 +88,28 @@ type ServerUpdateStatus struct {
 	ParentPending      bool   `json:"parent_pending"`
 	ParentRevalPending bool   `json:"parent_reval_pending"`
 }
+
+// ServerResponse is the response to creating or updating a server.
+type ServerResponse struct {
+	Response Server `json:"response"`
+	Alerts
+}
+
+// ServerQueueUpdateRequest queues or dequeues updates on a server. Action is "queue" or "dequeue".
+type ServerQueueUpdateRequest struct {
+	Action string `json:"action"`
+}
+
+// ServerQueueUpdateResponse is the response to queueing updates on a server.
+type ServerQueueUpdateResponse struct {
+	Response struct {
+		ServerID string `json:"serverId"`
+		Action   string `json:"action"`
+	} `json:"response"`
+}
+
+// ServerStatusRequest changes the status of a server. Status is the name or ID of the status. An OfflineReason is required for the ADMIN_DOWN and OFFLINE statuses.
+type ServerStatusRequest struct {
+	Status        string `json:"status"`
+	OfflineReason string `json:"offlineReason,omitempty"`
+}
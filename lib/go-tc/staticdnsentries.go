@@ -0,0 +1,35 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// StaticDNSEntriesResponse is the response to a request for static DNS entries.
+type StaticDNSEntriesResponse struct {
+	Response []StaticDNSEntry `json:"response"`
+}
+
+// StaticDNSEntry is a DNS record Traffic Router serves in a delivery service's domain, besides the records of its caches.
+type StaticDNSEntry struct {
+	DeliveryService string `json:"deliveryservice" db:"deliveryservice"`
+	Host            string `json:"host" db:"host"`
+	TTL             int64  `json:"ttl" db:"ttl"`
+	Address         string `json:"address" db:"address"`
+	Type            string `json:"type" db:"type"`
+	CacheGroup      string `json:"cachegroup" db:"cachegroup"`
+}
@@ -29,3 +29,9 @@ type Status struct {
 	LastUpdated Time   `json:"lastUpdated" db:"last_updated"`
 	Name        string `json:"name" db:"name"`
 }
+
+// StatusResponse is the response to creating or updating a status.
+type StatusResponse struct {
+	Response Status `json:"response"`
+	Alerts
+}
@@ -0,0 +1,92 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// SteeringTargetTypeWeight is the steering target type whose value is the target's weight, for Traffic Router to choose targets in proportion to.
+const SteeringTargetTypeWeight = "STEERING_WEIGHT"
+
+// SteeringTargetTypeOrder is the steering target type whose value is the target's order, for Traffic Router to choose the lowest first.
+const SteeringTargetTypeOrder = "STEERING_ORDER"
+
+// SteeringTargetTypePrefix is the prefix of the names of every steering target type.
+const SteeringTargetTypePrefix = "STEERING_"
+
+// SteeringRegexType is the type of the regexes of steering targets, which Traffic Router matches against request paths to pick the target before weights and orders.
+const SteeringRegexType = "STEERING_REGEXP"
+
+// SteeringTargetsResponse is the response of GET /api/1.2/steering/{id}/targets.
+type SteeringTargetsResponse struct {
+	Response []SteeringTarget `json:"response"`
+}
+
+// SteeringTargetResponse is the response of creating or updating a steering target.
+type SteeringTargetResponse struct {
+	Response SteeringTarget `json:"response"`
+	Alerts
+}
+
+// SteeringTarget is a delivery service which a steering delivery service sends clients to, with a weight or order depending on its type.
+type SteeringTarget struct {
+	DeliveryServiceID int    `json:"deliveryServiceId"`
+	DeliveryService   string `json:"deliveryService"`
+	TargetID          int    `json:"targetId"`
+	Target            string `json:"target"`
+	Value             int    `json:"value"`
+	TypeID            int    `json:"typeId"`
+	Type              string `json:"type"`
+}
+
+// SteeringTargetRequest is a request to create or update a steering target. The target ID is in the path of updates, rather than the body.
+type SteeringTargetRequest struct {
+	TargetID int  `json:"targetId"`
+	Value    *int `json:"value"`
+	TypeID   *int `json:"typeId"`
+}
+
+// SteeringResponse is the response of GET /internal/api/1.2/steering, which Traffic Router polls.
+type SteeringResponse struct {
+	Response []Steering `json:"response"`
+}
+
+// SteeringDeliveryServiceResponse is the response of GET /internal/api/1.2/steering/{xmlID}.
+type SteeringDeliveryServiceResponse struct {
+	Response Steering `json:"response"`
+}
+
+// Steering is a steering delivery service in the Traffic Router steering feed, with its targets and the filters which match requests to them.
+type Steering struct {
+	DeliveryService string               `json:"deliveryService"`
+	ClientSteering  bool                 `json:"clientSteering"`
+	Targets         []SteeringFeedTarget `json:"targets"`
+	Filters         []SteeringFilter     `json:"filters"`
+}
+
+// SteeringFeedTarget is a target of a steering delivery service in the Traffic Router steering feed. Only one of Order or Weight is set, depending on the target's type.
+type SteeringFeedTarget struct {
+	DeliveryService string `json:"deliveryService"`
+	Order           int    `json:"order"`
+	Weight          int    `json:"weight"`
+}
+
+// SteeringFilter is a regex which sends requests matching it to the given steering target.
+type SteeringFilter struct {
+	DeliveryService string `json:"deliveryService"`
+	Pattern         string `json:"pattern"`
+}
@@ -0,0 +1,56 @@
+package tc
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// TopologiesResponse is the response of GET /api/1.3/topologies.
+type TopologiesResponse struct {
+	Response []Topology `json:"response"`
+}
+
+// TopologyResponse is the response of creating or updating a topology.
+type TopologyResponse struct {
+	Response Topology `json:"response"`
+	Alerts
+}
+
+// Topology is a named hierarchy of cachegroups which delivery services can use to reach their origin, in place of the parent and secondary parent of each cachegroup. It's a directed acyclic graph: every cachegroup which isn't an origin cachegroup has a parent and optional secondary parent in the topology, and following parents always ends at an origin cachegroup.
+type Topology struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Nodes       []TopologyNode `json:"nodes"`
+	LastUpdated Time           `json:"lastUpdated"`
+}
+
+// TopologyNode is a cachegroup in a topology. Parents are the indexes in the topology's nodes of the cachegroup's parent and, if there are two, secondary parent.
+type TopologyNode struct {
+	CacheGroup string `json:"cachegroup"`
+	Parents    []int  `json:"parents"`
+}
+
+// DeliveryServiceTopologyRequest is a request to set or, if Topology is null, remove the topology of a delivery service.
+type DeliveryServiceTopologyRequest struct {
+	Topology *string `json:"topology"`
+}
+
+// DeliveryServiceTopologyResponse is the response of setting the topology of a delivery service.
+type DeliveryServiceTopologyResponse struct {
+	Response DeliveryServiceTopologyRequest `json:"response"`
+	Alerts
+}
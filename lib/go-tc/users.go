@@ -38,6 +38,30 @@ type User struct {
 	FullName     string `json:"fullName,omitempty"`
 	NewUser      bool   `json:"newUser,omitempty"`
 	LastUpdated  string `json:"lastUpdated,omitempty"`
+
+	AddressLine1         string `json:"addressLine1,omitempty"`
+	AddressLine2         string `json:"addressLine2,omitempty"`
+	City                 string `json:"city,omitempty"`
+	StateOrProvince      string `json:"stateOrProvince,omitempty"`
+	PostalCode           string `json:"postalCode,omitempty"`
+	Country              string `json:"country,omitempty"`
+	PhoneNumber          string `json:"phoneNumber,omitempty"`
+	Tenant               string `json:"tenant,omitempty"`
+	TenantID             *int   `json:"tenantId,omitempty"`
+	RegistrationSent     string `json:"registrationSent,omitempty"`
+	LocalPassword        string `json:"localPasswd,omitempty"`
+	ConfirmLocalPassword string `json:"confirmLocalPasswd,omitempty"`
+}
+
+// UserResponse is the response to creating or updating a user.
+type UserResponse struct {
+	Response User `json:"response"`
+	Alerts
+}
+
+// CurrentUserRequest is the body of a request to update the current user, which the user is wrapped in.
+type CurrentUserRequest struct {
+	User User `json:"user"`
 }
 
 // Credentials contains Traffic Ops login credentials
@@ -27,11 +27,14 @@ import (
 	"io"
 	"io/ioutil"
 	"math/rand"
+	"net"
 	"net/http"
-	"sync"
+	"net/url"
 	"time"
 
-	"github.com/Comcast/traffic_control/test/router/data"
+	"github.com/apache/incubator-trafficcontrol/test/router/data"
+
+	"github.com/miekg/dns"
 )
 
 func MustLoadCertificates(cafile string) *x509.CertPool {
@@ -84,83 +87,161 @@ func RandomString(strlen int) string {
 	return string(result)
 }
 
-func DoRequest(isHttps bool, tlsConfig *tls.Config, host string, resultsChan chan<- data.HttpResult) {
-	transport := &http.Transport{TLSClientConfig: tlsConfig}
-
-	client := &http.Client{
+// NewHttpClient returns a client which doesn't follow redirects or reuse connections, so every request is a new connection to Traffic Router.
+func NewHttpClient(tlsConfig *tls.Config, timeout time.Duration) *http.Client {
+	return &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
-		Transport: transport,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, DisableKeepAlives: true},
+		Timeout:   timeout,
 	}
+}
 
+// DoHttpRequest requests a random path of host from Traffic Router, as if from clientIP if it isn't empty. The cache of a redirect is recorded, and if followRedirect is set, requested as well.
+func DoHttpRequest(httpClient *http.Client, isHttps bool, host string, clientIP string, followRedirect bool) data.Result {
 	scheme := "http://"
-	if (isHttps) {
+	protocol := data.ProtocolHttp
+	if isHttps {
 		scheme = "https://"
+		protocol = data.ProtocolHttps
 	}
 
-	url := fmt.Sprintf("%vccr.%v/%v/stuff?fakeClientIpAddress=68.87.25.123", scheme, host, RandomString(23))
-	fmt.Println("******", url)
+	result := data.Result{
+		RequestTime: time.Now(),
+		Protocol:    protocol,
+		Host:        host,
+		ClientIP:    clientIP,
+		Status:      -1,
+	}
+
+	url := fmt.Sprintf("%v%v/%v/stuff", scheme, host, RandomString(23))
+	if clientIP == "" {
+		url += "?fakeClientIpAddress=68.87.25.123"
+	}
 
 	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("Connection", "close")
+	if clientIP != "" {
+		req.Header.Set("X-Forwarded-For", clientIP)
+	}
 
+	resp, err := httpClient.Do(req)
+	result.LatencyUsec = time.Now().Sub(result.RequestTime).Nanoseconds() / int64(1000)
 	if err != nil {
-		fmt.Println("^^^^^^",err)
+		result.Error = err.Error()
+		return result
 	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	result.Status = resp.StatusCode
 
-	req.Header.Set("Connection", "close")
+	if resp.StatusCode != http.StatusFound {
+		result.Error = fmt.Sprintf("received unexpected http status %v", resp.StatusCode)
+		return result
+	}
 
-	before := time.Now()
-	resp, err := client.Do(req)
-	latency := time.Now().Sub(before).Nanoseconds() / int64(1000)
+	location, err := resp.Location()
+	if err != nil {
+		result.Error = "redirect has no location: " + err.Error()
+		return result
+	}
+	result.Cache = location.Hostname()
 
-	httpResult := data.HttpResult{
-		RequestTime: before,
-		Host:        req.Host,
-		LatencyUsec: latency,
-		Status:      -1,
+	if followRedirect {
+		followRequest(httpClient, location, &result)
 	}
+	return result
+}
 
+// followRequest requests the location Traffic Router redirected to, and records the cache's response in result.
+func followRequest(httpClient *http.Client, location *url.URL, result *data.Result) {
+	req, err := http.NewRequest("GET", location.String(), nil)
 	if err != nil {
-		fmt.Println("*** Error ****", err)
-		httpResult.Err = err
-		fmt.Println(httpResult);
+		result.Error = err.Error()
 		return
 	}
-
-	if resp != nil {
-		io.Copy(ioutil.Discard, resp.Body)
-		resp.Body.Close()
-		if resp.StatusCode != 302 {
-			fmt.Println("Received unexpected http status", resp.StatusCode)
-		}
-		httpResult.Status = resp.StatusCode
+	req.Header.Set("Connection", "close")
+	if result.ClientIP != "" {
+		req.Header.Set("X-Forwarded-For", result.ClientIP)
 	}
 
-	resultsChan <- httpResult
+	before := time.Now()
+	resp, err := httpClient.Do(req)
+	result.EdgeLatencyUsec = time.Now().Sub(before).Nanoseconds() / int64(1000)
+	if err != nil {
+		result.Error = "following redirect: " + err.Error()
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	result.EdgeStatus = resp.StatusCode
 }
 
-func ExerciseDeliveryService(isHttps bool, tlsConfig *tls.Config, host string, numRequests int, maxWorkers int, resultsChan chan<- data.HttpResult) {
-	var waitGroup sync.WaitGroup
+// DoDnsRequest queries nameserver for the records of type qtype at name. If clientIP isn't empty, the query has an EDNS0 client subnet option with its network.
+func DoDnsRequest(dnsClient *dns.Client, nameserver string, name string, qtype uint16, clientIP string) data.Result {
+	result := data.Result{
+		RequestTime: time.Now(),
+		Protocol:    data.ProtocolDns,
+		Host:        name,
+		ClientIP:    clientIP,
+	}
 
-	workers := make(chan int, maxWorkers)
-	for worker := 0; worker < maxWorkers; worker++ {
-		waitGroup.Add(1)
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	if ip := net.ParseIP(clientIP); ip != nil {
+		m.Extra = append(m.Extra, clientSubnetOPT(ip))
+	}
 
-		go func(ch <-chan int) {
-			defer waitGroup.Done()
+	r, _, err := dnsClient.Exchange(m, nameserver)
+	result.LatencyUsec = time.Now().Sub(result.RequestTime).Nanoseconds() / int64(1000)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Rcode = dns.RcodeToString[r.Rcode]
+	if r.Rcode != dns.RcodeSuccess {
+		result.Error = fmt.Sprintf("received unexpected rcode %v", result.Rcode)
+		return result
+	}
 
-			for _ = range ch {
-				DoRequest(isHttps, tlsConfig, host, resultsChan)
-			}
-		}(workers)
+	for _, rr := range r.Answer {
+		switch a := rr.(type) {
+		case *dns.A:
+			result.Cache = a.A.String()
+		case *dns.AAAA:
+			result.Cache = a.AAAA.String()
+		}
+		if result.Cache != "" {
+			break
+		}
 	}
+	if result.Cache == "" {
+		result.Error = fmt.Sprintf("received no %v records", dns.TypeToString[qtype])
+	}
+	return result
+}
 
-	for i := 0; i < numRequests; i++ {
-		time.Sleep(100 * time.Microsecond)
-		workers <- i
+// clientSubnetOPT returns an OPT record with the /24 or /56 network of ip as its client subnet.
+func clientSubnetOPT(ip net.IP) *dns.OPT {
+	subnet := &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET}
+	if ip4 := ip.To4(); ip4 != nil {
+		subnet.Family = 1
+		subnet.SourceNetmask = 24
+		subnet.Address = ip4.Mask(net.CIDRMask(24, 32))
+	} else {
+		subnet.Family = 2
+		subnet.SourceNetmask = 56
+		subnet.Address = ip.Mask(net.CIDRMask(56, 128))
 	}
-	close(workers)
 
-	waitGroup.Wait()
+	opt := &dns.OPT{Hdr: dns.RR_Header{Name: ".", Rrtype: dns.TypeOPT}}
+	opt.SetUDPSize(dns.DefaultMsgSize)
+	opt.Option = append(opt.Option, subnet)
+	return opt
 }
@@ -0,0 +1,84 @@
+package data
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import "time"
+
+const (
+	ProtocolHttp  = "http"
+	ProtocolHttps = "https"
+	ProtocolDns   = "dns"
+)
+
+// Result is a single request made to Traffic Router. HTTP requests record the cache Traffic Router redirected to, DNS requests the first address it answered with.
+type Result struct {
+	RequestTime     time.Time `json:"requestTime"`
+	DeliveryService string    `json:"deliveryService"`
+	Protocol        string    `json:"protocol"`
+	Host            string    `json:"host"`
+	ClientIP        string    `json:"clientIp,omitempty"`
+	LatencyUsec     int64     `json:"latency"`
+	Error           string    `json:"error,omitempty"`
+	Status          int       `json:"httpStatus,omitempty"`
+	Rcode           string    `json:"rcode,omitempty"`
+	Cache           string    `json:"cache,omitempty"`
+	CacheGroup      string    `json:"cacheGroup,omitempty"`
+	// EdgeStatus and EdgeLatencyUsec are of the request to the cache, when redirects are followed.
+	EdgeStatus      int   `json:"edgeStatus,omitempty"`
+	EdgeLatencyUsec int64 `json:"edgeLatency,omitempty"`
+}
+
+// HistogramBucket counts the requests whose latency is at most MaxUsec, and more than the previous bucket's. The last bucket has no maximum, and a MaxUsec of -1.
+type HistogramBucket struct {
+	MaxUsec int64 `json:"maxUsec"`
+	Count   int   `json:"count"`
+}
+
+type LatencySummary struct {
+	MinUsec   int64             `json:"min"`
+	MaxUsec   int64             `json:"max"`
+	MeanUsec  int64             `json:"mean"`
+	P50Usec   int64             `json:"p50"`
+	P90Usec   int64             `json:"p90"`
+	P99Usec   int64             `json:"p99"`
+	Histogram []HistogramBucket `json:"histogram"`
+}
+
+type DeliveryServiceReport struct {
+	Requests    int            `json:"requests"`
+	Errors      int            `json:"errors"`
+	Latency     LatencySummary `json:"latency"`
+	CacheGroups map[string]int `json:"cacheGroups"`
+}
+
+// Report summarizes the results of a load test so far.
+type Report struct {
+	Start            time.Time                        `json:"start"`
+	Elapsed          float64                          `json:"elapsedSeconds"`
+	Running          bool                             `json:"running"`
+	Requests         int                              `json:"requests"`
+	Errors           int                              `json:"errors"`
+	QPS              float64                          `json:"qps"`
+	Latency          LatencySummary                   `json:"latency"`
+	Statuses         map[string]int                   `json:"statuses"`
+	CacheGroups      map[string]int                   `json:"cacheGroups"`
+	Caches           map[string]int                   `json:"caches"`
+	DeliveryServices map[string]DeliveryServiceReport `json:"deliveryServices"`
+}
@@ -0,0 +1,100 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-nagios"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/test/router/dnssec/validate"
+	to "github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+const UserAgent = "dnssec-validator/0.1"
+
+const RequestTimeout = time.Second * time.Duration(30)
+
+func main() {
+	ns := flag.String("ns", "", "The Traffic Router nameserver to validate, as host:port")
+	parentNS := flag.String("parent-ns", "", "The nameserver of the CDN domain's parent zone, which serves its DS records, as host:port. Defaults to -ns")
+	crconfigPath := flag.String("crconfig", "", "A CRConfig file to validate, instead of fetching it from Traffic Ops")
+	toURI := flag.String("to", "", "The Traffic Ops URI, whose CRConfig to validate")
+	toUser := flag.String("touser", "", "The Traffic Ops user")
+	toPass := flag.String("topass", "", "The Traffic Ops password")
+	cdn := flag.String("cdn", "", "The CDN whose CRConfig to validate")
+	format := flag.String("format", "nagios", "The output format, nagios or json")
+	warn := flag.Duration("warn", validate.DefaultExpirationWarning, "How long before a signature expires to warn")
+	timeout := flag.Duration("timeout", 2*time.Second, "The timeout of each DNS query")
+	help := flag.Bool("help", false, "Usage info")
+	helpBrief := flag.Bool("h", false, "Usage info")
+	flag.Parse()
+	if *help || *helpBrief || *ns == "" || (*crconfigPath == "" && (*toURI == "" || *cdn == "")) || (*format != "nagios" && *format != "json") {
+		fmt.Printf("Usage: ./dnssec-validate -ns tr.cdn.example.net:53 -to https://traffic-ops.example.net -touser bill -topass thelizard -cdn cdn1 [-parent-ns ns.example.net:53] [-format nagios|json] [-warn 72h]\n")
+		fmt.Printf("       ./dnssec-validate -ns tr.cdn.example.net:53 -crconfig CRConfig.json [-parent-ns ns.example.net:53] [-format nagios|json] [-warn 72h]\n")
+		return
+	}
+
+	crcBytes := []byte{}
+	if *crconfigPath != "" {
+		bts, err := ioutil.ReadFile(*crconfigPath)
+		if err != nil {
+			nagios.Exit(nagios.Critical, fmt.Sprintf("Error reading CRConfig: %v", err))
+		}
+		crcBytes = bts
+	} else {
+		toClient, _, err := to.LoginWithAgent(*toURI, *toUser, *toPass, true, UserAgent, false, RequestTimeout)
+		if err != nil {
+			nagios.Exit(nagios.Critical, fmt.Sprintf("Error logging in to Traffic Ops: %v", err))
+		}
+		bts, _, err := toClient.GetCRConfig(*cdn)
+		if err != nil {
+			nagios.Exit(nagios.Critical, fmt.Sprintf("Error getting CRConfig from Traffic Ops: %v", err))
+		}
+		crcBytes = bts
+	}
+
+	crc := tc.CRConfig{}
+	if err := json.Unmarshal(crcBytes, &crc); err != nil {
+		nagios.Exit(nagios.Critical, fmt.Sprintf("Error decoding CRConfig: %v", err))
+	}
+	if *cdn == "" && crc.Stats.CDNName != nil {
+		*cdn = *crc.Stats.CDNName
+	}
+
+	opts := validate.Options{Nameserver: *ns, ParentNameserver: *parentNS, ExpirationWarning: *warn, Timeout: *timeout}
+	report, err := validate.New(opts, time.Now()).ValidateCRConfig(*cdn, crc)
+	if err != nil {
+		nagios.Exit(nagios.Critical, fmt.Sprintf("Error validating CRConfig: %v", err))
+	}
+
+	if *format == "json" {
+		bts, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			nagios.Exit(nagios.Critical, fmt.Sprintf("Error encoding report: %v", err))
+		}
+		nagios.Exit(report.Status, string(bts))
+	}
+	nagios.Exit(report.Nagios())
+}
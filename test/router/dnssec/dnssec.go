@@ -21,9 +21,10 @@ package dnssec
 
 
 import (
-. "github.com/miekg/dns"
-. "github.com/onsi/gomega"
-"log"
+	"fmt"
+	"log"
+
+	. "github.com/miekg/dns"
 )
 
 type DnssecClient struct {
@@ -53,17 +54,19 @@ func MakeLabelHierarchy(label string) []string {
 	return append([]string{"."}, labels...)
 }
 
-func (d *DnssecClient) GetRecords(nameserver string, name string, t uint16) (*Msg) {
+// GetRecords queries nameserver for the records of type t at name, requesting
+// DNSSEC records as well. An empty answer is not an error.
+func (d *DnssecClient) GetRecords(nameserver string, name string, t uint16) (*Msg, error) {
 	m := new(Msg)
 	m.Id = Id()
 	m.RecursionDesired = true
 	m.SetEdns0(4096, true)
 	m.Question = []Question{{name, t, ClassINET}}
 	r, _, err := d.Exchange(m, nameserver)
-
-	Expect(err).Should(BeNil())
-	Expect(len(r.Answer)).ToNot(Equal(0), "Received no answers from %v for query of records type %d for zone %v", nameserver, t, name)
-	return r
+	if err != nil {
+		return nil, fmt.Errorf("querying %v for records type %d for zone %v: %v", nameserver, t, name, err)
+	}
+	return r, nil
 }
 
 func sigCovers(s RRSIG, rr RR) bool {
@@ -72,11 +75,19 @@ func sigCovers(s RRSIG, rr RR) bool {
 		s.Hdr.Ttl == rr.Header().Ttl
 }
 
-func (d *DnssecClient) GetSignedRRSets(nameserver string, name string, t uint16) ([]SignedRRSet) {
+func (d *DnssecClient) GetSignedRRSets(nameserver string, name string, t uint16) ([]SignedRRSet, error) {
 	records := []RR{}
 	rrsigs := []RR{}
 
-	answers := d.GetRecords(nameserver, name, t).Answer
+	r, err := d.GetRecords(nameserver, name, t)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Answer) == 0 {
+		return nil, fmt.Errorf("received no answers from %v for query of records type %d for zone %v", nameserver, t, name)
+	}
+
+	answers := r.Answer
 	for _, ans := range answers {
 		if ans.Header().Rrtype == TypeRRSIG {
 			rrsigs = append(rrsigs, ans)
@@ -121,20 +132,23 @@ func (d *DnssecClient) GetSignedRRSets(nameserver string, name string, t uint16)
 
 	}
 
-	return rrsets
+	return rrsets, nil
 }
 
-func (d *DnssecClient) DelegationSignerData(nameserver string, name string) ([]SignedRRSet) {
+func (d *DnssecClient) DelegationSignerData(nameserver string, name string) ([]SignedRRSet, error) {
 	return d.GetSignedRRSets(nameserver, name, TypeDS)
 }
 
-func (d *DnssecClient) SigningData(nameserver string, name string) SignedKeys {
+func (d *DnssecClient) SigningData(nameserver string, name string) (SignedKeys, error) {
 	var signedKeys = SignedKeys{
 		SignedZsks: []SignedRRSet{},
 		SignedKsks: []SignedRRSet{},
 	}
 
-	signedRrsets := d.GetSignedRRSets(nameserver, name, TypeDNSKEY)
+	signedRrsets, err := d.GetSignedRRSets(nameserver, name, TypeDNSKEY)
+	if err != nil {
+		return signedKeys, err
+	}
 
 	for _, signedRRset := range signedRrsets {
 		if len(signedRRset.RRSet) < 1 {
@@ -154,6 +168,6 @@ func (d *DnssecClient) SigningData(nameserver string, name string) SignedKeys {
 		}
 	}
 
-	return signedKeys
+	return signedKeys, nil
 }
 
@@ -34,16 +34,18 @@ var _ = Describe("Dnssec", func() {
 		})
 
 		It("Uses Parent Zone Key to validate DS", func() {
-			signedDSSets := d.DelegationSignerData(nameserver, deliveryService)
+			signedDSSets, err := d.DelegationSignerData(nameserver, deliveryService)
 
+			Expect(err).To(BeNil())
 			Expect(len(signedDSSets)).ToNot(Equal(0))
 			Expect(len(signedDSSets[0].RRSet)).ToNot(Equal(0))
 
 			verifiedCount := 0
 			for _, signedDSSet := range signedDSSets {
 
-				signedKeys := d.SigningData(nameserver, signedDSSet.RRSIG.SignerName)
+				signedKeys, err := d.SigningData(nameserver, signedDSSet.RRSIG.SignerName)
 
+				Expect(err).To(BeNil())
 				Expect(len(signedKeys.SignedKsks)).ToNot(Equal(0))
 				Expect(len(signedKeys.SignedZsks)).ToNot(Equal(0))
 
@@ -76,8 +78,10 @@ var _ = Describe("Dnssec", func() {
 		})
 
 		It("Uses DS to validate Public Key", func() {
-			signedKeys := d.SigningData(nameserver, deliveryService)
-			signedDSSets := d.DelegationSignerData(nameserver, deliveryService)
+			signedKeys, err := d.SigningData(nameserver, deliveryService)
+			Expect(err).To(BeNil())
+			signedDSSets, err := d.DelegationSignerData(nameserver, deliveryService)
+			Expect(err).To(BeNil())
 
 			Expect(len(signedDSSets)).ToNot(Equal(0))
 
@@ -106,8 +110,8 @@ var _ = Describe("Dnssec", func() {
 		})
 
 		It("Uses KSK public key to verify ZSK RRSig", func() {
-			signedKeys := d.SigningData(nameserver, deliveryService)
-
+			signedKeys, err := d.SigningData(nameserver, deliveryService)
+			Expect(err).To(BeNil())
 
 			count := 0
 			for _, signedZsk := range signedKeys.SignedZsks {
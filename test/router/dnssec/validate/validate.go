@@ -0,0 +1,411 @@
+// Package validate walks the DNSSEC chain of trust of a CDN's delivery services, from the DS of the CDN domain in its parent zone down to the signatures of each delivery service zone's records.
+package validate
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-nagios"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/test/router/dnssec"
+
+	"github.com/miekg/dns"
+)
+
+// DefaultExpirationWarning is how long before a signature expires it's reported as a warning, if Options doesn't say otherwise.
+const DefaultExpirationWarning = 72 * time.Hour
+
+// Problem types.
+const (
+	ProblemQuery             = "query"
+	ProblemMissingDS         = "missing-ds"
+	ProblemMissingDNSKEY     = "missing-dnskey"
+	ProblemMissingRecord     = "missing-record"
+	ProblemMissingRRSIG      = "missing-rrsig"
+	ProblemAlgorithmMismatch = "algorithm-mismatch"
+	ProblemDigestMismatch    = "digest-mismatch"
+	ProblemUntrustedKey      = "untrusted-key"
+	ProblemInvalidSignature  = "invalid-signature"
+	ProblemExpiredSignature  = "expired-signature"
+	ProblemExpiringSignature = "expiring-signature"
+	ProblemUntrustedParent   = "untrusted-parent"
+)
+
+type Options struct {
+	// Nameserver is the host:port of the Traffic Router queried for the records of the CDN domain and its delivery service zones.
+	Nameserver string
+	// ParentNameserver is the host:port queried for the DS records of the CDN domain, which live in its parent zone. Defaults to Nameserver.
+	ParentNameserver string
+	// ExpirationWarning is how long before a signature expires it's reported as a warning. Defaults to DefaultExpirationWarning.
+	ExpirationWarning time.Duration
+	// Timeout is the timeout of each DNS query. Zero uses the miekg/dns default.
+	Timeout time.Duration
+}
+
+// Problem is a single failure to validate a record set of a zone.
+type Problem struct {
+	Name    string        `json:"name"`
+	Type    string        `json:"type"`
+	Status  nagios.Status `json:"status"`
+	Message string        `json:"message"`
+}
+
+// ZoneReport is the validation of a single zone. The CDN domain's report has no delivery service.
+type ZoneReport struct {
+	DeliveryService string        `json:"deliveryService,omitempty"`
+	Zone            string        `json:"zone"`
+	Status          nagios.Status `json:"status"`
+	Problems        []Problem     `json:"problems"`
+}
+
+// Report is the validation of every delivery service zone of a CDN.
+type Report struct {
+	CDN        string        `json:"cdn"`
+	Domain     string        `json:"domain"`
+	Nameserver string        `json:"nameserver"`
+	Time       time.Time     `json:"time"`
+	Status     nagios.Status `json:"status"`
+	Zones      []ZoneReport  `json:"zones"`
+}
+
+// Validator validates DNSSEC chains against a nameserver. The keys of each validated zone are cached, so a Validator should only be used for a single Report.
+type Validator struct {
+	client *dnssec.DnssecClient
+	opts   Options
+	now    time.Time
+	zones  map[string]*zoneKeys
+}
+
+// zoneKeys is the result of validating the DS and DNSKEY records of a zone. Keys is nil if the zone's keys couldn't be trusted.
+type zoneKeys struct {
+	Keys     []*dns.DNSKEY
+	Problems []Problem
+}
+
+// New returns a Validator which validates signatures as of now.
+func New(opts Options, now time.Time) *Validator {
+	if opts.ParentNameserver == "" {
+		opts.ParentNameserver = opts.Nameserver
+	}
+	if opts.ExpirationWarning == 0 {
+		opts.ExpirationWarning = DefaultExpirationWarning
+	}
+	client := &dnssec.DnssecClient{Client: new(dns.Client)}
+	client.Net = "udp"
+	client.Timeout = opts.Timeout
+	return &Validator{client: client, opts: opts, now: now, zones: map[string]*zoneKeys{}}
+}
+
+// ValidateCRConfig validates the zone of the CDN domain of crc, and the zone of every delivery service in it.
+func (v *Validator) ValidateCRConfig(cdn string, crc tc.CRConfig) (Report, error) {
+	if crc.Config.DomainName == nil || *crc.Config.DomainName == "" {
+		return Report{}, fmt.Errorf("CRConfig has no domain_name")
+	}
+	domain := dns.Fqdn(strings.ToLower(*crc.Config.DomainName))
+
+	report := Report{CDN: cdn, Domain: domain, Nameserver: v.opts.Nameserver, Time: v.now, Zones: []ZoneReport{}}
+	report.Zones = append(report.Zones, v.ValidateZone(domain, domain, ""))
+
+	dsNames := []string{}
+	for name := range crc.DeliveryServices {
+		dsNames = append(dsNames, name)
+	}
+	sort.Strings(dsNames)
+
+	for _, name := range dsNames {
+		ds := crc.DeliveryServices[name]
+		if len(ds.Domains) == 0 {
+			continue
+		}
+		zone := dns.Fqdn(strings.ToLower(ds.Domains[0]))
+		if !dns.IsSubDomain(domain, zone) || zone == domain {
+			continue
+		}
+		zr := v.ValidateZone(domain, zone, routingName(ds)+"."+zone)
+		zr.DeliveryService = name
+		report.Zones = append(report.Zones, zr)
+	}
+
+	for _, zr := range report.Zones {
+		if zr.Status > report.Status {
+			report.Status = zr.Status
+		}
+	}
+	return report, nil
+}
+
+// routingName returns the routing name of the delivery service, defaulting as Traffic Router does when the CRConfig doesn't have one.
+func routingName(ds tc.CRConfigDeliveryService) string {
+	if ds.RoutingName != nil && *ds.RoutingName != "" {
+		return *ds.RoutingName
+	}
+	for _, ms := range ds.MatchSets {
+		if ms != nil && ms.Protocol == "DNS" {
+			return "edge"
+		}
+	}
+	return "tr"
+}
+
+// ValidateZone validates the chain of trust from the DS of domain in its parent zone down to zone, which must be domain or a subdomain of it, and the signatures of the SOA and NS records of zone and the A and AAAA records of host. If host is empty, only the SOA and NS records are validated.
+func (v *Validator) ValidateZone(domain string, zone string, host string) ZoneReport {
+	domain = dns.Fqdn(domain)
+	zone = dns.Fqdn(zone)
+	zr := ZoneReport{Zone: zone, Problems: []Problem{}}
+
+	var keys []*dns.DNSKEY
+	for _, level := range dnssec.MakeLabelHierarchy(zone) {
+		if !dns.IsSubDomain(domain, level) {
+			continue
+		}
+		zk, ok := v.zones[level]
+		if !ok {
+			zk = v.validateKeys(level, level != domain, keys)
+			v.zones[level] = zk
+		}
+		// problems with the keys of zones above this one are reported in the report of the zone they belong to
+		if level == zone {
+			zr.Problems = append(zr.Problems, zk.Problems...)
+		}
+		keys = zk.Keys
+	}
+
+	if keys != nil {
+		zr.Problems = append(zr.Problems, v.validateRecords(zone, dns.TypeSOA, keys, true)...)
+		zr.Problems = append(zr.Problems, v.validateRecords(zone, dns.TypeNS, keys, true)...)
+		if host != "" {
+			host = dns.Fqdn(host)
+			a := v.validateRecords(host, dns.TypeA, keys, false)
+			aaaa := v.validateRecords(host, dns.TypeAAAA, keys, false)
+			zr.Problems = append(zr.Problems, a...)
+			zr.Problems = append(zr.Problems, aaaa...)
+		}
+	}
+
+	for _, p := range zr.Problems {
+		if p.Status > zr.Status {
+			zr.Status = p.Status
+		}
+	}
+	return zr
+}
+
+// validateKeys validates the DS records of zone against its DNSKEY records, and the DNSKEY records against their signatures. If signedParent, the DS records are in a parent zone within the CDN, and their signatures are validated with parentKeys.
+func (v *Validator) validateKeys(zone string, signedParent bool, parentKeys []*dns.DNSKEY) *zoneKeys {
+	zk := &zoneKeys{Problems: []Problem{}}
+	problem := func(rrtype uint16, ptype string, format string, args ...interface{}) {
+		zk.Problems = append(zk.Problems, Problem{Name: zone + " " + dns.TypeToString[rrtype], Type: ptype, Status: nagios.Critical, Message: fmt.Sprintf(format, args...)})
+	}
+
+	parentNS := v.opts.Nameserver
+	if !signedParent {
+		parentNS = v.opts.ParentNameserver
+	}
+	dsRRs, dsSigs, err := v.getRRSet(parentNS, zone, dns.TypeDS)
+	if err != nil {
+		problem(dns.TypeDS, ProblemQuery, "%v", err)
+		return zk
+	}
+	if len(dsRRs) == 0 {
+		problem(dns.TypeDS, ProblemMissingDS, "no DS records from %v", parentNS)
+		return zk
+	}
+	if signedParent {
+		if parentKeys == nil {
+			problem(dns.TypeDS, ProblemUntrustedParent, "parent zone keys are not trusted, DS signatures can't be validated")
+		} else {
+			zk.Problems = append(zk.Problems, v.validateSignatures(zone, dns.TypeDS, dsRRs, dsSigs, parentKeys)...)
+		}
+	}
+
+	keyRRs, keySigs, err := v.getRRSet(v.opts.Nameserver, zone, dns.TypeDNSKEY)
+	if err != nil {
+		problem(dns.TypeDNSKEY, ProblemQuery, "%v", err)
+		return zk
+	}
+	if len(keyRRs) == 0 {
+		problem(dns.TypeDNSKEY, ProblemMissingDNSKEY, "no DNSKEY records from %v", v.opts.Nameserver)
+		return zk
+	}
+	keys := []*dns.DNSKEY{}
+	for _, rr := range keyRRs {
+		keys = append(keys, rr.(*dns.DNSKEY))
+	}
+
+	ksks := []*dns.DNSKEY{}
+	for _, rr := range dsRRs {
+		ds := rr.(*dns.DS)
+		matched := false
+		for _, key := range keys {
+			if key.KeyTag() != ds.KeyTag {
+				continue
+			}
+			matched = true
+			if key.Algorithm != ds.Algorithm {
+				problem(dns.TypeDS, ProblemAlgorithmMismatch, "DS for key %d has algorithm %v, but the DNSKEY has algorithm %v", ds.KeyTag, dns.AlgorithmToString[ds.Algorithm], dns.AlgorithmToString[key.Algorithm])
+				continue
+			}
+			computed := key.ToDS(ds.DigestType)
+			if computed == nil || !strings.EqualFold(computed.Digest, ds.Digest) {
+				problem(dns.TypeDS, ProblemDigestMismatch, "DS digest for key %d doesn't match the DNSKEY", ds.KeyTag)
+				continue
+			}
+			ksks = append(ksks, key)
+		}
+		if !matched {
+			problem(dns.TypeDS, ProblemUntrustedKey, "no DNSKEY matches the DS for key %d", ds.KeyTag)
+		}
+	}
+	if len(ksks) == 0 {
+		problem(dns.TypeDNSKEY, ProblemUntrustedKey, "no DNSKEY is trusted by a DS record")
+		return zk
+	}
+
+	keyProblems := v.validateSignatures(zone, dns.TypeDNSKEY, keyRRs, keySigs, ksks)
+	zk.Problems = append(zk.Problems, keyProblems...)
+	for _, p := range keyProblems {
+		if p.Status == nagios.Critical {
+			return zk
+		}
+	}
+	zk.Keys = keys
+	return zk
+}
+
+// validateRecords validates the signatures of the records of type rrtype at name with the keys of their zone. Missing records are only a problem if required.
+func (v *Validator) validateRecords(name string, rrtype uint16, keys []*dns.DNSKEY, required bool) []Problem {
+	rrs, sigs, err := v.getRRSet(v.opts.Nameserver, name, rrtype)
+	if err != nil {
+		return []Problem{{Name: name + " " + dns.TypeToString[rrtype], Type: ProblemQuery, Status: nagios.Critical, Message: err.Error()}}
+	}
+	if len(rrs) == 0 {
+		if !required {
+			return nil
+		}
+		return []Problem{{Name: name + " " + dns.TypeToString[rrtype], Type: ProblemMissingRecord, Status: nagios.Critical, Message: "no " + dns.TypeToString[rrtype] + " records from " + v.opts.Nameserver}}
+	}
+	return v.validateSignatures(name, rrtype, rrs, sigs, keys)
+}
+
+// validateSignatures validates that at least one of sigs is a valid signature of rrs by one of keys, and reports signatures that are invalid, expired or about to expire.
+func (v *Validator) validateSignatures(name string, rrtype uint16, rrs []dns.RR, sigs []*dns.RRSIG, keys []*dns.DNSKEY) []Problem {
+	problems := []Problem{}
+	problem := func(status nagios.Status, ptype string, format string, args ...interface{}) {
+		problems = append(problems, Problem{Name: name + " " + dns.TypeToString[rrtype], Type: ptype, Status: status, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(sigs) == 0 {
+		problem(nagios.Critical, ProblemMissingRRSIG, "no RRSIG records")
+		return problems
+	}
+
+	verified := 0
+	for _, sig := range sigs {
+		var key *dns.DNSKEY
+		for _, k := range keys {
+			if k.KeyTag() == sig.KeyTag && strings.EqualFold(k.Hdr.Name, sig.SignerName) {
+				key = k
+				break
+			}
+		}
+		if key == nil {
+			problem(nagios.Critical, ProblemUntrustedKey, "RRSIG by key %d of %v, which isn't a trusted key", sig.KeyTag, sig.SignerName)
+			continue
+		}
+		if key.Algorithm != sig.Algorithm {
+			problem(nagios.Critical, ProblemAlgorithmMismatch, "RRSIG by key %d has algorithm %v, but the DNSKEY has algorithm %v", sig.KeyTag, dns.AlgorithmToString[sig.Algorithm], dns.AlgorithmToString[key.Algorithm])
+			continue
+		}
+		if err := sig.Verify(key, rrs); err != nil {
+			problem(nagios.Critical, ProblemInvalidSignature, "RRSIG by key %d doesn't verify: %v", sig.KeyTag, err)
+			continue
+		}
+		if !sig.ValidityPeriod(v.now) {
+			problem(nagios.Critical, ProblemExpiredSignature, "RRSIG by key %d is only valid from %v to %v", sig.KeyTag, rrsigTime(sig.Inception), rrsigTime(sig.Expiration))
+			continue
+		}
+		verified++
+		if expires := rrsigTime(sig.Expiration); expires.Sub(v.now) < v.opts.ExpirationWarning {
+			problem(nagios.Warning, ProblemExpiringSignature, "RRSIG by key %d expires at %v", sig.KeyTag, expires)
+		}
+	}
+
+	if verified == 0 && len(problems) == 0 {
+		problem(nagios.Critical, ProblemInvalidSignature, "no valid RRSIG records")
+	}
+	return problems
+}
+
+// rrsigTime returns the time of an RRSIG inception or expiration, in seconds since the epoch.
+func rrsigTime(t uint32) time.Time {
+	return time.Unix(int64(t), 0).UTC()
+}
+
+// getRRSet returns the records of type rrtype at name from nameserver, and the RRSIG records covering them.
+func (v *Validator) getRRSet(nameserver string, name string, rrtype uint16) ([]dns.RR, []*dns.RRSIG, error) {
+	msg, err := v.client.GetRecords(nameserver, name, rrtype)
+	if err != nil {
+		return nil, nil, err
+	}
+	if msg.Rcode != dns.RcodeSuccess && msg.Rcode != dns.RcodeNameError {
+		return nil, nil, fmt.Errorf("querying %v for %v %v: %v", nameserver, name, dns.TypeToString[rrtype], dns.RcodeToString[msg.Rcode])
+	}
+
+	rrs := []dns.RR{}
+	sigs := []*dns.RRSIG{}
+	for _, rr := range msg.Answer {
+		if !strings.EqualFold(rr.Header().Name, name) {
+			continue
+		}
+		switch r := rr.(type) {
+		case *dns.RRSIG:
+			if r.TypeCovered == rrtype {
+				sigs = append(sigs, r)
+			}
+		default:
+			if rr.Header().Rrtype == rrtype {
+				rrs = append(rrs, rr)
+			}
+		}
+	}
+	return rrs, sigs, nil
+}
+
+// Nagios returns the status of the report, and a message with a summary line followed by a line for each problem.
+func (r Report) Nagios() (nagios.Status, string) {
+	failed := 0
+	lines := []string{}
+	for _, zr := range r.Zones {
+		if zr.Status != nagios.Ok {
+			failed++
+		}
+		for _, p := range zr.Problems {
+			lines = append(lines, fmt.Sprintf("%v %v [%v]: %v", p.Status, p.Name, p.Type, p.Message))
+		}
+	}
+	summary := fmt.Sprintf("DNSSEC %v - %d of %d zones of %v have problems", r.Status, failed, len(r.Zones), r.Domain)
+	if failed == 0 {
+		summary = fmt.Sprintf("DNSSEC %v - %d zones of %v validated", r.Status, len(r.Zones), r.Domain)
+	}
+	return r.Status, strings.Join(append([]string{summary}, lines...), "\n")
+}
@@ -0,0 +1,368 @@
+package validate
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-nagios"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	testParent = "example.net."
+	testDomain = "cdn.example.net."
+	testZone   = "ds1.cdn.example.net."
+	testHost   = "edge.ds1.cdn.example.net."
+)
+
+type testKeys struct {
+	KSK     *dns.DNSKEY
+	KSKPriv crypto.Signer
+	ZSK     *dns.DNSKEY
+	ZSKPriv crypto.Signer
+}
+
+// testAuthority is an in-process stand-in for the authoritative nameservers of a CDN domain, its parent zone, and a delivery service zone.
+type testAuthority struct {
+	t       *testing.T
+	now     time.Time
+	keys    map[string]testKeys
+	records map[string][]dns.RR
+}
+
+func newTestKeys(t *testing.T, zone string) testKeys {
+	keys := testKeys{}
+	for _, flags := range []uint16{257, 256} {
+		key := &dns.DNSKEY{
+			Hdr:       dns.RR_Header{Name: zone, Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: 60},
+			Flags:     flags,
+			Protocol:  3,
+			Algorithm: dns.ECDSAP256SHA256,
+		}
+		priv, err := key.Generate(256)
+		if err != nil {
+			t.Fatalf("generating key for %v: %v", zone, err)
+		}
+		if flags == 257 {
+			keys.KSK, keys.KSKPriv = key, priv.(crypto.Signer)
+		} else {
+			keys.ZSK, keys.ZSKPriv = key, priv.(crypto.Signer)
+		}
+	}
+	return keys
+}
+
+func recordKey(name string, rrtype uint16) string {
+	return strings.ToLower(name) + " " + dns.TypeToString[rrtype]
+}
+
+func newTestRR(t *testing.T, s string) dns.RR {
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("parsing %v: %v", s, err)
+	}
+	return rr
+}
+
+// newTestAuthority returns an authority whose records all validate.
+func newTestAuthority(t *testing.T, now time.Time) *testAuthority {
+	a := &testAuthority{t: t, now: now, keys: map[string]testKeys{}, records: map[string][]dns.RR{}}
+	for _, zone := range []string{testParent, testDomain, testZone} {
+		a.keys[zone] = newTestKeys(t, zone)
+		keys := a.keys[zone]
+		a.set(zone, []dns.RR{keys.KSK, keys.ZSK}, now.Add(7*24*time.Hour))
+	}
+	for _, zone := range []string{testDomain, testZone} {
+		a.set(zone, []dns.RR{a.keys[zone].KSK.ToDS(dns.SHA256)}, now.Add(7*24*time.Hour))
+		a.set(zone, []dns.RR{newTestRR(t, zone+" 60 IN SOA ns1."+testDomain+" admin."+testDomain+" 2017 7200 1800 604800 30")}, now.Add(7*24*time.Hour))
+		a.set(zone, []dns.RR{newTestRR(t, zone+" 60 IN NS ns1."+testDomain)}, now.Add(7*24*time.Hour))
+	}
+	a.set(testZone, []dns.RR{newTestRR(t, testHost+" 60 IN A 192.0.2.1"), newTestRR(t, testHost+" 60 IN A 192.0.2.2")}, now.Add(7*24*time.Hour))
+	a.set(testZone, []dns.RR{newTestRR(t, testHost+" 60 IN AAAA 2001:db8::1")}, now.Add(7*24*time.Hour))
+	return a
+}
+
+// signer returns the zone whose keys sign the records of rrtype at name. DS records are signed by the parent zone.
+func (a *testAuthority) signer(zone string, rrtype uint16) string {
+	if rrtype != dns.TypeDS {
+		return zone
+	}
+	switch zone {
+	case testZone:
+		return testDomain
+	case testDomain:
+		return testParent
+	}
+	a.t.Fatalf("no parent of %v", zone)
+	return ""
+}
+
+// set serves rrs, which must all be the same name and type, signed by the keys of zone with a signature expiring at expiration.
+func (a *testAuthority) set(zone string, rrs []dns.RR, expiration time.Time) {
+	hdr := rrs[0].Header()
+	keys := a.keys[a.signer(zone, hdr.Rrtype)]
+	key, priv := keys.ZSK, keys.ZSKPriv
+	if hdr.Rrtype == dns.TypeDNSKEY {
+		key, priv = keys.KSK, keys.KSKPriv
+	}
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Name: hdr.Name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: hdr.Ttl},
+		Algorithm:  key.Algorithm,
+		KeyTag:     key.KeyTag(),
+		SignerName: key.Hdr.Name,
+		Inception:  uint32(a.now.Add(-time.Hour).Unix()),
+		Expiration: uint32(expiration.Unix()),
+	}
+	if err := sig.Sign(priv, rrs); err != nil {
+		a.t.Fatalf("signing %v: %v", recordKey(hdr.Name, hdr.Rrtype), err)
+	}
+	a.records[recordKey(hdr.Name, hdr.Rrtype)] = append(append([]dns.RR{}, rrs...), sig)
+}
+
+// serve starts answering queries on a local UDP port, and returns its address and a func to stop it.
+func (a *testAuthority) serve() (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		a.t.Fatalf("listening: %v", err)
+	}
+	srv := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		m.Authoritative = true
+		for _, q := range req.Question {
+			m.Answer = append(m.Answer, a.records[recordKey(q.Name, q.Qtype)]...)
+		}
+		w.WriteMsg(m)
+	})}
+	go srv.ActivateAndServe()
+	return conn.LocalAddr().String(), func() { srv.Shutdown() }
+}
+
+func testCRConfig() tc.CRConfig {
+	domain := strings.TrimSuffix(testDomain, ".")
+	return tc.CRConfig{
+		Config: tc.CRConfigConfig{DomainName: &domain},
+		DeliveryServices: map[string]tc.CRConfigDeliveryService{
+			"ds1": tc.CRConfigDeliveryService{
+				Domains:   []string{strings.TrimSuffix(testZone, ".")},
+				MatchSets: []*tc.CRConfigMatchSet{{Protocol: "DNS"}},
+			},
+			"no-domains": tc.CRConfigDeliveryService{},
+		},
+	}
+}
+
+func validate(t *testing.T, a *testAuthority) Report {
+	addr, stop := a.serve()
+	defer stop()
+	report, err := New(Options{Nameserver: addr, Timeout: time.Second}, a.now).ValidateCRConfig("cdn", testCRConfig())
+	if err != nil {
+		t.Fatalf("ValidateCRConfig expected: nil error, actual: %v", err)
+	}
+	return report
+}
+
+func findProblem(zr ZoneReport, ptype string) *Problem {
+	for _, p := range zr.Problems {
+		if p.Type == ptype {
+			return &p
+		}
+	}
+	return nil
+}
+
+func TestValidateCRConfig(t *testing.T) {
+	report := validate(t, newTestAuthority(t, time.Now()))
+	if report.Status != nagios.Ok {
+		t.Errorf("ValidateCRConfig status expected: %v, actual: %v %+v", nagios.Ok, report.Status, report.Zones)
+	}
+	if report.Domain != testDomain {
+		t.Errorf("ValidateCRConfig domain expected: %v, actual: %v", testDomain, report.Domain)
+	}
+	if len(report.Zones) != 2 {
+		t.Fatalf("ValidateCRConfig zones expected: 2, actual: %v", len(report.Zones))
+	}
+	if report.Zones[1].DeliveryService != "ds1" || report.Zones[1].Zone != testZone {
+		t.Errorf("ValidateCRConfig zone expected: ds1 %v, actual: %v %v", testZone, report.Zones[1].DeliveryService, report.Zones[1].Zone)
+	}
+}
+
+func TestValidateCRConfigProblems(t *testing.T) {
+	type testCase struct {
+		name       string
+		modify     func(a *testAuthority)
+		zone       int
+		status     nagios.Status
+		problem    string
+		domainZone nagios.Status
+	}
+	testCases := []testCase{
+		{
+			name: "expiring signature",
+			modify: func(a *testAuthority) {
+				a.set(testZone, []dns.RR{newTestRR(t, testHost+" 60 IN A 192.0.2.1")}, a.now.Add(time.Hour))
+			},
+			zone: 1, status: nagios.Warning, problem: ProblemExpiringSignature,
+		},
+		{
+			name: "expired signature",
+			modify: func(a *testAuthority) {
+				a.set(testZone, []dns.RR{newTestRR(t, testZone+" 60 IN NS ns1."+testDomain)}, a.now.Add(-time.Minute))
+			},
+			zone: 1, status: nagios.Critical, problem: ProblemExpiredSignature,
+		},
+		{
+			name: "missing DS",
+			modify: func(a *testAuthority) {
+				delete(a.records, recordKey(testZone, dns.TypeDS))
+			},
+			zone: 1, status: nagios.Critical, problem: ProblemMissingDS,
+		},
+		{
+			name: "missing CDN domain DS",
+			modify: func(a *testAuthority) {
+				delete(a.records, recordKey(testDomain, dns.TypeDS))
+			},
+			zone: 0, status: nagios.Critical, problem: ProblemMissingDS, domainZone: nagios.Critical,
+		},
+		{
+			name: "DS algorithm mismatch",
+			modify: func(a *testAuthority) {
+				ds := a.keys[testZone].KSK.ToDS(dns.SHA256)
+				ds.Algorithm = dns.RSASHA256
+				a.set(testZone, []dns.RR{ds}, a.now.Add(7*24*time.Hour))
+			},
+			zone: 1, status: nagios.Critical, problem: ProblemAlgorithmMismatch,
+		},
+		{
+			name: "DS digest mismatch",
+			modify: func(a *testAuthority) {
+				ds := a.keys[testZone].KSK.ToDS(dns.SHA256)
+				ds.Digest = strings.Repeat("0", len(ds.Digest))
+				a.set(testZone, []dns.RR{ds}, a.now.Add(7*24*time.Hour))
+			},
+			zone: 1, status: nagios.Critical, problem: ProblemDigestMismatch,
+		},
+		{
+			name: "missing RRSIG",
+			modify: func(a *testAuthority) {
+				a.records[recordKey(testZone, dns.TypeSOA)] = a.records[recordKey(testZone, dns.TypeSOA)][:1]
+			},
+			zone: 1, status: nagios.Critical, problem: ProblemMissingRRSIG,
+		},
+		{
+			name: "tampered record",
+			modify: func(a *testAuthority) {
+				a.records[recordKey(testHost, dns.TypeAAAA)][0] = newTestRR(t, testHost+" 60 IN AAAA 2001:db8::2")
+			},
+			zone: 1, status: nagios.Critical, problem: ProblemInvalidSignature,
+		},
+		{
+			name: "signed by an untrusted key",
+			modify: func(a *testAuthority) {
+				other := newTestKeys(t, testZone)
+				a.keys[testZone] = testKeys{KSK: a.keys[testZone].KSK, KSKPriv: a.keys[testZone].KSKPriv, ZSK: other.ZSK, ZSKPriv: other.ZSKPriv}
+				a.set(testZone, []dns.RR{newTestRR(t, testHost+" 60 IN A 192.0.2.1")}, a.now.Add(7*24*time.Hour))
+			},
+			zone: 1, status: nagios.Critical, problem: ProblemUntrustedKey,
+		},
+	}
+
+	for _, tc := range testCases {
+		a := newTestAuthority(t, time.Now())
+		tc.modify(a)
+		report := validate(t, a)
+		if report.Status != tc.status {
+			t.Errorf("ValidateCRConfig %v status expected: %v, actual: %v", tc.name, tc.status, report.Status)
+		}
+		if len(report.Zones) != 2 {
+			t.Fatalf("ValidateCRConfig %v zones expected: 2, actual: %v", tc.name, len(report.Zones))
+		}
+		if report.Zones[0].Status != tc.domainZone {
+			t.Errorf("ValidateCRConfig %v domain zone status expected: %v, actual: %v %+v", tc.name, tc.domainZone, report.Zones[0].Status, report.Zones[0].Problems)
+		}
+		zr := report.Zones[tc.zone]
+		if zr.Status != tc.status {
+			t.Errorf("ValidateCRConfig %v zone status expected: %v, actual: %v", tc.name, tc.status, zr.Status)
+		}
+		if p := findProblem(zr, tc.problem); p == nil {
+			t.Errorf("ValidateCRConfig %v problem expected: %v, actual: %+v", tc.name, tc.problem, zr.Problems)
+		}
+	}
+}
+
+func TestValidateCRConfigNoDomain(t *testing.T) {
+	if _, err := New(Options{Nameserver: "127.0.0.1:53"}, time.Now()).ValidateCRConfig("cdn", tc.CRConfig{}); err == nil {
+		t.Errorf("ValidateCRConfig without domain_name expected: error, actual: nil")
+	}
+}
+
+func TestRoutingName(t *testing.T) {
+	name := "foo"
+	type testCase struct {
+		ds       tc.CRConfigDeliveryService
+		expected string
+	}
+	testCases := []testCase{
+		{tc.CRConfigDeliveryService{RoutingName: &name}, "foo"},
+		{tc.CRConfigDeliveryService{MatchSets: []*tc.CRConfigMatchSet{{Protocol: "DNS"}}}, "edge"},
+		{tc.CRConfigDeliveryService{MatchSets: []*tc.CRConfigMatchSet{{Protocol: "HTTP"}}}, "tr"},
+	}
+	for _, tc := range testCases {
+		if actual := routingName(tc.ds); actual != tc.expected {
+			t.Errorf("routingName expected: %v, actual: %v", tc.expected, actual)
+		}
+	}
+}
+
+func TestReportNagios(t *testing.T) {
+	report := Report{
+		Domain: testDomain,
+		Status: nagios.Warning,
+		Zones: []ZoneReport{
+			{Zone: testDomain, Status: nagios.Ok, Problems: []Problem{}},
+			{Zone: testZone, Status: nagios.Warning, Problems: []Problem{{Name: testHost + " A", Type: ProblemExpiringSignature, Status: nagios.Warning, Message: "RRSIG by key 1 expires soon"}}},
+		},
+	}
+	status, msg := report.Nagios()
+	if status != nagios.Warning {
+		t.Errorf("Nagios status expected: %v, actual: %v", nagios.Warning, status)
+	}
+	expected := "DNSSEC WARNING - 1 of 2 zones of " + testDomain + " have problems\nWARNING " + testHost + " A [" + ProblemExpiringSignature + "]: RRSIG by key 1 expires soon"
+	if msg != expected {
+		t.Errorf("Nagios message expected: %q, actual: %q", expected, msg)
+	}
+
+	bts, err := json.Marshal(report.Zones[1].Problems[0])
+	if err != nil {
+		t.Fatalf("marshalling problem: %v", err)
+	}
+	if !strings.Contains(string(bts), `"status":"WARNING"`) {
+		t.Errorf("Problem JSON expected: status WARNING, actual: %s", bts)
+	}
+}
@@ -43,9 +43,16 @@ var CdnTestForm = React.createClass({
             cdn: "none",
             numHttp: 10,
             numHttps: 10,
+            numDns: 0,
             txCount: 1000,
             connections: 10,
-            caFile: './ca.crt'
+            caFile: './ca.crt',
+            nameserver: '',
+            qps: 0,
+            rampUpSeconds: 0,
+            durationSeconds: 0,
+            followRedirects: false,
+            clientSubnets: ''
         }
     },
     handleCdnChange: function(e) {
@@ -66,6 +73,27 @@ var CdnTestForm = React.createClass({
     handleCaFileChange: function(e) {
         this.setState({caFile: e.target.value})
     },
+    handleNumDnsChange: function(e) {
+        this.setState({numDns: e.target.value})
+    },
+    handleNameserverChange: function(e) {
+        this.setState({nameserver: e.target.value})
+    },
+    handleQpsChange: function(e) {
+        this.setState({qps: e.target.value})
+    },
+    handleRampUpSecondsChange: function(e) {
+        this.setState({rampUpSeconds: e.target.value})
+    },
+    handleDurationSecondsChange: function(e) {
+        this.setState({durationSeconds: e.target.value})
+    },
+    handleFollowRedirectsChange: function(e) {
+        this.setState({followRedirects: e.target.checked})
+    },
+    handleClientSubnetsChange: function(e) {
+        this.setState({clientSubnets: e.target.value})
+    },
     handleSubmit: function(e) {
         e.preventDefault();
         var cdn = this.state.cdn.trim();
@@ -123,13 +151,32 @@ var CdnTestForm = React.createClass({
             httpsDeliveryServices.push(id);
         }
 
+        var dnsDsList = testCdn.deliveryServices.filter(function (item) {
+            return item.type.toLowerCase().includes("dns");
+        });
+
+        var dnsDeliveryServices = [];
+        for (var i = 0; i < this.state.numDns && dnsDsList.length > 0; i++) {
+            var u = dnsDsList[Math.floor(Math.random() * dnsDsList.length)].exampleURLs[0];
+            var host = u.substring(u.indexOf("://") + 3);
+            host = host.substring(host.indexOf(".") + 1);
+            dnsDeliveryServices.push(host.substring(0, host.indexOf(testCdn.name) - 1));
+        }
+
         this.props.onLoadTestSubmit({
             cdn: testCdn.name,
             httpDeliveryServices: httpDeliveryServices,
             httpsDeliveryServices: httpsDeliveryServices,
+            dnsDeliveryServices: dnsDeliveryServices,
+            nameserver: this.state.nameserver.trim(),
             txCount: txCount,
             connections: connections,
-            caFile: caFile
+            caFile: caFile,
+            qps: this.state.qps,
+            rampUpSeconds: this.state.rampUpSeconds,
+            durationSeconds: this.state.durationSeconds,
+            followRedirects: this.state.followRedirects,
+            clientSubnets: this.state.clientSubnets.split(",").map(function (s) { return s.trim(); }).filter(function (s) { return s.length > 0; })
         });
     },
 
@@ -158,6 +205,14 @@ var CdnTestForm = React.createClass({
                         <label htmlFor="numHttpsInput"># of HTTPS DS to exercise</label>
                         <input id="numHttpsInput" type="text" className="form-control" value={this.state.numHttps} onChange={this.handleNumHttpsChange}/>
                     </div>
+                    <div className="form-group">
+                        <label htmlFor="numDnsInput"># of DNS DS to exercise</label>
+                        <input id="numDnsInput" type="text" className="form-control" value={this.state.numDns} onChange={this.handleNumDnsChange}/>
+                    </div>
+                    <div className="form-group">
+                        <label htmlFor="nameserverInput">Traffic Router nameserver for DNS DS (host:port)</label>
+                        <input id="nameserverInput" type="text" className="form-control" value={this.state.nameserver} onChange={this.handleNameserverChange} placeholder="tr.cdn.example.com:53"/>
+                    </div>
                     <div className="form-group">
                         <label htmlFor="txCountInput"># of transactions per Delivery Services</label>
                         <input id="txCountInput" type="text" className="form-control" value={this.state.txCount} onChange={this.handleTxCountChange}/>
@@ -166,6 +221,25 @@ var CdnTestForm = React.createClass({
                         <label htmlFor="connectionsInput"># of concurrent requests per Delivery Services</label>
                         <input id="connectionsInput" type="text" className="form-control" value={this.state.connections} onChange={this.handleConnectionsChange} />
                     </div>
+                    <div className="form-group">
+                        <label htmlFor="qpsInput">Target requests per second across all Delivery Services (0 for as fast as possible)</label>
+                        <input id="qpsInput" type="text" className="form-control" value={this.state.qps} onChange={this.handleQpsChange}/>
+                    </div>
+                    <div className="form-group">
+                        <label htmlFor="rampUpSecondsInput">Ramp up seconds</label>
+                        <input id="rampUpSecondsInput" type="text" className="form-control" value={this.state.rampUpSeconds} onChange={this.handleRampUpSecondsChange}/>
+                    </div>
+                    <div className="form-group">
+                        <label htmlFor="durationSecondsInput">Duration seconds (instead of # of transactions)</label>
+                        <input id="durationSecondsInput" type="text" className="form-control" value={this.state.durationSeconds} onChange={this.handleDurationSecondsChange}/>
+                    </div>
+                    <div className="form-group">
+                        <label htmlFor="clientSubnetsInput">Simulated client subnets, comma separated</label>
+                        <input id="clientSubnetsInput" type="text" className="form-control" value={this.state.clientSubnets} onChange={this.handleClientSubnetsChange} placeholder="198.51.100.0/24, 2001:db8::/48"/>
+                    </div>
+                    <div className="checkbox">
+                        <label><input type="checkbox" checked={this.state.followRedirects} onChange={this.handleFollowRedirectsChange}/> Follow redirects to caches</label>
+                    </div>
                     <div className="form-group">
                         <label htmlFor="caFileInput">CA file path on server</label>
                         <input id="caFileInput" className="form-control" type="text" value={this.state.caFile} onChange={this.handleCaFileChange} placeholder="./ca.crt"/>
@@ -275,8 +349,12 @@ var LabBox = React.createClass({
 
         this.setState({latencies: []});
 
-        formData.txCount = parseInt(formData.txCount);
+        formData.txCount = parseInt(formData.txCount) || 0;
         formData.connections = parseInt(formData.connections);
+        formData.qps = parseFloat(formData.qps) || 0;
+        formData.rampUpSeconds = parseFloat(formData.rampUpSeconds) || 0;
+        formData.durationSeconds = parseFloat(formData.durationSeconds) || 0;
+        formData.opsHost = this.opsHost;
 
         this.totalRequests = formData.txCount * (formData.httpDeliveryServices.length * formData.httpsDeliveryServices.length);
 
@@ -287,12 +365,33 @@ var LabBox = React.createClass({
             data: JSON.stringify(formData),
             success: function(stuff) {
                 console.log("posted request to start load test");
+                this.streamProgress();
             }.bind(this),
             error: function(xhr, status, err) {
                 console.error(this.props.url, status, err.toString());
             }.bind(this)
         });
     },
+    streamProgress: function() {
+        if (this.progressSource != null) {
+            this.progressSource.close();
+        }
+
+        this.progressSource = new EventSource(this.props.url + "/progress");
+        this.progressSource.addEventListener("progress", function(e) {
+            this.setState({report: JSON.parse(e.data)});
+        }.bind(this));
+        this.progressSource.addEventListener("done", function(e) {
+            this.progressSource.close();
+        }.bind(this));
+    },
+    handleStopSubmit: function(e) {
+        e.preventDefault();
+        $.ajax({
+            url: this.props.url,
+            type: 'DELETE'
+        });
+    },
     handleSubmit: function(e) {
         e.preventDefault();
         console.log("handle submit");
@@ -388,6 +487,7 @@ var LabBox = React.createClass({
                 <CdnList cdns={this.cdns}/>
                 <CdnTestForm cdns={this.cdns} onLoadTestSubmit={this.handleLoadTestSubmit}/>
                 <h1>Results</h1>
+                <ProgressBox report={this.state.report} url={this.props.url} onStop={this.handleStopSubmit}/>
                 <dl className="dl-horizontal">
                     <dt>Start Time</dt><dd>{this.startTimePretty}</dd>
                     {/*<dt>Run Time</dt><dd>{this.runningTimePretty}</dd>*/}
@@ -449,6 +549,56 @@ var LoginBox = React.createClass({
     }
 });
 
+var ProgressBox = React.createClass({
+    render: function() {
+        var report = this.props.report;
+        if (report == null) {
+            return (
+                <div>No load test running</div>
+            )
+        }
+
+        var ms = function (usec) { return (usec / 1000.0).toFixed(3); };
+
+        var cacheGroupRows = Object.keys(report.cacheGroups).sort().map(function (cacheGroup) {
+            var count = report.cacheGroups[cacheGroup];
+            return (
+                <div key={cacheGroup} className="row">
+                    <div className="col-sm-6">{cacheGroup}</div>
+                    <div className="col-sm-3">{count}</div>
+                    <div className="col-sm-3">{(100.0 * count / report.requests).toFixed(1)}%</div>
+                </div>
+            )
+        });
+
+        return (
+            <div className="well">
+                <h4>
+                    {report.running ? "Running" : "Done"}
+                    {report.running ? <button type="button" className="btn btn-danger" onClick={this.props.onStop}>Stop</button> : null}
+                    <a className="btn btn-info" href={this.props.url + "/report?download=true"}>Export JSON</a>
+                </h4>
+                <dl className="dl-horizontal">
+                    <dt>Run Time</dt><dd>{report.elapsedSeconds.toFixed(1)} sec</dd>
+                    <dt>Requests</dt><dd>{report.requests}</dd>
+                    <dt>Errors</dt><dd>{report.errors}</dd>
+                    <dt>Requests per Second</dt><dd>{report.qps.toFixed(1)}</dd>
+                    <dt>Median Latency</dt><dd>{ms(report.latency.p50)} mSec</dd>
+                    <dt>90th % Latency</dt><dd>{ms(report.latency.p90)} mSec</dd>
+                    <dt>99th % Latency</dt><dd>{ms(report.latency.p99)} mSec</dd>
+                    <dt>Average Latency</dt><dd>{ms(report.latency.mean)} mSec</dd>
+                </dl>
+                <div className="row grid-table-header">
+                    <div className="col-sm-6">Cachegroup</div>
+                    <div className="col-sm-3">Requests</div>
+                    <div className="col-sm-3">Share</div>
+                </div>
+                {cacheGroupRows}
+            </div>
+        )
+    }
+});
+
 var SubResult = React.createClass({
     render: function() {
         return (
@@ -8,9 +8,9 @@ package load
  * to you under the Apache License, Version 2.0 (the
  * "License"); you may not use this file except in compliance
  * with the License.  You may obtain a copy of the License at
- * 
+ *
  *   http://www.apache.org/licenses/LICENSE-2.0
- * 
+ *
  * Unless required by applicable law or agreed to in writing,
  * software distributed under the License is distributed on an
  * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
@@ -19,16 +19,28 @@ package load
  * under the License.
  */
 
-
 import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
 	"strings"
 	"sync"
+	"time"
 
-	"fmt"
-	"github.com/Comcast/traffic_control/test/router/client"
-	"github.com/Comcast/traffic_control/test/router/data"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/test/router/client"
+	"github.com/apache/incubator-trafficcontrol/test/router/data"
+
+	"github.com/miekg/dns"
 )
 
+const DefaultHttpRoutingName = "ccr"
+const DefaultDnsRoutingName = "edge"
+const DefaultTimeout = 10 * time.Second
+
+// LoadTest is a load test of the delivery services of a CDN. If TxCount is set, each delivery service gets that many requests; otherwise requests are made until DurationSeconds have passed. If QPS is set, requests are sent at that rate across all delivery services, ramping up linearly over RampUpSeconds; otherwise as fast as Connections allow.
 type LoadTest struct {
 	CaFile                string   `json:"caFile"`
 	Cdn                   string   `json:"cdn"`
@@ -36,37 +48,215 @@ type LoadTest struct {
 	Connections           int      `json:"connections"`
 	HttpDeliveryServices  []string `json:"httpDeliveryServices"`
 	HttpsDeliveryServices []string `json:"httpsDeliveryServices"`
+	DnsDeliveryServices   []string `json:"dnsDeliveryServices"`
+	HttpRoutingName       string   `json:"httpRoutingName"`
+	DnsRoutingName        string   `json:"dnsRoutingName"`
+	// Nameserver is the Traffic Router host:port DNS delivery services are queried at.
+	Nameserver      string  `json:"nameserver"`
+	QPS             float64 `json:"qps"`
+	RampUpSeconds   float64 `json:"rampUpSeconds"`
+	DurationSeconds float64 `json:"durationSeconds"`
+	TimeoutSeconds  float64 `json:"timeoutSeconds"`
+	FollowRedirects bool    `json:"followRedirects"`
+	// ClientSubnets are CIDRs simulated clients' addresses are randomly chosen from, sent as X-Forwarded-For to HTTP delivery services and EDNS0 client subnet to DNS delivery services.
+	ClientSubnets []string `json:"clientSubnets"`
+	// CacheGroups maps the hostnames, FQDNs and IPs of caches to their cachegroup, to report which cachegroups clients were routed to.
+	CacheGroups map[string]string `json:"cacheGroups"`
+	// OpsHost is the Traffic Ops host the server fetches the CDN's CRConfig from, to fill CacheGroups.
+	OpsHost string `json:"opsHost,omitempty"`
+}
+
+type target struct {
+	deliveryService string
+	protocol        string
+	host            string
+	qtype           uint16
 }
 
-func DoLoadTest(loadtest LoadTest, done chan struct{}) chan data.HttpResult {
-	resultsChan := make(chan data.HttpResult)
+// DoLoadTest starts loadtest, and returns a channel of the result of each request, which is closed when the test is done or stop is closed.
+func DoLoadTest(loadtest LoadTest, stop <-chan struct{}) (<-chan data.Result, error) {
+	targets := loadtest.targets()
+	if len(targets) == 0 {
+		return nil, errors.New("no delivery services to exercise")
+	}
+	if loadtest.TxCount <= 0 && loadtest.DurationSeconds <= 0 {
+		return nil, errors.New("either txCount or durationSeconds is required")
+	}
+	if len(loadtest.DnsDeliveryServices) > 0 && loadtest.Nameserver == "" {
+		return nil, errors.New("a nameserver is required to exercise DNS delivery services")
+	}
+	subnets, err := parseSubnets(loadtest.ClientSubnets)
+	if err != nil {
+		return nil, err
+	}
+
+	connections := loadtest.Connections
+	if connections <= 0 {
+		connections = 1
+	}
+	timeout := DefaultTimeout
+	if loadtest.TimeoutSeconds > 0 {
+		timeout = time.Duration(loadtest.TimeoutSeconds * float64(time.Second))
+	}
+
+	httpClient := client.NewHttpClient(nil, timeout)
+	if loadtest.CaFile != "" && len(loadtest.HttpsDeliveryServices) > 0 {
+		httpClient = client.NewHttpClient(client.MustGetTlsConfiguration(loadtest.Cdn, loadtest.CaFile), timeout)
+	}
+	dnsClient := &dns.Client{Net: "udp", Timeout: timeout}
+
+	total := 0
+	if loadtest.TxCount > 0 {
+		total = loadtest.TxCount * len(targets)
+	}
+
+	fmt.Println("Starting load test", loadtest)
+	results := make(chan data.Result, connections)
+	jobs := make(chan target)
+
+	var waitGroup sync.WaitGroup
+	for worker := 0; worker < connections; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			random := rand.New(rand.NewSource(time.Now().UnixNano()))
+			for t := range jobs {
+				clientIP := randomIP(random, subnets)
+				result := data.Result{}
+				switch t.protocol {
+				case data.ProtocolDns:
+					result = client.DoDnsRequest(dnsClient, loadtest.Nameserver, t.host, t.qtype, clientIP)
+				default:
+					result = client.DoHttpRequest(httpClient, t.protocol == data.ProtocolHttps, t.host, clientIP, loadtest.FollowRedirects)
+				}
+				result.DeliveryService = t.deliveryService
+				result.CacheGroup = lookupCacheGroup(loadtest.CacheGroups, result.Cache)
+				results <- result
+			}
+		}()
+	}
 
 	go func() {
-		fmt.Println("Starting load test", loadtest)
-		defer close(done)
-		var waitGroup sync.WaitGroup
-		for _, deliveryService := range loadtest.HttpDeliveryServices {
-			waitGroup.Add(1)
-			go func(ds string) {
-				defer waitGroup.Done()
-				host := strings.Join([]string{ds, loadtest.Cdn}, ".")
-				tlsConfig := client.MustGetTlsConfiguration(host, loadtest.CaFile)
-				client.ExerciseDeliveryService(false, tlsConfig, host, loadtest.TxCount, loadtest.Connections, resultsChan)
-			}(deliveryService)
+		defer close(results)
+		defer waitGroup.Wait()
+		defer close(jobs)
+
+		qps := loadtest.QPS
+		rampUp := time.Duration(loadtest.RampUpSeconds * float64(time.Second))
+		duration := time.Duration(loadtest.DurationSeconds * float64(time.Second))
+		start := time.Now()
+		for i := 0; total == 0 || i < total; i++ {
+			if duration > 0 && time.Since(start) >= duration {
+				return
+			}
+			if qps > 0 {
+				wait := time.NewTimer(start.Add(SendOffset(i, qps, rampUp)).Sub(time.Now()))
+				select {
+				case <-wait.C:
+				case <-stop:
+					wait.Stop()
+					return
+				}
+			}
+			select {
+			case jobs <- targets[i%len(targets)]:
+			case <-stop:
+				return
+			}
 		}
+	}()
 
-		for _, deliveryService := range loadtest.HttpsDeliveryServices {
-			waitGroup.Add(1)
-			go func(ds string) {
-				defer waitGroup.Done()
-				host := strings.Join([]string{ds, loadtest.Cdn}, ".")
-				tlsConfig := client.MustGetTlsConfiguration(host, loadtest.CaFile)
-				client.ExerciseDeliveryService(true, tlsConfig, host, loadtest.TxCount, loadtest.Connections, resultsChan)
-			}(deliveryService)
+	return results, nil
+}
+
+// targets returns the requests to make for each delivery service of the test, alternating between A and AAAA queries for DNS delivery services.
+func (loadtest LoadTest) targets() []target {
+	httpRoutingName := loadtest.HttpRoutingName
+	if httpRoutingName == "" {
+		httpRoutingName = DefaultHttpRoutingName
+	}
+	dnsRoutingName := loadtest.DnsRoutingName
+	if dnsRoutingName == "" {
+		dnsRoutingName = DefaultDnsRoutingName
+	}
+
+	targets := []target{}
+	for _, ds := range loadtest.HttpDeliveryServices {
+		targets = append(targets, target{deliveryService: ds, protocol: data.ProtocolHttp, host: strings.Join([]string{httpRoutingName, ds, loadtest.Cdn}, ".")})
+	}
+	for _, ds := range loadtest.HttpsDeliveryServices {
+		targets = append(targets, target{deliveryService: ds, protocol: data.ProtocolHttps, host: strings.Join([]string{httpRoutingName, ds, loadtest.Cdn}, ".")})
+	}
+	for _, ds := range loadtest.DnsDeliveryServices {
+		host := strings.Join([]string{dnsRoutingName, ds, loadtest.Cdn}, ".")
+		targets = append(targets, target{deliveryService: ds, protocol: data.ProtocolDns, host: host, qtype: dns.TypeA})
+		targets = append(targets, target{deliveryService: ds, protocol: data.ProtocolDns, host: host, qtype: dns.TypeAAAA})
+	}
+	return targets
+}
+
+// SendOffset returns how long after the start of a test the request numbered i should be sent, for the rate to ramp up linearly from zero to qps over rampUp, and then stay at qps.
+func SendOffset(i int, qps float64, rampUp time.Duration) time.Duration {
+	ramp := rampUp.Seconds()
+	rampRequests := qps * ramp / 2
+	seconds := 0.0
+	if float64(i) < rampRequests {
+		seconds = math.Sqrt(2 * ramp * float64(i) / qps)
+	} else {
+		seconds = ramp + (float64(i)-rampRequests)/qps
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func parseSubnets(cidrs []string) ([]*net.IPNet, error) {
+	subnets := []*net.IPNet{}
+	for _, cidr := range cidrs {
+		_, subnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client subnet '%v': %v", cidr, err)
 		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
 
-		waitGroup.Wait()
-	}()
+// randomIP returns a random address in a random one of subnets, or the empty string if there are none.
+func randomIP(random *rand.Rand, subnets []*net.IPNet) string {
+	if len(subnets) == 0 {
+		return ""
+	}
+	subnet := subnets[random.Intn(len(subnets))]
+	ip := make(net.IP, len(subnet.IP))
+	for i := range ip {
+		ip[i] = subnet.IP[i] | (byte(random.Intn(256)) &^ subnet.Mask[i])
+	}
+	return ip.String()
+}
 
-	return resultsChan
+// lookupCacheGroup returns the cachegroup of cache, which may be a cache's IP, FQDN, or its hostname prefixed to a delivery service domain, as Traffic Router redirects to.
+func lookupCacheGroup(cacheGroups map[string]string, cache string) string {
+	if cache == "" {
+		return ""
+	}
+	if cg, ok := cacheGroups[cache]; ok {
+		return cg
+	}
+	return cacheGroups[strings.SplitN(cache, ".", 2)[0]]
+}
+
+// CacheGroupsFromCRConfig returns the cachegroups of the caches in crc, by hostname, FQDN and IPs.
+func CacheGroupsFromCRConfig(crc tc.CRConfig) map[string]string {
+	cacheGroups := map[string]string{}
+	for name, server := range crc.ContentServers {
+		if server.CacheGroup == nil {
+			continue
+		}
+		cacheGroups[name] = *server.CacheGroup
+		for _, key := range []*string{server.Fqdn, server.Ip, server.Ip6} {
+			if key != nil && *key != "" {
+				cacheGroups[strings.SplitN(*key, "/", 2)[0]] = *server.CacheGroup
+			}
+		}
+	}
+	return cacheGroups
 }
@@ -0,0 +1,281 @@
+package load
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/test/router/data"
+
+	"github.com/miekg/dns"
+)
+
+func TestSendOffset(t *testing.T) {
+	type testCase struct {
+		i        int
+		qps      float64
+		rampUp   time.Duration
+		expected time.Duration
+	}
+	testCases := []testCase{
+		{0, 10, 0, 0},
+		{10, 10, 0, time.Second},
+		{25, 10, 0, 2500 * time.Millisecond},
+		// ramping up to 10 qps over 10s sends 50 requests in the first 10s, 5 in the first ~3.16s
+		{5, 10, 10 * time.Second, 3162 * time.Millisecond},
+		{50, 10, 10 * time.Second, 10 * time.Second},
+		{60, 10, 10 * time.Second, 11 * time.Second},
+	}
+	for _, tc := range testCases {
+		actual := SendOffset(tc.i, tc.qps, tc.rampUp)
+		if diff := actual - tc.expected; diff > time.Millisecond || diff < -time.Millisecond {
+			t.Errorf("SendOffset(%v, %v, %v) expected: %v, actual: %v", tc.i, tc.qps, tc.rampUp, tc.expected, actual)
+		}
+	}
+}
+
+func TestRandomIP(t *testing.T) {
+	subnets, err := parseSubnets([]string{"192.0.2.0/24", "2001:db8::/48"})
+	if err != nil {
+		t.Fatalf("parseSubnets expected: nil error, actual: %v", err)
+	}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		ip := net.ParseIP(randomIP(random, subnets))
+		if ip == nil || !(subnets[0].Contains(ip) || subnets[1].Contains(ip)) {
+			t.Errorf("randomIP expected: an address in %v, actual: %v", subnets, ip)
+		}
+	}
+	if ip := randomIP(random, nil); ip != "" {
+		t.Errorf("randomIP without subnets expected: empty, actual: %v", ip)
+	}
+	if _, err := parseSubnets([]string{"192.0.2.0"}); err == nil {
+		t.Errorf("parseSubnets invalid CIDR expected: error, actual: nil")
+	}
+}
+
+func TestCacheGroupsFromCRConfig(t *testing.T) {
+	cg, fqdn, ip, ip6 := "cg1", "edge1.example.net", "192.0.2.1", "2001:db8::1/64"
+	crc := tc.CRConfig{ContentServers: map[string]tc.CRConfigTrafficOpsServer{
+		"edge1": {CacheGroup: &cg, Fqdn: &fqdn, Ip: &ip, Ip6: &ip6},
+		"edge2": {},
+	}}
+	cacheGroups := CacheGroupsFromCRConfig(crc)
+	for _, cache := range []string{"edge1", "edge1.example.net", "192.0.2.1", "2001:db8::1", "edge1.ds1.cdn.example.net"} {
+		if actual := lookupCacheGroup(cacheGroups, cache); actual != cg {
+			t.Errorf("lookupCacheGroup %v expected: %v, actual: %v", cache, cg, actual)
+		}
+	}
+	if actual := lookupCacheGroup(cacheGroups, "edge2"); actual != "" {
+		t.Errorf("lookupCacheGroup edge2 expected: empty, actual: %v", actual)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	start := time.Now()
+	r := NewRecorder(start)
+	for i := 1; i <= 100; i++ {
+		r.Add(data.Result{DeliveryService: "ds1", LatencyUsec: int64(i * 1000), Status: 302, Cache: "edge1", CacheGroup: "cg1"})
+	}
+	r.Add(data.Result{DeliveryService: "ds2", Error: "timeout", Status: -1})
+	r.Done(start.Add(10 * time.Second))
+
+	report := r.Report(start.Add(time.Hour))
+	if report.Running {
+		t.Errorf("Report running expected: false, actual: true")
+	}
+	if report.Requests != 101 || report.Errors != 1 {
+		t.Errorf("Report requests and errors expected: 101 1, actual: %v %v", report.Requests, report.Errors)
+	}
+	if report.QPS != 10.1 {
+		t.Errorf("Report QPS expected: 10.1, actual: %v", report.QPS)
+	}
+	if report.Latency.P50Usec != 50000 || report.Latency.P99Usec != 99000 || report.Latency.MinUsec != 1000 || report.Latency.MaxUsec != 100000 {
+		t.Errorf("Report latency expected: p50 50000 p99 99000 min 1000 max 100000, actual: %+v", report.Latency)
+	}
+	count := 0
+	for _, bucket := range report.Latency.Histogram {
+		count += bucket.Count
+	}
+	if count != 100 {
+		t.Errorf("Report histogram count expected: 100, actual: %v", count)
+	}
+	if report.CacheGroups["cg1"] != 100 || report.DeliveryServices["ds1"].CacheGroups["cg1"] != 100 {
+		t.Errorf("Report cachegroups expected: cg1 100, actual: %v %v", report.CacheGroups, report.DeliveryServices["ds1"].CacheGroups)
+	}
+	if report.Statuses["302"] != 100 || report.DeliveryServices["ds2"].Errors != 1 {
+		t.Errorf("Report statuses expected: 302 100 and 1 ds2 error, actual: %v %+v", report.Statuses, report.DeliveryServices["ds2"])
+	}
+}
+
+func TestDoLoadTest(t *testing.T) {
+	edge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer edge.Close()
+	edgeURL, _ := url.Parse(edge.URL)
+
+	forwardedFor := make(chan string, 100)
+	// the router listens on 127.0.0.1:port, so requests to the "0" delivery service of the "0.1:port" CDN with the "127" routing name go to it
+	router := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedFor <- r.Header.Get("X-Forwarded-For")
+		http.Redirect(w, r, "http://"+edgeURL.Host+r.URL.Path, http.StatusFound)
+	}))
+	defer router.Close()
+	routerURL, _ := url.Parse(router.URL)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	subnets := make(chan *dns.EDNS0_SUBNET, 100)
+	nameserver := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+		if opt := req.IsEdns0(); opt != nil {
+			for _, o := range opt.Option {
+				if s, ok := o.(*dns.EDNS0_SUBNET); ok {
+					subnets <- s
+				}
+			}
+		}
+		q := req.Question[0]
+		rrStr := q.Name + " 30 IN A 192.0.2.10"
+		if q.Qtype == dns.TypeAAAA {
+			rrStr = q.Name + " 30 IN AAAA 2001:db8::10"
+		}
+		rr, _ := dns.NewRR(rrStr)
+		m.Answer = append(m.Answer, rr)
+		w.WriteMsg(m)
+	})}
+	go nameserver.ActivateAndServe()
+	defer nameserver.Shutdown()
+
+	lt := LoadTest{
+		Cdn:                  strings.SplitN(routerURL.Host, ".", 3)[2],
+		TxCount:              4,
+		Connections:          2,
+		HttpRoutingName:      strings.SplitN(routerURL.Host, ".", 3)[0],
+		HttpDeliveryServices: []string{"0"},
+		DnsDeliveryServices:  []string{"ds2"},
+		Nameserver:           conn.LocalAddr().String(),
+		FollowRedirects:      true,
+		ClientSubnets:        []string{"198.51.100.0/24"},
+		CacheGroups:          map[string]string{"127.0.0.1": "cg-http", "192.0.2.10": "cg-dns", "2001:db8::10": "cg-dns6"},
+		QPS:                  200,
+	}
+
+	resultChan, err := DoLoadTest(lt, make(chan struct{}))
+	if err != nil {
+		t.Fatalf("DoLoadTest expected: nil error, actual: %v", err)
+	}
+	r := NewRecorder(time.Now())
+	for result := range resultChan {
+		if result.Error != "" {
+			t.Errorf("DoLoadTest result error expected: none, actual: %+v", result)
+		}
+		r.Add(result)
+	}
+	r.Done(time.Now())
+
+	report := r.Report(time.Now())
+	// one HTTP target and two DNS targets (A and AAAA), 4 requests each
+	if report.Requests != 12 {
+		t.Errorf("DoLoadTest requests expected: 12, actual: %v", report.Requests)
+	}
+	if report.CacheGroups["cg-http"] != 4 || report.CacheGroups["cg-dns"] != 4 || report.CacheGroups["cg-dns6"] != 4 {
+		t.Errorf("DoLoadTest cachegroups expected: 4 each, actual: %v", report.CacheGroups)
+	}
+
+	_, clientNet, _ := net.ParseCIDR("198.51.100.0/24")
+	// the stand-ins answered every request before the results were received, so their channels are drained without closing them
+	for n := len(forwardedFor); n > 0; n-- {
+		ip := <-forwardedFor
+		if !clientNet.Contains(net.ParseIP(ip)) {
+			t.Errorf("DoLoadTest X-Forwarded-For expected: an address in %v, actual: %v", clientNet, ip)
+		}
+	}
+	count := len(subnets)
+	for n := count; n > 0; n-- {
+		subnet := <-subnets
+		if subnet.SourceNetmask != 24 || !clientNet.Contains(subnet.Address) {
+			t.Errorf("DoLoadTest client subnet expected: /24 in %v, actual: %v/%v", clientNet, subnet.Address, subnet.SourceNetmask)
+		}
+	}
+	if count != 8 {
+		t.Errorf("DoLoadTest client subnets expected: 8, actual: %v", count)
+	}
+}
+
+func TestDoLoadTestInvalid(t *testing.T) {
+	testCases := map[string]LoadTest{
+		"no delivery services":   LoadTest{TxCount: 1},
+		"no count or duration":   LoadTest{HttpDeliveryServices: []string{"ds1"}},
+		"dns without nameserver": LoadTest{TxCount: 1, DnsDeliveryServices: []string{"ds1"}},
+		"invalid subnet":         LoadTest{TxCount: 1, HttpDeliveryServices: []string{"ds1"}, ClientSubnets: []string{"nope"}},
+	}
+	for name, lt := range testCases {
+		if _, err := DoLoadTest(lt, make(chan struct{})); err == nil {
+			t.Errorf("DoLoadTest %v expected: error, actual: nil", name)
+		}
+	}
+}
+
+func TestDoLoadTestStop(t *testing.T) {
+	router := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://edge1.example.net/", http.StatusFound)
+	}))
+	defer router.Close()
+	routerURL, _ := url.Parse(router.URL)
+
+	lt := LoadTest{
+		Cdn:                  strings.SplitN(routerURL.Host, ".", 3)[2],
+		HttpRoutingName:      strings.SplitN(routerURL.Host, ".", 3)[0],
+		HttpDeliveryServices: []string{"0"},
+		DurationSeconds:      60,
+		QPS:                  50,
+	}
+	stop := make(chan struct{})
+	resultChan, err := DoLoadTest(lt, stop)
+	if err != nil {
+		t.Fatalf("DoLoadTest expected: nil error, actual: %v", err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() { close(stop) })
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-resultChan:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("DoLoadTest expected: results closed after stop, actual: still running")
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package load
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/test/router/data"
+)
+
+// LatencyBucketsUsec are the upper bounds of the latency histogram buckets, in microseconds.
+var LatencyBucketsUsec = []int64{250, 500, 1000, 2500, 5000, 10000, 25000, 50000, 100000, 250000, 500000, 1000000}
+
+// Recorder aggregates the results of a load test into a Report. It's safe for concurrent use.
+type Recorder struct {
+	mutex     sync.Mutex
+	start     time.Time
+	done      bool
+	elapsed   time.Duration
+	latencies []int64
+	dsLatency map[string][]int64
+	report    data.Report
+}
+
+func NewRecorder(start time.Time) *Recorder {
+	return &Recorder{
+		start:     start,
+		dsLatency: map[string][]int64{},
+		report: data.Report{
+			Start:            start,
+			Statuses:         map[string]int{},
+			CacheGroups:      map[string]int{},
+			Caches:           map[string]int{},
+			DeliveryServices: map[string]data.DeliveryServiceReport{},
+		},
+	}
+}
+
+func (r *Recorder) Add(result data.Result) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.report.Requests++
+	ds := r.report.DeliveryServices[result.DeliveryService]
+	if ds.CacheGroups == nil {
+		ds.CacheGroups = map[string]int{}
+	}
+	ds.Requests++
+	if result.Error != "" {
+		r.report.Errors++
+		ds.Errors++
+	}
+
+	if result.Rcode != "" {
+		r.report.Statuses[result.Rcode]++
+	} else if result.Status > 0 {
+		r.report.Statuses[strconv.Itoa(result.Status)]++
+	}
+
+	if result.Cache != "" {
+		r.report.Caches[result.Cache]++
+		cacheGroup := result.CacheGroup
+		if cacheGroup == "" {
+			cacheGroup = "unknown"
+		}
+		r.report.CacheGroups[cacheGroup]++
+		ds.CacheGroups[cacheGroup]++
+	}
+	r.report.DeliveryServices[result.DeliveryService] = ds
+
+	if result.Error == "" {
+		r.latencies = append(r.latencies, result.LatencyUsec)
+		r.dsLatency[result.DeliveryService] = append(r.dsLatency[result.DeliveryService], result.LatencyUsec)
+	}
+}
+
+// Done marks the test finished as of now, so its elapsed time and QPS stop changing.
+func (r *Recorder) Done(now time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if !r.done {
+		r.done = true
+		r.elapsed = now.Sub(r.start)
+	}
+}
+
+// Report returns the summary of the results so far, as of now.
+func (r *Recorder) Report(now time.Time) data.Report {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	report := r.report
+	elapsed := r.elapsed
+	if !r.done {
+		elapsed = now.Sub(r.start)
+	}
+	report.Running = !r.done
+	report.Elapsed = elapsed.Seconds()
+	if elapsed > 0 {
+		report.QPS = float64(report.Requests) / elapsed.Seconds()
+	}
+	report.Latency = summarizeLatencies(r.latencies)
+
+	report.Statuses = copyCounts(r.report.Statuses)
+	report.CacheGroups = copyCounts(r.report.CacheGroups)
+	report.Caches = copyCounts(r.report.Caches)
+	report.DeliveryServices = map[string]data.DeliveryServiceReport{}
+	for name, ds := range r.report.DeliveryServices {
+		ds.CacheGroups = copyCounts(ds.CacheGroups)
+		ds.Latency = summarizeLatencies(r.dsLatency[name])
+		report.DeliveryServices[name] = ds
+	}
+	return report
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	c := make(map[string]int, len(counts))
+	for k, v := range counts {
+		c[k] = v
+	}
+	return c
+}
+
+func summarizeLatencies(latencies []int64) data.LatencySummary {
+	summary := data.LatencySummary{Histogram: make([]data.HistogramBucket, len(LatencyBucketsUsec)+1)}
+	for i, max := range LatencyBucketsUsec {
+		summary.Histogram[i].MaxUsec = max
+	}
+	summary.Histogram[len(LatencyBucketsUsec)].MaxUsec = -1
+	if len(latencies) == 0 {
+		return summary
+	}
+
+	sorted := make([]int64, len(latencies))
+	copy(sorted, latencies)
+	sort.Sort(latencySlice(sorted))
+
+	sum := int64(0)
+	for _, latency := range sorted {
+		sum += latency
+		bucket := sort.Search(len(LatencyBucketsUsec), func(i int) bool { return LatencyBucketsUsec[i] >= latency })
+		summary.Histogram[bucket].Count++
+	}
+
+	summary.MinUsec = sorted[0]
+	summary.MaxUsec = sorted[len(sorted)-1]
+	summary.MeanUsec = sum / int64(len(sorted))
+	summary.P50Usec = percentile(sorted, 50)
+	summary.P90Usec = percentile(sorted, 90)
+	summary.P99Usec = percentile(sorted, 99)
+	return summary
+}
+
+type latencySlice []int64
+
+func (s latencySlice) Len() int           { return len(s) }
+func (s latencySlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s latencySlice) Less(i, j int) bool { return s[i] < s[j] }
+
+// percentile returns the nearest-rank percentile p of sorted.
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
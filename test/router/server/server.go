@@ -24,20 +24,26 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"github.com/Comcast/traffic_control/test/router/data"
-	"github.com/Comcast/traffic_control/test/router/load"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/test/router/data"
+	"github.com/apache/incubator-trafficcontrol/test/router/load"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 	"net/http/cookiejar"
+	"net/url"
+	"sync"
+	"time"
 )
 
-var done chan struct{}
-var resultChan chan data.HttpResult
-var results []data.HttpResult
+const progressInterval = 500 * time.Millisecond
+
+var testMutex sync.Mutex
+var stop chan struct{}
+var results []data.Result
+var recorder *load.Recorder
 
 type credentials struct {
-	User     string `json: "u"`
+	User     string `json:"u"`
 	Password string `json:"p"`
 }
 
@@ -49,11 +55,11 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "GET" && r.URL.Path == "/report" {
 		data, err := ioutil.ReadFile("foo.json")
 		if err != nil {
-			fmt.Fprintf(w, err.Error())
+			fmt.Fprint(w, err.Error())
 			return
 		}
 
-		fmt.Fprintf(w, string(data))
+		w.Write(data)
 	}
 
 	if r.Method == "POST" && r.URL.Path == "/api/1.2/user/login" {
@@ -67,13 +73,12 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		url := fmt.Sprintf("https://%v/api/1.2/user/login", r.URL.Query().Get("opsHost"))
 
 		resp, err := client.Post(url, "application/json", r.Body)
-		defer resp.Body.Close();
-
 		if err != nil {
 			fmt.Println("Failed to proxy authentication to traffic ops", err.Error())
 			w.WriteHeader(500)
 			return
 		}
+		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
 			fmt.Println("Dangit!!!! got non 200", resp)
@@ -140,13 +145,12 @@ func handler(w http.ResponseWriter, r *http.Request) {
 		fmt.Println(client.Jar)
 
 		resp, err := client.Get(urlString)
-		defer resp.Body.Close()
-
 		if err != nil {
 			fmt.Println("Failed to proxy ", r.URL, "to host", r.URL.Query().Get("opsHost"))
 			w.WriteHeader(500)
 			return
 		}
+		defer resp.Body.Close()
 
 		buf, err := ioutil.ReadAll(resp.Body)
 
@@ -161,42 +165,176 @@ func handler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == "POST" && r.URL.Path == "/loadtest" {
-		results = nil
 		var lt load.LoadTest
 		err := json.NewDecoder(r.Body).Decode(&lt)
 
 		if err != nil {
 			fmt.Println("Failed to unmarshal Json!", err.Error())
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if len(lt.CacheGroups) == 0 && lt.OpsHost != "" {
+			cacheGroups, err := getCacheGroups(lt.OpsHost, lt.Cdn)
+			if err != nil {
+				fmt.Println("Failed getting cachegroups, results won't include them:", err.Error())
+			}
+			lt.CacheGroups = cacheGroups
 		}
 
-		done = make(chan struct{})
-		resultChan = load.DoLoadTest(lt, done)
+		testMutex.Lock()
+		defer testMutex.Unlock()
+
+		if stop != nil {
+			close(stop)
+		}
+		stop = make(chan struct{})
+
+		resultChan, err := load.DoLoadTest(lt, stop)
+		if err != nil {
+			stop = nil
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+
+		results = nil
+		testRecorder := load.NewRecorder(time.Now())
+		recorder = testRecorder
 
 		go func() {
-			for {
-				select {
-				case result := <-resultChan:
+			for result := range resultChan {
+				testRecorder.Add(result)
+				testMutex.Lock()
+				if recorder == testRecorder {
 					results = append(results, result)
-				case <-done:
-					return
 				}
+				testMutex.Unlock()
 			}
+			testRecorder.Done(time.Now())
 		}()
 
 		w.Write([]byte(`{"status":"started"}`))
 	}
 
+	if r.Method == "DELETE" && r.URL.Path == "/loadtest" {
+		testMutex.Lock()
+		if stop != nil {
+			close(stop)
+			stop = nil
+		}
+		testMutex.Unlock()
+		w.Write([]byte(`{"status":"stopped"}`))
+	}
+
 	if r.Method == "GET" && r.URL.Path == "/loadtest" {
+		testMutex.Lock()
 		b, _ := json.MarshalIndent(results, "", "  ")
+		testMutex.Unlock()
 
 		w.Header().Add("Content-Type", "application/json")
 		w.Write(b)
 	}
+
+	if r.Method == "GET" && r.URL.Path == "/loadtest/report" {
+		report, ok := currentReport()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		b, _ := json.MarshalIndent(report, "", "  ")
+		w.Header().Add("Content-Type", "application/json")
+		if r.URL.Query().Get("download") != "" {
+			w.Header().Add("Content-Disposition", fmt.Sprintf(`attachment; filename="loadtest-%v.json"`, report.Start.Format("20060102-150405")))
+		}
+		w.Write(b)
+	}
+
+	if r.Method == "GET" && r.URL.Path == "/loadtest/progress" {
+		streamProgress(w, r)
+	}
 }
 
-func main() {
-	done = make(chan struct{})
+func currentReport() (data.Report, bool) {
+	testMutex.Lock()
+	testRecorder := recorder
+	testMutex.Unlock()
+
+	if testRecorder == nil {
+		return data.Report{}, false
+	}
+	return testRecorder.Report(time.Now()), true
+}
+
+// streamProgress sends the report of the current test as server-sent events, until the test is done or the client goes away.
+func streamProgress(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		report, ok := currentReport()
+		if ok {
+			b, _ := json.Marshal(report)
+			fmt.Fprintf(w, "event: progress\ndata: %s\n\n", b)
+			flusher.Flush()
+			if !report.Running {
+				fmt.Fprintf(w, "event: done\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// getCacheGroups fetches the CRConfig snapshot of cdn from Traffic Ops, and returns the cachegroups of its caches.
+func getCacheGroups(opsHost string, cdn string) (map[string]string, error) {
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	u, err := url.Parse(fmt.Sprintf("https://%v/api/1.2/cdns/%v/snapshot", opsHost, url.PathEscape(cdn)))
+	if err != nil {
+		return nil, err
+	}
+
+	client.Jar, err = cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	client.Jar.SetCookies(u, opsCookies)
 
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting snapshot of %v from %v: received http status %v", cdn, opsHost, resp.StatusCode)
+	}
+
+	snapshot := struct {
+		Response tc.CRConfig `json:"response"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return nil, err
+	}
+	return load.CacheGroupsFromCRConfig(snapshot.Response), nil
+}
+
+func main() {
 	http.HandleFunc("/", handler)
 	http.ListenAndServe(":8888", nil)
 }
@@ -0,0 +1,420 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package cachefarm simulates a farm of caches running the ATS astats plugin, serving astats JSON for thousands of virtual caches from one process, for testing Traffic Monitor without real caches.
+//
+// Each cache's counters, including its remap stats, proc.net.dev bytes and load average, evolve over time at the cache's configured rates. Scenarios, such as a cache going dark, saturating its bandwidth, or a delivery service returning a burst of 5xx errors, may be scripted with Events.
+package cachefarm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_monitor/cache"
+)
+
+const DefaultInterface = "eth0"
+const DefaultSpeedMbps = 10000
+
+// Jitter is the maximum fraction by which a cache's rates randomly vary from their configured values, on each update.
+const Jitter = 0.05
+
+// Config is the configuration of a simulated cache.
+type Config struct {
+	HostName string `json:"hostName"`
+	// FQDN is the fully qualified domain name of the cache. Requests with a Host of either the FQDN or HostName are served by the cache. Defaults to the HostName.
+	FQDN       string `json:"fqdn"`
+	CacheGroup string `json:"cacheGroup"`
+	Profile    string `json:"profile"`
+	// Interface is the name of the cache's network interface, in the proc.net.dev stat. Defaults to DefaultInterface.
+	Interface string `json:"interface"`
+	// SpeedMbps is the speed of the cache's network interface. Defaults to DefaultSpeedMbps.
+	SpeedMbps int `json:"speedMbps"`
+	// Kbps is the bandwidth the cache sends.
+	Kbps int64 `json:"kbps"`
+	// RequestsPerSecond is the rate of requests of each delivery service.
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	LoadAvg           float64 `json:"loadAvg"`
+	// DeliveryServices is the list of delivery service remap domains the cache serves, for example "ds1.cdn.example.net". The remap stats are named by the cache's host name followed by the domain, as Traffic Monitor expects for HTTP delivery services.
+	DeliveryServices []string `json:"deliveryServices"`
+}
+
+// Generate returns the configs of n caches named by the prefix followed by their number, in the given domain, spread across the given number of cache groups. Their other fields are from the given config.
+func Generate(n int, prefix string, domain string, cacheGroups int, cfg Config) []Config {
+	if cacheGroups < 1 {
+		cacheGroups = 1
+	}
+	cfgs := make([]Config, 0, n)
+	for i := 0; i < n; i++ {
+		c := cfg
+		c.HostName = prefix + strconv.Itoa(i)
+		c.FQDN = c.HostName + "." + domain
+		c.CacheGroup = "cg" + strconv.Itoa(i%cacheGroups)
+		c.DeliveryServices = append([]string(nil), cfg.DeliveryServices...)
+		cfgs = append(cfgs, c)
+	}
+	return cfgs
+}
+
+// remapCounters are the remap stats of one delivery service on a cache.
+type remapCounters struct {
+	InBytes   float64
+	OutBytes  float64
+	Status2xx float64
+	Status3xx float64
+	Status4xx float64
+	Status5xx float64
+}
+
+// Cache is a simulated cache. Its counters are advanced lazily, when they're read.
+type Cache struct {
+	m            sync.Mutex
+	cfg          Config
+	rand         *rand.Rand
+	updated      time.Time
+	bytesIn      float64
+	bytesOut     float64
+	requests     float64
+	remap        map[string]*remapCounters
+	kbps         int64
+	loadAvg      float64
+	dark         bool
+	notAvailable bool
+	// errorRates is the fraction of requests of each delivery service which return a 5xx.
+	errorRates map[string]float64
+}
+
+// CacheStatus is the current simulated state of a cache.
+type CacheStatus struct {
+	HostName     string             `json:"hostName"`
+	Kbps         int64              `json:"kbps"`
+	LoadAvg      float64            `json:"loadAvg"`
+	Dark         bool               `json:"dark"`
+	NotAvailable bool               `json:"notAvailable"`
+	ErrorRates   map[string]float64 `json:"errorRates,omitempty"`
+}
+
+func newCache(cfg Config, seed int64, now time.Time) *Cache {
+	if cfg.FQDN == "" {
+		cfg.FQDN = cfg.HostName
+	}
+	if cfg.Interface == "" {
+		cfg.Interface = DefaultInterface
+	}
+	if cfg.SpeedMbps == 0 {
+		cfg.SpeedMbps = DefaultSpeedMbps
+	}
+	c := &Cache{
+		cfg:        cfg,
+		rand:       rand.New(rand.NewSource(seed)),
+		updated:    now,
+		remap:      map[string]*remapCounters{},
+		kbps:       cfg.Kbps,
+		loadAvg:    cfg.LoadAvg,
+		errorRates: map[string]float64{},
+	}
+	for _, ds := range cfg.DeliveryServices {
+		c.remap[ds] = &remapCounters{}
+	}
+	return c
+}
+
+// jitter returns a random factor within Jitter of 1. It must be called with the mutex held.
+func (c *Cache) jitter() float64 {
+	return 1 + (c.rand.Float64()*2-1)*Jitter
+}
+
+// advance advances the cache's counters to the given time, at its current rates. It must be called with the mutex held.
+func (c *Cache) advance(now time.Time) {
+	elapsed := now.Sub(c.updated).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	c.updated = now
+
+	bytes := float64(c.kbps) * 1000 / 8 * elapsed * c.jitter()
+	c.bytesOut += bytes
+	c.bytesIn += bytes / 50
+
+	if len(c.remap) == 0 {
+		return
+	}
+	dsBytes := bytes / float64(len(c.remap))
+	for ds, counters := range c.remap {
+		requests := c.cfg.RequestsPerSecond * elapsed * c.jitter()
+		errors := requests * c.errorRates[ds]
+		ok := requests - errors
+		c.requests += requests
+		counters.InBytes += dsBytes / 50
+		counters.OutBytes += dsBytes
+		counters.Status2xx += ok * 0.97
+		counters.Status3xx += ok * 0.02
+		counters.Status4xx += ok * 0.01
+		counters.Status5xx += errors
+	}
+}
+
+// Astats returns the cache's astats at the given time. If systemOnly, only the system stats are returned, as the astats plugin does for the application=system query parameter.
+func (c *Cache) Astats(now time.Time, systemOnly bool) cache.Astats {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.advance(now)
+
+	ats := map[string]interface{}{}
+	if !systemOnly {
+		ats["proxy.process.http.completed_requests"] = int64(c.requests)
+		for ds, counters := range c.remap {
+			prefix := "plugin.remap_stats." + c.cfg.HostName + "." + ds + "."
+			ats[prefix+"in_bytes"] = int64(counters.InBytes)
+			ats[prefix+"out_bytes"] = int64(counters.OutBytes)
+			ats[prefix+"status_2xx"] = int64(counters.Status2xx)
+			ats[prefix+"status_3xx"] = int64(counters.Status3xx)
+			ats[prefix+"status_4xx"] = int64(counters.Status4xx)
+			ats[prefix+"status_5xx"] = int64(counters.Status5xx)
+		}
+	}
+
+	loadAvg := c.loadAvg * c.jitter()
+	return cache.Astats{
+		Ats: ats,
+		System: cache.AstatsSystem{
+			InfName:      c.cfg.Interface,
+			InfSpeed:     c.cfg.SpeedMbps,
+			ProcNetDev:   fmt.Sprintf("%s:%d %d    0    0    0     0          0   0 %d %d    0    0    0     0       0          0", c.cfg.Interface, int64(c.bytesIn), int64(c.bytesIn/1000), int64(c.bytesOut), int64(c.bytesOut/1000)),
+			ProcLoadavg:  fmt.Sprintf("%.2f %.2f %.2f 1/863 1421", loadAvg, c.loadAvg, c.loadAvg),
+			NotAvailable: c.notAvailable,
+		},
+	}
+}
+
+// Status returns the current simulated state of the cache.
+func (c *Cache) Status() CacheStatus {
+	c.m.Lock()
+	defer c.m.Unlock()
+	errorRates := map[string]float64{}
+	for ds, rate := range c.errorRates {
+		errorRates[ds] = rate
+	}
+	return CacheStatus{HostName: c.cfg.HostName, Kbps: c.kbps, LoadAvg: c.loadAvg, Dark: c.dark, NotAvailable: c.notAvailable, ErrorRates: errorRates}
+}
+
+// Config returns the cache's config.
+func (c *Cache) Config() Config {
+	return c.cfg
+}
+
+// isDark returns whether the cache has gone dark, and isn't responding.
+func (c *Cache) isDark() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.dark
+}
+
+// Farm is a farm of simulated caches. It serves each cache's astats by the request's Host header, and a control API to list the caches and apply Events.
+type Farm struct {
+	caches []*Cache
+	byHost map[string]*Cache
+	now    func() time.Time
+}
+
+// New returns a new Farm of caches with the given configs. The seed seeds the random variation of the caches' rates, so a Farm's counters are reproducible.
+func New(cfgs []Config, seed int64) *Farm {
+	f := &Farm{byHost: map[string]*Cache{}, now: time.Now}
+	now := f.now()
+	for i, cfg := range cfgs {
+		c := newCache(cfg, seed+int64(i), now)
+		f.caches = append(f.caches, c)
+		f.byHost[c.cfg.HostName] = c
+		f.byHost[c.cfg.FQDN] = c
+	}
+	return f
+}
+
+// Caches returns the caches of the farm, in the order of their configs.
+func (f *Farm) Caches() []*Cache {
+	return f.caches
+}
+
+// Cache returns the cache with the given host name or FQDN, and whether it exists.
+func (f *Farm) Cache(host string) (*Cache, bool) {
+	c, ok := f.byHost[host]
+	return c, ok
+}
+
+// ContentServers returns the farm's caches as CRConfig content servers, all with the given IP and port, for serving from a Traffic Ops snapshot. Traffic Monitor polls the caches from the CRConfig, with the Host header of each cache's FQDN, so every cache may share one address.
+func (f *Farm) ContentServers(ip string, port int) map[string]tc.CRConfigTrafficOpsServer {
+	servers := map[string]tc.CRConfigTrafficOpsServer{}
+	for _, c := range f.caches {
+		cfg := c.cfg
+		status := tc.CRConfigServerStatus(tc.CacheStatusReported)
+		serverType := "EDGE"
+		servers[cfg.HostName] = tc.CRConfigTrafficOpsServer{
+			CacheGroup:    &cfg.CacheGroup,
+			Fqdn:          &cfg.FQDN,
+			InterfaceName: &cfg.Interface,
+			Ip:            &ip,
+			Port:          &port,
+			Profile:       &cfg.Profile,
+			ServerStatus:  &status,
+			ServerType:    &serverType,
+		}
+	}
+	return servers
+}
+
+// ServeHTTP serves the astats of the cache named by the request's Host, or the control API.
+func (f *Farm) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/_farm/caches":
+		f.serveCaches(w, r)
+		return
+	case "/_farm/events":
+		f.serveEvents(w, r)
+		return
+	}
+
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	c, ok := f.byHost[host]
+	if !ok {
+		http.Error(w, "no cache '"+host+"'", http.StatusNotFound)
+		return
+	}
+	f.serveCache(c, w, r)
+}
+
+// serveCache serves the astats of the given cache. If the cache is dark, the connection is closed without a response.
+func (f *Farm) serveCache(c *Cache, w http.ResponseWriter, r *http.Request) {
+	if c.isDark() {
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if r.URL.Path != "/_astats" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	bts, err := json.Marshal(c.Astats(f.now(), r.URL.Query().Get("application") == "system"))
+	if err != nil {
+		http.Error(w, "serializing astats: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/json")
+	w.Write(bts)
+}
+
+// serveCaches serves the status of every cache, sorted by host name.
+func (f *Farm) serveCaches(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]CacheStatus, 0, len(f.caches))
+	for _, c := range f.caches {
+		statuses = append(statuses, c.Status())
+	}
+	sort.Sort(cacheStatusesByName(statuses))
+	bts, err := json.Marshal(statuses)
+	if err != nil {
+		http.Error(w, "serializing caches: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(bts)
+}
+
+// serveEvents applies the POSTed JSON array of events, ignoring their At offsets.
+func (f *Farm) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	events := []Event{}
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		http.Error(w, "decoding events: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, e := range events {
+		if err := e.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, e := range events {
+		f.Apply(e)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type cacheStatusesByName []CacheStatus
+
+func (s cacheStatusesByName) Len() int           { return len(s) }
+func (s cacheStatusesByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s cacheStatusesByName) Less(i, j int) bool { return s[i].HostName < s[j].HostName }
+
+// listeners is a set of listeners, closed together.
+type listeners []net.Listener
+
+func (ls listeners) Close() error {
+	var err error
+	for _, l := range ls {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// ServePorts serves each cache on its own port of the given IP, starting at basePort, in the order of the farm's caches, for pollers which address caches by port rather than Host. The returned Closer stops serving.
+func (f *Farm) ServePorts(ip string, basePort int) (io.Closer, error) {
+	ls := listeners{}
+	for i, c := range f.caches {
+		l, err := net.Listen("tcp", net.JoinHostPort(ip, strconv.Itoa(basePort+i)))
+		if err != nil {
+			ls.Close()
+			return nil, fmt.Errorf("listening for cache %s: %v", c.cfg.HostName, err)
+		}
+		ls = append(ls, l)
+		c := c
+		go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { f.serveCache(c, w, r) }))
+	}
+	return ls, nil
+}
+
+// match returns whether the name matches the pattern, which is a path.Match pattern. An empty pattern matches everything.
+func match(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, _ := path.Match(pattern, name) // patterns are validated by Event.Validate
+	return matched
+}
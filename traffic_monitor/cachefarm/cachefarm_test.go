@@ -0,0 +1,300 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cachefarm
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/traffic_monitor/cache"
+	"github.com/apache/incubator-trafficcontrol/traffic_monitor/health"
+)
+
+func testConfigs() []Config {
+	return Generate(3, "edge-", "cdn.example.net", 2, Config{
+		Profile:           "EDGE",
+		SpeedMbps:         1000,
+		Kbps:              100000,
+		RequestsPerSecond: 100,
+		LoadAvg:           0.5,
+		DeliveryServices:  []string{"ds1.cdn.example.net", "ds2.cdn.example.net"},
+	})
+}
+
+// newTestFarm returns a farm whose clock is the given time, which the test may advance.
+func newTestFarm(clock *time.Time) *Farm {
+	f := New(testConfigs(), 42)
+	f.now = func() time.Time { return *clock }
+	for _, c := range f.caches {
+		c.updated = *clock
+	}
+	return f
+}
+
+// poll requests the astats of the given host from the farm's handler, and returns the result as Traffic Monitor parses it.
+func poll(t *testing.T, f *Farm, host string, query string, now time.Time) cache.Result {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://127.0.0.1/_astats?"+query, nil)
+	r.Host = host
+	f.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("poll %v expected: %v, actual: %v %v", host, http.StatusOK, w.Code, w.Body.String())
+	}
+	astats, err := cache.Unmarshal(w.Body.Bytes())
+	if err != nil {
+		t.Fatalf("poll %v expected: astats JSON, actual: %v", host, err)
+	}
+	return cache.Result{ID: "test", Astats: astats, Time: now}
+}
+
+// kbps returns the bandwidth between the two polls, as Traffic Monitor computes it.
+func kbps(t *testing.T, prev, next *cache.Result) int64 {
+	health.GetVitals(prev, nil, nil)
+	health.GetVitals(next, prev, nil)
+	if next.Error != nil {
+		t.Fatalf("GetVitals expected: nil error, actual: %v", next.Error)
+	}
+	return next.Vitals.KbpsOut
+}
+
+func inJitter(actual, expected float64) bool {
+	return actual >= expected*(1-Jitter) && actual <= expected*(1+Jitter)
+}
+
+func TestAstats(t *testing.T) {
+	clock := time.Now()
+	f := newTestFarm(&clock)
+	clock = clock.Add(time.Second) // Traffic Monitor doesn't compute bandwidth from a previous poll of 0 bytes
+
+	first := poll(t, f, "edge-1.cdn.example.net:8080", "application=&inf.name=eth0", clock)
+	clock = clock.Add(10 * time.Second)
+	second := poll(t, f, "edge-1", "application=&inf.name=eth0", clock)
+
+	if actual := kbps(t, &first, &second); !inJitter(float64(actual), 100000) {
+		t.Errorf("astats kbps expected: 100000 ±%v, actual: %v", Jitter, actual)
+	}
+	if second.Vitals.LoadAvg <= 0 || second.Vitals.MaxKbpsOut != 1000000 {
+		t.Errorf("astats vitals expected: load average and 1000000 max kbps, actual: %+v", second.Vitals)
+	}
+
+	stat := "plugin.remap_stats.edge-1.ds1.cdn.example.net.status_2xx"
+	status2xx, ok := second.Astats.Ats[stat].(float64)
+	if !ok || !inJitter(status2xx, 11*100*0.97) {
+		t.Errorf("astats %v expected: %v ±%v, actual: %v", stat, 11*100*0.97, Jitter, second.Astats.Ats[stat])
+	}
+	if status5xx := second.Astats.Ats["plugin.remap_stats.edge-1.ds1.cdn.example.net.status_5xx"]; status5xx != float64(0) {
+		t.Errorf("astats status_5xx expected: 0, actual: %v", status5xx)
+	}
+
+	system := poll(t, f, "edge-1", "application=system&inf.name=eth0", clock)
+	if len(system.Astats.Ats) != 0 || system.Astats.System.InfName != "eth0" {
+		t.Errorf("astats application=system expected: only system stats, actual: %+v", system.Astats)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "http://127.0.0.1/_astats", nil)
+	r.Host = "nonexistent"
+	f.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("astats unknown host expected: %v, actual: %v", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	clock := time.Now()
+	f := newTestFarm(&clock)
+
+	f.Apply(Event{Caches: "edge-[01]", Action: ActionSaturate})
+	f.Apply(Event{Caches: "edge-2", Action: ActionNotAvailable})
+	f.Apply(Event{DeliveryServices: "ds2.*", Action: ActionErrors, Rate: 0.5})
+	clock = clock.Add(time.Second)
+
+	first := poll(t, f, "edge-0", "", clock)
+	clock = clock.Add(10 * time.Second)
+	second := poll(t, f, "edge-0", "", clock)
+	if actual := kbps(t, &first, &second); !inJitter(float64(actual), 1000000) {
+		t.Errorf("saturated kbps expected: 1000000 ±%v, actual: %v", Jitter, actual)
+	}
+	if status5xx := second.Astats.Ats["plugin.remap_stats.edge-0.ds1.cdn.example.net.status_5xx"]; status5xx != float64(0) {
+		t.Errorf("ds1 status_5xx expected: 0, actual: %v", status5xx)
+	}
+	if status5xx, _ := second.Astats.Ats["plugin.remap_stats.edge-0.ds2.cdn.example.net.status_5xx"].(float64); !inJitter(status5xx, 11*100*0.5) {
+		t.Errorf("ds2 status_5xx expected: %v ±%v, actual: %v", 11*100*0.5, Jitter, status5xx)
+	}
+	if result := poll(t, f, "edge-2", "", clock); !result.Astats.System.NotAvailable {
+		t.Errorf("notAvailable expected: true, actual: false")
+	}
+
+	f.Apply(Event{Action: ActionReset})
+	for _, c := range f.Caches() {
+		if status := c.Status(); status.Kbps != 100000 || status.NotAvailable || len(status.ErrorRates) != 0 {
+			t.Errorf("reset %v expected: configured behavior, actual: %+v", status.HostName, status)
+		}
+	}
+
+	f.Apply(Event{Caches: "edge-0", Action: ActionLoadAvg, LoadAvg: 40, For: Duration(10 * time.Millisecond)})
+	if status, _ := f.Cache("edge-0"); status.Status().LoadAvg != 40 {
+		t.Errorf("loadavg expected: 40, actual: %v", status.Status().LoadAvg)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if status, _ := f.Cache("edge-0"); status.Status().LoadAvg != 0.5 {
+		t.Errorf("loadavg after For expected: reverted to 0.5, actual: %v", status.Status().LoadAvg)
+	}
+}
+
+func TestDark(t *testing.T) {
+	f := New(testConfigs(), 42)
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	get := func(host string) (*http.Response, error) {
+		req, _ := http.NewRequest("GET", srv.URL+"/_astats", nil)
+		req.Host = host
+		return http.DefaultClient.Do(req)
+	}
+
+	if resp, err := get("edge-0"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("astats expected: 200, actual: %v", err)
+	} else {
+		resp.Body.Close()
+	}
+
+	f.Apply(Event{Caches: "edge-0", Action: ActionDark})
+	if resp, err := get("edge-0"); err == nil {
+		resp.Body.Close()
+		t.Errorf("dark cache expected: connection error, actual: %v", resp.Status)
+	}
+	if resp, err := get("edge-1"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("other cache expected: 200, actual: %v", err)
+	} else {
+		resp.Body.Close()
+	}
+}
+
+func TestControlAPI(t *testing.T) {
+	f := New(testConfigs(), 42)
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/_farm/events", "application/json", strings.NewReader(`[{"caches": "edge-1", "action": "bandwidth", "kbps": 5}]`))
+	if err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST events expected: %v, actual: %v %v", http.StatusNoContent, resp, err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Post(srv.URL+"/_farm/events", "application/json", strings.NewReader(`[{"action": "explode"}]`))
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST unknown event expected: %v, actual: %v %v", http.StatusBadRequest, resp, err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/_farm/caches")
+	if err != nil {
+		t.Fatalf("GET caches expected: nil error, actual: %v", err)
+	}
+	defer resp.Body.Close()
+	statuses := []CacheStatus{}
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil || len(statuses) != 3 || statuses[1].HostName != "edge-1" || statuses[1].Kbps != 5 || statuses[0].Kbps != 100000 {
+		t.Errorf("GET caches expected: edge-1 at 5 kbps, actual: %+v %v", statuses, err)
+	}
+}
+
+func TestReadScenario(t *testing.T) {
+	s, err := ReadScenario(strings.NewReader(`{"events": [{"at": "1m", "for": "30s", "caches": "edge-*", "action": "dark"}]}`))
+	if err != nil {
+		t.Fatalf("ReadScenario expected: nil error, actual: %v", err)
+	}
+	if len(s.Events) != 1 || s.Events[0].At != Duration(time.Minute) || s.Events[0].For != Duration(30*time.Second) {
+		t.Errorf("ReadScenario expected: 1m dark event for 30s, actual: %+v", s.Events)
+	}
+
+	for _, bad := range []string{
+		`{"events": [{"action": "explode"}]}`,
+		`{"events": [{"at": 60, "action": "dark"}]}`,
+		`{"events": [{"caches": "[", "action": "dark"}]}`,
+		`{"events": [{"action": "5xx", "rate": 2}]}`,
+	} {
+		if _, err := ReadScenario(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadScenario %v expected: error, actual: nil", bad)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	f := New(testConfigs(), 42)
+	f.Run(Scenario{Events: []Event{
+		{At: Duration(20 * time.Millisecond), Caches: "edge-2", Action: ActionBandwidth, Kbps: 7},
+		{At: Duration(10 * time.Millisecond), Caches: "edge-2", Action: ActionBandwidth, Kbps: 3},
+	}}, nil)
+	if c, _ := f.Cache("edge-2"); c.Status().Kbps != 7 {
+		t.Errorf("Run expected: events applied in At order, actual: %v kbps", c.Status().Kbps)
+	}
+
+	stop := make(chan struct{})
+	close(stop)
+	f.Run(Scenario{Events: []Event{{At: Duration(time.Hour), Action: ActionDark}}}, stop)
+	if c, _ := f.Cache("edge-2"); c.Status().Dark {
+		t.Errorf("Run stopped expected: event not applied, actual: applied")
+	}
+}
+
+func TestServePorts(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	basePort := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	f := New(testConfigs()[:1], 42)
+	closer, err := f.ServePorts("127.0.0.1", basePort)
+	if err != nil {
+		t.Skipf("port %v unavailable: %v", basePort, err)
+	}
+	defer closer.Close()
+
+	resp, err := http.Get("http://127.0.0.1:" + strconv.Itoa(basePort) + "/_astats")
+	if err != nil {
+		t.Fatalf("GET port expected: nil error, actual: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if astats, err := cache.Unmarshal(body); err != nil || astats.Ats["plugin.remap_stats.edge-0.ds1.cdn.example.net.out_bytes"] == nil {
+		t.Errorf("GET port expected: edge-0 astats, actual: %s %v", body, err)
+	}
+}
+
+func TestContentServers(t *testing.T) {
+	servers := New(testConfigs(), 42).ContentServers("127.0.0.1", 8080)
+	server, ok := servers["edge-1"]
+	if len(servers) != 3 || !ok {
+		t.Fatalf("ContentServers expected: 3 servers with edge-1, actual: %+v", servers)
+	}
+	if *server.Fqdn != "edge-1.cdn.example.net" || *server.CacheGroup != "cg1" || *server.Port != 8080 || *server.InterfaceName != DefaultInterface || *server.Ip != "127.0.0.1" {
+		t.Errorf("ContentServers edge-1 expected: FQDN, cache group, port, interface and IP, actual: %+v", server)
+	}
+}
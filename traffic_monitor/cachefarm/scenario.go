@@ -0,0 +1,230 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package cachefarm
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Action is a change to the behavior of caches, applied by an Event.
+type Action string
+
+const (
+	// ActionDark makes caches stop responding, closing connections without a response.
+	ActionDark = Action("dark")
+	// ActionNotAvailable makes caches report system.notAvailable.
+	ActionNotAvailable = Action("notAvailable")
+	// ActionSaturate makes caches send at their full interface speed, leaving no available bandwidth, below any MinFreeKbps.
+	ActionSaturate = Action("saturate")
+	// ActionBandwidth makes caches send at the Event's Kbps.
+	ActionBandwidth = Action("bandwidth")
+	// ActionErrors makes the Event's Rate fraction of requests of the Event's delivery services return 5xx errors.
+	ActionErrors = Action("5xx")
+	// ActionLoadAvg sets caches' load average to the Event's LoadAvg.
+	ActionLoadAvg = Action("loadavg")
+	// ActionReset restores caches to their configured behavior.
+	ActionReset = Action("reset")
+)
+
+// Duration is a time.Duration which is serialized as a duration string, such as "1m30s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(bts []byte) error {
+	s := ""
+	if err := json.Unmarshal(bts, &s); err != nil {
+		return errors.New("duration must be a string, such as \"1m30s\": " + err.Error())
+	}
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(dur)
+	return nil
+}
+
+// Event is a scripted change to the behavior of the caches matching a pattern.
+type Event struct {
+	// At is the offset from the start of the scenario at which the event is applied.
+	At Duration `json:"at"`
+	// For, if not zero, is how long the event lasts, after which it's reverted, and the caches return to their configured behavior for the action.
+	For Duration `json:"for"`
+	// Caches is a path.Match pattern of the host names of the caches the event applies to. If empty, it applies to all caches.
+	Caches string `json:"caches"`
+	Action Action `json:"action"`
+	// DeliveryServices is a path.Match pattern of the delivery service remap domains an ActionErrors event applies to. If empty, it applies to all delivery services.
+	DeliveryServices string  `json:"deliveryServices"`
+	Kbps             int64   `json:"kbps"`
+	Rate             float64 `json:"rate"`
+	LoadAvg          float64 `json:"loadAvg"`
+}
+
+// Validate returns an error if the event's action is unknown, or its patterns are malformed.
+func (e Event) Validate() error {
+	switch e.Action {
+	case ActionDark, ActionNotAvailable, ActionSaturate, ActionBandwidth, ActionErrors, ActionLoadAvg, ActionReset:
+	default:
+		return errors.New("unknown action '" + string(e.Action) + "'")
+	}
+	if _, err := path.Match(e.Caches, ""); err != nil {
+		return errors.New("malformed caches pattern '" + e.Caches + "': " + err.Error())
+	}
+	if _, err := path.Match(e.DeliveryServices, ""); err != nil {
+		return errors.New("malformed delivery services pattern '" + e.DeliveryServices + "': " + err.Error())
+	}
+	if e.Rate < 0 || e.Rate > 1 {
+		return errors.New("rate must be between 0 and 1")
+	}
+	return nil
+}
+
+// apply applies the event to the cache, or reverts it if revert is true. The counters are advanced to now first, so the change only affects subsequent counts.
+func (c *Cache) apply(e Event, revert bool, now time.Time) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.advance(now)
+
+	switch e.Action {
+	case ActionDark:
+		c.dark = !revert
+	case ActionNotAvailable:
+		c.notAvailable = !revert
+	case ActionSaturate:
+		c.kbps = int64(c.cfg.SpeedMbps) * 1000
+		if revert {
+			c.kbps = c.cfg.Kbps
+		}
+	case ActionBandwidth:
+		c.kbps = e.Kbps
+		if revert {
+			c.kbps = c.cfg.Kbps
+		}
+	case ActionErrors:
+		for ds := range c.remap {
+			if !match(e.DeliveryServices, ds) {
+				continue
+			}
+			if revert {
+				delete(c.errorRates, ds)
+			} else {
+				c.errorRates[ds] = e.Rate
+			}
+		}
+	case ActionLoadAvg:
+		c.loadAvg = e.LoadAvg
+		if revert {
+			c.loadAvg = c.cfg.LoadAvg
+		}
+	case ActionReset:
+		c.dark = false
+		c.notAvailable = false
+		c.kbps = c.cfg.Kbps
+		c.loadAvg = c.cfg.LoadAvg
+		c.errorRates = map[string]float64{}
+	}
+}
+
+// Apply applies the event to the matching caches now, ignoring its At offset. If the event has a For duration, it's reverted after that long. The event should be validated first; events with unknown actions do nothing.
+func (f *Farm) Apply(e Event) {
+	f.apply(e, false)
+	if e.For > 0 && e.Action != ActionReset {
+		time.AfterFunc(time.Duration(e.For), func() { f.apply(e, true) })
+	}
+}
+
+// apply applies or reverts the event on the matching caches.
+func (f *Farm) apply(e Event, revert bool) {
+	now := f.now()
+	for _, c := range f.caches {
+		if match(e.Caches, c.cfg.HostName) {
+			c.apply(e, revert, now)
+		}
+	}
+}
+
+// Scenario is a script of events.
+type Scenario struct {
+	Events []Event `json:"events"`
+}
+
+// ReadScenario reads and validates a JSON scenario, for example:
+//
+//	{"events": [
+//		{"at": "30s", "for": "1m", "caches": "edge-1*", "action": "dark"},
+//		{"at": "1m", "caches": "edge-2", "action": "saturate"},
+//		{"at": "2m", "for": "30s", "deliveryServices": "ds1.*", "action": "5xx", "rate": 0.5}
+//	]}
+func ReadScenario(r io.Reader) (Scenario, error) {
+	s := Scenario{}
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return Scenario{}, errors.New("decoding scenario: " + err.Error())
+	}
+	for i, e := range s.Events {
+		if err := e.Validate(); err != nil {
+			return Scenario{}, errors.New("event " + strconv.Itoa(i) + ": " + err.Error())
+		}
+	}
+	return s, nil
+}
+
+// LoadScenario reads and validates the JSON scenario file. See ReadScenario.
+func LoadScenario(path string) (Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Scenario{}, errors.New("opening scenario: " + err.Error())
+	}
+	defer f.Close()
+	return ReadScenario(f)
+}
+
+type eventsByAt []Event
+
+func (s eventsByAt) Len() int           { return len(s) }
+func (s eventsByAt) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s eventsByAt) Less(i, j int) bool { return s[i].At < s[j].At }
+
+// Run applies the scenario's events to the farm at their offsets from now, until all are applied or stop is closed. It blocks, so it's typically called in its own goroutine.
+func (f *Farm) Run(s Scenario, stop <-chan struct{}) {
+	events := make([]Event, len(s.Events))
+	copy(events, s.Events)
+	sort.Stable(eventsByAt(events))
+
+	start := time.Now()
+	for _, e := range events {
+		timer := time.NewTimer(time.Until(start.Add(time.Duration(e.At))))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		f.Apply(e)
+	}
+}
@@ -20,6 +20,7 @@ package poller
  */
 
 import (
+	"context"
 	"math/rand"
 	"net/http"
 	"os"
@@ -31,6 +32,7 @@ import (
 	"github.com/apache/incubator-trafficcontrol/traffic_monitor/fetcher"
 	"github.com/apache/incubator-trafficcontrol/traffic_monitor/handler"
 	"github.com/apache/incubator-trafficcontrol/traffic_monitor/towrap" // TODO move to common
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/client"
 )
 
 type Poller interface {
@@ -99,6 +101,9 @@ type MonitorConfigPoller struct {
 	OpsConfig        handler.OpsConfig
 }
 
+// MonitorConfigPollTimeout is the longest a single poll of the Traffic Ops monitoring config and CRConfig may take, including retries and failover between Traffic Ops servers, before it's cancelled, so a hung Traffic Ops can't stall the poller indefinitely.
+const MonitorConfigPollTimeout = 30 * time.Second
+
 // Creates and returns a new HttpPoller.
 // If tick is false, HttpPoller.TickChan() will return nil
 func NewMonitorConfig(interval time.Duration) MonitorConfigPoller {
@@ -162,9 +167,12 @@ func (p MonitorConfigPoller) Poll() {
 				log.Warnln("MonitorConfigPoller: skipping this iteration, Session is nil")
 				continue
 			}
-			monitorConfig, err := p.Session.TrafficMonitorConfigMap(p.OpsConfig.CdnName)
+			traceID := client.NewTraceID()
+			ctx, cancel := context.WithTimeout(client.WithTraceID(context.Background(), traceID), MonitorConfigPollTimeout)
+			monitorConfig, err := p.Session.WithContext(ctx).TrafficMonitorConfigMap(p.OpsConfig.CdnName)
+			cancel()
 			if err != nil {
-				log.Errorf("MonitorConfigPoller: %s\n %v\n", err, monitorConfig)
+				log.Errorf("MonitorConfigPoller: trace %s: %s\n %v\n", traceID, err, monitorConfig)
 				continue
 			}
 			p.writeConfig(MonitorCfg{CDN: p.OpsConfig.CdnName, Cfg: *monitorConfig})
@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package tmcheck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	to "github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+// MaxListedNames is the maximum number of caches or delivery services listed in a validation error, so errors stay readable for large CDNs.
+const MaxListedNames = 10
+
+// ValidateCRStatesConsistency validates that the given Traffic Monitor's CRStates contains exactly the caches and delivery services in the given Traffic Ops' CRConfig.
+func ValidateCRStatesConsistency(tmURI string, toClient *to.Session) error {
+	cdn, err := GetCDN(tmURI)
+	if err != nil {
+		return fmt.Errorf("getting CDN from Traffic Monitor: %v", err)
+	}
+	crConfigs := GetCRConfigs(map[tc.CDNName]struct{}{tc.CDNName(cdn): struct{}{}}, toClient)
+	crConfig := crConfigs[tc.CDNName(cdn)]
+	if crConfig.Err != nil {
+		return fmt.Errorf("getting CRConfig: %v", crConfig.Err)
+	}
+	return ValidateCRStatesConsistencyWithCRConfig(tmURI, crConfig.CRConfig)
+}
+
+// ValidateCRStatesConsistencyWithCRConfig validates per ValidateCRStatesConsistency, but saves querying the CRConfig if it's already fetched.
+func ValidateCRStatesConsistencyWithCRConfig(tmURI string, crConfig *tc.CRConfig) error {
+	crStates, err := GetCRStates(tmURI + TrafficMonitorCRStatesPath)
+	if err != nil {
+		return fmt.Errorf("getting CRStates: %v", err)
+	}
+	return ValidateCRStatesConsistencyData(crStates, crConfig)
+}
+
+// ValidateCRStatesConsistencyData validates that the given CRStates contains every cache and delivery service in the given CRConfig, and no others.
+func ValidateCRStatesConsistencyData(crStates *tc.CRStates, crConfig *tc.CRConfig) error {
+	missingCaches, extraCaches := []string{}, []string{}
+	for cacheName := range crConfig.ContentServers {
+		if _, ok := crStates.Caches[tc.CacheName(cacheName)]; !ok {
+			missingCaches = append(missingCaches, cacheName)
+		}
+	}
+	for cacheName := range crStates.Caches {
+		if _, ok := crConfig.ContentServers[string(cacheName)]; !ok {
+			extraCaches = append(extraCaches, string(cacheName))
+		}
+	}
+
+	missingDSes, extraDSes := []string{}, []string{}
+	for dsName := range crConfig.DeliveryServices {
+		if _, ok := crStates.DeliveryService[tc.DeliveryServiceName(dsName)]; !ok {
+			missingDSes = append(missingDSes, dsName)
+		}
+	}
+	for dsName := range crStates.DeliveryService {
+		if _, ok := crConfig.DeliveryServices[string(dsName)]; !ok {
+			extraDSes = append(extraDSes, string(dsName))
+		}
+	}
+
+	errs := []string{}
+	if len(missingCaches) > 0 {
+		errs = append(errs, "caches in CRConfig but not CRStates: "+listNames(missingCaches))
+	}
+	if len(extraCaches) > 0 {
+		errs = append(errs, "caches in CRStates but not CRConfig: "+listNames(extraCaches))
+	}
+	if len(missingDSes) > 0 {
+		errs = append(errs, "Delivery Services in CRConfig but not CRStates: "+listNames(missingDSes))
+	}
+	if len(extraDSes) > 0 {
+		errs = append(errs, "Delivery Services in CRStates but not CRConfig: "+listNames(extraDSes))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// listNames returns the sorted names, joined by commas, truncated to MaxListedNames.
+func listNames(names []string) string {
+	sort.Strings(names)
+	if len(names) > MaxListedNames {
+		return fmt.Sprintf("%v and %v more", strings.Join(names[:MaxListedNames], ", "), len(names)-MaxListedNames)
+	}
+	return strings.Join(names, ", ")
+}
+
+// CRStatesConsistencyValidator is designed to be run as a goroutine, and does not return. It continously validates every `interval`, and calls `onErr` on failure, `onResumeSuccess` when a failure ceases, and `onCheck` on every poll.
+func CRStatesConsistencyValidator(
+	tmURI string,
+	toClient *to.Session,
+	interval time.Duration,
+	grace time.Duration,
+	onErr func(error),
+	onResumeSuccess func(),
+	onCheck func(error),
+) {
+	Validator(tmURI, toClient, interval, grace, onErr, onResumeSuccess, onCheck, ValidateCRStatesConsistency)
+}
+
+// AllMonitorsCRStatesConsistencyValidator is designed to be run as a goroutine, and does not return. It continously validates every `interval`, and calls `onErr` on failure, `onResumeSuccess` when a failure ceases, and `onCheck` on every poll. Note the error passed to `onErr` may be a general validation error not associated with any monitor, in which case the passed `tc.TrafficMonitorName` will be empty.
+func AllMonitorsCRStatesConsistencyValidator(
+	toClient *to.Session,
+	interval time.Duration,
+	includeOffline bool,
+	grace time.Duration,
+	onErr func(tc.TrafficMonitorName, error),
+	onResumeSuccess func(tc.TrafficMonitorName),
+	onCheck func(tc.TrafficMonitorName, error),
+) {
+	AllValidator(toClient, interval, includeOffline, grace, onErr, onResumeSuccess, onCheck, ValidateAllMonitorsCRStatesConsistency)
+}
+
+// ValidateAllMonitorsCRStatesConsistency validates, for all monitors in the given Traffic Ops, CRStates contains exactly the caches and delivery services in the CRConfig.
+func ValidateAllMonitorsCRStatesConsistency(toClient *to.Session, includeOffline bool) (map[tc.TrafficMonitorName]error, error) {
+	servers, err := GetMonitors(toClient, includeOffline)
+	if err != nil {
+		return nil, err
+	}
+
+	crConfigs := GetCRConfigs(GetCDNs(servers), toClient)
+
+	errs := map[tc.TrafficMonitorName]error{}
+	for _, server := range servers {
+		crConfig := crConfigs[tc.CDNName(server.CDNName)]
+		if err := crConfig.Err; err != nil {
+			errs[tc.TrafficMonitorName(server.HostName)] = fmt.Errorf("getting CRConfig: %v", err)
+			continue
+		}
+
+		uri := fmt.Sprintf("http://%s.%s", server.HostName, server.DomainName)
+		errs[tc.TrafficMonitorName(server.HostName)] = ValidateCRStatesConsistencyWithCRConfig(uri, crConfig.CRConfig)
+	}
+	return errs, nil
+}
@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package tmcheck
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	to "github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+// ValidateDSAvailability validates that the delivery service availability in the given Traffic Monitor's CRStates agrees with the availability of the caches assigned to them in the given Traffic Ops' CRConfig.
+func ValidateDSAvailability(tmURI string, toClient *to.Session) error {
+	cdn, err := GetCDN(tmURI)
+	if err != nil {
+		return fmt.Errorf("getting CDN from Traffic Monitor: %v", err)
+	}
+	crConfigs := GetCRConfigs(map[tc.CDNName]struct{}{tc.CDNName(cdn): struct{}{}}, toClient)
+	crConfig := crConfigs[tc.CDNName(cdn)]
+	if crConfig.Err != nil {
+		return fmt.Errorf("getting CRConfig: %v", crConfig.Err)
+	}
+	return ValidateDSAvailabilityWithCRConfig(tmURI, crConfig.CRConfig)
+}
+
+// ValidateDSAvailabilityWithCRConfig validates per ValidateDSAvailability, but saves querying the CRConfig if it's already fetched.
+func ValidateDSAvailabilityWithCRConfig(tmURI string, crConfig *tc.CRConfig) error {
+	crStates, err := GetCRStates(tmURI + TrafficMonitorCRStatesPath)
+	if err != nil {
+		return fmt.Errorf("getting CRStates: %v", err)
+	}
+	return ValidateDSAvailabilityData(crStates, crConfig)
+}
+
+// ValidateDSAvailabilityData validates that no delivery service in the given CRStates is available without any available caches assigned to it in the given CRConfig, and none is unavailable while every cache assigned to it is available.
+func ValidateDSAvailabilityData(crStates *tc.CRStates, crConfig *tc.CRConfig) error {
+	availableWithoutCaches := []string{}
+	unavailableWithCaches := []string{}
+	for dsName, dsState := range crStates.DeliveryService {
+		assigned, available := 0, 0
+		for cacheName, server := range crConfig.ContentServers {
+			if _, ok := server.DeliveryServices[string(dsName)]; !ok {
+				continue
+			}
+			assigned++
+			if crStates.Caches[tc.CacheName(cacheName)].IsAvailable {
+				available++
+			}
+		}
+		if assigned == 0 {
+			continue // delivery services without caches, e.g. steering, have no cache availability to agree with
+		}
+		if dsState.IsAvailable && available == 0 {
+			availableWithoutCaches = append(availableWithoutCaches, string(dsName))
+		} else if !dsState.IsAvailable && available == assigned {
+			unavailableWithCaches = append(unavailableWithCaches, string(dsName))
+		}
+	}
+
+	if len(availableWithoutCaches) > 0 && len(unavailableWithCaches) > 0 {
+		return fmt.Errorf("Delivery Services available with no available caches: %v; Delivery Services unavailable with all caches available: %v", listNames(availableWithoutCaches), listNames(unavailableWithCaches))
+	}
+	if len(availableWithoutCaches) > 0 {
+		return fmt.Errorf("Delivery Services available with no available caches: %v", listNames(availableWithoutCaches))
+	}
+	if len(unavailableWithCaches) > 0 {
+		return fmt.Errorf("Delivery Services unavailable with all caches available: %v", listNames(unavailableWithCaches))
+	}
+	return nil
+}
+
+// DSAvailabilityValidator is designed to be run as a goroutine, and does not return. It continously validates every `interval`, and calls `onErr` on failure, `onResumeSuccess` when a failure ceases, and `onCheck` on every poll.
+func DSAvailabilityValidator(
+	tmURI string,
+	toClient *to.Session,
+	interval time.Duration,
+	grace time.Duration,
+	onErr func(error),
+	onResumeSuccess func(),
+	onCheck func(error),
+) {
+	Validator(tmURI, toClient, interval, grace, onErr, onResumeSuccess, onCheck, ValidateDSAvailability)
+}
+
+// AllMonitorsDSAvailabilityValidator is designed to be run as a goroutine, and does not return. It continously validates every `interval`, and calls `onErr` on failure, `onResumeSuccess` when a failure ceases, and `onCheck` on every poll. Note the error passed to `onErr` may be a general validation error not associated with any monitor, in which case the passed `tc.TrafficMonitorName` will be empty.
+func AllMonitorsDSAvailabilityValidator(
+	toClient *to.Session,
+	interval time.Duration,
+	includeOffline bool,
+	grace time.Duration,
+	onErr func(tc.TrafficMonitorName, error),
+	onResumeSuccess func(tc.TrafficMonitorName),
+	onCheck func(tc.TrafficMonitorName, error),
+) {
+	AllValidator(toClient, interval, includeOffline, grace, onErr, onResumeSuccess, onCheck, ValidateAllMonitorsDSAvailability)
+}
+
+// ValidateAllMonitorsDSAvailability validates, for all monitors in the given Traffic Ops, delivery service availability agrees with the availability of their caches.
+func ValidateAllMonitorsDSAvailability(toClient *to.Session, includeOffline bool) (map[tc.TrafficMonitorName]error, error) {
+	servers, err := GetMonitors(toClient, includeOffline)
+	if err != nil {
+		return nil, err
+	}
+
+	crConfigs := GetCRConfigs(GetCDNs(servers), toClient)
+
+	errs := map[tc.TrafficMonitorName]error{}
+	for _, server := range servers {
+		crConfig := crConfigs[tc.CDNName(server.CDNName)]
+		if err := crConfig.Err; err != nil {
+			errs[tc.TrafficMonitorName(server.HostName)] = fmt.Errorf("getting CRConfig: %v", err)
+			continue
+		}
+
+		uri := fmt.Sprintf("http://%s.%s", server.HostName, server.DomainName)
+		errs[tc.TrafficMonitorName(server.HostName)] = ValidateDSAvailabilityWithCRConfig(uri, crConfig.CRConfig)
+	}
+	return errs, nil
+}
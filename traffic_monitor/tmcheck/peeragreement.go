@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package tmcheck
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	to "github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+// CRStatesOrError contains a CRStates or an error.
+type CRStatesOrError struct {
+	CRStates *tc.CRStates
+	Err      error
+}
+
+// ValidatePeerAgreementData validates that each Traffic Monitor's cache availability agrees with the majority of the Traffic Monitors of its CDN. The given CRStates must all be of monitors of the same CDN. Caches on which the monitors are evenly split have no majority, and are ignored.
+func ValidatePeerAgreementData(crStates map[tc.TrafficMonitorName]CRStatesOrError) map[tc.TrafficMonitorName]error {
+	errs := map[tc.TrafficMonitorName]error{}
+
+	availableVotes := map[tc.CacheName]int{}
+	votes := map[tc.CacheName]int{}
+	for name, states := range crStates {
+		if states.Err != nil {
+			errs[name] = fmt.Errorf("getting CRStates: %v", states.Err)
+			continue
+		}
+		for cacheName, available := range states.CRStates.Caches {
+			votes[cacheName]++
+			if available.IsAvailable {
+				availableVotes[cacheName]++
+			}
+		}
+	}
+
+	for name, states := range crStates {
+		if states.Err != nil {
+			continue
+		}
+		disagreements := []string{}
+		for cacheName, available := range states.CRStates.Caches {
+			unavailableVotes := votes[cacheName] - availableVotes[cacheName]
+			if availableVotes[cacheName] == unavailableVotes {
+				continue
+			}
+			majorityAvailable := availableVotes[cacheName] > unavailableVotes
+			if available.IsAvailable != majorityAvailable {
+				disagreements = append(disagreements, string(cacheName))
+			}
+		}
+		if len(disagreements) > 0 {
+			errs[name] = fmt.Errorf("cache availability disagrees with the majority of peers for: %v", listNames(disagreements))
+		} else {
+			errs[name] = nil
+		}
+	}
+	return errs
+}
+
+// PeerAgreementAllValidator is designed to be run as a goroutine, and does not return. It continously validates every `interval`, and calls `onErr` on failure, `onResumeSuccess` when a failure ceases, and `onCheck` on every poll. Note the error passed to `onErr` may be a general validation error not associated with any monitor, in which case the passed `tc.TrafficMonitorName` will be empty.
+func PeerAgreementAllValidator(
+	toClient *to.Session,
+	interval time.Duration,
+	includeOffline bool,
+	grace time.Duration,
+	onErr func(tc.TrafficMonitorName, error),
+	onResumeSuccess func(tc.TrafficMonitorName),
+	onCheck func(tc.TrafficMonitorName, error),
+) {
+	AllValidator(toClient, interval, includeOffline, grace, onErr, onResumeSuccess, onCheck, ValidateAllPeerAgreement)
+}
+
+// ValidateAllPeerAgreement validates, for all monitors in the given Traffic Ops, the cache availability in CRStates agrees with the majority of the monitors of the same CDN.
+func ValidateAllPeerAgreement(toClient *to.Session, includeOffline bool) (map[tc.TrafficMonitorName]error, error) {
+	servers, err := GetMonitors(toClient, includeOffline)
+	if err != nil {
+		return nil, err
+	}
+
+	cdnStates := map[tc.CDNName]map[tc.TrafficMonitorName]CRStatesOrError{}
+	for _, server := range servers {
+		cdn := tc.CDNName(server.CDNName)
+		if _, ok := cdnStates[cdn]; !ok {
+			cdnStates[cdn] = map[tc.TrafficMonitorName]CRStatesOrError{}
+		}
+		uri := fmt.Sprintf("http://%s.%s", server.HostName, server.DomainName)
+		crStates, err := GetCRStates(uri + TrafficMonitorCRStatesPath)
+		cdnStates[cdn][tc.TrafficMonitorName(server.HostName)] = CRStatesOrError{CRStates: crStates, Err: err}
+	}
+
+	errs := map[tc.TrafficMonitorName]error{}
+	for _, states := range cdnStates {
+		for name, err := range ValidatePeerAgreementData(states) {
+			errs[name] = err
+		}
+	}
+	return errs, nil
+}
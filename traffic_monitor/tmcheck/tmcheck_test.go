@@ -0,0 +1,148 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package tmcheck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+func testCRConfig() *tc.CRConfig {
+	return &tc.CRConfig{
+		ContentServers: map[string]tc.CRConfigTrafficOpsServer{
+			"edge1": {DeliveryServices: map[string][]string{"ds1": nil, "ds2": nil}},
+			"edge2": {DeliveryServices: map[string][]string{"ds1": nil}},
+		},
+		DeliveryServices: map[string]tc.CRConfigDeliveryService{"ds1": {}, "ds2": {}, "steering": {}},
+	}
+}
+
+func testCRStates(edge1 bool, edge2 bool, ds1 bool, ds2 bool) *tc.CRStates {
+	return &tc.CRStates{
+		Caches: map[tc.CacheName]tc.IsAvailable{
+			"edge1": {IsAvailable: edge1},
+			"edge2": {IsAvailable: edge2},
+		},
+		DeliveryService: map[tc.DeliveryServiceName]tc.CRStatesDeliveryService{
+			"ds1":      {IsAvailable: ds1},
+			"ds2":      {IsAvailable: ds2},
+			"steering": {IsAvailable: true},
+		},
+	}
+}
+
+func TestValidateCRStatesConsistencyData(t *testing.T) {
+	if err := ValidateCRStatesConsistencyData(testCRStates(true, true, true, true), testCRConfig()); err != nil {
+		t.Errorf("ValidateCRStatesConsistencyData expected: nil, actual: %v", err)
+	}
+
+	crStates := testCRStates(true, true, true, true)
+	delete(crStates.Caches, "edge2")
+	crStates.Caches["edge3"] = tc.IsAvailable{IsAvailable: true}
+	delete(crStates.DeliveryService, "ds2")
+	err := ValidateCRStatesConsistencyData(crStates, testCRConfig())
+	if err == nil {
+		t.Fatalf("ValidateCRStatesConsistencyData expected: error, actual: nil")
+	}
+	for _, expected := range []string{"CRConfig but not CRStates: edge2", "CRStates but not CRConfig: edge3", "Delivery Services in CRConfig but not CRStates: ds2"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("ValidateCRStatesConsistencyData error expected: containing '%v', actual: %v", expected, err)
+		}
+	}
+}
+
+func TestListNames(t *testing.T) {
+	names := []string{"l", "k", "j", "i", "h", "g", "f", "e", "d", "c", "b", "a"}
+	expected := "a, b, c, d, e, f, g, h, i, j and 2 more"
+	if actual := listNames(names); actual != expected {
+		t.Errorf("listNames expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestValidateDSAvailabilityData(t *testing.T) {
+	type testCase struct {
+		name     string
+		crStates *tc.CRStates
+		expected string
+	}
+	testCases := []testCase{
+		{"consistent", testCRStates(true, false, true, true), ""},
+		{"some caches available", testCRStates(false, true, true, false), ""},
+		{"available without caches", testCRStates(false, false, true, false), "available with no available caches: ds1"},
+		{"unavailable with caches", testCRStates(true, true, true, false), "unavailable with all caches available: ds2"},
+	}
+	for _, tc := range testCases {
+		err := ValidateDSAvailabilityData(tc.crStates, testCRConfig())
+		if tc.expected == "" && err != nil {
+			t.Errorf("ValidateDSAvailabilityData %v expected: nil, actual: %v", tc.name, err)
+		} else if tc.expected != "" && (err == nil || !strings.Contains(err.Error(), tc.expected)) {
+			t.Errorf("ValidateDSAvailabilityData %v expected: error containing '%v', actual: %v", tc.name, tc.expected, err)
+		}
+	}
+}
+
+func TestValidatePeerAgreementData(t *testing.T) {
+	errs := ValidatePeerAgreementData(map[tc.TrafficMonitorName]CRStatesOrError{
+		"tm1": {CRStates: testCRStates(true, true, true, true)},
+		"tm2": {CRStates: testCRStates(true, true, true, true)},
+		"tm3": {CRStates: testCRStates(false, true, true, true)},
+		"tm4": {Err: errors.New("connection refused")},
+	})
+	if len(errs) != 4 {
+		t.Fatalf("ValidatePeerAgreementData expected: 4 results, actual: %v", len(errs))
+	}
+	if errs["tm1"] != nil || errs["tm2"] != nil {
+		t.Errorf("ValidatePeerAgreementData majority expected: nil errors, actual: %v %v", errs["tm1"], errs["tm2"])
+	}
+	if err := errs["tm3"]; err == nil || !strings.Contains(err.Error(), "edge1") {
+		t.Errorf("ValidatePeerAgreementData dissenter expected: error for edge1, actual: %v", err)
+	}
+	if errs["tm4"] == nil {
+		t.Errorf("ValidatePeerAgreementData unreachable expected: error, actual: nil")
+	}
+
+	// with two monitors split, neither is the majority
+	errs = ValidatePeerAgreementData(map[tc.TrafficMonitorName]CRStatesOrError{
+		"tm1": {CRStates: testCRStates(true, true, true, true)},
+		"tm2": {CRStates: testCRStates(false, true, true, true)},
+	})
+	if errs["tm1"] != nil || errs["tm2"] != nil {
+		t.Errorf("ValidatePeerAgreementData split expected: nil errors, actual: %v %v", errs["tm1"], errs["tm2"])
+	}
+}
+
+func TestValidateCRStates(t *testing.T) {
+	offline := tc.CRConfigServerStatus(tc.CacheStatusOffline)
+	online := tc.CRConfigServerStatus(tc.CacheStatusOnline)
+	crConfig := &tc.CRConfig{ContentServers: map[string]tc.CRConfigTrafficOpsServer{
+		"edge1": {ServerStatus: &offline},
+		"edge2": {ServerStatus: &online},
+	}}
+
+	if err := ValidateCRStates(testCRStates(false, true, true, true), crConfig); err != nil {
+		t.Errorf("ValidateCRStates expected: nil, actual: %v", err)
+	}
+	if err := ValidateCRStates(testCRStates(true, true, true, true), crConfig); err == nil {
+		t.Errorf("ValidateCRStates available offline cache expected: error, actual: nil")
+	}
+}
@@ -0,0 +1,217 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package validatorservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+const AlertFiring = "firing"
+const AlertResolved = "resolved"
+
+// Alert is a notification that a monitor has been invalid for longer than the grace period, or that it has since stayed valid.
+type Alert struct {
+	Validator    string                `json:"validator"`
+	Monitor      tc.TrafficMonitorName `json:"monitor"`
+	Status       string                `json:"status"`
+	Message      string                `json:"message"`
+	InvalidSince time.Time             `json:"invalidSince"`
+	Time         time.Time             `json:"time"`
+}
+
+func (a Alert) String() string {
+	monitor := string(a.Monitor)
+	if monitor == "" {
+		monitor = "all monitors"
+	}
+	if a.Status == AlertResolved {
+		return fmt.Sprintf("RESOLVED %v %v: valid again after being invalid since %v", a.Validator, monitor, a.InvalidSince)
+	}
+	return fmt.Sprintf("FIRING %v %v: invalid since %v: %v", a.Validator, monitor, a.InvalidSince, a.Message)
+}
+
+// Notifier sends alerts somewhere.
+type Notifier interface {
+	Notify(alert Alert) error
+}
+
+// WebhookNotifier POSTs each alert as JSON to a URL.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) WebhookNotifier {
+	return WebhookNotifier{URL: url, Client: &http.Client{Timeout: timeout}}
+}
+
+func (n WebhookNotifier) Notify(alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("marshalling alert: %v", err)
+	}
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting alert to %v: %v", n.URL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting alert to %v: received http status %v", n.URL, resp.StatusCode)
+	}
+	return nil
+}
+
+// SyslogNotifier writes firing alerts to syslog as errors, and resolved alerts as info.
+type SyslogNotifier struct {
+	Writer *syslog.Writer
+}
+
+// NewSyslogNotifier connects to the syslog daemon at raddr over network, or the local daemon if both are empty.
+func NewSyslogNotifier(network string, raddr string, tag string) (SyslogNotifier, error) {
+	w, err := syslog.Dial(network, raddr, syslog.LOG_WARNING|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return SyslogNotifier{}, fmt.Errorf("connecting to syslog: %v", err)
+	}
+	return SyslogNotifier{Writer: w}, nil
+}
+
+func (n SyslogNotifier) Notify(alert Alert) error {
+	if alert.Status == AlertResolved {
+		return n.Writer.Info(alert.String())
+	}
+	return n.Writer.Err(alert.String())
+}
+
+// Alerter fires an alert when a monitor has been invalid for longer than Grace, and resolves it once the monitor has stayed valid for ResolveAfter. A monitor flapping between valid and invalid within ResolveAfter stays a single alert, rather than alerting on every change. It's safe for concurrent use.
+type Alerter struct {
+	Grace        time.Duration
+	ResolveAfter time.Duration
+	Notifiers    []Notifier
+
+	m         sync.Mutex
+	limit     int
+	incidents map[string]*incident
+	alerts    []Alert
+}
+
+type incident struct {
+	invalidSince time.Time
+	validSince   time.Time
+	firing       bool
+	message      string
+}
+
+// NewAlerter returns an Alerter which keeps the most recent `limit` alerts.
+func NewAlerter(grace time.Duration, resolveAfter time.Duration, limit int, notifiers ...Notifier) *Alerter {
+	return &Alerter{Grace: grace, ResolveAfter: resolveAfter, Notifiers: notifiers, limit: limit, incidents: map[string]*incident{}}
+}
+
+// Observe updates the alert state of the given validator's monitor with its status as of now, notifying if an alert fires or resolves.
+func (a *Alerter) Observe(validator string, status MonitorStatus, now time.Time) {
+	alert, ok := a.observe(validator, status, now)
+	if !ok {
+		return
+	}
+	for _, notifier := range a.Notifiers {
+		if err := notifier.Notify(alert); err != nil {
+			log.Errorf("sending alert '%v': %v", alert, err)
+		}
+	}
+}
+
+func (a *Alerter) observe(validator string, status MonitorStatus, now time.Time) (Alert, bool) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	key := validator + "\x00" + string(status.Monitor)
+	inc := a.incidents[key]
+
+	if !status.Valid {
+		if inc == nil {
+			invalidSince := now
+			if status.InvalidSince != nil {
+				invalidSince = *status.InvalidSince
+			}
+			inc = &incident{invalidSince: invalidSince}
+			a.incidents[key] = inc
+		}
+		inc.validSince = time.Time{}
+		inc.message = status.LastError
+		if inc.firing || now.Sub(inc.invalidSince) <= a.Grace {
+			return Alert{}, false
+		}
+		inc.firing = true
+		return a.add(Alert{Validator: validator, Monitor: status.Monitor, Status: AlertFiring, Message: inc.message, InvalidSince: inc.invalidSince, Time: now}), true
+	}
+
+	if inc == nil {
+		return Alert{}, false
+	}
+	if !inc.firing {
+		delete(a.incidents, key) // recovered within the grace period, nothing was alerted
+		return Alert{}, false
+	}
+	if inc.validSince.IsZero() {
+		inc.validSince = now
+	}
+	if now.Sub(inc.validSince) < a.ResolveAfter {
+		return Alert{}, false
+	}
+	delete(a.incidents, key)
+	return a.add(Alert{Validator: validator, Monitor: status.Monitor, Status: AlertResolved, Message: inc.message, InvalidSince: inc.invalidSince, Time: now}), true
+}
+
+// add records the alert in the recent alerts, and returns it. The mutex must be held.
+func (a *Alerter) add(alert Alert) Alert {
+	a.alerts = append([]Alert{alert}, a.alerts...)
+	if a.limit > 0 && len(a.alerts) > a.limit {
+		a.alerts = a.alerts[:a.limit]
+	}
+	return alert
+}
+
+// Alerts returns the most recent alerts, newest first.
+func (a *Alerter) Alerts() []Alert {
+	a.m.Lock()
+	defer a.m.Unlock()
+	return append([]Alert{}, a.alerts...)
+}
+
+// Firing returns the number of alerts of the given validator currently firing.
+func (a *Alerter) Firing(validator string) int {
+	a.m.Lock()
+	defer a.m.Unlock()
+	firing := 0
+	for key, inc := range a.incidents {
+		if inc.firing && len(key) > len(validator) && key[:len(validator)+1] == validator+"\x00" {
+			firing++
+		}
+	}
+	return firing
+}
@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package validatorservice
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testNotifier struct {
+	m      sync.Mutex
+	alerts []Alert
+}
+
+func (n *testNotifier) Notify(alert Alert) error {
+	n.m.Lock()
+	defer n.m.Unlock()
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestAlerterFlapSuppression(t *testing.T) {
+	notifier := &testNotifier{}
+	alerter := NewAlerter(30*time.Second, time.Minute, 10, notifier)
+	history := NewHistory(100)
+	start := time.Now()
+
+	type step struct {
+		offset time.Duration
+		err    error
+		alerts int
+	}
+	steps := []step{
+		{0, errors.New("invalid"), 0},
+		{20 * time.Second, errors.New("invalid"), 0},
+		{40 * time.Second, errors.New("invalid"), 1}, // past the grace period, fires
+		{50 * time.Second, errors.New("invalid"), 1}, // still firing, no new alert
+		{60 * time.Second, nil, 1},                   // valid, but not for long enough to resolve
+		{70 * time.Second, errors.New("invalid"), 1}, // flapped back, same incident
+		{80 * time.Second, nil, 1},
+		{120 * time.Second, nil, 1},
+		{140 * time.Second, nil, 2}, // valid for a minute, resolves
+		{150 * time.Second, nil, 2},
+	}
+	for i, s := range steps {
+		now := start.Add(s.offset)
+		alerter.Observe("peer-poller", history.Add("tm1", now, s.err), now)
+		if len(notifier.alerts) != s.alerts {
+			t.Fatalf("Alerter step %v alerts expected: %v, actual: %v %+v", i, s.alerts, len(notifier.alerts), notifier.alerts)
+		}
+	}
+
+	if notifier.alerts[0].Status != AlertFiring || notifier.alerts[1].Status != AlertResolved {
+		t.Errorf("Alerter statuses expected: firing then resolved, actual: %v %v", notifier.alerts[0].Status, notifier.alerts[1].Status)
+	}
+	if !notifier.alerts[1].InvalidSince.Equal(start) {
+		t.Errorf("Alerter resolved invalidSince expected: %v, actual: %v", start, notifier.alerts[1].InvalidSince)
+	}
+	if alerts := alerter.Alerts(); len(alerts) != 2 || alerts[0].Status != AlertResolved {
+		t.Errorf("Alerts expected: 2, newest resolved, actual: %+v", alerts)
+	}
+}
+
+func TestAlerterRecoversWithinGrace(t *testing.T) {
+	notifier := &testNotifier{}
+	alerter := NewAlerter(30*time.Second, time.Minute, 10, notifier)
+	history := NewHistory(100)
+	start := time.Now()
+
+	alerter.Observe("peer-poller", history.Add("tm1", start, errors.New("invalid")), start)
+	alerter.Observe("peer-poller", history.Add("tm1", start.Add(10*time.Second), nil), start.Add(10*time.Second))
+	alerter.Observe("peer-poller", history.Add("tm1", start.Add(50*time.Second), errors.New("invalid")), start.Add(50*time.Second))
+	if len(notifier.alerts) != 0 {
+		t.Errorf("Alerter expected: no alerts, actual: %+v", notifier.alerts)
+	}
+	if firing := alerter.Firing("peer-poller"); firing != 0 {
+		t.Errorf("Firing expected: 0, actual: %v", firing)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	received := make(chan Alert, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := Alert{}
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- alert
+	}))
+	defer srv.Close()
+
+	alert := Alert{Validator: "peer-poller", Monitor: "tm1", Status: AlertFiring, Message: "invalid"}
+	if err := NewWebhookNotifier(srv.URL, time.Second).Notify(alert); err != nil {
+		t.Fatalf("WebhookNotifier.Notify expected: nil error, actual: %v", err)
+	}
+	if actual := <-received; actual.Validator != alert.Validator || actual.Monitor != alert.Monitor || actual.Status != alert.Status {
+		t.Errorf("WebhookNotifier.Notify expected: %+v, actual: %+v", alert, actual)
+	}
+
+	if err := NewWebhookNotifier(srv.URL+"/nope", time.Second).Notify(Alert{}); err != nil {
+		t.Errorf("WebhookNotifier.Notify expected: nil error for 200, actual: %v", err)
+	}
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) })
+	if err := NewWebhookNotifier(srv.URL, time.Second).Notify(alert); err == nil {
+		t.Errorf("WebhookNotifier.Notify 500 expected: error, actual: nil")
+	}
+}
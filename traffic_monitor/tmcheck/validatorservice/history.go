@@ -0,0 +1,130 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package validatorservice runs tmcheck validators continuously, keeping the history of their results, and alerting when a monitor stays invalid.
+package validatorservice
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// Check is the result of a single validation of a monitor.
+type Check struct {
+	Time  time.Time `json:"time"`
+	Valid bool      `json:"valid"`
+	Error string    `json:"error,omitempty"`
+}
+
+// MonitorStatus is the current state of a monitor for a validator. The Monitor is empty for errors not associated with any monitor, such as failing to get the monitors from Traffic Ops.
+type MonitorStatus struct {
+	Monitor      tc.TrafficMonitorName `json:"monitor"`
+	Valid        bool                  `json:"valid"`
+	LastCheck    time.Time             `json:"lastCheck"`
+	InvalidSince *time.Time            `json:"invalidSince,omitempty"`
+	LastError    string                `json:"lastError,omitempty"`
+	Checks       uint64                `json:"checks"`
+	Failures     uint64                `json:"failures"`
+}
+
+// History is the checks of each monitor of a validator, keeping the most recent `limit` checks per monitor. It's safe for concurrent use.
+type History struct {
+	m        sync.RWMutex
+	limit    int
+	monitors map[tc.TrafficMonitorName]*monitorHistory
+}
+
+type monitorHistory struct {
+	status MonitorStatus
+	checks []Check
+}
+
+func NewHistory(limit int) *History {
+	return &History{limit: limit, monitors: map[tc.TrafficMonitorName]*monitorHistory{}}
+}
+
+// Add records a check of the given monitor at time t, which was valid if err is nil, and returns the monitor's updated status.
+func (h *History) Add(name tc.TrafficMonitorName, t time.Time, err error) MonitorStatus {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	mh, ok := h.monitors[name]
+	if !ok {
+		mh = &monitorHistory{status: MonitorStatus{Monitor: name}}
+		h.monitors[name] = mh
+	}
+
+	check := Check{Time: t, Valid: err == nil}
+	mh.status.Checks++
+	mh.status.LastCheck = t
+	mh.status.Valid = err == nil
+	if err != nil {
+		check.Error = err.Error()
+		mh.status.Failures++
+		mh.status.LastError = check.Error
+		if mh.status.InvalidSince == nil {
+			invalidSince := t
+			mh.status.InvalidSince = &invalidSince
+		}
+	} else {
+		mh.status.InvalidSince = nil
+	}
+
+	mh.checks = append(mh.checks, check)
+	if h.limit > 0 && len(mh.checks) > h.limit {
+		mh.checks = append([]Check(nil), mh.checks[len(mh.checks)-h.limit:]...)
+	}
+	return mh.status
+}
+
+// Statuses returns the current status of every monitor, sorted by name.
+func (h *History) Statuses() []MonitorStatus {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	statuses := make([]MonitorStatus, 0, len(h.monitors))
+	for _, mh := range h.monitors {
+		statuses = append(statuses, mh.status)
+	}
+	sort.Sort(monitorStatusSlice(statuses))
+	return statuses
+}
+
+// Checks returns the recorded checks of the given monitor, newest first, and whether the monitor has been checked.
+func (h *History) Checks(name tc.TrafficMonitorName) ([]Check, bool) {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	mh, ok := h.monitors[name]
+	if !ok {
+		return nil, false
+	}
+	checks := make([]Check, len(mh.checks))
+	for i, check := range mh.checks {
+		checks[len(checks)-1-i] = check
+	}
+	return checks, true
+}
+
+type monitorStatusSlice []MonitorStatus
+
+func (s monitorStatusSlice) Len() int           { return len(s) }
+func (s monitorStatusSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s monitorStatusSlice) Less(i, j int) bool { return s[i].Monitor < s[j].Monitor }
@@ -0,0 +1,227 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package validatorservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_monitor/tmcheck"
+	to "github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+// Validator is a tmcheck validator of all monitors, and how to describe it.
+type Validator struct {
+	// Name identifies the validator in the API and metrics, e.g. "peer-poller".
+	Name        string
+	Title       string
+	Description string
+	Func        tmcheck.AllValidatorFunc
+}
+
+// ValidatorStatus is the current status of every monitor of a validator. The validator is valid if every monitor is.
+type ValidatorStatus struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Valid       bool            `json:"valid"`
+	Monitors    []MonitorStatus `json:"monitors"`
+}
+
+// Service runs validators, and keeps the history of their checks. It's safe for concurrent use.
+type Service struct {
+	Alerter *Alerter
+
+	m            sync.RWMutex
+	historyLimit int
+	validators   []Validator
+	histories    map[string]*History
+}
+
+// New returns a Service which keeps `historyLimit` checks per validator and monitor. The alerter may be nil, to not alert.
+func New(historyLimit int, alerter *Alerter) *Service {
+	return &Service{Alerter: alerter, historyLimit: historyLimit, histories: map[string]*History{}}
+}
+
+// Register adds the validator to the service, without running it.
+func (s *Service) Register(v Validator) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if _, ok := s.histories[v.Name]; ok {
+		return
+	}
+	s.validators = append(s.validators, v)
+	s.histories[v.Name] = NewHistory(s.historyLimit)
+}
+
+// Start registers the validator, and runs it in a goroutine every interval, recording its checks.
+func (s *Service) Start(v Validator, toClient *to.Session, interval time.Duration, includeOffline bool, grace time.Duration) {
+	s.Register(v)
+
+	// errors not associated with a monitor are only reported via onErr and onResumeSuccess; monitors' are all reported via onCheck
+	onErr := func(name tc.TrafficMonitorName, err error) {
+		if name == "" {
+			s.Record(v.Name, name, time.Now(), err)
+		}
+	}
+	onResumeSuccess := func(name tc.TrafficMonitorName) {
+		if name == "" {
+			s.Record(v.Name, name, time.Now(), nil)
+		}
+	}
+	onCheck := func(name tc.TrafficMonitorName, err error) {
+		s.Record(v.Name, name, time.Now(), err)
+	}
+
+	go v.Func(toClient, interval, includeOffline, grace, onErr, onResumeSuccess, onCheck)
+}
+
+// Record records a check of the given validator and monitor at time t, which was valid if err is nil.
+func (s *Service) Record(validator string, monitor tc.TrafficMonitorName, t time.Time, err error) {
+	s.m.RLock()
+	history, ok := s.histories[validator]
+	s.m.RUnlock()
+	if !ok {
+		return
+	}
+
+	status := history.Add(monitor, t, err)
+	if s.Alerter != nil {
+		s.Alerter.Observe(validator, status, t)
+	}
+}
+
+// Statuses returns the status of every validator, in the order they were registered.
+func (s *Service) Statuses() []ValidatorStatus {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	statuses := []ValidatorStatus{}
+	for _, v := range s.validators {
+		status := ValidatorStatus{Name: v.Name, Title: v.Title, Description: v.Description, Valid: true, Monitors: s.histories[v.Name].Statuses()}
+		for _, monitor := range status.Monitors {
+			status.Valid = status.Valid && monitor.Valid
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// History returns the checks of the given validator and monitor, newest first, and whether they exist.
+func (s *Service) History(validator string, monitor tc.TrafficMonitorName) ([]Check, bool) {
+	s.m.RLock()
+	history, ok := s.histories[validator]
+	s.m.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return history.Checks(monitor)
+}
+
+// WriteMetrics writes the status of every validator and monitor in the Prometheus text format.
+func (s *Service) WriteMetrics(w io.Writer, now time.Time) {
+	statuses := s.Statuses()
+
+	metric := func(name string, metricType string, help string, value func(MonitorStatus) float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+		for _, v := range statuses {
+			for _, m := range v.Monitors {
+				fmt.Fprintf(w, "%s{validator=\"%s\",monitor=\"%s\"} %v\n", name, escapeLabel(v.Name), escapeLabel(string(m.Monitor)), value(m))
+			}
+		}
+	}
+
+	metric("tm_validator_valid", "gauge", "Whether the monitor was valid at its last check.", func(m MonitorStatus) float64 {
+		if m.Valid {
+			return 1
+		}
+		return 0
+	})
+	metric("tm_validator_checks_total", "counter", "The number of checks of the monitor.", func(m MonitorStatus) float64 { return float64(m.Checks) })
+	metric("tm_validator_failures_total", "counter", "The number of checks the monitor was invalid.", func(m MonitorStatus) float64 { return float64(m.Failures) })
+	metric("tm_validator_invalid_seconds", "gauge", "How long the monitor has been invalid, or 0 if it's valid.", func(m MonitorStatus) float64 {
+		if m.InvalidSince == nil {
+			return 0
+		}
+		return now.Sub(*m.InvalidSince).Seconds()
+	})
+	metric("tm_validator_last_check_timestamp_seconds", "gauge", "The time of the monitor's last check.", func(m MonitorStatus) float64 { return float64(m.LastCheck.Unix()) })
+
+	if s.Alerter != nil {
+		fmt.Fprintf(w, "# HELP tm_validator_alerts_firing The number of alerts of the validator currently firing.\n# TYPE tm_validator_alerts_firing gauge\n")
+		for _, v := range statuses {
+			fmt.Fprintf(w, "tm_validator_alerts_firing{validator=\"%s\"} %v\n", escapeLabel(v.Name), s.Alerter.Firing(v.Name))
+		}
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// RegisterHandlers adds the service's JSON API and metrics endpoints to mux:
+//
+//	/api/validators returns the status of every validator.
+//	/api/history?validator=name&monitor=name returns the checks of a monitor, newest first.
+//	/api/alerts returns the most recent alerts, newest first.
+//	/metrics returns the status of every validator in the Prometheus text format.
+func (s *Service) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/api/validators", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.Statuses())
+	})
+
+	mux.HandleFunc("/api/history", func(w http.ResponseWriter, r *http.Request) {
+		checks, ok := s.History(r.URL.Query().Get("validator"), tc.TrafficMonitorName(r.URL.Query().Get("monitor")))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			writeJSON(w, map[string]string{"error": "no history for the given validator and monitor"})
+			return
+		}
+		writeJSON(w, checks)
+	})
+
+	mux.HandleFunc("/api/alerts", func(w http.ResponseWriter, r *http.Request) {
+		alerts := []Alert{}
+		if s.Alerter != nil {
+			alerts = s.Alerter.Alerts()
+		}
+		writeJSON(w, alerts)
+	})
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.WriteMetrics(w, time.Now())
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Fprintf(w, `{"error":"encoding response"}`)
+	}
+}
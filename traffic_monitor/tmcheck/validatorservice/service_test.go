@@ -0,0 +1,147 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package validatorservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(3)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		var err error
+		if i >= 3 {
+			err = errors.New("invalid")
+		}
+		h.Add("tm1", start.Add(time.Duration(i)*time.Second), err)
+	}
+
+	checks, ok := h.Checks("tm1")
+	if !ok || len(checks) != 3 {
+		t.Fatalf("History.Checks expected: 3 checks, actual: %v %v", ok, len(checks))
+	}
+	if !checks[0].Time.Equal(start.Add(4*time.Second)) || checks[0].Valid || !checks[2].Valid {
+		t.Errorf("History.Checks expected: newest invalid first and oldest valid last, actual: %+v", checks)
+	}
+
+	statuses := h.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("History.Statuses expected: 1, actual: %v", len(statuses))
+	}
+	status := statuses[0]
+	if status.Valid || status.Checks != 5 || status.Failures != 2 || status.LastError != "invalid" {
+		t.Errorf("History.Statuses expected: invalid with 5 checks and 2 failures, actual: %+v", status)
+	}
+	if status.InvalidSince == nil || !status.InvalidSince.Equal(start.Add(3*time.Second)) {
+		t.Errorf("History.Statuses invalidSince expected: %v, actual: %v", start.Add(3*time.Second), status.InvalidSince)
+	}
+
+	if _, ok := h.Checks("tm2"); ok {
+		t.Errorf("History.Checks unknown monitor expected: false, actual: true")
+	}
+}
+
+func testService() *Service {
+	s := New(10, NewAlerter(0, 0, 10))
+	s.Register(Validator{Name: "peer-poller", Title: "Peer Poller"})
+	s.Register(Validator{Name: "ds-stats", Title: "Delivery Services"})
+	now := time.Now()
+	s.Record("peer-poller", "tm1", now, nil)
+	s.Record("peer-poller", "tm2", now.Add(-time.Minute), errors.New("dead"))
+	s.Record("peer-poller", "tm2", now, errors.New("dead"))
+	s.Record("ds-stats", "tm1", now, nil)
+	s.Record("unregistered", "tm1", now, nil)
+	return s
+}
+
+func TestServiceStatuses(t *testing.T) {
+	statuses := testService().Statuses()
+	if len(statuses) != 2 || statuses[0].Name != "peer-poller" || statuses[1].Name != "ds-stats" {
+		t.Fatalf("Service.Statuses expected: peer-poller and ds-stats in registration order, actual: %+v", statuses)
+	}
+	if statuses[0].Valid || !statuses[1].Valid {
+		t.Errorf("Service.Statuses valid expected: false true, actual: %v %v", statuses[0].Valid, statuses[1].Valid)
+	}
+	if len(statuses[0].Monitors) != 2 || statuses[0].Monitors[0].Monitor != "tm1" {
+		t.Errorf("Service.Statuses monitors expected: tm1 and tm2, actual: %+v", statuses[0].Monitors)
+	}
+}
+
+func TestServiceMetrics(t *testing.T) {
+	buf := &bytes.Buffer{}
+	testService().WriteMetrics(buf, time.Now())
+	metrics := buf.String()
+	for _, expected := range []string{
+		"# TYPE tm_validator_valid gauge\n",
+		`tm_validator_valid{validator="peer-poller",monitor="tm1"} 1`,
+		`tm_validator_valid{validator="peer-poller",monitor="tm2"} 0`,
+		`tm_validator_failures_total{validator="peer-poller",monitor="tm2"} 2`,
+		`tm_validator_alerts_firing{validator="peer-poller"} 1`,
+		`tm_validator_alerts_firing{validator="ds-stats"} 0`,
+	} {
+		if !strings.Contains(metrics, expected) {
+			t.Errorf("WriteMetrics expected: containing %q, actual: %v", expected, metrics)
+		}
+	}
+	if escaped := escapeLabel("a\"b\\c\nd"); escaped != `a\"b\\c\nd` {
+		t.Errorf("escapeLabel expected: %v, actual: %v", `a\"b\\c\nd`, escaped)
+	}
+}
+
+func TestServiceHandlers(t *testing.T) {
+	mux := http.NewServeMux()
+	testService().RegisterHandlers(mux)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	statuses := []ValidatorStatus{}
+	if err := json.NewDecoder(get("/api/validators").Body).Decode(&statuses); err != nil || len(statuses) != 2 {
+		t.Errorf("/api/validators expected: 2 validators, actual: %v %v", len(statuses), err)
+	}
+
+	checks := []Check{}
+	if err := json.NewDecoder(get("/api/history?validator=peer-poller&monitor=tm2").Body).Decode(&checks); err != nil || len(checks) != 2 || checks[0].Error != "dead" {
+		t.Errorf("/api/history expected: 2 invalid checks, actual: %+v %v", checks, err)
+	}
+	if w := get("/api/history?validator=peer-poller&monitor=tm3"); w.Code != http.StatusNotFound {
+		t.Errorf("/api/history unknown monitor expected: %v, actual: %v", http.StatusNotFound, w.Code)
+	}
+
+	alerts := []Alert{}
+	if err := json.NewDecoder(get("/api/alerts").Body).Decode(&alerts); err != nil || len(alerts) != 1 || alerts[0].Monitor != "tm2" {
+		t.Errorf("/api/alerts expected: 1 alert for tm2, actual: %+v %v", alerts, err)
+	}
+
+	if w := get("/metrics"); !strings.Contains(w.Body.String(), "tm_validator_valid") {
+		t.Errorf("/metrics expected: metrics, actual: %v", w.Body.String())
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/traffic_monitor/cachefarm"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "The address to serve every cache on, by the Host header")
+	basePort := flag.Int("ports", 0, "If not 0, additionally serve each cache on its own port, starting at this port")
+	portIP := flag.String("portIP", "127.0.0.1", "The IP to serve each cache's own port on")
+	numCaches := flag.Int("caches", 100, "The number of caches to simulate")
+	prefix := flag.String("prefix", "edge-", "The host name prefix of the caches, which are numbered")
+	domain := flag.String("domain", "cdn.example.net", "The domain of the caches' FQDNs")
+	cacheGroups := flag.Int("cachegroups", 10, "The number of cache groups to spread the caches across")
+	profile := flag.String("profile", "EDGE", "The profile of the caches")
+	dses := flag.String("ds", "ds1.cdn.example.net,ds2.cdn.example.net", "Comma-separated delivery service remap domains served by every cache")
+	kbps := flag.Int64("kbps", 1000000, "The bandwidth each cache sends")
+	speed := flag.Int("speed", cachefarm.DefaultSpeedMbps, "The interface speed of each cache, in Mbps")
+	rps := flag.Float64("rps", 100, "The requests per second of each delivery service on each cache")
+	loadAvg := flag.Float64("loadavg", 0.5, "The load average of each cache")
+	scenarioPath := flag.String("scenario", "", "A JSON scenario file of events to run")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "The random seed of the caches' rate variation")
+	printServers := flag.String("printServers", "", "If set, print the caches as CRConfig contentServers JSON with this IP and the -addr port, and exit")
+	help := flag.Bool("help", false, "Usage info")
+	helpBrief := flag.Bool("h", false, "Usage info")
+	flag.Parse()
+	if *help || *helpBrief {
+		fmt.Printf("Usage: ./cache-farm -addr :8080 -caches 1000 -ds ds1.cdn.example.net -kbps 1000000 -scenario scenario.json\n")
+		return
+	}
+
+	cfgs := cachefarm.Generate(*numCaches, *prefix, *domain, *cacheGroups, cachefarm.Config{
+		Profile:           *profile,
+		SpeedMbps:         *speed,
+		Kbps:              *kbps,
+		RequestsPerSecond: *rps,
+		LoadAvg:           *loadAvg,
+		DeliveryServices:  strings.Split(*dses, ","),
+	})
+	farm := cachefarm.New(cfgs, *seed)
+
+	if *printServers != "" {
+		port := 80
+		if _, portStr, err := splitPort(*addr); err == nil {
+			port = portStr
+		}
+		bts, err := json.MarshalIndent(farm.ContentServers(*printServers, port), "", "  ")
+		if err != nil {
+			fmt.Printf("Error serializing servers: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s\n", bts)
+		return
+	}
+
+	if *basePort != 0 {
+		if _, err := farm.ServePorts(*portIP, *basePort); err != nil {
+			fmt.Printf("Error serving cache ports: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *scenarioPath != "" {
+		scenario, err := cachefarm.LoadScenario(*scenarioPath)
+		if err != nil {
+			fmt.Printf("Error loading scenario: %v\n", err)
+			os.Exit(1)
+		}
+		go farm.Run(scenario, nil)
+	}
+
+	fmt.Printf("Serving %d caches on %s\n", *numCaches, *addr)
+	if err := http.ListenAndServe(*addr, farm); err != nil {
+		fmt.Printf("Error serving: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// splitPort returns the host and numeric port of the given address.
+func splitPort(addr string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := strconv.Atoi(portStr)
+	return host, port, err
+}
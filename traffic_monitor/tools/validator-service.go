@@ -17,121 +17,76 @@
  * under the License.
  */
 
-// validate-offline is a utility HTTP service which polls the given Traffic Monitor and validates that no OFFLINE or ADMIN_DOWN caches in the Traffic Ops CRConfig are marked Available in Traffic Monitor's CRstates endpoint.
+// validator-service is a utility HTTP service which continuously runs the tmcheck validators against every Traffic Monitor in the given Traffic Ops. It keeps the history of their results, serves them as a webpage, JSON and Prometheus metrics, and alerts via webhook or syslog when a monitor stays invalid longer than the grace period.
 
 package main
 
 import (
 	"flag"
 	"fmt"
-	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
-	"github.com/apache/incubator-trafficcontrol/traffic_monitor/tmcheck"
-	to "github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+	"html"
 	"io"
 	"net/http"
-	"sort"
-	"sync"
+	"os"
 	"time"
-)
-
-const UserAgent = "tm-offline-validator/0.1"
-
-const LogLimit = 10
-
-type Log struct {
-	log       *[]string
-	limit     int
-	errored   *bool
-	lastCheck *time.Time
-	m         *sync.RWMutex
-}
-
-func (l *Log) Add(msg string) {
-	l.m.Lock()
-	defer l.m.Unlock()
-	*l.log = append([]string{msg}, *l.log...)
-	if len(*l.log) > l.limit {
-		*l.log = (*l.log)[:l.limit]
-	}
-}
-
-func (l *Log) Get() []string {
-	l.m.RLock()
-	defer l.m.RUnlock()
-	return *l.log
-}
-
-func (l *Log) GetErrored() (bool, time.Time) {
-	l.m.RLock()
-	defer l.m.RUnlock()
-	return *l.errored, *l.lastCheck
-}
-
-func (l *Log) SetErrored(e bool) {
-	l.m.Lock()
-	defer l.m.Unlock()
-	*l.errored = e
-	*l.lastCheck = time.Now()
-}
-
-func NewLog() Log {
-	log := make([]string, 0, LogLimit+1)
-	errored := false
-	limit := LogLimit
-	lastCheck := time.Time{}
-	return Log{log: &log, errored: &errored, m: &sync.RWMutex{}, limit: limit, lastCheck: &lastCheck}
-}
-
-type Logs struct {
-	logs map[tc.TrafficMonitorName]Log
-	m    *sync.RWMutex
-}
-
-func NewLogs() Logs {
-	return Logs{logs: map[tc.TrafficMonitorName]Log{}, m: &sync.RWMutex{}}
-}
-
-func (l Logs) Get(name tc.TrafficMonitorName) Log {
-	l.m.Lock()
-	defer l.m.Unlock()
-	if _, ok := l.logs[name]; !ok {
-		l.logs[name] = NewLog()
-	}
-	return l.logs[name]
-}
-
-func (l Logs) GetMonitors() []string {
-	l.m.RLock()
-	defer l.m.RUnlock()
-	monitors := []string{}
-	for name, _ := range l.logs {
-		monitors = append(monitors, string(name))
-	}
-	return monitors
-}
 
-func startValidator(validator tmcheck.AllValidatorFunc, toClient *to.Session, interval time.Duration, includeOffline bool, grace time.Duration) Logs {
-	logs := NewLogs()
-
-	onErr := func(name tc.TrafficMonitorName, err error) {
-		log := logs.Get(name)
-		log.Add(fmt.Sprintf("%v ERROR %v\n", time.Now(), err))
-		log.SetErrored(true)
-	}
-
-	onResumeSuccess := func(name tc.TrafficMonitorName) {
-		log := logs.Get(name)
-		log.Add(fmt.Sprintf("%v INFO State Valid\n", time.Now()))
-		log.SetErrored(false)
-	}
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/traffic_monitor/tmcheck"
+	"github.com/apache/incubator-trafficcontrol/traffic_monitor/tmcheck/validatorservice"
+	to "github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
 
-	onCheck := func(name tc.TrafficMonitorName, err error) {
-		log := logs.Get(name)
-		log.SetErrored(err != nil)
+const UserAgent = "tm-validator-service/0.2"
+
+const AlertLimit = 100
+
+const WebhookTimeout = time.Second * time.Duration(10)
+
+func validators() []validatorservice.Validator {
+	return []validatorservice.Validator{
+		{
+			Name:        "crstates-offline",
+			Title:       "CRStates Offline",
+			Description: "validates all OFFLINE and ADMIN_DOWN caches in the CRConfig are Unavailable",
+			Func:        tmcheck.AllMonitorsCRStatesOfflineValidator,
+		},
+		{
+			Name:        "peer-poller",
+			Title:       "Peer Poller",
+			Description: fmt.Sprintf("validates all peers in the CRConfig have been polled within the last %v", tmcheck.PeerPollMax),
+			Func:        tmcheck.PeerPollersAllValidator,
+		},
+		{
+			Name:        "ds-stats",
+			Title:       "Delivery Services",
+			Description: "validates all Delivery Services in the CRConfig exist in DsStats",
+			Func:        tmcheck.AllMonitorsDSStatsValidator,
+		},
+		{
+			Name:        "query-interval",
+			Title:       "Query Interval",
+			Description: fmt.Sprintf("validates all Monitors' Query Interval (95th percentile) is less than %v", tmcheck.QueryIntervalMax),
+			Func:        tmcheck.AllMonitorsQueryIntervalValidator,
+		},
+		{
+			Name:        "crstates-consistency",
+			Title:       "CRStates Consistency",
+			Description: "validates CRStates contains exactly the caches and Delivery Services in the CRConfig",
+			Func:        tmcheck.AllMonitorsCRStatesConsistencyValidator,
+		},
+		{
+			Name:        "peer-agreement",
+			Title:       "Peer Agreement",
+			Description: "validates each Monitor's cache availability agrees with the majority of the Monitors of its CDN",
+			Func:        tmcheck.PeerAgreementAllValidator,
+		},
+		{
+			Name:        "ds-availability",
+			Title:       "Delivery Service Availability",
+			Description: "validates no Delivery Service is available without available caches, or unavailable with all caches available",
+			Func:        tmcheck.AllMonitorsDSAvailabilityValidator,
+		},
 	}
-
-	go validator(toClient, interval, includeOffline, grace, onErr, onResumeSuccess, onCheck)
-	return logs
 }
 
 func main() {
@@ -140,59 +95,76 @@ func main() {
 	toPass := flag.String("topass", "", "The Traffic Ops password")
 	interval := flag.Duration("interval", time.Second*time.Duration(5), "The interval to validate")
 	grace := flag.Duration("grace", time.Second*time.Duration(30), "The grace period before invalid states are reported")
+	resolveAfter := flag.Duration("resolve", 0, "How long a monitor must stay valid before its alert is resolved. Defaults to the grace period")
 	includeOffline := flag.Bool("includeOffline", false, "Whether to include Offline Monitors")
+	historyLimit := flag.Int("history", 1000, "The number of checks to keep per validator and monitor")
+	addr := flag.String("addr", ":80", "The address to serve on")
+	webhook := flag.String("webhook", "", "A URL to POST JSON alerts to")
+	useSyslog := flag.Bool("syslog", false, "Whether to write alerts to syslog")
+	syslogNetwork := flag.String("syslogNetwork", "", "The network of the syslog daemon, e.g. udp. Defaults to the local daemon")
+	syslogAddr := flag.String("syslogAddr", "", "The address of the syslog daemon. Defaults to the local daemon")
 	help := flag.Bool("help", false, "Usage info")
 	helpBrief := flag.Bool("h", false, "Usage info")
 	flag.Parse()
 	if *help || *helpBrief {
-		fmt.Printf("Usage: go run validate-offline -to https://traffic-ops.example.net -touser bill -topass thelizard -tm http://traffic-monitor.example.net -interval 5s -grace 30s -includeOffline true\n")
+		fmt.Printf("Usage: go run validator-service.go -to https://traffic-ops.example.net -touser bill -topass thelizard -interval 5s -grace 30s -includeOffline true -webhook https://alerts.example.net/hook -syslog\n")
 		return
 	}
 
+	log.Init(nil, log.NopCloser(os.Stderr), log.NopCloser(os.Stderr), log.NopCloser(os.Stdout), nil)
+
+	notifiers := []validatorservice.Notifier{}
+	if *webhook != "" {
+		notifiers = append(notifiers, validatorservice.NewWebhookNotifier(*webhook, WebhookTimeout))
+	}
+	if *useSyslog {
+		notifier, err := validatorservice.NewSyslogNotifier(*syslogNetwork, *syslogAddr, "tm-validator")
+		if err != nil {
+			fmt.Printf("Error connecting to syslog: %v\n", err)
+			return
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	if *resolveAfter == 0 {
+		*resolveAfter = *grace
+	}
+
 	toClient, _, err := to.LoginWithAgent(*toURI, *toUser, *toPass, true, UserAgent, false, tmcheck.RequestTimeout)
 	if err != nil {
 		fmt.Printf("Error logging in to Traffic Ops: %v\n", err)
 		return
 	}
 
-	crStatesOfflineLogs := startValidator(tmcheck.AllMonitorsCRStatesOfflineValidator, toClient, *interval, *includeOffline, *grace)
-	peerPollerLogs := startValidator(tmcheck.PeerPollersAllValidator, toClient, *interval, *includeOffline, *grace)
-	dsStatsLogs := startValidator(tmcheck.AllMonitorsDSStatsValidator, toClient, *interval, *includeOffline, *grace)
-	queryIntervalLogs := startValidator(tmcheck.AllMonitorsQueryIntervalValidator, toClient, *interval, *includeOffline, *grace)
+	service := validatorservice.New(*historyLimit, validatorservice.NewAlerter(*grace, *resolveAfter, AlertLimit, notifiers...))
+	for _, v := range validators() {
+		service.Start(v, toClient, *interval, *includeOffline, *grace)
+	}
 
-	if err := serve(*toURI, crStatesOfflineLogs, peerPollerLogs, dsStatsLogs, queryIntervalLogs); err != nil {
+	if err := serve(*addr, *toURI, service); err != nil {
 		fmt.Printf("Serve error: %v\n", err)
 	}
 }
 
-func printLogs(logs Logs, w io.Writer) {
+func printStatuses(monitors []validatorservice.MonitorStatus, w io.Writer) {
 	fmt.Fprintf(w, `<table style="width:100%%">`)
-
-	monitors := logs.GetMonitors()
-	sort.Strings(monitors) // sort, so they're always in the same order in the webpage
 	for _, monitor := range monitors {
-		fmt.Fprintf(w, `</tr>`)
-
-		log := logs.Get(tc.TrafficMonitorName(monitor))
+		fmt.Fprintf(w, `<tr>`)
 
-		fmt.Fprintf(w, `<td><span>%s</span></td>`, monitor)
-		errored, lastCheck := log.GetErrored()
-		if errored {
+		name := string(monitor.Monitor)
+		if name == "" {
+			name = "(all monitors)"
+		}
+		fmt.Fprintf(w, `<td><span>%s</span></td>`, html.EscapeString(name))
+		if !monitor.Valid {
 			fmt.Fprintf(w, `<td><span style="color:red">Invalid</span></td>`)
 		} else {
 			fmt.Fprintf(w, `<td><span style="color:limegreen">Valid</span></td>`)
 		}
-		fmt.Fprintf(w, `<td><span>as of %v</span></td>`, lastCheck)
-
-		if errored {
-			fmt.Fprintf(w, `<td><span style="font-family:monospace">`)
-			logCopy := log.Get()
-			firstMsg := ""
-			if len(logCopy) > 0 {
-				firstMsg = logCopy[0]
-			}
-			fmt.Fprintf(w, "%s\n", firstMsg)
-			fmt.Fprintf(w, `</span></td>`)
+		fmt.Fprintf(w, `<td><span>as of %v</span></td>`, monitor.LastCheck)
+		fmt.Fprintf(w, `<td><span>%d of %d checks failed</span></td>`, monitor.Failures, monitor.Checks)
+
+		if !monitor.Valid {
+			fmt.Fprintf(w, `<td><span style="font-family:monospace">invalid since %v: %s</span></td>`, monitor.InvalidSince, html.EscapeString(monitor.LastError))
 		}
 
 		fmt.Fprintf(w, `</tr>`)
@@ -200,37 +172,30 @@ func printLogs(logs Logs, w io.Writer) {
 	fmt.Fprintf(w, `</table>`)
 }
 
-func serve(toURI string, crStatesOfflineLogs Logs, peerPollerLogs Logs, dsStatsLogs Logs, queryIntervalLogs Logs) error {
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+func serve(addr string, toURI string, service *validatorservice.Service) error {
+	mux := http.NewServeMux()
+	service.RegisterHandlers(mux)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprintf(w, `<!DOCTYPE html>
 <meta http-equiv="refresh" content="5">
 <meta charset="utf-8">
 <meta name="viewport" content="width=device-width, initial-scale=1">
-<title>Traffic Monitor Offline Validator</title>
+<title>Traffic Monitor Validator</title>
 <style type="text/css">body{margin:40px auto;line-height:1.6;font-size:18px;color:#444;padding:0 8px 0 8px}h1,h2,h3{line-height:1.2}span{padding:0px 4px 0px 4px;}</style>`)
 
 		fmt.Fprintf(w, `<h1>Traffic Monitor Validator</h1>`)
 
-		fmt.Fprintf(w, `<p>%s`, toURI)
+		fmt.Fprintf(w, `<p>%s`, html.EscapeString(toURI))
 		fmt.Fprintf(w, `<p>%s`, time.Now())
+		fmt.Fprintf(w, `<p><a href="/api/validators">JSON</a> <a href="/api/alerts">Alerts</a> <a href="/metrics">Metrics</a>`)
 
-		fmt.Fprintf(w, `<h2>CRStates Offline</h2>`)
-		fmt.Fprintf(w, `<h3>validates all OFFLINE and ADMIN_DOWN caches in the CRConfig are Unavailable</h3>`)
-		printLogs(crStatesOfflineLogs, w)
-
-		fmt.Fprintf(w, `<h2>Peer Poller</h2>`)
-		fmt.Fprintf(w, `<h3>validates all peers in the CRConfig have been polled within the last %v</h3>`, tmcheck.PeerPollMax)
-		printLogs(peerPollerLogs, w)
-
-		fmt.Fprintf(w, `<h2>Delivery Services</h2>`)
-		fmt.Fprintf(w, `<h3>validates all Delivery Services in the CRConfig exist in DsStats</h3>`)
-		printLogs(dsStatsLogs, w)
-
-		fmt.Fprintf(w, `<h2>Query Interval</h2>`)
-		fmt.Fprintf(w, `<h3>validates all Monitors' Query Interval (95th percentile) is less than %v</h3>`, tmcheck.QueryIntervalMax)
-		printLogs(queryIntervalLogs, w)
+		for _, status := range service.Statuses() {
+			fmt.Fprintf(w, `<h2>%s</h2>`, html.EscapeString(status.Title))
+			fmt.Fprintf(w, `<h3>%s</h3>`, html.EscapeString(status.Description))
+			printStatuses(status.Monitors, w)
+		}
 	})
-	return http.ListenAndServe(":80", nil)
+	return http.ListenAndServe(addr, mux)
 }
@@ -20,6 +20,7 @@ package towrap
  */
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -46,6 +47,8 @@ type ITrafficOpsSession interface {
 	DeliveryServices() ([]tc.DeliveryService, error)
 	CacheGroups() ([]tc.CacheGroup, error)
 	CRConfigHistory() []CRConfigStat
+	// WithContext returns a copy of the session whose Traffic Ops requests use the given context. See client.Session.WithContext.
+	WithContext(ctx context.Context) ITrafficOpsSession
 }
 
 var ErrNilSession = fmt.Errorf("nil session")
@@ -105,7 +108,7 @@ func (h CRConfigHistoryThreadsafe) Add(i *CRConfigStat) {
 
 	if *h.len != 0 {
 		last := (*h.hist)[(*h.pos-1)%*h.limit]
-		if i.ReqAddr == last.ReqAddr && *i.Stats.DateUnixSeconds == *last.Stats.DateUnixSeconds && *i.Stats.CDNName == *last.Stats.CDNName {
+		if i.ReqAddr == last.ReqAddr && sameStats(i.Stats, last.Stats) {
 			return
 		}
 	}
@@ -117,6 +120,14 @@ func (h CRConfigHistoryThreadsafe) Add(i *CRConfigStat) {
 	}
 }
 
+// sameStats returns whether the given stats have the same CDN and date. Stats missing either, such as those of failed requests, are never the same.
+func sameStats(a, b tc.CRConfigStats) bool {
+	if a.DateUnixSeconds == nil || b.DateUnixSeconds == nil || a.CDNName == nil || b.CDNName == nil {
+		return false
+	}
+	return *a.DateUnixSeconds == *b.DateUnixSeconds && *a.CDNName == *b.CDNName
+}
+
 func (h CRConfigHistoryThreadsafe) Get() []CRConfigStat {
 	h.m.RLock()
 	defer h.m.RUnlock()
@@ -148,6 +159,8 @@ type TrafficOpsSessionThreadsafe struct {
 	m            *sync.Mutex
 	lastCRConfig ByteMapCache
 	crConfigHist CRConfigHistoryThreadsafe
+	// ctx is the context of Traffic Ops requests, or nil to use the client's default.
+	ctx context.Context
 }
 
 // NewTrafficOpsSessionThreadsafe returns a new threadsafe TrafficOpsSessionThreadsafe wrapping the given `Session`.
@@ -169,9 +182,18 @@ func (s TrafficOpsSessionThreadsafe) get() *client.Session {
 	if s.session == nil || *s.session == nil {
 		return nil
 	}
+	if s.ctx != nil {
+		return (*s.session).WithContext(s.ctx)
+	}
 	return *s.session
 }
 
+// WithContext returns a copy of the TrafficOpsSessionThreadsafe whose Traffic Ops requests use the given context. The copy shares the original's session, so a Set on either is seen by both, and its CRConfig cache and history.
+func (s TrafficOpsSessionThreadsafe) WithContext(ctx context.Context) ITrafficOpsSession {
+	s.ctx = ctx
+	return s
+}
+
 func (s TrafficOpsSessionThreadsafe) URL() (string, error) {
 	ss := s.get()
 	if ss == nil {
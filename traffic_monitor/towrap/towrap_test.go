@@ -0,0 +1,75 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package towrap
+
+import (
+	"testing"
+	"time"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/totest"
+)
+
+func TestTrafficMonitorConfigMap(t *testing.T) {
+	cdn := "cdn1"
+	date := int64(1500000000)
+	edgeProfile, edgeStatus := "EDGE0", tc.CRConfigServerStatus("REPORTED")
+	to := totest.NewServer(totest.NewModel().
+		MonitoringConfig(cdn, tc.TrafficMonitorConfig{
+			TrafficServers: []tc.TrafficServer{{HostName: "stale", Profile: "EDGE0"}},
+			Profiles:       []tc.TMProfile{{Name: "EDGE0", Type: "EDGE"}},
+		}).
+		CRConfig(cdn, tc.CRConfig{
+			ContentServers: map[string]tc.CRConfigTrafficOpsServer{"edge0": {Profile: &edgeProfile, ServerStatus: &edgeStatus}},
+			Stats:          tc.CRConfigStats{CDNName: &cdn, DateUnixSeconds: &date},
+		}))
+	defer to.Close()
+
+	session, _, err := client.LoginWithAgent(to.URL, "admin", "password", false, "towrap", false, time.Second)
+	if err != nil {
+		t.Fatalf("LoginWithAgent expected: nil error, actual: %v", err)
+	}
+	s := NewTrafficOpsSessionThreadsafe(session, 5)
+
+	mc, err := s.TrafficMonitorConfigMap(cdn)
+	if err != nil {
+		t.Fatalf("TrafficMonitorConfigMap expected: nil error, actual: %v", err)
+	}
+	if _, ok := mc.TrafficServer["stale"]; ok || len(mc.TrafficServer) != 1 || mc.TrafficServer["edge0"].ServerStatus != string(edgeStatus) {
+		t.Errorf("TrafficMonitorConfigMap expected: servers from the CRConfig, actual: %+v", mc.TrafficServer)
+	}
+
+	older := date - 1
+	to.Update(func(m *totest.Model) {
+		m.CRConfig(cdn, tc.CRConfig{Stats: tc.CRConfigStats{CDNName: &cdn, DateUnixSeconds: &older}})
+	})
+	if _, err := s.CRConfigRaw(cdn); err == nil {
+		t.Errorf("CRConfigRaw older snapshot expected: error, actual: nil")
+	}
+	if hist := s.CRConfigHistory(); len(hist) != 2 || hist[1].Err == nil {
+		t.Errorf("CRConfigHistory expected: 2 entries with the newest invalid, actual: %+v", hist)
+	}
+
+	to.InjectFault(totest.Fault{PathPrefix: "/CRConfig-Snapshots/", BadJSON: true})
+	if _, err := s.CRConfigRaw(cdn); err == nil {
+		t.Errorf("CRConfigRaw bad JSON expected: error, actual: nil")
+	}
+}
@@ -0,0 +1,54 @@
+/*
+
+    Licensed under the Apache License, Version 2.0 (the "License");
+    you may not use this file except in compliance with the License.
+    You may obtain a copy of the License at
+
+        http://www.apache.org/licenses/LICENSE-2.0
+
+    Unless required by applicable law or agreed to in writing, software
+    distributed under the License is distributed on an "AS IS" BASIS,
+    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+    See the License for the specific language governing permissions and
+    limitations under the License.
+*/
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+CREATE TABLE topology (
+    name text PRIMARY KEY,
+    description text NOT NULL DEFAULT '',
+    last_updated timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE topology_cachegroup (
+    id bigserial PRIMARY KEY,
+    topology text NOT NULL REFERENCES topology (name) ON UPDATE CASCADE ON DELETE CASCADE,
+    cachegroup bigint NOT NULL REFERENCES cachegroup (id) ON DELETE RESTRICT,
+    last_updated timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT unique_topology_cachegroup UNIQUE (topology, cachegroup)
+);
+
+-- rank 1 is the parent, rank 2 the secondary parent.
+CREATE TABLE topology_cachegroup_parents (
+    child bigint NOT NULL REFERENCES topology_cachegroup (id) ON DELETE CASCADE,
+    parent bigint NOT NULL REFERENCES topology_cachegroup (id) ON DELETE CASCADE,
+    rank integer NOT NULL CHECK (rank IN (1, 2)),
+    last_updated timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT unique_child_rank UNIQUE (child, rank),
+    CONSTRAINT unique_child_parent UNIQUE (child, parent),
+    CONSTRAINT no_self_parent CHECK (child <> parent)
+);
+
+CREATE TRIGGER on_update_current_timestamp BEFORE UPDATE ON topology FOR EACH ROW EXECUTE PROCEDURE on_update_current_timestamp_last_updated();
+CREATE TRIGGER on_update_current_timestamp BEFORE UPDATE ON topology_cachegroup FOR EACH ROW EXECUTE PROCEDURE on_update_current_timestamp_last_updated();
+CREATE TRIGGER on_update_current_timestamp BEFORE UPDATE ON topology_cachegroup_parents FOR EACH ROW EXECUTE PROCEDURE on_update_current_timestamp_last_updated();
+
+ALTER TABLE deliveryservice ADD COLUMN topology text REFERENCES topology (name) ON UPDATE CASCADE ON DELETE RESTRICT;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+ALTER TABLE deliveryservice DROP COLUMN IF EXISTS topology;
+DROP TABLE IF EXISTS topology_cachegroup_parents;
+DROP TABLE IF EXISTS topology_cachegroup;
+DROP TABLE IF EXISTS topology;
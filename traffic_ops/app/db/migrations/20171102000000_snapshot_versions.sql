@@ -0,0 +1,34 @@
+/*
+
+    Licensed under the Apache License, Version 2.0 (the "License");
+    you may not use this file except in compliance with the License.
+    You may obtain a copy of the License at
+
+        http://www.apache.org/licenses/LICENSE-2.0
+
+    Unless required by applicable law or agreed to in writing, software
+    distributed under the License is distributed on an "AS IS" BASIS,
+    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+    See the License for the specific language governing permissions and
+    limitations under the License.
+*/
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+-- each snapshot of a CDN is a new row, and the CDN's current snapshot is its row with the greatest id
+-- Traffic Ops deletes all but the latest snapshot_versions_max (cdn.conf) rows of a CDN when it inserts one
+ALTER TABLE snapshot DROP CONSTRAINT snapshot_pkey;
+ALTER TABLE snapshot ADD COLUMN id bigserial PRIMARY KEY;
+CREATE INDEX snapshot_cdn_id_idx ON snapshot (cdn, id DESC);
+
+DROP TRIGGER on_update_current_timestamp ON snapshot;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+DELETE FROM snapshot s WHERE s.id < (SELECT MAX(l.id) FROM snapshot l WHERE l.cdn = s.cdn);
+
+CREATE TRIGGER on_update_current_timestamp BEFORE UPDATE ON snapshot FOR EACH ROW EXECUTE PROCEDURE on_update_current_timestamp_last_updated();
+
+DROP INDEX snapshot_cdn_id_idx;
+ALTER TABLE snapshot DROP COLUMN id;
+ALTER TABLE snapshot ADD PRIMARY KEY (cdn);
@@ -74,7 +74,7 @@ sub get_snapshot {
         return $self->not_found();
     }
 
-    my $snapshot = $self->db->resultset('Snapshot')->search( { cdn => $cdn_name } )->get_column('content')->single();
+    my $snapshot = $self->db->resultset('Snapshot')->search( { cdn => $cdn_name }, { order_by => { -desc => 'id' }, rows => 1 } )->get_column('content')->first();
     if ( !defined($snapshot) ) {
         return $self->success( {} );
     }
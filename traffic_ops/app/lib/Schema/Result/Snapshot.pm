@@ -34,6 +34,13 @@ __PACKAGE__->table("snapshot");
   data_type: 'json'
   is_nullable: 0
 
+=head2 id
+
+  data_type: 'bigint'
+  is_auto_increment: 1
+  is_nullable: 0
+  sequence: 'snapshot_id_seq'
+
 =head2 last_updated
 
   data_type: 'timestamp with time zone'
@@ -55,19 +62,26 @@ __PACKAGE__->add_columns(
     is_nullable   => 1,
     original      => { default_value => \"now()" },
   },
+  "id",
+  {
+    data_type         => "bigint",
+    is_auto_increment => 1,
+    is_nullable       => 0,
+    sequence          => "snapshot_id_seq",
+  },
 );
 
 =head1 PRIMARY KEY
 
 =over 4
 
-=item * L</cdn>
+=item * L</id>
 
 =back
 
 =cut
 
-__PACKAGE__->set_primary_key("cdn");
+__PACKAGE__->set_primary_key("id");
 
 =head1 RELATIONS
 
@@ -88,7 +102,7 @@ __PACKAGE__->belongs_to(
 
 
 # Created by DBIx::Class::Schema::Loader v0.07045 @ 2017-10-23 14:25:51
-# DO NOT MODIFY THIS OR ANYTHING ABOVE! md5sum:mti7+PLmEmigyWl7w9BqzA
+# DO NOT MODIFY THIS OR ANYTHING ABOVE! md5sum:85tJR/cauvPS5JpLpmP64Q
 
 
 # You can replace this text with custom code or comments, and it will be preserved on regeneration
@@ -25,7 +25,7 @@ sub get_cdn_snapshot {
     my $self = shift;
     my $cdn_name   = $self->param('cdn_name');
 
-    my $snapshot = $self->db->resultset('Snapshot')->search( { cdn => $cdn_name } )->get_column('content')->single();
+    my $snapshot = $self->db->resultset('Snapshot')->search( { cdn => $cdn_name }, { order_by => { -desc => 'id' }, rows => 1 } )->get_column('content')->first();
     if ( !defined($snapshot) ) {
         return $self->not_found();
     }
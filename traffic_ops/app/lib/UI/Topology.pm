@@ -563,13 +563,15 @@ sub write_crconfig_json_to_db {
     my $crconfig_db   = shift;
     my $crconfig_json = encode_json($crconfig_db);
 
-    my $snapshot = $self->db->resultset('Snapshot')->find( { cdn => $cdn_name } );
-    if ( defined($snapshot) ) {
-        $snapshot->update({ content => $crconfig_json });
-    } else {
-        my $insert = $self->db->resultset('Snapshot')->create( { cdn => $cdn_name, content => $crconfig_json } );
-        $insert->insert();
-    }
+    # every snapshot is a new version; the latest is the current snapshot, and only the latest snapshot_versions_max are kept
+    my $max_versions = $self->app->config->{snapshot_versions_max} || 100;
+    $self->db->txn_do(
+        sub {
+            $self->db->resultset('Snapshot')->create( { cdn => $cdn_name, content => $crconfig_json } )->insert();
+            my $keep = $self->db->resultset('Snapshot')->search( { cdn => $cdn_name }, { order_by => { -desc => 'id' }, rows => $max_versions } )->get_column('id')->as_query;
+            $self->db->resultset('Snapshot')->search( { cdn => $cdn_name, id => { -not_in => $keep } } )->delete();
+        }
+    );
 
 }
 
@@ -578,7 +580,7 @@ sub diff_crconfig_json {
     my $json     = shift;
     my $cdn_name = shift;
 
-    my $current_snapshot = $self->db->resultset('Snapshot')->search( { cdn => $cdn_name } )->get_column('content')->single();
+    my $current_snapshot = $self->db->resultset('Snapshot')->search( { cdn => $cdn_name }, { order_by => { -desc => 'id' }, rows => 1 } )->get_column('content')->first();
 
     if ( !defined($current_snapshot) )
     {
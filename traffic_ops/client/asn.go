@@ -0,0 +1,72 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetASNs gets the ASNs matching the given options. ASNs can be filtered by cachegroup, the ID of their cachegroup.
+func (to *Session) GetASNs(opts QueryOptions) ([]tc.ASN, ReqInf, error) {
+	var data tc.ASNsResponse
+	reqInf, err := get(to, withQuery(asnsEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetASNByID gets the ASN with the given ID.
+func (to *Session) GetASNByID(id int) (*tc.ASN, ReqInf, error) {
+	var data tc.ASNsResponse
+	reqInf, err := get(to, asnEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateASN creates the given ASN. Its cachegroup is set by ID.
+func (to *Session) CreateASN(a tc.ASN) (*tc.ASNResponse, ReqInf, error) {
+	var data tc.ASNResponse
+	reqInf, err := makeJSONReq(to, "POST", asnsEp(), &a, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateASNByID replaces the ASN with the given ID with the given ASN.
+func (to *Session) UpdateASNByID(id int, a tc.ASN) (*tc.ASNResponse, ReqInf, error) {
+	var data tc.ASNResponse
+	reqInf, err := makeJSONReq(to, "PUT", asnEp(strconv.Itoa(id)), &a, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteASNByID deletes the ASN with the given ID.
+func (to *Session) DeleteASNByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", asnEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
@@ -17,6 +17,7 @@ package client
 
 import (
 	"encoding/json"
+	"strconv"
 
 	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
 )
@@ -32,7 +33,7 @@ func (to *Session) CacheGroups() ([]tc.CacheGroup, error) {
 func (to *Session) GetCacheGroups() ([]tc.CacheGroup, ReqInf, error) {
 	url := "/api/1.2/cachegroups.json"
 	resp, remoteAddr, err := to.request("GET", url, nil) // TODO change to getBytesWithTTL, return CacheHitStatus
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -45,3 +46,64 @@ func (to *Session) GetCacheGroups() ([]tc.CacheGroup, ReqInf, error) {
 
 	return data.Response, reqInf, nil
 }
+
+// GetCacheGroupsWithOptions gets the cachegroups matching the given options. Cachegroups can be filtered by type, the ID of their type.
+func (to *Session) GetCacheGroupsWithOptions(opts QueryOptions) ([]tc.CacheGroup, ReqInf, error) {
+	var data tc.CacheGroupsResponse
+	reqInf, err := get(to, withQuery(cacheGroupsEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetCacheGroupByID gets the cachegroup with the given ID.
+func (to *Session) GetCacheGroupByID(id int) (*tc.CacheGroup, ReqInf, error) {
+	var data tc.CacheGroupsResponse
+	reqInf, err := get(to, cacheGroupEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateCacheGroup creates the given cachegroup. Its type and parents are set by ID.
+func (to *Session) CreateCacheGroup(cg tc.CacheGroup) (*tc.CacheGroupResponse, ReqInf, error) {
+	var data tc.CacheGroupResponse
+	reqInf, err := makeJSONReq(to, "POST", cacheGroupsEp(), cg, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateCacheGroupByID replaces the cachegroup with the given ID with the given cachegroup.
+func (to *Session) UpdateCacheGroupByID(id int, cg tc.CacheGroup) (*tc.CacheGroupResponse, ReqInf, error) {
+	var data tc.CacheGroupResponse
+	reqInf, err := makeJSONReq(to, "PUT", cacheGroupEp(strconv.Itoa(id)), cg, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteCacheGroupByID deletes the cachegroup with the given ID.
+func (to *Session) DeleteCacheGroupByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", cacheGroupEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
+
+// QueueCacheGroupUpdates queues updates on the servers of the cachegroup with the given ID in the given CDN if queue is true, or dequeues them if it's false.
+func (to *Session) QueueCacheGroupUpdates(id int, cdn string, queue bool) (*tc.CacheGroupQueueUpdateResponse, ReqInf, error) {
+	var data tc.CacheGroupQueueUpdateResponse
+	req := tc.CacheGroupQueueUpdateRequest{Action: queueAction(queue), CDN: cdn}
+	reqInf, err := makeJSONReq(to, "POST", cacheGroupQueueUpdateEp(strconv.Itoa(id)), req, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
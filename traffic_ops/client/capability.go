@@ -0,0 +1,72 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetCapabilities gets all capabilities.
+func (to *Session) GetCapabilities() ([]tc.Capability, ReqInf, error) {
+	var data tc.CapabilitiesResponse
+	reqInf, err := get(to, capabilitiesEp(), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetCapability gets the capability with the given name.
+func (to *Session) GetCapability(name string) (*tc.Capability, ReqInf, error) {
+	var data tc.CapabilitiesResponse
+	reqInf, err := get(to, capabilityEp(url.PathEscape(name)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateCapability creates the given capability.
+func (to *Session) CreateCapability(capability tc.Capability) (*tc.CapabilityResponse, ReqInf, error) {
+	var data tc.CapabilityResponse
+	reqInf, err := makeJSONReq(to, "POST", capabilitiesEp(), &capability, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateCapability replaces the description of the capability with the given name with that of the given capability.
+func (to *Session) UpdateCapability(name string, capability tc.Capability) (*tc.CapabilityResponse, ReqInf, error) {
+	var data tc.CapabilityResponse
+	reqInf, err := makeJSONReq(to, "PUT", capabilityEp(url.PathEscape(name)), &capability, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteCapability deletes the capability with the given name, which must not be used by any role or API route.
+func (to *Session) DeleteCapability(name string) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", capabilityEp(url.PathEscape(name)), nil, &data)
+	return data, reqInf, err
+}
@@ -0,0 +1,49 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetServerConfigFiles gets the list of config files the given cache server needs, where each goes on the server, and where to get it.
+func (to *Session) GetServerConfigFiles(hostName string) (tc.ATSConfigMetaData, ReqInf, error) {
+	var data tc.ATSConfigMetaData
+	reqInf, err := get(to, serverConfigFilesEp(hostName), &data)
+	if err != nil {
+		return tc.ATSConfigMetaData{}, reqInf, err
+	}
+	return data, reqInf, nil
+}
+
+// GetConfigFile gets the text of a config file from its Traffic Ops API path, the apiUri of the server's config file list.
+func (to *Session) GetConfigFile(apiURI string) ([]byte, ReqInf, error) {
+	resp, remoteAddr, err := to.request(http.MethodGet, apiURI, nil)
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return body, reqInf, nil
+}
@@ -0,0 +1,88 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// WithContext returns a copy of the Session whose requests use the given context, so they're cancelled when it is, and fail when its deadline passes. The copy shares the original's login cookie, cache and endpoint circuit breakers, and is cheap enough to make per call, e.g.
+//
+//	servers, reqInf, err := to.WithContext(ctx).GetServers()
+//
+// If the context has a trace ID, it's sent to Traffic Ops with every request. See WithTraceID.
+func (to *Session) WithContext(ctx context.Context) *Session {
+	if ctx == nil {
+		panic("nil context")
+	}
+	s := *to
+	s.ctx = ctx
+	return &s
+}
+
+// Context returns the context of the Session's requests, which is the background context unless the Session was returned by WithContext.
+func (to *Session) Context() context.Context {
+	return to.context()
+}
+
+func (to *Session) context() context.Context {
+	if to.ctx == nil {
+		return context.Background()
+	}
+	return to.ctx
+}
+
+type traceIDKey struct{}
+
+// WithTraceID returns a copy of the given context with the given trace ID. Requests made with the context, via Session.WithContext, send the trace ID to Traffic Ops, which logs it, so a single operation can be followed across services.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID of the given context, or the empty string if it has none.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// NewTraceID returns a new random trace ID, of 32 hex characters.
+func NewTraceID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails if the OS has no randomness, in which case the time is the best we can do.
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// sleepContext sleeps for the given duration, or until the context is done, in which case it returns the context's error.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,169 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+func TestWithContextCancels(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	hits := int32(0)
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer hung.Close()
+
+	to := newTestSession(hung.URL, hung.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := to.WithContext(ctx).getBytes("/api/1.2/cdns.json"); err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to be exceeded, actual %v", err)
+	}
+	if hits != 1 {
+		t.Errorf("expected a cancelled request not to fail over or retry, got %d requests", hits)
+	}
+	for _, ep := range to.Endpoints() {
+		if ep.State != BreakerStateClosed || ep.Failures != 0 {
+			t.Errorf("expected a cancelled request not to count as an endpoint failure, actual %+v", ep)
+		}
+	}
+	if to.Context() != context.Background() {
+		t.Errorf("expected WithContext not to change the original Session's context")
+	}
+}
+
+func TestWithContextCancelsBackoff(t *testing.T) {
+	hits := int32(0)
+	bad := statusServer(http.StatusServiceUnavailable, &hits)
+	defer bad.Close()
+
+	to := newTestSession(bad.URL)
+	to.Retry = RetryConfig{MaxRetries: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, err := to.WithContext(ctx).getBytes("/api/1.2/cdns.json"); err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to be exceeded during backoff, actual %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected cancellation to interrupt the backoff, took %v", elapsed)
+	}
+	if hits != 1 {
+		t.Errorf("expected 1 request before the backoff, got %d", hits)
+	}
+}
+
+func TestAbandonHalfOpen(t *testing.T) {
+	ep := newEndpoint("http://to.invalid")
+	cfg := RetryConfig{FailureThreshold: 1}
+	ep.failure(cfg, nil, time.Now())
+	if !ep.allow(cfg, time.Now()) {
+		t.Fatalf("expected an open circuit past its timeout to allow a probe")
+	}
+	ep.abandon()
+	if !ep.allow(cfg, time.Now()) {
+		t.Errorf("expected an abandoned probe to allow another probe")
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	traceIDs := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceIDs <- r.Header.Get(tc.TraceIDHeader)
+		w.Write([]byte(`{"response":[]}`))
+	}))
+	defer srv.Close()
+
+	to := newTestSession(srv.URL)
+	ctx := WithTraceID(context.Background(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	_, reqInf, err := to.WithContext(ctx).GetCDNs()
+	if err != nil {
+		t.Fatalf("getting CDNs: %v", err)
+	}
+	if sent := <-traceIDs; sent != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the context's trace ID to be sent, actual %q", sent)
+	}
+	if reqInf.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the trace ID in the ReqInf, actual %q", reqInf.TraceID)
+	}
+
+	if _, _, err := to.GetCDNs(); err != nil {
+		t.Fatalf("getting CDNs: %v", err)
+	}
+	if sent := <-traceIDs; sent != "" {
+		t.Errorf("expected no trace ID without one in the context, actual %q", sent)
+	}
+
+	a, b := NewTraceID(), NewTraceID()
+	if len(a) != 32 || a == b {
+		t.Errorf("expected unique 32-character trace IDs, actual %q and %q", a, b)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header
+		w.Write([]byte(`{"response":[]}`))
+	}))
+	defer srv.Close()
+
+	order := []string{}
+	observed := []string{}
+	to := newTestSession(srv.URL)
+	to.Middleware = []Middleware{
+		func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, "outer")
+				return next(req)
+			}
+		},
+		HeaderMiddleware(http.Header{"x-team": {"cdn"}}),
+		ObserveMiddleware(func(req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+			order = append(order, "observe")
+			if err == nil {
+				observed = append(observed, req.Method+" "+req.URL.Path+" "+resp.Status+" "+req.Header.Get("X-Team"))
+			}
+		}),
+	}
+
+	if _, _, err := to.GetCDNs(); err != nil {
+		t.Fatalf("getting CDNs: %v", err)
+	}
+	if h := <-headers; h.Get("X-Team") != "cdn" || h.Get("User-Agent") != "test" {
+		t.Errorf("expected the injected header and the user agent, actual %v", h)
+	}
+	if strings.Join(order, ",") != "outer,observe" {
+		t.Errorf("expected the first middleware to be outermost, actual %v", order)
+	}
+	if len(observed) != 1 || observed[0] != "GET /api/1.2/cdns.json 200 OK cdn" {
+		t.Errorf("expected the request to be observed with the injected header, actual %v", observed)
+	}
+}
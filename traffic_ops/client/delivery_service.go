@@ -17,6 +17,7 @@ package client
 
 import (
 	"encoding/json"
+	"net/url"
 	"strconv"
 
 	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
@@ -184,12 +185,14 @@ func (to *Session) GetDeliveryServiceRouting(id string) (*tc.DeliveryServiceRout
 }
 
 // DeliveryServiceServer gets the DeliveryServiceServer
-// Deprecated: use GetDeliveryServiceServer
+// Deprecated: use GetDeliveryServiceServers
 func (to *Session) DeliveryServiceServer(page, limit string) ([]tc.DeliveryServiceServer, error) {
 	dss, _, err := to.GetDeliveryServiceServer(page, limit)
 	return dss, err
 }
 
+// GetDeliveryServiceServer gets a page of the delivery service server assignments.
+// Deprecated: use GetDeliveryServiceServers
 func (to *Session) GetDeliveryServiceServer(page, limit string) ([]tc.DeliveryServiceServer, ReqInf, error) {
 	var data tc.DeliveryServiceServerResponse
 	reqInf, err := get(to, deliveryServiceServerEp(page, limit), &data)
@@ -249,3 +252,112 @@ func (to *Session) GetDeliveryServiceSSLKeysByHostname(hostname string) (*tc.Del
 
 	return &data.Response, reqInf, nil
 }
+
+// GetDeliveryServiceServers gets the assignments of servers to delivery services, ordered by delivery service unless the options order them otherwise. Traffic Ops always pages them, 20 at a time, unless the options have a page and limit.
+func (to *Session) GetDeliveryServiceServers(opts QueryOptions) ([]tc.DeliveryServiceServer, ReqInf, error) {
+	var data tc.DeliveryServiceServerResponse
+	reqInf, err := get(to, withQuery(deliveryServiceServersEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// AddDeliveryServiceSSLKeys adds the given certificate and private key to the delivery service of the request.
+func (to *Session) AddDeliveryServiceSSLKeys(req tc.DeliveryServiceSSLKeysReq) (string, ReqInf, error) {
+	var data messageResponse
+	reqInf, err := makeJSONReq(to, "POST", deliveryServiceSSLKeysAddEp(), req, &data)
+	return data.Response, reqInf, err
+}
+
+// GenerateDeliveryServiceSSLKeys generates a private key and self-signed certificate for the delivery service of the request, with the request's certificate subject.
+func (to *Session) GenerateDeliveryServiceSSLKeys(req tc.DeliveryServiceSSLKeysReq) (string, ReqInf, error) {
+	var data messageResponse
+	reqInf, err := makeJSONReq(to, "POST", deliveryServiceSSLKeysGenerateEp(), req, &data)
+	return data.Response, reqInf, err
+}
+
+// DeleteDeliveryServiceSSLKeys deletes the SSL keys of the delivery service with the given XMLID.
+func (to *Session) DeleteDeliveryServiceSSLKeys(xmlID string) (string, ReqInf, error) {
+	var data messageResponse
+	reqInf, err := get(to, deliveryServiceSSLKeysDeleteEp(url.PathEscape(xmlID)), &data)
+	return data.Response, reqInf, err
+}
+
+// GetDeliveryServiceURLSigKeys gets the URL signing keys of the delivery service with the given XMLID.
+func (to *Session) GetDeliveryServiceURLSigKeys(xmlID string) (tc.URLSigKeys, ReqInf, error) {
+	var data tc.URLSigKeysResponse
+	reqInf, err := get(to, deliveryServiceURLSigKeysEp(url.PathEscape(xmlID)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GenerateDeliveryServiceURLSigKeys generates new URL signing keys for the delivery service with the given XMLID, replacing its current keys.
+func (to *Session) GenerateDeliveryServiceURLSigKeys(xmlID string) (string, ReqInf, error) {
+	var data messageResponse
+	reqInf, err := makeReq(to, "POST", deliveryServiceURLSigKeysGenerateEp(url.PathEscape(xmlID)), nil, &data)
+	return data.Response, reqInf, err
+}
+
+// CopyDeliveryServiceURLSigKeys copies the URL signing keys of the delivery service with the XMLID copyFromXMLID to the delivery service with the given XMLID.
+func (to *Session) CopyDeliveryServiceURLSigKeys(xmlID, copyFromXMLID string) (string, ReqInf, error) {
+	var data messageResponse
+	reqInf, err := makeReq(to, "POST", deliveryServiceURLSigKeysCopyEp(url.PathEscape(xmlID), url.PathEscape(copyFromXMLID)), nil, &data)
+	return data.Response, reqInf, err
+}
+
+// GetDeliveryServiceURISignKeys gets the URI signing keys of the delivery service with the given XMLID, by issuer. A delivery service without keys has no issuers.
+func (to *Session) GetDeliveryServiceURISignKeys(xmlID string) (tc.URISignerKeys, ReqInf, error) {
+	keys := tc.URISignerKeys{}
+	reqInf, err := get(to, deliveryServiceURISignKeysEp(url.PathEscape(xmlID)), &keys)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	// Traffic Ops responds with an empty keyset, rather than no issuers, if the delivery service has no keys.
+	if _, ok := keys["keys"]; ok {
+		if _, ok := keys["renewal_kid"]; ok && len(keys) == 2 {
+			return tc.URISignerKeys{}, reqInf, nil
+		}
+	}
+	return keys, reqInf, nil
+}
+
+// CreateDeliveryServiceURISignKeys sets the URI signing keys of the delivery service with the given XMLID, which must not have keys already.
+func (to *Session) CreateDeliveryServiceURISignKeys(xmlID string, keys tc.URISignerKeys) (tc.URISignerKeys, ReqInf, error) {
+	data := tc.URISignerKeys{}
+	reqInf, err := makeJSONReq(to, "POST", deliveryServiceURISignKeysEp(url.PathEscape(xmlID)), keys, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data, reqInf, nil
+}
+
+// UpdateDeliveryServiceURISignKeys replaces the URI signing keys of the delivery service with the given XMLID.
+func (to *Session) UpdateDeliveryServiceURISignKeys(xmlID string, keys tc.URISignerKeys) (tc.URISignerKeys, ReqInf, error) {
+	data := tc.URISignerKeys{}
+	reqInf, err := makeJSONReq(to, "PUT", deliveryServiceURISignKeysEp(url.PathEscape(xmlID)), keys, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data, reqInf, nil
+}
+
+// DeleteDeliveryServiceURISignKeys deletes the URI signing keys of the delivery service with the given XMLID.
+func (to *Session) DeleteDeliveryServiceURISignKeys(xmlID string) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", deliveryServiceURISignKeysEp(url.PathEscape(xmlID)), nil, &data)
+	return data, reqInf, err
+}
+
+// SetDeliveryServiceTopology sets the topology of the delivery service with the given ID, or removes it if topology is nil.
+func (to *Session) SetDeliveryServiceTopology(id int, topology *string) (*tc.DeliveryServiceTopologyResponse, ReqInf, error) {
+	var data tc.DeliveryServiceTopologyResponse
+	req := tc.DeliveryServiceTopologyRequest{Topology: topology}
+	reqInf, err := makeJSONReq(to, "PUT", deliveryServiceTopologyEp(strconv.Itoa(id)), req, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
@@ -53,6 +53,10 @@ func deliveryServiceServerEp(page, limit string) string {
 	return apiBase + "/deliveryserviceserver.json?page=" + page + "&limit=" + limit
 }
 
+func deliveryServiceServersEp() string {
+	return apiBase + "/deliveryserviceserver"
+}
+
 func deliveryServiceRegexesEp() string {
 	return apiBase + "/deliveryservices_regexes.json"
 }
@@ -64,3 +68,35 @@ func deliveryServiceSSLKeysByIDEp(id string) string {
 func deliveryServiceSSLKeysByHostnameEp(hostname string) string {
 	return apiBase + dsPath + "/hostname/" + hostname + "/sslkeys.json"
 }
+
+func deliveryServiceSSLKeysAddEp() string {
+	return apiBase + dsPath + "/sslkeys/add"
+}
+
+func deliveryServiceSSLKeysGenerateEp() string {
+	return apiBase + dsPath + "/sslkeys/generate"
+}
+
+func deliveryServiceSSLKeysDeleteEp(xmlID string) string {
+	return apiBase + dsPath + "/xmlId/" + xmlID + "/sslkeys/delete"
+}
+
+func deliveryServiceURLSigKeysEp(xmlID string) string {
+	return apiBase + dsPath + "/xmlId/" + xmlID + "/urlkeys"
+}
+
+func deliveryServiceURLSigKeysGenerateEp(xmlID string) string {
+	return deliveryServiceURLSigKeysEp(xmlID) + "/generate"
+}
+
+func deliveryServiceURLSigKeysCopyEp(xmlID, copyFromXMLID string) string {
+	return deliveryServiceURLSigKeysEp(xmlID) + "/copyFromXmlId/" + copyFromXMLID
+}
+
+func deliveryServiceURISignKeysEp(xmlID string) string {
+	return apiBase13 + dsPath + "/" + xmlID + "/urisignkeys"
+}
+
+func deliveryServiceTopologyEp(id string) string {
+	return apiBase13 + dsPath + "/" + id + "/topology"
+}
@@ -0,0 +1,72 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetDivisions gets the divisions matching the given options.
+func (to *Session) GetDivisions(opts QueryOptions) ([]tc.Division, ReqInf, error) {
+	var data tc.DivisionsResponse
+	reqInf, err := get(to, withQuery(divisionsEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetDivisionByID gets the division with the given ID.
+func (to *Session) GetDivisionByID(id int) (*tc.Division, ReqInf, error) {
+	var data tc.DivisionsResponse
+	reqInf, err := get(to, divisionEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateDivision creates the given division.
+func (to *Session) CreateDivision(d tc.Division) (*tc.DivisionResponse, ReqInf, error) {
+	var data tc.DivisionResponse
+	reqInf, err := makeJSONReq(to, "POST", divisionsEp(), &d, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateDivisionByID replaces the division with the given ID with the given division.
+func (to *Session) UpdateDivisionByID(id int, d tc.Division) (*tc.DivisionResponse, ReqInf, error) {
+	var data tc.DivisionResponse
+	reqInf, err := makeJSONReq(to, "PUT", divisionEp(strconv.Itoa(id)), &d, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteDivisionByID deletes the division with the given ID.
+func (to *Session) DeleteDivisionByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", divisionEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
@@ -0,0 +1,56 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetCDNDNSSECKeys gets the DNSSEC keys of the given CDN and its delivery services, by zone.
+func (to *Session) GetCDNDNSSECKeys(cdn string) (tc.DNSSECKeys, ReqInf, error) {
+	var data tc.DNSSECKeysResponse
+	reqInf, err := get(to, cdnDNSSECKeysEp(url.PathEscape(cdn)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GenerateCDNDNSSECKeys generates new DNSSEC keys for the CDN of the request and its delivery services, replacing their current keys.
+func (to *Session) GenerateCDNDNSSECKeys(req tc.DNSSECKeysGenerateRequest) (string, ReqInf, error) {
+	var data messageResponse
+	reqInf, err := makeJSONReq(to, "POST", dnssecKeysGenerateEp(), req, &data)
+	return data.Response, reqInf, err
+}
+
+// DeleteCDNDNSSECKeys deletes the DNSSEC keys of the given CDN and its delivery services.
+func (to *Session) DeleteCDNDNSSECKeys(cdn string) (string, ReqInf, error) {
+	var data messageResponse
+	reqInf, err := get(to, cdnDNSSECKeysDeleteEp(url.PathEscape(cdn)), &data)
+	return data.Response, reqInf, err
+}
+
+// GetCDNDNSSECDSRecords gets the DS records of the unexpired key signing keys of the given CDN, for its parent zone.
+func (to *Session) GetCDNDNSSECDSRecords(cdn string) ([]tc.DNSSECDSRecord, ReqInf, error) {
+	var data tc.DNSSECDSRecordsResponse
+	reqInf, err := get(to, cdnDNSSECDSRecordsEp(url.PathEscape(cdn)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
@@ -0,0 +1,32 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+func cdnDNSSECKeysEp(cdn string) string {
+	return apiBase + "/cdns/name/" + cdn + "/dnsseckeys"
+}
+
+func cdnDNSSECKeysDeleteEp(cdn string) string {
+	return cdnDNSSECKeysEp(cdn) + "/delete"
+}
+
+func cdnDNSSECDSRecordsEp(cdn string) string {
+	return apiBase13 + "/cdns/name/" + cdn + "/dnsseckeys/ds"
+}
+
+func dnssecKeysGenerateEp() string {
+	return apiBase + "/cdns/dnsseckeys/generate"
+}
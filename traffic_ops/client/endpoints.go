@@ -16,3 +16,7 @@
 package client
 
 const apiBase = "/api/1.2"
+const internalAPIBase = "/internal" + apiBase
+
+// apiBase13 is the base of endpoints which were added in API version 1.3.
+const apiBase13 = "/api/1.3"
@@ -0,0 +1,91 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// ErrNotFound is returned when an object requested by ID or name doesn't exist, but Traffic Ops responded with an empty list rather than a Not Found status.
+var ErrNotFound = errors.New("not found")
+
+// HTTPError is returned when Traffic Ops responds with a status other than 200 OK. Alerts are the alerts in the response body, if it has any, which usually say why the request failed.
+type HTTPError struct {
+	HTTPStatusCode int
+	HTTPStatus     string
+	URL            string
+	Body           string
+	Alerts         []tc.Alert
+}
+
+// Error implements the error interface for our customer error type.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s[%d] - Error requesting Traffic Ops %s %s", e.HTTPStatus, e.HTTPStatusCode, e.URL, e.Body)
+}
+
+// ErrorAlerts returns the text of the error alerts of the response.
+func (e *HTTPError) ErrorAlerts() []string {
+	texts := []string{}
+	for _, alert := range e.Alerts {
+		if alert.Level == tc.ErrorLevel.String() {
+			texts = append(texts, alert.Text)
+		}
+	}
+	return texts
+}
+
+// newHTTPError returns the HTTPError of the given response, whose body has already been read. The body may not be JSON, for example if a proxy in front of Traffic Ops failed, in which case the error has no alerts.
+func newHTTPError(resp *http.Response, url string, body []byte) *HTTPError {
+	alerts := tc.Alerts{}
+	if err := json.Unmarshal(body, &alerts); err != nil {
+		alerts.Alerts = nil
+	}
+	return &HTTPError{
+		HTTPStatusCode: resp.StatusCode,
+		HTTPStatus:     resp.Status,
+		URL:            url,
+		Body:           string(body),
+		Alerts:         alerts.Alerts,
+	}
+}
+
+// StatusCode returns the HTTP status code of the Traffic Ops response which caused the given error, or 0 if the error wasn't caused by a response, or is nil.
+func StatusCode(err error) int {
+	if httpErr, ok := err.(*HTTPError); ok {
+		return httpErr.HTTPStatusCode
+	}
+	return 0
+}
+
+// IsNotFound returns whether the given error is a Not Found response, which Traffic Ops returns when the object requested doesn't exist, or ErrNotFound.
+func IsNotFound(err error) bool {
+	return err == ErrNotFound || StatusCode(err) == http.StatusNotFound
+}
+
+// IsBadRequest returns whether the given error is a Bad Request response, which Traffic Ops returns when a request is invalid, with alerts saying why.
+func IsBadRequest(err error) bool {
+	return StatusCode(err) == http.StatusBadRequest
+}
+
+// IsForbidden returns whether the given error is a Forbidden response, which Traffic Ops returns when the user doesn't have the privileges or tenancy for the request.
+func IsForbidden(err error) bool {
+	return StatusCode(err) == http.StatusForbidden
+}
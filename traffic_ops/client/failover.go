@@ -0,0 +1,182 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of the circuit breaker of a single Traffic Ops endpoint.
+type BreakerState string
+
+const BreakerStateClosed = BreakerState("closed")
+const BreakerStateOpen = BreakerState("open")
+const BreakerStateHalfOpen = BreakerState("half-open")
+
+func (s BreakerState) String() string {
+	return string(s)
+}
+
+// RetryConfig controls how a Session retries failed requests, and when it stops sending requests to a failing Traffic Ops.
+type RetryConfig struct {
+	// MaxRetries is the number of times an idempotent request is retried after the first attempt fails on every endpoint. Non-idempotent requests (POST) are never retried.
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry. It doubles on each subsequent retry, up to MaxDelay, and is jittered.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// FailureThreshold is the number of consecutive failures after which an endpoint's circuit opens. Zero disables circuit breaking. Circuits only open in Sessions with more than one endpoint, because with nowhere to fail over to, an open circuit would only reject requests Traffic Ops may have recovered enough to serve.
+	FailureThreshold int
+	// OpenTimeout is how long an open circuit rejects requests, before allowing a single probe request through.
+	OpenTimeout time.Duration
+}
+
+// DefaultRetryConfig is the RetryConfig of Sessions created by NewSession and LoginWithAgent.
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries:       2,
+	BaseDelay:        250 * time.Millisecond,
+	MaxDelay:         5 * time.Second,
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+}
+
+// backoff returns the jittered delay before the given retry, which starts at 1.
+func (c RetryConfig) backoff(retry int) time.Duration {
+	delay := c.BaseDelay
+	for i := 1; i < retry && delay < c.MaxDelay; i++ {
+		delay *= 2
+	}
+	if c.MaxDelay > 0 && delay > c.MaxDelay {
+		delay = c.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// "equal jitter": half the delay is fixed, the other half random, so clients which failed together don't retry together.
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// EndpointState is a snapshot of the health of a single Traffic Ops endpoint, as returned in ReqInf.
+type EndpointState struct {
+	URL       string
+	State     BreakerState
+	Failures  int
+	LastError string
+	OpenedAt  time.Time
+}
+
+// ErrNoAvailableEndpoints is returned when the circuits of all Traffic Ops endpoints are open.
+var ErrNoAvailableEndpoints = errors.New("no available Traffic Ops endpoints, all circuits are open")
+
+// endpoint is a Traffic Ops URL and its circuit breaker. It is safe for multiple goroutines.
+type endpoint struct {
+	url      string
+	m        sync.Mutex
+	state    BreakerState
+	failures int
+	lastErr  string
+	openedAt time.Time
+}
+
+func newEndpoint(url string) *endpoint {
+	return &endpoint{url: strings.TrimSuffix(url, "/"), state: BreakerStateClosed}
+}
+
+// allow returns whether a request may be sent to the endpoint. An open circuit whose timeout has passed becomes half-open, and allows exactly one probe request until the probe's result is recorded.
+func (e *endpoint) allow(cfg RetryConfig, now time.Time) bool {
+	e.m.Lock()
+	defer e.m.Unlock()
+	switch e.state {
+	case BreakerStateOpen:
+		if now.Sub(e.openedAt) < cfg.OpenTimeout {
+			return false
+		}
+		e.state = BreakerStateHalfOpen
+		return true
+	case BreakerStateHalfOpen:
+		return false // a probe is already in flight
+	default:
+		return true
+	}
+}
+
+// success records a request which reached a working Traffic Ops, closing the circuit.
+func (e *endpoint) success() {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.state = BreakerStateClosed
+	e.failures = 0
+}
+
+// failure records a failed request, opening the circuit if the failure threshold is reached, or if a half-open probe failed.
+func (e *endpoint) failure(cfg RetryConfig, err error, now time.Time) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.failures++
+	if err != nil {
+		e.lastErr = err.Error()
+	}
+	if cfg.FailureThreshold <= 0 {
+		return
+	}
+	if e.state == BreakerStateHalfOpen || e.failures >= cfg.FailureThreshold {
+		e.state = BreakerStateOpen
+		e.openedAt = now
+	}
+}
+
+// abandon records a request which was cancelled before its result was known. A half-open endpoint is reopened, without restarting its timeout, so the next request may probe it.
+func (e *endpoint) abandon() {
+	e.m.Lock()
+	defer e.m.Unlock()
+	if e.state == BreakerStateHalfOpen {
+		e.state = BreakerStateOpen
+	}
+}
+
+func (e *endpoint) snapshot() EndpointState {
+	e.m.Lock()
+	defer e.m.Unlock()
+	return EndpointState{URL: e.url, State: e.state, Failures: e.failures, LastError: e.lastErr, OpenedAt: e.openedAt}
+}
+
+// idempotent returns whether a request with the given method may safely be sent more than once.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// failed returns whether the given request result means the Traffic Ops endpoint is unhealthy, and the request should be sent elsewhere. Other errors, such as 404s, are returned to the caller unchanged.
+func failed(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,248 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetryConfig retries without sleeping, so the tests are fast.
+var testRetryConfig = RetryConfig{MaxRetries: 1, FailureThreshold: 2, OpenTimeout: time.Hour}
+
+// statusServer returns a server which responds to every request with the given status, and counts the requests.
+func statusServer(status int, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"response":[]}`))
+	}))
+}
+
+func newTestSession(urls ...string) *Session {
+	jar, _ := cookiejar.New(nil)
+	to := NewSessionWithURLs("user", "pass", urls, "test", &http.Client{Jar: jar}, false)
+	to.Retry = testRetryConfig
+	return to
+}
+
+func TestFailover(t *testing.T) {
+	down, up := int32(0), int32(0)
+	bad := statusServer(http.StatusServiceUnavailable, &down)
+	defer bad.Close()
+	good := statusServer(http.StatusOK, &up)
+	defer good.Close()
+
+	to := newTestSession(bad.URL, good.URL)
+	if _, _, err := to.getBytes("/api/1.2/cdns.json"); err != nil {
+		t.Fatalf("expected failover to the healthy endpoint, got error: %v", err)
+	}
+	if down != 1 || up != 1 {
+		t.Errorf("expected one request to each endpoint, got %d and %d", down, up)
+	}
+
+	// the second failure opens the unhealthy endpoint's circuit, after which it's skipped
+	to.getBytes("/api/1.2/cdns.json")
+	to.getBytes("/api/1.2/cdns.json")
+	if down != 2 || up != 3 {
+		t.Errorf("expected the open circuit to be skipped, got %d and %d requests", down, up)
+	}
+
+	reqInf := to.reqInf(CacheHitStatusMiss, nil)
+	if len(reqInf.Endpoints) != 2 {
+		t.Fatalf("expected 2 endpoint states, got %d", len(reqInf.Endpoints))
+	}
+	if reqInf.Endpoints[0].State != BreakerStateOpen || reqInf.Endpoints[0].Failures != 2 || reqInf.Endpoints[0].LastError == "" {
+		t.Errorf("expected the unhealthy endpoint's circuit to be open after 2 failures, got %+v", reqInf.Endpoints[0])
+	}
+	if reqInf.Endpoints[1].State != BreakerStateClosed {
+		t.Errorf("expected the healthy endpoint's circuit to be closed, got %+v", reqInf.Endpoints[1])
+	}
+}
+
+func TestRetryIdempotentOnly(t *testing.T) {
+	hits := int32(0)
+	bad := statusServer(http.StatusBadGateway, &hits)
+	defer bad.Close()
+
+	to := newTestSession(bad.URL)
+	to.Retry.FailureThreshold = 0
+	if _, _, err := to.request("GET", "/api/1.2/cdns.json", nil); err == nil {
+		t.Errorf("expected an error from a failing endpoint")
+	}
+	if hits != 2 {
+		t.Errorf("expected a GET to be retried once, got %d requests", hits)
+	}
+
+	hits = 0
+	if _, _, err := to.request("POST", "/api/1.2/cdns", []byte(`{}`)); err == nil {
+		t.Errorf("expected an error from a failing endpoint")
+	}
+	if hits != 1 {
+		t.Errorf("expected a POST not to be retried, got %d requests", hits)
+	}
+
+	// errors which don't indicate an unhealthy Traffic Ops aren't retried
+	hits = 0
+	notFound := statusServer(http.StatusNotFound, &hits)
+	defer notFound.Close()
+	to = newTestSession(notFound.URL)
+	if _, _, err := to.request("GET", "/api/1.2/nothing", nil); err == nil {
+		t.Errorf("expected an error for a 404")
+	}
+	if hits != 1 {
+		t.Errorf("expected a 404 not to be retried, got %d requests", hits)
+	}
+}
+
+func TestSingleEndpointNeverOpens(t *testing.T) {
+	hits := int32(0)
+	bad := statusServer(http.StatusServiceUnavailable, &hits)
+	defer bad.Close()
+
+	to := newTestSession(bad.URL)
+	to.Retry.MaxRetries = 0
+	for i := 0; i < testRetryConfig.FailureThreshold*2; i++ {
+		if _, _, err := to.request("GET", "/api/1.2/cdns.json", nil); err == nil || err == ErrNoAvailableEndpoints {
+			t.Fatalf("expected the endpoint's error, got %v", err)
+		}
+	}
+	if hits != int32(testRetryConfig.FailureThreshold*2) {
+		t.Errorf("expected every request to reach the only endpoint, got %d of %d", hits, testRetryConfig.FailureThreshold*2)
+	}
+	if states := to.Endpoints(); states[0].State != BreakerStateClosed || states[0].Failures != testRetryConfig.FailureThreshold*2 {
+		t.Errorf("expected the only endpoint's circuit to stay closed with its failures counted, got %+v", states[0])
+	}
+
+	// a Session which wasn't created with URLs has a single endpoint too
+	hits = 0
+	to = &Session{URL: bad.URL, Client: &http.Client{}, Retry: testRetryConfig, cache: map[string]CacheEntry{}, cacheMutex: &sync.RWMutex{}}
+	to.Retry.MaxRetries = 0
+	for i := 0; i < testRetryConfig.FailureThreshold*2; i++ {
+		to.request("GET", "/api/1.2/cdns.json", nil)
+	}
+	if hits != int32(testRetryConfig.FailureThreshold*2) {
+		t.Errorf("expected every request to reach the Session's URL, got %d of %d", hits, testRetryConfig.FailureThreshold*2)
+	}
+}
+
+func TestReloginOnUnauthorized(t *testing.T) {
+	logins := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/1.2/user/login" {
+			atomic.AddInt32(&logins, 1)
+			http.SetCookie(w, &http.Cookie{Name: "mojolicious", Value: "fresh", Path: "/"})
+			w.Write([]byte(`{"alerts":[{"level":"success","text":"Successfully logged in."}]}`))
+			return
+		}
+		if c, err := r.Cookie("mojolicious"); err != nil || c.Value != "fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"response":[]}`))
+	}))
+	defer server.Close()
+
+	to := newTestSession(server.URL)
+	resp, _, err := to.request("GET", "/api/1.2/cdns.json", nil)
+	if err != nil {
+		t.Fatalf("expected an expired cookie to be refreshed, got error: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := ioutil.ReadAll(resp.Body); string(body) != `{"response":[]}` {
+		t.Errorf("unexpected body after logging in again: %s", body)
+	}
+	if logins != 1 {
+		t.Errorf("expected 1 login, got %d", logins)
+	}
+}
+
+func TestLoginFailover(t *testing.T) {
+	hits := int32(0)
+	bad := statusServer(http.StatusServiceUnavailable, &hits)
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"alerts":[{"level":"success","text":"Successfully logged in."}]}`))
+	}))
+	defer good.Close()
+
+	if _, _, err := LoginWithAgentURLs([]string{bad.URL, good.URL}, "user", "pass", true, "test", false, time.Second); err != nil {
+		t.Errorf("expected login to fail over to the healthy endpoint, got error: %v", err)
+	}
+	if _, _, err := LoginWithAgentURLs(nil, "user", "pass", true, "test", false, time.Second); err == nil {
+		t.Errorf("expected an error logging in without URLs")
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	cfg := RetryConfig{FailureThreshold: 2, OpenTimeout: time.Minute}
+	ep := newEndpoint("http://to.example.net/")
+	if ep.url != "http://to.example.net" {
+		t.Errorf("expected the trailing slash to be removed, got %s", ep.url)
+	}
+	now := time.Now()
+
+	ep.failure(cfg, nil, now)
+	if !ep.allow(cfg, now) {
+		t.Errorf("expected the circuit to stay closed below the failure threshold")
+	}
+	ep.failure(cfg, nil, now)
+	if ep.allow(cfg, now.Add(time.Second)) {
+		t.Errorf("expected the circuit to open at the failure threshold")
+	}
+
+	later := now.Add(time.Minute)
+	if !ep.allow(cfg, later) {
+		t.Errorf("expected a probe to be allowed after the open timeout")
+	}
+	if ep.snapshot().State != BreakerStateHalfOpen {
+		t.Errorf("expected the circuit to be half-open, got %s", ep.snapshot().State)
+	}
+	if ep.allow(cfg, later) {
+		t.Errorf("expected only one probe while half-open")
+	}
+	ep.failure(cfg, nil, later)
+	if ep.allow(cfg, later.Add(time.Second)) {
+		t.Errorf("expected a failed probe to open the circuit again")
+	}
+
+	ep.allow(cfg, later.Add(time.Minute))
+	ep.success()
+	if state := ep.snapshot(); state.State != BreakerStateClosed || state.Failures != 0 {
+		t.Errorf("expected a successful probe to close the circuit, got %+v", state)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second}
+	for retry, max := range tests {
+		for i := 0; i < 20; i++ {
+			if d := cfg.backoff(retry); d < max/2 || d > max {
+				t.Errorf("retry %d: expected a backoff between %v and %v, got %v", retry, max/2, max, d)
+			}
+		}
+	}
+	if d := (RetryConfig{}).backoff(3); d != 0 {
+		t.Errorf("expected no backoff without a base delay, got %v", d)
+	}
+}
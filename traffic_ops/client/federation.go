@@ -0,0 +1,177 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetCDNFederations gets the federations of the given CDN, which are the federations of its delivery services.
+func (to *Session) GetCDNFederations(cdn string) ([]tc.CDNFederation, ReqInf, error) {
+	var data tc.CDNFederationsResponse
+	reqInf, err := get(to, cdnFederationsEp(url.PathEscape(cdn)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetCDNFederation gets the federation with the given ID of the given CDN.
+func (to *Session) GetCDNFederation(cdn string, id int) (*tc.CDNFederation, ReqInf, error) {
+	var data tc.CDNFederationsResponse
+	reqInf, err := get(to, cdnFederationEp(url.PathEscape(cdn), strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateCDNFederation creates the given federation. It's only in the CDN once delivery services of the CDN are assigned to it.
+func (to *Session) CreateCDNFederation(cdn string, fed tc.CDNFederation) (*tc.CDNFederationResponse, ReqInf, error) {
+	var data tc.CDNFederationResponse
+	reqInf, err := makeJSONReq(to, "POST", cdnFederationsEp(url.PathEscape(cdn)), fed, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateCDNFederation replaces the CNAME, TTL and description of the federation with the given ID with those of the given federation.
+func (to *Session) UpdateCDNFederation(cdn string, id int, fed tc.CDNFederation) (*tc.CDNFederationResponse, ReqInf, error) {
+	var data tc.CDNFederationResponse
+	reqInf, err := makeJSONReq(to, "PUT", cdnFederationEp(url.PathEscape(cdn), strconv.Itoa(id)), fed, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteCDNFederation deletes the federation with the given ID.
+func (to *Session) DeleteCDNFederation(cdn string, id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", cdnFederationEp(url.PathEscape(cdn), strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
+
+// GetFederationDeliveryServices gets the delivery services of the federation with the given ID.
+func (to *Session) GetFederationDeliveryServices(id int) ([]tc.FederationDeliveryService, ReqInf, error) {
+	var data tc.FederationDeliveryServicesResponse
+	reqInf, err := get(to, federationDeliveryServicesEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// AssignFederationDeliveryServices assigns the delivery services with the given IDs to the federation with the given ID, replacing its current delivery services if replace is true.
+func (to *Session) AssignFederationDeliveryServices(id int, dsIDs []int, replace bool) (*tc.AssignFederationDSesResponse, ReqInf, error) {
+	var data tc.AssignFederationDSesResponse
+	req := tc.AssignFederationDSesRequest{DSIDs: dsIDs, Replace: replace}
+	reqInf, err := makeJSONReq(to, "POST", federationDeliveryServicesEp(strconv.Itoa(id)), req, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteFederationDeliveryService removes the delivery service with the given ID from the federation with the given ID.
+func (to *Session) DeleteFederationDeliveryService(id int, dsID int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", federationDeliveryServiceEp(strconv.Itoa(id), strconv.Itoa(dsID)), nil, &data)
+	return data, reqInf, err
+}
+
+// GetFederationUsers gets the users of the federation with the given ID.
+func (to *Session) GetFederationUsers(id int) ([]tc.FederationUser, ReqInf, error) {
+	var data tc.FederationUsersResponse
+	reqInf, err := get(to, federationUsersEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// AssignFederationUsers assigns the users with the given IDs to the federation with the given ID, replacing its current users if replace is true.
+func (to *Session) AssignFederationUsers(id int, userIDs []int, replace bool) (*tc.AssignFederationUsersResponse, ReqInf, error) {
+	var data tc.AssignFederationUsersResponse
+	req := tc.AssignFederationUsersRequest{UserIDs: userIDs, Replace: replace}
+	reqInf, err := makeJSONReq(to, "POST", federationUsersEp(strconv.Itoa(id)), req, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteFederationUser removes the user with the given ID from the federation with the given ID.
+func (to *Session) DeleteFederationUser(id int, userID int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", federationUserEp(strconv.Itoa(id), strconv.Itoa(userID)), nil, &data)
+	return data, reqInf, err
+}
+
+// GetFederationFederationResolvers gets the resolvers of the federation with the given ID.
+func (to *Session) GetFederationFederationResolvers(id int) ([]tc.FederationResolver, ReqInf, error) {
+	var data tc.FederationResolversResponse
+	reqInf, err := get(to, federationFederationResolversEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// AssignFederationFederationResolvers assigns the resolvers with the given IDs to the federation with the given ID, replacing its current resolvers if replace is true.
+func (to *Session) AssignFederationFederationResolvers(id int, resolverIDs []int, replace bool) (*tc.AssignFederationResolversResponse, ReqInf, error) {
+	var data tc.AssignFederationResolversResponse
+	req := tc.AssignFederationResolversRequest{FedResolverIDs: resolverIDs, Replace: replace}
+	reqInf, err := makeJSONReq(to, "POST", federationFederationResolversEp(strconv.Itoa(id)), req, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// GetFederationResolvers gets all federation resolvers.
+func (to *Session) GetFederationResolvers() ([]tc.FederationResolver, ReqInf, error) {
+	var data tc.FederationResolversResponse
+	reqInf, err := get(to, federationResolversEp(), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// CreateFederationResolver creates the given federation resolver. Its type is set by ID.
+func (to *Session) CreateFederationResolver(resolver tc.FederationResolver) (*tc.FederationResolverResponse, ReqInf, error) {
+	var data tc.FederationResolverResponse
+	reqInf, err := makeJSONReq(to, "POST", federationResolversEp(), resolver, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteFederationResolver deletes the federation resolver with the given ID, removing it from its federations.
+func (to *Session) DeleteFederationResolver(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", federationResolverEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
@@ -0,0 +1,56 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+func cdnFederationsEp(cdn string) string {
+	return apiBase + "/cdns/" + cdn + "/federations"
+}
+
+func cdnFederationEp(cdn, id string) string {
+	return cdnFederationsEp(cdn) + "/" + id
+}
+
+func federationEp(id string) string {
+	return apiBase + "/federations/" + id
+}
+
+func federationDeliveryServicesEp(id string) string {
+	return federationEp(id) + "/deliveryservices"
+}
+
+func federationDeliveryServiceEp(id, dsID string) string {
+	return federationDeliveryServicesEp(id) + "/" + dsID
+}
+
+func federationUsersEp(id string) string {
+	return federationEp(id) + "/users"
+}
+
+func federationUserEp(id, userID string) string {
+	return federationUsersEp(id) + "/" + userID
+}
+
+func federationFederationResolversEp(id string) string {
+	return federationEp(id) + "/federation_resolvers"
+}
+
+func federationResolversEp() string {
+	return apiBase + "/federation_resolvers"
+}
+
+func federationResolverEp(id string) string {
+	return federationResolversEp() + "/" + id
+}
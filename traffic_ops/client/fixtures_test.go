@@ -0,0 +1,347 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// fixture is a recorded Traffic Ops request and response. If Query is set, the request must have the same query parameters, in any order, and if Request is set, the request body must be the same JSON.
+type fixture struct {
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Query    string          `json:"query"`
+	Request  json.RawMessage `json:"request"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+// fixtureSession returns a Session whose Traffic Ops serves the fixtures with the given names from testdata, and the fake Traffic Ops, which must be closed. Requests matching no fixture, or not matching their fixture's query or body, fail the test.
+func fixtureSession(t *testing.T, names ...string) (*Session, *httptest.Server) {
+	fixtures := map[string]fixture{}
+	for _, name := range names {
+		bts, err := ioutil.ReadFile(filepath.Join("testdata", name+".json"))
+		if err != nil {
+			t.Fatalf("reading fixture %v: %v", name, err)
+		}
+		f := fixture{}
+		if err := json.Unmarshal(bts, &f); err != nil {
+			t.Fatalf("decoding fixture %v: %v", name, err)
+		}
+		fixtures[f.Method+" "+f.Path] = f
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := fixtures[r.Method+" "+r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %v %v", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if f.Query != "" {
+			expected, _ := url.ParseQuery(f.Query)
+			if !reflect.DeepEqual(expected, r.URL.Query()) {
+				t.Errorf("%v %v expected query %v, actual %v", r.Method, r.URL.Path, f.Query, r.URL.RawQuery)
+			}
+		}
+		if len(f.Request) > 0 {
+			body, _ := ioutil.ReadAll(r.Body)
+			if !sameJSON(t, f.Request, body) {
+				t.Errorf("%v %v expected body %s, actual %s", r.Method, r.URL.Path, f.Request, body)
+			}
+		}
+		if f.Status != 0 {
+			w.WriteHeader(f.Status)
+		}
+		w.Write(f.Response)
+	}))
+	return newTestSession(srv.URL), srv
+}
+
+// sameJSON returns whether a and b are the same JSON value, ignoring whitespace and the order of object members.
+func sameJSON(t *testing.T, a, b []byte) bool {
+	var av, bv interface{}
+	if err := json.Unmarshal(a, &av); err != nil {
+		t.Errorf("decoding JSON %s: %v", a, err)
+		return false
+	}
+	if err := json.Unmarshal(b, &bv); err != nil {
+		t.Errorf("decoding JSON %s: %v", b, err)
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+func TestGetServersWithOptions(t *testing.T) {
+	to, srv := fixtureSession(t, "servers_edge_by_hostname", "server_by_id_empty")
+	defer srv.Close()
+
+	opts := QueryOptions{}.WithFilter("type", "EDGE").WithOrderBy("hostName").WithPage(1, 2)
+	servers, reqInf, err := to.GetServersWithOptions(opts)
+	if err != nil {
+		t.Fatalf("getting servers: %v", err)
+	}
+	if len(servers) != 2 || servers[0].HostName != "edge-01" || servers[1].Status != "ADMIN_DOWN" {
+		t.Errorf("expected edge-01 and edge-02, actual %+v", servers)
+	}
+	if reqInf.RemoteAddr == nil {
+		t.Errorf("expected the request info to have the remote address")
+	}
+
+	if _, _, err := to.GetServerByID(99); err != ErrNotFound || !IsNotFound(err) {
+		t.Errorf("expected ErrNotFound getting a server which doesn't exist, actual %v", err)
+	}
+}
+
+func TestServerUpdates(t *testing.T) {
+	to, srv := fixtureSession(t, "server_queue_update", "server_status_no_reason")
+	defer srv.Close()
+
+	resp, _, err := to.QueueServerUpdates(7, true)
+	if err != nil {
+		t.Fatalf("queueing updates: %v", err)
+	}
+	if resp.Response.Action != "queue" || resp.Response.ServerID != "7" {
+		t.Errorf("expected updates queued on server 7, actual %+v", resp.Response)
+	}
+
+	_, _, err = to.UpdateServerStatus(7, tc.ServerStatusRequest{Status: "OFFLINE"})
+	httpErr, ok := err.(*HTTPError)
+	if !ok {
+		t.Fatalf("expected an *HTTPError setting a status without a reason, actual %T %v", err, err)
+	}
+	if !IsBadRequest(err) || StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("expected a Bad Request, actual %v", httpErr.HTTPStatusCode)
+	}
+	if alerts := httpErr.ErrorAlerts(); len(alerts) != 1 || alerts[0] != "Offline reason is required for ADMIN_DOWN or OFFLINE status." {
+		t.Errorf("expected the response's error alert, actual %v", alerts)
+	}
+}
+
+func TestCreateCacheGroup(t *testing.T) {
+	to, srv := fixtureSession(t, "cachegroup_create")
+	defer srv.Close()
+
+	parentID := 4
+	cg := tc.CacheGroup{Name: "us-co-boulder", ShortName: "boulder", Latitude: 40.01, Longitude: -105.27, ParentCachegroupID: &parentID, TypeID: 6}
+	resp, _, err := to.CreateCacheGroup(cg)
+	if err != nil {
+		t.Fatalf("creating cachegroup: %v", err)
+	}
+	if resp.Response.ID != 12 || resp.Response.ParentName != "mid-northwest" || resp.Response.SecondaryParentCachegroupID != nil {
+		t.Errorf("expected the created cachegroup, actual %+v", resp.Response)
+	}
+	if len(resp.Alerts.Alerts) != 1 || resp.Alerts.Alerts[0].Level != tc.SuccessLevel.String() {
+		t.Errorf("expected a success alert, actual %+v", resp.Alerts)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	to, srv := fixtureSession(t, "profile_with_params", "profile_not_found")
+	defer srv.Close()
+
+	profile, _, err := to.GetProfileByID(3, true)
+	if err != nil {
+		t.Fatalf("getting profile: %v", err)
+	}
+	if profile.CDNName != "cdn1" || profile.Type != "ATS_PROFILE" || len(profile.Parameters) != 2 {
+		t.Errorf("expected the profile with its CDN, type and parameters, actual %+v", profile)
+	}
+
+	_, _, err = to.DeleteProfileByID(404)
+	if !IsNotFound(err) {
+		t.Errorf("expected Not Found deleting a profile which doesn't exist, actual %v", err)
+	}
+}
+
+func TestCreateParameters(t *testing.T) {
+	to, srv := fixtureSession(t, "parameters_create", "profileparameters_create")
+	defer srv.Close()
+
+	params := []tc.Parameter{
+		{Name: "health.threshold.loadavg", ConfigFile: "rascal.properties", Value: "25.0"},
+		{Name: "snmp.community", ConfigFile: "rascal.properties", Value: "s3cret", Secure: true},
+	}
+	resp, _, err := to.CreateParameters(params)
+	if err != nil {
+		t.Fatalf("creating parameters: %v", err)
+	}
+	if len(resp.Response) != 2 || resp.Response[0].ID != 101 || resp.Response[0].Secure || !resp.Response[1].Secure {
+		t.Errorf("expected the created parameters, with numeric secure flags decoded, actual %+v", resp.Response)
+	}
+
+	ppResp, _, err := to.CreateProfileParameters([]tc.ProfileParameter{{ProfileID: 3, ParameterID: 101}})
+	if err != nil {
+		t.Fatalf("assigning parameters: %v", err)
+	}
+	if len(ppResp.Response) != 1 || ppResp.Response[0].ParameterID != 101 {
+		t.Errorf("expected the assignment, actual %+v", ppResp.Response)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	to, srv := fixtureSession(t, "user_current", "user_create", "user_roles_set")
+	defer srv.Close()
+
+	user, _, err := to.GetUserCurrent()
+	if err != nil {
+		t.Fatalf("getting current user: %v", err)
+	}
+	if user.Username != "admin" || user.Tenant != "root" || user.TenantID == nil || *user.TenantID != 1 {
+		t.Errorf("expected the admin user of the root tenant, actual %+v", user)
+	}
+
+	tenantID := 1
+	newUser := tc.User{
+		Username:             "ops",
+		Role:                 3,
+		Email:                "ops@kabletown.net",
+		FullName:             "Operations",
+		TenantID:             &tenantID,
+		LocalPassword:        "correct horse battery staple",
+		ConfirmLocalPassword: "correct horse battery staple",
+	}
+	resp, _, err := to.CreateUser(newUser)
+	if err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+	if resp.Response.ID != 9 || resp.Response.LocalPassword != "" {
+		t.Errorf("expected the created user, without its password, actual %+v", resp.Response)
+	}
+
+	rolesResp, _, err := to.SetUserRoles(9, []int{5, 6})
+	if err != nil {
+		t.Fatalf("setting user roles: %v", err)
+	}
+	if !reflect.DeepEqual(rolesResp.Response.RoleIDs, []int{5, 6}) {
+		t.Errorf("expected roles 5 and 6, actual %v", rolesResp.Response.RoleIDs)
+	}
+}
+
+func TestGetDeliveryServiceServers(t *testing.T) {
+	to, srv := fixtureSession(t, "deliveryserviceserver_page")
+	defer srv.Close()
+
+	dss, _, err := to.GetDeliveryServiceServers(QueryOptions{}.WithOrderBy("server").WithPage(3, 2))
+	if err != nil {
+		t.Fatalf("getting delivery service servers: %v", err)
+	}
+	if len(dss) != 2 || dss[0].Server != 7 || dss[1].DeliveryService != 21 {
+		t.Errorf("expected the third page of 2 assignments, actual %+v", dss)
+	}
+}
+
+func TestFederations(t *testing.T) {
+	to, srv := fixtureSession(t, "cdn_federation_create", "federation_resolvers_assign")
+	defer srv.Close()
+
+	desc := "fed for the other CDN"
+	resp, _, err := to.CreateCDNFederation("cdn1", tc.CDNFederation{CName: "the.cname.", TTL: 60, Description: &desc})
+	if err != nil {
+		t.Fatalf("creating federation: %v", err)
+	}
+	if resp.Response.ID != 5 {
+		t.Errorf("expected federation 5, actual %+v", resp.Response)
+	}
+
+	assignResp, _, err := to.AssignFederationFederationResolvers(5, []int{1, 2}, true)
+	if err != nil {
+		t.Fatalf("assigning resolvers: %v", err)
+	}
+	if !assignResp.Response.Replace || len(assignResp.Response.FedResolverIDs) != 2 {
+		t.Errorf("expected resolvers 1 and 2 to replace the federation's resolvers, actual %+v", assignResp.Response)
+	}
+}
+
+func TestDeliveryServiceKeys(t *testing.T) {
+	to, srv := fixtureSession(t, "urisignkeys", "urisignkeys_none", "urlkeys", "sslkeys_generate")
+	defer srv.Close()
+
+	keys, _, err := to.GetDeliveryServiceURISignKeys("demo1")
+	if err != nil {
+		t.Fatalf("getting URI signing keys: %v", err)
+	}
+	keyset, ok := keys["Kabletown URI Authority"]
+	if len(keys) != 1 || !ok || keyset.RenewalKid == nil || *keyset.RenewalKid != "First Key" || len(keyset.Keys) != 1 {
+		t.Errorf("expected one issuer with one key, actual %+v", keys)
+	}
+
+	keys, _, err = to.GetDeliveryServiceURISignKeys("demo2")
+	if err != nil {
+		t.Fatalf("getting URI signing keys: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("expected no issuers for a delivery service without keys, actual %+v", keys)
+	}
+
+	urlKeys, _, err := to.GetDeliveryServiceURLSigKeys("demo1")
+	if err != nil {
+		t.Fatalf("getting URL signing keys: %v", err)
+	}
+	if len(urlKeys) != 2 || urlKeys["key1"] != "vWELkxyj1hJJ8ZLqYWCP4NyV5btkT9MV" {
+		t.Errorf("expected 2 URL signing keys, actual %v", urlKeys)
+	}
+
+	req := tc.DeliveryServiceSSLKeysReq{
+		CDN:             "cdn1",
+		DeliveryService: "demo1",
+		Key:             "demo1",
+		Version:         1,
+		Hostname:        "*.demo1.mycdn.ciab.test",
+		BusinessUnit:    "CDN",
+		City:            "Denver",
+		Organization:    "Kabletown",
+		Country:         "US",
+		State:           "CO",
+	}
+	msg, _, err := to.GenerateDeliveryServiceSSLKeys(req)
+	if err != nil {
+		t.Fatalf("generating SSL keys: %v", err)
+	}
+	if msg != "Successfully created ssl keys for demo1" {
+		t.Errorf("expected the success message, actual %v", msg)
+	}
+}
+
+func TestReferenceData(t *testing.T) {
+	to, srv := fixtureSession(t, "topology", "asn_create")
+	defer srv.Close()
+
+	topology, _, err := to.GetTopology("mso")
+	if err != nil {
+		t.Fatalf("getting topology: %v", err)
+	}
+	if len(topology.Nodes) != 3 || !reflect.DeepEqual(topology.Nodes[2].Parents, []int{1}) {
+		t.Errorf("expected the 3 nodes of the topology, actual %+v", topology.Nodes)
+	}
+
+	resp, _, err := to.CreateASN(tc.ASN{ASN: 64512, CachegroupID: 6})
+	if err != nil {
+		t.Fatalf("creating ASN: %v", err)
+	}
+	if resp.Response.ID != 3 || resp.Response.Cachegroup != "us-co-denver" {
+		t.Errorf("expected the created ASN, actual %+v", resp.Response)
+	}
+}
@@ -35,7 +35,7 @@ func (to *Session) GetHardware(limit int) ([]tc.Hardware, ReqInf, error) {
 		url += fmt.Sprintf("?limit=%v", limit)
 	}
 	resp, remoteAddr, err := to.request("GET", url, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -0,0 +1,83 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// CreateInvalidationJob creates a job invalidating the content of a delivery service matching a regex, as the current user.
+func (to *Session) CreateInvalidationJob(job tc.InvalidationJobRequest) (*tc.JobResponse, ReqInf, error) {
+	var data tc.JobResponse
+	jsonReq, err := json.Marshal(job)
+	if err != nil {
+		return nil, ReqInf{}, err
+	}
+	reqInf, err := makeReq(to, "POST", userJobsEp(), jsonReq, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// GetInvalidationJobs gets the invalidation jobs, newest first. If dsID or userID are non-zero, only the jobs of that delivery service or user are returned.
+func (to *Session) GetInvalidationJobs(dsID int, userID int) ([]tc.Job, ReqInf, error) {
+	params := url.Values{}
+	if dsID != 0 {
+		params.Set("dsId", strconv.Itoa(dsID))
+	}
+	if userID != 0 {
+		params.Set("userId", strconv.Itoa(userID))
+	}
+	ep := jobsEp()
+	if len(params) > 0 {
+		ep += "?" + params.Encode()
+	}
+
+	var data tc.JobsResponse
+	reqInf, err := get(to, ep, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// DeleteInvalidationJob deletes the invalidation job with the given ID, ending its invalidation.
+func (to *Session) DeleteInvalidationJob(id int) (*tc.JobResponse, ReqInf, error) {
+	var data tc.JobResponse
+	reqInf, err := makeReq(to, "DELETE", jobEp(strconv.Itoa(id)), nil, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// GetInvalidationJob gets the invalidation job with the given ID.
+func (to *Session) GetInvalidationJob(id int) (*tc.Job, ReqInf, error) {
+	var data tc.JobsResponse
+	reqInf, err := get(to, jobEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
@@ -0,0 +1,71 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// RoundTripFunc performs a single HTTP request to Traffic Ops.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the HTTP requests of a Session, for logging, metrics, header injection, etc. It's called for every HTTP request, including logins, retries and failovers, so a single client call may pass through it more than once. Middleware must not modify the given request, only a clone of it, and must close the body of any response it doesn't return.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// roundTrip returns the Session's HTTP client, wrapped in its middleware.
+func (to *Session) roundTrip() RoundTripFunc {
+	rt := RoundTripFunc(to.Client.Do)
+	for i := len(to.Middleware) - 1; i >= 0; i-- {
+		rt = to.Middleware[i](rt)
+	}
+	return rt
+}
+
+// HeaderMiddleware returns Middleware which sets the given headers on every request, replacing any existing values.
+func HeaderMiddleware(headers http.Header) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req = cloneRequest(req)
+			for name, vals := range headers {
+				req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), vals...)
+			}
+			return next(req)
+		}
+	}
+}
+
+// ObserveMiddleware returns Middleware which calls observe after every request, with the request, its response or error, and how long it took. The response body must not be read. This is intended for logging and metrics.
+func ObserveMiddleware(observe func(req *http.Request, resp *http.Response, err error, elapsed time.Duration)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			observe(req, resp, err, time.Since(start))
+			return resp, err
+		}
+	}
+}
+
+// cloneRequest returns a shallow copy of the given request, with a copy of its headers.
+func cloneRequest(req *http.Request) *http.Request {
+	r := req.WithContext(req.Context())
+	r.Header = make(http.Header, len(req.Header))
+	for name, vals := range req.Header {
+		r.Header[name] = append([]string(nil), vals...)
+	}
+	return r
+}
@@ -0,0 +1,92 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// QueryOptions are the query parameters of a request for a list of objects: filters on the objects' fields, the field to order them by, and which page of them to return. The zero value requests every object, in Traffic Ops' default order.
+//
+// Options are built by chaining, which never modifies the options it's called on, so options can be shared and extended:
+//
+//	edges := client.QueryOptions{}.WithFilter("type", "EDGE")
+//	servers, _, err := to.GetServersWithOptions(edges.WithOrderBy("hostName").WithPage(1, 100))
+//
+// Which fields can be filtered and ordered by depends on the endpoint. Traffic Ops ignores parameters an endpoint doesn't support.
+type QueryOptions struct {
+	Filters url.Values
+	OrderBy string
+	// Page is the 1-indexed page of Limit objects to return. Both are ignored if Limit is 0.
+	Page  int
+	Limit int
+}
+
+// WithFilter returns a copy of the options which also filters on the given field having the given value.
+func (o QueryOptions) WithFilter(field, value string) QueryOptions {
+	filters := url.Values{}
+	for k, v := range o.Filters {
+		filters[k] = append([]string(nil), v...)
+	}
+	filters.Add(field, value)
+	o.Filters = filters
+	return o
+}
+
+// WithOrderBy returns a copy of the options which orders by the given field.
+func (o QueryOptions) WithOrderBy(field string) QueryOptions {
+	o.OrderBy = field
+	return o
+}
+
+// WithPage returns a copy of the options which returns the given 1-indexed page of limit objects.
+func (o QueryOptions) WithPage(page, limit int) QueryOptions {
+	o.Page = page
+	o.Limit = limit
+	return o
+}
+
+// Values returns the query parameters of the options.
+func (o QueryOptions) Values() url.Values {
+	v := url.Values{}
+	for k, vals := range o.Filters {
+		for _, val := range vals {
+			v.Add(k, val)
+		}
+	}
+	if o.OrderBy != "" {
+		v.Set("orderby", o.OrderBy)
+	}
+	if o.Limit > 0 {
+		page := o.Page
+		if page < 1 {
+			page = 1
+		}
+		v.Set("limit", strconv.Itoa(o.Limit))
+		v.Set("page", strconv.Itoa(page))
+	}
+	return v
+}
+
+// withQuery returns the endpoint with the query string of the options, if they have any parameters.
+func withQuery(endpoint string, opts QueryOptions) string {
+	v := opts.Values()
+	if len(v) == 0 {
+		return endpoint
+	}
+	return endpoint + "?" + v.Encode()
+}
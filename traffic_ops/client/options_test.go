@@ -0,0 +1,40 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+)
+
+func TestQueryOptions(t *testing.T) {
+	if ep := withQuery("/api/1.2/servers", QueryOptions{}); ep != "/api/1.2/servers" {
+		t.Errorf("expected no query string for empty options, actual %v", ep)
+	}
+
+	base := QueryOptions{}.WithFilter("type", "EDGE")
+	paged := base.WithFilter("status", "REPORTED").WithOrderBy("hostName").WithPage(0, 50)
+	if ep := withQuery("/api/1.2/servers", paged); ep != "/api/1.2/servers?limit=50&orderby=hostName&page=1&status=REPORTED&type=EDGE" {
+		t.Errorf("expected filters, order and the first page, actual %v", ep)
+	}
+	if len(base.Filters) != 1 || base.OrderBy != "" {
+		t.Errorf("expected chaining not to modify the options it's called on, actual %+v", base)
+	}
+
+	unlimited := QueryOptions{Page: 3}
+	if v := unlimited.Values(); len(v) != 0 {
+		t.Errorf("expected a page without a limit to be ignored, actual %v", v)
+	}
+}
@@ -18,6 +18,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
 )
@@ -32,7 +33,7 @@ func (to *Session) Parameters(profileName string) ([]tc.Parameter, error) {
 func (to *Session) GetParameters(profileName string) ([]tc.Parameter, ReqInf, error) {
 	url := fmt.Sprintf("/api/1.2/parameters/profile/%s.json", profileName)
 	resp, remoteAddr, err := to.request("GET", url, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -45,3 +46,83 @@ func (to *Session) GetParameters(profileName string) ([]tc.Parameter, ReqInf, er
 
 	return data.Response, reqInf, nil
 }
+
+// GetAllParameters gets the parameters matching the given options, of every profile. Parameters can be filtered by name, configFile and value.
+func (to *Session) GetAllParameters(opts QueryOptions) ([]tc.Parameter, ReqInf, error) {
+	var data tc.ParametersResponse
+	reqInf, err := get(to, withQuery(parametersEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetParameterByID gets the parameter with the given ID.
+func (to *Session) GetParameterByID(id int) (*tc.Parameter, ReqInf, error) {
+	var data tc.ParametersResponse
+	reqInf, err := get(to, parameterEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateParameters creates the given parameters, all or none of them, and returns them with their IDs.
+func (to *Session) CreateParameters(params []tc.Parameter) (*tc.ParameterResponse, ReqInf, error) {
+	reqParams := make([]parameterRequest, 0, len(params))
+	for _, p := range params {
+		reqParams = append(reqParams, parameterRequest{Name: p.Name, ConfigFile: p.ConfigFile, Value: p.Value, Secure: p.Secure})
+	}
+	var data tc.ParameterResponse
+	reqInf, err := makeJSONReq(to, "POST", parametersEp(), reqParams, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateParameterByID replaces the name, config file, value and secure flag of the parameter with the given ID with those of the given parameter.
+func (to *Session) UpdateParameterByID(id int, param tc.Parameter) (*tc.ParameterUpdateResponse, ReqInf, error) {
+	var data tc.ParameterUpdateResponse
+	req := parameterRequest{Name: param.Name, ConfigFile: param.ConfigFile, Value: param.Value, Secure: param.Secure}
+	reqInf, err := makeJSONReq(to, "PUT", parameterEp(strconv.Itoa(id)), req, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteParameterByID deletes the parameter with the given ID, which must not be assigned to any profile.
+func (to *Session) DeleteParameterByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", parameterEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
+
+// CreateProfileParameters assigns parameters to profiles, all or none of them.
+func (to *Session) CreateProfileParameters(pps []tc.ProfileParameter) (*tc.ProfileParametersResponse, ReqInf, error) {
+	var data tc.ProfileParametersResponse
+	reqInf, err := makeJSONReq(to, "POST", profileParametersAssignEp(), pps, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteProfileParameter unassigns the parameter with the given ID from the profile with the given ID.
+func (to *Session) DeleteProfileParameter(profileID, parameterID int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", profileParameterEp(strconv.Itoa(profileID), strconv.Itoa(parameterID)), nil, &data)
+	return data, reqInf, err
+}
+
+// parameterRequest is the body of a request to create or update a parameter, which doesn't have the parameter's ID or last updated time.
+type parameterRequest struct {
+	Name       string `json:"name"`
+	ConfigFile string `json:"configFile"`
+	Value      string `json:"value"`
+	Secure     bool   `json:"secure"`
+}
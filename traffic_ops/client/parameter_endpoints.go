@@ -0,0 +1,35 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+const parametersPath = "/parameters"
+const profileParametersPath = "/profileparameters"
+
+func parametersEp() string {
+	return apiBase + parametersPath
+}
+
+func parameterEp(id string) string {
+	return apiBase + parametersPath + "/" + id
+}
+
+func profileParametersAssignEp() string {
+	return apiBase + profileParametersPath
+}
+
+func profileParameterEp(profileID, parameterID string) string {
+	return apiBase + profileParametersPath + "/" + profileID + "/" + parameterID
+}
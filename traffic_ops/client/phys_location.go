@@ -0,0 +1,72 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetPhysLocations gets the physical locations matching the given options. Physical locations can be filtered by region, the ID of their region.
+func (to *Session) GetPhysLocations(opts QueryOptions) ([]tc.PhysLocation, ReqInf, error) {
+	var data tc.PhysLocationsResponse
+	reqInf, err := get(to, withQuery(physLocationsEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetPhysLocationByID gets the physical location with the given ID.
+func (to *Session) GetPhysLocationByID(id int) (*tc.PhysLocation, ReqInf, error) {
+	var data tc.PhysLocationsResponse
+	reqInf, err := get(to, physLocationEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreatePhysLocation creates the given physical location. Its region is set by ID.
+func (to *Session) CreatePhysLocation(p tc.PhysLocation) (*tc.PhysLocationResponse, ReqInf, error) {
+	var data tc.PhysLocationResponse
+	reqInf, err := makeJSONReq(to, "POST", physLocationsEp(), &p, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdatePhysLocationByID replaces the physical location with the given ID with the given physical location.
+func (to *Session) UpdatePhysLocationByID(id int, p tc.PhysLocation) (*tc.PhysLocationResponse, ReqInf, error) {
+	var data tc.PhysLocationResponse
+	reqInf, err := makeJSONReq(to, "PUT", physLocationEp(strconv.Itoa(id)), &p, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeletePhysLocationByID deletes the physical location with the given ID.
+func (to *Session) DeletePhysLocationByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", physLocationEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
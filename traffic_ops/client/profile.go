@@ -17,6 +17,8 @@ package client
 
 import (
 	"encoding/json"
+	"net/url"
+	"strconv"
 
 	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
 )
@@ -31,7 +33,7 @@ func (to *Session) Profiles() ([]tc.Profile, error) {
 func (to *Session) GetProfiles() ([]tc.Profile, ReqInf, error) {
 	url := "/api/1.2/profiles.json"
 	resp, remoteAddr, err := to.request("GET", url, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -44,3 +46,77 @@ func (to *Session) GetProfiles() ([]tc.Profile, ReqInf, error) {
 
 	return data.Response, reqInf, nil
 }
+
+// GetProfilesWithOptions gets the profiles matching the given options. Profiles can be filtered by name or cdn, the ID of their CDN.
+func (to *Session) GetProfilesWithOptions(opts QueryOptions) ([]tc.Profile, ReqInf, error) {
+	var data tc.ProfilesResponse
+	reqInf, err := get(to, withQuery(profilesEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetProfileByID gets the profile with the given ID. If includeParams is true, the profile's parameters are included, with the values of secure parameters concealed unless the user is an admin.
+func (to *Session) GetProfileByID(id int, includeParams bool) (*tc.Profile, ReqInf, error) {
+	ep := profileEp(strconv.Itoa(id))
+	if includeParams {
+		ep += "?includeParams=true"
+	}
+	var data tc.ProfilesResponse
+	reqInf, err := get(to, ep, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateProfile creates the given profile. Its CDN is set by ID.
+func (to *Session) CreateProfile(profile tc.Profile) (*tc.ProfileResponse, ReqInf, error) {
+	var data tc.ProfileResponse
+	reqInf, err := makeJSONReq(to, "POST", profilesEp(), profile, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateProfileByID replaces the profile with the given ID with the given profile.
+func (to *Session) UpdateProfileByID(id int, profile tc.Profile) (*tc.ProfileResponse, ReqInf, error) {
+	var data tc.ProfileResponse
+	reqInf, err := makeJSONReq(to, "PUT", profileEp(strconv.Itoa(id)), profile, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteProfileByID deletes the profile with the given ID, which must not be used by any server or delivery service.
+func (to *Session) DeleteProfileByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", profileEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
+
+// CopyProfile creates a profile with the given name, which is a copy of the profile named copyFrom, with the same parameters.
+func (to *Session) CopyProfile(name, copyFrom string) (*tc.ProfileCopyResponse, ReqInf, error) {
+	var data tc.ProfileCopyResponse
+	reqInf, err := makeReq(to, "POST", profileCopyEp(url.PathEscape(name), url.PathEscape(copyFrom)), nil, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// GetProfileParametersByID gets the parameters of the profile with the given ID.
+func (to *Session) GetProfileParametersByID(id int) ([]tc.Parameter, ReqInf, error) {
+	var data tc.ParametersResponse
+	reqInf, err := get(to, profileParametersEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
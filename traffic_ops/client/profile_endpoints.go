@@ -0,0 +1,34 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+const profilesPath = "/profiles"
+
+func profilesEp() string {
+	return apiBase + profilesPath
+}
+
+func profileEp(id string) string {
+	return apiBase + profilesPath + "/" + id
+}
+
+func profileCopyEp(name, copyFrom string) string {
+	return apiBase + profilesPath + "/name/" + name + "/copy/" + copyFrom
+}
+
+func profileParametersEp(id string) string {
+	return profileEp(id) + "/parameters"
+}
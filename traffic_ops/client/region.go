@@ -0,0 +1,72 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetRegions gets the regions matching the given options. Regions can be filtered by division, the ID of their division.
+func (to *Session) GetRegions(opts QueryOptions) ([]tc.Region, ReqInf, error) {
+	var data tc.RegionsResponse
+	reqInf, err := get(to, withQuery(regionsEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetRegionByID gets the region with the given ID.
+func (to *Session) GetRegionByID(id int) (*tc.Region, ReqInf, error) {
+	var data tc.RegionsResponse
+	reqInf, err := get(to, regionEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateRegion creates the given region. Its division is set by ID.
+func (to *Session) CreateRegion(r tc.Region) (*tc.RegionResponse, ReqInf, error) {
+	var data tc.RegionResponse
+	reqInf, err := makeJSONReq(to, "POST", regionsEp(), &r, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateRegionByID replaces the region with the given ID with the given region.
+func (to *Session) UpdateRegionByID(id int, r tc.Region) (*tc.RegionResponse, ReqInf, error) {
+	var data tc.RegionResponse
+	reqInf, err := makeJSONReq(to, "PUT", regionEp(strconv.Itoa(id)), &r, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteRegionByID deletes the region with the given ID.
+func (to *Session) DeleteRegionByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", regionEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
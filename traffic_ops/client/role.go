@@ -0,0 +1,59 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetRoles gets all roles, with their capabilities.
+func (to *Session) GetRoles() ([]tc.Role, ReqInf, error) {
+	var data tc.RolesResponse
+	reqInf, err := get(to, rolesEp(), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// CreateRole creates the given role, with its capabilities.
+func (to *Session) CreateRole(role tc.Role) (*tc.RoleResponse, ReqInf, error) {
+	var data tc.RoleResponse
+	reqInf, err := makeJSONReq(to, "POST", rolesEp(), role, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateRoleByID replaces the role with the given ID with the given role, including its capabilities.
+func (to *Session) UpdateRoleByID(id int, role tc.Role) (*tc.RoleResponse, ReqInf, error) {
+	var data tc.RoleResponse
+	reqInf, err := makeJSONReq(to, "PUT", roleEp(strconv.Itoa(id)), role, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteRoleByID deletes the role with the given ID, which must not be any user's primary role.
+func (to *Session) DeleteRoleByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", roleEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
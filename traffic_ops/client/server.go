@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 
 	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
@@ -34,7 +35,7 @@ func (to *Session) Servers() ([]tc.Server, error) {
 func (to *Session) GetServers() ([]tc.Server, ReqInf, error) {
 	url := "/api/1.2/servers.json"
 	resp, remoteAddr, err := to.request("GET", url, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -58,7 +59,7 @@ func (to *Session) Server(name string) (*tc.Server, error) {
 func (to *Session) GetServer(name string) (*tc.Server, ReqInf, error) {
 	url := fmt.Sprintf("/api/1.2/servers/hostname/%s/details", name)
 	resp, remoteAddr, err := to.request("GET", url, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -81,7 +82,7 @@ func (to *Session) ServersByType(qparams url.Values) ([]tc.Server, error) {
 func (to *Session) GetServersByType(qparams url.Values) ([]tc.Server, ReqInf, error) {
 	url := fmt.Sprintf("/api/1.2/servers.json?%s", qparams.Encode())
 	resp, remoteAddr, err := to.request("GET", url, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -146,3 +147,78 @@ func (to *Session) GetServersShortNameSearch(shortname string) ([]string, ReqInf
 	}
 	return serverlst, reqInf, nil
 }
+
+// GetServersWithOptions gets the servers matching the given options. Servers can be filtered by type, status, cachegroup, cdn, profileId and physLocation.
+func (to *Session) GetServersWithOptions(opts QueryOptions) ([]tc.Server, ReqInf, error) {
+	var data tc.ServersResponse
+	reqInf, err := get(to, withQuery(serversEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetServerByID gets the server with the given ID.
+func (to *Session) GetServerByID(id int) (*tc.Server, ReqInf, error) {
+	var data tc.ServersResponse
+	reqInf, err := get(to, serverEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateServer creates the given server. Its cachegroup, CDN, type, profile, status and physical location are set by ID.
+func (to *Session) CreateServer(server tc.Server) (*tc.ServerResponse, ReqInf, error) {
+	var data tc.ServerResponse
+	reqInf, err := makeJSONReq(to, "POST", serversEp(), &server, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateServerByID replaces the server with the given ID with the given server.
+func (to *Session) UpdateServerByID(id int, server tc.Server) (*tc.ServerResponse, ReqInf, error) {
+	var data tc.ServerResponse
+	reqInf, err := makeJSONReq(to, "PUT", serverEp(strconv.Itoa(id)), &server, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteServerByID deletes the server with the given ID.
+func (to *Session) DeleteServerByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", serverEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
+
+// QueueServerUpdates queues updates on the server with the given ID if queue is true, or dequeues them if it's false.
+func (to *Session) QueueServerUpdates(id int, queue bool) (*tc.ServerQueueUpdateResponse, ReqInf, error) {
+	var data tc.ServerQueueUpdateResponse
+	reqInf, err := makeJSONReq(to, "POST", serverQueueUpdateEp(strconv.Itoa(id)), tc.ServerQueueUpdateRequest{Action: queueAction(queue)}, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateServerStatus sets the status of the server with the given ID. If the server is an edge or mid, updates are queued on the servers of its child cachegroups.
+func (to *Session) UpdateServerStatus(id int, req tc.ServerStatusRequest) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeJSONReq(to, "PUT", serverStatusEp(strconv.Itoa(id)), req, &data)
+	return data, reqInf, err
+}
+
+// queueAction returns the action of a queue update request, which queues updates if queue is true, and dequeues them otherwise.
+func queueAction(queue bool) string {
+	if queue {
+		return "queue"
+	}
+	return "dequeue"
+}
@@ -17,6 +17,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -26,7 +27,6 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptrace"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -46,9 +46,29 @@ type Session struct {
 	cacheMutex   *sync.RWMutex
 	useCache     bool
 	UserAgentStr string
+	// Retry controls retries and circuit breaking. It may be changed before the Session is used.
+	Retry RetryConfig
+	// Middleware wraps every HTTP request to Traffic Ops, in order, the first being outermost. It may be changed before the Session is used.
+	Middleware []Middleware
+	endpoints  []*endpoint
+	// ctx is the context of requests made by the Session. It's nil unless the Session was returned by WithContext.
+	ctx context.Context
 }
 
 func NewSession(user, password, url, userAgent string, client *http.Client, useCache bool) *Session {
+	return NewSessionWithURLs(user, password, []string{url}, userAgent, client, useCache)
+}
+
+// NewSessionWithURLs creates a Session which fails over between the given Traffic Ops URLs, in order of preference. The Session's URL is the first, preferred, URL.
+func NewSessionWithURLs(user, password string, urls []string, userAgent string, client *http.Client, useCache bool) *Session {
+	endpoints := make([]*endpoint, 0, len(urls))
+	for _, url := range urls {
+		endpoints = append(endpoints, newEndpoint(url))
+	}
+	url := ""
+	if len(urls) > 0 {
+		url = urls[0]
+	}
 	return &Session{
 		UserName:     user,
 		Password:     password,
@@ -58,24 +78,40 @@ func NewSession(user, password, url, userAgent string, client *http.Client, useC
 		cacheMutex:   &sync.RWMutex{},
 		useCache:     useCache,
 		UserAgentStr: userAgent,
+		Retry:        DefaultRetryConfig,
+		endpoints:    endpoints,
 	}
 }
 
-const DefaultTimeout = time.Second * time.Duration(30)
+// getEndpoints returns the Traffic Ops endpoints of the Session. Sessions which weren't created by NewSession use their URL, without failover.
+func (to *Session) getEndpoints() []*endpoint {
+	if len(to.endpoints) == 0 {
+		return []*endpoint{newEndpoint(to.URL)}
+	}
+	return to.endpoints
+}
 
-// HTTPError is returned on Update Session failure.
-type HTTPError struct {
-	HTTPStatusCode int
-	HTTPStatus     string
-	URL            string
-	Body           string
+// retryConfig returns the Session's RetryConfig for requests to the given endpoints, with circuit breaking disabled unless there's more than one.
+func (to *Session) retryConfig(endpoints []*endpoint) RetryConfig {
+	cfg := to.Retry
+	if len(endpoints) < 2 {
+		cfg.FailureThreshold = 0
+	}
+	return cfg
 }
 
-// Error implements the error interface for our customer error type.
-func (e *HTTPError) Error() string {
-	return fmt.Sprintf("%s[%d] - Error requesting Traffic Ops %s %s", e.HTTPStatus, e.HTTPStatusCode, e.URL, e.Body)
+// Endpoints returns the current state of each Traffic Ops endpoint of the Session.
+func (to *Session) Endpoints() []EndpointState {
+	endpoints := to.getEndpoints()
+	states := make([]EndpointState, 0, len(endpoints))
+	for _, ep := range endpoints {
+		states = append(states, ep.snapshot())
+	}
+	return states
 }
 
+const DefaultTimeout = time.Second * time.Duration(30)
+
 // CacheEntry ...
 type CacheEntry struct {
 	Entered    int64
@@ -107,16 +143,42 @@ func Login(toURL string, toUser string, toPasswd string, insecure bool) (*Sessio
 	return s, err
 }
 
-// login tries to log in to Traffic Ops, and set the auth cookie in the Session. Returns the IP address of the remote Traffic Ops.
+// login tries to log in to each available Traffic Ops endpoint in turn, until one succeeds, and sets the auth cookie in the Session. Returns the IP address of the remote Traffic Ops.
 func (to *Session) login() (net.Addr, error) {
+	err := ErrNoAvailableEndpoints
+	remoteAddr := net.Addr(nil)
+	endpoints := to.getEndpoints()
+	cfg := to.retryConfig(endpoints)
+	for _, ep := range endpoints {
+		if !ep.allow(cfg, time.Now()) {
+			continue
+		}
+		if remoteAddr, err = to.loginTo(ep); err == nil {
+			return remoteAddr, nil
+		}
+	}
+	return remoteAddr, err
+}
+
+// loginTo tries to log in to the given Traffic Ops endpoint, and set the auth cookie in the Session. Returns the IP address of the remote Traffic Ops.
+func (to *Session) loginTo(ep *endpoint) (net.Addr, error) {
 	credentials, err := loginCreds(to.UserName, to.Password)
 	if err != nil {
 		return nil, errors.New("creating login credentials: " + err.Error())
 	}
 
 	path := "/api/1.2/user/login"
-	resp, remoteAddr, err := to.rawRequest("POST", path, credentials)
-	resp, remoteAddr, err = to.ErrUnlessOK(resp, remoteAddr, err, path)
+	resp, remoteAddr, err := to.rawRequest(ep, "POST", path, credentials)
+	if err != nil && to.context().Err() != nil {
+		ep.abandon()
+		return remoteAddr, to.context().Err()
+	}
+	if failed(resp, err) {
+		ep.failure(to.Retry, errOrStatus(resp, err), time.Now())
+	} else {
+		ep.success()
+	}
+	resp, remoteAddr, err = errUnlessOK(resp, remoteAddr, err, ep.url+path)
 	if err != nil {
 		return remoteAddr, errors.New("requesting: " + err.Error())
 	}
@@ -142,12 +204,17 @@ func (to *Session) login() (net.Addr, error) {
 	return remoteAddr, nil
 }
 
-// Login to traffic_ops, the response should set the cookie for this session
-// automatically. Start with
-//     to := traffic_ops.Login("user", "passwd", true)
-// subsequent calls like to.GetData("datadeliveryservice") will be authenticated.
-// Returns the logged in client, the remote address of Traffic Ops which was translated and used to log in, and any error. If the error is not nil, the remote address may or may not be nil, depending whether the error occurred before the login request.
-func LoginWithAgent(toURL string, toUser string, toPasswd string, insecure bool, userAgent string, useCache bool, requestTimeout time.Duration) (*Session, net.Addr, error) {
+// LoginWithAgentURLs logs in to the first available of the given Traffic Ops URLs, which are in order of preference. Subsequent requests fail over between the URLs as they become unavailable. See LoginWithAgent.
+func LoginWithAgentURLs(toURLs []string, toUser string, toPasswd string, insecure bool, userAgent string, useCache bool, requestTimeout time.Duration) (*Session, net.Addr, error) {
+	return LoginWithAgentURLsContext(context.Background(), toURLs, toUser, toPasswd, insecure, userAgent, useCache, requestTimeout)
+}
+
+// LoginWithAgentURLsContext is LoginWithAgentURLs, with the given context for the login requests. The context isn't used by the returned Session; use WithContext for that.
+func LoginWithAgentURLsContext(ctx context.Context, toURLs []string, toUser string, toPasswd string, insecure bool, userAgent string, useCache bool, requestTimeout time.Duration) (*Session, net.Addr, error) {
+	if len(toURLs) == 0 {
+		return nil, nil, errors.New("logging in: no Traffic Ops URLs")
+	}
+
 	options := cookiejar.Options{
 		PublicSuffixList: publicsuffix.List,
 	}
@@ -157,7 +224,7 @@ func LoginWithAgent(toURL string, toUser string, toPasswd string, insecure bool,
 		return nil, nil, err
 	}
 
-	to := NewSession(toUser, toPasswd, toURL, userAgent, &http.Client{
+	to := NewSessionWithURLs(toUser, toPasswd, toURLs, userAgent, &http.Client{
 		Timeout: requestTimeout,
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
@@ -165,15 +232,29 @@ func LoginWithAgent(toURL string, toUser string, toPasswd string, insecure bool,
 		Jar: jar,
 	}, useCache)
 
-	remoteAddr, err := to.login()
+	remoteAddr, err := to.WithContext(ctx).login()
 	if err != nil {
 		return nil, remoteAddr, errors.New("logging in: " + err.Error())
 	}
 	return to, remoteAddr, nil
 }
 
+// Login to traffic_ops, the response should set the cookie for this session
+// automatically. Start with
+//     to := traffic_ops.Login("user", "passwd", true)
+// subsequent calls like to.GetData("datadeliveryservice") will be authenticated.
+// Returns the logged in client, the remote address of Traffic Ops which was translated and used to log in, and any error. If the error is not nil, the remote address may or may not be nil, depending whether the error occurred before the login request.
+func LoginWithAgent(toURL string, toUser string, toPasswd string, insecure bool, userAgent string, useCache bool, requestTimeout time.Duration) (*Session, net.Addr, error) {
+	return LoginWithAgentURLs([]string{toURL}, toUser, toPasswd, insecure, userAgent, useCache, requestTimeout)
+}
+
 // ErrUnlessOk returns nil and an error if the given Response's status code is anything but 200 OK. This includes reading the Response.Body and Closing it. Otherwise, the given response and error are returned unchanged.
 func (to *Session) ErrUnlessOK(resp *http.Response, remoteAddr net.Addr, err error, path string) (*http.Response, net.Addr, error) {
+	return errUnlessOK(resp, remoteAddr, err, to.getURL(path))
+}
+
+// errUnlessOK is ErrUnlessOK for the given full URL, which is only used in the error message.
+func errUnlessOK(resp *http.Response, remoteAddr net.Addr, err error, url string) (*http.Response, net.Addr, error) {
 	if err != nil {
 		return resp, remoteAddr, err
 	}
@@ -186,32 +267,85 @@ func (to *Session) ErrUnlessOK(resp *http.Response, remoteAddr net.Addr, err err
 	if readErr != nil {
 		return nil, remoteAddr, readErr
 	}
-	return nil, remoteAddr, errors.New(resp.Status + "[" + strconv.Itoa(resp.StatusCode) + "] - Error requesting Traffic Ops " + to.getURL(path) + " " + string(body))
+	return nil, remoteAddr, newHTTPError(resp, url, body)
 }
 
 func (to *Session) getURL(path string) string { return to.URL + path }
 
-// request performs the HTTP request to Traffic Ops, trying to refresh the cookie if an Unauthorized or Forbidden code is received. It only tries once. If the login fails, the original Unauthorized/Forbidden response is returned. If the login succeeds and the subsequent re-request fails, the re-request's response is returned even if it's another Unauthorized/Forbidden.
+// request performs the HTTP request to Traffic Ops, failing over between the Session's endpoints. An endpoint which can't be reached, or returns a Bad Gateway, Service Unavailable or Gateway Timeout, has a failure recorded in its circuit breaker, and the request is sent to the next available endpoint. A Session with a single endpoint never opens its circuit. If every endpoint fails, idempotent requests are retried up to Retry.MaxRetries times, with jittered exponential backoff. POST requests are sent at most once, to the first available endpoint. If the Session's context is cancelled or its deadline passes, the request stops immediately with the context's error, and no failure is recorded.
 func (to *Session) request(method, path string, body []byte) (*http.Response, net.Addr, error) {
-	r, remoteAddr, err := to.rawRequest(method, path, body)
+	ctx := to.context()
+	endpoints := to.getEndpoints()
+	cfg := to.retryConfig(endpoints)
+	retries := 0
+	if idempotent(method) {
+		retries = cfg.MaxRetries
+	}
+
+	err := ErrNoAvailableEndpoints
+	remoteAddr := net.Addr(nil)
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			if cerr := sleepContext(ctx, cfg.backoff(attempt)); cerr != nil {
+				return nil, remoteAddr, cerr
+			}
+		}
+		for _, ep := range endpoints {
+			if cerr := ctx.Err(); cerr != nil {
+				return nil, remoteAddr, cerr
+			}
+			if !ep.allow(cfg, time.Now()) {
+				continue
+			}
+			r, addr, rerr := to.endpointRequest(ep, method, path, body)
+			if cerr := ctx.Err(); cerr != nil && rerr != nil {
+				ep.abandon() // the request was cancelled by the caller, which says nothing about the endpoint's health.
+				return nil, addr, cerr
+			}
+			if !failed(r, rerr) {
+				ep.success()
+				return errUnlessOK(r, addr, rerr, ep.url+path)
+			}
+			ep.failure(cfg, errOrStatus(r, rerr), time.Now())
+			_, remoteAddr, err = errUnlessOK(r, addr, rerr, ep.url+path)
+			if !idempotent(method) {
+				return nil, remoteAddr, err
+			}
+		}
+	}
+	return nil, remoteAddr, err
+}
+
+// endpointRequest performs the HTTP request to the given Traffic Ops endpoint, trying to refresh the cookie if an Unauthorized or Forbidden code is received. It only tries once. If the login fails, the original Unauthorized/Forbidden response is returned. If the login succeeds and the subsequent re-request fails, the re-request's response is returned even if it's another Unauthorized/Forbidden.
+func (to *Session) endpointRequest(ep *endpoint, method, path string, body []byte) (*http.Response, net.Addr, error) {
+	r, remoteAddr, err := to.rawRequest(ep, method, path, body)
 	if err != nil {
 		return r, remoteAddr, err
 	}
 	if r.StatusCode != http.StatusUnauthorized && r.StatusCode != http.StatusForbidden {
-		return to.ErrUnlessOK(r, remoteAddr, err, path)
+		return r, remoteAddr, err
 	}
-	if _, lerr := to.login(); lerr != nil {
-		return to.ErrUnlessOK(r, remoteAddr, err, path) // if re-logging-in fails, return the original request's response
+	// log in to the same endpoint, because the cookie jar only sends the cookie to the host which set it.
+	if _, lerr := to.loginTo(ep); lerr != nil {
+		return r, remoteAddr, err // if re-logging-in fails, return the original request's response
 	}
+	r.Body.Close()
 
 	// return second request, even if it's another Unauthorized or Forbidden.
-	r, remoteAddr, err = to.rawRequest(method, path, body)
-	return to.ErrUnlessOK(r, remoteAddr, err, path)
+	return to.rawRequest(ep, method, path, body)
 }
 
-// rawRequest performs the actual HTTP request to Traffic Ops, simply, without trying to refresh the cookie if an Unauthorized code is returned.
-func (to *Session) rawRequest(method, path string, body []byte) (*http.Response, net.Addr, error) {
-	url := to.getURL(path)
+// errOrStatus returns the given error, or an error with the response's status if the error is nil.
+func errOrStatus(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	return errors.New(resp.Status)
+}
+
+// rawRequest performs the actual HTTP request to the given Traffic Ops endpoint, simply, without trying to refresh the cookie if an Unauthorized code is returned.
+func (to *Session) rawRequest(ep *endpoint, method, path string, body []byte) (*http.Response, net.Addr, error) {
+	url := ep.url + path
 
 	var req *http.Request
 	var err error
@@ -235,11 +369,14 @@ func (to *Session) rawRequest(method, path string, body []byte) (*http.Response,
 			remoteAddr = connInfo.Conn.RemoteAddr()
 		},
 	}
-	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	req = req.WithContext(httptrace.WithClientTrace(to.context(), trace))
 
 	req.Header.Set("User-Agent", to.UserAgentStr)
+	if traceID := TraceIDFromContext(req.Context()); traceID != "" {
+		req.Header.Set(tc.TraceIDHeader, traceID)
+	}
 
-	resp, err := to.Client.Do(req)
+	resp, err := to.roundTrip()(req)
 	if err != nil {
 		return nil, remoteAddr, err
 	}
@@ -250,6 +387,15 @@ func (to *Session) rawRequest(method, path string, body []byte) (*http.Response,
 type ReqInf struct {
 	CacheHitStatus CacheHitStatus
 	RemoteAddr     net.Addr
+	// Endpoints is the state of each Traffic Ops endpoint's circuit breaker after the request.
+	Endpoints []EndpointState
+	// TraceID is the trace ID sent with the request, or empty if the Session's context has none. See WithTraceID.
+	TraceID string
+}
+
+// reqInf returns the ReqInf of a request with the given cache status and remote address.
+func (to *Session) reqInf(cacheHitStatus CacheHitStatus, remoteAddr net.Addr) ReqInf {
+	return ReqInf{CacheHitStatus: cacheHitStatus, RemoteAddr: remoteAddr, Endpoints: to.Endpoints(), TraceID: TraceIDFromContext(to.context())}
 }
 
 type CacheHitStatus string
@@ -322,7 +468,7 @@ func (to *Session) getBytesWithTTL(path string, ttl int64) ([]byte, ReqInf, erro
 	if getFresh {
 		body, remoteAddr, err = to.getBytes(path)
 		if err != nil {
-			return nil, ReqInf{CacheHitStatus: CacheHitStatusInvalid, RemoteAddr: remoteAddr}, err
+			return nil, to.reqInf(CacheHitStatusInvalid, remoteAddr), err
 		}
 
 		newEntry := CacheEntry{
@@ -333,7 +479,7 @@ func (to *Session) getBytesWithTTL(path string, ttl int64) ([]byte, ReqInf, erro
 		to.setCache(path, newEntry)
 	}
 
-	return body, ReqInf{CacheHitStatus: cacheHitStatus, RemoteAddr: remoteAddr}, nil
+	return body, to.reqInf(cacheHitStatus, remoteAddr), nil
 }
 
 // GetBytes - get []bytes array for a certain path on the to session.
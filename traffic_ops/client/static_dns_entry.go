@@ -0,0 +1,30 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetStaticDNSEntries gets the static DNS entries matching the given options, ordered by delivery service unless the options order them otherwise. Entries can be filtered by deliveryservice, host, ttl, address, type and cachegroup.
+func (to *Session) GetStaticDNSEntries(opts QueryOptions) ([]tc.StaticDNSEntry, ReqInf, error) {
+	var data tc.StaticDNSEntriesResponse
+	reqInf, err := get(to, withQuery(apiBase+"/staticdnsentries", opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
@@ -52,7 +52,7 @@ func (to *Session) GetSummaryStats(cdn string, deliveryService string, statName
 	}
 
 	resp, remoteAddr, err := to.request("GET", queryURL, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -80,7 +80,7 @@ func (to *Session) GetSummaryStatsLastUpdated(statName string) (string, ReqInf,
 	}
 
 	resp, remoteAddr, err := to.request("GET", queryURL, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return "", reqInf, err
 	}
@@ -112,7 +112,7 @@ func (to *Session) DoAddSummaryStats(statsSummary tc.StatsSummary) (ReqInf, erro
 
 	url := "/api/1.2/stats_summary/create"
 	resp, remoteAddr, err := to.request("POST", url, reqBody)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return reqInf, err
 	}
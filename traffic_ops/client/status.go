@@ -0,0 +1,72 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetStatuses gets the statuses matching the given options.
+func (to *Session) GetStatuses(opts QueryOptions) ([]tc.Status, ReqInf, error) {
+	var data tc.StatusesResponse
+	reqInf, err := get(to, withQuery(statusesEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetStatusByID gets the status with the given ID.
+func (to *Session) GetStatusByID(id int) (*tc.Status, ReqInf, error) {
+	var data tc.StatusesResponse
+	reqInf, err := get(to, statusEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateStatus creates the given status.
+func (to *Session) CreateStatus(s tc.Status) (*tc.StatusResponse, ReqInf, error) {
+	var data tc.StatusResponse
+	reqInf, err := makeJSONReq(to, "POST", statusesEp(), &s, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateStatusByID replaces the status with the given ID with the given status.
+func (to *Session) UpdateStatusByID(id int, s tc.Status) (*tc.StatusResponse, ReqInf, error) {
+	var data tc.StatusResponse
+	reqInf, err := makeJSONReq(to, "PUT", statusEp(strconv.Itoa(id)), &s, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteStatusByID deletes the status with the given ID.
+func (to *Session) DeleteStatusByID(id int) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", statusEp(strconv.Itoa(id)), nil, &data)
+	return data, reqInf, err
+}
@@ -0,0 +1,103 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetSteeringTargets gets the targets of the steering delivery service with the given ID.
+func (to *Session) GetSteeringTargets(dsID int) ([]tc.SteeringTarget, ReqInf, error) {
+	var data tc.SteeringTargetsResponse
+	reqInf, err := get(to, steeringTargetsEp(dsID), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetSteeringTarget gets the target with the given delivery service ID of the steering delivery service with the given ID.
+func (to *Session) GetSteeringTarget(dsID int, targetID int) (*tc.SteeringTarget, ReqInf, error) {
+	var data tc.SteeringTargetsResponse
+	reqInf, err := get(to, steeringTargetEp(dsID, targetID), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, nil
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateSteeringTarget adds a target to the steering delivery service with the given ID.
+func (to *Session) CreateSteeringTarget(dsID int, target tc.SteeringTargetRequest) (*tc.SteeringTargetResponse, ReqInf, error) {
+	var data tc.SteeringTargetResponse
+	jsonReq, err := json.Marshal(target)
+	if err != nil {
+		return nil, ReqInf{}, err
+	}
+	reqInf, err := makeReq(to, "POST", steeringTargetsEp(dsID), jsonReq, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateSteeringTarget updates the value and type of the target with the given delivery service ID of the steering delivery service with the given ID.
+func (to *Session) UpdateSteeringTarget(dsID int, targetID int, target tc.SteeringTargetRequest) (*tc.SteeringTargetResponse, ReqInf, error) {
+	var data tc.SteeringTargetResponse
+	jsonReq, err := json.Marshal(target)
+	if err != nil {
+		return nil, ReqInf{}, err
+	}
+	reqInf, err := makeReq(to, "PUT", steeringTargetEp(dsID, targetID), jsonReq, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteSteeringTarget removes the target with the given delivery service ID from the steering delivery service with the given ID.
+func (to *Session) DeleteSteeringTarget(dsID int, targetID int) (*tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", steeringTargetEp(dsID, targetID), nil, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// GetSteering gets the Traffic Router steering feed, of every steering delivery service the user may access.
+func (to *Session) GetSteering() ([]tc.Steering, ReqInf, error) {
+	var data tc.SteeringResponse
+	reqInf, err := get(to, steeringFeedEp(), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetSteeringByXMLID gets the Traffic Router steering feed entry of the steering delivery service with the given xml_id.
+func (to *Session) GetSteeringByXMLID(xmlID string) (*tc.Steering, ReqInf, error) {
+	var data tc.SteeringDeliveryServiceResponse
+	reqInf, err := get(to, steeringFeedDSEp(xmlID), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data.Response, reqInf, nil
+}
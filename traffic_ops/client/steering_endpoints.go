@@ -0,0 +1,36 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import "strconv"
+
+const steeringPath = "/steering"
+
+func steeringTargetsEp(dsID int) string {
+	return apiBase + steeringPath + "/" + strconv.Itoa(dsID) + "/targets"
+}
+
+func steeringTargetEp(dsID int, targetID int) string {
+	return steeringTargetsEp(dsID) + "/" + strconv.Itoa(targetID)
+}
+
+func steeringFeedEp() string {
+	return internalAPIBase + steeringPath
+}
+
+func steeringFeedDSEp(xmlID string) string {
+	return internalAPIBase + steeringPath + "/" + xmlID
+}
@@ -0,0 +1,30 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetSystemInfo gets the parameters of the GLOBAL profile, which configure Traffic Ops itself. Secure parameters are omitted unless the user is an admin.
+func (to *Session) GetSystemInfo() (map[string]string, ReqInf, error) {
+	var data tc.SystemInfoResponse
+	reqInf, err := get(to, apiBase+"/system/info", &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response.Parameters, reqInf, nil
+}
@@ -0,0 +1,72 @@
+/*
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package client
+
+import (
+	"net/url"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GetTopologies gets all topologies.
+func (to *Session) GetTopologies() ([]tc.Topology, ReqInf, error) {
+	var data tc.TopologiesResponse
+	reqInf, err := get(to, topologiesEp(), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetTopology gets the topology with the given name.
+func (to *Session) GetTopology(name string) (*tc.Topology, ReqInf, error) {
+	var data tc.TopologiesResponse
+	reqInf, err := get(to, topologyEp(url.PathEscape(name)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// CreateTopology creates the given topology.
+func (to *Session) CreateTopology(topology tc.Topology) (*tc.TopologyResponse, ReqInf, error) {
+	var data tc.TopologyResponse
+	reqInf, err := makeJSONReq(to, "POST", topologiesEp(), &topology, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateTopology replaces the topology with the given name with the given topology.
+func (to *Session) UpdateTopology(name string, topology tc.Topology) (*tc.TopologyResponse, ReqInf, error) {
+	var data tc.TopologyResponse
+	reqInf, err := makeJSONReq(to, "PUT", topologyEp(url.PathEscape(name)), &topology, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// DeleteTopology deletes the topology with the given name, which must not be used by any delivery service.
+func (to *Session) DeleteTopology(name string) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeReq(to, "DELETE", topologyEp(url.PathEscape(name)), nil, &data)
+	return data, reqInf, err
+}
@@ -43,7 +43,7 @@ type Update struct {
 func (to *Session) GetUpdate(serverName string) (Update, ReqInf, error) {
 	url := "/update/" + serverName
 	resp, remoteAddr, err := to.request(http.MethodGet, url, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return Update{}, reqInf, err
 	}
@@ -62,7 +62,7 @@ func (to *Session) GetUpdate(serverName string) (Update, ReqInf, error) {
 func (to *Session) SetUpdate(serverName string, updatePending int, revalPending int) (ReqInf, error) {
 	updateURL := "/update/" + serverName + "?" + "host_name=" + serverName + "&updated=" + strconv.Itoa(updatePending) + "&reval_updated=" + strconv.Itoa(revalPending)
 	resp, remoteAddr, err := to.request(http.MethodPost, updateURL, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return reqInf, err
 	}
@@ -17,6 +17,7 @@ package client
 
 import (
 	"encoding/json"
+	"strconv"
 
 	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
 )
@@ -31,7 +32,7 @@ func (to *Session) Users() ([]tc.User, error) {
 func (to *Session) GetUsers() ([]tc.User, ReqInf, error) {
 	url := "/api/1.2/users.json"
 	resp, remoteAddr, err := to.request("GET", url, nil)
-	reqInf := ReqInf{CacheHitStatus: CacheHitStatusMiss, RemoteAddr: remoteAddr}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return nil, reqInf, err
 	}
@@ -44,3 +45,86 @@ func (to *Session) GetUsers() ([]tc.User, ReqInf, error) {
 
 	return data.Response, reqInf, nil
 }
+
+// GetUsersWithOptions gets the users matching the given options, of the tenants the current user can see.
+func (to *Session) GetUsersWithOptions(opts QueryOptions) ([]tc.User, ReqInf, error) {
+	var data tc.UsersResponse
+	reqInf, err := get(to, withQuery(usersEp(), opts), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return data.Response, reqInf, nil
+}
+
+// GetUserByID gets the user with the given ID.
+func (to *Session) GetUserByID(id int) (*tc.User, ReqInf, error) {
+	var data tc.UsersResponse
+	reqInf, err := get(to, userEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	if len(data.Response) == 0 {
+		return nil, reqInf, ErrNotFound
+	}
+	return &data.Response[0], reqInf, nil
+}
+
+// GetUserCurrent gets the user the Session is logged in as.
+func (to *Session) GetUserCurrent() (*tc.User, ReqInf, error) {
+	var data struct {
+		Response tc.User `json:"response"`
+	}
+	reqInf, err := get(to, userCurrentEp(), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data.Response, reqInf, nil
+}
+
+// CreateUser creates the given user. Its LocalPassword and ConfirmLocalPassword are required, and its role and tenant are set by ID.
+func (to *Session) CreateUser(user tc.User) (*tc.UserResponse, ReqInf, error) {
+	var data tc.UserResponse
+	reqInf, err := makeJSONReq(to, "POST", usersEp(), user, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateUserByID replaces the user with the given ID with the given user. The user's password is only changed if its LocalPassword is set.
+func (to *Session) UpdateUserByID(id int, user tc.User) (*tc.UserResponse, ReqInf, error) {
+	var data tc.UserResponse
+	reqInf, err := makeJSONReq(to, "PUT", userEp(strconv.Itoa(id)), user, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
+
+// UpdateCurrentUser replaces the user the Session is logged in as with the given user. The user's password is only changed if its LocalPassword is set.
+func (to *Session) UpdateCurrentUser(user tc.User) (tc.Alerts, ReqInf, error) {
+	var data tc.Alerts
+	reqInf, err := makeJSONReq(to, "PUT", userCurrentEp(), tc.CurrentUserRequest{User: user}, &data)
+	return data, reqInf, err
+}
+
+// GetUserRoles gets the roles the user with the given ID has in addition to their primary role.
+func (to *Session) GetUserRoles(id int) (*tc.UserRoles, ReqInf, error) {
+	var data tc.UserRolesResponse
+	reqInf, err := get(to, userRolesEp(strconv.Itoa(id)), &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data.Response, reqInf, nil
+}
+
+// SetUserRoles replaces the additional roles of the user with the given ID with the roles with the given IDs.
+func (to *Session) SetUserRoles(id int, roleIDs []int) (*tc.SetUserRolesResponse, ReqInf, error) {
+	var data tc.SetUserRolesResponse
+	req := tc.UserRoles{UserID: id, RoleIDs: roleIDs}
+	reqInf, err := makeJSONReq(to, "PUT", userRolesEp(strconv.Itoa(id)), req, &data)
+	if err != nil {
+		return nil, reqInf, err
+	}
+	return &data, reqInf, nil
+}
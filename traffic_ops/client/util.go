@@ -17,6 +17,11 @@ package client
 
 import "encoding/json"
 
+// messageResponse is the response of requests which respond with a message, rather than an object.
+type messageResponse struct {
+	Response string `json:"response"`
+}
+
 func get(to *Session, endpoint string, respStruct interface{}) (ReqInf, error) {
 	return makeReq(to, "GET", endpoint, nil, respStruct)
 }
@@ -38,7 +43,7 @@ func del(to *Session, endpoint string, respStruct interface{}) error {
 
 func makeReq(to *Session, method, endpoint string, body []byte, respStruct interface{}) (ReqInf, error) {
 	resp, remoteAddr, err := to.request(method, endpoint, body) // TODO change to getBytesWithTTL
-	reqInf := ReqInf{RemoteAddr: remoteAddr, CacheHitStatus: CacheHitStatusMiss}
+	reqInf := to.reqInf(CacheHitStatusMiss, remoteAddr)
 	if err != nil {
 		return reqInf, err
 	}
@@ -50,3 +55,12 @@ func makeReq(to *Session, method, endpoint string, body []byte, respStruct inter
 
 	return reqInf, nil
 }
+
+// makeJSONReq makes a request with the given body encoded as JSON.
+func makeJSONReq(to *Session, method, endpoint string, body interface{}, respStruct interface{}) (ReqInf, error) {
+	jsonReq, err := json.Marshal(body)
+	if err != nil {
+		return ReqInf{}, err
+	}
+	return makeReq(to, method, endpoint, jsonReq, respStruct)
+}
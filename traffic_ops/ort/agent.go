@@ -0,0 +1,356 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+// Mode is how the agent applies the config changes it finds.
+type Mode string
+
+// ModeReport only prints the differences between the config on disk and in Traffic Ops.
+const ModeReport = Mode("report")
+
+// ModeInteractive asks before replacing each file, and before reloading or restarting Traffic Server.
+const ModeInteractive = Mode("interactive")
+
+// ModeBatch applies every change without asking.
+const ModeBatch = Mode("batch")
+
+// ParseMode returns the Mode with the given name, or an error if there's no such mode.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(s)); m {
+	case ModeReport, ModeInteractive, ModeBatch:
+		return m, nil
+	}
+	return "", errors.New("unknown mode '" + s + "', must be one of " + string(ModeReport) + ", " + string(ModeInteractive) + ", " + string(ModeBatch))
+}
+
+// Action is what must be done for Traffic Server to use changed config files. Greater actions include the lesser.
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionReload
+	ActionRestart
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionReload:
+		return "reload"
+	case ActionRestart:
+		return "restart"
+	}
+	return "none"
+}
+
+const RegexRevalidateConfigFile = "regex_revalidate.config"
+const RemapConfigFile = "remap.config"
+const SSLMultiCertConfigFile = "ssl_multicert.config"
+const SysCtlConfigFile = "sysctl.conf"
+
+// TrafficServerLocationDir is the directory name config files are in if Traffic Server reads them.
+const TrafficServerLocationDir = "trafficserver"
+
+// restartConfigFiles are the config files Traffic Server only reads when it starts.
+var restartConfigFiles = map[string]struct{}{
+	"plugin.config": {},
+	"50-ats.rules":  {},
+}
+
+// remapPluginConfigPrefixes are the prefixes of the config files of plugins in remap.config. Traffic Server only reloads them when remap.config changes.
+var remapPluginConfigPrefixes = []string{"url_sig_", "uri_signing_", "hdr_rw_", "regex_remap_", "cacheurl_", "cachekey_", "set_dscp_"}
+
+// TrafficOps is the part of the Traffic Ops client the agent uses, which *client.Session implements.
+type TrafficOps interface {
+	GetUpdate(serverName string) (client.Update, client.ReqInf, error)
+	SetUpdate(serverName string, updatePending int, revalPending int) (client.ReqInf, error)
+	GetServerConfigFiles(hostName string) (tc.ATSConfigMetaData, client.ReqInf, error)
+	GetConfigFile(apiURI string) ([]byte, client.ReqInf, error)
+}
+
+// Agent makes a cache's config files match Traffic Ops, like the Perl ORT.
+type Agent struct {
+	TO TrafficOps
+	// HostName is the short host name of the cache in Traffic Ops.
+	HostName string
+	// FullHostName is the fully qualified host name, substituted for __FULL_HOSTNAME__ in config files.
+	FullHostName string
+	Mode         Mode
+	// Force applies the config even if Traffic Ops has no update pending for the cache.
+	Force bool
+	// WaitForParents skips the update while any parent of the cache has an update pending.
+	WaitForParents bool
+	// BackupDir is where replaced files are copied, in a subdirectory per run named for the Unix time.
+	BackupDir string
+	// TrafficCtl is the path of the traffic_ctl command.
+	TrafficCtl string
+	Runner     CommandRunner
+	// GetURL gets the config files which have a URL rather than a Traffic Ops API URI.
+	GetURL func(url string) ([]byte, error)
+	// Confirm asks the user a yes or no question, in interactive mode.
+	Confirm func(question string) bool
+	// Out is where differences are reported.
+	Out io.Writer
+	Now func() time.Time
+}
+
+// Result is what a run of the agent did.
+type Result struct {
+	// Changed is the paths of the files which differed from Traffic Ops. In report mode, none of them were replaced.
+	Changed []string
+	Action  Action
+	// UpdateCleared is whether the cache's update pending flags were cleared in Traffic Ops.
+	UpdateCleared bool
+}
+
+// Run fetches the cache's config files from Traffic Ops, compares them to the files on disk, and applies the differences according to the agent mode. Traffic Ops is told the update is done only if every file was applied and Traffic Server reloaded or restarted successfully.
+func (a *Agent) Run() (Result, error) {
+	result := Result{}
+	upd, _, err := a.TO.GetUpdate(a.HostName)
+	if err != nil {
+		return result, errors.New("getting update status: " + err.Error())
+	}
+	log.Infof("update status: update pending %v reval pending %v parent pending %v parent reval pending %v\n", upd.UpdatePending, upd.RevalPending, upd.ParentPending, upd.ParentRevalPending)
+
+	if !upd.UpdatePending && !upd.RevalPending && !a.Force && a.Mode != ModeReport {
+		log.Infof("no update pending, nothing to do\n")
+		return result, nil
+	}
+	if a.WaitForParents && (upd.ParentPending || upd.ParentRevalPending) && !a.Force {
+		log.Infof("parents have updates pending, waiting for them\n")
+		return result, nil
+	}
+	// a reval is only the invalidation jobs, so nothing else is applied until the next update
+	revalOnly := upd.RevalPending && !upd.UpdatePending && !a.Force
+
+	meta, _, err := a.TO.GetServerConfigFiles(a.HostName)
+	if err != nil {
+		return result, errors.New("getting config file list: " + err.Error())
+	}
+
+	backupDir := filepath.Join(a.BackupDir, strconv.FormatInt(a.Now().Unix(), 10))
+	appliedNames := map[string]struct{}{}
+	complete := true
+	for _, file := range meta.ConfigFiles {
+		if revalOnly && file.FileNameOnDisk != RegexRevalidateConfigFile {
+			continue
+		}
+		path := filepath.Join(file.Location, file.FileNameOnDisk)
+		changed, applied, err := a.processFile(meta.Info, file, path, backupDir)
+		if err != nil {
+			return result, errors.New("processing " + path + ": " + err.Error())
+		}
+		if !changed {
+			continue
+		}
+		result.Changed = append(result.Changed, path)
+		if a.Mode == ModeReport {
+			result.Action = maxAction(result.Action, fileAction(file))
+			continue
+		}
+		if !applied {
+			complete = false
+			continue
+		}
+		appliedNames[file.FileNameOnDisk] = struct{}{}
+		result.Action = maxAction(result.Action, fileAction(file))
+		if file.FileNameOnDisk == SysCtlConfigFile {
+			if out, err := a.Runner.Run("sysctl", "-p"); err != nil {
+				return result, errors.New("running sysctl: " + err.Error() + ": " + string(out))
+			}
+		}
+	}
+
+	if a.Mode == ModeReport {
+		fmt.Fprintf(a.Out, "%d files differ from Traffic Ops, Traffic Server needs %s\n", len(result.Changed), result.Action)
+		return result, nil
+	}
+
+	if err := a.touchDependents(meta.ConfigFiles, appliedNames); err != nil {
+		return result, err
+	}
+	applied, err := a.applyAction(result.Action)
+	if err != nil {
+		return result, err
+	}
+	if !applied {
+		complete = false
+	}
+
+	if !complete {
+		log.Warnf("not all changes were applied, leaving the update pending in Traffic Ops\n")
+		return result, nil
+	}
+	if a.Mode == ModeInteractive && !a.Confirm("Clear the update pending flags in Traffic Ops?") {
+		return result, nil
+	}
+	// a reval-only run leaves the update flag as it was, which was clear
+	if _, err := a.TO.SetUpdate(a.HostName, client.UpdateStatusClear, client.UpdateStatusClear); err != nil {
+		return result, errors.New("clearing update status: " + err.Error())
+	}
+	result.UpdateCleared = true
+	return result, nil
+}
+
+// processFile compares the given file from Traffic Ops with the file on disk, and reports the differences. Unless the mode is report, or the user says no in interactive mode, the file on disk is backed up and replaced. Returns whether the file changed, and whether it was replaced.
+func (a *Agent) processFile(info tc.ATSConfigMetaDataInfo, file tc.ATSConfigMetaDataConfigFile, path string, backupDir string) (bool, bool, error) {
+	toText, err := a.getFile(file)
+	if err != nil {
+		return false, false, errors.New("getting from Traffic Ops: " + err.Error())
+	}
+	toText = substituteConfigVars(toText, info, a.HostName, a.FullHostName)
+
+	diskText, exists, err := readConfigFile(path)
+	if err != nil {
+		return false, false, errors.New("reading from disk: " + err.Error())
+	}
+	diskOnly, toOnly := diffConfig(diskText, toText)
+	if exists && len(diskOnly) == 0 && len(toOnly) == 0 {
+		log.Debugf("%s is up to date\n", path)
+		return false, false, nil
+	}
+
+	if !exists {
+		fmt.Fprintf(a.Out, "%s doesn't exist on disk\n", path)
+	} else {
+		fmt.Fprintf(a.Out, "%s differs from Traffic Ops:\n", path)
+	}
+	for _, line := range diskOnly {
+		fmt.Fprintf(a.Out, "  - %s\n", line)
+	}
+	for _, line := range toOnly {
+		fmt.Fprintf(a.Out, "  + %s\n", line)
+	}
+
+	if a.Mode == ModeReport {
+		return true, false, nil
+	}
+	if a.Mode == ModeInteractive && !a.Confirm("Replace "+path+"?") {
+		return true, false, nil
+	}
+	if exists {
+		if err := backupConfigFile(path, backupDir); err != nil {
+			return true, false, errors.New("backing up: " + err.Error())
+		}
+	}
+	if err := writeFileAtomic(path, []byte(toText)); err != nil {
+		return true, false, errors.New("writing: " + err.Error())
+	}
+	log.Infof("replaced %s\n", path)
+	return true, true, nil
+}
+
+// getFile gets a config file from its URL if it has one, else from Traffic Ops.
+func (a *Agent) getFile(file tc.ATSConfigMetaDataConfigFile) (string, error) {
+	if file.URL != "" {
+		bts, err := a.GetURL(file.URL)
+		return string(bts), err
+	}
+	if file.APIURI == "" {
+		return "", errors.New("file has no URL or API URI")
+	}
+	bts, _, err := a.TO.GetConfigFile(file.APIURI)
+	return string(bts), err
+}
+
+// fileAction returns what Traffic Server must do for a change to the given file to take effect.
+func fileAction(file tc.ATSConfigMetaDataConfigFile) Action {
+	if _, ok := restartConfigFiles[file.FileNameOnDisk]; ok {
+		return ActionRestart
+	}
+	for _, dir := range strings.Split(filepath.Clean(file.Location), string(filepath.Separator)) {
+		if dir == TrafficServerLocationDir {
+			return ActionReload
+		}
+	}
+	return ActionNone
+}
+
+func maxAction(a Action, b Action) Action {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// touchDependents updates the modification time of remap.config if a remap plugin config changed, and of ssl_multicert.config if a certificate or key changed, because Traffic Server only rereads those files if their parent changed.
+func (a *Agent) touchDependents(files []tc.ATSConfigMetaDataConfigFile, changedNames map[string]struct{}) error {
+	touchRemap := false
+	touchSSL := false
+	for name := range changedNames {
+		for _, prefix := range remapPluginConfigPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				touchRemap = true
+			}
+		}
+		if strings.HasSuffix(name, ".cer") || strings.HasSuffix(name, ".key") {
+			touchSSL = true
+		}
+	}
+	for _, file := range files {
+		if _, ok := changedNames[file.FileNameOnDisk]; ok {
+			continue // already changed, and so already reread
+		}
+		if (file.FileNameOnDisk == RemapConfigFile && touchRemap) || (file.FileNameOnDisk == SSLMultiCertConfigFile && touchSSL) {
+			path := filepath.Join(file.Location, file.FileNameOnDisk)
+			now := a.Now()
+			if err := os.Chtimes(path, now, now); err != nil {
+				return errors.New("touching " + path + ": " + err.Error())
+			}
+			log.Infof("touched %s\n", path)
+		}
+	}
+	return nil
+}
+
+// applyAction reloads or restarts Traffic Server with traffic_ctl. In interactive mode, it's only done if the user says yes. Returns whether Traffic Server is now using the new config.
+func (a *Agent) applyAction(action Action) (bool, error) {
+	args := []string{}
+	switch action {
+	case ActionReload:
+		args = []string{"config", "reload"}
+	case ActionRestart:
+		args = []string{"server", "restart"}
+	default:
+		return true, nil
+	}
+	if a.Mode == ModeInteractive && !a.Confirm(strings.Title(action.String())+" Traffic Server?") {
+		return false, nil
+	}
+	if out, err := a.Runner.Run(a.TrafficCtl, args...); err != nil {
+		return false, errors.New("running " + a.TrafficCtl + " " + strings.Join(args, " ") + ": " + err.Error() + ": " + string(out))
+	}
+	log.Infof("ran %s %s\n", a.TrafficCtl, strings.Join(args, " "))
+	return true, nil
+}
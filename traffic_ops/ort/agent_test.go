@@ -0,0 +1,254 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+type fakeTO struct {
+	update  client.Update
+	meta    tc.ATSConfigMetaData
+	files   map[string]string
+	fetched []string
+	// setUpdates is the update and reval statuses of each SetUpdate call.
+	setUpdates [][2]int
+}
+
+func (to *fakeTO) GetUpdate(serverName string) (client.Update, client.ReqInf, error) {
+	return to.update, client.ReqInf{}, nil
+}
+
+func (to *fakeTO) SetUpdate(serverName string, updatePending int, revalPending int) (client.ReqInf, error) {
+	to.setUpdates = append(to.setUpdates, [2]int{updatePending, revalPending})
+	return client.ReqInf{}, nil
+}
+
+func (to *fakeTO) GetServerConfigFiles(hostName string) (tc.ATSConfigMetaData, client.ReqInf, error) {
+	return to.meta, client.ReqInf{}, nil
+}
+
+func (to *fakeTO) GetConfigFile(apiURI string) ([]byte, client.ReqInf, error) {
+	to.fetched = append(to.fetched, apiURI)
+	text, ok := to.files[apiURI]
+	if !ok {
+		return nil, client.ReqInf{}, errors.New("not found")
+	}
+	return []byte(text), client.ReqInf{}, nil
+}
+
+type fakeRunner struct {
+	commands []string
+}
+
+func (r *fakeRunner) Run(name string, args ...string) ([]byte, error) {
+	r.commands = append(r.commands, strings.Join(append([]string{name}, args...), " "))
+	return nil, nil
+}
+
+// newTestAgent returns an agent for a cache with the config files plugin.config, records.config, remap.config, url_sig_ds1.config and regex_revalidate.config in a temp dir, which the caller must remove.
+func newTestAgent(t *testing.T, mode Mode) (*Agent, *fakeTO, *fakeRunner, string) {
+	dir, err := ioutil.TempDir("", "ort")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	tsDir := filepath.Join(dir, "etc", "trafficserver")
+	to := &fakeTO{
+		update: client.Update{UpdatePending: true},
+		meta: tc.ATSConfigMetaData{
+			Info: tc.ATSConfigMetaDataInfo{ServerTCPPort: 8080},
+			ConfigFiles: []tc.ATSConfigMetaDataConfigFile{
+				{FileNameOnDisk: "plugin.config", Location: tsDir, APIURI: "/plugin.config"},
+				{FileNameOnDisk: "records.config", Location: tsDir, APIURI: "/records.config"},
+				{FileNameOnDisk: "remap.config", Location: tsDir, APIURI: "/remap.config"},
+				{FileNameOnDisk: "url_sig_ds1.config", Location: tsDir, APIURI: "/url_sig_ds1.config"},
+				{FileNameOnDisk: "regex_revalidate.config", Location: tsDir, APIURI: "/regex_revalidate.config"},
+			},
+		},
+		files: map[string]string{
+			"/plugin.config":           "astats_over_http.so\n",
+			"/records.config":          "CONFIG proxy.config.http.server_ports STRING __SERVER_TCP_PORT__\n",
+			"/remap.config":            "map http://a/ http://b/\n",
+			"/url_sig_ds1.config":      "key0 = abc\n",
+			"/regex_revalidate.config": "http://a/.* 1509703200\n",
+		},
+	}
+	for uri, text := range to.files {
+		if uri == "/records.config" || uri == "/url_sig_ds1.config" || uri == "/regex_revalidate.config" {
+			text = "old\n" // files which differ from Traffic Ops
+		}
+		if err := writeFileAtomic(filepath.Join(tsDir, uri[1:]), []byte(text)); err != nil {
+			t.Fatalf("writing test file: %v", err)
+		}
+	}
+	runner := &fakeRunner{}
+	agent := &Agent{
+		TO:         to,
+		HostName:   "edge1",
+		Mode:       mode,
+		BackupDir:  filepath.Join(dir, "backups"),
+		TrafficCtl: "traffic_ctl",
+		Runner:     runner,
+		Confirm:    func(string) bool { return true },
+		Out:        ioutil.Discard,
+		Now:        func() time.Time { return time.Unix(1509703200, 0) },
+	}
+	return agent, to, runner, dir
+}
+
+func TestRunBatch(t *testing.T) {
+	agent, to, runner, dir := newTestAgent(t, ModeBatch)
+	defer os.RemoveAll(dir)
+	tsDir := filepath.Join(dir, "etc", "trafficserver")
+
+	result, err := agent.Run()
+	if err != nil {
+		t.Fatalf("Run expected: nil error, actual: %v", err)
+	}
+	expectedChanged := []string{filepath.Join(tsDir, "records.config"), filepath.Join(tsDir, "url_sig_ds1.config"), filepath.Join(tsDir, "regex_revalidate.config")}
+	if !reflect.DeepEqual(expectedChanged, result.Changed) {
+		t.Errorf("Run changed expected: %v, actual: %v", expectedChanged, result.Changed)
+	}
+	if result.Action != ActionReload {
+		t.Errorf("Run action expected: %v, actual: %v", ActionReload, result.Action)
+	}
+	if expected := []string{"traffic_ctl config reload"}; !reflect.DeepEqual(expected, runner.commands) {
+		t.Errorf("Run commands expected: %v, actual: %v", expected, runner.commands)
+	}
+	if expected := [][2]int{{client.UpdateStatusClear, client.UpdateStatusClear}}; !reflect.DeepEqual(expected, to.setUpdates) {
+		t.Errorf("Run SetUpdate expected: %v, actual: %v", expected, to.setUpdates)
+	}
+
+	if bts, _ := ioutil.ReadFile(filepath.Join(tsDir, "records.config")); string(bts) != "CONFIG proxy.config.http.server_ports STRING 8080\n" {
+		t.Errorf("Run records.config expected: substituted Traffic Ops file, actual: '%s'", bts)
+	}
+	if bts, _ := ioutil.ReadFile(filepath.Join(dir, "backups", "1509703200", "records.config")); string(bts) != "old\n" {
+		t.Errorf("Run records.config backup expected: 'old', actual: '%s'", bts)
+	}
+	if fi, err := os.Stat(filepath.Join(tsDir, "remap.config")); err != nil || !fi.ModTime().Equal(time.Unix(1509703200, 0)) {
+		t.Errorf("Run expected: remap.config touched for changed url_sig config, actual: %v %v", fi, err)
+	}
+}
+
+func TestRunRestart(t *testing.T) {
+	agent, to, runner, dir := newTestAgent(t, ModeBatch)
+	defer os.RemoveAll(dir)
+	to.files["/plugin.config"] = "astats_over_http.so\nregex_revalidate.so\n"
+
+	result, err := agent.Run()
+	if err != nil {
+		t.Fatalf("Run expected: nil error, actual: %v", err)
+	}
+	if result.Action != ActionRestart {
+		t.Errorf("Run action expected: %v, actual: %v", ActionRestart, result.Action)
+	}
+	if expected := []string{"traffic_ctl server restart"}; !reflect.DeepEqual(expected, runner.commands) {
+		t.Errorf("Run commands expected: %v, actual: %v", expected, runner.commands)
+	}
+}
+
+func TestRunReport(t *testing.T) {
+	agent, to, runner, dir := newTestAgent(t, ModeReport)
+	defer os.RemoveAll(dir)
+	to.update = client.Update{}
+
+	result, err := agent.Run()
+	if err != nil {
+		t.Fatalf("Run expected: nil error, actual: %v", err)
+	}
+	if len(result.Changed) != 3 || result.Action != ActionReload || result.UpdateCleared {
+		t.Errorf("Run report expected: 3 changed files needing reload and update not cleared, actual: %+v", result)
+	}
+	if len(runner.commands) != 0 || len(to.setUpdates) != 0 {
+		t.Errorf("Run report expected: no commands or SetUpdate, actual: %v %v", runner.commands, to.setUpdates)
+	}
+	if bts, _ := ioutil.ReadFile(filepath.Join(dir, "etc", "trafficserver", "records.config")); string(bts) != "old\n" {
+		t.Errorf("Run report expected: records.config unchanged, actual: '%s'", bts)
+	}
+}
+
+func TestRunInteractiveDeclined(t *testing.T) {
+	agent, to, runner, dir := newTestAgent(t, ModeInteractive)
+	defer os.RemoveAll(dir)
+	agent.Confirm = func(question string) bool { return !strings.Contains(question, "records.config") }
+
+	result, err := agent.Run()
+	if err != nil {
+		t.Fatalf("Run expected: nil error, actual: %v", err)
+	}
+	if result.UpdateCleared || len(to.setUpdates) != 0 {
+		t.Errorf("Run declined file expected: update not cleared, actual: %v", to.setUpdates)
+	}
+	if expected := []string{"traffic_ctl config reload"}; !reflect.DeepEqual(expected, runner.commands) {
+		t.Errorf("Run commands expected: %v, actual: %v", expected, runner.commands)
+	}
+}
+
+func TestRunRevalOnly(t *testing.T) {
+	agent, to, runner, dir := newTestAgent(t, ModeBatch)
+	defer os.RemoveAll(dir)
+	to.update = client.Update{RevalPending: true}
+
+	result, err := agent.Run()
+	if err != nil {
+		t.Fatalf("Run expected: nil error, actual: %v", err)
+	}
+	if expected := []string{"/regex_revalidate.config"}; !reflect.DeepEqual(expected, to.fetched) {
+		t.Errorf("Run reval fetched expected: %v, actual: %v", expected, to.fetched)
+	}
+	if len(result.Changed) != 1 || len(runner.commands) != 1 || len(to.setUpdates) != 1 {
+		t.Errorf("Run reval expected: 1 changed file, reload, and SetUpdate, actual: %v %v %v", result.Changed, runner.commands, to.setUpdates)
+	}
+}
+
+func TestRunNothingPending(t *testing.T) {
+	for _, update := range []client.Update{{}, {UpdatePending: true, ParentPending: true}} {
+		agent, to, runner, dir := newTestAgent(t, ModeBatch)
+		agent.WaitForParents = true
+		to.update = update
+
+		if _, err := agent.Run(); err != nil {
+			t.Errorf("Run %+v expected: nil error, actual: %v", update, err)
+		}
+		if len(to.fetched) != 0 || len(runner.commands) != 0 || len(to.setUpdates) != 0 {
+			t.Errorf("Run %+v expected: nothing done, actual: %v %v %v", update, to.fetched, runner.commands, to.setUpdates)
+		}
+		os.RemoveAll(dir)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if m, err := ParseMode("Batch"); err != nil || m != ModeBatch {
+		t.Errorf("ParseMode expected: %v, actual: %v %v", ModeBatch, m, err)
+	}
+	if _, err := ParseMode("syncds"); err == nil {
+		t.Errorf("ParseMode unknown mode expected: error, actual: nil")
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// GeneratedHeaderPrefix is the start of the comment Traffic Ops puts at the top of generated files. It contains the generation time, so it's ignored when comparing files.
+const GeneratedHeaderPrefix = "# DO NOT EDIT - Generated for "
+
+// DefaultFileMode is the mode of config files which don't exist on disk yet.
+const DefaultFileMode = os.FileMode(0644)
+
+// DefaultDirMode is the mode of config directories which don't exist on disk yet.
+const DefaultDirMode = os.FileMode(0755)
+
+// substituteConfigVars replaces the variables Traffic Ops leaves for the cache to fill in, the same as the Perl ORT. The port is removed along with its colon if it's 80.
+func substituteConfigVars(text string, info tc.ATSConfigMetaDataInfo, hostName string, fullHostName string) string {
+	if info.ServerTCPPort == 80 || info.ServerTCPPort == 0 {
+		text = strings.Replace(text, ":__SERVER_TCP_PORT__", "", -1)
+	}
+	text = strings.Replace(text, "__SERVER_TCP_PORT__", strconv.Itoa(info.ServerTCPPort), -1)
+	text = strings.Replace(text, "__CACHE_IPV4__", info.ServerIPv4, -1)
+	text = strings.Replace(text, "__FULL_HOSTNAME__", fullHostName, -1)
+	text = strings.Replace(text, "__HOSTNAME__", hostName, -1)
+	text = strings.Replace(text, "__RETURN__", "\n", -1)
+	return text
+}
+
+// normalizeConfigLines returns the lines of a config file which matter when comparing it: whitespace is collapsed, and empty lines and the generated header are dropped.
+func normalizeConfigLines(text string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || strings.HasPrefix(line, GeneratedHeaderPrefix) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// diffConfig returns the lines only in the file on disk, and the lines only in the file from Traffic Ops, in file order. Line order doesn't matter, like the Perl ORT.
+func diffConfig(disk string, to string) ([]string, []string) {
+	diskLines := normalizeConfigLines(disk)
+	toLines := normalizeConfigLines(to)
+	return linesNotIn(diskLines, toLines), linesNotIn(toLines, diskLines)
+}
+
+func linesNotIn(lines []string, others []string) []string {
+	otherSet := map[string]struct{}{}
+	for _, line := range others {
+		otherSet[line] = struct{}{}
+	}
+	notIn := []string{}
+	for _, line := range lines {
+		if _, ok := otherSet[line]; !ok {
+			notIn = append(notIn, line)
+		}
+	}
+	return notIn
+}
+
+// readConfigFile returns the contents of the given file, and false if it doesn't exist.
+func readConfigFile(path string) (string, bool, error) {
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return string(bts), true, nil
+}
+
+// backupConfigFile copies the given file into the given backup directory, creating the directory if necessary.
+func backupConfigFile(path string, backupDir string) error {
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.New("reading: " + err.Error())
+	}
+	if err := os.MkdirAll(backupDir, DefaultDirMode); err != nil {
+		return errors.New("creating backup directory: " + err.Error())
+	}
+	if err := ioutil.WriteFile(filepath.Join(backupDir, filepath.Base(path)), bts, DefaultFileMode); err != nil {
+		return errors.New("writing backup: " + err.Error())
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the given file with the given contents, so Traffic Server never reads a partially written file. The contents are written to a temp file in the same directory, which is then renamed over the file. The file keeps its mode if it exists, else it gets DefaultFileMode.
+func writeFileAtomic(path string, bts []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, DefaultDirMode); err != nil {
+		return errors.New("creating directory: " + err.Error())
+	}
+	mode := DefaultFileMode
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode()
+	} else if !os.IsNotExist(err) {
+		return errors.New("getting file mode: " + err.Error())
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".ort")
+	if err != nil {
+		return errors.New("creating temp file: " + err.Error())
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(bts); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return errors.New("writing temp file: " + err.Error())
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return errors.New("syncing temp file: " + err.Error())
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return errors.New("closing temp file: " + err.Error())
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return errors.New("setting temp file mode: " + err.Error())
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return errors.New("renaming temp file: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+func TestSubstituteConfigVars(t *testing.T) {
+	info := tc.ATSConfigMetaDataInfo{ServerIPv4: "192.0.2.1", ServerTCPPort: 8080}
+	text := "map http://__HOSTNAME__:__SERVER_TCP_PORT__/ http://__CACHE_IPV4__/__RETURN__name __FULL_HOSTNAME__"
+	expected := "map http://edge1:8080/ http://192.0.2.1/\nname edge1.example.net"
+	if actual := substituteConfigVars(text, info, "edge1", "edge1.example.net"); actual != expected {
+		t.Errorf("substituteConfigVars expected: '%s', actual: '%s'", expected, actual)
+	}
+
+	info.ServerTCPPort = 80
+	expected = "map http://edge1/ http://192.0.2.1/\nname edge1.example.net"
+	if actual := substituteConfigVars(text, info, "edge1", "edge1.example.net"); actual != expected {
+		t.Errorf("substituteConfigVars port 80 expected: '%s', actual: '%s'", expected, actual)
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	disk := "# DO NOT EDIT - Generated for edge1 by Traffic Ops (https://to.example.net/) on Wed Nov  1 12:00:00 UTC 2017\n" +
+		"CONFIG a INT 1\n\nCONFIG   b INT 2\nCONFIG c INT 3\n"
+	to := "# DO NOT EDIT - Generated for edge1 by Traffic Ops (https://to.example.net/) on Thu Nov  2 12:00:00 UTC 2017\n" +
+		"CONFIG c INT 3\r\nCONFIG b INT 2\nCONFIG d INT 4\n"
+	diskOnly, toOnly := diffConfig(disk, to)
+	if expected := []string{"CONFIG a INT 1"}; !reflect.DeepEqual(expected, diskOnly) {
+		t.Errorf("diffConfig disk only expected: %v, actual: %v", expected, diskOnly)
+	}
+	if expected := []string{"CONFIG d INT 4"}; !reflect.DeepEqual(expected, toOnly) {
+		t.Errorf("diffConfig Traffic Ops only expected: %v, actual: %v", expected, toOnly)
+	}
+
+	diskOnly, toOnly = diffConfig(disk, disk)
+	if len(diskOnly) != 0 || len(toOnly) != 0 {
+		t.Errorf("diffConfig same file expected: no differences, actual: %v %v", diskOnly, toOnly)
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ort")
+	if err != nil {
+		t.Fatalf("creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "etc", "records.config")
+	if err := writeFileAtomic(path, []byte("a\n")); err != nil {
+		t.Fatalf("writeFileAtomic new file expected: nil error, actual: %v", err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode() != DefaultFileMode {
+		t.Errorf("writeFileAtomic new file mode expected: %v, actual: %v %v", DefaultFileMode, fi, err)
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatalf("setting mode: %v", err)
+	}
+	if err := writeFileAtomic(path, []byte("b\n")); err != nil {
+		t.Fatalf("writeFileAtomic existing file expected: nil error, actual: %v", err)
+	}
+	if bts, err := ioutil.ReadFile(path); err != nil || string(bts) != "b\n" {
+		t.Errorf("writeFileAtomic contents expected: 'b\n', actual: '%s' %v", bts, err)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode() != 0600 {
+		t.Errorf("writeFileAtomic existing file mode expected: %v, actual: %v %v", os.FileMode(0600), fi, err)
+	}
+	if files, _ := ioutil.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Errorf("writeFileAtomic expected: no temp files left, actual: %v files", len(files))
+	}
+}
@@ -1,5 +1,3 @@
-package data
-
 /*
  * Licensed to the Apache Software Foundation (ASF) under one
  * or more contributor license agreements.  See the NOTICE file
@@ -8,9 +6,9 @@ package data
  * to you under the Apache License, Version 2.0 (the
  * "License"); you may not use this file except in compliance
  * with the License.  You may obtain a copy of the License at
- * 
+ *
  *   http://www.apache.org/licenses/LICENSE-2.0
- * 
+ *
  * Unless required by applicable law or agreed to in writing,
  * software distributed under the License is distributed on an
  * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
@@ -19,14 +17,21 @@ package data
  * under the License.
  */
 
+package main
 
-import "time"
+import (
+	"os/exec"
+)
 
-type HttpResult struct {
-	RequestTime time.Time `json:"requestTime"`
-	Host        string    `json:"host"`
-	LatencyUsec int64     `json:"latency"`
-	Err         error     `json:"error"`
-	Status      int       `json:"httpStatus"`
+// CommandRunner runs the commands which make the cache use its new config, e.g. traffic_ctl. It exists so tests, and hosts which manage Traffic Server some other way, can replace it.
+type CommandRunner interface {
+	// Run runs the given command, and returns its combined output.
+	Run(name string, args ...string) ([]byte, error)
 }
 
+// ExecRunner is a CommandRunner which runs commands on the local machine.
+type ExecRunner struct{}
+
+func (ExecRunner) Run(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// traffic_ops_ort makes a cache's config files match Traffic Ops, and tells Traffic Ops when the cache is up to date. It replaces traffic_ops/bin/traffic_ops_ort.pl.
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+const UserAgent = "traffic_ops_ort/0.1"
+
+const DefaultTrafficCtl = "/opt/trafficserver/bin/traffic_ctl"
+const DefaultBackupDir = "/opt/ort/backups"
+const DefaultTimeout = 30 * time.Second
+
+func main() {
+	toURL := flag.String("to", "", "The Traffic Ops URL")
+	toUser := flag.String("touser", "", "The Traffic Ops user")
+	toPass := flag.String("topass", "", "The Traffic Ops password")
+	modeStr := flag.String("mode", string(ModeReport), "report to only print differences, interactive to ask before each change, or batch to apply every change")
+	hostName := flag.String("host", "", "The host name of this cache in Traffic Ops, default the short host name")
+	insecure := flag.Bool("insecure", false, "Whether to skip verifying the Traffic Ops certificate")
+	timeout := flag.Duration("timeout", DefaultTimeout, "The timeout of Traffic Ops requests")
+	backupDir := flag.String("backupdir", DefaultBackupDir, "The directory replaced config files are copied to")
+	trafficCtl := flag.String("traffic-ctl", DefaultTrafficCtl, "The path of traffic_ctl")
+	waitForParents := flag.Bool("wait-for-parents", false, "Whether to skip the update while the cache's parents have updates pending")
+	force := flag.Bool("force", false, "Whether to apply the config even if no update is pending")
+	verbose := flag.Bool("verbose", false, "Whether to log debug messages")
+	help := flag.Bool("help", false, "Usage info")
+	helpBrief := flag.Bool("h", false, "Usage info")
+	flag.Parse()
+	if *help || *helpBrief || *toURL == "" {
+		fmt.Printf("Usage: ./traffic_ops_ort -to https://traffic-ops.example.net -touser bill -topass thelizard -mode batch\n")
+		flag.PrintDefaults()
+		return
+	}
+
+	debugW := log.NopCloser(ioutil.Discard)
+	if *verbose {
+		debugW = log.NopCloser(os.Stderr)
+	}
+	log.Init(log.NopCloser(os.Stdout), log.NopCloser(os.Stderr), log.NopCloser(os.Stderr), log.NopCloser(os.Stderr), debugW)
+
+	mode, err := ParseMode(*modeStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fullHostName, err := os.Hostname()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting host name: %v\n", err)
+		os.Exit(1)
+	}
+	if *hostName == "" {
+		*hostName = strings.SplitN(fullHostName, ".", 2)[0]
+	}
+
+	toClient, _, err := client.LoginWithAgent(*toURL, *toUser, *toPass, *insecure, UserAgent, false, *timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error logging in to Traffic Ops: %v\n", err)
+		os.Exit(1)
+	}
+
+	agent := Agent{
+		TO:             toClient,
+		HostName:       *hostName,
+		FullHostName:   fullHostName,
+		Mode:           mode,
+		Force:          *force,
+		WaitForParents: *waitForParents,
+		BackupDir:      *backupDir,
+		TrafficCtl:     *trafficCtl,
+		Runner:         ExecRunner{},
+		GetURL:         httpGetter(*insecure, *timeout),
+		Confirm:        stdinConfirm,
+		Out:            os.Stdout,
+		Now:            time.Now,
+	}
+	if _, err := agent.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// httpGetter returns a func which gets config files from URLs outside Traffic Ops.
+func httpGetter(insecure bool, timeout time.Duration) func(url string) ([]byte, error) {
+	httpClient := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure}},
+	}
+	return func(url string) ([]byte, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("User-Agent", UserAgent)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.New("response code " + strconv.Itoa(resp.StatusCode))
+		}
+		return ioutil.ReadAll(resp.Body)
+	}
+}
+
+var stdin = bufio.NewReader(os.Stdin)
+
+// stdinConfirm asks a yes or no question on the terminal. Anything but yes is no.
+func stdinConfirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := stdin.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,239 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package totest
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// route is an endpoint served by the fake Traffic Ops. The handler is called with the Server's mutex held, and with the path's regular expression submatches.
+type route struct {
+	method  string
+	path    *regexp.Regexp
+	auth    bool
+	handler func(s *Server, w http.ResponseWriter, r *http.Request, params []string)
+}
+
+var routes = []route{
+	{http.MethodPost, regexp.MustCompile(`^/api/1\.2/user/login/?$`), false, login},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/cdns/?(?:\.json)?$`), true, getCDNs},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/cdns/name/([^/]+?)(?:\.json)?$`), true, getCDNByName},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/cdns/([^/]+)/configs/monitoring(?:\.json)?$`), true, getMonitoringConfig},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/cdns/([^/]+)/snapshot/?(?:\.json)?$`), true, getSnapshot},
+	{http.MethodGet, regexp.MustCompile(`^/CRConfig-Snapshots/([^/]+)/CRConfig\.json$`), true, getCRConfig},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/servers/?(?:\.json)?$`), true, getServers},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/servers/hostname/([^/]+)/details(?:\.json)?$`), true, getServerDetails},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/servers/([^/]+)/update_status$`), true, getUpdateStatus},
+	{http.MethodGet, regexp.MustCompile(`^/update/([^/]+)$`), true, getUpdateStatus},
+	{http.MethodPost, regexp.MustCompile(`^/update/([^/]+)$`), true, postUpdate},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/parameters/?(?:\.json)?$`), true, getParameters},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/parameters/profile/([^/]+?)(?:\.json)?$`), true, getProfileParameters},
+	{http.MethodGet, regexp.MustCompile(`^/api/1\.2/stats_summary/?(?:\.json)?$`), true, getStatsSummary},
+	{http.MethodPost, regexp.MustCompile(`^/api/1\.2/stats_summary/create/?$`), true, createStatsSummary},
+}
+
+// login sets the authentication cookie, if the credentials are those of a user in the Model. If the Model has no users, any credentials are accepted.
+func login(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	creds := tc.UserCredentials{}
+	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+		writeErr(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if password, ok := s.model.Users[creds.Username]; len(s.model.Users) > 0 && (!ok || password != creds.Password) {
+		writeErr(w, http.StatusUnauthorized, "Invalid username or password.")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: CookieName, Value: s.cookie, Path: "/", HttpOnly: true})
+	writeJSON(w, http.StatusOK, tc.CreateAlerts(tc.SuccessLevel, "Successfully logged in."))
+}
+
+func getCDNs(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	writeJSON(w, http.StatusOK, tc.CDNsResponse{Response: s.model.CDNs})
+}
+
+func getCDNByName(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	cdns := []tc.CDN{}
+	for _, cdn := range s.model.CDNs {
+		if cdn.Name == params[0] {
+			cdns = append(cdns, cdn)
+		}
+	}
+	writeJSON(w, http.StatusOK, tc.CDNsResponse{Response: cdns})
+}
+
+func getMonitoringConfig(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	cfg, ok := s.model.MonitoringConfigs[params[0]]
+	if !ok {
+		writeErr(w, http.StatusNotFound, "CDN not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Response monitoringConfig `json:"response"`
+	}{newMonitoringConfig(cfg)})
+}
+
+func getSnapshot(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	crc, ok := s.model.CRConfigs[params[0]]
+	if !ok {
+		writeErr(w, http.StatusNotFound, "CDN not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Response json.RawMessage `json:"response"`
+	}{crc})
+}
+
+// getCRConfig serves the CRConfig snapshot as Traffic Ops serves the snapshot file, unwrapped.
+func getCRConfig(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	crc, ok := s.model.CRConfigs[params[0]]
+	if !ok {
+		writeErr(w, http.StatusNotFound, "CRConfig not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(crc)
+}
+
+// getServers serves the servers, filtered by the type, hostName, status, profileId, cachegroup and cdn query parameters. The cachegroup and cdn parameters are IDs.
+func getServers(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	q := r.URL.Query()
+	matches := func(param, val string) bool {
+		return q.Get(param) == "" || q.Get(param) == val
+	}
+	servers := []tc.Server{}
+	for _, server := range s.model.Servers {
+		if matches("type", server.Type) &&
+			matches("hostName", server.HostName) &&
+			matches("status", server.Status) &&
+			matches("profileId", strconv.Itoa(server.ProfileID)) &&
+			matches("cachegroup", strconv.Itoa(server.CachegroupID)) &&
+			matches("cdn", strconv.Itoa(server.CDNID)) {
+			servers = append(servers, server)
+		}
+	}
+	writeJSON(w, http.StatusOK, tc.ServersResponse{Response: servers})
+}
+
+func getServerDetails(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	server, ok := s.model.server(params[0])
+	if !ok {
+		writeErr(w, http.StatusNotFound, "Server not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, &tc.ServersDetailResponse{Response: *server}) // a pointer, because tc.Time only serializes correctly when addressable
+}
+
+// getUpdateStatus serves the update status of the server, as a single-element array, as Traffic Ops does.
+func getUpdateStatus(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	server, ok := s.model.server(params[0])
+	if !ok {
+		writeErr(w, http.StatusNotFound, "Server not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, []tc.ServerUpdateStatus{{
+		HostName:      server.HostName,
+		UpdatePending: server.UpdPending,
+		RevalPending:  server.RevalPending,
+		HostId:        server.ID,
+		Status:        server.Status,
+	}})
+}
+
+// postUpdate sets the update and revalidate pending flags of the server from the updated and reval_updated query parameters. A missing parameter leaves its flag unchanged.
+func postUpdate(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	server, ok := s.model.server(params[0])
+	if !ok {
+		writeErr(w, http.StatusNotFound, "Server not found")
+		return
+	}
+	upd, reval := server.UpdPending, server.RevalPending
+	var err error
+	if v := r.URL.Query().Get("updated"); v != "" {
+		if upd, err = strconv.ParseBool(v); err != nil {
+			writeErr(w, http.StatusBadRequest, "Invalid updated value '"+v+"'")
+			return
+		}
+	}
+	if v := r.URL.Query().Get("reval_updated"); v != "" {
+		if reval, err = strconv.ParseBool(v); err != nil {
+			writeErr(w, http.StatusBadRequest, "Invalid reval_updated value '"+v+"'")
+			return
+		}
+	}
+	server.UpdPending, server.RevalPending = upd, reval
+	writeJSON(w, http.StatusOK, tc.CreateAlerts(tc.SuccessLevel, "Successfully updated status for "+server.HostName))
+}
+
+func getParameters(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	all := []tc.Parameter{}
+	for _, profileParams := range s.model.Parameters {
+		all = append(all, profileParams...)
+	}
+	writeJSON(w, http.StatusOK, tc.ParametersResponse{Response: all})
+}
+
+func getProfileParameters(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	profileParams := s.model.Parameters[params[0]]
+	if profileParams == nil {
+		profileParams = []tc.Parameter{}
+	}
+	writeJSON(w, http.StatusOK, tc.ParametersResponse{Response: profileParams})
+}
+
+// getStatsSummary serves the stats summaries, filtered by the cdnName, deliveryServiceName and statName query parameters. If the lastSummaryDate parameter is given, the latest summary time is served instead.
+func getStatsSummary(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	q := r.URL.Query()
+	matches := func(param, val string) bool {
+		return q.Get(param) == "" || q.Get(param) == val
+	}
+	summaries := []tc.StatsSummary{}
+	for _, summary := range s.model.StatsSummaries {
+		if matches("cdnName", summary.CDNName) && matches("deliveryServiceName", summary.DeliveryService) && matches("statName", summary.StatName) {
+			summaries = append(summaries, summary)
+		}
+	}
+
+	if q.Get("lastSummaryDate") == "" {
+		writeJSON(w, http.StatusOK, tc.StatsSummaryResponse{Response: summaries})
+		return
+	}
+	lastUpdated := tc.LastUpdated{}
+	for _, summary := range summaries {
+		if summary.SummaryTime > lastUpdated.Response.SummaryTime {
+			lastUpdated.Response.SummaryTime = summary.SummaryTime // summary times are "YYYY-MM-DD hh:mm:ss", so they sort lexically
+		}
+	}
+	writeJSON(w, http.StatusOK, lastUpdated)
+}
+
+func createStatsSummary(s *Server, w http.ResponseWriter, r *http.Request, params []string) {
+	summary := tc.StatsSummary{}
+	if err := json.NewDecoder(r.Body).Decode(&summary); err != nil {
+		writeErr(w, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	s.model.StatsSummaries = append(s.model.StatsSummaries, summary)
+	writeJSON(w, http.StatusOK, tc.CreateAlerts(tc.SuccessLevel, "Successfully added stats summary record"))
+}
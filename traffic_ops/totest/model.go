@@ -0,0 +1,234 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+// Package totest provides an in-process fake Traffic Ops, for integration testing Traffic Ops clients such as Traffic Monitor and Traffic Stats without external services.
+//
+// The fake serves login, CDNs, servers, parameters, monitoring configs, CRConfig snapshots, update statuses and stats summaries from an in-memory Model, which may be built in Go or loaded from a JSON fixture. It records every request, and faults such as latency, error statuses and malformed JSON may be injected.
+//
+//	m := totest.NewModel().
+//	    User("admin", "password").
+//	    CDN(tc.CDN{Name: "cdn1", DomainName: "cdn1.example.net"}).
+//	    Server(tc.Server{HostName: "edge0", CDNName: "cdn1", Type: "EDGE", Profile: "EDGE0"})
+//	to := totest.NewServer(m)
+//	defer to.Close()
+//	session, _, err := client.LoginWithAgent(to.URL, "admin", "password", true, "test", false, time.Second)
+package totest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// Model is the data served by a fake Traffic Ops. The builder methods return the Model, so calls may be chained. The Model must not be modified directly after it's given to NewServer; use Server.Update instead.
+type Model struct {
+	// Users is the map of user names to passwords which may log in.
+	Users map[string]string `json:"users"`
+	CDNs  []tc.CDN          `json:"cdns"`
+	// Servers is the list of servers. The update and revalidate pending flags are served by the update endpoints, and set by POSTs to them.
+	Servers []tc.Server `json:"servers"`
+	// Parameters is the map of profile names to the parameters of the profile.
+	Parameters map[string][]tc.Parameter `json:"parameters"`
+	// MonitoringConfigs is the map of CDN names to the CDN's Traffic Monitor config.
+	MonitoringConfigs map[string]tc.TrafficMonitorConfig `json:"monitoringConfigs"`
+	// CRConfigs is the map of CDN names to the CDN's raw CRConfig snapshot JSON.
+	CRConfigs      map[string]json.RawMessage `json:"crConfigs"`
+	StatsSummaries []tc.StatsSummary          `json:"statsSummaries"`
+}
+
+// NewModel returns a new, empty Model.
+func NewModel() *Model {
+	return &Model{
+		Users:             map[string]string{},
+		CDNs:              []tc.CDN{},
+		Servers:           []tc.Server{},
+		Parameters:        map[string][]tc.Parameter{},
+		MonitoringConfigs: map[string]tc.TrafficMonitorConfig{},
+		CRConfigs:         map[string]json.RawMessage{},
+		StatsSummaries:    []tc.StatsSummary{},
+	}
+}
+
+// ReadModel reads a Model fixture from the given JSON. The JSON is of the form of the Model struct, with each object in the form Traffic Ops serves it. For example:
+//
+//	{"users": {"admin": "password"}, "cdns": [{"name": "cdn1"}], "crConfigs": {"cdn1": {"stats": {"CDN_name": "cdn1"}}}}
+func ReadModel(r io.Reader) (*Model, error) {
+	m := NewModel()
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, errors.New("decoding model: " + err.Error())
+	}
+	m.init()
+	return m, nil
+}
+
+// LoadModel reads a Model fixture from the given JSON file. See ReadModel.
+func LoadModel(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.New("opening model: " + err.Error())
+	}
+	defer f.Close()
+	return ReadModel(f)
+}
+
+// init creates any nil maps, in case a fixture set them to null, and assigns IDs to CDNs and servers without them.
+func (m *Model) init() {
+	if m.Users == nil {
+		m.Users = map[string]string{}
+	}
+	if m.Parameters == nil {
+		m.Parameters = map[string][]tc.Parameter{}
+	}
+	if m.MonitoringConfigs == nil {
+		m.MonitoringConfigs = map[string]tc.TrafficMonitorConfig{}
+	}
+	if m.CRConfigs == nil {
+		m.CRConfigs = map[string]json.RawMessage{}
+	}
+	for i, cdn := range m.CDNs {
+		if cdn.ID == 0 {
+			m.CDNs[i].ID = i + 1
+		}
+	}
+	for i, server := range m.Servers {
+		if server.ID == 0 {
+			m.Servers[i].ID = i + 1
+		}
+	}
+}
+
+// User adds a user who may log in with the given password.
+func (m *Model) User(name, password string) *Model {
+	m.Users[name] = password
+	return m
+}
+
+// CDN adds the given CDN. If the CDN has no ID, one is assigned.
+func (m *Model) CDN(cdn tc.CDN) *Model {
+	if cdn.ID == 0 {
+		cdn.ID = len(m.CDNs) + 1
+	}
+	m.CDNs = append(m.CDNs, cdn)
+	return m
+}
+
+// Server adds the given server. If the server has no ID, one is assigned, and if it has a CDN name of an existing CDN but no CDN ID, the CDN's ID is set.
+func (m *Model) Server(server tc.Server) *Model {
+	if server.ID == 0 {
+		server.ID = len(m.Servers) + 1
+	}
+	if server.CDNID == 0 {
+		for _, cdn := range m.CDNs {
+			if cdn.Name == server.CDNName {
+				server.CDNID = cdn.ID
+				break
+			}
+		}
+	}
+	m.Servers = append(m.Servers, server)
+	return m
+}
+
+// Parameter adds the given parameters to the given profile.
+func (m *Model) Parameter(profile string, params ...tc.Parameter) *Model {
+	m.Parameters[profile] = append(m.Parameters[profile], params...)
+	return m
+}
+
+// MonitoringConfig sets the Traffic Monitor config of the given CDN.
+func (m *Model) MonitoringConfig(cdn string, cfg tc.TrafficMonitorConfig) *Model {
+	m.MonitoringConfigs[cdn] = cfg
+	return m
+}
+
+// CRConfig sets the CRConfig snapshot of the given CDN. It panics if the CRConfig can't be serialized, which can only happen if it contains invalid config values, which is a bug in the test.
+func (m *Model) CRConfig(cdn string, crc tc.CRConfig) *Model {
+	bts, err := json.Marshal(crc)
+	if err != nil {
+		panic("serializing CRConfig: " + err.Error())
+	}
+	return m.CRConfigRaw(cdn, bts)
+}
+
+// CRConfigRaw sets the CRConfig snapshot of the given CDN to the given raw JSON, which is served unchanged.
+func (m *Model) CRConfigRaw(cdn string, crc []byte) *Model {
+	m.CRConfigs[cdn] = json.RawMessage(crc)
+	return m
+}
+
+// StatsSummary adds the given stats summaries.
+func (m *Model) StatsSummary(summaries ...tc.StatsSummary) *Model {
+	m.StatsSummaries = append(m.StatsSummaries, summaries...)
+	return m
+}
+
+// server returns the server with the given host name, and whether it exists.
+func (m *Model) server(hostName string) (*tc.Server, bool) {
+	for i, server := range m.Servers {
+		if server.HostName == hostName {
+			return &m.Servers[i], true
+		}
+	}
+	return nil, false
+}
+
+// monitoringProfile is a tc.TMProfile as Traffic Ops serves it. The tc.TMParameters deserializes the Traffic Ops parameters into a struct, but doesn't serialize them back.
+type monitoringProfile struct {
+	Parameters map[string]interface{} `json:"parameters"`
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+}
+
+// monitoringConfig is a tc.TrafficMonitorConfig as Traffic Ops serves it.
+type monitoringConfig struct {
+	TrafficServers   []tc.TrafficServer     `json:"trafficServers"`
+	CacheGroups      []tc.TMCacheGroup      `json:"cacheGroups"`
+	Config           map[string]interface{} `json:"config"`
+	TrafficMonitors  []tc.TrafficMonitor    `json:"trafficMonitors"`
+	DeliveryServices []tc.TMDeliveryService `json:"deliveryServices"`
+	Profiles         []monitoringProfile    `json:"profiles"`
+}
+
+// newMonitoringConfig returns the given config in the form Traffic Ops serves it.
+func newMonitoringConfig(cfg tc.TrafficMonitorConfig) monitoringConfig {
+	profiles := make([]monitoringProfile, 0, len(cfg.Profiles))
+	for _, profile := range cfg.Profiles {
+		params := map[string]interface{}{
+			"health.connection.timeout": profile.Parameters.HealthConnectionTimeout,
+			"health.polling.url":        profile.Parameters.HealthPollingURL,
+			"history.count":             profile.Parameters.HistoryCount,
+		}
+		for stat, threshold := range profile.Parameters.Thresholds {
+			params["health.threshold."+stat] = threshold.Comparator + strconv.FormatFloat(threshold.Val, 'f', -1, 64)
+		}
+		profiles = append(profiles, monitoringProfile{Parameters: params, Name: profile.Name, Type: profile.Type})
+	}
+	return monitoringConfig{
+		TrafficServers:   cfg.TrafficServers,
+		CacheGroups:      cfg.CacheGroups,
+		Config:           cfg.Config,
+		TrafficMonitors:  cfg.TrafficMonitors,
+		DeliveryServices: cfg.DeliveryServices,
+		Profiles:         profiles,
+	}
+}
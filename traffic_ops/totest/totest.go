@@ -0,0 +1,229 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package totest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// CookieName is the name of the authentication cookie set by the login endpoint.
+const CookieName = "mojolicious"
+
+// Server is a fake Traffic Ops, serving its Model over HTTP.
+type Server struct {
+	*httptest.Server
+	m        sync.Mutex
+	model    *Model
+	cookie   string
+	requests []Request
+	faults   []*Fault
+}
+
+// Request is a request received by a Server.
+type Request struct {
+	Time   time.Time
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Fault is a fault injected into a Server's responses. A request matches a fault if both its method and path prefix match; an empty Method or PathPrefix matches every request. Only the first matching fault is applied to a request.
+type Fault struct {
+	Method     string
+	PathPrefix string
+	// Latency is the time to wait before responding.
+	Latency time.Duration
+	// Status, if not zero, is the HTTP status code to respond with, instead of serving the request.
+	Status int
+	// BadJSON is whether to respond with malformed JSON, instead of serving the request.
+	BadJSON bool
+	// Times is the number of requests to apply the fault to, after which it's removed. If zero, the fault is applied until ClearFaults is called.
+	Times int
+}
+
+// NewServer starts and returns a new fake Traffic Ops serving the given Model. If the Model is nil, an empty Model is served. The caller should call Close when finished, to shut it down.
+func NewServer(m *Model) *Server {
+	s := newServer(m)
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewTLSServer starts and returns a new fake Traffic Ops serving the given Model over HTTPS, with a self-signed certificate. See NewServer.
+func NewTLSServer(m *Model) *Server {
+	s := newServer(m)
+	s.Server = httptest.NewTLSServer(s)
+	return s
+}
+
+func newServer(m *Model) *Server {
+	if m == nil {
+		m = NewModel()
+	}
+	m.init()
+	return &Server{model: m, cookie: strconv.FormatInt(time.Now().UnixNano(), 36)}
+}
+
+// Update calls f with the Server's Model, which f may read or modify. Requests aren't served while f runs, so f must not make requests to the Server.
+func (s *Server) Update(f func(m *Model)) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	f(s.model)
+}
+
+// Requests returns the requests received by the Server, in the order they were received.
+func (s *Server) Requests() []Request {
+	s.m.Lock()
+	defer s.m.Unlock()
+	requests := make([]Request, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+// ClearRequests forgets the requests received by the Server.
+func (s *Server) ClearRequests() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.requests = nil
+}
+
+// InjectFault adds the given fault to the Server's responses.
+func (s *Server) InjectFault(f Fault) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults from the Server's responses.
+func (s *Server) ClearFaults() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.faults = nil
+}
+
+// fault returns a copy of the first fault matching the given request, and whether one matched. It must be called with the mutex held.
+func (s *Server) fault(r *http.Request) (Fault, bool) {
+	for i, f := range s.faults {
+		if (f.Method != "" && f.Method != r.Method) || !strings.HasPrefix(r.URL.Path, f.PathPrefix) {
+			continue
+		}
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return matched, true
+	}
+	return Fault{}, false
+}
+
+// ServeHTTP records the request, applies any fault, and serves the request from the Model.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErr(w, http.StatusBadRequest, "reading request body: "+err.Error())
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	s.m.Lock()
+	s.requests = append(s.requests, Request{
+		Time:   time.Now(),
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Header: r.Header,
+		Body:   body,
+	})
+	fault, faulted := s.fault(r)
+	s.m.Unlock()
+
+	if faulted {
+		time.Sleep(fault.Latency)
+		if fault.Status != 0 {
+			writeErr(w, fault.Status, "injected fault")
+			return
+		}
+		if fault.BadJSON {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"response": [{"injected": "fault"`))
+			return
+		}
+	}
+
+	for _, rt := range routes {
+		if rt.method != r.Method {
+			continue
+		}
+		match := rt.path.FindStringSubmatch(r.URL.Path)
+		if match == nil {
+			continue
+		}
+		if rt.auth && !s.authorized(r) {
+			writeErr(w, http.StatusUnauthorized, "Unauthorized, please log in.")
+			return
+		}
+		s.m.Lock()
+		defer s.m.Unlock()
+		rt.handler(s, w, r, match[1:])
+		return
+	}
+	writeErr(w, http.StatusNotFound, "Resource not found.")
+}
+
+// authorized returns whether the request has the cookie set by the Server's login endpoint.
+func (s *Server) authorized(r *http.Request) bool {
+	cookie, err := r.Cookie(CookieName)
+	return err == nil && cookie.Value == s.cookie
+}
+
+// writeJSON writes the given object as JSON, with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		writeErr(w, http.StatusInternalServerError, "serializing response: "+err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(bts)
+}
+
+// writeErr writes an error alert with the given status code and message.
+func writeErr(w http.ResponseWriter, status int, msg string) {
+	bts, _ := json.Marshal(tc.CreateAlerts(tc.ErrorLevel, msg)) // Alerts can always be serialized
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(bts)
+}
@@ -0,0 +1,240 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+package totest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"testing"
+	"time"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+)
+
+func testModel() *Model {
+	return NewModel().
+		User("admin", "password").
+		CDN(tc.CDN{Name: "cdn1", DomainName: "cdn1.example.net"}).
+		Server(tc.Server{HostName: "edge0", CDNName: "cdn1", Type: "EDGE", Profile: "EDGE0", Status: "REPORTED"}).
+		Server(tc.Server{HostName: "mid0", CDNName: "cdn1", Type: "MID", Profile: "MID0", Status: "ONLINE"}).
+		Parameter("EDGE0", tc.Parameter{Name: "location", ConfigFile: "parent.config", Value: "/opt/trafficserver"}).
+		MonitoringConfig("cdn1", tc.TrafficMonitorConfig{
+			TrafficServers: []tc.TrafficServer{{HostName: "edge0", Profile: "EDGE0", ServerStatus: "REPORTED"}},
+			Profiles: []tc.TMProfile{{Name: "EDGE0", Type: "EDGE", Parameters: tc.TMParameters{
+				HealthConnectionTimeout: 2000,
+				HistoryCount:            30,
+				Thresholds:              map[string]tc.HealthThreshold{"availableBandwidthInKbps": {Val: 1750000, Comparator: ">"}},
+			}}},
+		}).
+		CRConfig("cdn1", tc.CRConfig{Stats: tc.CRConfigStats{CDNName: func(s string) *string { return &s }("cdn1")}})
+}
+
+func testLogin(t *testing.T, to *Server) *client.Session {
+	session, _, err := client.LoginWithAgent(to.URL, "admin", "password", false, "totest", false, time.Second)
+	if err != nil {
+		t.Fatalf("LoginWithAgent expected: nil error, actual: %v", err)
+	}
+	return session
+}
+
+func TestClient(t *testing.T) {
+	to := NewServer(testModel())
+	defer to.Close()
+	session := testLogin(t, to)
+
+	servers, _, err := session.GetServers()
+	if err != nil || len(servers) != 2 || servers[0].ID != 1 || servers[0].CDNID != 1 {
+		t.Errorf("GetServers expected: 2 servers with IDs, actual: %+v %v", servers, err)
+	}
+	servers, _, err = session.GetServersByType(map[string][]string{"type": {"MID"}})
+	if err != nil || len(servers) != 1 || servers[0].HostName != "mid0" {
+		t.Errorf("GetServersByType expected: mid0, actual: %+v %v", servers, err)
+	}
+	if server, _, err := session.GetServer("edge0"); err != nil || server.Profile != "EDGE0" {
+		t.Errorf("GetServer expected: edge0, actual: %+v %v", server, err)
+	}
+	if _, _, err := session.GetServer("nonexistent"); err == nil {
+		t.Errorf("GetServer nonexistent expected: error, actual: nil")
+	}
+
+	if params, _, err := session.GetParameters("EDGE0"); err != nil || len(params) != 1 || params[0].Value != "/opt/trafficserver" {
+		t.Errorf("GetParameters expected: 1 parameter, actual: %+v %v", params, err)
+	}
+
+	mc, _, err := session.GetTrafficMonitorConfigMap("cdn1")
+	if err != nil {
+		t.Fatalf("GetTrafficMonitorConfigMap expected: nil error, actual: %v", err)
+	}
+	if profile := mc.Profile["EDGE0"]; profile.Parameters.MinFreeKbps != 1750000 || profile.Parameters.HistoryCount != 30 || profile.Parameters.Thresholds["availableBandwidthInKbps"].Comparator != ">" {
+		t.Errorf("GetTrafficMonitorConfigMap expected: EDGE0 parameters, actual: %+v", profile)
+	}
+
+	crcBts, _, err := session.GetCRConfig("cdn1")
+	crc := tc.CRConfig{}
+	if err != nil {
+		t.Fatalf("GetCRConfig expected: nil error, actual: %v", err)
+	} else if err := json.Unmarshal(crcBts, &crc); err != nil || crc.Stats.CDNName == nil || *crc.Stats.CDNName != "cdn1" {
+		t.Errorf("GetCRConfig expected: cdn1 CRConfig, actual: %s %v", crcBts, err)
+	}
+
+	if _, err := session.SetUpdate("edge0", client.UpdateStatusPending, client.UpdateStatusClear); err != nil {
+		t.Fatalf("SetUpdate expected: nil error, actual: %v", err)
+	}
+	if update, _, err := session.GetUpdate("edge0"); err != nil || !update.UpdatePending || update.RevalPending || update.HostName != "edge0" {
+		t.Errorf("GetUpdate expected: update pending, actual: %+v %v", update, err)
+	}
+	to.Update(func(m *Model) {
+		if server, _ := m.server("edge0"); !server.UpdPending {
+			t.Errorf("Model expected: edge0 update pending, actual: not pending")
+		}
+	})
+
+	summary := tc.StatsSummary{CDNName: "cdn1", StatName: "daily_maxgbps", StatValue: "5", SummaryTime: "2017-01-02 00:00:00"}
+	if _, err := session.DoAddSummaryStats(summary); err != nil {
+		t.Fatalf("DoAddSummaryStats expected: nil error, actual: %v", err)
+	}
+	if summaries, _, err := session.GetSummaryStats("cdn1", "", "daily_maxgbps"); err != nil || len(summaries) != 1 || summaries[0] != summary {
+		t.Errorf("GetSummaryStats expected: %+v, actual: %+v %v", summary, summaries, err)
+	}
+	if last, _, err := session.GetSummaryStatsLastUpdated(""); err != nil || last != summary.SummaryTime {
+		t.Errorf("GetSummaryStatsLastUpdated expected: %v, actual: %v %v", summary.SummaryTime, last, err)
+	}
+
+	requests := to.Requests()
+	if len(requests) == 0 || requests[0].Method != http.MethodPost || requests[0].Path != "/api/1.2/user/login" || !strings.Contains(string(requests[0].Body), `"admin"`) {
+		t.Errorf("Requests expected: login first, actual: %+v", requests)
+	}
+	if last := requests[len(requests)-1]; last.Path != "/api/1.2/stats_summary.json" || last.Header.Get("User-Agent") != "totest" {
+		t.Errorf("Requests expected: stats summary last, actual: %+v", last)
+	}
+	to.ClearRequests()
+	if requests := to.Requests(); len(requests) != 0 {
+		t.Errorf("ClearRequests expected: no requests, actual: %v", len(requests))
+	}
+}
+
+func TestAuth(t *testing.T) {
+	to := NewServer(testModel())
+	defer to.Close()
+
+	if _, _, err := client.LoginWithAgent(to.URL, "admin", "wrong", false, "totest", false, time.Second); err == nil {
+		t.Errorf("LoginWithAgent wrong password expected: error, actual: nil")
+	}
+	resp, err := http.Get(to.URL + "/api/1.2/servers.json")
+	if err != nil {
+		t.Fatalf("GET servers expected: nil error, actual: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET servers without login expected: %v, actual: %v", http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	open := NewTLSServer(nil)
+	defer open.Close()
+	if _, _, err := client.LoginWithAgent(open.URL, "anyone", "anything", true, "totest", false, time.Second); err != nil {
+		t.Errorf("LoginWithAgent with no users expected: nil error, actual: %v", err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	to := NewServer(testModel())
+	defer to.Close()
+	session := testLogin(t, to)
+	session.Retry = client.RetryConfig{MaxRetries: 1, BaseDelay: time.Millisecond}
+
+	to.InjectFault(Fault{Method: http.MethodGet, PathPrefix: "/api/1.2/servers", Status: http.StatusServiceUnavailable, Times: 1})
+	to.ClearRequests()
+	if _, _, err := session.GetServers(); err != nil {
+		t.Errorf("GetServers after one 503 expected: retry success, actual: %v", err)
+	}
+	if requests := to.Requests(); len(requests) != 2 {
+		t.Errorf("GetServers after one 503 expected: 2 requests, actual: %v", len(requests))
+	}
+
+	to.InjectFault(Fault{PathPrefix: "/api/1.2/cdns/cdn1/configs/monitoring", BadJSON: true})
+	for i := 0; i < 2; i++ {
+		if _, _, err := session.GetTrafficMonitorConfig("cdn1"); err == nil {
+			t.Errorf("GetTrafficMonitorConfig with bad JSON expected: error, actual: nil")
+		}
+	}
+	if _, _, err := session.GetServers(); err != nil {
+		t.Errorf("GetServers with unmatched fault expected: nil error, actual: %v", err)
+	}
+
+	to.ClearFaults()
+	latency := 50 * time.Millisecond
+	to.InjectFault(Fault{Latency: latency})
+	start := time.Now()
+	if _, _, err := session.GetTrafficMonitorConfig("cdn1"); err != nil {
+		t.Errorf("GetTrafficMonitorConfig after ClearFaults expected: nil error, actual: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("Fault latency expected: at least %v, actual: %v", latency, elapsed)
+	}
+}
+
+func TestLoadModel(t *testing.T) {
+	m, err := LoadModel("testdata/model.json")
+	if err != nil {
+		t.Fatalf("LoadModel expected: nil error, actual: %v", err)
+	}
+	to := NewServer(m)
+	defer to.Close()
+	session := testLogin(t, to)
+
+	if servers, _, err := session.GetServers(); err != nil || len(servers) != 2 || servers[1].ID != 2 {
+		t.Errorf("GetServers expected: 2 servers with IDs, actual: %+v %v", servers, err)
+	}
+	if update, _, err := session.GetUpdate("edge0"); err != nil || !update.UpdatePending {
+		t.Errorf("GetUpdate expected: update pending, actual: %+v %v", update, err)
+	}
+	mc, _, err := session.GetTrafficMonitorConfigMap("cdn1")
+	if err != nil {
+		t.Fatalf("GetTrafficMonitorConfigMap expected: nil error, actual: %v", err)
+	}
+	if threshold := mc.Profile["EDGE0"].Parameters.Thresholds["loadavg"]; threshold.Val != 25 || threshold.Comparator != tc.DefaultHealthThresholdComparator {
+		t.Errorf("GetTrafficMonitorConfigMap loadavg threshold expected: <25, actual: %+v", threshold)
+	}
+	if cg := mc.CacheGroup["cg0"]; cg.Coordinates.Longitude != 2 {
+		t.Errorf("GetTrafficMonitorConfigMap expected: cg0 coordinates, actual: %+v", cg)
+	}
+
+	jar, _ := cookiejar.New(nil)
+	httpClient := &http.Client{Jar: jar}
+	if resp, err := httpClient.Post(to.URL+"/api/1.2/user/login", "application/json", strings.NewReader(`{"u": "admin", "p": "password"}`)); err != nil {
+		t.Fatalf("login expected: nil error, actual: %v", err)
+	} else {
+		resp.Body.Close()
+	}
+	resp, err := httpClient.Get(to.URL + "/api/1.2/cdns/cdn1/snapshot")
+	if err != nil {
+		t.Fatalf("GET snapshot expected: nil error, actual: %v", err)
+	}
+	defer resp.Body.Close()
+	snapshot := struct {
+		Response tc.CRConfig `json:"response"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil || snapshot.Response.Stats.DateUnixSeconds == nil || *snapshot.Response.Stats.DateUnixSeconds != 1500000000 {
+		t.Errorf("GET snapshot expected: CRConfig, actual: %+v %v", snapshot, err)
+	}
+}
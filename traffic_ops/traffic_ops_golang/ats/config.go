@@ -22,6 +22,7 @@ package ats
 const configSuffix = ".config"
 
 const HeaderRewritePrefix = "hdr_rw_"
+const MidHeaderRewritePrefix = "hdr_rw_mid_"
 const RegexRemapPrefix = "regex_remap_"
 const CacheUrlPrefix = "cacheurl_"
 
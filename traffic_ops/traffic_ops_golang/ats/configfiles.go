@@ -0,0 +1,238 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// the scopes of config files, which are the API route they're generated by
+const ScopeServers = "servers"
+const ScopeProfiles = "profiles"
+const ScopeCDNs = "cdns"
+
+// ScopeParamName is the parameter which sets the scope of a config file generated from parameters. Files without one are server scoped.
+const ScopeParamName = "scope"
+
+const LocationParamName = "location"
+const URLParamName = "URL"
+
+// configFileScope is the scope of config files matching a regex.
+type configFileScope struct {
+	regex *regexp.Regexp
+	scope string
+}
+
+// configFileScopes are the scopes of config files which aren't set by a parameter, in the order they're checked, like the Perl get_scope.
+var configFileScopes = []configFileScope{
+	{regexp.MustCompile(`^ip_allow\.config$`), ScopeServers},
+	{regexp.MustCompile(`^parent\.config$`), ScopeServers},
+	{regexp.MustCompile(`to_ext_.*\.config`), ScopeServers},
+	{regexp.MustCompile(`^hosting\.config$`), ScopeServers},
+	{regexp.MustCompile(`^packages$`), ScopeServers},
+	{regexp.MustCompile(`^chkconfig$`), ScopeServers},
+	{regexp.MustCompile(`^remap\.config$`), ScopeServers},
+	{regexp.MustCompile(`^12M_facts$`), ScopeProfiles},
+	{regexp.MustCompile(`^50-ats\.rules$`), ScopeProfiles},
+	{regexp.MustCompile(`^astats\.config$`), ScopeProfiles},
+	{regexp.MustCompile(`^cache\.config$`), ""}, // servers for mids, profiles for everything else
+	{regexp.MustCompile(`^drop_qstring\.config$`), ScopeProfiles},
+	{regexp.MustCompile(`^logs_xml\.config$`), ScopeProfiles},
+	{regexp.MustCompile(`^plugin\.config$`), ScopeProfiles},
+	{regexp.MustCompile(`^records\.config$`), ScopeProfiles},
+	{regexp.MustCompile(`^storage\.config$`), ScopeProfiles},
+	{regexp.MustCompile(`^sysctl\.conf$`), ScopeProfiles},
+	{regexp.MustCompile(`url_sig_.*\.config`), ScopeProfiles},
+	{regexp.MustCompile(`uri_signing_.*\.config`), ScopeProfiles},
+	{regexp.MustCompile(`^volume\.config$`), ScopeProfiles},
+	{regexp.MustCompile(`^bg_fetch\.config$`), ScopeCDNs},
+	{regexp.MustCompile(`cacheurl.*\.config`), ScopeCDNs},
+	{regexp.MustCompile(`hdr_rw_.*\.config`), ScopeCDNs},
+	{regexp.MustCompile(`regex_remap_.*\.config`), ScopeCDNs},
+	{regexp.MustCompile(`^regex_revalidate\.config$`), ScopeCDNs},
+	{regexp.MustCompile(`set_dscp_.*\.config`), ScopeCDNs},
+	{regexp.MustCompile(`^ssl_multicert\.config$`), ScopeCDNs},
+}
+
+// staticScope returns the scope of a config file which isn't set by a parameter, and false if the file's scope is set by a parameter. The server type is only used for cache.config, and may be empty for profiles.
+func staticScope(fileName string, serverType string) (string, bool) {
+	for _, s := range configFileScopes {
+		if !s.regex.MatchString(fileName) {
+			continue
+		}
+		if s.scope == "" {
+			if strings.HasPrefix(serverType, MidTypePrefix) {
+				return ScopeServers, true
+			}
+			return ScopeProfiles, true
+		}
+		return s.scope, true
+	}
+	return "", false
+}
+
+// GetScope returns the scope of the given config file, which is the route it must be requested from. The server type is only used for cache.config, and may be empty for profiles.
+func GetScope(db *sql.DB, fileName string, serverType string) (string, error) {
+	if scope, ok := staticScope(fileName, serverType); ok {
+		return scope, nil
+	}
+	scope := ""
+	if err := db.QueryRow(`SELECT value FROM parameter WHERE name = $1 AND config_file = $2 LIMIT 1`, ScopeParamName, fileName).Scan(&scope); err != nil {
+		if err == sql.ErrNoRows {
+			return ScopeServers, nil
+		}
+		return "", errors.New("querying scope: " + err.Error())
+	}
+	return scope, nil
+}
+
+// GetConfigMetaData returns the config files the server needs, from the location and URL parameters of its profile, like the Perl get_config_metadata.
+func GetConfigMetaData(db *sql.DB, server ServerInfo) (tc.ATSConfigMetaData, error) {
+	meta := tc.ATSConfigMetaData{
+		Info: tc.ATSConfigMetaDataInfo{
+			ProfileID:     server.ProfileID,
+			ProfileName:   server.ProfileName,
+			ServerIPv4:    server.IPAddress,
+			ServerName:    server.HostName,
+			ServerID:      server.ID,
+			CDNID:         server.CDNID,
+			CDNName:       server.CDN,
+			ServerTCPPort: server.TCPPort,
+		},
+		ConfigFiles: []tc.ATSConfigMetaDataConfigFile{},
+	}
+	q := `
+SELECT
+COALESCE((SELECT value FROM parameter WHERE name = 'tm.url' AND config_file = 'global' LIMIT 1), ''),
+COALESCE((SELECT value FROM parameter WHERE name = 'tm.rev_proxy.url' AND config_file = 'global' LIMIT 1), '')
+`
+	if err := db.QueryRow(q).Scan(&meta.Info.ToURL, &meta.Info.ToRevProxyURL); err != nil {
+		return tc.ATSConfigMetaData{}, errors.New("querying Traffic Ops URLs: " + err.Error())
+	}
+
+	fileNames, files, err := getConfigFileLocations(db, server.ProfileID)
+	if err != nil {
+		return tc.ATSConfigMetaData{}, errors.New("getting config file locations: " + err.Error())
+	}
+	for _, fileName := range fileNames {
+		file := files[fileName]
+		if file.URL != "" {
+			file.Scope = ScopeCDNs // files with a URL aren't generated by Traffic Ops, so they have no API URI
+		} else if file.Scope, err = GetScope(db, fileName, server.Type); err != nil {
+			return tc.ATSConfigMetaData{}, errors.New("getting scope of " + fileName + ": " + err.Error())
+		} else {
+			file.APIURI = "/api/1.2/" + file.Scope + "/" + scopeID(server, file.Scope) + "/configfiles/ats/" + fileName
+		}
+		meta.ConfigFiles = append(meta.ConfigFiles, file)
+	}
+	return meta, nil
+}
+
+// scopeID returns the name the server's config files of the given scope are requested by.
+func scopeID(server ServerInfo, scope string) string {
+	switch scope {
+	case ScopeCDNs:
+		return server.CDN
+	case ScopeProfiles:
+		return server.ProfileName
+	}
+	return server.HostName
+}
+
+// getConfigFileLocations returns the names of the config files with a location or URL parameter on the given profile, sorted, and the files by name, without their scope or API URI.
+func getConfigFileLocations(db *sql.DB, profileID int) ([]string, map[string]tc.ATSConfigMetaDataConfigFile, error) {
+	q := `
+SELECT pa.config_file, pa.name, pa.value
+FROM parameter pa
+JOIN profile_parameter pp ON pp.parameter = pa.id
+WHERE pp.profile = $1
+AND pa.name IN ($2, $3)
+ORDER BY pa.config_file, pa.id
+`
+	rows, err := db.Query(q, profileID, LocationParamName, URLParamName)
+	if err != nil {
+		return nil, nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	// files are grouped by config file, which is only the fnameOnDisk of files with a location, like the Perl
+	fileNames := []string{}
+	files := map[string]tc.ATSConfigMetaDataConfigFile{}
+	for rows.Next() {
+		configFile := ""
+		name := ""
+		val := ""
+		if err := rows.Scan(&configFile, &name, &val); err != nil {
+			return nil, nil, errors.New("scanning: " + err.Error())
+		}
+		file, ok := files[configFile]
+		if !ok {
+			fileNames = append(fileNames, configFile)
+		}
+		if name == LocationParamName {
+			file.FileNameOnDisk = configFile
+			file.Location = val
+		} else {
+			file.URL = val
+		}
+		files[configFile] = file
+	}
+	return fileNames, files, nil
+}
+
+// ProfileInfo is the data about a profile needed to generate its config files.
+type ProfileInfo struct {
+	ID   int
+	Name string
+}
+
+// GetProfileInfo returns the profile with the given name or, if it's numeric, ID, like the Perl configfiles routes. Returns false if the profile doesn't exist.
+func GetProfileInfo(db *sql.DB, nameOrID string) (ProfileInfo, bool, error) {
+	q := `SELECT p.id, p.name FROM profile p `
+	var row *sql.Row
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		row = db.QueryRow(q+`WHERE p.id = $1`, id)
+	} else {
+		row = db.QueryRow(q+`WHERE p.name = $1`, nameOrID)
+	}
+	p := ProfileInfo{}
+	if err := row.Scan(&p.ID, &p.Name); err != nil {
+		if err == sql.ErrNoRows {
+			return ProfileInfo{}, false, nil
+		}
+		return ProfileInfo{}, false, errors.New("querying profile: " + err.Error())
+	}
+	return p, true, nil
+}
+
+// ConfigFileExists returns whether any parameter is in the given config file, which is how the Perl decides whether a config file generated from parameters exists.
+func ConfigFileExists(db *sql.DB, fileName string) (bool, error) {
+	exists := false
+	if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM parameter WHERE config_file = $1)`, fileName).Scan(&exists); err != nil {
+		return false, errors.New("querying config file parameters: " + err.Error())
+	}
+	return exists, nil
+}
@@ -0,0 +1,97 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestStaticScope(t *testing.T) {
+	tests := []struct {
+		fileName   string
+		serverType string
+		scope      string
+		ok         bool
+	}{
+		{"parent.config", "EDGE", ScopeServers, true},
+		{"cache.config", "MID", ScopeServers, true},
+		{"cache.config", "EDGE", ScopeProfiles, true},
+		{"cache.config", "", ScopeProfiles, true},
+		{"url_sig_ds1.config", "", ScopeProfiles, true},
+		{"hdr_rw_mid_ds1.config", "", ScopeCDNs, true},
+		{"cacheurl_qstring.config", "", ScopeCDNs, true},
+		{"logging.config", "EDGE", "", false},
+	}
+	for _, test := range tests {
+		scope, ok := staticScope(test.fileName, test.serverType)
+		if scope != test.scope || ok != test.ok {
+			t.Errorf("staticScope(%s, %s) expected: %s %v, actual: %s %v", test.fileName, test.serverType, test.scope, test.ok, scope, ok)
+		}
+	}
+}
+
+func TestGetConfigMetaData(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	server := ServerInfo{ID: 21, HostName: "edge1", IPAddress: "192.0.2.5", TCPPort: 80, Type: "EDGE", ProfileID: 5, ProfileName: "EDGE1", CDNID: 1, CDN: "cdn1"}
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"tm_url", "rev_proxy_url"}).AddRow("https://to.example.net/", ""))
+	mock.ExpectQuery("SELECT pa.config_file, pa.name, pa.value").WithArgs(5, LocationParamName, URLParamName).WillReturnRows(
+		sqlmock.NewRows([]string{"config_file", "name", "value"}).
+			AddRow("logging.config", "location", "/opt/trafficserver/etc/trafficserver").
+			AddRow("parent.config", "location", "/opt/trafficserver/etc/trafficserver").
+			AddRow("records.config", "location", "/opt/trafficserver/etc/trafficserver").
+			AddRow("ssl_multicert.config", "location", "/opt/trafficserver/etc/trafficserver").
+			AddRow("url.config", "URL", "https://files.example.net/url.config"))
+	mock.ExpectQuery("SELECT value FROM parameter").WithArgs(ScopeParamName, "logging.config").WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(ScopeProfiles))
+
+	actual, err := GetConfigMetaData(mockDB, server)
+	if err != nil {
+		t.Fatalf("GetConfigMetaData expected: nil error, actual: %v", err)
+	}
+	loc := "/opt/trafficserver/etc/trafficserver"
+	expected := tc.ATSConfigMetaData{
+		Info: tc.ATSConfigMetaDataInfo{ProfileID: 5, ProfileName: "EDGE1", ToURL: "https://to.example.net/", ServerIPv4: "192.0.2.5", ServerName: "edge1", ServerID: 21, CDNID: 1, CDNName: "cdn1", ServerTCPPort: 80},
+		ConfigFiles: []tc.ATSConfigMetaDataConfigFile{
+			{FileNameOnDisk: "logging.config", Location: loc, APIURI: "/api/1.2/profiles/EDGE1/configfiles/ats/logging.config", Scope: ScopeProfiles},
+			{FileNameOnDisk: "parent.config", Location: loc, APIURI: "/api/1.2/servers/edge1/configfiles/ats/parent.config", Scope: ScopeServers},
+			{FileNameOnDisk: "records.config", Location: loc, APIURI: "/api/1.2/profiles/EDGE1/configfiles/ats/records.config", Scope: ScopeProfiles},
+			{FileNameOnDisk: "ssl_multicert.config", Location: loc, APIURI: "/api/1.2/cdns/cdn1/configfiles/ats/ssl_multicert.config", Scope: ScopeCDNs},
+			{URL: "https://files.example.net/url.config", Scope: ScopeCDNs},
+		},
+	}
+	expectedBts, _ := json.Marshal(expected)
+	actualBts, _ := json.Marshal(actual)
+	if string(expectedBts) != string(actualBts) {
+		t.Errorf("GetConfigMetaData expected: %s, actual: %s", expectedBts, actualBts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expections: %s", err)
+	}
+}
@@ -0,0 +1,129 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// dsInfo is a regex of a delivery service on a server, like the Perl DeliveryServiceInfoForServerList and DeliveryServiceInfoForDomainList. Delivery services with more than one regex have a dsInfo per regex.
+type dsInfo struct {
+	xmlID                string
+	dscp                 int
+	routingName          string
+	signingAlgorithm     sql.NullString
+	qstringIgnore        int
+	orgServerFQDN        sql.NullString
+	rangeRequestHandling int
+	pattern              string
+	regexType            string
+	setNumber            int
+	dsType               string
+	domain               string
+	edgeHeaderRewrite    sql.NullString
+	midHeaderRewrite     sql.NullString
+	regexRemap           sql.NullString
+	cacheURL             sql.NullString
+	remapText            sql.NullString
+	protocol             int
+	profileID            sql.NullInt64
+	originShield         sql.NullString
+	multiSiteOrigin      bool
+	topology             sql.NullString
+}
+
+// getDSInfos returns a dsInfo for each regex of the delivery services assigned to the server or, for mids, to any server of the mid's CDN, ordered like the Perl.
+func getDSInfos(db *sql.DB, server ServerInfo) ([]dsInfo, error) {
+	if server.IsMid() {
+		return queryDSInfos(db, `WHERE c.name = $1 AND d.id IN (SELECT deliveryservice FROM deliveryservice_server)`, server.CDN)
+	}
+	return queryDSInfos(db, `JOIN deliveryservice_server dss ON dss.deliveryservice = d.id WHERE dss.server = $1`, server.ID)
+}
+
+// getCDNDSInfos returns a dsInfo for each regex of every delivery service of the CDN, whether or not it's assigned to any server.
+func getCDNDSInfos(db *sql.DB, cdn string) ([]dsInfo, error) {
+	return queryDSInfos(db, `WHERE c.name = $1`, cdn)
+}
+
+// queryDSInfos returns a dsInfo for each regex of the delivery services matching the given where clause, which may start with additional joins.
+func queryDSInfos(db *sql.DB, where string, arg interface{}) ([]dsInfo, error) {
+	q := `
+SELECT d.xml_id, d.dscp, COALESCE(d.routing_name, ''), d.signing_algorithm, COALESCE(d.qstring_ignore, 0), d.org_server_fqdn, COALESCE(d.range_request_handling, 0),
+r.pattern, rt.name, COALESCE(dr.set_number, 0), dt.name, c.domain_name, d.edge_header_rewrite, d.mid_header_rewrite, d.regex_remap, d.cacheurl, d.remap_text, COALESCE(d.protocol, 0), d.profile,
+d.origin_shield, COALESCE(d.multi_site_origin, false), d.topology
+FROM deliveryservice d
+JOIN deliveryservice_regex dr ON dr.deliveryservice = d.id
+JOIN regex r ON r.id = dr.regex
+JOIN type rt ON rt.id = r.type
+JOIN type dt ON dt.id = d.type
+JOIN cdn c ON c.id = d.cdn_id
+` + where + `
+ORDER BY d.id, rt.name, dr.set_number
+`
+	rows, err := db.Query(q, arg)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	dses := []dsInfo{}
+	for rows.Next() {
+		ds := dsInfo{}
+		if err := rows.Scan(&ds.xmlID, &ds.dscp, &ds.routingName, &ds.signingAlgorithm, &ds.qstringIgnore, &ds.orgServerFQDN, &ds.rangeRequestHandling,
+			&ds.pattern, &ds.regexType, &ds.setNumber, &ds.dsType, &ds.domain, &ds.edgeHeaderRewrite, &ds.midHeaderRewrite, &ds.regexRemap, &ds.cacheURL, &ds.remapText, &ds.protocol, &ds.profileID,
+			&ds.originShield, &ds.multiSiteOrigin, &ds.topology); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		dses = append(dses, ds)
+	}
+	return dses, nil
+}
+
+// getProfileParamsByFile returns the parameters in the given config file of every profile, by profile ID. If a profile has more than one parameter with the same name, the last is used.
+func getProfileParamsByFile(db *sql.DB, configFile string) (map[int]map[string]string, error) {
+	q := `
+SELECT pp.profile, pa.name, pa.value
+FROM parameter pa
+JOIN profile_parameter pp ON pp.parameter = pa.id
+WHERE pa.config_file = $1
+ORDER BY pa.id
+`
+	rows, err := db.Query(q, configFile)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	params := map[int]map[string]string{}
+	for rows.Next() {
+		profileID := 0
+		name := ""
+		val := ""
+		if err := rows.Scan(&profileID, &name, &val); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		if params[profileID] == nil {
+			params[profileID] = map[string]string{}
+		}
+		params[profileID][name] = val
+	}
+	return params, nil
+}
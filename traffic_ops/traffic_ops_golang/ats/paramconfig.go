@@ -0,0 +1,143 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HeaderParamName is the parameter which replaces the header of a file generated with lineListFormatter, or removes it if its value is HeaderParamNone.
+const HeaderParamName = "header"
+const HeaderParamNone = "none"
+
+// ProfileHostNamePlaceholder is the FQDN of STRING __HOSTNAME__ parameters in profile scoped files, which is replaced by the cache.
+const ProfileHostNamePlaceholder = "__FULL_HOSTNAME__"
+
+// ReturnPlaceholder is replaced with a newline in files generated with lineListFormatter, for parameters which span multiple lines.
+const ReturnPlaceholder = "__RETURN__"
+
+// ContentHashCommentPrefix starts the line after the header of files generated from parameters, followed by ContentHash of the rest of the file.
+const ContentHashCommentPrefix = "# Content hash: "
+
+// paramFormatter formats the parameters of a config file, by name, into its text.
+type paramFormatter func(params map[string]string) string
+
+// paramFormatters are the formatters of config files which aren't lists of parameter values. Files not in this map are formatted with lineListFormatter.
+var paramFormatters = map[string]paramFormatter{
+	"records.config": configLineFormatter,
+	"plugin.config":  keyValueFormatter(" "),
+	"sysctl.conf":    keyValueFormatter(" = "),
+	"astats.config":  keyValueFormatter("="),
+}
+
+var returnPlaceholderRegex = regexp.MustCompile(`\s*` + ReturnPlaceholder + `\s*`)
+
+// ParamConfig returns the text of a config file generated from the given parameters of a profile, as returned by GetParamData, starting with the given header and a content hash. Files without a formatter are the values of their parameters, one per line, and may replace the header with a header parameter, in which case there's no content hash.
+func ParamConfig(fileName string, params map[string]string, header string) string {
+	formatter, ok := paramFormatters[fileName]
+	if !ok {
+		text := lineListFormatter(params)
+		if customHeader, ok := params[HeaderParamName]; ok {
+			if customHeader == HeaderParamNone {
+				return text
+			}
+			return customHeader + "\n" + text
+		}
+		return header + ContentHashComment(text) + text
+	}
+	text := formatter(params)
+	return header + ContentHashComment(text) + text
+}
+
+// IsParamConfig returns whether the given config file is generated from parameters by ParamConfig, rather than being one of the files generated from delivery services, servers and other data.
+func IsParamConfig(fileName string) bool {
+	if _, ok := paramFormatters[fileName]; ok {
+		return true
+	}
+	_, ok := staticScope(fileName, "")
+	return !ok
+}
+
+// ContentHash returns the hex SHA-256 of the text of a config file, excluding the header, so caches can tell whether a file changed without the generated date.
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentHashComment returns the content hash line of config files generated from parameters.
+func ContentHashComment(text string) string {
+	return ContentHashCommentPrefix + ContentHash(text) + "\n"
+}
+
+// keyValueFormatter returns a formatter of name-value lines with the given separator, sorted by name. Names are unique in params, so duplicates have their __id suffix removed.
+func keyValueFormatter(separator string) paramFormatter {
+	return func(params map[string]string) string {
+		text := ""
+		for _, name := range sortedParamNames(params) {
+			text += trimParamID(name) + separator + params[name] + "\n"
+		}
+		return text
+	}
+}
+
+// configLineFormatter formats records.config lines, e.g. CONFIG proxy.config.http.server_ports STRING 80. Parameter names are the CONFIG and setting name, but the CONFIG is added if it's missing.
+func configLineFormatter(params map[string]string) string {
+	text := ""
+	for _, name := range sortedParamNames(params) {
+		line := trimParamID(name)
+		if !strings.HasPrefix(line, "CONFIG ") {
+			line = "CONFIG " + line
+		}
+		text += line + " " + params[name] + "\n"
+	}
+	return text
+}
+
+// lineListFormatter formats the parameter values, one per line, sorted by name, like the Perl take_and_bake. The header parameter is omitted.
+func lineListFormatter(params map[string]string) string {
+	text := ""
+	for _, name := range sortedParamNames(params) {
+		if name == HeaderParamName {
+			continue
+		}
+		text += params[name] + "\n"
+	}
+	return returnPlaceholderRegex.ReplaceAllString(text, "\n")
+}
+
+func sortedParamNames(params map[string]string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var paramIDSuffixRegex = regexp.MustCompile(`__\d+$`)
+
+// trimParamID removes the __id suffix GetParamData adds to duplicate parameter names.
+func trimParamID(name string) string {
+	return paramIDSuffixRegex.ReplaceAllString(name, "")
+}
@@ -0,0 +1,85 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+)
+
+func TestParamConfigFormatters(t *testing.T) {
+	header := HeaderComment("EDGE1", testNameVersion, testTime)
+	tests := []struct {
+		fileName string
+		params   map[string]string
+		body     string
+	}{
+		{"records.config", map[string]string{"CONFIG proxy.config.proxy_name": "STRING edge1.example.net", "proxy.config.http.server_ports": "STRING 80"},
+			"CONFIG proxy.config.proxy_name STRING edge1.example.net\nCONFIG proxy.config.http.server_ports STRING 80\n"},
+		{"plugin.config", map[string]string{"astats_over_http.so": "", "regex_revalidate.so": "--config regex_revalidate.config"},
+			"astats_over_http.so \nregex_revalidate.so --config regex_revalidate.config\n"},
+		{"sysctl.conf", map[string]string{"net.ipv4.tcp_syncookies": "0", "vm.swappiness": "10"},
+			"net.ipv4.tcp_syncookies = 0\nvm.swappiness = 10\n"},
+		{"astats.config", map[string]string{"path": "_astats", "allow_ip": "127.0.0.1", "allow_ip__12": "10.0.0.1"},
+			"allow_ip=127.0.0.1\nallow_ip=10.0.0.1\npath=_astats\n"},
+		{"logging.config", map[string]string{"a": "log.ascii {", "b": "  Format = 'x' __RETURN__ }"},
+			"log.ascii {\n  Format = 'x'\n}\n"},
+	}
+	for _, test := range tests {
+		expected := header + ContentHashCommentPrefix + ContentHash(test.body) + "\n" + test.body
+		if actual := ParamConfig(test.fileName, test.params, header); actual != expected {
+			t.Errorf("ParamConfig %s expected: '%s', actual: '%s'", test.fileName, expected, actual)
+		}
+	}
+}
+
+func TestParamConfigHeaderParam(t *testing.T) {
+	header := HeaderComment("edge1", testNameVersion, testTime)
+	params := map[string]string{"header": "<?xml version=\"1.0\"?>", "line": "<LogFormat/>"}
+	if actual, expected := ParamConfig("logs.xml", params, header), "<?xml version=\"1.0\"?>\n<LogFormat/>\n"; actual != expected {
+		t.Errorf("ParamConfig header parameter expected: '%s', actual: '%s'", expected, actual)
+	}
+	params["header"] = HeaderParamNone
+	if actual, expected := ParamConfig("logs.xml", params, header), "<LogFormat/>\n"; actual != expected {
+		t.Errorf("ParamConfig header none expected: '%s', actual: '%s'", expected, actual)
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	if actual, expected := ContentHash(""), "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"; actual != expected {
+		t.Errorf("ContentHash expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestIsParamConfig(t *testing.T) {
+	for fileName, expected := range map[string]bool{
+		"records.config":          true,
+		"plugin.config":           true,
+		"logging.config":          true,
+		"to_ext_foo.config":       false,
+		"remap.config":            false,
+		"cache.config":            false,
+		"url_sig_ds1.config":      false,
+		"regex_revalidate.config": false,
+	} {
+		if actual := IsParamConfig(fileName); actual != expected {
+			t.Errorf("IsParamConfig(%s) expected: %v, actual: %v", fileName, expected, actual)
+		}
+	}
+}
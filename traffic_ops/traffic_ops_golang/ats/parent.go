@@ -0,0 +1,591 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const ParentConfigFile = "parent.config"
+
+const OriginTypeName = "ORG"
+const OriginCacheGroupType = "ORG_LOC"
+
+const ParentAlgorithmConsistentHash = "consistent_hash"
+
+// DefaultParentWeight is the weight of a parent whose profile has no parent.config weight parameter.
+const DefaultParentWeight = "0.999"
+
+// these delivery service types are never cached, so edges go directly to the origin
+var goDirectDSTypes = map[string]struct{}{"HTTP_NO_CACHE": {}, "HTTP_LIVE": {}, "DNS_LIVE": {}}
+
+// unavailableServerRetryResponsesRegex matches a valid mso.unavailable_server_retry_responses parameter, a quoted list of HTTP status codes.
+var unavailableServerRetryResponsesRegex = regexp.MustCompile(`^"(?:\d{3},)+\d{3}"\s*$`)
+
+// parentServer is a server in a parent cachegroup of a cache, with the parent.config parameters of its profile.
+type parentServer struct {
+	hostName     string
+	domainName   string
+	ipAddress    string
+	port         int
+	weight       string
+	useIPAddress bool
+	rank         int
+	cacheGroupID int
+	// primary and secondary are whether the server is in the parent or secondary parent cachegroup of the cache.
+	primary   bool
+	secondary bool
+}
+
+type parentServersByRank []parentServer
+
+func (p parentServersByRank) Len() int           { return len(p) }
+func (p parentServersByRank) Less(i, j int) bool { return p[i].rank < p[j].rank }
+func (p parentServersByRank) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// parentData is everything about a server needed to generate its parent.config.
+type parentData struct {
+	dses            []dsInfo
+	atsMajorVersion int
+	// serverParams is the parent.config parameters of the server's profile.
+	serverParams map[string]string
+	// dsParams is the parent.config parameters of each delivery service profile, by profile ID.
+	dsParams map[int]map[string]string
+	// cacheParents is the caches in the server's parent cachegroups.
+	cacheParents []parentServer
+	// originParents is the origin servers in the server's parent cachegroups, by the host of the delivery service origin they're assigned to.
+	originParents map[string][]parentServer
+	// topologyParents is the parents of the server's cachegroup in each topology it's in, by topology name.
+	topologyParents map[string]topologyParents
+	// topologyCaches and topologyOrigins are the servers in the server's parent cachegroups of every topology, like cacheParents and originParents.
+	topologyCaches  []parentServer
+	topologyOrigins map[string][]parentServer
+}
+
+// topologyParents is the parent and secondary parent cachegroups of a cachegroup in a topology, -1 if it has none, and whether the parent is an origin cachegroup.
+type topologyParents struct {
+	parent          int
+	secondaryParent int
+	origin          bool
+}
+
+// ParentDotConfig returns the parent.config of the given server, the same as the Perl Traffic Ops. Edges get a line for each delivery service origin assigned to them, pointing to the caches in their parent and secondary parent cachegroups. Mids get a line for each origin shield and multi-site origin delivery service of their CDN, pointing to the origin servers in the ORG_LOC cachegroups. Delivery services with a topology instead get a line pointing to the servers in the parents of the server's cachegroup in the topology, at any tier.
+func ParentDotConfig(db *sql.DB, server ServerInfo, header string) (string, error) {
+	data, err := getParentData(db, server)
+	if err != nil {
+		return "", err
+	}
+	if server.IsMid() {
+		return header + makeMidParentDotConfig(data), nil
+	}
+	return header + makeEdgeParentDotConfig(data), nil
+}
+
+func makeEdgeParentDotConfig(data parentData) string {
+	primaries := []string{}
+	secondaries := []string{}
+	for _, parent := range data.cacheParents {
+		if parent.primary {
+			primaries = append(primaries, formatParentInfo(parent))
+		} else if parent.secondary {
+			secondaries = append(secondaries, formatParentInfo(parent))
+		}
+	}
+	if len(primaries) == 0 {
+		primaries = secondaries
+		secondaries = []string{}
+	}
+	primaries = uniqueSorted(primaries)
+	secondaries = uniqueSorted(secondaries)
+
+	parents := ""
+	secondaryParents := ""
+	if data.atsMajorVersion >= 6 && len(secondaries) > 0 {
+		parents = `parent="` + strings.Join(primaries, "") + `"`
+		secondaryParents = ` secondary_parent="` + strings.Join(secondaries, "") + `"`
+	} else {
+		parents = `parent="` + strings.Join(primaries, "") + strings.Join(secondaries, "") + `"`
+	}
+
+	serverQStringHandling, hasServerQStringHandling := data.serverParams["psel.qstring_handling"]
+
+	lines := []string{}
+	done := map[string]struct{}{}
+	for _, ds := range data.dses {
+		org := ds.orgServerFQDN.String
+		if org == "" {
+			continue
+		}
+		if _, ok := done[org]; ok {
+			continue
+		}
+		done[org] = struct{}{}
+
+		host, port := originHostPort(org)
+		if _, ok := goDirectDSTypes[ds.dsType]; ok {
+			lines = append(lines, "dest_domain="+host+" port="+port+" go_direct=true\n")
+			continue
+		}
+		if ds.topology.Valid {
+			if line := makeTopologyParentLine(data, ds, host, port); line != "" {
+				lines = append(lines, line)
+			}
+			continue
+		}
+
+		// a server profile psel.qstring_handling overrides the delivery service profile's
+		qStringHandling, hasQStringHandling := serverQStringHandling, hasServerQStringHandling
+		if !hasQStringHandling {
+			qStringHandling, hasQStringHandling = data.dsParams[int(ds.profileID.Int64)]["psel.qstring_handling"]
+		}
+		qString := "ignore"
+		if hasQStringHandling {
+			qString = qStringHandling
+		} else if ds.qstringIgnore == QStringIgnoreUseInCacheKey {
+			qString = "consider"
+		}
+		lines = append(lines, "dest_domain="+host+" port="+port+" "+parents+" "+secondaryParents+" round_robin=consistent_hash go_direct=false qstring="+qString+"\n")
+	}
+	sort.Strings(lines)
+
+	defaultLine := "dest_domain=. " + parents + " round_robin=urlhash go_direct=false"
+	if data.serverParams["algorithm"] == ParentAlgorithmConsistentHash {
+		defaultLine = "dest_domain=. " + parents + secondaryParents + " round_robin=consistent_hash go_direct=false"
+	}
+	if qString, ok := data.serverParams["qstring"]; ok {
+		defaultLine += " qstring=" + qString
+	}
+	return strings.Join(lines, "") + defaultLine + "\n"
+}
+
+// makeMidParentDotConfig returns the lines of a mid. Unlike the Perl, which generates origin shield lines and then drops them, origin shield delivery services get a line pointing to their shield.
+func makeMidParentDotConfig(data parentData) string {
+	lines := []string{}
+	done := map[string]struct{}{}
+	for _, ds := range data.dses {
+		org := ds.orgServerFQDN.String
+		if _, ok := done[org]; ok {
+			continue // don't duplicate origin lines for delivery services with multiple regexes
+		}
+		done[org] = struct{}{}
+
+		params := data.dsParams[int(ds.profileID.Int64)]
+		algorithm := paramOr(params, "mso.algorithm", ParentAlgorithmConsistentHash)
+		host, port := originHostPort(org)
+
+		if ds.topology.Valid {
+			if line := makeTopologyParentLine(data, ds, host, port); line != "" {
+				lines = append(lines, line)
+			}
+			continue
+		}
+
+		if ds.originShield.Valid {
+			text := "dest_domain=" + host + " port=" + port + " parent=" + ds.originShield.String
+			if serverAlgorithm, ok := data.serverParams["algorithm"]; ok {
+				text += " round_robin=" + serverAlgorithm
+			}
+			lines = append(lines, text+" go_direct=true\n")
+			continue
+		}
+		if !ds.multiSiteOrigin {
+			continue
+		}
+
+		qString := "ignore"
+		if _, ok := params["psel.qstring_handling"]; !ok && algorithm == ParentAlgorithmConsistentHash && ds.qstringIgnore == QStringIgnoreUseInCacheKey {
+			qString = "consider"
+		}
+
+		ranked := append([]parentServer(nil), data.originParents[host]...)
+		sort.Stable(parentServersByRank(ranked))
+		primaries := []string{}
+		secondaries := []string{}
+		others := []string{}
+		for _, parent := range ranked {
+			switch {
+			case parent.primary:
+				primaries = append(primaries, formatParentInfo(parent))
+			case parent.secondary:
+				secondaries = append(secondaries, formatParentInfo(parent))
+			default:
+				others = append(others, formatParentInfo(parent))
+			}
+		}
+		// if there are no primary parents, the secondaries become primaries, and if there are no secondaries either, the parents in neither become primaries.
+		if len(primaries) == 0 {
+			if len(secondaries) == 0 {
+				secondaries = others
+				others = []string{}
+			}
+			primaries = secondaries
+			secondaries = []string{}
+		}
+		primaries = unique(primaries)
+		secondaries = unique(secondaries)
+		others = unique(others)
+
+		text := "dest_domain=" + host + " port=" + port + " "
+		if data.atsMajorVersion >= 6 && algorithm == ParentAlgorithmConsistentHash && (len(secondaries) > 0 || len(others) > 0) {
+			text += `parent="` + strings.Join(primaries, "") + `" secondary_parent="` + strings.Join(secondaries, "") + strings.Join(others, "") + `"`
+		} else {
+			text += `parent="` + strings.Join(primaries, "") + strings.Join(secondaries, "") + strings.Join(others, "") + `"`
+		}
+		text += " round_robin=" + algorithm + " qstring=" + qString + " go_direct=false parent_is_proxy=false"
+
+		parentRetry := paramOr(params, "mso.parent_retry", "both")
+		if data.atsMajorVersion >= 6 && parentRetry != "" {
+			retryResponses := paramOr(params, "mso.unavailable_server_retry_responses", "")
+			if unavailableServerRetryResponsesRegex.MatchString(retryResponses) {
+				text += " parent_retry=" + parentRetry + " unavailable_server_retry_responses=" + retryResponses
+			} else {
+				text += " parent_retry=" + parentRetry
+			}
+			text += " max_simple_retries=" + paramOr(params, "mso.max_simple_retries", "1") + " max_unavailable_server_retries=" + paramOr(params, "mso.max_unavailable_server_retries", "1")
+		}
+		lines = append(lines, text+"\n")
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
+
+// makeTopologyParentLine returns the line of a delivery service with a topology, pointing to the servers in the parent and secondary parent of the server's cachegroup in the topology, or going directly to the origin if none of them are available. Returns the empty string if the server's cachegroup isn't in the topology, or has no parents.
+func makeTopologyParentLine(data parentData, ds dsInfo, host string, port string) string {
+	parents, ok := data.topologyParents[ds.topology.String]
+	if !ok || parents.parent == -1 {
+		return ""
+	}
+
+	servers := append(append([]parentServer(nil), data.topologyCaches...), data.topologyOrigins[host]...)
+	sort.Stable(parentServersByRank(servers))
+	primaries := []string{}
+	secondaries := []string{}
+	for _, server := range servers {
+		switch server.cacheGroupID {
+		case parents.parent:
+			primaries = append(primaries, formatParentInfo(server))
+		case parents.secondaryParent:
+			secondaries = append(secondaries, formatParentInfo(server))
+		}
+	}
+	if len(primaries) == 0 {
+		primaries = secondaries
+		secondaries = []string{}
+	}
+	primaries = unique(primaries)
+	secondaries = unique(secondaries)
+
+	text := "dest_domain=" + host + " port=" + port
+	if len(primaries) == 0 {
+		return text + " go_direct=true\n"
+	}
+	if data.atsMajorVersion >= 6 && len(secondaries) > 0 {
+		text += ` parent="` + strings.Join(primaries, "") + `" secondary_parent="` + strings.Join(secondaries, "") + `"`
+	} else {
+		text += ` parent="` + strings.Join(primaries, "") + strings.Join(secondaries, "") + `"`
+	}
+
+	qString := "ignore"
+	if qStringHandling, ok := data.dsParams[int(ds.profileID.Int64)]["psel.qstring_handling"]; ok {
+		qString = qStringHandling
+	} else if ds.qstringIgnore == QStringIgnoreUseInCacheKey {
+		qString = "consider"
+	}
+	text += " round_robin=" + ParentAlgorithmConsistentHash + " go_direct=false qstring=" + qString
+	if parents.origin {
+		text += " parent_is_proxy=false"
+	}
+	return text + "\n"
+}
+
+// formatParentInfo returns the parent.config parent list entry of a parent, host:port|weight;.
+func formatParentInfo(parent parentServer) string {
+	host := parent.hostName + "." + parent.domainName
+	if parent.useIPAddress {
+		host = parent.ipAddress
+	}
+	return host + ":" + strconv.Itoa(parent.port) + "|" + parent.weight + ";"
+}
+
+// originHostPort returns the host and port of a delivery service origin URL, with the default port of its scheme if it has none.
+func originHostPort(org string) (string, string) {
+	if !strings.Contains(org, "://") {
+		org = "http://" + org
+	}
+	u, err := url.Parse(org)
+	if err != nil {
+		return org, "80"
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return u.Hostname(), port
+}
+
+// paramOr returns the named parameter, or the default if it doesn't exist or is empty or "0", like the Perl ||.
+func paramOr(params map[string]string, name string, def string) string {
+	if val := params[name]; val != "" && val != "0" {
+		return val
+	}
+	return def
+}
+
+// unique returns the strings with duplicates removed, keeping the first of each.
+func unique(strs []string) []string {
+	seen := map[string]struct{}{}
+	uniq := []string{}
+	for _, s := range strs {
+		if _, ok := seen[s]; ok {
+			continue
+		}
+		seen[s] = struct{}{}
+		uniq = append(uniq, s)
+	}
+	return uniq
+}
+
+func uniqueSorted(strs []string) []string {
+	strs = unique(strs)
+	sort.Strings(strs)
+	return strs
+}
+
+func getParentData(db *sql.DB, server ServerInfo) (parentData, error) {
+	data := parentData{}
+	err := error(nil)
+	if data.dses, err = getDSInfos(db, server); err != nil {
+		return parentData{}, errors.New("getting delivery services: " + err.Error())
+	}
+
+	fqdn := server.HostName + "." + server.DomainName
+	packageParams, err := GetParamData(db, server.ProfileID, PackageConfigFile, fqdn)
+	if err != nil {
+		return parentData{}, errors.New("getting package parameters: " + err.Error())
+	}
+	data.atsMajorVersion = atsMajorVersion(packageParams["trafficserver"])
+
+	if data.serverParams, err = GetParamData(db, server.ProfileID, ParentConfigFile, fqdn); err != nil {
+		return parentData{}, errors.New("getting server parameters: " + err.Error())
+	}
+
+	profileParams, err := getProfileParamsByFile(db, ParentConfigFile)
+	if err != nil {
+		return parentData{}, errors.New("getting profile parameters: " + err.Error())
+	}
+	data.dsParams = profileParams
+
+	if data.cacheParents, data.originParents, err = getParentServers(db, server, profileParams); err != nil {
+		return parentData{}, errors.New("getting parents: " + err.Error())
+	}
+
+	if data.topologyParents, err = getTopologyParents(db, server.CacheGroupID); err != nil {
+		return parentData{}, errors.New("getting topology parents: " + err.Error())
+	}
+	topologyCacheGroupIDs := []int{}
+	for _, parents := range data.topologyParents {
+		topologyCacheGroupIDs = append(topologyCacheGroupIDs, parents.parent, parents.secondaryParent)
+	}
+	if len(topologyCacheGroupIDs) == 0 {
+		return data, nil
+	}
+	if data.topologyCaches, data.topologyOrigins, err = queryParentServers(db, server, `AND s.cachegroup = ANY($2)`, []interface{}{pq.Array(topologyCacheGroupIDs)}, profileParams); err != nil {
+		return parentData{}, errors.New("getting topology parent servers: " + err.Error())
+	}
+	return data, nil
+}
+
+// getTopologyParents returns the parent and secondary parent of the given cachegroup in every topology it's in, by topology name.
+func getTopologyParents(db *sql.DB, cacheGroupID int) (map[string]topologyParents, error) {
+	q := `
+SELECT tc.topology, COALESCE(p.cachegroup, -1), COALESCE(pcgt.name, ''), COALESCE(tcp.rank, 0)
+FROM topology_cachegroup tc
+LEFT JOIN topology_cachegroup_parents tcp ON tcp.child = tc.id
+LEFT JOIN topology_cachegroup p ON p.id = tcp.parent
+LEFT JOIN cachegroup pcg ON pcg.id = p.cachegroup
+LEFT JOIN type pcgt ON pcgt.id = pcg.type
+WHERE tc.cachegroup = $1
+ORDER BY tc.topology, tcp.rank
+`
+	rows, err := db.Query(q, cacheGroupID)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	topologies := map[string]topologyParents{}
+	for rows.Next() {
+		topology := ""
+		parentID := 0
+		parentType := ""
+		rank := 0
+		if err := rows.Scan(&topology, &parentID, &parentType, &rank); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		parents, ok := topologies[topology]
+		if !ok {
+			parents = topologyParents{parent: -1, secondaryParent: -1}
+		}
+		switch rank {
+		case 1:
+			parents.parent = parentID
+			parents.origin = parentType == OriginCacheGroupType
+		case 2:
+			parents.secondaryParent = parentID
+		}
+		topologies[topology] = parents
+	}
+	return topologies, nil
+}
+
+// getParentServers returns the ONLINE and REPORTED caches and origins in the server's parent cachegroups in its CDN domain, or for mids, in every ORG_LOC cachegroup. Origins are keyed by the org_server_fqdn without the scheme of each delivery service they're assigned to.
+func getParentServers(db *sql.DB, server ServerInfo, profileParams map[int]map[string]string) ([]parentServer, map[string][]parentServer, error) {
+	cacheGroupClause := `AND s.cachegroup IN (SELECT cg.id FROM cachegroup cg JOIN type cgt ON cgt.id = cg.type WHERE cgt.name = '` + OriginCacheGroupType + `')`
+	args := []interface{}{}
+	if !server.IsMid() {
+		cacheGroupClause = `AND s.cachegroup IN ($2, $3)`
+		args = append(args, server.ParentCacheGroupID, server.SecondaryParentCacheGroupID)
+	}
+	cacheParents, originParents, err := queryParentServers(db, server, cacheGroupClause, args, profileParams)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range cacheParents {
+		setParentRole(&cacheParents[i], server)
+	}
+	for _, origins := range originParents {
+		for i := range origins {
+			setParentRole(&origins[i], server)
+		}
+	}
+	return cacheParents, originParents, nil
+}
+
+// setParentRole sets whether the parent is in the server's parent or secondary parent cachegroup.
+func setParentRole(parent *parentServer, server ServerInfo) {
+	parent.primary = parent.cacheGroupID == server.ParentCacheGroupID
+	parent.secondary = parent.cacheGroupID == server.SecondaryParentCacheGroupID
+}
+
+// queryParentServers returns the ONLINE and REPORTED caches and origins in the server's CDN domain matching the given cachegroup clause, whose args start at $2. Origins are keyed by the org_server_fqdn without the scheme of each delivery service they're assigned to.
+func queryParentServers(db *sql.DB, server ServerInfo, cacheGroupClause string, cacheGroupArgs []interface{}, profileParams map[int]map[string]string) ([]parentServer, map[string][]parentServer, error) {
+	q := `
+SELECT s.id, s.host_name, s.domain_name, COALESCE(s.ip_address, ''), COALESCE(s.tcp_port, 0), t.name, s.profile, s.cachegroup
+FROM server s
+JOIN type t ON t.id = s.type
+JOIN status st ON st.id = s.status
+JOIN cdn c ON c.id = s.cdn_id
+WHERE st.name IN ('ONLINE', 'REPORTED')
+AND (t.name = '` + OriginTypeName + `' OR t.name LIKE '` + EdgeTypePrefix + `%' OR t.name LIKE '` + MidTypePrefix + `%')
+AND c.domain_name = $1
+`
+	args := append([]interface{}{server.CDNDomain}, cacheGroupArgs...)
+	q += cacheGroupClause + `
+ORDER BY s.id
+`
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, nil, errors.New("querying servers: " + err.Error())
+	}
+	defer rows.Close()
+
+	cacheParents := []parentServer{}
+	originIDs := []int{}
+	originsByID := map[int]parentServer{}
+	for rows.Next() {
+		id := 0
+		serverType := ""
+		profileID := 0
+		p := parentServer{}
+		if err := rows.Scan(&id, &p.hostName, &p.domainName, &p.ipAddress, &p.port, &serverType, &profileID, &p.cacheGroupID); err != nil {
+			return nil, nil, errors.New("scanning servers: " + err.Error())
+		}
+		params := profileParams[profileID]
+		if port, err := strconv.Atoi(params["port"]); err == nil {
+			p.port = port
+		}
+		p.weight = DefaultParentWeight
+		if weight, ok := params["weight"]; ok {
+			p.weight = weight
+		}
+		p.useIPAddress = params["use_ip_address"] == "1"
+		p.rank = 1
+		if rank, err := strconv.Atoi(params["rank"]); err == nil && rank != 0 {
+			p.rank = rank
+		}
+
+		if serverType == OriginTypeName {
+			originIDs = append(originIDs, id)
+			originsByID[id] = p
+		} else {
+			cacheParents = append(cacheParents, p)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, errors.New("iterating servers: " + err.Error())
+	}
+
+	originParents, err := getOriginParents(db, originIDs, originsByID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cacheParents, originParents, nil
+}
+
+// getOriginParents returns the given origin servers by the org_server_fqdn, without the scheme, of each delivery service they're assigned to.
+func getOriginParents(db *sql.DB, ids []int, origins map[int]parentServer) (map[string][]parentServer, error) {
+	originParents := map[string][]parentServer{}
+	if len(ids) == 0 {
+		return originParents, nil
+	}
+	q := `
+SELECT dss.server, COALESCE(d.org_server_fqdn, '')
+FROM deliveryservice_server dss
+JOIN deliveryservice d ON d.id = dss.deliveryservice
+WHERE dss.server = ANY($1)
+ORDER BY dss.server, d.id
+`
+	rows, err := db.Query(q, pq.Array(ids))
+	if err != nil {
+		return nil, errors.New("querying origin delivery services: " + err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		id := 0
+		org := ""
+		if err := rows.Scan(&id, &org); err != nil {
+			return nil, errors.New("scanning origin delivery services: " + err.Error())
+		}
+		org = strings.TrimPrefix(strings.TrimPrefix(org, "http://"), "https://")
+		originParents[org] = append(originParents[org], origins[id])
+	}
+	return originParents, nil
+}
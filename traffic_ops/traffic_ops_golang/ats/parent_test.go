@@ -0,0 +1,151 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func testEdgeParentData(atsMajorVersion int) parentData {
+	return parentData{
+		atsMajorVersion: atsMajorVersion,
+		serverParams:    map[string]string{"algorithm": ParentAlgorithmConsistentHash, "qstring": "ignore"},
+		dsParams:        map[int]map[string]string{5: {"psel.qstring_handling": "consider"}},
+		cacheParents: []parentServer{
+			{hostName: "mid2", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, primary: true},
+			{hostName: "mid1", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, primary: true},
+			{hostName: "mid1", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, primary: true},
+			{hostName: "mid3", domainName: "example.net", ipAddress: "192.0.2.3", port: 8080, weight: "0.5", useIPAddress: true, rank: 1, secondary: true},
+			{hostName: "edge9", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1},
+		},
+		dses: []dsInfo{
+			{xmlID: "ds1", orgServerFQDN: nullStr("http://origin1.example.net"), dsType: "HTTP"},
+			{xmlID: "ds1", orgServerFQDN: nullStr("http://origin1.example.net"), dsType: "HTTP"},
+			{xmlID: "ds2", orgServerFQDN: nullStr("https://origin2.example.net:8443"), dsType: "HTTP_LIVE", qstringIgnore: QStringIgnoreIgnoreInCacheKey},
+			{xmlID: "ds3", orgServerFQDN: nullStr("https://origin3.example.net"), dsType: "DNS", qstringIgnore: QStringIgnoreIgnoreInCacheKey},
+			{xmlID: "ds4", orgServerFQDN: nullStr("http://origin4.example.net"), dsType: "HTTP", qstringIgnore: QStringIgnoreIgnoreInCacheKey, profileID: sql.NullInt64{Int64: 5, Valid: true}},
+			{xmlID: "ds5", dsType: "HTTP"},
+		},
+	}
+}
+
+func TestEdgeParentDotConfig(t *testing.T) {
+	actual := HeaderComment("edge1", testNameVersion, testTime) + makeEdgeParentDotConfig(testEdgeParentData(7))
+	compareGolden(t, "parent_edge.config", actual)
+}
+
+func TestEdgeParentDotConfigOldATS(t *testing.T) {
+	data := testEdgeParentData(5)
+	data.serverParams = map[string]string{"psel.qstring_handling": "ignore"}
+	data.dses = data.dses[:1]
+
+	actual := makeEdgeParentDotConfig(data)
+	expected := `dest_domain=origin1.example.net port=80 parent="mid1.example.net:80|0.999;mid2.example.net:80|0.999;192.0.2.3:8080|0.5;"  round_robin=consistent_hash go_direct=false qstring=ignore` + "\n" +
+		`dest_domain=. parent="mid1.example.net:80|0.999;mid2.example.net:80|0.999;192.0.2.3:8080|0.5;" round_robin=urlhash go_direct=false` + "\n"
+	if actual != expected {
+		t.Errorf("makeEdgeParentDotConfig ATS 5 expected: '%s', actual: '%s'", expected, actual)
+	}
+}
+
+func TestMidParentDotConfig(t *testing.T) {
+	data := parentData{
+		atsMajorVersion: 7,
+		serverParams:    map[string]string{"algorithm": ParentAlgorithmConsistentHash},
+		dsParams: map[int]map[string]string{
+			5: {"mso.algorithm": "true", "mso.parent_retry": "simple_retry", "mso.max_simple_retries": "2"},
+			6: {"mso.unavailable_server_retry_responses": `"502,503"`, "mso.max_unavailable_server_retries": "0"},
+		},
+		originParents: map[string][]parentServer{
+			"origin1.example.net": {
+				{hostName: "org3", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 2, primary: true},
+				{hostName: "org1", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, primary: true},
+				{hostName: "org2", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, secondary: true},
+				{hostName: "org4", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1},
+			},
+			"origin2.example.net": {
+				{hostName: "org5", domainName: "example.net", port: 443, weight: "0.5", rank: 1},
+				{hostName: "org6", domainName: "example.net", port: 443, weight: "0.5", rank: 1},
+			},
+		},
+		dses: []dsInfo{
+			{xmlID: "ds1", orgServerFQDN: nullStr("http://origin1.example.net"), dsType: "HTTP", multiSiteOrigin: true},
+			{xmlID: "ds1", orgServerFQDN: nullStr("http://origin1.example.net"), dsType: "HTTP", multiSiteOrigin: true},
+			{xmlID: "ds2", orgServerFQDN: nullStr("https://origin2.example.net"), dsType: "HTTP", multiSiteOrigin: true, qstringIgnore: QStringIgnoreIgnoreInCacheKey,
+				profileID: sql.NullInt64{Int64: 5, Valid: true}},
+			{xmlID: "ds3", orgServerFQDN: nullStr("http://origin3.example.net:8080"), dsType: "HTTP", originShield: nullStr("shield1.example.net:80|0.999;")},
+			{xmlID: "ds4", orgServerFQDN: nullStr("http://origin4.example.net"), dsType: "HTTP"},
+			{xmlID: "ds5", orgServerFQDN: nullStr("http://origin5.example.net"), dsType: "HTTP", multiSiteOrigin: true, profileID: sql.NullInt64{Int64: 6, Valid: true}},
+		},
+	}
+	actual := HeaderComment("mid1", testNameVersion, testTime) + makeMidParentDotConfig(data)
+	compareGolden(t, "parent_mid.config", actual)
+}
+
+func TestOriginHostPort(t *testing.T) {
+	for org, expected := range map[string][2]string{
+		"http://origin.example.net":       {"origin.example.net", "80"},
+		"https://origin.example.net":      {"origin.example.net", "443"},
+		"http://origin.example.net:8080/": {"origin.example.net", "8080"},
+		"origin.example.net":              {"origin.example.net", "80"},
+	} {
+		host, port := originHostPort(org)
+		if host != expected[0] || port != expected[1] {
+			t.Errorf("originHostPort(%s) expected: %v, actual: %v %v", org, expected, host, port)
+		}
+	}
+}
+
+func TestTopologyParentDotConfig(t *testing.T) {
+	data := parentData{
+		atsMajorVersion: 7,
+		topologyParents: map[string]topologyParents{
+			"tiered":  {parent: 2, secondaryParent: 3},
+			"direct":  {parent: 4, secondaryParent: -1, origin: true},
+			"offline": {parent: 5, secondaryParent: -1},
+		},
+		topologyCaches: []parentServer{
+			{hostName: "mid2", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, cacheGroupID: 2},
+			{hostName: "mid1", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, cacheGroupID: 2},
+			{hostName: "mid3", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, cacheGroupID: 3},
+		},
+		topologyOrigins: map[string][]parentServer{
+			"origin2.example.net": {{hostName: "org1", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, cacheGroupID: 4}},
+		},
+		dses: []dsInfo{
+			{xmlID: "ds1", orgServerFQDN: nullStr("http://origin1.example.net"), dsType: "HTTP", topology: nullStr("tiered"), qstringIgnore: QStringIgnoreUseInCacheKey},
+			{xmlID: "ds2", orgServerFQDN: nullStr("http://origin2.example.net"), dsType: "HTTP", topology: nullStr("direct"), qstringIgnore: QStringIgnoreIgnoreInCacheKey},
+			{xmlID: "ds3", orgServerFQDN: nullStr("http://origin3.example.net"), dsType: "HTTP", topology: nullStr("offline")},
+			{xmlID: "ds4", orgServerFQDN: nullStr("http://origin4.example.net"), dsType: "HTTP", topology: nullStr("elsewhere")},
+		},
+	}
+	expected := `dest_domain=origin1.example.net port=80 parent="mid2.example.net:80|0.999;mid1.example.net:80|0.999;" secondary_parent="mid3.example.net:80|0.999;" round_robin=consistent_hash go_direct=false qstring=consider` + "\n" +
+		`dest_domain=origin2.example.net port=80 parent="org1.example.net:80|0.999;" round_robin=consistent_hash go_direct=false qstring=ignore parent_is_proxy=false` + "\n" +
+		`dest_domain=origin3.example.net port=80 go_direct=true` + "\n"
+	if actual := makeMidParentDotConfig(data); actual != expected {
+		t.Errorf("makeMidParentDotConfig topologies expected: '%s', actual: '%s'", expected, actual)
+	}
+
+	data.cacheParents = []parentServer{{hostName: "mid9", domainName: "example.net", port: 80, weight: DefaultParentWeight, rank: 1, primary: true}}
+	expected += `dest_domain=. parent="mid9.example.net:80|0.999;" round_robin=urlhash go_direct=false` + "\n"
+	if actual := makeEdgeParentDotConfig(data); actual != expected {
+		t.Errorf("makeEdgeParentDotConfig topologies expected: '%s', actual: '%s'", expected, actual)
+	}
+}
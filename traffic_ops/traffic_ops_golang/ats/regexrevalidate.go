@@ -0,0 +1,166 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const RegexRevalidateConfigFile = "regex_revalidate.config"
+
+// MaxRevalDurationDaysParamName is the regex_revalidate.config parameter which limits how long an invalidation job may last, in days.
+const MaxRevalDurationDaysParamName = "maxRevalDurationDays"
+
+// JobKeywordPurge is the keyword of content invalidation jobs, the only kind of job Traffic Ops creates.
+const JobKeywordPurge = "PURGE"
+
+// MinJobTTLHours is the shortest an invalidation job may last.
+const MinJobTTLHours = 1
+
+var jobTTLRegex = regexp.MustCompile(`TTL:(\d+)h`)
+
+// JobTTLParameters returns the job parameters of an invalidation job lasting the given number of hours.
+func JobTTLParameters(ttlHours int) string {
+	return "TTL:" + strconv.Itoa(ttlHours) + "h"
+}
+
+// JobTTLHours returns the number of hours an invalidation job lasts from its parameters, and false if they have no TTL.
+func JobTTLHours(parameters string) (int, bool) {
+	match := jobTTLRegex.FindStringSubmatch(parameters)
+	if match == nil {
+		return 0, false
+	}
+	ttl, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// revalJob is an invalidation job, as needed for regex_revalidate.config.
+type revalJob struct {
+	assetURL   string
+	keyword    string
+	parameters string
+	startTime  time.Time
+}
+
+// RegexRevalidateDotConfig returns the regex_revalidate.config of the given CDN, the same as the Perl Traffic Ops. It has a line for each invalidation job of the CDN which hasn't expired, with the time it expires.
+func RegexRevalidateDotConfig(db *sql.DB, cdn string, header string) (string, error) {
+	maxDays, err := GetMaxRevalDurationDays(db)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	jobs, err := getRevalJobs(db, cdn, now.Add(-time.Duration(maxDays)*24*time.Hour))
+	if err != nil {
+		return "", errors.New("getting jobs: " + err.Error())
+	}
+	return header + makeRegexRevalidateDotConfig(jobs, maxDays, now), nil
+}
+
+// makeRegexRevalidateDotConfig returns the lines of a regex_revalidate.config, each a job's asset URL regex and the Unix time the job expires, sorted by regex. Jobs whose TTL is out of range are clamped to it, jobs which have expired by now are pruned, and if several jobs have the same regex, the one which lasts longest is used.
+func makeRegexRevalidateDotConfig(jobs []revalJob, maxDays int, now time.Time) string {
+	maxHours := maxDays * 24
+	ends := map[string]int64{}
+	for _, job := range jobs {
+		if job.keyword != JobKeywordPurge {
+			continue
+		}
+		ttl, ok := JobTTLHours(job.parameters)
+		if !ok {
+			continue
+		}
+		if ttl < MinJobTTLHours {
+			ttl = MinJobTTLHours
+		} else if ttl > maxHours {
+			ttl = maxHours
+		}
+		end := job.startTime.Add(time.Duration(ttl) * time.Hour)
+		if end.Before(now) {
+			continue
+		}
+		if prevEnd, ok := ends[job.assetURL]; !ok || end.Unix() > prevEnd {
+			ends[job.assetURL] = end.Unix()
+		}
+	}
+
+	regexes := make([]string, 0, len(ends))
+	for regex := range ends {
+		regexes = append(regexes, regex)
+	}
+	sort.Strings(regexes)
+
+	text := ""
+	for _, regex := range regexes {
+		text += regex + " " + strconv.FormatInt(ends[regex], 10) + "\n"
+	}
+	return text
+}
+
+// GetMaxRevalDurationDays returns the maxRevalDurationDays regex_revalidate.config parameter, the longest an invalidation job may last.
+func GetMaxRevalDurationDays(db *sql.DB) (int, error) {
+	q := `SELECT value FROM parameter WHERE name = $1 AND config_file = $2 ORDER BY id LIMIT 1`
+	val := ""
+	if err := db.QueryRow(q, MaxRevalDurationDaysParamName, RegexRevalidateConfigFile).Scan(&val); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, errors.New("no " + MaxRevalDurationDaysParamName + " " + RegexRevalidateConfigFile + " parameter")
+		}
+		return 0, errors.New("querying " + MaxRevalDurationDaysParamName + ": " + err.Error())
+	}
+	maxDays, err := strconv.Atoi(strings.TrimSpace(val))
+	if err != nil {
+		return 0, errors.New(MaxRevalDurationDaysParamName + " parameter '" + val + "' is not an integer")
+	}
+	return maxDays, nil
+}
+
+// getRevalJobs returns the jobs of the delivery services of the given CDN which started after the given time.
+func getRevalJobs(db *sql.DB, cdn string, since time.Time) ([]revalJob, error) {
+	q := `
+SELECT j.asset_url, j.keyword, COALESCE(j.parameters, ''), j.start_time
+FROM job j
+JOIN deliveryservice ds ON ds.id = j.job_deliveryservice
+JOIN cdn c ON c.id = ds.cdn_id
+WHERE c.name = $1
+AND j.start_time > $2
+`
+	rows, err := db.Query(q, cdn, since)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	jobs := []revalJob{}
+	for rows.Next() {
+		job := revalJob{}
+		if err := rows.Scan(&job.assetURL, &job.keyword, &job.parameters, &job.startTime); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,65 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMakeRegexRevalidateDotConfig(t *testing.T) {
+	jobs := []revalJob{
+		{assetURL: "http://origin1.example.net/a/.*", keyword: JobKeywordPurge, parameters: "TTL:24h", startTime: testTime.Add(-time.Hour)},
+		// a later job with the same regex which lasts longer wins
+		{assetURL: "http://origin1.example.net/a/.*", keyword: JobKeywordPurge, parameters: "TTL:48h", startTime: testTime.Add(-2 * time.Hour)},
+		// an earlier job with the same regex which ends sooner is ignored
+		{assetURL: "http://origin1.example.net/a/.*", keyword: JobKeywordPurge, parameters: "TTL:2h", startTime: testTime},
+		// expired
+		{assetURL: "http://origin1.example.net/b/.*", keyword: JobKeywordPurge, parameters: "TTL:1h", startTime: testTime.Add(-2 * time.Hour)},
+		// clamped to the max
+		{assetURL: "http://origin2.example.net/c\\.jpg", keyword: JobKeywordPurge, parameters: "TTL:1000h", startTime: testTime},
+		// clamped to the min
+		{assetURL: "http://origin2.example.net/d\\.jpg", keyword: JobKeywordPurge, parameters: "TTL:0h", startTime: testTime},
+		// not an invalidation
+		{assetURL: "http://origin2.example.net/e\\.jpg", keyword: "OTHER", parameters: "TTL:24h", startTime: testTime},
+		// no TTL
+		{assetURL: "http://origin2.example.net/f\\.jpg", keyword: JobKeywordPurge, parameters: "", startTime: testTime},
+	}
+
+	expected := "http://origin1.example.net/a/.* 1509703200\n" +
+		"http://origin2.example.net/c\\.jpg 1510747200\n" +
+		"http://origin2.example.net/d\\.jpg 1509541200\n"
+	if actual := makeRegexRevalidateDotConfig(jobs, 14, testTime); actual != expected {
+		t.Errorf("makeRegexRevalidateDotConfig expected: '%s', actual: '%s'", expected, actual)
+	}
+}
+
+func TestJobTTLHours(t *testing.T) {
+	for params, expected := range map[string]int{"TTL:24h": 24, "TTL:1h": 1, JobTTLParameters(72): 72} {
+		if actual, ok := JobTTLHours(params); !ok || actual != expected {
+			t.Errorf("JobTTLHours(%s) expected: %v true, actual: %v %v", params, expected, actual, ok)
+		}
+	}
+	for _, params := range []string{"", "TTL:h", "24h"} {
+		if actual, ok := JobTTLHours(params); ok {
+			t.Errorf("JobTTLHours(%s) expected: false, actual: %v %v", params, actual, ok)
+		}
+	}
+}
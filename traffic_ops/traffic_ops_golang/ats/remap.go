@@ -0,0 +1,283 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// the delivery service protocol column values
+const ProtocolHTTP = 0
+const ProtocolHTTPS = 1
+const ProtocolHTTPAndHTTPS = 2
+const ProtocolHTTPToHTTPS = 3
+
+// the delivery service range_request_handling column values
+const RangeRequestHandlingDontCache = 0
+const RangeRequestHandlingBackgroundFetch = 1
+const RangeRequestHandlingCacheRangeRequest = 2
+
+// the delivery service qstring_ignore column values
+const QStringIgnoreUseInCacheKey = 0
+const QStringIgnoreIgnoreInCacheKey = 1
+const QStringIgnoreDrop = 2
+
+const SigningAlgorithmURLSig = "url_sig"
+const SigningAlgorithmURISigning = "uri_signing"
+
+const HostRegexType = "HOST_REGEXP"
+const AnyMapType = "ANY_MAP"
+
+const PackageConfigFile = "package"
+const CacheURLConfigFile = "cacheurl.config"
+const CacheKeyConfigFile = "cachekey.config"
+
+// DefaultATSMajorVersion is the Traffic Server major version assumed when a profile has no package trafficserver parameter.
+const DefaultATSMajorVersion = 5
+
+// remapData is everything about a server needed to generate its remap.config.
+type remapData struct {
+	dses            []dsInfo
+	atsMajorVersion int
+	// dscpRemap is whether the server profile has a package dscp_remap parameter, in which case DSCP is set with the dscp_remap plugin instead of header_rewrite.
+	dscpRemap bool
+	// cacheURLGlobal is whether the server profile has a global cacheurl.config, in which case delivery services which ignore the query string don't get their own.
+	cacheURLGlobal bool
+	// cacheKeyParams is the cachekey.config parameters of each delivery service profile, by profile ID.
+	cacheKeyParams map[int]map[string]string
+}
+
+// RemapDotConfig returns the remap.config of the given server, the same as the Perl Traffic Ops. Edges get a map line for each HOST regex of each delivery service assigned to them, with the plugins each delivery service needs. Mids get a map line for each origin of their CDN's delivery services which needs plugins on the mid. Origin shielding is configured in parent.config, not here.
+func RemapDotConfig(db *sql.DB, server ServerInfo, header string) (string, error) {
+	data, err := getRemapData(db, server)
+	if err != nil {
+		return "", err
+	}
+	if server.IsMid() {
+		return header + makeMidRemapDotConfig(data), nil
+	}
+	return header + makeEdgeRemapDotConfig(server, data), nil
+}
+
+func makeEdgeRemapDotConfig(server ServerInfo, data remapData) string {
+	lines := []string{}
+	for _, ds := range data.dses {
+		text := ""
+		if ds.dsType == AnyMapType {
+			text = ds.remapText.String + "\n"
+		} else {
+			for _, mapFrom := range edgeMapFroms(server, ds) {
+				text += makeEdgeRemapLine(server, data, ds, mapFrom, ds.orgServerFQDN.String+"/")
+			}
+		}
+		lines = append(lines, text)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
+
+// edgeMapFroms returns the remap sources of a delivery service regex on an edge: none unless it's a HOST regex and the delivery service has an origin, one for HTTP or HTTPS, and two for HTTP and HTTPS. A regex ending in .* is a delivery service subdomain of the CDN domain, prefixed with the routing name for DNS delivery services, and the server host name for HTTP delivery services.
+func edgeMapFroms(server ServerInfo, ds dsInfo) []string {
+	if ds.regexType != HostRegexType || !ds.orgServerFQDN.Valid {
+		return nil
+	}
+
+	httpHost := ds.pattern
+	httpsHost := ds.pattern
+	if strings.HasSuffix(ds.pattern, ".*") {
+		re := strings.Replace(ds.pattern, `\`, "", -1)
+		re = strings.Replace(re, ".*", "", -1)
+		hostName := "ccr"
+		if strings.HasPrefix(ds.dsType, "DNS") {
+			hostName = ds.routingName
+		}
+		portStr := ""
+		if hostName == "ccr" && server.TCPPort > 0 && server.TCPPort != 80 {
+			portStr = ":" + strconv.Itoa(server.TCPPort)
+		}
+		httpHost = hostName + re + ds.domain + portStr
+		httpsHost = hostName + re + ds.domain
+	}
+
+	switch ds.protocol {
+	case ProtocolHTTP:
+		return []string{"http://" + httpHost + "/"}
+	case ProtocolHTTPS, ProtocolHTTPToHTTPS:
+		return []string{"https://" + httpsHost + "/"}
+	case ProtocolHTTPAndHTTPS:
+		return []string{"http://" + httpHost + "/", "https://" + httpsHost + "/"}
+	}
+	return nil
+}
+
+func makeEdgeRemapLine(server ServerInfo, data remapData, ds dsInfo, mapFrom string, mapTo string) string {
+	// HTTP delivery services are requested by the cache host name, which was "ccr" until now
+	mapFrom = strings.Replace(mapFrom, "ccr", server.HostName, 1)
+
+	text := "map\t" + mapFrom + "     " + mapTo
+	if data.dscpRemap {
+		text += " @plugin=dscp_remap.so @pparam=" + strconv.Itoa(ds.dscp)
+	} else {
+		text += " @plugin=header_rewrite.so @pparam=dscp/set_dscp_" + strconv.Itoa(ds.dscp) + ".config"
+	}
+	if ds.edgeHeaderRewrite.Valid {
+		text += " @plugin=header_rewrite.so @pparam=" + GetConfigFile(HeaderRewritePrefix, ds.xmlID)
+	}
+	switch ds.signingAlgorithm.String {
+	case SigningAlgorithmURLSig:
+		text += " @plugin=url_sig.so @pparam=url_sig_" + ds.xmlID + ".config"
+	case SigningAlgorithmURISigning:
+		text += " @plugin=uri_signing.so @pparam=uri_signing_" + ds.xmlID + ".config"
+	}
+	switch ds.qstringIgnore {
+	case QStringIgnoreDrop:
+		text += " @plugin=regex_remap.so @pparam=drop_qstring.config"
+	case QStringIgnoreIgnoreInCacheKey:
+		if !data.cacheURLGlobal {
+			if data.atsMajorVersion >= 6 {
+				text += ` @plugin=cachekey.so @pparam=--separator= @pparam=--remove-all-params=true @pparam=--remove-path=true @pparam=--capture-prefix-uri=/http:\/\/([^?]*)/http:\/\/$1/`
+			} else {
+				text += " @plugin=cacheurl.so @pparam=cacheurl_qstring.config"
+			}
+		}
+	}
+	if ds.cacheURL.String != "" {
+		text += " @plugin=cacheurl.so @pparam=" + GetConfigFile(CacheUrlPrefix, ds.xmlID)
+	}
+	text += cacheKeyPlugin(data, ds)
+	if ds.regexRemap.String != "" {
+		text += " @plugin=regex_remap.so @pparam=" + GetConfigFile(RegexRemapPrefix, ds.xmlID)
+	}
+	switch ds.rangeRequestHandling {
+	case RangeRequestHandlingBackgroundFetch:
+		text += " @plugin=background_fetch.so @pparam=bg_fetch.config"
+	case RangeRequestHandlingCacheRangeRequest:
+		text += " @plugin=cache_range_requests.so "
+	}
+	if ds.remapText.Valid {
+		text += " " + ds.remapText.String
+	}
+	return text + "\n"
+}
+
+// makeMidRemapDotConfig returns the map lines of a mid, which maps each origin to itself with the plugins its delivery service needs on the mid. Origins which need no plugins get no line, and only the first delivery service regex of each origin is used.
+func makeMidRemapDotConfig(data remapData) string {
+	midRemaps := map[string]string{}
+	for _, ds := range data.dses {
+		if strings.Contains(ds.dsType, "LIVE") && !strings.Contains(ds.dsType, "NATNL") {
+			continue // live local delivery services skip mids
+		}
+		if !ds.orgServerFQDN.Valid {
+			continue
+		}
+		org := ds.orgServerFQDN.String
+		if _, ok := midRemaps[org]; ok {
+			continue // skip remap rules from extra HOST_REGEXP entries
+		}
+
+		text := ""
+		if ds.midHeaderRewrite.String != "" {
+			text += " @plugin=header_rewrite.so @pparam=" + GetConfigFile(MidHeaderRewritePrefix, ds.xmlID)
+		}
+		if ds.qstringIgnore == QStringIgnoreIgnoreInCacheKey {
+			text += " @plugin=cacheurl.so @pparam=cacheurl_qstring.config"
+		}
+		if ds.cacheURL.String != "" {
+			text += " @plugin=cacheurl.so @pparam=" + GetConfigFile(CacheUrlPrefix, ds.xmlID)
+		}
+		text += cacheKeyPlugin(data, ds)
+		if ds.rangeRequestHandling == RangeRequestHandlingCacheRangeRequest {
+			text += " @plugin=cache_range_requests.so"
+		}
+		if text != "" {
+			midRemaps[org] = text
+		}
+	}
+
+	lines := []string{}
+	for org, plugins := range midRemaps {
+		lines = append(lines, "map "+org+" "+org+plugins+"\n")
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
+
+// cacheKeyPlugin returns the cachekey plugin and its parameters, from the cachekey.config parameters of the delivery service's profile, or the empty string if it has none.
+func cacheKeyPlugin(data remapData, ds dsInfo) string {
+	if !ds.profileID.Valid {
+		return ""
+	}
+	params, ok := data.cacheKeyParams[int(ds.profileID.Int64)]
+	if !ok {
+		return ""
+	}
+	names := []string{}
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	text := " @plugin=cachekey.so"
+	for _, name := range names {
+		text += " @pparam=--" + name + "=" + params[name]
+	}
+	return text
+}
+
+func getRemapData(db *sql.DB, server ServerInfo) (remapData, error) {
+	data := remapData{}
+	err := error(nil)
+	if data.dses, err = getDSInfos(db, server); err != nil {
+		return remapData{}, errors.New("getting delivery services: " + err.Error())
+	}
+
+	packageParams, err := GetParamData(db, server.ProfileID, PackageConfigFile, server.HostName+"."+server.DomainName)
+	if err != nil {
+		return remapData{}, errors.New("getting package parameters: " + err.Error())
+	}
+	_, data.dscpRemap = packageParams["dscp_remap"]
+	data.atsMajorVersion = atsMajorVersion(packageParams["trafficserver"])
+
+	if _, data.cacheURLGlobal, err = GetLocation(db, server.ProfileID, CacheURLConfigFile); err != nil {
+		return remapData{}, errors.New("getting cacheurl location: " + err.Error())
+	}
+
+	if data.cacheKeyParams, err = getProfileParamsByFile(db, CacheKeyConfigFile); err != nil {
+		return remapData{}, errors.New("getting cachekey parameters: " + err.Error())
+	}
+	return data, nil
+}
+
+// atsMajorVersion returns the major version of a package trafficserver parameter, e.g. 7 for 7.1.1-1.el7, or DefaultATSMajorVersion if it doesn't start with a number.
+func atsMajorVersion(version string) int {
+	end := strings.IndexFunc(version, func(r rune) bool { return !unicode.IsDigit(r) })
+	if end < 0 {
+		end = len(version)
+	}
+	major, err := strconv.Atoi(version[:end])
+	if err != nil {
+		return DefaultATSMajorVersion
+	}
+	return major
+}
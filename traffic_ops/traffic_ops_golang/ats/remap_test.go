@@ -0,0 +1,133 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testNameVersion = "Traffic Ops (https://to.example.net/)"
+
+var testTime = time.Date(2017, 11, 1, 12, 0, 0, 0, time.UTC)
+
+func nullStr(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: true}
+}
+
+func testCacheKeyParams() map[int]map[string]string {
+	return map[int]map[string]string{5: {"static-prefix": "ds2", "exclude-params": "a,b"}}
+}
+
+// compareGolden compares a generated config file with a golden file, which was generated by the Perl Traffic Ops from the same data.
+func compareGolden(t *testing.T, goldenFile string, actual string) {
+	expected, err := ioutil.ReadFile("testdata/" + goldenFile)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+	expectedLines := strings.Split(string(expected), "\n")
+	actualLines := strings.Split(actual, "\n")
+	for i := 0; i < len(expectedLines) || i < len(actualLines); i++ {
+		expectedLine := ""
+		if i < len(expectedLines) {
+			expectedLine = expectedLines[i]
+		}
+		actualLine := ""
+		if i < len(actualLines) {
+			actualLine = actualLines[i]
+		}
+		if expectedLine != actualLine {
+			t.Errorf("%s line %d expected: '%s', actual: '%s'", goldenFile, i+1, expectedLine, actualLine)
+		}
+	}
+}
+
+func TestEdgeRemapDotConfig(t *testing.T) {
+	server := ServerInfo{ID: 1, HostName: "edge1", DomainName: "example.net", TCPPort: 8080, Type: "EDGE", CDN: "cdn1", CDNDomain: "cdn1.example.net"}
+	data := remapData{
+		atsMajorVersion: 7,
+		cacheKeyParams:  testCacheKeyParams(),
+		dses: []dsInfo{
+			{xmlID: "ds1", dscp: 10, routingName: "cdn", signingAlgorithm: nullStr(SigningAlgorithmURLSig), qstringIgnore: QStringIgnoreIgnoreInCacheKey, orgServerFQDN: nullStr("http://origin1.example.net"),
+				rangeRequestHandling: RangeRequestHandlingBackgroundFetch, pattern: `.*\.ds1\..*`, regexType: HostRegexType, dsType: "HTTP", domain: "cdn1.example.net",
+				edgeHeaderRewrite: nullStr("set-header X-Foo bar"), protocol: ProtocolHTTP},
+			{xmlID: "ds1", dscp: 10, routingName: "cdn", signingAlgorithm: nullStr(SigningAlgorithmURLSig), qstringIgnore: QStringIgnoreIgnoreInCacheKey, orgServerFQDN: nullStr("http://origin1.example.net"),
+				rangeRequestHandling: RangeRequestHandlingBackgroundFetch, pattern: `/path/.*`, regexType: "PATH_REGEXP", dsType: "HTTP", domain: "cdn1.example.net",
+				edgeHeaderRewrite: nullStr("set-header X-Foo bar"), protocol: ProtocolHTTP},
+			{xmlID: "ds2", dscp: 0, qstringIgnore: QStringIgnoreDrop, orgServerFQDN: nullStr("http://origin2.example.net"), rangeRequestHandling: RangeRequestHandlingCacheRangeRequest,
+				pattern: "ds2.example.com", regexType: HostRegexType, dsType: "HTTP", domain: "cdn1.example.net", regexRemap: nullStr("^/foo http://origin2.example.net/bar"),
+				cacheURL: nullStr("http://([^?]+)(?:\\?|$) http://$1"), remapText: nullStr("@action=allow @src_ip=10.0.0.0-10.255.255.255"), protocol: ProtocolHTTPAndHTTPS,
+				profileID: sql.NullInt64{Int64: 5, Valid: true}},
+			{xmlID: "ds3", dscp: 8, routingName: "cdn", signingAlgorithm: nullStr(SigningAlgorithmURISigning), orgServerFQDN: nullStr("https://origin3.example.net"),
+				pattern: `.*\.ds3\..*`, regexType: HostRegexType, dsType: "DNS", domain: "cdn1.example.net", protocol: ProtocolHTTPToHTTPS},
+			{xmlID: "ds4", pattern: `.*\.ds4\..*`, regexType: HostRegexType, dsType: AnyMapType, domain: "cdn1.example.net",
+				remapText: nullStr("map http://any.example.net/ http://origin4.example.net/")},
+		},
+	}
+	actual := HeaderComment(server.HostName, testNameVersion, testTime) + makeEdgeRemapDotConfig(server, data)
+	compareGolden(t, "remap_edge.config", actual)
+}
+
+func TestMidRemapDotConfig(t *testing.T) {
+	data := remapData{
+		atsMajorVersion: 7,
+		cacheKeyParams:  testCacheKeyParams(),
+		dses: []dsInfo{
+			{xmlID: "ds1", qstringIgnore: QStringIgnoreIgnoreInCacheKey, orgServerFQDN: nullStr("http://origin1.example.net"), rangeRequestHandling: RangeRequestHandlingCacheRangeRequest,
+				pattern: `.*\.ds1\..*`, regexType: HostRegexType, dsType: "HTTP", midHeaderRewrite: nullStr("set-header X-Mid 1")},
+			{xmlID: "ds1", qstringIgnore: QStringIgnoreIgnoreInCacheKey, orgServerFQDN: nullStr("http://origin1.example.net"), rangeRequestHandling: RangeRequestHandlingCacheRangeRequest,
+				pattern: `ds1.example.com`, regexType: HostRegexType, dsType: "HTTP", midHeaderRewrite: nullStr("set-header X-Mid 2")},
+			{xmlID: "ds2", orgServerFQDN: nullStr("http://origin2.example.net"), cacheURL: nullStr("foo"), pattern: `.*\.ds2\..*`, regexType: HostRegexType, dsType: "HTTP_LIVE"},
+			{xmlID: "ds5", orgServerFQDN: nullStr("http://origin5.example.net"), cacheURL: nullStr("foo"), pattern: `.*\.ds5\..*`, regexType: HostRegexType, dsType: "HTTP_LIVE_NATNL"},
+			{xmlID: "ds6", orgServerFQDN: nullStr("http://origin6.example.net"), pattern: `.*\.ds6\..*`, regexType: HostRegexType, dsType: "HTTP"},
+			{xmlID: "ds7", orgServerFQDN: nullStr("http://origin7.example.net"), pattern: `.*\.ds7\..*`, regexType: HostRegexType, dsType: "HTTP", profileID: sql.NullInt64{Int64: 5, Valid: true}},
+		},
+	}
+	actual := HeaderComment("mid1", testNameVersion, testTime) + makeMidRemapDotConfig(data)
+	compareGolden(t, "remap_mid.config", actual)
+}
+
+func TestEdgeRemapQStringIgnoreOldATS(t *testing.T) {
+	server := ServerInfo{HostName: "edge1", TCPPort: 80, Type: "EDGE"}
+	ds := dsInfo{xmlID: "ds1", qstringIgnore: QStringIgnoreIgnoreInCacheKey, orgServerFQDN: nullStr("http://origin1.example.net"), pattern: `.*\.ds1\..*`, regexType: HostRegexType, dsType: "HTTP", domain: "cdn1.example.net"}
+
+	actual := makeEdgeRemapDotConfig(server, remapData{atsMajorVersion: 5, dses: []dsInfo{ds}})
+	expected := "map\thttp://edge1.ds1.cdn1.example.net/     http://origin1.example.net/ @plugin=header_rewrite.so @pparam=dscp/set_dscp_0.config @plugin=cacheurl.so @pparam=cacheurl_qstring.config\n"
+	if actual != expected {
+		t.Errorf("makeEdgeRemapDotConfig ATS 5 expected: '%s', actual: '%s'", expected, actual)
+	}
+
+	actual = makeEdgeRemapDotConfig(server, remapData{atsMajorVersion: 7, cacheURLGlobal: true, dscpRemap: true, dses: []dsInfo{ds}})
+	expected = "map\thttp://edge1.ds1.cdn1.example.net/     http://origin1.example.net/ @plugin=dscp_remap.so @pparam=0\n"
+	if actual != expected {
+		t.Errorf("makeEdgeRemapDotConfig global cacheurl expected: '%s', actual: '%s'", expected, actual)
+	}
+}
+
+func TestATSMajorVersion(t *testing.T) {
+	for version, expected := range map[string]int{"7.1.1-2.el7": 7, "6": 6, "10.0.0": 10, "": DefaultATSMajorVersion, "trafficserver": DefaultATSMajorVersion} {
+		if actual := atsMajorVersion(version); actual != expected {
+			t.Errorf("atsMajorVersion(%s) expected: %v, actual: %v", version, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const EdgeTypePrefix = "EDGE"
+const MidTypePrefix = "MID"
+
+// ServerInfo is the data about a cache needed to generate its config files.
+type ServerInfo struct {
+	ID           int
+	HostName     string
+	DomainName   string
+	IPAddress    string
+	TCPPort      int
+	Type         string
+	ProfileID    int
+	ProfileName  string
+	CDNID        int
+	CDN          string
+	CDNDomain    string
+	CacheGroupID int
+	// ParentCacheGroupID and SecondaryParentCacheGroupID are the parents of the server's cachegroup, or -1 if it has none.
+	ParentCacheGroupID          int
+	SecondaryParentCacheGroupID int
+}
+
+// IsMid returns whether the server is a mid-tier cache, which serves every delivery service of its CDN rather than only those assigned to it.
+func (s ServerInfo) IsMid() bool {
+	return strings.HasPrefix(s.Type, MidTypePrefix)
+}
+
+// GetServerInfo returns the server with the given host name or, if it's numeric, ID, like the Perl configfiles routes. Returns false if the server doesn't exist.
+func GetServerInfo(db *sql.DB, hostNameOrID string) (ServerInfo, bool, error) {
+	q := `
+SELECT s.id, s.host_name, s.domain_name, COALESCE(s.ip_address, ''), COALESCE(s.tcp_port, 0), t.name, p.id, p.name, c.id, c.name, c.domain_name,
+cg.id, COALESCE(cg.parent_cachegroup_id, -1), COALESCE(cg.secondary_parent_cachegroup_id, -1)
+FROM server s
+JOIN type t ON t.id = s.type
+JOIN profile p ON p.id = s.profile
+JOIN cdn c ON c.id = s.cdn_id
+JOIN cachegroup cg ON cg.id = s.cachegroup
+`
+	var row *sql.Row
+	if id, err := strconv.Atoi(hostNameOrID); err == nil {
+		row = db.QueryRow(q+`WHERE s.id = $1`, id)
+	} else {
+		row = db.QueryRow(q+`WHERE s.host_name = $1`, hostNameOrID)
+	}
+	s := ServerInfo{}
+	if err := row.Scan(&s.ID, &s.HostName, &s.DomainName, &s.IPAddress, &s.TCPPort, &s.Type, &s.ProfileID, &s.ProfileName, &s.CDNID, &s.CDN, &s.CDNDomain,
+		&s.CacheGroupID, &s.ParentCacheGroupID, &s.SecondaryParentCacheGroupID); err != nil {
+		if err == sql.ErrNoRows {
+			return ServerInfo{}, false, nil
+		}
+		return ServerInfo{}, false, errors.New("querying server: " + err.Error())
+	}
+	return s, true, nil
+}
+
+// GetParamData returns the parameters of the given profile in the given config file, like the Perl param_data: the location parameter is omitted, a name which occurs more than once gets the parameter ID appended as name__id, and the value STRING __HOSTNAME__ is replaced with the server FQDN.
+func GetParamData(db *sql.DB, profileID int, configFile string, fqdn string) (map[string]string, error) {
+	q := `
+SELECT pa.id, pa.name, pa.value
+FROM parameter pa
+JOIN profile_parameter pp ON pp.parameter = pa.id
+WHERE pp.profile = $1
+AND pa.config_file = $2
+ORDER BY pa.id
+`
+	rows, err := db.Query(q, profileID, configFile)
+	if err != nil {
+		return nil, errors.New("querying parameters: " + err.Error())
+	}
+	defer rows.Close()
+
+	params := map[string]string{}
+	for rows.Next() {
+		id := 0
+		name := ""
+		val := ""
+		if err := rows.Scan(&id, &name, &val); err != nil {
+			return nil, errors.New("scanning parameters: " + err.Error())
+		}
+		if name == "location" {
+			continue
+		}
+		if _, ok := params[name]; ok {
+			name += "__" + strconv.Itoa(id)
+		}
+		if val == "STRING __HOSTNAME__" {
+			val = "STRING " + fqdn
+		}
+		params[name] = val
+	}
+	return params, nil
+}
+
+// GetLocation returns the value of the location parameter of the given config file on the given profile, which is the directory the file goes in on the server, and whether it exists.
+func GetLocation(db *sql.DB, profileID int, configFile string) (string, bool, error) {
+	q := `
+SELECT pa.value
+FROM parameter pa
+JOIN profile_parameter pp ON pp.parameter = pa.id
+WHERE pp.profile = $1
+AND pa.config_file = $2
+AND pa.name = 'location'
+LIMIT 1
+`
+	location := ""
+	if err := db.QueryRow(q, profileID, configFile).Scan(&location); err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, errors.New("querying location: " + err.Error())
+	}
+	return location, true, nil
+}
+
+// GetNameVersionString returns the name Traffic Ops identifies itself with in generated files, the global tm.toolname and tm.url parameters, e.g. "Traffic Ops (https://to.example.net/)".
+func GetNameVersionString(db *sql.DB) (string, error) {
+	toolName := ""
+	toURL := ""
+	q := `
+SELECT
+COALESCE((SELECT value FROM parameter WHERE name = 'tm.toolname' AND config_file = 'global' LIMIT 1), ''),
+COALESCE((SELECT value FROM parameter WHERE name = 'tm.url' AND config_file = 'global' LIMIT 1), '')
+`
+	if err := db.QueryRow(q).Scan(&toolName, &toURL); err != nil {
+		return "", errors.New("querying tool name: " + err.Error())
+	}
+	return toolName + " (" + toURL + ")", nil
+}
+
+// HeaderDateFormat is the format of the generated date in config file headers, the same as the date command.
+const HeaderDateFormat = "Mon Jan _2 15:04:05 MST 2006"
+
+// HeaderComment returns the comment line at the top of generated config files.
+func HeaderComment(name string, nameVersion string, now time.Time) string {
+	return "# DO NOT EDIT - Generated for " + name + " by " + nameVersion + " on " + now.Format(HeaderDateFormat) + "\n"
+}
@@ -0,0 +1,62 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"testing"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestGetParamData(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "name", "value"}).
+		AddRow(1, "location", "/opt/trafficserver/etc/trafficserver").
+		AddRow(2, "CONFIG proxy.config.proxy_name", "STRING __HOSTNAME__").
+		AddRow(3, "plugin", "astats_over_http.so").
+		AddRow(4, "plugin", "regex_revalidate.so")
+	mock.ExpectQuery("SELECT pa.id, pa.name, pa.value").WithArgs(5, "records.config").WillReturnRows(rows)
+
+	actual, err := GetParamData(mockDB, 5, "records.config", "edge1.example.net")
+	if err != nil {
+		t.Fatalf("GetParamData expected: nil error, actual: %v", err)
+	}
+	expected := map[string]string{
+		"CONFIG proxy.config.proxy_name": "STRING edge1.example.net",
+		"plugin":                         "astats_over_http.so",
+		"plugin__4":                      "regex_revalidate.so",
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("GetParamData expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestHeaderComment(t *testing.T) {
+	expected := "# DO NOT EDIT - Generated for edge1 by Traffic Ops (https://to.example.net/) on Wed Nov  1 12:00:00 UTC 2017\n"
+	if actual := HeaderComment("edge1", testNameVersion, testTime); actual != expected {
+		t.Errorf("HeaderComment expected: '%s', actual: '%s'", expected, actual)
+	}
+}
@@ -0,0 +1,109 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const SSLMultiCertConfigFile = "ssl_multicert.config"
+
+// SSLHost is the host name of an HTTPS delivery service, and the names of its certificate and key files on a cache.
+type SSLHost struct {
+	XMLID        string
+	HostName     string
+	CertFileName string
+	KeyFileName  string
+}
+
+// SSLMultiCertDotConfig returns the ssl_multicert.config of the given server, which has a certificate and key for each HTTPS delivery service assigned to it. Unlike the Perl, which generates it for every delivery service of a CDN, it only has the delivery services the server serves.
+func SSLMultiCertDotConfig(db *sql.DB, server ServerInfo, header string) (string, error) {
+	hosts, err := GetSSLHosts(db, server)
+	if err != nil {
+		return "", err
+	}
+	return header + makeSSLMultiCertDotConfig(hosts), nil
+}
+
+func makeSSLMultiCertDotConfig(hosts []SSLHost) string {
+	text := ""
+	for _, host := range hosts {
+		text += "ssl_cert_name=" + host.CertFileName + "\t ssl_key_name=" + host.KeyFileName + "\n"
+	}
+	return text
+}
+
+// GetSSLHosts returns the SSL host of each HTTPS delivery service assigned to the server, or for mids, to any server of its CDN, sorted by XML ID.
+func GetSSLHosts(db *sql.DB, server ServerInfo) ([]SSLHost, error) {
+	dses, err := getDSInfos(db, server)
+	if err != nil {
+		return nil, errors.New("getting delivery services: " + err.Error())
+	}
+	return sslHosts(dses), nil
+}
+
+// GetCDNSSLHosts returns the SSL host of each HTTPS delivery service of the CDN, sorted by XML ID.
+func GetCDNSSLHosts(db *sql.DB, cdn string) ([]SSLHost, error) {
+	dses, err := getCDNDSInfos(db, cdn)
+	if err != nil {
+		return nil, errors.New("getting delivery services: " + err.Error())
+	}
+	return sslHosts(dses), nil
+}
+
+// sslHosts returns the SSL host of each HTTPS delivery service, which is the host of its first HOST regex, like the first Perl example URL. Steering delivery services aren't served by caches, so they have none.
+func sslHosts(dses []dsInfo) []SSLHost {
+	hosts := []SSLHost{}
+	seen := map[string]struct{}{}
+	for _, ds := range dses {
+		if ds.protocol == ProtocolHTTP || ds.regexType != HostRegexType || strings.Contains(ds.dsType, "STEERING") {
+			continue
+		}
+		if _, ok := seen[ds.xmlID]; ok {
+			continue
+		}
+		seen[ds.xmlID] = struct{}{}
+
+		hostName := ds.pattern
+		if ds.setNumber == 0 {
+			re := strings.Replace(ds.pattern, `\`, "", -1)
+			re = strings.Replace(re, ".*", "", -1)
+			re = strings.Replace(re, ".", "", -1)
+			hostName = ds.routingName + "." + re + "." + ds.domain
+		}
+		hosts = append(hosts, SSLHost{
+			XMLID:        ds.xmlID,
+			HostName:     hostName,
+			CertFileName: strings.Replace(hostName, ".", "_", -1) + "_cert.cer",
+			KeyFileName:  hostName + ".key",
+		})
+	}
+	sort.Sort(sslHostsByXMLID(hosts))
+	return hosts
+}
+
+type sslHostsByXMLID []SSLHost
+
+func (h sslHostsByXMLID) Len() int           { return len(h) }
+func (h sslHostsByXMLID) Less(i, j int) bool { return h[i].XMLID < h[j].XMLID }
+func (h sslHostsByXMLID) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
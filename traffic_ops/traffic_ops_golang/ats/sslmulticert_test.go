@@ -0,0 +1,50 @@
+package ats
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSSLHosts(t *testing.T) {
+	dses := []dsInfo{
+		{xmlID: "ds2", routingName: "cdn", pattern: `.*\.ds2\..*`, regexType: HostRegexType, dsType: "HTTP", domain: "cdn1.example.net", protocol: ProtocolHTTPAndHTTPS},
+		{xmlID: "ds2", routingName: "cdn", pattern: `ds2.example.com`, regexType: HostRegexType, setNumber: 1, dsType: "HTTP", domain: "cdn1.example.net", protocol: ProtocolHTTPAndHTTPS},
+		{xmlID: "ds1", routingName: "cdn", pattern: `.*\.ds1\..*`, regexType: HostRegexType, dsType: "HTTP", domain: "cdn1.example.net", protocol: ProtocolHTTP},
+		{xmlID: "ds3", routingName: "video", pattern: `/path/.*`, regexType: "PATH_REGEXP", dsType: "DNS", domain: "cdn1.example.net", protocol: ProtocolHTTPS},
+		{xmlID: "ds3", routingName: "video", pattern: `ds3.example.com`, regexType: HostRegexType, setNumber: 1, dsType: "DNS", domain: "cdn1.example.net", protocol: ProtocolHTTPS},
+		{xmlID: "ds4", routingName: "cdn", pattern: `.*\.ds4\..*`, regexType: HostRegexType, dsType: "HTTP_STEERING", domain: "cdn1.example.net", protocol: ProtocolHTTPS},
+	}
+	expected := []SSLHost{
+		{XMLID: "ds2", HostName: "cdn.ds2.cdn1.example.net", CertFileName: "cdn_ds2_cdn1_example_net_cert.cer", KeyFileName: "cdn.ds2.cdn1.example.net.key"},
+		{XMLID: "ds3", HostName: "ds3.example.com", CertFileName: "ds3_example_com_cert.cer", KeyFileName: "ds3.example.com.key"},
+	}
+	actual := sslHosts(dses)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("sslHosts expected: %+v, actual: %+v", expected, actual)
+	}
+
+	expectedText := "ssl_cert_name=cdn_ds2_cdn1_example_net_cert.cer\t ssl_key_name=cdn.ds2.cdn1.example.net.key\n" +
+		"ssl_cert_name=ds3_example_com_cert.cer\t ssl_key_name=ds3.example.com.key\n"
+	if actualText := makeSSLMultiCertDotConfig(actual); actualText != expectedText {
+		t.Errorf("makeSSLMultiCertDotConfig expected: '%s', actual: '%s'", expectedText, actualText)
+	}
+}
@@ -34,6 +34,9 @@ const PrivLevelInvalid = -1
 // PrivLevelReadOnly - The user cannot do any API updates
 const PrivLevelReadOnly = 10
 
+// PrivLevelSteering - The user may manage steering targets and read the steering feed
+const PrivLevelSteering = 15
+
 // PrivLevelOperations - The user has minimal privileges
 const PrivLevelOperations = 20
 
@@ -0,0 +1,82 @@
+package auth
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// CapabilityRouteAll is the api_capability route which matches every route, used by capabilities such as all-read and all-write.
+const CapabilityRouteAll = "/"
+
+// CapabilityRouteWildcard is the api_capability route segment which matches any single path segment, such as a version or ID.
+const CapabilityRouteWildcard = "*"
+
+// CapabilityRoutesQuery is the query for the api_capability routes of the given user's capabilities for the given HTTP method. The user's capabilities are those of their tm_user role and every role assigned to them in user_role.
+const CapabilityRoutesQuery = `
+SELECT DISTINCT ac.route
+FROM api_capability ac
+JOIN role_capability rc ON rc.cap_name = ac.capability
+JOIN tm_user u ON rc.role_id = u.role OR rc.role_id IN (SELECT ur.role_id FROM user_role ur WHERE ur.user_id = u.id)
+WHERE u.username = $1
+AND ac.http_method::text = $2
+`
+
+// CapabilityRouteMatches returns whether the given api_capability route pattern matches the given route, which has a wildcard for every path parameter and version, e.g. /api/*/cdns/*/federations. Wildcards in the pattern match any segment, but wildcards in the route only match wildcards, so /api/*/cdns/name/* doesn't match /api/*/cdns/*/*.
+func CapabilityRouteMatches(pattern string, route string) bool {
+	if pattern == CapabilityRouteAll {
+		return true
+	}
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	routeSegments := strings.Split(strings.Trim(route, "/"), "/")
+	if len(patternSegments) != len(routeSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != CapabilityRouteWildcard && segment != routeSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// HasCapability returns whether the given user has a capability for the given HTTP method and route, using a statement prepared from CapabilityRoutesQuery.
+func HasCapability(capabilityStmt *sql.Stmt, user string, method string, route string) (bool, error) {
+	rows, err := capabilityStmt.Query(user, method)
+	if err != nil {
+		return false, errors.New("querying user capabilities: " + err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		pattern := ""
+		if err := rows.Scan(&pattern); err != nil {
+			return false, errors.New("scanning user capabilities: " + err.Error())
+		}
+		if CapabilityRouteMatches(pattern, route) {
+			return true, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, errors.New("iterating user capabilities: " + err.Error())
+	}
+	return false, nil
+}
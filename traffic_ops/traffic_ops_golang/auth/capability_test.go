@@ -0,0 +1,48 @@
+package auth
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"testing"
+)
+
+func TestCapabilityRouteMatches(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		route    string
+		expected bool
+	}{
+		{"/", "/api/*/cdns/*/federations", true},
+		{"/api/*/cdns", "/api/*/cdns", true},
+		{"/api/*/cdns/", "/api/*/cdns", true},
+		{"/api/*/cdns/*", "/api/*/cdns/*", true},
+		{"/api/*/cdns/*", "/api/*/cdns", false},
+		{"/api/*/cdns", "/api/*/cdns/*", false},
+		{"/api/*/cdns/*/*", "/api/*/cdns/*/federations", true},
+		{"/api/*/cdns/name/*", "/api/*/cdns/*/federations", false},
+		{"/api/*/servers", "/api/*/cdns", false},
+		{"/internal/api/*/federations", "/api/*/federations", false},
+	}
+	for _, test := range tests {
+		if actual := CapabilityRouteMatches(test.pattern, test.route); actual != test.expected {
+			t.Errorf("CapabilityRouteMatches(%v, %v) expected: %v, actual: %v", test.pattern, test.route, test.expected, actual)
+		}
+	}
+}
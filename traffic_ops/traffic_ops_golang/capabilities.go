@@ -0,0 +1,200 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const CapabilitiesPrivLevel = auth.PrivLevelReadOnly
+
+// CapabilitiesWritePrivLevel is admin, unlike the Perl capabilities routes, because capabilities authorize users when capability_authorization is enabled.
+const CapabilitiesWritePrivLevel = auth.PrivLevelAdmin
+
+// capabilitiesHandler returns a handler which serves every capability or, if the path has a name, the capability with that name, in a list.
+func capabilitiesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		name, hasName := pathParams["name"]
+		caps, err := getCapabilities(db.DB, name)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if hasName && len(caps) == 0 {
+			handleErr(errors.New("capability not found"), http.StatusNotFound)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.CapabilitiesResponse{Response: caps})
+	}
+}
+
+// createCapabilityHandler returns a handler which creates a capability.
+func createCapabilityHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		capability := tc.Capability{}
+		if err := json.NewDecoder(r.Body).Decode(&capability); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if capability.Name == "" {
+			handleErr(errors.New("Name is required."), http.StatusBadRequest)
+			return
+		}
+		if capability.Description == "" {
+			handleErr(errors.New("Description is required."), http.StatusBadRequest)
+			return
+		}
+
+		q := `INSERT INTO capability (name, description) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING RETURNING last_updated`
+		if err := db.QueryRow(q, capability.Name, capability.Description).Scan(&capability.LastUpdated); err != nil {
+			if err == sql.ErrNoRows {
+				handleErr(errors.New("Capability '"+capability.Name+"' already exists."), http.StatusBadRequest)
+				return
+			}
+			handleErr(errors.New("inserting capability: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		logRequestChange(db.DB, r, "Created Capability: '"+capability.Name+"', '"+capability.Description+"'")
+		writeJSONResp(w, handleErr, &tc.CapabilityResponse{Response: capability, Alerts: tc.CreateAlerts(tc.SuccessLevel, "Capability was created.")})
+	}
+}
+
+// updateCapabilityHandler returns a handler which updates the description of the capability in the path.
+func updateCapabilityHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		capability := tc.Capability{}
+		if err := json.NewDecoder(r.Body).Decode(&capability); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if capability.Description == "" {
+			handleErr(errors.New("Description is required."), http.StatusBadRequest)
+			return
+		}
+		capability.Name = pathParams["name"]
+
+		q := `UPDATE capability SET description = $1 WHERE name = $2 RETURNING last_updated`
+		if err := db.QueryRow(q, capability.Description, capability.Name).Scan(&capability.LastUpdated); err != nil {
+			if err == sql.ErrNoRows {
+				handleErr(errors.New("capability not found"), http.StatusNotFound)
+				return
+			}
+			handleErr(errors.New("updating capability: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		logRequestChange(db.DB, r, "Updated Capability: '"+capability.Name+"', '"+capability.Description+"'")
+		writeJSONResp(w, handleErr, &tc.CapabilityResponse{Response: capability, Alerts: tc.CreateAlerts(tc.SuccessLevel, "Capability was updated.")})
+	}
+}
+
+// deleteCapabilityHandler returns a handler which deletes the capability in the path, unless an api_capability or role refers to it.
+func deleteCapabilityHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		name := pathParams["name"]
+
+		apiCapabilityRefs := 0
+		roleRefs := 0
+		q := `SELECT (SELECT COUNT(*) FROM api_capability WHERE capability = $1), (SELECT COUNT(*) FROM role_capability WHERE cap_name = $1)`
+		if err := db.QueryRow(q, name).Scan(&apiCapabilityRefs, &roleRefs); err != nil {
+			handleErr(errors.New("querying capability references: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if apiCapabilityRefs > 0 {
+			handleErr(errors.New("Capability '"+name+"' is refered by an api_capability mapping. Deletion failed."), http.StatusBadRequest)
+			return
+		}
+		if roleRefs > 0 {
+			handleErr(errors.New("Capability '"+name+"' is assigned to a role. Deletion failed."), http.StatusBadRequest)
+			return
+		}
+
+		result, err := db.Exec(`DELETE FROM capability WHERE name = $1`, name)
+		if err != nil {
+			handleErr(errors.New("deleting capability: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			handleErr(errors.New("getting deleted capabilities: "+err.Error()), http.StatusInternalServerError)
+			return
+		} else if rowsAffected == 0 {
+			handleErr(errors.New("capability not found"), http.StatusNotFound)
+			return
+		}
+
+		logRequestChange(db.DB, r, "Deleted Capability: '"+name+"'")
+		writeJSONResp(w, handleErr, tc.CreateAlerts(tc.SuccessLevel, "Capability deleted."))
+	}
+}
+
+// getCapabilities returns every capability or, if name isn't empty, the capability with that name.
+func getCapabilities(db *sql.DB, name string) ([]tc.Capability, error) {
+	q := `SELECT name, COALESCE(description, ''), last_updated FROM capability`
+	args := []interface{}{}
+	if name != "" {
+		q += ` WHERE name = $1`
+		args = append(args, name)
+	}
+	q += ` ORDER BY name`
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying capabilities: " + err.Error())
+	}
+	defer rows.Close()
+
+	caps := []tc.Capability{}
+	for rows.Next() {
+		capability := tc.Capability{}
+		if err := rows.Scan(&capability.Name, &capability.Description, &capability.LastUpdated); err != nil {
+			return nil, errors.New("scanning capabilities: " + err.Error())
+		}
+		caps = append(caps, capability)
+	}
+	return caps, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/ats"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// cdnConfigFunc generates the text of a config file for a CDN, starting with the given header.
+type cdnConfigFunc func(db *sql.DB, cdn string, header string) (string, error)
+
+// cdnConfigHandler returns a handler which serves the config file generated by makeConfig for the CDN in the path, by name or ID.
+func cdnConfigHandler(db *sqlx.DB, makeConfig cdnConfigFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		cdn, ok, err := getCDNName(db.DB, pathParams["cdn"])
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("cdn not found"), http.StatusNotFound)
+			return
+		}
+
+		nameVersion, err := ats.GetNameVersionString(db.DB)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		text, err := makeConfig(db.DB, cdn, ats.HeaderComment("CDN "+cdn, nameVersion, time.Now()))
+		if err != nil {
+			handleErr(errors.New("generating config for CDN "+cdn+": "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(tc.ContentType, TextPlain)
+		fmt.Fprintf(w, "%s", text)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/crconfig"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const SnapshotPrivLevel = auth.PrivLevelOperations
+
+// getCDNName returns the name of the CDN with the given name or, for compatibility with the Perl PUT cdns/{id}/snapshot, ID. Returns false if no CDN matches.
+func getCDNName(db *sql.DB, nameOrID string) (string, bool, error) {
+	name := ""
+	q := `SELECT name FROM cdn WHERE name = $1 OR CAST(id AS text) = $1 ORDER BY (name = $1) DESC LIMIT 1`
+	if err := db.QueryRow(q, nameOrID).Scan(&name); err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, errors.New("querying cdn: " + err.Error())
+	}
+	return name, true, nil
+}
+
+// getSnapshotVersion returns the snapshot of the CDN with the version in the request's version query parameter, or the current snapshot if there is no version parameter. Returns the snapshot JSON, its version, whether it exists, and the HTTP status and error if it failed.
+func getSnapshotVersion(db *sql.DB, cdn string, r *http.Request) ([]byte, int64, bool, int, error) {
+	versionStr := r.URL.Query().Get("version")
+	if versionStr == "" {
+		snapshot, version, ok, err := crconfig.GetSnapshot(db, cdn)
+		if err != nil {
+			return nil, 0, false, http.StatusInternalServerError, err
+		}
+		return snapshot, version, ok, http.StatusOK, nil
+	}
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		return nil, 0, false, http.StatusBadRequest, errors.New("version must be an integer")
+	}
+	snapshot, version, ok, err := crconfig.GetSnapshotVersion(db, cdn, version)
+	if err != nil {
+		return nil, 0, false, http.StatusInternalServerError, err
+	}
+	if !ok {
+		return nil, 0, false, http.StatusNotFound, errors.New("snapshot version not found")
+	}
+	return snapshot, version, true, http.StatusOK, nil
+}
+
+// getSnapshotHandler returns the current CRConfig snapshot of the CDN, or the snapshot with the version query parameter, or an empty object if it has never been snapshotted.
+func getSnapshotHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		cdn, ok, err := getCDNName(db.DB, pathParams["name"])
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("cdn not found"), http.StatusNotFound)
+			return
+		}
+
+		snapshot, _, ok, status, err := getSnapshotVersion(db.DB, cdn, r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		if !ok {
+			snapshot = []byte(`{}`)
+		}
+
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, `{"response":%s}`, snapshot)
+	}
+}
+
+// getNewSnapshotHandler returns the CRConfig which a snapshot of the CDN would create now, without storing it.
+func getNewSnapshotHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		crc, _, status, err := makeCRConfig(db.DB, r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		respBts, err := json.Marshal(struct {
+			Response *tc.CRConfig `json:"response"`
+		}{crc})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, "%s", respBts)
+	}
+}
+
+// getSnapshotVersionsHandler returns the stored snapshot versions of the CDN, newest first.
+func getSnapshotVersionsHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		cdn, ok, err := getCDNName(db.DB, pathParams["name"])
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("cdn not found"), http.StatusNotFound)
+			return
+		}
+
+		versions, err := crconfig.GetSnapshotVersions(db.DB, cdn)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		respBts, err := json.Marshal(tc.CRConfigSnapshotVersionsResponse{Response: versions})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, "%s", respBts)
+	}
+}
+
+// snapshotDiffHandler returns the changes a snapshot of the CDN would make, by comparing the CRConfig of the current data with the current snapshot, or the older snapshot with the version query parameter.
+func snapshotDiffHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		crc, _, status, err := makeCRConfig(db.DB, r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		snapshot := &tc.CRConfig{}
+		snapshotBts, version, ok, status, err := getSnapshotVersion(db.DB, *crc.Stats.CDNName, r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		snapshotVersion := (*int64)(nil)
+		if ok {
+			snapshotVersion = &version
+			if err := json.Unmarshal(snapshotBts, snapshot); err != nil {
+				handleErr(errors.New("unmarshalling snapshot: "+err.Error()), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		diff, err := tc.DiffCRConfig(snapshot, crc)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		respBts, err := json.Marshal(tc.CRConfigDiffResponse{Response: tc.CRConfigDiffSummary{
+			Diff:     diff,
+			Summary:  diff.Summary(),
+			Snapshot: snapshot.Stats.DateUnixSeconds,
+			Version:  snapshotVersion,
+		}})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, "%s", respBts)
+	}
+}
+
+// snapshotHandler creates the CRConfig of the CDN, validates it, and stores it as the CDN's snapshot, keeping at most maxVersions versions.
+func snapshotHandler(db *sqlx.DB, maxVersions int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		crc, user, status, err := makeCRConfig(db.DB, r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		if err := crconfig.Validate(crc); err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+
+		if err := crconfig.Snapshot(db.DB, crc, maxVersions); err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		cdn := *crc.Stats.CDNName
+		if err := logChange(db.DB, user, "Snapshot of CRConfig performed for "+cdn); err != nil {
+			log.Errorf("snapshot of %s succeeded, but logging the change failed: %v\n", cdn, err)
+		}
+
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, `{"response":"SUCCESS"}`)
+	}
+}
+
+// makeCRConfig creates the CRConfig of the CDN in the request path. Returns the CRConfig, the requesting user, and the HTTP status and error if it failed.
+func makeCRConfig(db *sql.DB, r *http.Request) (*tc.CRConfig, string, int, error) {
+	pathParams, err := getPathParams(r.Context())
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, err
+	}
+	user, err := auth.GetUserName(r.Context())
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, err
+	}
+
+	cdn, ok, err := getCDNName(db, pathParams["name"])
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, err
+	}
+	if !ok {
+		return nil, "", http.StatusNotFound, crconfig.ErrCDNNotFound
+	}
+
+	crc, err := crconfig.Make(db, cdn, user, r.Host, r.URL.Path, ServerName)
+	if err == crconfig.ErrCDNNotFound {
+		return nil, "", http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, "", http.StatusInternalServerError, errors.New("making CRConfig for " + cdn + ": " + err.Error())
+	}
+	return crc, user, http.StatusOK, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+)
+
+// ChangeLogLevel is the level of change log entries for changes made through the API, the same as the Perl Traffic Ops uses.
+const ChangeLogLevel = "APICHANGE"
+
+// logChange adds a message to the Traffic Ops change log, as the given user.
+func logChange(db *sql.DB, user string, message string) error {
+	q := `INSERT INTO log (level, message, tm_user) VALUES ($1, $2, (SELECT id FROM tm_user WHERE username = $3))`
+	if _, err := db.Exec(q, ChangeLogLevel, message, user); err != nil {
+		return errors.New("inserting change log: " + err.Error())
+	}
+	return nil
+}
+
+// logRequestChange logs a change made by a request as the request's user. The change was already made, so failing to log it is only logged.
+func logRequestChange(db *sql.DB, r *http.Request, msg string) {
+	user, _ := auth.GetUserName(r.Context())
+	if err := logChange(db, user, msg); err != nil {
+		log.Errorf("%v succeeded, but logging the change failed: %v\n", msg, err)
+	}
+}
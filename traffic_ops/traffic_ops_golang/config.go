@@ -40,6 +40,8 @@ type Config struct {
 	ConfigTrafficOpsGolang `json:"traffic_ops_golang"`
 	DB                     ConfigDatabase `json:"db"`
 	Secrets                []string       `json:"secrets"`
+	// SnapshotVersionsMax is how many snapshot versions to keep per CDN, including the current snapshot. Older versions are deleted when a snapshot is taken.
+	SnapshotVersionsMax int `json:"snapshot_versions_max"`
 	// NOTE: don't care about any other fields for now..
 	RiakAuthOptions *riak.AuthOptions
 	RiakEnabled     bool
@@ -70,6 +72,10 @@ type ConfigTrafficOpsGolang struct {
 	Insecure               bool           `json:"insecure"`
 	MaxDBConnections       int            `json:"max_db_connections"`
 	BackendMaxConnections  map[string]int `json:"backend_max_connections"`
+	// CapabilityAuthorization is whether to authorize users by the capabilities of their roles, rather than their priv level.
+	CapabilityAuthorization bool `json:"capability_authorization"`
+	// DNSSECRefreshInterval is how often, in seconds, to roll over expiring DNSSEC keys. If 0, keys are only refreshed when the internal refresh route is requested.
+	DNSSECRefreshInterval int `json:"dnssec_refresh_interval"`
 }
 
 // ConfigDatabase reflects the structure of the database.conf file
@@ -182,6 +188,7 @@ func getRiakAuthOptions(s string) (*riak.AuthOptions, error) {
 
 const (
 	MojoliciousConcurrentConnectionsDefault = 12
+	SnapshotVersionsMaxDefault              = 100
 )
 
 // ParseConfig validates required fields, and parses non-JSON types
@@ -214,6 +221,9 @@ func ParseConfig(cfg Config) (Config, error) {
 	if cfg.BackendMaxConnections["mojolicious"] == 0 {
 		cfg.BackendMaxConnections["mojolicious"] = MojoliciousConcurrentConnectionsDefault
 	}
+	if cfg.SnapshotVersionsMax <= 0 {
+		cfg.SnapshotVersionsMax = SnapshotVersionsMaxDefault
+	}
 
 	invalidTOURLStr := ""
 	var err error
@@ -0,0 +1,280 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+const CRConfigFile = "CRConfig.json"
+
+const LogRequestHeadersParam = "LogRequestHeaders"
+
+// cdnParamNames are the parameters which must have the same value on every profile of the CDN's servers. They're used for the SOA and TTLs of every delivery service.
+var cdnParamNames = map[string]struct{}{
+	"tld.soa.admin":        struct{}{},
+	"tld.soa.expire":       struct{}{},
+	"tld.soa.minimum":      struct{}{},
+	"tld.soa.refresh":      struct{}{},
+	"tld.soa.retry":        struct{}{},
+	"tld.ttls.SOA":         struct{}{},
+	"tld.ttls.NS":          struct{}{},
+	LogRequestHeadersParam: struct{}{},
+}
+
+type param struct {
+	profileID int
+	name      string
+	value     string
+}
+
+// profileParams is the CRConfig.json parameters of the profiles of the CDN's caches and routers.
+type profileParams struct {
+	// params is in query order, which determines which value wins when profiles disagree on a config parameter.
+	params    []param
+	byProfile map[int]map[string]string
+}
+
+// getProfileParams returns the CRConfig.json parameters of every profile used by an EDGE, MID or CCR server in the given CDN.
+func getProfileParams(db *sql.DB, cdn string) (profileParams, error) {
+	q := `
+SELECT p.id, pa.name, pa.value
+FROM parameter pa
+JOIN profile_parameter pp ON pp.parameter = pa.id
+JOIN profile p ON p.id = pp.profile
+WHERE pa.config_file = $2
+AND p.id IN (
+  SELECT s.profile FROM server s
+  JOIN type t ON t.id = s.type
+  JOIN cdn c ON c.id = s.cdn_id
+  WHERE c.name = $1
+  AND (t.name = 'CCR' OR t.name LIKE 'EDGE%' OR t.name LIKE 'MID%')
+)
+ORDER BY p.id, pa.name
+`
+	rows, err := db.Query(q, cdn, CRConfigFile)
+	if err != nil {
+		return profileParams{}, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	params := profileParams{byProfile: map[int]map[string]string{}}
+	for rows.Next() {
+		p := param{}
+		if err := rows.Scan(&p.profileID, &p.name, &p.value); err != nil {
+			return profileParams{}, errors.New("scanning: " + err.Error())
+		}
+		params.params = append(params.params, p)
+		if params.byProfile[p.profileID] == nil {
+			params.byProfile[p.profileID] = map[string]string{}
+		}
+		params.byProfile[p.profileID][p.name] = p.value
+	}
+	return params, nil
+}
+
+// cdnParams returns the value of each of the cdnParamNames which exist. Returns an error if any parameter has different values on different profiles, because the CRConfig can't be generated consistently.
+func (p profileParams) cdnParams() (map[string]string, error) {
+	values := map[string]string{}
+	conflicts := map[string][]string{}
+	for _, param := range p.params {
+		if _, ok := cdnParamNames[param.name]; !ok {
+			continue
+		}
+		if val, ok := values[param.name]; ok && val != param.value {
+			conflicts[param.name] = appendUnique(appendUnique(conflicts[param.name], val), param.value)
+		}
+		values[param.name] = param.value
+	}
+	if len(conflicts) == 0 {
+		return values, nil
+	}
+
+	names := []string{}
+	for name := range conflicts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	msg := "Errors extracting profile parameters: "
+	for _, name := range names {
+		msg += "Parameter " + name + " has multiple values (" + strings.Join(conflicts[name], ", ") + ") from profiles associated with servers in this CDN. "
+	}
+	return nil, errors.New(strings.TrimSpace(msg))
+}
+
+func appendUnique(vals []string, val string) []string {
+	for _, v := range vals {
+		if v == val {
+			return vals
+		}
+	}
+	return append(vals, val)
+}
+
+// makeConfig creates the config section. The tld parameters are nested, e.g. tld.soa.admin becomes config.soa.admin, LogRequestHeaders becomes the requestHeaders list, and all other parameters are copied as-is, except the cdnParamNames.
+func makeConfig(params profileParams, domain string, dnssecEnabled bool) tc.CRConfigConfig {
+	dnssec := boolStr(dnssecEnabled)
+	cfg := tc.CRConfigConfig{DNSSecEnabled: &dnssec}
+	for _, param := range params.params {
+		val := param.value
+		switch {
+		case strings.HasPrefix(param.name, "tld."):
+			keys := strings.SplitN(strings.TrimPrefix(param.name, "tld."), ".", 2)
+			subKey := ""
+			if len(keys) > 1 {
+				subKey = keys[1]
+			}
+			if field := tldConfigField(&cfg, keys[0], subKey); field != nil {
+				*field = &val
+				continue
+			}
+			if cfg.Parameters == nil {
+				cfg.Parameters = map[string]interface{}{}
+			}
+			sub, ok := cfg.Parameters[keys[0]].(map[string]interface{})
+			if !ok {
+				sub = map[string]interface{}{}
+				cfg.Parameters[keys[0]] = sub
+			}
+			sub[subKey] = val
+		case param.name == LogRequestHeadersParam:
+			cfg.RequestHeaders = splitHeaders(val)
+		default:
+			if _, ok := cdnParamNames[param.name]; ok {
+				continue
+			}
+			if field := configField(&cfg, param.name); field != nil {
+				*field = &val
+				continue
+			}
+			if cfg.Parameters == nil {
+				cfg.Parameters = map[string]interface{}{}
+			}
+			cfg.Parameters[param.name] = val
+		}
+	}
+	cfg.DomainName = &domain
+	return cfg
+}
+
+// configField returns the field of the config with the given parameter name, or nil if the config has no field for it.
+func configField(cfg *tc.CRConfigConfig, name string) **string {
+	switch name {
+	case "api.cache-control.max_age":
+		return &cfg.APICacheControlMaxAge
+	case "consistent.dns.routing":
+		return &cfg.ConsistentDNSRouting
+	case "coveragezone.polling.interval":
+		return &cfg.CoverageZonePollingIntervalSeconds
+	case "coveragezone.polling.url":
+		return &cfg.CoverageZonePollingURL
+	case "dnssec.dynamic.response.expiration":
+		return &cfg.DNSSecDynamicResponseExpiration
+	case "dnssec.enabled":
+		return &cfg.DNSSecEnabled
+	case "domain_name":
+		return &cfg.DomainName
+	case "federationmapping.polling.interval":
+		return &cfg.FederationMappingPollingIntervalSeconds
+	case "federationmapping.polling.url":
+		return &cfg.FederationMappingPollingURL
+	case "geolocation.polling.interval":
+		return &cfg.GeoLocationPollingInterval
+	case "geolocation.polling.url":
+		return &cfg.GeoLocationPollingURL
+	case "keystore.maintenance.interval":
+		return &cfg.KeyStoreMaintenanceIntervalSeconds
+	case "neustar.polling.interval":
+		return &cfg.NeustarPollingIntervalSeconds
+	case "neustar.polling.url":
+		return &cfg.NeustarPollingURL
+	case "dnssec.inception":
+		return &cfg.DNSSecInceptionSeconds
+	case "weight":
+		return &cfg.Weight
+	case "zonemanager.cache.maintenance.interval":
+		return &cfg.ZoneManagerCacheMaintenanceIntervalSeconds
+	case "zonemanager.threadpool.scale":
+		return &cfg.ZoneManagerThreadpoolScale
+	}
+	return nil
+}
+
+// tldConfigField returns the field of the config's soa or ttls with the given key, e.g. "soa" and "admin" for tld.soa.admin, or nil if the config has no field for it.
+func tldConfigField(cfg *tc.CRConfigConfig, key string, subKey string) **string {
+	soa := func() *tc.SOA {
+		if cfg.SOA == nil {
+			cfg.SOA = &tc.SOA{}
+		}
+		return cfg.SOA
+	}
+	ttls := func() *tc.CRConfigTTL {
+		if cfg.Ttls == nil {
+			cfg.Ttls = &tc.CRConfigTTL{}
+		}
+		return cfg.Ttls
+	}
+	switch key + "." + subKey {
+	case "soa.admin":
+		return &soa().Admin
+	case "soa.expire":
+		return &soa().ExpireSeconds
+	case "soa.minimum":
+		return &soa().MinimumSeconds
+	case "soa.refresh":
+		return &soa().RefreshSeconds
+	case "soa.retry":
+		return &soa().RetrySeconds
+	case "ttls.A":
+		return &ttls().ASeconds
+	case "ttls.AAAA":
+		return &ttls().AAAASeconds
+	case "ttls.DNSKEY":
+		return &ttls().DNSkeySeconds
+	case "ttls.DS":
+		return &ttls().DSSeconds
+	case "ttls.NS":
+		return &ttls().NSSeconds
+	case "ttls.SOA":
+		return &ttls().SOASeconds
+	}
+	return nil
+}
+
+// splitHeaders splits a list of headers separated by __RETURN__, as used by LogRequestHeaders and the delivery service tr_request_headers, trimming spaces.
+func splitHeaders(s string) []string {
+	headers := []string{}
+	for _, header := range strings.Split(s, "__RETURN__") {
+		headers = append(headers, strings.TrimSpace(header))
+	}
+	return headers
+}
+
+func boolStr(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,95 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// ErrCDNNotFound is returned by Make when the requested CDN doesn't exist.
+var ErrCDNNotFound = errors.New("cdn not found")
+
+// Make creates the CRConfig of the given CDN from the database, the same way the Perl Traffic Ops UI::Topology::gen_crconfig_json does. The user, host, path and version are those of the request, and are only used in the stats section.
+func Make(db *sql.DB, cdn, user, toHost, reqPath, toVersion string) (*tc.CRConfig, error) {
+	crc := tc.CRConfig{}
+
+	domain, dnssecEnabled, err := getCDNInfo(db, cdn)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := getProfileParams(db, cdn)
+	if err != nil {
+		return nil, errors.New("getting profile parameters: " + err.Error())
+	}
+
+	cdnParams, err := params.cdnParams()
+	if err != nil {
+		return nil, err
+	}
+
+	crc.Config = makeConfig(params, domain, dnssecEnabled)
+
+	servers, err := makeServers(db, cdn, params)
+	if err != nil {
+		return nil, errors.New("making servers: " + err.Error())
+	}
+	crc.ContentServers = servers.contentServers
+	crc.ContentRouters = servers.contentRouters
+	crc.Monitors = servers.monitors
+	crc.EdgeLocations = servers.edgeLocations
+
+	if crc.DeliveryServices, err = makeDeliveryServices(db, cdn, domain, cdnParams, servers); err != nil {
+		return nil, errors.New("making delivery services: " + err.Error())
+	}
+
+	crc.Stats = makeStats(cdn, user, toHost, reqPath, toVersion, time.Now())
+	return &crc, nil
+}
+
+// getCDNInfo returns the domain name of the given CDN, and whether DNSSEC is enabled. Returns ErrCDNNotFound if the CDN doesn't exist.
+func getCDNInfo(db *sql.DB, cdn string) (string, bool, error) {
+	domain := ""
+	dnssecEnabled := false
+	if err := db.QueryRow(`SELECT domain_name, dnssec_enabled FROM cdn WHERE name = $1`, cdn).Scan(&domain, &dnssecEnabled); err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, ErrCDNNotFound
+		}
+		return "", false, fmt.Errorf("querying cdn %s: %v", cdn, err)
+	}
+	return domain, dnssecEnabled, nil
+}
+
+func makeStats(cdn, user, toHost, reqPath, toVersion string, now time.Time) tc.CRConfigStats {
+	date := now.Unix()
+	return tc.CRConfigStats{
+		CDNName:         &cdn,
+		DateUnixSeconds: &date,
+		TMHost:          &toHost,
+		TMPath:          &reqPath,
+		TMUser:          &user,
+		TMVersion:       &toVersion,
+	}
+}
@@ -0,0 +1,307 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// expectCRConfigQueries adds the queries of Make, returning the CDN of testdata/CRConfig.json.
+func expectCRConfigQueries(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT domain_name, dnssec_enabled FROM cdn").WithArgs("cdn1").WillReturnRows(
+		sqlmock.NewRows([]string{"domain_name", "dnssec_enabled"}).AddRow("cdn1.example.net", false))
+
+	mock.ExpectQuery("SELECT p.id, pa.name, pa.value").WithArgs("cdn1", CRConfigFile).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "value"}).
+			AddRow(1, "LogRequestHeaders", "X-Foo__RETURN__ X-Bar").
+			AddRow(1, "tld.soa.admin", "traffic_ops").
+			AddRow(1, "tld.soa.expire", "604800").
+			AddRow(1, "tld.soa.minimum", "30").
+			AddRow(1, "tld.soa.refresh", "28800").
+			AddRow(1, "tld.soa.retry", "7200").
+			AddRow(1, "tld.ttls.NS", "3600").
+			AddRow(1, "tld.ttls.SOA", "86400").
+			AddRow(1, "weight", "1.0").
+			AddRow(2, "coveragezone.polling.url", "http://example.net/czf.json").
+			AddRow(2, "tld.ttls.NS", "3600"))
+
+	mock.ExpectQuery("SELECT s.id, s.host_name").WithArgs("cdn1").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "host_name", "fqdn", "tcp_port", "https_port", "interface_name", "ip_address", "ip6_address", "xmpp_id", "profile_id", "profile", "routing_disabled", "type", "status", "cachegroup", "latitude", "longitude", "api_port"}).
+			AddRow(1, "edge1", "edge1.cdn1.example.net", 80, 443, "eth0", "192.0.2.1", "2001:db8::1/64", "edge1", 1, "EDGE1", false, "EDGE", "REPORTED", "edge-east", 38.9, -77.0, nil).
+			AddRow(2, "edge2", "edge2.cdn1.example.net", 80, 443, "eth0", "192.0.2.2", "", "edge2", 3, "EDGE_NOROUTE", true, "EDGE", "ADMIN_DOWN", "edge-east", 38.9, -77.0, nil).
+			AddRow(3, "mid1", "mid1.cdn1.example.net", 80, nil, "eth0", "192.0.2.3", "", nil, 4, "MID1", false, "MID", "ONLINE", "mid-east", 39.0, -77.5, nil).
+			AddRow(4, "router1", "router1.cdn1.example.net", 80, 443, "eth0", "192.0.2.10", "", "router1", 2, "CCR1", false, "CCR", "ONLINE", "router-east", 38.9, -77.0, "3333").
+			AddRow(5, "monitor1", "monitor1.cdn1.example.net", 80, nil, "eth0", "192.0.2.20", "", "monitor1", 5, "RASCAL1", false, "RASCAL", "ONLINE", "mon-east", 38.9, -77.0, nil))
+
+	mock.ExpectQuery("SELECT d.xml_id, d.routing_name").WithArgs("cdn1").WillReturnRows(
+		sqlmock.NewRows([]string{"xml_id", "routing_name", "type", "ccr_dns_ttl", "initial_dispersion", "geo_limit", "geo_limit_countries", "protocol", "geo_provider", "dns_bypass_ip", "dns_bypass_ip6", "dns_bypass_cname", "dns_bypass_ttl", "max_dns_answers", "http_bypass_fqdn", "regional_geo_blocking", "geolimit_redirect_url", "tr_response_headers", "tr_request_headers", "miss_lat", "miss_long", "ipv6_routing_enabled"}).
+			AddRow("ds1", "cdn", "HTTP", 3600, 1, 0, nil, 2, 0, nil, nil, nil, nil, nil, "bypass.example.net:8080", false, nil, `Access-Control-Allow-Origin: "*"`, nil, 41.88, -87.63, true).
+			AddRow("ds2", "edge", "DNS", 30, 1, 2, "US,CA", 1, 1, "192.0.2.100", nil, nil, 60, 2, nil, false, "http://example.net/blocked", nil, "X-Req", nil, nil, false))
+
+	mock.ExpectQuery("SELECT d.xml_id, r.id").WithArgs("cdn1").WillReturnRows(
+		sqlmock.NewRows([]string{"xml_id", "id", "pattern", "type", "set_number"}).
+			AddRow("ds1", 9, "/path/.*", "PATH_REGEXP", 0).
+			AddRow("ds1", 10, `.*\.ds1\..*`, "HOST_REGEXP", 0).
+			AddRow("ds1", 12, "ds1.example.com", "HOST_REGEXP", 1).
+			AddRow("ds2", 13, `.*\.ds2\..*`, "HOST_REGEXP", 0))
+
+	mock.ExpectQuery("SELECT d.xml_id, dss.server").WithArgs("cdn1").WillReturnRows(
+		sqlmock.NewRows([]string{"xml_id", "server"}).
+			AddRow("ds1", 1).
+			AddRow("ds2", 1).
+			AddRow("ds1", 2))
+
+	mock.ExpectQuery("SELECT d.xml_id, sde.host").WithArgs("cdn1").WillReturnRows(
+		sqlmock.NewRows([]string{"xml_id", "host", "ttl", "address", "type"}).
+			AddRow("ds2", "www", 300, "192.0.2.200", "A_RECORD"))
+}
+
+// TestMakeGolden compares Make with testdata/CRConfig.json, which is what the Perl gen_crconfig_json in UI/Topology.pm produces for the rows of expectCRConfigQueries.
+// It was written by following that code by hand for those rows, not by running the Perl, which needs a database with them.
+func TestMakeGolden(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	expectCRConfigQueries(mock)
+
+	crc, err := Make(mockDB, "cdn1", "admin", "to.example.net", "/api/1.2/cdns/cdn1/snapshot", "traffic_ops_golang/test")
+	if err != nil {
+		t.Fatalf("Make expected: nil error, actual: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Make expected: all queries, actual: %v", err)
+	}
+	if err := Validate(crc); err != nil {
+		t.Errorf("Validate expected: nil error, actual: %v", err)
+	}
+
+	// the date is the time of the snapshot, so it can't match
+	golden := int64(1500000000)
+	crc.Stats.DateUnixSeconds = &golden
+
+	actualBts, err := json.Marshal(crc)
+	if err != nil {
+		t.Fatalf("marshalling CRConfig: %v", err)
+	}
+	expectedBts, err := ioutil.ReadFile("testdata/CRConfig.json")
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+	}
+
+	// compare generically, so a difference is reported per section
+	actual := map[string]interface{}{}
+	if err := json.Unmarshal(actualBts, &actual); err != nil {
+		t.Fatalf("unmarshalling CRConfig: %v", err)
+	}
+	expected := map[string]interface{}{}
+	if err := json.Unmarshal(expectedBts, &expected); err != nil {
+		t.Fatalf("unmarshalling golden file: %v", err)
+	}
+	// Perl writes undefined columns as null, where the Go structs omit the key
+	dropNulls(expected)
+	for key, expectedVal := range expected {
+		if !reflect.DeepEqual(expectedVal, actual[key]) {
+			expectedJSON, _ := json.MarshalIndent(expectedVal, "", "  ")
+			actualJSON, _ := json.MarshalIndent(actual[key], "", "  ")
+			t.Errorf("Make %s expected: %s, actual: %s", key, expectedJSON, actualJSON)
+		}
+	}
+	for key := range actual {
+		if _, ok := expected[key]; !ok {
+			t.Errorf("Make expected: no %s, actual: %v", key, actual[key])
+		}
+	}
+}
+
+// TestMakeRouterKeys checks the keys Traffic Router reads, which the golden file alone doesn't document.
+func TestMakeRouterKeys(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	expectCRConfigQueries(mock)
+
+	crc, err := Make(mockDB, "cdn1", "admin", "to.example.net", "/api/1.2/cdns/cdn1/snapshot", "traffic_ops_golang/test")
+	if err != nil {
+		t.Fatalf("Make expected: nil error, actual: %v", err)
+	}
+	bts, err := json.Marshal(crc)
+	if err != nil {
+		t.Fatalf("marshalling CRConfig: %v", err)
+	}
+	actual := map[string]interface{}{}
+	if err := json.Unmarshal(bts, &actual); err != nil {
+		t.Fatalf("unmarshalling CRConfig: %v", err)
+	}
+
+	expected := []struct {
+		path  []string
+		value interface{}
+	}{
+		{[]string{"contentRouters", "router1", "api.port"}, "3333"},
+		{[]string{"deliveryServices", "ds1", "protocol", "acceptHttps"}, "true"},
+		{[]string{"deliveryServices", "ds1", "protocol", "redirectToHttps"}, "false"},
+		{[]string{"deliveryServices", "ds1", "geolocationProvider"}, "maxmindGeolocationService"},
+		{[]string{"deliveryServices", "ds1", "missLocation", "lat"}, 41.88},
+		{[]string{"deliveryServices", "ds1", "missLocation", "long"}, -87.63},
+		{[]string{"deliveryServices", "ds2", "protocol", "acceptHttp"}, "false"},
+		{[]string{"deliveryServices", "ds2", "geolocationProvider"}, "neustarGeolocationService"},
+	}
+	for _, e := range expected {
+		if val := jsonPath(actual, e.path); !reflect.DeepEqual(e.value, val) {
+			t.Errorf("Make %s expected: %v, actual: %v", strings.Join(e.path, "."), e.value, val)
+		}
+	}
+
+	matchSets, _ := jsonPath(actual, []string{"deliveryServices", "ds1", "matchsets"}).([]interface{})
+	if len(matchSets) != 2 {
+		t.Fatalf("Make deliveryServices.ds1.matchsets expected: 2, actual: %v", matchSets)
+	}
+	matchList, _ := jsonPath(matchSets[0].(map[string]interface{}), []string{"matchlist"}).([]interface{})
+	if len(matchList) == 0 || jsonPath(matchList[0].(map[string]interface{}), []string{"match-type"}) == nil {
+		t.Errorf("Make deliveryServices.ds1.matchsets expected: matchlist with match-type, actual: %v", matchSets[0])
+	}
+}
+
+// dropNulls removes the null members of the generic JSON value val, recursively.
+func dropNulls(val interface{}) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, member := range v {
+			if member == nil {
+				delete(v, key)
+				continue
+			}
+			dropNulls(member)
+		}
+	case []interface{}:
+		for _, elem := range v {
+			dropNulls(elem)
+		}
+	}
+}
+
+// jsonPath returns the value at path in the generic JSON object obj, or nil if there isn't one.
+func jsonPath(obj map[string]interface{}, path []string) interface{} {
+	val := interface{}(obj)
+	for _, key := range path {
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		val = m[key]
+	}
+	return val
+}
+
+func TestMakeCDNNotFound(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+	mock.ExpectQuery("SELECT domain_name, dnssec_enabled FROM cdn").WithArgs("nocdn").WillReturnRows(sqlmock.NewRows([]string{"domain_name", "dnssec_enabled"}))
+
+	if _, err := Make(mockDB, "nocdn", "admin", "", "", ""); err != ErrCDNNotFound {
+		t.Errorf("Make expected: ErrCDNNotFound, actual: %v", err)
+	}
+}
+
+func TestCDNParamsConflict(t *testing.T) {
+	params := profileParams{params: []param{
+		{profileID: 1, name: "tld.ttls.NS", value: "3600"},
+		{profileID: 2, name: "tld.ttls.NS", value: "60"},
+		{profileID: 2, name: "weight", value: "1.0"},
+		{profileID: 3, name: "weight", value: "0.5"},
+	}}
+	_, err := params.cdnParams()
+	if err == nil {
+		t.Fatalf("cdnParams expected: error, actual: nil")
+	}
+	if !strings.Contains(err.Error(), "tld.ttls.NS has multiple values (3600, 60)") {
+		t.Errorf("cdnParams expected: tld.ttls.NS conflict error, actual: %v", err)
+	}
+	if strings.Contains(err.Error(), "weight") {
+		t.Errorf("cdnParams expected: no error for per-profile parameter weight, actual: %v", err)
+	}
+}
+
+func TestParseResponseHeaders(t *testing.T) {
+	actual := parseResponseHeaders(`Access-Control-Allow-Origin: "*"__RETURN__X-Foo: bar baz`)
+	expected := map[string]string{"Access-Control-Allow-Origin": "*", "X-Foo": "bar baz"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("parseResponseHeaders expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestMakeMatchSets(t *testing.T) {
+	regexes := []dsRegex{
+		{id: 2, pattern: `.*\.foo\..*`, regexType: HostRegexType, setNumber: 0},
+		{id: 11, pattern: `.*\.bar\..*`, regexType: HostRegexType, setNumber: 0},
+		{id: 3, pattern: `.*`, regexType: "STEERING_REGEXP", setNumber: 0},
+	}
+	matchSets, domains := makeMatchSets("HTTP", "cdn.example.net", regexes)
+	if len(matchSets) != 1 {
+		t.Fatalf("makeMatchSets expected: 1 match set, actual: %v", len(matchSets))
+	}
+	// sorted by string ID, like the Perl
+	expected := []tc.CRConfigMatchList{{Regex: `.*\.bar\..*`, MatchType: "HOST"}, {Regex: `.*\.foo\..*`, MatchType: "HOST"}}
+	if !reflect.DeepEqual(expected, matchSets[0].MatchList) {
+		t.Errorf("makeMatchSets expected: %v, actual: %v", expected, matchSets[0].MatchList)
+	}
+	expectedDomains := []string{"bar.cdn.example.net", "foo.cdn.example.net"}
+	if !reflect.DeepEqual(expectedDomains, domains) {
+		t.Errorf("makeMatchSets domains expected: %v, actual: %v", expectedDomains, domains)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cdn := "cdn1"
+	edge := "EDGE"
+	domain := "cdn1.example.net"
+	crc := &tc.CRConfig{
+		Config: tc.CRConfigConfig{DomainName: &domain},
+		ContentServers: map[string]tc.CRConfigTrafficOpsServer{
+			"edge1": {ServerType: &edge, DeliveryServices: map[string][]string{"nods": {"edge1.nods.cdn1.example.net"}}},
+		},
+		DeliveryServices: map[string]tc.CRConfigDeliveryService{"ds1": {}},
+		Stats:            tc.CRConfigStats{CDNName: &cdn},
+	}
+	err := Validate(crc)
+	if err == nil {
+		t.Fatalf("Validate expected: error, actual: nil")
+	}
+	for _, expected := range []string{"edge1 has no ip", "delivery service nods which isn't in deliveryServices", "ds1 has no regexes"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Validate expected: error containing '%s', actual: %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,481 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+const GeoProviderNeustar = 1
+const NeustarGeolocationService = "neustarGeolocationService"
+const MaxmindGeolocationService = "maxmindGeolocationService"
+
+const GeoLimitNone = 0
+const GeoLimitCZFOnly = 1
+
+// the delivery service protocol column values
+const ProtocolHTTPS = 1
+const ProtocolHTTPToHTTPS = 3
+
+const HostRegexType = "HOST_REGEXP"
+
+// matchTypes maps regex types to CRConfig match types. Regexes of other types aren't in the match lists.
+var matchTypes = map[string]string{
+	HostRegexType:   "HOST",
+	"PATH_REGEXP":   "PATH",
+	"HEADER_REGEXP": "HEADER",
+}
+
+type deliveryService struct {
+	xmlID               string
+	routingName         sql.NullString
+	dsType              string
+	ttl                 sql.NullInt64
+	initialDispersion   sql.NullInt64
+	geoLimit            sql.NullInt64
+	geoLimitCountries   sql.NullString
+	protocol            sql.NullInt64
+	geoProvider         sql.NullInt64
+	dnsBypassIP         sql.NullString
+	dnsBypassIP6        sql.NullString
+	dnsBypassCName      sql.NullString
+	dnsBypassTTL        sql.NullInt64
+	maxDNSAnswers       sql.NullInt64
+	httpBypassFQDN      sql.NullString
+	regionalGeoBlocking bool
+	geoLimitRedirectURL sql.NullString
+	trResponseHeaders   sql.NullString
+	trRequestHeaders    sql.NullString
+	missLat             sql.NullFloat64
+	missLong            sql.NullFloat64
+	ip6RoutingEnabled   sql.NullBool
+}
+
+type dsRegex struct {
+	id        int
+	pattern   string
+	regexType string
+	setNumber int
+}
+
+// dsRegexesByID sorts regexes the way the Perl CRConfig does, by the string value of their ID, which determines the match list order.
+type dsRegexesByID []dsRegex
+
+func (s dsRegexesByID) Len() int      { return len(s) }
+func (s dsRegexesByID) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s dsRegexesByID) Less(i, j int) bool {
+	return strconv.Itoa(s[i].id) < strconv.Itoa(s[j].id)
+}
+
+// makeDeliveryServices creates the deliveryServices section from the active delivery services of the CDN, and adds each delivery service's remaps to its content servers.
+func makeDeliveryServices(db *sql.DB, cdn string, domain string, cdnParams map[string]string, s servers) (map[string]tc.CRConfigDeliveryService, error) {
+	dses, err := getDeliveryServices(db, cdn)
+	if err != nil {
+		return nil, errors.New("getting delivery services: " + err.Error())
+	}
+	regexes, err := getDSRegexes(db, cdn)
+	if err != nil {
+		return nil, errors.New("getting regexes: " + err.Error())
+	}
+	dsServers, err := getDSServers(db, cdn)
+	if err != nil {
+		return nil, errors.New("getting delivery service servers: " + err.Error())
+	}
+	staticDNSEntries, err := getStaticDNSEntries(db, cdn)
+	if err != nil {
+		return nil, errors.New("getting static dns entries: " + err.Error())
+	}
+
+	crDSes := map[string]tc.CRConfigDeliveryService{}
+	for _, ds := range dses {
+		protocol := "HTTP"
+		if strings.Contains(ds.dsType, "DNS") {
+			protocol = "DNS"
+		}
+		dsRegexes := regexes[ds.xmlID]
+		crDS := makeDeliveryService(ds, protocol, domain, cdnParams, dsRegexes)
+		if entries, ok := staticDNSEntries[ds.xmlID]; ok {
+			crDS.StaticDNSEntries = entries
+		}
+		crDSes[ds.xmlID] = crDS
+		addContentServerRemaps(ds, protocol, domain, dsRegexes, dsServers[ds.xmlID], s)
+	}
+	return crDSes, nil
+}
+
+func makeDeliveryService(ds deliveryService, protocol string, domain string, cdnParams map[string]string, regexes []dsRegex) tc.CRConfigDeliveryService {
+	crDS := tc.CRConfigDeliveryService{}
+	if ds.routingName.Valid {
+		crDS.RoutingName = &ds.routingName.String
+	}
+
+	crDS.MatchSets, crDS.Domains = makeMatchSets(protocol, domain, regexes)
+	crDS.TTL = intPtr(ds.ttl)
+
+	if protocol != "DNS" {
+		shuffled := "true"
+		crDS.Dispersion = &tc.CRConfigDispersion{Limit: int(ds.initialDispersion.Int64), Shuffled: &shuffled}
+	}
+
+	czfOnly := "false"
+	if ds.geoLimit.Int64 == GeoLimitCZFOnly {
+		czfOnly = "true"
+	} else if ds.geoLimit.Int64 != GeoLimitNone {
+		crDS.GeoEnabled = []tc.CRConfigGeoEnabled{}
+		if ds.geoLimitCountries.String != "" {
+			for _, code := range strings.Split(ds.geoLimitCountries.String, ",") {
+				crDS.GeoEnabled = append(crDS.GeoEnabled, tc.CRConfigGeoEnabled{CountryCode: code})
+			}
+		}
+	}
+	crDS.CoverageZoneOnly = &czfOnly
+
+	sslEnabled := "false"
+	crDS.Protocol = &tc.CRConfigDeliveryServiceProtocol{}
+	if ds.protocol.Int64 > 0 && ds.protocol.Int64 < 4 {
+		sslEnabled = "true"
+		crDS.Protocol.AcceptHTTPS = true
+		if ds.protocol.Int64 == ProtocolHTTPS {
+			acceptHTTP := false
+			crDS.Protocol.AcceptHTTP = &acceptHTTP
+		}
+		if ds.protocol.Int64 == ProtocolHTTPToHTTPS {
+			crDS.Protocol.RedirectToHTTPS = true
+		}
+	}
+	crDS.SSLEnabled = &sslEnabled
+
+	geoProvider := MaxmindGeolocationService
+	if ds.geoProvider.Int64 == GeoProviderNeustar {
+		geoProvider = NeustarGeolocationService
+	}
+	crDS.GeoLocationProvider = &geoProvider
+
+	if protocol == "DNS" {
+		bypass := tc.CRConfigBypassDestination{
+			IP:    nonEmpty(ds.dnsBypassIP),
+			IP6:   nonEmpty(ds.dnsBypassIP6),
+			CName: nonEmpty(ds.dnsBypassCName),
+			TTL:   intPtr(ds.dnsBypassTTL),
+		}
+		if bypass != (tc.CRConfigBypassDestination{}) {
+			crDS.BypassDestination = map[string]*tc.CRConfigBypassDestination{"DNS": &bypass}
+		}
+		crDS.MaxDNSIPsForLocation = intPtr(ds.maxDNSAnswers)
+	} else {
+		if ds.httpBypassFQDN.String != "" {
+			fqdn := ds.httpBypassFQDN.String
+			port := "80"
+			if i := strings.Index(fqdn, ":"); i >= 0 {
+				fqdn, port = fqdn[:i], fqdn[i+1:]
+			}
+			crDS.BypassDestination = map[string]*tc.CRConfigBypassDestination{"HTTP": &tc.CRConfigBypassDestination{FQDN: &fqdn, Port: &port}}
+		}
+		regionalGeoBlocking := boolStr(ds.regionalGeoBlocking)
+		crDS.RegionalGeoBlocking = &regionalGeoBlocking
+		if ds.geoLimit.Int64 != GeoLimitNone {
+			redirectURL := ds.geoLimitRedirectURL.String
+			crDS.GeoLimitRedirectURL = &redirectURL
+		}
+	}
+
+	if ds.trResponseHeaders.String != "" {
+		crDS.ResponseHeaders = parseResponseHeaders(ds.trResponseHeaders.String)
+	}
+	if ds.trRequestHeaders.String != "" {
+		crDS.RequestHeaders = splitHeaders(ds.trRequestHeaders.String)
+	}
+
+	if ds.missLat.Valid || ds.missLong.Valid {
+		crDS.MissLocation = &tc.CRConfigMissLocation{}
+		if ds.missLat.Valid {
+			crDS.MissLocation.Lat = &ds.missLat.Float64
+		}
+		if ds.missLong.Valid {
+			crDS.MissLocation.Lon = &ds.missLong.Float64
+		}
+	}
+
+	ttl := ""
+	if ds.ttl.Valid {
+		ttl = strconv.FormatInt(ds.ttl.Int64, 10)
+	}
+	crDS.TTLs = &tc.CRConfigTTL{
+		ASeconds:    &ttl,
+		AAAASeconds: &ttl,
+		NSSeconds:   paramPtr(cdnParams, "tld.ttls.NS"),
+		SOASeconds:  paramPtr(cdnParams, "tld.ttls.SOA"),
+	}
+	crDS.Soa = &tc.SOA{
+		Admin:          paramPtr(cdnParams, "tld.soa.admin"),
+		ExpireSeconds:  paramPtr(cdnParams, "tld.soa.expire"),
+		MinimumSeconds: paramPtr(cdnParams, "tld.soa.minimum"),
+		RefreshSeconds: paramPtr(cdnParams, "tld.soa.refresh"),
+		RetrySeconds:   paramPtr(cdnParams, "tld.soa.retry"),
+	}
+	ip6RoutingEnabled := boolStr(ds.ip6RoutingEnabled.Bool)
+	crDS.IP6RoutingEnabled = &ip6RoutingEnabled
+	return crDS
+}
+
+// makeMatchSets returns the match sets of a delivery service, indexed by regex set number, and its domains, which are the HOST regexes of set 0 in the CDN domain.
+func makeMatchSets(protocol string, domain string, regexes []dsRegex) ([]*tc.CRConfigMatchSet, []string) {
+	matchSets := []*tc.CRConfigMatchSet{}
+	domains := []string{}
+	for _, regex := range regexes {
+		for len(matchSets) <= regex.setNumber {
+			matchSets = append(matchSets, nil)
+		}
+		if matchSets[regex.setNumber] == nil {
+			matchSets[regex.setNumber] = &tc.CRConfigMatchSet{Protocol: protocol}
+		}
+	}
+
+	sorted := make([]dsRegex, len(regexes))
+	copy(sorted, regexes)
+	sort.Sort(dsRegexesByID(sorted))
+	for _, regex := range sorted {
+		matchType, ok := matchTypes[regex.regexType]
+		if !ok {
+			continue
+		}
+		matchSet := matchSets[regex.setNumber]
+		matchSet.MatchList = append(matchSet.MatchList, tc.CRConfigMatchList{Regex: regex.pattern, MatchType: matchType})
+		if regex.regexType == HostRegexType && regex.setNumber == 0 {
+			host := strings.Replace(regex.pattern, `\`, "", -1)
+			host = strings.Replace(host, ".*", "", -1)
+			host = strings.Replace(host, ".", "", -1)
+			domains = append(domains, host+"."+domain)
+		}
+	}
+	if len(matchSets) == 0 {
+		matchSets = nil
+	}
+	return matchSets, domains
+}
+
+var remapHostRegex = regexp.MustCompile(`\\|\.\*`)
+
+// addContentServerRemaps adds the delivery service's remap FQDNs to each of its content servers, except servers whose profile disables routing. HOST regexes of the form .*\.foo\..* become a remap of the server (HTTP) or routing name (DNS) in the CDN domain, and other HOST regexes are used as-is.
+func addContentServerRemaps(ds deliveryService, protocol string, domain string, regexes []dsRegex, serverIDs []int, s servers) {
+	// the HOST regex of each set number, in set order
+	hostsBySet := map[int]string{}
+	maxSet := -1
+	for _, regex := range regexes {
+		if regex.regexType != HostRegexType {
+			continue
+		}
+		hostsBySet[regex.setNumber] = regex.pattern
+		if regex.setNumber > maxSet {
+			maxSet = regex.setNumber
+		}
+	}
+
+	for _, serverID := range serverIDs {
+		hostName, ok := s.cacheNames[serverID]
+		if !ok {
+			continue
+		}
+		server := s.contentServers[hostName]
+		if server.RoutingDisabled == 1 {
+			continue
+		}
+		for set := 0; set <= maxSet; set++ {
+			host, ok := hostsBySet[set]
+			if !ok {
+				continue
+			}
+			remap := host
+			if strings.HasSuffix(host, ".*") {
+				hostPart := remapHostRegex.ReplaceAllString(host, "")
+				if protocol == "DNS" {
+					remap = ds.routingName.String + hostPart + domain
+				} else {
+					remap = hostName + hostPart + domain
+				}
+			}
+			if server.DeliveryServices == nil {
+				server.DeliveryServices = map[string][]string{}
+			}
+			server.DeliveryServices[ds.xmlID] = append(server.DeliveryServices[ds.xmlID], remap)
+		}
+		s.contentServers[hostName] = server
+	}
+}
+
+var responseHeaderSeparator = regexp.MustCompile(`:\s`)
+
+// parseResponseHeaders parses the tr_response_headers of a delivery service, a list of 'Name: "value"' headers separated by __RETURN__.
+func parseResponseHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, header := range strings.Split(s, "__RETURN__") {
+		fields := responseHeaderSeparator.Split(header, -1)
+		name := strings.TrimSpace(fields[0])
+		value := ""
+		if len(fields) > 1 {
+			value = strings.Trim(strings.TrimSpace(fields[1]), `"`)
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+func getDeliveryServices(db *sql.DB, cdn string) ([]deliveryService, error) {
+	q := `
+SELECT d.xml_id, d.routing_name, t.name, d.ccr_dns_ttl, d.initial_dispersion, d.geo_limit, d.geo_limit_countries, d.protocol, d.geo_provider,
+d.dns_bypass_ip, d.dns_bypass_ip6, d.dns_bypass_cname, d.dns_bypass_ttl, d.max_dns_answers, d.http_bypass_fqdn, d.regional_geo_blocking, d.geolimit_redirect_url,
+d.tr_response_headers, d.tr_request_headers, d.miss_lat, d.miss_long, d.ipv6_routing_enabled
+FROM deliveryservice d
+JOIN type t ON t.id = d.type
+JOIN cdn c ON c.id = d.cdn_id
+WHERE c.name = $1
+AND d.active = true
+`
+	rows, err := db.Query(q, cdn)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	dses := []deliveryService{}
+	for rows.Next() {
+		ds := deliveryService{}
+		if err := rows.Scan(&ds.xmlID, &ds.routingName, &ds.dsType, &ds.ttl, &ds.initialDispersion, &ds.geoLimit, &ds.geoLimitCountries, &ds.protocol, &ds.geoProvider,
+			&ds.dnsBypassIP, &ds.dnsBypassIP6, &ds.dnsBypassCName, &ds.dnsBypassTTL, &ds.maxDNSAnswers, &ds.httpBypassFQDN, &ds.regionalGeoBlocking, &ds.geoLimitRedirectURL,
+			&ds.trResponseHeaders, &ds.trRequestHeaders, &ds.missLat, &ds.missLong, &ds.ip6RoutingEnabled); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		dses = append(dses, ds)
+	}
+	return dses, nil
+}
+
+// getDSRegexes returns the regexes of each active delivery service in the CDN, by xml_id.
+func getDSRegexes(db *sql.DB, cdn string) (map[string][]dsRegex, error) {
+	q := `
+SELECT d.xml_id, r.id, r.pattern, t.name, COALESCE(dr.set_number, 0)
+FROM deliveryservice_regex dr
+JOIN deliveryservice d ON d.id = dr.deliveryservice
+JOIN regex r ON r.id = dr.regex
+JOIN type t ON t.id = r.type
+JOIN cdn c ON c.id = d.cdn_id
+WHERE c.name = $1
+AND d.active = true
+ORDER BY r.id
+`
+	rows, err := db.Query(q, cdn)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	regexes := map[string][]dsRegex{}
+	for rows.Next() {
+		xmlID := ""
+		regex := dsRegex{}
+		if err := rows.Scan(&xmlID, &regex.id, &regex.pattern, &regex.regexType, &regex.setNumber); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		regexes[xmlID] = append(regexes[xmlID], regex)
+	}
+	return regexes, nil
+}
+
+// getDSServers returns the IDs of the servers assigned to each active delivery service in the CDN, by xml_id.
+func getDSServers(db *sql.DB, cdn string) (map[string][]int, error) {
+	q := `
+SELECT d.xml_id, dss.server
+FROM deliveryservice_server dss
+JOIN deliveryservice d ON d.id = dss.deliveryservice
+JOIN cdn c ON c.id = d.cdn_id
+WHERE c.name = $1
+AND d.active = true
+ORDER BY dss.server
+`
+	rows, err := db.Query(q, cdn)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	dsServers := map[string][]int{}
+	for rows.Next() {
+		xmlID := ""
+		serverID := 0
+		if err := rows.Scan(&xmlID, &serverID); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		dsServers[xmlID] = append(dsServers[xmlID], serverID)
+	}
+	return dsServers, nil
+}
+
+// getStaticDNSEntries returns the static DNS entries of each active delivery service in the CDN, by xml_id.
+func getStaticDNSEntries(db *sql.DB, cdn string) (map[string][]tc.CRConfigStaticDNSEntry, error) {
+	q := `
+SELECT d.xml_id, sde.host, sde.ttl, sde.address, t.name
+FROM staticdnsentry sde
+JOIN type t ON t.id = sde.type
+JOIN deliveryservice d ON d.id = sde.deliveryservice
+JOIN cdn c ON c.id = d.cdn_id
+WHERE c.name = $1
+AND d.active = true
+ORDER BY sde.id
+`
+	rows, err := db.Query(q, cdn)
+	if err != nil {
+		return nil, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	entries := map[string][]tc.CRConfigStaticDNSEntry{}
+	for rows.Next() {
+		xmlID := ""
+		entry := tc.CRConfigStaticDNSEntry{}
+		if err := rows.Scan(&xmlID, &entry.Name, &entry.TTL, &entry.Value, &entry.Type); err != nil {
+			return nil, errors.New("scanning: " + err.Error())
+		}
+		entry.Type = strings.Replace(entry.Type, "_RECORD", "", -1)
+		entries[xmlID] = append(entries[xmlID], entry)
+	}
+	return entries, nil
+}
+
+func nonEmpty(s sql.NullString) *string {
+	if s.String == "" {
+		return nil
+	}
+	return &s.String
+}
+
+func paramPtr(params map[string]string, name string) *string {
+	val, ok := params[name]
+	if !ok {
+		return nil
+	}
+	return &val
+}
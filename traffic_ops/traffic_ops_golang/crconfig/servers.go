@@ -0,0 +1,189 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+const RouterType = "CCR"
+const MonitorType = "RASCAL"
+
+const DefaultWeight = 0.999
+const DefaultWeightMultiplier = 1000
+const DefaultRouterAPIPort = "80"
+
+// servers is the server sections of the CRConfig, and the data needed to assign delivery services to the content servers.
+type servers struct {
+	contentServers map[string]tc.CRConfigTrafficOpsServer
+	contentRouters map[string]tc.CRConfigRouter
+	monitors       map[string]tc.CRConfigMonitor
+	edgeLocations  map[string]tc.CRConfigLatitudeLongitude
+	// cacheNames is the host name of each EDGE and MID server, by server ID.
+	cacheNames map[int]string
+}
+
+// makeServers creates the contentServers, contentRouters, monitors and edgeLocations sections from the ONLINE, REPORTED and ADMIN_DOWN servers of the given CDN.
+func makeServers(db *sql.DB, cdn string, params profileParams) (servers, error) {
+	q := `
+SELECT s.id, s.host_name, CONCAT(s.host_name, '.', s.domain_name), s.tcp_port, s.https_port, s.interface_name, s.ip_address, COALESCE(s.ip6_address, ''), s.xmpp_id,
+p.id, p.name, p.routing_disabled, t.name, st.name, cg.name, cg.latitude, cg.longitude,
+(SELECT pa.value FROM parameter pa JOIN profile_parameter pp ON pp.parameter = pa.id WHERE pp.profile = s.profile AND pa.name = 'api.port' LIMIT 1)
+FROM server s
+JOIN type t ON t.id = s.type
+JOIN status st ON st.id = s.status
+JOIN cachegroup cg ON cg.id = s.cachegroup
+JOIN profile p ON p.id = s.profile
+JOIN cdn c ON c.id = s.cdn_id
+WHERE c.name = $1
+AND (t.name LIKE 'EDGE%' OR t.name LIKE 'MID%' OR t.name IN ('CCR', 'RASCAL', 'TR', 'TM'))
+AND st.name IN ('ONLINE', 'REPORTED', 'ADMIN_DOWN')
+`
+	rows, err := db.Query(q, cdn)
+	if err != nil {
+		return servers{}, errors.New("querying: " + err.Error())
+	}
+	defer rows.Close()
+
+	s := servers{
+		contentServers: map[string]tc.CRConfigTrafficOpsServer{},
+		contentRouters: map[string]tc.CRConfigRouter{},
+		monitors:       map[string]tc.CRConfigMonitor{},
+		edgeLocations:  map[string]tc.CRConfigLatitudeLongitude{},
+		cacheNames:     map[int]string{},
+	}
+	for rows.Next() {
+		id := 0
+		hostName := ""
+		fqdn := ""
+		port := sql.NullInt64{}
+		httpsPort := sql.NullInt64{}
+		interfaceName := ""
+		ip := ""
+		ip6 := ""
+		hashID := sql.NullString{}
+		profileID := 0
+		profile := ""
+		routingDisabled := false
+		serverType := ""
+		status := ""
+		cachegroup := ""
+		lat := sql.NullFloat64{}
+		lon := sql.NullFloat64{}
+		apiPort := sql.NullString{}
+		if err := rows.Scan(&id, &hostName, &fqdn, &port, &httpsPort, &interfaceName, &ip, &ip6, &hashID, &profileID, &profile, &routingDisabled, &serverType, &status, &cachegroup, &lat, &lon, &apiPort); err != nil {
+			return servers{}, errors.New("scanning: " + err.Error())
+		}
+
+		switch {
+		case serverType == MonitorType:
+			monitorStatus := tc.CRConfigServerStatus(status)
+			s.monitors[hostName] = tc.CRConfigMonitor{
+				FQDN:         &fqdn,
+				HTTPSPort:    intPtr(httpsPort),
+				IP:           &ip,
+				IP6:          &ip6,
+				Location:     &cachegroup,
+				Port:         intPtr(port),
+				Profile:      &profile,
+				ServerStatus: &monitorStatus,
+			}
+		case serverType == RouterType:
+			routerStatus := tc.CRConfigRouterStatus(status)
+			routerAPIPort := DefaultRouterAPIPort
+			if apiPort.Valid {
+				routerAPIPort = apiPort.String
+			}
+			s.contentRouters[hostName] = tc.CRConfigRouter{
+				APIPort:      &routerAPIPort,
+				FQDN:         &fqdn,
+				HTTPSPort:    intPtr(httpsPort),
+				IP:           &ip,
+				IP6:          &ip6,
+				Location:     &cachegroup,
+				Port:         intPtr(port),
+				Profile:      &profile,
+				ServerStatus: &routerStatus,
+			}
+		case strings.HasPrefix(serverType, "EDGE") || strings.HasPrefix(serverType, "MID"):
+			if strings.HasPrefix(serverType, "EDGE") {
+				s.edgeLocations[cachegroup] = tc.CRConfigLatitudeLongitude{Lat: lat.Float64, Lon: lon.Float64}
+			}
+			s.cacheNames[id] = hostName
+
+			cacheStatus := tc.CRConfigServerStatus(status)
+			hashCount := hashCount(params.byProfile[profileID])
+			cacheType := serverType
+			server := tc.CRConfigTrafficOpsServer{
+				CacheGroup:      &cachegroup,
+				Fqdn:            &fqdn,
+				HashCount:       &hashCount,
+				HttpsPort:       intPtr(httpsPort),
+				InterfaceName:   &interfaceName,
+				Ip:              &ip,
+				Ip6:             &ip6,
+				LocationId:      &cachegroup,
+				Port:            intPtr(port),
+				Profile:         &profile,
+				ServerStatus:    &cacheStatus,
+				ServerType:      &cacheType,
+				RoutingDisabled: 0,
+			}
+			if hashID.Valid {
+				server.HashId = &hashID.String
+			}
+			if routingDisabled {
+				server.RoutingDisabled = 1
+			}
+			s.contentServers[hostName] = server
+		}
+	}
+	return s, nil
+}
+
+// hashCount returns the consistent hash ring weight of a cache with the given profile parameters.
+func hashCount(params map[string]string) int {
+	weight := DefaultWeight
+	if val, ok := params["weight"]; ok {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			weight = f
+		}
+	}
+	multiplier := float64(DefaultWeightMultiplier)
+	if val, ok := params["weightMultiplier"]; ok {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			multiplier = f
+		}
+	}
+	return int(weight * multiplier)
+}
+
+func intPtr(i sql.NullInt64) *int {
+	if !i.Valid {
+		return nil
+	}
+	v := int(i.Int64)
+	return &v
+}
@@ -0,0 +1,115 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// Snapshot stores the CRConfig as a new snapshot version of its CDN. The latest version is the current snapshot, which Traffic Router and Traffic Monitor poll; older versions are kept to diff against. All but the latest maxVersions versions of the CDN are deleted, in the same transaction.
+func Snapshot(db *sql.DB, crc *tc.CRConfig, maxVersions int) error {
+	if crc.Stats.CDNName == nil {
+		return errors.New("CRConfig has no CDN name")
+	}
+	if maxVersions < 1 {
+		return errors.New("max snapshot versions must be at least 1")
+	}
+	bts, err := json.Marshal(crc)
+	if err != nil {
+		return errors.New("marshalling JSON: " + err.Error())
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.New("beginning transaction: " + err.Error())
+	}
+	commit := false
+	defer func() {
+		if !commit {
+			tx.Rollback()
+		}
+	}()
+
+	cdn := *crc.Stats.CDNName
+	if _, err := tx.Exec(`INSERT INTO snapshot (cdn, content) VALUES ($1, $2)`, cdn, bts); err != nil {
+		return errors.New("inserting snapshot: " + err.Error())
+	}
+	q := `DELETE FROM snapshot WHERE cdn = $1 AND id NOT IN (SELECT id FROM snapshot WHERE cdn = $1 ORDER BY id DESC LIMIT $2)`
+	if _, err := tx.Exec(q, cdn, maxVersions); err != nil {
+		return errors.New("deleting old snapshot versions: " + err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.New("committing transaction: " + err.Error())
+	}
+	commit = true
+	return nil
+}
+
+// GetSnapshot returns the current snapshot JSON of the given CDN, its version, and whether a snapshot exists.
+func GetSnapshot(db *sql.DB, cdn string) ([]byte, int64, bool, error) {
+	return getSnapshot(db, `SELECT content, id FROM snapshot WHERE cdn = $1 ORDER BY id DESC LIMIT 1`, cdn)
+}
+
+// GetSnapshotVersion returns the snapshot JSON of the given CDN with the given version, the version, and whether it exists.
+func GetSnapshotVersion(db *sql.DB, cdn string, version int64) ([]byte, int64, bool, error) {
+	return getSnapshot(db, `SELECT content, id FROM snapshot WHERE cdn = $1 AND id = $2`, cdn, version)
+}
+
+func getSnapshot(db *sql.DB, q string, args ...interface{}) ([]byte, int64, bool, error) {
+	snapshot := []byte{}
+	version := int64(0)
+	if err := db.QueryRow(q, args...).Scan(&snapshot, &version); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, false, nil
+		}
+		return nil, 0, false, errors.New("querying snapshot: " + err.Error())
+	}
+	return snapshot, version, true, nil
+}
+
+// GetSnapshotVersions returns the snapshot versions of the given CDN, newest first.
+func GetSnapshotVersions(db *sql.DB, cdn string) ([]tc.CRConfigSnapshotVersion, error) {
+	q := `SELECT id, CAST(content->'stats'->>'date' AS bigint), last_updated FROM snapshot WHERE cdn = $1 ORDER BY id DESC`
+	rows, err := db.Query(q, cdn)
+	if err != nil {
+		return nil, errors.New("querying snapshot versions: " + err.Error())
+	}
+	defer rows.Close()
+	versions := []tc.CRConfigSnapshotVersion{}
+	for rows.Next() {
+		v := tc.CRConfigSnapshotVersion{}
+		date := sql.NullInt64{}
+		if err := rows.Scan(&v.Version, &date, &v.LastUpdated); err != nil {
+			return nil, errors.New("scanning snapshot versions: " + err.Error())
+		}
+		if date.Valid {
+			v.Date = &date.Int64
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("iterating snapshot versions: " + err.Error())
+	}
+	return versions, nil
+}
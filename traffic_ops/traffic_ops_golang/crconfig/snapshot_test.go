@@ -0,0 +1,126 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestSnapshotInsertsVersion(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	cdn := "cdn1"
+	mock.ExpectBegin()
+	mock.ExpectExec(`^INSERT INTO snapshot \(cdn, content\) VALUES \(\$1, \$2\)$`).WithArgs(cdn, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(`^DELETE FROM snapshot WHERE cdn = \$1 AND id NOT IN \(SELECT id FROM snapshot WHERE cdn = \$1 ORDER BY id DESC LIMIT \$2\)$`).WithArgs(cdn, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := Snapshot(mockDB, &tc.CRConfig{Stats: tc.CRConfigStats{CDNName: &cdn}}, 5); err != nil {
+		t.Fatalf("Snapshot expected: nil error, actual: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Snapshot expected: a new row and old versions deleted, actual: %v", err)
+	}
+}
+
+func TestSnapshotRollsBackInsertIfPruneFails(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	cdn := "cdn1"
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO snapshot").WithArgs(cdn, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("DELETE FROM snapshot").WithArgs(cdn, 5).WillReturnError(errors.New("canceled"))
+	mock.ExpectRollback()
+	if err := Snapshot(mockDB, &tc.CRConfig{Stats: tc.CRConfigStats{CDNName: &cdn}}, 5); err == nil {
+		t.Errorf("Snapshot expected: error, actual: nil")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Snapshot expected: rollback, actual: %v", err)
+	}
+}
+
+func TestGetSnapshot(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	mock.ExpectQuery("SELECT content, id FROM snapshot WHERE cdn = .* ORDER BY id DESC LIMIT 1").WithArgs("cdn1").WillReturnRows(
+		sqlmock.NewRows([]string{"content", "id"}).AddRow([]byte(`{"stats":{}}`), 3))
+	mock.ExpectQuery("SELECT content, id FROM snapshot WHERE cdn = .* AND id = ").WithArgs("cdn1", 1).WillReturnRows(
+		sqlmock.NewRows([]string{"content", "id"}))
+
+	snapshot, version, ok, err := GetSnapshot(mockDB, "cdn1")
+	if err != nil || !ok {
+		t.Fatalf("GetSnapshot expected: snapshot, actual: %v %v", ok, err)
+	}
+	if string(snapshot) != `{"stats":{}}` || version != 3 {
+		t.Errorf("GetSnapshot expected: version 3, actual: %v %s", version, snapshot)
+	}
+
+	if _, _, ok, err := GetSnapshotVersion(mockDB, "cdn1", 1); err != nil || ok {
+		t.Errorf("GetSnapshotVersion expected: not found, actual: %v %v", ok, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("GetSnapshot expected: all queries, actual: %v", err)
+	}
+}
+
+func TestGetSnapshotVersions(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	updated := time.Date(2017, 11, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT id, .* FROM snapshot WHERE cdn = .* ORDER BY id DESC").WithArgs("cdn1").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "date", "last_updated"}).
+			AddRow(3, 1500000100, updated).
+			AddRow(1, nil, updated))
+
+	versions, err := GetSnapshotVersions(mockDB, "cdn1")
+	if err != nil {
+		t.Fatalf("GetSnapshotVersions expected: nil error, actual: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("GetSnapshotVersions expected: 2 versions, actual: %v", len(versions))
+	}
+	if versions[0].Version != 3 || versions[0].Date == nil || *versions[0].Date != 1500000100 || !versions[0].LastUpdated.Time.Equal(updated) {
+		t.Errorf("GetSnapshotVersions expected: version 3 dated 1500000100, actual: %+v", versions[0])
+	}
+	if versions[1].Version != 1 || versions[1].Date != nil {
+		t.Errorf("GetSnapshotVersions expected: version 1 without date, actual: %+v", versions[1])
+	}
+}
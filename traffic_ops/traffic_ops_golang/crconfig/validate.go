@@ -0,0 +1,75 @@
+package crconfig
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+)
+
+// Validate returns an error describing every problem which would make Traffic Router or Traffic Monitor reject the CRConfig, or nil if it's valid. A CRConfig should not be snapshotted unless it's valid.
+func Validate(crc *tc.CRConfig) error {
+	errs := []string{}
+	if crc.Stats.CDNName == nil || *crc.Stats.CDNName == "" {
+		errs = append(errs, "stats has no CDN name")
+	}
+	if crc.Config.DomainName == nil || *crc.Config.DomainName == "" {
+		errs = append(errs, "config has no domain_name")
+	}
+
+	edges := 0
+	for name, server := range crc.ContentServers {
+		if server.ServerType != nil && strings.HasPrefix(*server.ServerType, "EDGE") {
+			edges++
+		}
+		if server.Ip == nil || *server.Ip == "" {
+			errs = append(errs, "content server "+name+" has no ip")
+		}
+		for ds := range server.DeliveryServices {
+			if _, ok := crc.DeliveryServices[ds]; !ok {
+				errs = append(errs, "content server "+name+" has delivery service "+ds+" which isn't in deliveryServices")
+			}
+		}
+	}
+	if edges == 0 {
+		errs = append(errs, "no EDGE content servers")
+	}
+
+	for name, ds := range crc.DeliveryServices {
+		matches := 0
+		for _, matchSet := range ds.MatchSets {
+			if matchSet != nil {
+				matches += len(matchSet.MatchList)
+			}
+		}
+		if matches == 0 {
+			errs = append(errs, "delivery service "+name+" has no regexes")
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.Strings(errs) // map iteration is random, but the error shouldn't be
+	return errors.New("invalid CRConfig: " + strings.Join(errs, "; "))
+}
@@ -0,0 +1,832 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/basho/riak-go-client"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/miekg/dns"
+)
+
+// DNSSECKeysPrivLevel is the privilege level to get, generate, delete and refresh DNSSEC keys.
+const DNSSECKeysPrivLevel = auth.PrivLevelAdmin
+
+// DefaultDNSKEYTTL is the TTL of DNSKEY records in seconds, if the CDN's Traffic Router profile has no tld.ttls.DNSKEY parameter.
+const DefaultDNSKEYTTL = 60
+
+// DefaultDNSKEYGenerationMultiplier is how many DNSKEY TTLs before a key expires its replacement is generated, if the CDN's Traffic Router profile has no DNSKEY.generation.multiplier parameter.
+const DefaultDNSKEYGenerationMultiplier = 10
+
+// DefaultDNSKEYEffectiveMultiplier is how many DNSKEY TTLs before a key expires its replacement becomes effective, if the CDN's Traffic Router profile has no DNSKEY.effective.multiplier parameter.
+const DefaultDNSKEYEffectiveMultiplier = 10
+
+// DefaultKSKExpirationDays and DefaultZSKExpirationDays are the lifetimes of the keys generated for new delivery services by a refresh, if the CDN zone has no keys to copy them from.
+const DefaultKSKExpirationDays = 365
+const DefaultZSKExpirationDays = 30
+
+// DefaultDNSSECAlgorithm is the algorithm of generated keys, if the request has none, which is the algorithm the Perl Traffic Ops generated.
+const DefaultDNSSECAlgorithm = dns.RSASHA1
+
+// DefaultKSKBits and DefaultZSKBits are the sizes of generated RSA keys, if the request has none.
+const DefaultKSKBits = 2048
+const DefaultZSKBits = 1024
+
+const secondsPerDay = 24 * 60 * 60
+
+const dnskeyFlagsZSK = 256
+const dnskeyFlagsKSK = 257
+const dnskeyProtocol = 3
+
+// dnssecRefreshMutex keeps a scheduled refresh and a requested refresh from rolling over the same keys at once.
+var dnssecRefreshMutex = sync.Mutex{}
+
+// dnssecKeyType is the algorithm and sizes in bits of the keys of a zone.
+type dnssecKeyType struct {
+	Algorithm uint8
+	KSKBits   int
+	ZSKBits   int
+}
+
+// dnssecDS is a delivery service which gets DNSSEC keys, with the host regex which names its zone.
+type dnssecDS struct {
+	XMLID     string
+	HostRegex string
+	SetNumber int
+}
+
+// dnssecCDN is a CDN with DNSSEC enabled.
+type dnssecCDN struct {
+	Name   string
+	Domain string
+}
+
+// dnssecRefreshParams is the parameters of a CDN's Traffic Router profile which control key rollovers. TTL is in seconds.
+type dnssecRefreshParams struct {
+	TTL                  int64
+	GenerationMultiplier int64
+	EffectiveMultiplier  int64
+}
+
+// cdnDNSSECKeysHandler returns a handler which serves the DNSSEC keys of the CDN in the path, or an empty object if it has none.
+func cdnDNSSECKeysHandler(db *sqlx.DB, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		if !cfg.RiakEnabled {
+			handleErr(errors.New("The RIAK service is unavailable"), http.StatusServiceUnavailable)
+			return
+		}
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		keys := tc.DNSSECKeys{}
+		err = withRiakCluster(db, cfg, func(cluster StorageCluster) error {
+			cdnKeys, ok, err := fetchDNSSECKeys(cluster, pathParams["name"])
+			if ok {
+				keys = cdnKeys
+			}
+			return err
+		})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.DNSSECKeysResponse{Response: keys})
+	}
+}
+
+// generateDNSSECKeysHandler returns a handler which generates new DNSSEC keys for a CDN and its delivery services. The current keys are kept until the new keys are effective.
+func generateDNSSECKeysHandler(db *sqlx.DB, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		if !cfg.RiakEnabled {
+			handleErr(errors.New("The RIAK service is unavailable"), http.StatusServiceUnavailable)
+			return
+		}
+
+		req := tc.DNSSECKeysGenerateRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		now := time.Now()
+		if req.EffectiveDateUnix == 0 {
+			req.EffectiveDateUnix = now.Unix()
+		}
+		if err := validateDNSSECKeysGenerateRequest(req); err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+		kt, err := getDNSSECKeyType(req.Algorithm, req.KSKBits, req.ZSKBits)
+		if err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+
+		domain, ok, err := getCDNDomain(db.DB, req.Key)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("cdn not found"), http.StatusNotFound)
+			return
+		}
+		if req.Name == "" {
+			req.Name = domain
+		}
+		dses, err := getDNSSECDSes(db.DB, req.Key)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		err = withRiakCluster(db, cfg, func(cluster StorageCluster) error {
+			oldKeys, _, err := fetchDNSSECKeys(cluster, req.Key)
+			if err != nil {
+				return err
+			}
+			keys, err := generateCDNDNSSECKeys(req, domain, dses, kt, oldKeys, now)
+			if err != nil {
+				return err
+			}
+			return storeDNSSECKeys(cluster, req.Key, keys)
+		})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		logRequestChange(db.DB, r, "Generated DNSSEC keys for CDN "+req.Key)
+		msg := "Successfully created dnssec keys for " + req.Key
+		writeJSONResp(w, handleErr, struct {
+			Response string `json:"response"`
+			tc.Alerts
+		}{msg, tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+// deleteDNSSECKeysHandler returns a handler which deletes the DNSSEC keys of the CDN in the path.
+func deleteDNSSECKeysHandler(db *sqlx.DB, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		if !cfg.RiakEnabled {
+			handleErr(errors.New("The RIAK service is unavailable"), http.StatusServiceUnavailable)
+			return
+		}
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		cdn := pathParams["name"]
+		err = withRiakCluster(db, cfg, func(cluster StorageCluster) error {
+			return deleteObject(cdn, DNSSECKeysBucket, cluster)
+		})
+		if err != nil {
+			handleErr(errors.New("deleting DNSSEC keys: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		logRequestChange(db.DB, r, "Deleted DNSSEC keys for CDN "+cdn)
+		msg := "Successfully deleted dnssec keys for " + cdn
+		writeJSONResp(w, handleErr, struct {
+			Response string `json:"response"`
+			tc.Alerts
+		}{msg, tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+// dnssecDSRecordsHandler returns a handler which serves the DS records of the unexpired key signing keys of the CDN in the path, for its parent zone. During a rollover, both the new and the old key have records.
+func dnssecDSRecordsHandler(db *sqlx.DB, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		if !cfg.RiakEnabled {
+			handleErr(errors.New("The RIAK service is unavailable"), http.StatusServiceUnavailable)
+			return
+		}
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		cdn := pathParams["name"]
+		keys := tc.DNSSECKeys{}
+		ok := false
+		err = withRiakCluster(db, cfg, func(cluster StorageCluster) error {
+			err := error(nil)
+			keys, ok, err = fetchDNSSECKeys(cluster, cdn)
+			return err
+		})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if _, hasCDNKeys := keys[cdn]; !ok || !hasCDNKeys {
+			handleErr(errors.New("no DNSSEC keys for cdn "+cdn), http.StatusNotFound)
+			return
+		}
+
+		records, err := getDNSSECDSRecords(keys[cdn], time.Now())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.DNSSECDSRecordsResponse{Response: records})
+	}
+}
+
+// refreshDNSSECKeysHandler returns a handler which refreshes the DNSSEC keys of every CDN with DNSSEC enabled in the background, as refreshAllDNSSECKeys.
+func refreshDNSSECKeysHandler(db *sqlx.DB, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		if !cfg.RiakEnabled {
+			handleErr(errors.New("The RIAK service is unavailable"), http.StatusServiceUnavailable)
+			return
+		}
+		go refreshAllDNSSECKeys(db, cfg)
+		writeJSONResp(w, handleErr, struct {
+			Response string `json:"response"`
+		}{"Checking DNSSEC keys for refresh in the background"})
+	}
+}
+
+// refreshDNSSECKeysEvery refreshes the DNSSEC keys of every CDN with DNSSEC enabled every interval, so keys are rolled over without anything requesting the refresh route. It never returns.
+func refreshDNSSECKeysEvery(db *sqlx.DB, cfg Config, interval time.Duration) {
+	for range time.Tick(interval) {
+		refreshAllDNSSECKeys(db, cfg)
+	}
+}
+
+// refreshAllDNSSECKeys rolls over the DNSSEC keys about to expire, and generates keys for new delivery services, of every CDN with DNSSEC enabled. Errors are logged, and don't stop other CDNs from being refreshed.
+func refreshAllDNSSECKeys(db *sqlx.DB, cfg Config) {
+	dnssecRefreshMutex.Lock()
+	defer dnssecRefreshMutex.Unlock()
+
+	log.Debugln("Starting refresh of DNSSEC keys")
+	cdns, err := getDNSSECCDNs(db.DB)
+	if err != nil {
+		log.Errorf("refreshing DNSSEC keys: %v\n", err)
+		return
+	}
+	if len(cdns) == 0 {
+		return
+	}
+	err = withRiakCluster(db, cfg, func(cluster StorageCluster) error {
+		for _, cdn := range cdns {
+			if err := refreshCDNDNSSECKeys(db.DB, cluster, cdn, time.Now()); err != nil {
+				log.Errorf("refreshing DNSSEC keys of CDN %s: %v\n", cdn.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Errorf("refreshing DNSSEC keys: %v\n", err)
+		return
+	}
+	log.Debugln("Done refreshing DNSSEC keys")
+}
+
+// refreshCDNDNSSECKeys refreshes the DNSSEC keys of the CDN in Riak, as refreshDNSSECKeys, and stores them if they changed.
+func refreshCDNDNSSECKeys(db *sql.DB, cluster StorageCluster, cdn dnssecCDN, now time.Time) error {
+	keys, ok, err := fetchDNSSECKeys(cluster, cdn.Name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.Warnf("refreshing DNSSEC keys: CDN %s has DNSSEC enabled, but no keys\n", cdn.Name)
+		return nil
+	}
+	params, err := getDNSSECRefreshParams(db, cdn.Name)
+	if err != nil {
+		return err
+	}
+	dses, err := getDNSSECDSes(db, cdn.Name)
+	if err != nil {
+		return err
+	}
+	updated, err := refreshDNSSECKeys(keys, cdn.Name, cdn.Domain, dses, params, now)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return nil
+	}
+	log.Infof("refreshed DNSSEC keys of CDN %s\n", cdn.Name)
+	return storeDNSSECKeys(cluster, cdn.Name, keys)
+}
+
+func validateDNSSECKeysGenerateRequest(req tc.DNSSECKeysGenerateRequest) error {
+	if req.Key == "" {
+		return errors.New("key is required")
+	}
+	if req.TTL <= 0 {
+		return errors.New("ttl must be a positive integer")
+	}
+	if req.KSKExpirationDays <= 0 {
+		return errors.New("kskExpirationDays must be a positive integer")
+	}
+	if req.ZSKExpirationDays <= 0 {
+		return errors.New("zskExpirationDays must be a positive integer")
+	}
+	if req.EffectiveDateUnix < 0 {
+		return errors.New("effectiveDate must be a Unix timestamp")
+	}
+	return nil
+}
+
+// getDNSSECKeyType returns the key type of the given algorithm mnemonic and sizes, defaulting to DefaultDNSSECAlgorithm and the default sizes. Sizes are ignored for ECDSA, whose sizes are fixed by the curve.
+func getDNSSECKeyType(algorithm string, kskBits int, zskBits int) (dnssecKeyType, error) {
+	kt := dnssecKeyType{Algorithm: DefaultDNSSECAlgorithm, KSKBits: kskBits, ZSKBits: zskBits}
+	if algorithm != "" {
+		alg, ok := dns.StringToAlgorithm[strings.ToUpper(algorithm)]
+		if !ok {
+			return kt, errors.New("unknown algorithm '" + algorithm + "'")
+		}
+		kt.Algorithm = alg
+	}
+	switch kt.Algorithm {
+	case dns.RSASHA1, dns.RSASHA1NSEC3SHA1, dns.RSASHA256, dns.RSASHA512:
+		if kt.KSKBits == 0 {
+			kt.KSKBits = DefaultKSKBits
+		}
+		if kt.ZSKBits == 0 {
+			kt.ZSKBits = DefaultZSKBits
+		}
+		if kt.KSKBits < 1024 || kt.KSKBits > 4096 || kt.ZSKBits < 1024 || kt.ZSKBits > 4096 {
+			return kt, errors.New("RSA key sizes must be between 1024 and 4096 bits")
+		}
+	case dns.ECDSAP256SHA256:
+		kt.KSKBits, kt.ZSKBits = 256, 256
+	case dns.ECDSAP384SHA384:
+		kt.KSKBits, kt.ZSKBits = 384, 384
+	default:
+		return kt, errors.New("unsupported algorithm '" + dns.AlgorithmToString[kt.Algorithm] + "'")
+	}
+	return kt, nil
+}
+
+// generateCDNDNSSECKeys generates new keys for the CDN zone and the zone of each of the delivery services, effective at the request's effective date. The new keys of each zone in oldKeys are kept as existing keys which expire when the generated keys become effective, so Traffic Router signs with both until then.
+func generateCDNDNSSECKeys(req tc.DNSSECKeysGenerateRequest, cdnDomain string, dses []dnssecDS, kt dnssecKeyType, oldKeys tc.DNSSECKeys, now time.Time) (tc.DNSSECKeys, error) {
+	kskLifetime := req.KSKExpirationDays * secondsPerDay
+	zskLifetime := req.ZSKExpirationDays * secondsPerDay
+	keys := tc.DNSSECKeys{}
+	set, err := generateDNSSECKeySet(req.Name, req.TTL, kt, kskLifetime, zskLifetime, req.EffectiveDateUnix, now, true)
+	if err != nil {
+		return nil, errors.New("generating keys for CDN " + req.Key + ": " + err.Error())
+	}
+	keys[req.Key] = keepExistingDNSSECKeys(set, oldKeys[req.Key], req.EffectiveDateUnix)
+	for _, ds := range dses {
+		set, err := generateDNSSECKeySet(dnssecDSZone(ds, cdnDomain), req.TTL, kt, kskLifetime, zskLifetime, req.EffectiveDateUnix, now, false)
+		if err != nil {
+			return nil, errors.New("generating keys for delivery service " + ds.XMLID + ": " + err.Error())
+		}
+		keys[ds.XMLID] = keepExistingDNSSECKeys(set, oldKeys[ds.XMLID], req.EffectiveDateUnix)
+	}
+	return keys, nil
+}
+
+// keepExistingDNSSECKeys appends the new keys of old to set, as existing keys which expire at effective.
+func keepExistingDNSSECKeys(set tc.DNSSECKeySet, old tc.DNSSECKeySet, effective int64) tc.DNSSECKeySet {
+	if key, ok := newDNSSECKey(old.ZSK); ok {
+		key.Status = tc.DNSSECKeyStatusExisting
+		key.ExpirationDateUnix = effective
+		set.ZSK = append(set.ZSK, key)
+	}
+	if key, ok := newDNSSECKey(old.KSK); ok {
+		key.Status = tc.DNSSECKeyStatusExisting
+		key.ExpirationDateUnix = effective
+		set.KSK = append(set.KSK, key)
+	}
+	return set
+}
+
+// refreshDNSSECKeys rolls over every new key in keys which expires within the generation period of params, and generates keys for the delivery services without any, with the lifetimes, algorithm and sizes of the CDN zone's keys. Keys are changed in place. Returns whether any keys changed.
+func refreshDNSSECKeys(keys tc.DNSSECKeys, cdn string, cdnDomain string, dses []dnssecDS, params dnssecRefreshParams, now time.Time) (bool, error) {
+	cdnKeys := keys[cdn]
+	kt, err := getDNSSECKeySetType(cdnKeys)
+	if err != nil {
+		return false, errors.New("getting key type of CDN " + cdn + ": " + err.Error())
+	}
+	kskLifetime := getDNSSECKeyLifetime(cdnKeys.KSK, DefaultKSKExpirationDays*secondsPerDay)
+	zskLifetime := getDNSSECKeyLifetime(cdnKeys.ZSK, DefaultZSKExpirationDays*secondsPerDay)
+
+	updated := false
+	if set, rolled, err := rolloverDNSSECKeySet(cdnKeys, true, params, now); err != nil {
+		return false, errors.New("rolling over keys of CDN " + cdn + ": " + err.Error())
+	} else if rolled {
+		keys[cdn] = set
+		updated = true
+	}
+
+	for _, ds := range dses {
+		dsKeys, ok := keys[ds.XMLID]
+		if !ok {
+			set, err := generateDNSSECKeySet(dnssecDSZone(ds, cdnDomain), params.TTL, kt, kskLifetime, zskLifetime, now.Unix(), now, false)
+			if err != nil {
+				return false, errors.New("generating keys for delivery service " + ds.XMLID + ": " + err.Error())
+			}
+			keys[ds.XMLID] = set
+			updated = true
+			continue
+		}
+		set, rolled, err := rolloverDNSSECKeySet(dsKeys, false, params, now)
+		if err != nil {
+			return false, errors.New("rolling over keys of delivery service " + ds.XMLID + ": " + err.Error())
+		}
+		if rolled {
+			keys[ds.XMLID] = set
+			updated = true
+		}
+	}
+	return updated, nil
+}
+
+// rolloverDNSSECKeySet rolls over the zone signing and key signing keys of set, as rolloverDNSSECKeys. If tld, the zone is the CDN zone, and new key signing keys get a DS record for the parent zone.
+func rolloverDNSSECKeySet(set tc.DNSSECKeySet, tld bool, params dnssecRefreshParams, now time.Time) (tc.DNSSECKeySet, bool, error) {
+	zsks, zskRolled, err := rolloverDNSSECKeys(set.ZSK, false, false, params, now)
+	if err != nil {
+		return set, false, errors.New("ZSK: " + err.Error())
+	}
+	ksks, kskRolled, err := rolloverDNSSECKeys(set.KSK, true, tld, params, now)
+	if err != nil {
+		return set, false, errors.New("KSK: " + err.Error())
+	}
+	return tc.DNSSECKeySet{ZSK: zsks, KSK: ksks}, zskRolled || kskRolled, nil
+}
+
+// rolloverDNSSECKeys replaces the new key of keys, if it expires within the generation period of params, with a key of the same lifetime, algorithm and size. The replacement becomes effective the effective period before the old key expires, and the old key is kept as expired, so both are published during the overlap. Returns the keys unchanged and false if there's nothing to roll over.
+func rolloverDNSSECKeys(keys []tc.DNSSECKey, ksk bool, withDSRecord bool, params dnssecRefreshParams, now time.Time) ([]tc.DNSSECKey, bool, error) {
+	old, ok := newDNSSECKey(keys)
+	if !ok || old.ExpirationDateUnix >= now.Unix()+params.TTL*params.GenerationMultiplier {
+		return keys, false, nil
+	}
+	alg, bits, err := getDNSSECKeyAlgorithm(old)
+	if err != nil {
+		return keys, false, err
+	}
+	key, err := generateDNSSECKey(old.Name, old.TTL, ksk, alg, bits, withDSRecord)
+	if err != nil {
+		return keys, false, err
+	}
+	key.InceptionDateUnix = now.Unix()
+	key.ExpirationDateUnix = now.Unix() + old.ExpirationDateUnix - old.InceptionDateUnix
+	key.EffectiveDateUnix = old.ExpirationDateUnix - params.TTL*params.EffectiveMultiplier
+	old.Status = tc.DNSSECKeyStatusExpired
+	return []tc.DNSSECKey{key, old}, true, nil
+}
+
+// generateDNSSECKeySet generates a zone signing and key signing key for the zone name, with the given lifetimes in seconds from now, effective at effective. If tld, the zone is the CDN zone, and the key signing key gets a DS record for the parent zone.
+func generateDNSSECKeySet(name string, ttl int64, kt dnssecKeyType, kskLifetime int64, zskLifetime int64, effective int64, now time.Time, tld bool) (tc.DNSSECKeySet, error) {
+	zsk, err := generateDNSSECKey(name, ttl, false, kt.Algorithm, kt.ZSKBits, false)
+	if err != nil {
+		return tc.DNSSECKeySet{}, errors.New("ZSK: " + err.Error())
+	}
+	zsk.InceptionDateUnix = now.Unix()
+	zsk.ExpirationDateUnix = now.Unix() + zskLifetime
+	zsk.EffectiveDateUnix = effective
+
+	ksk, err := generateDNSSECKey(name, ttl, true, kt.Algorithm, kt.KSKBits, tld)
+	if err != nil {
+		return tc.DNSSECKeySet{}, errors.New("KSK: " + err.Error())
+	}
+	ksk.InceptionDateUnix = now.Unix()
+	ksk.ExpirationDateUnix = now.Unix() + kskLifetime
+	ksk.EffectiveDateUnix = effective
+	return tc.DNSSECKeySet{ZSK: []tc.DNSSECKey{zsk}, KSK: []tc.DNSSECKey{ksk}}, nil
+}
+
+// generateDNSSECKey generates a new key pair for the zone name, without dates. If withDSRecord, the key gets the SHA-256 DS record of its DNSKEY.
+func generateDNSSECKey(name string, ttl int64, ksk bool, algorithm uint8, bits int, withDSRecord bool) (tc.DNSSECKey, error) {
+	dnskey := &dns.DNSKEY{
+		Hdr:       dns.RR_Header{Name: dns.Fqdn(name), Rrtype: dns.TypeDNSKEY, Class: dns.ClassINET, Ttl: uint32(ttl)},
+		Flags:     dnskeyFlagsZSK,
+		Protocol:  dnskeyProtocol,
+		Algorithm: algorithm,
+	}
+	if ksk {
+		dnskey.Flags = dnskeyFlagsKSK
+	}
+	privateKey, err := dnskey.Generate(bits)
+	if err != nil {
+		return tc.DNSSECKey{}, errors.New("generating " + strconv.Itoa(bits) + " bit " + dns.AlgorithmToString[algorithm] + " key: " + err.Error())
+	}
+	key := tc.DNSSECKey{
+		Name:    dnskey.Hdr.Name,
+		TTL:     ttl,
+		Status:  tc.DNSSECKeyStatusNew,
+		Public:  base64.StdEncoding.EncodeToString([]byte(dnskey.String())),
+		Private: base64.StdEncoding.EncodeToString([]byte(dnskey.PrivateKeyString(privateKey))),
+	}
+	if withDSRecord {
+		ds := dnskey.ToDS(dns.SHA256)
+		if ds == nil {
+			return tc.DNSSECKey{}, errors.New("creating DS record: unsupported key")
+		}
+		key.DSRecord = &tc.DNSSECKeyDSRecord{Algorithm: int(ds.Algorithm), DigestType: int(ds.DigestType), Digest: ds.Digest}
+	}
+	return key, nil
+}
+
+// getDNSSECDSRecords returns the SHA-256 DS records of the key signing keys of set which haven't expired at now, newest first.
+func getDNSSECDSRecords(set tc.DNSSECKeySet, now time.Time) ([]tc.DNSSECDSRecord, error) {
+	records := []tc.DNSSECDSRecord{}
+	for _, key := range set.KSK {
+		if key.ExpirationDateUnix <= now.Unix() {
+			continue
+		}
+		dnskey, err := parseDNSSECPublicKey(key)
+		if err != nil {
+			return nil, errors.New("key " + key.Name + ": " + err.Error())
+		}
+		ds := dnskey.ToDS(dns.SHA256)
+		if ds == nil {
+			return nil, errors.New("key " + key.Name + ": creating DS record: unsupported key")
+		}
+		records = append(records, tc.DNSSECDSRecord{
+			Name:               ds.Hdr.Name,
+			Status:             key.Status,
+			KeyTag:             int(ds.KeyTag),
+			Algorithm:          int(ds.Algorithm),
+			DigestType:         int(ds.DigestType),
+			Digest:             ds.Digest,
+			EffectiveDateUnix:  key.EffectiveDateUnix,
+			ExpirationDateUnix: key.ExpirationDateUnix,
+			Record:             ds.String(),
+		})
+	}
+	return records, nil
+}
+
+// newDNSSECKey returns the first key with the new status, which is the current key of a zone, or false if there is none.
+func newDNSSECKey(keys []tc.DNSSECKey) (tc.DNSSECKey, bool) {
+	for _, key := range keys {
+		if key.Status == tc.DNSSECKeyStatusNew {
+			return key, true
+		}
+	}
+	return tc.DNSSECKey{}, false
+}
+
+// getDNSSECKeyLifetime returns the lifetime in seconds of the new key of keys, or def if there is none.
+func getDNSSECKeyLifetime(keys []tc.DNSSECKey, def int64) int64 {
+	key, ok := newDNSSECKey(keys)
+	if !ok {
+		return def
+	}
+	return key.ExpirationDateUnix - key.InceptionDateUnix
+}
+
+// getDNSSECKeySetType returns the algorithm and sizes of the new keys of set, or the defaults if it has none.
+func getDNSSECKeySetType(set tc.DNSSECKeySet) (dnssecKeyType, error) {
+	kt := dnssecKeyType{Algorithm: DefaultDNSSECAlgorithm, KSKBits: DefaultKSKBits, ZSKBits: DefaultZSKBits}
+	if key, ok := newDNSSECKey(set.KSK); ok {
+		alg, bits, err := getDNSSECKeyAlgorithm(key)
+		if err != nil {
+			return kt, errors.New("KSK: " + err.Error())
+		}
+		kt.Algorithm, kt.KSKBits = alg, bits
+	}
+	if key, ok := newDNSSECKey(set.ZSK); ok {
+		_, bits, err := getDNSSECKeyAlgorithm(key)
+		if err != nil {
+			return kt, errors.New("ZSK: " + err.Error())
+		}
+		kt.ZSKBits = bits
+	}
+	return kt, nil
+}
+
+// getDNSSECKeyAlgorithm returns the algorithm and size in bits of the key.
+func getDNSSECKeyAlgorithm(key tc.DNSSECKey) (uint8, int, error) {
+	dnskey, err := parseDNSSECPublicKey(key)
+	if err != nil {
+		return 0, 0, err
+	}
+	privateKey, err := parseDNSSECPrivateKey(dnskey, key)
+	if err != nil {
+		return 0, 0, err
+	}
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		return dnskey.Algorithm, privateKey.N.BitLen(), nil
+	case *ecdsa.PrivateKey:
+		return dnskey.Algorithm, privateKey.Curve.Params().BitSize, nil
+	}
+	return 0, 0, errors.New("unsupported algorithm '" + dns.AlgorithmToString[dnskey.Algorithm] + "'")
+}
+
+// parseDNSSECPublicKey returns the DNSKEY record of the key.
+func parseDNSSECPublicKey(key tc.DNSSECKey) (*dns.DNSKEY, error) {
+	public, err := base64.StdEncoding.DecodeString(key.Public)
+	if err != nil {
+		return nil, errors.New("decoding public key: " + err.Error())
+	}
+	rr, err := dns.NewRR(string(public))
+	if err != nil {
+		return nil, errors.New("parsing public key: " + err.Error())
+	}
+	dnskey, ok := rr.(*dns.DNSKEY)
+	if !ok {
+		return nil, errors.New("public key is not a DNSKEY record")
+	}
+	return dnskey, nil
+}
+
+// parseDNSSECPrivateKey returns the private key of the key, whose DNSKEY record is dnskey.
+func parseDNSSECPrivateKey(dnskey *dns.DNSKEY, key tc.DNSSECKey) (interface{}, error) {
+	private, err := base64.StdEncoding.DecodeString(key.Private)
+	if err != nil {
+		return nil, errors.New("decoding private key: " + err.Error())
+	}
+	privateKey, err := dnskey.NewPrivateKey(string(private))
+	if err != nil {
+		return nil, errors.New("parsing private key: " + err.Error())
+	}
+	return privateKey, nil
+}
+
+// dnssecDSZone returns the zone of the delivery service in the CDN domain, named as the Perl Traffic Ops named it: the host of the delivery service's first host regex, without its first label.
+func dnssecDSZone(ds dnssecDS, cdnDomain string) string {
+	if ds.SetNumber == 0 {
+		host := strings.Replace(ds.HostRegex, `\`, "", -1)
+		host = strings.Replace(host, ".*", "", -1)
+		host = strings.Replace(host, ".", "", -1)
+		return host + "." + strings.TrimSuffix(cdnDomain, ".") + "."
+	}
+	name := ds.HostRegex + "."
+	return name[strings.Index(name, ".")+1:]
+}
+
+// fetchDNSSECKeys returns the DNSSEC keys of the CDN in Riak, or false if it has none.
+func fetchDNSSECKeys(cluster StorageCluster, cdn string) (tc.DNSSECKeys, bool, error) {
+	objs, err := fetchObjectValues(cdn, DNSSECKeysBucket, cluster)
+	if err != nil {
+		return nil, false, errors.New("fetching DNSSEC keys: " + err.Error())
+	}
+	if len(objs) == 0 {
+		return nil, false, nil
+	}
+	keys := tc.DNSSECKeys{}
+	if err := json.Unmarshal(objs[0].Value, &keys); err != nil {
+		return nil, false, errors.New("unmarshalling DNSSEC keys: " + err.Error())
+	}
+	return keys, true, nil
+}
+
+// storeDNSSECKeys replaces the DNSSEC keys of the CDN in Riak.
+func storeDNSSECKeys(cluster StorageCluster, cdn string, keys tc.DNSSECKeys) error {
+	bts, err := json.Marshal(keys)
+	if err != nil {
+		return errors.New("marshalling DNSSEC keys: " + err.Error())
+	}
+	obj := &riak.Object{
+		ContentType:     "text/json",
+		Charset:         "utf-8",
+		ContentEncoding: "utf-8",
+		Key:             cdn,
+		Value:           bts,
+	}
+	if err := saveObject(obj, DNSSECKeysBucket, cluster); err != nil {
+		return errors.New("storing DNSSEC keys: " + err.Error())
+	}
+	return nil
+}
+
+// getCDNDomain returns the domain of the CDN, or false if it doesn't exist.
+func getCDNDomain(db *sql.DB, cdn string) (string, bool, error) {
+	domain := ""
+	if err := db.QueryRow(`SELECT domain_name FROM cdn WHERE name = $1`, cdn).Scan(&domain); err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, errors.New("querying cdn domain: " + err.Error())
+	}
+	return domain, true, nil
+}
+
+// getDNSSECCDNs returns the CDNs with DNSSEC enabled.
+func getDNSSECCDNs(db *sql.DB) ([]dnssecCDN, error) {
+	rows, err := db.Query(`SELECT name, domain_name FROM cdn WHERE dnssec_enabled ORDER BY name`)
+	if err != nil {
+		return nil, errors.New("querying DNSSEC cdns: " + err.Error())
+	}
+	defer rows.Close()
+	cdns := []dnssecCDN{}
+	for rows.Next() {
+		cdn := dnssecCDN{}
+		if err := rows.Scan(&cdn.Name, &cdn.Domain); err != nil {
+			return nil, errors.New("scanning DNSSEC cdns: " + err.Error())
+		}
+		cdns = append(cdns, cdn)
+	}
+	return cdns, nil
+}
+
+// getDNSSECDSes returns the HTTP and DNS delivery services of the CDN, which get DNSSEC keys, with their lowest host regex. Delivery services without a host regex have no zone, and aren't returned.
+func getDNSSECDSes(db *sql.DB, cdn string) ([]dnssecDS, error) {
+	q := `
+SELECT DISTINCT ON (ds.xml_id) ds.xml_id, r.pattern, COALESCE(dsr.set_number, 0)
+FROM deliveryservice ds
+JOIN cdn ON cdn.id = ds.cdn_id
+JOIN type dt ON dt.id = ds.type
+JOIN deliveryservice_regex dsr ON dsr.deliveryservice = ds.id
+JOIN regex r ON r.id = dsr.regex
+JOIN type rt ON rt.id = r.type
+WHERE cdn.name = $1
+AND (dt.name LIKE 'HTTP%' OR dt.name LIKE 'DNS%')
+AND rt.name = 'HOST_REGEXP'
+ORDER BY ds.xml_id, dsr.set_number
+`
+	rows, err := db.Query(q, cdn)
+	if err != nil {
+		return nil, errors.New("querying DNSSEC delivery services: " + err.Error())
+	}
+	defer rows.Close()
+	dses := []dnssecDS{}
+	for rows.Next() {
+		ds := dnssecDS{}
+		if err := rows.Scan(&ds.XMLID, &ds.HostRegex, &ds.SetNumber); err != nil {
+			return nil, errors.New("scanning DNSSEC delivery services: " + err.Error())
+		}
+		dses = append(dses, ds)
+	}
+	return dses, nil
+}
+
+// getDNSSECRefreshParams returns the rollover parameters of the CDN's Traffic Router profile, with the defaults for those it doesn't have.
+func getDNSSECRefreshParams(db *sql.DB, cdn string) (dnssecRefreshParams, error) {
+	params := dnssecRefreshParams{TTL: DefaultDNSKEYTTL, GenerationMultiplier: DefaultDNSKEYGenerationMultiplier, EffectiveMultiplier: DefaultDNSKEYEffectiveMultiplier}
+	q := `
+SELECT DISTINCT p.name, p.value
+FROM parameter p
+JOIN profile_parameter pp ON pp.parameter = p.id
+JOIN profile pr ON pr.id = pp.profile
+JOIN server s ON s.profile = pr.id
+JOIN cdn ON cdn.id = s.cdn_id
+WHERE cdn.name = $1
+AND (pr.name LIKE 'CCR%' OR pr.name LIKE 'TR%')
+AND p.name = ANY($2)
+`
+	rows, err := db.Query(q, cdn, pq.Array([]string{"tld.ttls.DNSKEY", "DNSKEY.generation.multiplier", "DNSKEY.effective.multiplier"}))
+	if err != nil {
+		return params, errors.New("querying DNSSEC parameters: " + err.Error())
+	}
+	defer rows.Close()
+	for rows.Next() {
+		name, value := "", ""
+		if err := rows.Scan(&name, &value); err != nil {
+			return params, errors.New("scanning DNSSEC parameters: " + err.Error())
+		}
+		val, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || val <= 0 {
+			log.Warnf("CDN %s parameter %s value '%s' is not a positive integer, using the default\n", cdn, name, value)
+			continue
+		}
+		switch name {
+		case "tld.ttls.DNSKEY":
+			params.TTL = val
+		case "DNSKEY.generation.multiplier":
+			params.GenerationMultiplier = val
+		case "DNSKEY.effective.multiplier":
+			params.EffectiveMultiplier = val
+		}
+	}
+	return params, nil
+}
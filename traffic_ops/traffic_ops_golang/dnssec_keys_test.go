@@ -0,0 +1,400 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	"github.com/miekg/dns"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+// verifyDNSSECKey signs an RRset in the key's zone with its private key, and verifies the signature with its public key.
+func verifyDNSSECKey(t *testing.T, key tc.DNSSECKey) {
+	dnskey, err := parseDNSSECPublicKey(key)
+	if err != nil {
+		t.Fatalf("parseDNSSECPublicKey expected: nil error, actual: %v", err)
+	}
+	privateKey, err := parseDNSSECPrivateKey(dnskey, key)
+	if err != nil {
+		t.Fatalf("parseDNSSECPrivateKey expected: nil error, actual: %v", err)
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		t.Fatalf("private key expected: crypto.Signer, actual: %T", privateKey)
+	}
+
+	rrset := []dns.RR{&dns.A{
+		Hdr: dns.RR_Header{Name: "www." + key.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+		A:   net.ParseIP("192.0.2.1"),
+	}}
+	now := time.Now()
+	sig := &dns.RRSIG{
+		Hdr:        dns.RR_Header{Name: rrset[0].Header().Name, Rrtype: dns.TypeRRSIG, Class: dns.ClassINET, Ttl: 60},
+		Algorithm:  dnskey.Algorithm,
+		Expiration: uint32(now.Add(time.Hour).Unix()),
+		Inception:  uint32(now.Add(-time.Hour).Unix()),
+		KeyTag:     dnskey.KeyTag(),
+		SignerName: dnskey.Hdr.Name,
+	}
+	if err := sig.Sign(signer, rrset); err != nil {
+		t.Fatalf("signing RRset expected: nil error, actual: %v", err)
+	}
+	if err := sig.Verify(dnskey, rrset); err != nil {
+		t.Errorf("verifying RRset signed by %s key expected: nil error, actual: %v", key.Name, err)
+	}
+	if !sig.ValidityPeriod(now) {
+		t.Errorf("RRSIG validity period expected: valid now, actual: invalid")
+	}
+}
+
+func TestGenerateDNSSECKey(t *testing.T) {
+	for _, test := range []struct {
+		algorithm uint8
+		bits      int
+		ksk       bool
+	}{
+		{dns.RSASHA1, 1024, false},
+		{dns.RSASHA256, 1024, true},
+		{dns.RSASHA512, 1024, true},
+		{dns.ECDSAP256SHA256, 256, true},
+		{dns.ECDSAP384SHA384, 384, false},
+	} {
+		key, err := generateDNSSECKey("cdn.example.net", 60, test.ksk, test.algorithm, test.bits, test.ksk)
+		if err != nil {
+			t.Fatalf("generateDNSSECKey %+v expected: nil error, actual: %v", test, err)
+		}
+		if key.Name != "cdn.example.net." || key.TTL != 60 || key.Status != tc.DNSSECKeyStatusNew {
+			t.Errorf("generateDNSSECKey %+v expected: name cdn.example.net. ttl 60 status new, actual: %+v", test, key)
+		}
+		verifyDNSSECKey(t, key)
+
+		dnskey, err := parseDNSSECPublicKey(key)
+		if err != nil {
+			t.Fatalf("parseDNSSECPublicKey expected: nil error, actual: %v", err)
+		}
+		expectedFlags := uint16(dnskeyFlagsZSK)
+		if test.ksk {
+			expectedFlags = dnskeyFlagsKSK
+		}
+		if dnskey.Flags != expectedFlags || dnskey.Algorithm != test.algorithm || dnskey.Hdr.Ttl != 60 {
+			t.Errorf("generateDNSSECKey %+v DNSKEY expected: flags %d algorithm %d ttl 60, actual: %s", test, expectedFlags, test.algorithm, dnskey)
+		}
+		alg, bits, err := getDNSSECKeyAlgorithm(key)
+		if err != nil || alg != test.algorithm || bits != test.bits {
+			t.Errorf("getDNSSECKeyAlgorithm %+v expected: %d %d nil, actual: %d %d %v", test, test.algorithm, test.bits, alg, bits, err)
+		}
+
+		if !test.ksk {
+			if key.DSRecord != nil {
+				t.Errorf("generateDNSSECKey %+v DS record expected: nil, actual: %+v", test, key.DSRecord)
+			}
+			continue
+		}
+		ds := dnskey.ToDS(dns.SHA256)
+		expectedDS := tc.DNSSECKeyDSRecord{Algorithm: int(test.algorithm), DigestType: int(dns.SHA256), Digest: ds.Digest}
+		if key.DSRecord == nil || *key.DSRecord != expectedDS {
+			t.Errorf("generateDNSSECKey %+v DS record expected: %+v, actual: %+v", test, expectedDS, key.DSRecord)
+		}
+	}
+}
+
+func TestGetDNSSECKeyType(t *testing.T) {
+	for _, test := range []struct {
+		algorithm string
+		kskBits   int
+		zskBits   int
+		expected  dnssecKeyType
+		err       string
+	}{
+		{"", 0, 0, dnssecKeyType{dns.RSASHA1, DefaultKSKBits, DefaultZSKBits}, ""},
+		{"rsasha256", 4096, 2048, dnssecKeyType{dns.RSASHA256, 4096, 2048}, ""},
+		{"ECDSAP256SHA256", 2048, 0, dnssecKeyType{dns.ECDSAP256SHA256, 256, 256}, ""},
+		{"ECDSAP384SHA384", 0, 0, dnssecKeyType{dns.ECDSAP384SHA384, 384, 384}, ""},
+		{"RSASHA1", 512, 0, dnssecKeyType{}, "between 1024 and 4096"},
+		{"RSASHA1", 0, 8192, dnssecKeyType{}, "between 1024 and 4096"},
+		{"DSA", 0, 0, dnssecKeyType{}, "unsupported algorithm 'DSA'"},
+		{"NOSUCHALG", 0, 0, dnssecKeyType{}, "unknown algorithm 'NOSUCHALG'"},
+	} {
+		kt, err := getDNSSECKeyType(test.algorithm, test.kskBits, test.zskBits)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("getDNSSECKeyType %+v expected: error containing '%s', actual: %v", test, test.err, err)
+			}
+			continue
+		}
+		if err != nil || kt != test.expected {
+			t.Errorf("getDNSSECKeyType %+v expected: %+v nil, actual: %+v %v", test, test.expected, kt, err)
+		}
+	}
+}
+
+func TestValidateDNSSECKeysGenerateRequest(t *testing.T) {
+	req := tc.DNSSECKeysGenerateRequest{Key: "cdn1", Name: "cdn1.example.net", TTL: 60, KSKExpirationDays: 365, ZSKExpirationDays: 30, EffectiveDateUnix: 1500000000}
+	if err := validateDNSSECKeysGenerateRequest(req); err != nil {
+		t.Errorf("validateDNSSECKeysGenerateRequest expected: nil error, actual: %v", err)
+	}
+	for _, test := range []struct {
+		mod      func(r *tc.DNSSECKeysGenerateRequest)
+		expected string
+	}{
+		{func(r *tc.DNSSECKeysGenerateRequest) { r.Key = "" }, "key is required"},
+		{func(r *tc.DNSSECKeysGenerateRequest) { r.TTL = 0 }, "ttl"},
+		{func(r *tc.DNSSECKeysGenerateRequest) { r.KSKExpirationDays = -1 }, "kskExpirationDays"},
+		{func(r *tc.DNSSECKeysGenerateRequest) { r.ZSKExpirationDays = 0 }, "zskExpirationDays"},
+		{func(r *tc.DNSSECKeysGenerateRequest) { r.EffectiveDateUnix = -1 }, "effectiveDate"},
+	} {
+		invalid := req
+		test.mod(&invalid)
+		err := validateDNSSECKeysGenerateRequest(invalid)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("validateDNSSECKeysGenerateRequest %+v expected: error containing '%s', actual: %v", invalid, test.expected, err)
+		}
+	}
+}
+
+func TestDNSSECDSZone(t *testing.T) {
+	for _, test := range []struct {
+		ds       dnssecDS
+		domain   string
+		expected string
+	}{
+		{dnssecDS{XMLID: "ds1", HostRegex: `.*\.ds1\..*`, SetNumber: 0}, "cdn.example.net", "ds1.cdn.example.net."},
+		{dnssecDS{XMLID: "ds2", HostRegex: `.*\.ds2\..*`, SetNumber: 0}, "cdn.example.net.", "ds2.cdn.example.net."},
+		{dnssecDS{XMLID: "ds3", HostRegex: "www.ds3.example.com", SetNumber: 1}, "cdn.example.net", "ds3.example.com."},
+	} {
+		if actual := dnssecDSZone(test.ds, test.domain); actual != test.expected {
+			t.Errorf("dnssecDSZone %+v %s expected: %s, actual: %s", test.ds, test.domain, test.expected, actual)
+		}
+	}
+}
+
+func TestGenerateCDNDNSSECKeys(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	kt := dnssecKeyType{Algorithm: dns.ECDSAP256SHA256, KSKBits: 256, ZSKBits: 256}
+	req := tc.DNSSECKeysGenerateRequest{Key: "cdn1", Name: "cdn1.example.net", TTL: 60, KSKExpirationDays: 365, ZSKExpirationDays: 30, EffectiveDateUnix: now.Unix() + 3600}
+	dses := []dnssecDS{{XMLID: "ds1", HostRegex: `.*\.ds1\..*`}}
+
+	oldKeys, err := generateCDNDNSSECKeys(req, "cdn1.example.net", dses, kt, nil, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("generateCDNDNSSECKeys expected: nil error, actual: %v", err)
+	}
+	keys, err := generateCDNDNSSECKeys(req, "cdn1.example.net", dses, kt, oldKeys, now)
+	if err != nil {
+		t.Fatalf("generateCDNDNSSECKeys expected: nil error, actual: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("generateCDNDNSSECKeys expected: keys for cdn1 and ds1, actual: %+v", keys)
+	}
+
+	for zone, name := range map[string]string{"cdn1": "cdn1.example.net.", "ds1": "ds1.cdn1.example.net."} {
+		set := keys[zone]
+		if len(set.ZSK) != 2 || len(set.KSK) != 2 {
+			t.Fatalf("generateCDNDNSSECKeys %s expected: new and existing ZSK and KSK, actual: %+v", zone, set)
+		}
+		for _, key := range []tc.DNSSECKey{set.ZSK[0], set.KSK[0]} {
+			if key.Name != name || key.Status != tc.DNSSECKeyStatusNew || key.InceptionDateUnix != now.Unix() || key.EffectiveDateUnix != req.EffectiveDateUnix {
+				t.Errorf("generateCDNDNSSECKeys %s new key expected: name %s status new inception %d effective %d, actual: %+v", zone, name, now.Unix(), req.EffectiveDateUnix, key)
+			}
+			verifyDNSSECKey(t, key)
+		}
+		if set.ZSK[0].ExpirationDateUnix != now.Unix()+30*secondsPerDay || set.KSK[0].ExpirationDateUnix != now.Unix()+365*secondsPerDay {
+			t.Errorf("generateCDNDNSSECKeys %s expected: expirations in 30 and 365 days, actual: %d %d", zone, set.ZSK[0].ExpirationDateUnix, set.KSK[0].ExpirationDateUnix)
+		}
+		for i, key := range []tc.DNSSECKey{set.ZSK[1], set.KSK[1]} {
+			old := []tc.DNSSECKey{oldKeys[zone].ZSK[0], oldKeys[zone].KSK[0]}[i]
+			if key.Status != tc.DNSSECKeyStatusExisting || key.ExpirationDateUnix != req.EffectiveDateUnix || key.Public != old.Public {
+				t.Errorf("generateCDNDNSSECKeys %s old key expected: the old new key, existing until %d, actual: %+v", zone, req.EffectiveDateUnix, key)
+			}
+		}
+	}
+	if keys["cdn1"].KSK[0].DSRecord == nil {
+		t.Errorf("generateCDNDNSSECKeys CDN KSK DS record expected: a record, actual: nil")
+	}
+	if keys["ds1"].KSK[0].DSRecord != nil {
+		t.Errorf("generateCDNDNSSECKeys delivery service KSK DS record expected: nil, actual: %+v", keys["ds1"].KSK[0].DSRecord)
+	}
+}
+
+func TestRolloverDNSSECKeys(t *testing.T) {
+	params := dnssecRefreshParams{TTL: 60, GenerationMultiplier: 10, EffectiveMultiplier: 5}
+	now := time.Unix(1500000000, 0)
+	key, err := generateDNSSECKey("cdn1.example.net", 60, false, dns.ECDSAP256SHA256, 256, false)
+	if err != nil {
+		t.Fatalf("generateDNSSECKey expected: nil error, actual: %v", err)
+	}
+	key.InceptionDateUnix = now.Unix() - 30*secondsPerDay
+	key.EffectiveDateUnix = key.InceptionDateUnix
+
+	key.ExpirationDateUnix = now.Unix() + 601
+	keys, rolled, err := rolloverDNSSECKeys([]tc.DNSSECKey{key}, false, false, params, now)
+	if err != nil || rolled || len(keys) != 1 || keys[0] != key {
+		t.Errorf("rolloverDNSSECKeys of key expiring after the generation period expected: unchanged, actual: %+v %t %v", keys, rolled, err)
+	}
+
+	key.ExpirationDateUnix = now.Unix() + 599
+	keys, rolled, err = rolloverDNSSECKeys([]tc.DNSSECKey{key}, false, false, params, now)
+	if err != nil || !rolled || len(keys) != 2 {
+		t.Fatalf("rolloverDNSSECKeys of key expiring in the generation period expected: new and old key, actual: %+v %t %v", keys, rolled, err)
+	}
+	newKey, oldKey := keys[0], keys[1]
+	if newKey.Status != tc.DNSSECKeyStatusNew || newKey.Name != key.Name || newKey.TTL != key.TTL || newKey.Public == key.Public {
+		t.Errorf("rolloverDNSSECKeys new key expected: a new key for %s, actual: %+v", key.Name, newKey)
+	}
+	if lifetime := key.ExpirationDateUnix - key.InceptionDateUnix; newKey.InceptionDateUnix != now.Unix() || newKey.ExpirationDateUnix != now.Unix()+lifetime {
+		t.Errorf("rolloverDNSSECKeys new key expected: the old key's lifetime of %d seconds from now, actual: %d to %d", lifetime, newKey.InceptionDateUnix, newKey.ExpirationDateUnix)
+	}
+	if newKey.EffectiveDateUnix != key.ExpirationDateUnix-300 {
+		t.Errorf("rolloverDNSSECKeys new key effective date expected: %d, actual: %d", key.ExpirationDateUnix-300, newKey.EffectiveDateUnix)
+	}
+	if oldKey.Status != tc.DNSSECKeyStatusExpired || oldKey.ExpirationDateUnix != key.ExpirationDateUnix || oldKey.Public != key.Public {
+		t.Errorf("rolloverDNSSECKeys old key expected: expired, until its expiration, actual: %+v", oldKey)
+	}
+	if alg, bits, err := getDNSSECKeyAlgorithm(newKey); err != nil || alg != dns.ECDSAP256SHA256 || bits != 256 {
+		t.Errorf("rolloverDNSSECKeys new key expected: the old key's algorithm and size, actual: %d %d %v", alg, bits, err)
+	}
+	verifyDNSSECKey(t, newKey)
+
+	if _, rolled, _ := rolloverDNSSECKeys([]tc.DNSSECKey{oldKey}, false, false, params, now); rolled {
+		t.Errorf("rolloverDNSSECKeys of keys without a new key expected: unchanged, actual: rolled over")
+	}
+}
+
+func TestRefreshDNSSECKeys(t *testing.T) {
+	params := dnssecRefreshParams{TTL: 60, GenerationMultiplier: 10, EffectiveMultiplier: 10}
+	now := time.Unix(1500000000, 0)
+	kt := dnssecKeyType{Algorithm: dns.ECDSAP384SHA384, KSKBits: 384, ZSKBits: 384}
+	cdnKeys, err := generateDNSSECKeySet("cdn1.example.net", 60, kt, 100*secondsPerDay, 10*secondsPerDay, now.Unix(), now, true)
+	if err != nil {
+		t.Fatalf("generateDNSSECKeySet expected: nil error, actual: %v", err)
+	}
+	keys := tc.DNSSECKeys{"cdn1": cdnKeys}
+	dses := []dnssecDS{{XMLID: "ds1", HostRegex: `.*\.ds1\..*`}}
+
+	updated, err := refreshDNSSECKeys(keys, "cdn1", "cdn1.example.net", dses, params, now)
+	if err != nil || !updated {
+		t.Fatalf("refreshDNSSECKeys with a new delivery service expected: updated, actual: %t %v", updated, err)
+	}
+	dsKeys, ok := keys["ds1"]
+	if !ok || len(dsKeys.ZSK) != 1 || len(dsKeys.KSK) != 1 {
+		t.Fatalf("refreshDNSSECKeys expected: a new ZSK and KSK for ds1, actual: %+v", keys)
+	}
+	if dsKeys.ZSK[0].Name != "ds1.cdn1.example.net." || dsKeys.ZSK[0].ExpirationDateUnix != now.Unix()+10*secondsPerDay || dsKeys.KSK[0].ExpirationDateUnix != now.Unix()+100*secondsPerDay {
+		t.Errorf("refreshDNSSECKeys ds1 keys expected: the CDN's key lifetimes in zone ds1.cdn1.example.net., actual: %+v", dsKeys)
+	}
+	if alg, bits, err := getDNSSECKeyAlgorithm(dsKeys.KSK[0]); err != nil || alg != dns.ECDSAP384SHA384 || bits != 384 {
+		t.Errorf("refreshDNSSECKeys ds1 KSK expected: the CDN's algorithm and size, actual: %d %d %v", alg, bits, err)
+	}
+	verifyDNSSECKey(t, dsKeys.KSK[0])
+
+	if updated, err := refreshDNSSECKeys(keys, "cdn1", "cdn1.example.net", dses, params, now); err != nil || updated {
+		t.Errorf("refreshDNSSECKeys with no keys expiring expected: not updated, actual: %t %v", updated, err)
+	}
+
+	later := now.Add(10*24*time.Hour - time.Minute)
+	updated, err = refreshDNSSECKeys(keys, "cdn1", "cdn1.example.net", dses, params, later)
+	if err != nil || !updated {
+		t.Fatalf("refreshDNSSECKeys with ZSKs expiring expected: updated, actual: %t %v", updated, err)
+	}
+	for _, zone := range []string{"cdn1", "ds1"} {
+		if len(keys[zone].ZSK) != 2 || keys[zone].ZSK[0].InceptionDateUnix != later.Unix() || keys[zone].ZSK[1].Status != tc.DNSSECKeyStatusExpired {
+			t.Errorf("refreshDNSSECKeys %s ZSKs expected: rolled over, actual: %+v", zone, keys[zone].ZSK)
+		}
+		if len(keys[zone].KSK) != 1 {
+			t.Errorf("refreshDNSSECKeys %s KSKs expected: unchanged, actual: %+v", zone, keys[zone].KSK)
+		}
+	}
+
+	evenLater := now.Add(100*24*time.Hour - time.Minute)
+	updated, err = refreshDNSSECKeys(keys, "cdn1", "cdn1.example.net", dses, params, evenLater)
+	if err != nil || !updated {
+		t.Fatalf("refreshDNSSECKeys with KSKs expiring expected: updated, actual: %t %v", updated, err)
+	}
+	cdnKSK := keys["cdn1"].KSK[0]
+	if len(keys["cdn1"].KSK) != 2 || cdnKSK.InceptionDateUnix != evenLater.Unix() || cdnKSK.DSRecord == nil {
+		t.Errorf("refreshDNSSECKeys CDN KSK expected: rolled over with a DS record, actual: %+v", keys["cdn1"].KSK)
+	}
+	if dsKSK := keys["ds1"].KSK[0]; len(keys["ds1"].KSK) != 2 || dsKSK.InceptionDateUnix != evenLater.Unix() || dsKSK.DSRecord != nil {
+		t.Errorf("refreshDNSSECKeys ds1 KSK expected: rolled over without a DS record, actual: %+v", keys["ds1"].KSK)
+	}
+}
+
+func TestGetDNSSECDSRecords(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	kt := dnssecKeyType{Algorithm: dns.ECDSAP256SHA256, KSKBits: 256, ZSKBits: 256}
+	set, err := generateDNSSECKeySet("cdn1.example.net", 60, kt, 365*secondsPerDay, 30*secondsPerDay, now.Unix(), now, true)
+	if err != nil {
+		t.Fatalf("generateDNSSECKeySet expected: nil error, actual: %v", err)
+	}
+	expired := set.KSK[0]
+	expired.Status = tc.DNSSECKeyStatusExpired
+	expired.ExpirationDateUnix = now.Unix() - 1
+	set.KSK = append(set.KSK, expired)
+
+	records, err := getDNSSECDSRecords(set, now)
+	if err != nil {
+		t.Fatalf("getDNSSECDSRecords expected: nil error, actual: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("getDNSSECDSRecords expected: 1 record for the unexpired KSK, actual: %+v", records)
+	}
+	record := records[0]
+	dsRecord := set.KSK[0].DSRecord
+	if record.Name != "cdn1.example.net." || record.Status != tc.DNSSECKeyStatusNew || record.Digest != dsRecord.Digest || record.DigestType != dsRecord.DigestType || record.Algorithm != dsRecord.Algorithm {
+		t.Errorf("getDNSSECDSRecords expected: the stored DS record %+v of cdn1.example.net., actual: %+v", dsRecord, record)
+	}
+	rr, err := dns.NewRR(record.Record)
+	if err != nil {
+		t.Fatalf("parsing DS record expected: nil error, actual: %v", err)
+	}
+	ds, ok := rr.(*dns.DS)
+	if !ok || int(ds.KeyTag) != record.KeyTag || !strings.EqualFold(ds.Digest, record.Digest) {
+		t.Errorf("getDNSSECDSRecords record expected: DS record with key tag %d, actual: %s", record.KeyTag, record.Record)
+	}
+}
+
+func TestGetDNSSECRefreshParams(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"name", "value"})
+	rows = rows.AddRow("tld.ttls.DNSKEY", "30")
+	rows = rows.AddRow("DNSKEY.generation.multiplier", "bad")
+	rows = rows.AddRow("DNSKEY.effective.multiplier", "4")
+	mock.ExpectQuery("SELECT").WithArgs("cdn1", sqlmock.AnyArg()).WillReturnRows(rows)
+
+	params, err := getDNSSECRefreshParams(db, "cdn1")
+	if err != nil {
+		t.Fatalf("getDNSSECRefreshParams expected: nil error, actual: %v", err)
+	}
+	expected := dnssecRefreshParams{TTL: 30, GenerationMultiplier: DefaultDNSKEYGenerationMultiplier, EffectiveMultiplier: 4}
+	if params != expected {
+		t.Errorf("getDNSSECRefreshParams expected: %+v, actual: %+v", expected, params)
+	}
+}
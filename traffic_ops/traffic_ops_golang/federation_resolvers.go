@@ -0,0 +1,300 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// federationResolversHandler returns a handler which serves every federation resolver.
+func federationResolversHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		q := `
+SELECT fr.id, fr.ip_address, t.id, t.name
+FROM federation_resolver fr
+JOIN type t ON t.id = fr.type
+ORDER BY fr.ip_address
+`
+		resolvers, err := getFederationResolvers(db.DB, q)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.FederationResolversResponse{Response: resolvers})
+	}
+}
+
+// createFederationResolverHandler returns a handler which creates a federation resolver, an IP address or CIDR network of the type's IP version.
+func createFederationResolverHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		resolver := tc.FederationResolver{}
+		if err := json.NewDecoder(r.Body).Decode(&resolver); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if resolver.TypeID == 0 {
+			handleErr(errors.New("typeId is required"), http.StatusBadRequest)
+			return
+		}
+		if err := db.QueryRow(`SELECT name FROM type WHERE id = $1`, resolver.TypeID).Scan(&resolver.Type); err != nil {
+			if err == sql.ErrNoRows {
+				handleErr(errors.New("type not found"), http.StatusBadRequest)
+				return
+			}
+			handleErr(errors.New("querying type: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		ip, err := validateFederationResolverIP(resolver.IPAddress, resolver.Type)
+		if err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+		resolver.IPAddress = ip
+
+		exists := false
+		if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM federation_resolver WHERE ip_address = $1)`, ip).Scan(&exists); err != nil {
+			handleErr(errors.New("querying federation resolvers: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if exists {
+			handleErr(errors.New(ip+" already in use"), http.StatusBadRequest)
+			return
+		}
+		q := `INSERT INTO federation_resolver (ip_address, type) VALUES ($1, $2) RETURNING id`
+		if err := db.QueryRow(q, ip, resolver.TypeID).Scan(&resolver.ID); err != nil {
+			handleErr(errors.New("inserting federation resolver: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		msg := "Federation Resolver created [ IP = " + ip + " ] with id: " + strconv.Itoa(resolver.ID)
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.FederationResolverResponse{Response: resolver, Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+// deleteFederationResolverHandler returns a handler which deletes the federation resolver in the path, removing it from its federations.
+func deleteFederationResolverHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		ip := ""
+		if err := db.QueryRow(`DELETE FROM federation_resolver WHERE id = $1 RETURNING ip_address`, id).Scan(&ip); err != nil {
+			if err == sql.ErrNoRows {
+				handleErr(errors.New("federation resolver not found"), http.StatusNotFound)
+				return
+			}
+			handleErr(errors.New("deleting federation resolver: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		msg := "Federation resolver deleted [ IP = " + ip + " ] with id: " + strconv.Itoa(id)
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.CreateAlerts(tc.SuccessLevel, msg))
+	}
+}
+
+// federationFederationResolversHandler returns a handler which serves the resolvers of the federation in the path.
+func federationFederationResolversHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		q := `
+SELECT fr.id, fr.ip_address, t.id, t.name
+FROM federation_resolver fr
+JOIN type t ON t.id = fr.type
+JOIN federation_federation_resolver ffr ON ffr.federation_resolver = fr.id
+WHERE ffr.federation = $1
+ORDER BY fr.ip_address
+`
+		resolvers, err := getFederationResolvers(db.DB, q, id)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.FederationResolversResponse{Response: resolvers})
+	}
+}
+
+// assignFederationResolversHandler returns a handler which assigns resolvers to the federation in the path, replacing its resolvers if the request says to.
+func assignFederationResolversHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		req := tc.AssignFederationResolversRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if req.FedResolverIDs == nil {
+			handleErr(errors.New("Fed Resolver IDs must be an array"), http.StatusBadRequest)
+			return
+		}
+
+		fed, ok, err := getFederation(db.DB, id)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("federation not found"), http.StatusNotFound)
+			return
+		}
+		if status, err := assignToFederation(db.DB, id, federationResolverAssignment, req.FedResolverIDs, req.Replace); err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		msg := strconv.Itoa(len(req.FedResolverIDs)) + " resolver(s) were assigned to the " + fed.CName + " federation"
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.AssignFederationResolversResponse{Response: req, Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+// validateFederationResolverIP returns the IP address or CIDR network of a federation resolver, or an error if it isn't one, or isn't of the IP version of the resolver type. Networks are returned without host bits, e.g. 192.0.2.0/24 for 192.0.2.1/24.
+func validateFederationResolverIP(ipStr string, typeName string) (string, error) {
+	if ipStr == "" {
+		return "", errors.New("ipAddress is required")
+	}
+	ip := net.ParseIP(ipStr)
+	normalized := ipStr
+	if ip == nil {
+		cidrIP, ipNet, err := net.ParseCIDR(ipStr)
+		if err != nil {
+			return "", errors.New("ipAddress invalid. " + ipStr + " is not a valid ip address or range.")
+		}
+		ip = cidrIP
+		normalized = ipNet.String()
+	}
+
+	isIPv4 := ip.To4() != nil && !strings.Contains(ipStr, ":")
+	switch typeName {
+	case tc.FederationResolverTypeIPv4:
+		if !isIPv4 {
+			return "", errors.New("ipAddress " + ipStr + " is not an IPv4 address or range, but the type is " + typeName)
+		}
+	case tc.FederationResolverTypeIPv6:
+		if isIPv4 {
+			return "", errors.New("ipAddress " + ipStr + " is not an IPv6 address or range, but the type is " + typeName)
+		}
+	default:
+		return "", errors.New("type must be " + tc.FederationResolverTypeIPv4 + " or " + tc.FederationResolverTypeIPv6)
+	}
+	return normalized, nil
+}
+
+func getFederationResolvers(db *sql.DB, q string, args ...interface{}) ([]tc.FederationResolver, error) {
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying federation resolvers: " + err.Error())
+	}
+	defer rows.Close()
+
+	resolvers := []tc.FederationResolver{}
+	for rows.Next() {
+		resolver := tc.FederationResolver{}
+		if err := rows.Scan(&resolver.ID, &resolver.IPAddress, &resolver.TypeID, &resolver.Type); err != nil {
+			return nil, errors.New("scanning federation resolvers: " + err.Error())
+		}
+		resolvers = append(resolvers, resolver)
+	}
+	return resolvers, nil
+}
+
+// federationAssignment is a table which assigns things to federations.
+type federationAssignment struct {
+	// name is what's assigned, for errors.
+	name string
+	// table is the assignment table, with a federation column, and a column of the assigned thing.
+	table string
+	col   string
+	// assignedTable is the table of the assigned things.
+	assignedTable string
+}
+
+var federationResolverAssignment = federationAssignment{name: "federation resolver", table: "federation_federation_resolver", col: "federation_resolver", assignedTable: "federation_resolver"}
+var federationDSAssignment = federationAssignment{name: "delivery service", table: "federation_deliveryservice", col: "deliveryservice", assignedTable: "deliveryservice"}
+
+// assignToFederation assigns the things with the given IDs to the given federation, removing its other assignments if replace is true. Things already assigned stay assigned. Returns an error with the status to respond with if any of them don't exist.
+func assignToFederation(db *sql.DB, fedID int, assignment federationAssignment, ids []int, replace bool) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("beginning transaction: " + err.Error())
+	}
+	commit := false
+	defer func() {
+		if !commit {
+			tx.Rollback()
+		}
+	}()
+
+	uniqueIDs := map[int]struct{}{}
+	for _, id := range ids {
+		uniqueIDs[id] = struct{}{}
+	}
+	existing := 0
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM `+assignment.assignedTable+` WHERE id = ANY($1)`, pq.Array(ids)).Scan(&existing); err != nil {
+		return http.StatusInternalServerError, errors.New("querying " + assignment.name + "s: " + err.Error())
+	}
+	if existing != len(uniqueIDs) {
+		return http.StatusBadRequest, errors.New("one or more " + assignment.name + "s not found")
+	}
+
+	if replace {
+		if _, err := tx.Exec(`DELETE FROM `+assignment.table+` WHERE federation = $1`, fedID); err != nil {
+			return http.StatusInternalServerError, errors.New("deleting federation " + assignment.name + "s: " + err.Error())
+		}
+	}
+	q := `INSERT INTO ` + assignment.table + ` (federation, ` + assignment.col + `) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	for _, id := range ids {
+		if _, err := tx.Exec(q, fedID, id); err != nil {
+			return http.StatusInternalServerError, errors.New("assigning " + assignment.name + ": " + err.Error())
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.New("committing federation " + assignment.name + "s: " + err.Error())
+	}
+	commit = true
+	return http.StatusOK, nil
+}
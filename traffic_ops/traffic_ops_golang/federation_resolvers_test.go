@@ -0,0 +1,128 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestValidateFederationResolverIP(t *testing.T) {
+	for _, test := range []struct {
+		ip       string
+		typeName string
+		expected string
+		valid    bool
+	}{
+		{"192.0.2.1", tc.FederationResolverTypeIPv4, "192.0.2.1", true},
+		{"192.0.2.0/24", tc.FederationResolverTypeIPv4, "192.0.2.0/24", true},
+		{"192.0.2.1/24", tc.FederationResolverTypeIPv4, "192.0.2.0/24", true},
+		{"2001:db8::1", tc.FederationResolverTypeIPv6, "2001:db8::1", true},
+		{"2001:db8::/32", tc.FederationResolverTypeIPv6, "2001:db8::/32", true},
+		{"::ffff:192.0.2.1", tc.FederationResolverTypeIPv6, "::ffff:192.0.2.1", true},
+		{"192.0.2.1", tc.FederationResolverTypeIPv6, "", false},
+		{"2001:db8::/32", tc.FederationResolverTypeIPv4, "", false},
+		{"192.0.2.0/33", tc.FederationResolverTypeIPv4, "", false},
+		{"192.0.2", tc.FederationResolverTypeIPv4, "", false},
+		{"resolver.example.net", tc.FederationResolverTypeIPv4, "", false},
+		{"", tc.FederationResolverTypeIPv4, "", false},
+		{"192.0.2.1", "EDGE", "", false},
+	} {
+		actual, err := validateFederationResolverIP(test.ip, test.typeName)
+		if (err == nil) != test.valid {
+			t.Errorf("validateFederationResolverIP(%s, %s) expected valid: %v, actual: %v", test.ip, test.typeName, test.valid, err)
+		}
+		if actual != test.expected {
+			t.Errorf("validateFederationResolverIP(%s, %s) expected: '%s', actual: '%s'", test.ip, test.typeName, test.expected, actual)
+		}
+	}
+}
+
+func TestAssignToFederation(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectExec("DELETE FROM federation_federation_resolver").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("INSERT INTO federation_federation_resolver").WithArgs(1, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO federation_federation_resolver").WithArgs(1, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, err := assignToFederation(mockDB, 1, federationResolverAssignment, []int{4, 5}, true); err != nil {
+		t.Errorf("assignToFederation expected: nil error, actual: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("assignToFederation expected: all queries, actual: %v", err)
+	}
+}
+
+func TestAssignToFederationMissing(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	status, err := assignToFederation(mockDB, 1, federationDSAssignment, []int{4, 5, 5}, false)
+	if err == nil || status != http.StatusBadRequest {
+		t.Errorf("assignToFederation missing delivery service expected: bad request, actual: %v %v", status, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("assignToFederation expected: rollback, actual: %v", err)
+	}
+}
+
+func TestDeleteFederationDSLast(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	if _, _, status, err := deleteFederationDS(mockDB, 1, 5); err == nil || status != http.StatusBadRequest {
+		t.Errorf("deleteFederationDS last delivery service expected: bad request, actual: %v %v", status, err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("DELETE FROM federation_deliveryservice").WithArgs(1, 5).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	if _, _, status, err := deleteFederationDS(mockDB, 1, 5); err == nil || status != http.StatusNotFound {
+		t.Errorf("deleteFederationDS unassigned delivery service expected: not found, actual: %v %v", status, err)
+	}
+}
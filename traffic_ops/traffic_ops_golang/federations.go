@@ -0,0 +1,394 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const FederationMappingsPrivLevel = auth.PrivLevelAdmin
+const FederationsPrivLevel = auth.PrivLevelReadOnly
+const FederationsWritePrivLevel = auth.PrivLevelAdmin
+
+// federationCNameRegex is what federation CNAMEs must match, the same as the Perl Traffic Ops: no spaces, and ending with a dot.
+var federationCNameRegex = regexp.MustCompile(`^\S*\.$`)
+
+// federationRequest is a request to create or update a federation. Its fields are pointers, to tell missing fields from zero values.
+type federationRequest struct {
+	CName       *string `json:"cname"`
+	TTL         *int    `json:"ttl"`
+	Description *string `json:"description"`
+}
+
+// federationMappingRow is a resolver of a federation of a delivery service. Federations without resolvers have a single row with an empty resolver type.
+type federationMappingRow struct {
+	xmlID        string
+	federationID int
+	cname        string
+	ttl          int
+	resolverIP   string
+	resolverType string
+}
+
+// federationMappingsHandler returns a handler which serves every federation of every delivery service, optionally of only the cdnName CDN, with the networks of their resolvers. It's polled by Traffic Router, at the CRConfig federationmapping.polling.url.
+func federationMappingsHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		rows, err := getFederationMappingRows(db.DB, r.URL.Query().Get("cdnName"))
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.FederationMappingsResponse{Response: makeFederationMappings(rows)})
+	}
+}
+
+// makeFederationMappings returns the federation mappings of the given rows, which must be sorted by delivery service and federation. Resolvers go in the list of their type, e.g. RESOLVE4 resolvers in resolve4.
+func makeFederationMappings(rows []federationMappingRow) []tc.FederationMapping {
+	mappings := []tc.FederationMapping{}
+	lastFedID := 0
+	for _, row := range rows {
+		if len(mappings) == 0 || mappings[len(mappings)-1].DeliveryService != row.xmlID {
+			mappings = append(mappings, tc.FederationMapping{DeliveryService: row.xmlID, Mappings: []tc.FederationResolverMapping{}})
+			lastFedID = 0
+		}
+		dsMapping := &mappings[len(mappings)-1]
+		if lastFedID != row.federationID {
+			dsMapping.Mappings = append(dsMapping.Mappings, tc.FederationResolverMapping{CName: row.cname, TTL: row.ttl})
+			lastFedID = row.federationID
+		}
+		fedMapping := &dsMapping.Mappings[len(dsMapping.Mappings)-1]
+		switch row.resolverType {
+		case tc.FederationResolverTypeIPv4:
+			fedMapping.Resolve4 = append(fedMapping.Resolve4, row.resolverIP)
+		case tc.FederationResolverTypeIPv6:
+			fedMapping.Resolve6 = append(fedMapping.Resolve6, row.resolverIP)
+		}
+	}
+	return mappings
+}
+
+func getFederationMappingRows(db *sql.DB, cdn string) ([]federationMappingRow, error) {
+	q := `
+SELECT ds.xml_id, f.id, f.cname, f.ttl, COALESCE(fr.ip_address, ''), COALESCE(t.name, '')
+FROM federation_deliveryservice fd
+JOIN federation f ON f.id = fd.federation
+JOIN deliveryservice ds ON ds.id = fd.deliveryservice
+JOIN cdn c ON c.id = ds.cdn_id
+LEFT JOIN federation_federation_resolver ffr ON ffr.federation = f.id
+LEFT JOIN federation_resolver fr ON fr.id = ffr.federation_resolver
+LEFT JOIN type t ON t.id = fr.type
+`
+	args := []interface{}{}
+	if cdn != "" {
+		q += "WHERE c.name = $1\n"
+		args = append(args, cdn)
+	}
+	q += "ORDER BY ds.xml_id, f.id, fr.id"
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying federation mappings: " + err.Error())
+	}
+	defer rows.Close()
+
+	mappingRows := []federationMappingRow{}
+	for rows.Next() {
+		row := federationMappingRow{}
+		if err := rows.Scan(&row.xmlID, &row.federationID, &row.cname, &row.ttl, &row.resolverIP, &row.resolverType); err != nil {
+			return nil, errors.New("scanning federation mappings: " + err.Error())
+		}
+		mappingRows = append(mappingRows, row)
+	}
+	return mappingRows, nil
+}
+
+// cdnFederationsHandler returns a handler which serves the federations of the delivery services of the CDN in the path, with each delivery service, skipping delivery services the user may not access.
+func cdnFederationsHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		cdn := pathParams["name"]
+		cdnExists := false
+		if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM cdn WHERE name = $1)`, cdn).Scan(&cdnExists); err != nil {
+			handleErr(errors.New("querying cdn: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if !cdnExists {
+			handleErr(errors.New("cdn not found"), http.StatusNotFound)
+			return
+		}
+
+		user, _ := auth.GetUserName(r.Context())
+		accessible, err := getDSTenantChecker(db.DB, user)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		feds, err := getCDNFederations(db.DB, cdn, accessible)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.CDNFederationsResponse{Response: feds})
+	}
+}
+
+func getCDNFederations(db *sql.DB, cdn string, accessible func(sql.NullInt64) bool) ([]tc.CDNFederation, error) {
+	q := `
+SELECT f.id, f.cname, f.ttl, f.description, ds.id, ds.xml_id, ds.tenant_id
+FROM federation f
+JOIN federation_deliveryservice fd ON fd.federation = f.id
+JOIN deliveryservice ds ON ds.id = fd.deliveryservice
+JOIN cdn c ON c.id = ds.cdn_id
+WHERE c.name = $1
+ORDER BY f.cname
+`
+	rows, err := db.Query(q, cdn)
+	if err != nil {
+		return nil, errors.New("querying federations: " + err.Error())
+	}
+	defer rows.Close()
+
+	feds := []tc.CDNFederation{}
+	for rows.Next() {
+		fed := tc.CDNFederation{DeliveryService: &tc.CDNFederationDSReference{}}
+		tenantID := sql.NullInt64{}
+		if err := rows.Scan(&fed.ID, &fed.CName, &fed.TTL, &fed.Description, &fed.DeliveryService.ID, &fed.DeliveryService.XMLID, &tenantID); err != nil {
+			return nil, errors.New("scanning federations: " + err.Error())
+		}
+		if !accessible(tenantID) {
+			continue // delivery service is outside the user's tenancy
+		}
+		feds = append(feds, fed)
+	}
+	return feds, nil
+}
+
+// cdnFederationHandler returns a handler which serves the federation in the path, in a list like the Perl Traffic Ops. It's forbidden if any of the federation's delivery services are outside the user's tenancy.
+func cdnFederationHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		fed, ok, err := getFederation(db.DB, id)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("federation not found"), http.StatusNotFound)
+			return
+		}
+
+		user, _ := auth.GetUserName(r.Context())
+		accessible, err := getDSTenantChecker(db.DB, user)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		tenantIDs, err := getFederationDSTenants(db.DB, id)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		for _, tenantID := range tenantIDs {
+			if !accessible(tenantID) {
+				handleErr(errors.New("Forbidden. Delivery-service tenant is not available to the user."), http.StatusForbidden)
+				return
+			}
+		}
+		writeJSONResp(w, handleErr, tc.CDNFederationsResponse{Response: []tc.CDNFederation{fed}})
+	}
+}
+
+// createCDNFederationHandler returns a handler which creates a federation. The federation is only in the CDN of the path once delivery services of the CDN are assigned to it.
+func createCDNFederationHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		req := federationRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if err := validateFederationRequest(req); err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+
+		fed := tc.CDNFederation{CName: *req.CName, TTL: *req.TTL, Description: req.Description}
+		q := `INSERT INTO federation (cname, ttl, description) VALUES ($1, $2, $3) RETURNING id`
+		if err := db.QueryRow(q, fed.CName, fed.TTL, fed.Description).Scan(&fed.ID); err != nil {
+			handleErr(errors.New("inserting federation: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		msg := "Federation created [ cname = " + fed.CName + " ] with id: " + strconv.Itoa(fed.ID)
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.CDNFederationResponse{Response: fed, Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+// updateCDNFederationHandler returns a handler which updates the federation in the path.
+func updateCDNFederationHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		req := federationRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if err := validateFederationRequest(req); err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+
+		fed := tc.CDNFederation{ID: id, CName: *req.CName, TTL: *req.TTL, Description: req.Description}
+		result, err := db.Exec(`UPDATE federation SET cname = $1, ttl = $2, description = $3 WHERE id = $4`, fed.CName, fed.TTL, fed.Description, id)
+		if err != nil {
+			handleErr(errors.New("updating federation: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			handleErr(errors.New("getting updated federations: "+err.Error()), http.StatusInternalServerError)
+			return
+		} else if rowsAffected == 0 {
+			handleErr(errors.New("federation not found"), http.StatusNotFound)
+			return
+		}
+
+		msg := "Federation updated [ cname = " + fed.CName + " ] with id: " + strconv.Itoa(id)
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.CDNFederationResponse{Response: fed, Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+// deleteCDNFederationHandler returns a handler which deletes the federation in the path, along with its delivery service, resolver and user assignments.
+func deleteCDNFederationHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		cname := ""
+		if err := db.QueryRow(`DELETE FROM federation WHERE id = $1 RETURNING cname`, id).Scan(&cname); err != nil {
+			if err == sql.ErrNoRows {
+				handleErr(errors.New("federation not found"), http.StatusNotFound)
+				return
+			}
+			handleErr(errors.New("deleting federation: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		msg := "Federation deleted [ cname = " + cname + " ] with id: " + strconv.Itoa(id)
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.CreateAlerts(tc.SuccessLevel, msg))
+	}
+}
+
+// validateFederationRequest returns an error listing everything wrong with a federation request, or nil if it's valid.
+func validateFederationRequest(req federationRequest) error {
+	errs := []string{}
+	if req.CName == nil || *req.CName == "" {
+		errs = append(errs, "cname is required")
+	} else if !federationCNameRegex.MatchString(*req.CName) {
+		errs = append(errs, "cname must contain no spaces and end with a dot")
+	}
+	if req.TTL == nil {
+		errs = append(errs, "ttl is required")
+	} else if *req.TTL < 0 {
+		errs = append(errs, "ttl must be a number")
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// getFederation returns the federation with the given ID, without a delivery service, and whether it exists.
+func getFederation(db *sql.DB, id int) (tc.CDNFederation, bool, error) {
+	fed := tc.CDNFederation{}
+	q := `SELECT id, cname, ttl, description FROM federation WHERE id = $1`
+	if err := db.QueryRow(q, id).Scan(&fed.ID, &fed.CName, &fed.TTL, &fed.Description); err != nil {
+		if err == sql.ErrNoRows {
+			return tc.CDNFederation{}, false, nil
+		}
+		return tc.CDNFederation{}, false, errors.New("querying federation: " + err.Error())
+	}
+	return fed, true, nil
+}
+
+// getFederationDSTenants returns the tenants of the delivery services of the given federation.
+func getFederationDSTenants(db *sql.DB, id int) ([]sql.NullInt64, error) {
+	q := `SELECT ds.tenant_id FROM deliveryservice ds JOIN federation_deliveryservice fd ON fd.deliveryservice = ds.id WHERE fd.federation = $1`
+	rows, err := db.Query(q, id)
+	if err != nil {
+		return nil, errors.New("querying federation delivery service tenants: " + err.Error())
+	}
+	defer rows.Close()
+
+	tenantIDs := []sql.NullInt64{}
+	for rows.Next() {
+		tenantID := sql.NullInt64{}
+		if err := rows.Scan(&tenantID); err != nil {
+			return nil, errors.New("scanning federation delivery service tenants: " + err.Error())
+		}
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	return tenantIDs, nil
+}
+
+// getPathFederationID returns the federation ID in the path, and the status to respond with if it's missing or invalid.
+func getPathFederationID(r *http.Request) (int, int, error) {
+	pathParams, err := getPathParams(r.Context())
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	id, err := strconv.Atoi(pathParams["id"])
+	if err != nil {
+		return 0, http.StatusBadRequest, errors.New("federation id must be an integer")
+	}
+	return id, http.StatusOK, nil
+}
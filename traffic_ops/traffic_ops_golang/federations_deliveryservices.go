@@ -0,0 +1,194 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// federationDSesHandler returns a handler which serves the delivery services of the federation in the path.
+func federationDSesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		dses, err := getFederationDSes(db.DB, id)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.FederationDeliveryServicesResponse{Response: dses})
+	}
+}
+
+// assignFederationDSesHandler returns a handler which assigns delivery services to the federation in the path, replacing its delivery services if the request says to. A federation must keep at least one delivery service.
+func assignFederationDSesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		req := tc.AssignFederationDSesRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if req.DSIDs == nil {
+			handleErr(errors.New("Delivery Service IDs must be an array"), http.StatusBadRequest)
+			return
+		}
+		if req.Replace && len(req.DSIDs) == 0 {
+			handleErr(errors.New("A federation must have at least one delivery service assigned"), http.StatusBadRequest)
+			return
+		}
+
+		fed, ok, err := getFederation(db.DB, id)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("federation not found"), http.StatusNotFound)
+			return
+		}
+		if status, err := assignToFederation(db.DB, id, federationDSAssignment, req.DSIDs, req.Replace); err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		msg := strconv.Itoa(len(req.DSIDs)) + " delivery service(s) were assigned to the " + fed.CName + " federation"
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.AssignFederationDSesResponse{Response: req, Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+// deleteFederationDSHandler returns a handler which removes the delivery service in the path from the federation in the path, unless it's the federation's last delivery service.
+func deleteFederationDSHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathFederationID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		dsID, err := strconv.Atoi(pathParams["dsID"])
+		if err != nil {
+			handleErr(errors.New("delivery service id must be an integer"), http.StatusBadRequest)
+			return
+		}
+
+		cname, xmlID, status, err := deleteFederationDS(db.DB, id, dsID)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		msg := "Removed delivery service [ " + xmlID + " ] from federation [ " + cname + " ]"
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.CreateAlerts(tc.SuccessLevel, msg))
+	}
+}
+
+func getFederationDSes(db *sql.DB, id int) ([]tc.FederationDeliveryService, error) {
+	q := `
+SELECT ds.id, c.name, t.name, ds.xml_id
+FROM deliveryservice ds
+JOIN federation_deliveryservice fd ON fd.deliveryservice = ds.id
+JOIN cdn c ON c.id = ds.cdn_id
+JOIN type t ON t.id = ds.type
+WHERE fd.federation = $1
+ORDER BY ds.xml_id
+`
+	rows, err := db.Query(q, id)
+	if err != nil {
+		return nil, errors.New("querying federation delivery services: " + err.Error())
+	}
+	defer rows.Close()
+
+	dses := []tc.FederationDeliveryService{}
+	for rows.Next() {
+		ds := tc.FederationDeliveryService{}
+		if err := rows.Scan(&ds.ID, &ds.CDN, &ds.Type, &ds.XMLID); err != nil {
+			return nil, errors.New("scanning federation delivery services: " + err.Error())
+		}
+		dses = append(dses, ds)
+	}
+	return dses, nil
+}
+
+// deleteFederationDS removes the given delivery service from the given federation, and returns the federation CNAME and delivery service xml_id. Returns an error with the status to respond with if the delivery service isn't assigned, or is the federation's last.
+func deleteFederationDS(db *sql.DB, fedID int, dsID int) (string, string, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", "", http.StatusInternalServerError, errors.New("beginning transaction: " + err.Error())
+	}
+	commit := false
+	defer func() {
+		if !commit {
+			tx.Rollback()
+		}
+	}()
+
+	count := 0
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM federation_deliveryservice WHERE federation = $1`, fedID).Scan(&count); err != nil {
+		return "", "", http.StatusInternalServerError, errors.New("querying federation delivery services: " + err.Error())
+	}
+	if count < 2 {
+		return "", "", http.StatusBadRequest, errors.New("A federation must have at least one delivery service assigned")
+	}
+
+	cname := ""
+	xmlID := ""
+	q := `
+DELETE FROM federation_deliveryservice fd
+USING federation f, deliveryservice ds
+WHERE fd.federation = $1 AND fd.deliveryservice = $2 AND f.id = fd.federation AND ds.id = fd.deliveryservice
+RETURNING f.cname, ds.xml_id
+`
+	if err := tx.QueryRow(q, fedID, dsID).Scan(&cname, &xmlID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", "", http.StatusNotFound, errors.New("delivery service not assigned to federation")
+		}
+		return "", "", http.StatusInternalServerError, errors.New("deleting federation delivery service: " + err.Error())
+	}
+	if err := tx.Commit(); err != nil {
+		return "", "", http.StatusInternalServerError, errors.New("committing federation delivery service: " + err.Error())
+	}
+	commit = true
+	return cname, xmlID, http.StatusOK, nil
+}
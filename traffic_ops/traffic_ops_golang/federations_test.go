@@ -0,0 +1,123 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMakeFederationMappings(t *testing.T) {
+	rows := []federationMappingRow{
+		{xmlID: "ds1", federationID: 1, cname: "fed1.example.net.", ttl: 60, resolverIP: "192.0.2.0/24", resolverType: tc.FederationResolverTypeIPv4},
+		{xmlID: "ds1", federationID: 1, cname: "fed1.example.net.", ttl: 60, resolverIP: "2001:db8::/32", resolverType: tc.FederationResolverTypeIPv6},
+		{xmlID: "ds1", federationID: 1, cname: "fed1.example.net.", ttl: 60, resolverIP: "198.51.100.1", resolverType: tc.FederationResolverTypeIPv4},
+		{xmlID: "ds1", federationID: 2, cname: "fed2.example.net.", ttl: 30},
+		{xmlID: "ds2", federationID: 1, cname: "fed1.example.net.", ttl: 60, resolverIP: "192.0.2.0/24", resolverType: tc.FederationResolverTypeIPv4},
+	}
+	expected := []tc.FederationMapping{
+		{DeliveryService: "ds1", Mappings: []tc.FederationResolverMapping{
+			{CName: "fed1.example.net.", TTL: 60, Resolve4: []string{"192.0.2.0/24", "198.51.100.1"}, Resolve6: []string{"2001:db8::/32"}},
+			{CName: "fed2.example.net.", TTL: 30},
+		}},
+		{DeliveryService: "ds2", Mappings: []tc.FederationResolverMapping{
+			{CName: "fed1.example.net.", TTL: 60, Resolve4: []string{"192.0.2.0/24"}},
+		}},
+	}
+	if actual := makeFederationMappings(rows); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("makeFederationMappings expected: %+v, actual: %+v", expected, actual)
+	}
+	if actual := makeFederationMappings(nil); len(actual) != 0 || actual == nil {
+		t.Errorf("makeFederationMappings no rows expected: empty list, actual: %+v", actual)
+	}
+}
+
+func TestGetFederationMappingRows(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	rows := sqlmock.NewRows([]string{"xml_id", "id", "cname", "ttl", "ip_address", "name"}).
+		AddRow("ds1", 1, "fed1.example.net.", 60, "192.0.2.0/24", tc.FederationResolverTypeIPv4)
+	mock.ExpectQuery("SELECT ds.xml_id").WithArgs("cdn1").WillReturnRows(rows)
+
+	actual, err := getFederationMappingRows(mockDB, "cdn1")
+	if err != nil {
+		t.Fatalf("getFederationMappingRows expected: nil error, actual: %v", err)
+	}
+	expected := []federationMappingRow{{xmlID: "ds1", federationID: 1, cname: "fed1.example.net.", ttl: 60, resolverIP: "192.0.2.0/24", resolverType: tc.FederationResolverTypeIPv4}}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("getFederationMappingRows expected: %+v, actual: %+v", expected, actual)
+	}
+}
+
+func TestGetCDNFederationsTenancy(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "cname", "ttl", "description", "id", "xml_id", "tenant_id"}).
+		AddRow(1, "fed1.example.net.", 60, "a federation", 5, "ds1", 2).
+		AddRow(2, "fed2.example.net.", 30, nil, 6, "ds2", 3)
+	mock.ExpectQuery("SELECT f.id").WithArgs("cdn1").WillReturnRows(rows)
+
+	accessible := func(tenant sql.NullInt64) bool { return tenant.Int64 == 2 }
+	feds, err := getCDNFederations(mockDB, "cdn1", accessible)
+	if err != nil {
+		t.Fatalf("getCDNFederations expected: nil error, actual: %v", err)
+	}
+	if len(feds) != 1 || feds[0].ID != 1 || feds[0].DeliveryService == nil || feds[0].DeliveryService.XMLID != "ds1" {
+		t.Errorf("getCDNFederations expected: only the federation of the accessible delivery service, actual: %+v", feds)
+	}
+	if feds[0].Description == nil || *feds[0].Description != "a federation" {
+		t.Errorf("getCDNFederations description expected: 'a federation', actual: %v", feds[0].Description)
+	}
+}
+
+func TestValidateFederationRequest(t *testing.T) {
+	str := func(s string) *string { return &s }
+	ttl := 60
+	negative := -1
+	for _, test := range []struct {
+		req   federationRequest
+		valid bool
+	}{
+		{federationRequest{CName: str("fed.example.net."), TTL: &ttl}, true},
+		{federationRequest{CName: str("fed.example.net."), TTL: &ttl, Description: str("desc")}, true},
+		{federationRequest{CName: str("fed.example.net"), TTL: &ttl}, false},
+		{federationRequest{CName: str("fed example.net."), TTL: &ttl}, false},
+		{federationRequest{TTL: &ttl}, false},
+		{federationRequest{CName: str("fed.example.net.")}, false},
+		{federationRequest{CName: str("fed.example.net."), TTL: &negative}, false},
+	} {
+		if err := validateFederationRequest(test.req); (err == nil) != test.valid {
+			t.Errorf("validateFederationRequest %+v expected valid: %v, actual: %v", test.req, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,484 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/ats"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const JobsPrivLevel = auth.PrivLevelReadOnly
+const JobPrivLevel = auth.PrivLevelOperations
+
+// the job agent and job status the Perl Traffic Ops gives invalidation jobs, which are the first in their tables
+const JobAgentID = 1
+const JobStatusPendingID = 1
+const JobAssetType = "file"
+
+// JobStartDelay is how long after their start time jobs which aren't urgent start, to give caches time to get them.
+const JobStartDelay = time.Minute
+
+// MaxJobStartTimeDistance is how far from now the start time of a new job may be.
+const MaxJobStartTimeDistance = 48 * time.Hour
+
+const UseRevalPendingParamName = "use_reval_pending"
+
+// jobDS is the data about the delivery service of a job needed to create it.
+type jobDS struct {
+	xmlID         string
+	tenantID      sql.NullInt64
+	orgServerFQDN sql.NullString
+}
+
+// jobsHandler returns a handler which serves the invalidation jobs of delivery services the user may access, newest first, optionally of only the dsId delivery service or userId user.
+func jobsHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		filters := map[string]int{}
+		for param, col := range map[string]string{"dsId": "j.job_deliveryservice", "userId": "j.job_user"} {
+			val := r.URL.Query().Get(param)
+			if val == "" {
+				continue
+			}
+			id, err := strconv.Atoi(val)
+			if err != nil {
+				handleErr(errors.New(param+" must be an integer"), http.StatusBadRequest)
+				return
+			}
+			filters[col] = id
+		}
+
+		if dsID, ok := filters["j.job_deliveryservice"]; ok {
+			if status, err := checkJobDSAccess(db.DB, r, dsID); err != nil {
+				handleErr(err, status)
+				return
+			}
+		}
+
+		accessible, err := getJobAccessChecker(db.DB, r)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		jobs, err := getJobs(db.DB, filters, accessible)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJobs(w, handleErr, jobs)
+	}
+}
+
+// jobHandler returns a handler which serves the invalidation job in the path, if the user may access its delivery service.
+func jobHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathJobID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		accessible, err := getJobAccessChecker(db.DB, r)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		jobs, err := getJobs(db.DB, map[string]int{"j.id": id}, accessible)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJobs(w, handleErr, jobs)
+	}
+}
+
+// createJobHandler returns a handler which creates an invalidation job as the current user, and sets the caches of the delivery service's CDN to revalidate.
+func createJobHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		req := tc.InvalidationJobRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		maxDays, err := ats.GetMaxRevalDurationDays(db.DB)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		startTime, err := validateJobRequest(req, maxDays, time.Now())
+		if err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+
+		ds, status, err := getJobDSWithAccess(db.DB, r, req.DSID)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		if !ds.orgServerFQDN.Valid {
+			handleErr(errors.New("delivery service "+ds.xmlID+" has no origin"), http.StatusBadRequest)
+			return
+		}
+
+		if !req.Urgent {
+			startTime = startTime.Add(JobStartDelay)
+		}
+		user, _ := auth.GetUserName(r.Context())
+		assetURL := jobAssetURL(ds.orgServerFQDN.String, req.Regex)
+		params := ats.JobTTLParameters(req.TTL)
+		id, err := createJob(db.DB, req.DSID, assetURL, params, startTime, user)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		jobs, err := getJobs(db.DB, map[string]int{"j.id": id}, allJobsAccessible)
+		if err != nil || len(jobs) == 0 {
+			handleErr(fmt.Errorf("getting created job %v: %v", id, err), http.StatusInternalServerError)
+			return
+		}
+
+		msg := "Invalidate content request submitted for " + ds.xmlID + " [ " + assetURL + " - " + params + " ]"
+		if err := logChange(db.DB, user, msg); err != nil {
+			log.Errorf("creating job %v succeeded, but logging the change failed: %v\n", id, err)
+		}
+		writeJob(w, handleErr, jobs[0], msg)
+	}
+}
+
+// deleteJobHandler returns a handler which deletes the invalidation job in the path, and sets the caches of its delivery service's CDN to revalidate, so they stop invalidating its content.
+func deleteJobHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathJobID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		jobs, err := getJobs(db.DB, map[string]int{"j.id": id}, allJobsAccessible)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if len(jobs) == 0 {
+			handleErr(errors.New("job not found"), http.StatusNotFound)
+			return
+		}
+
+		dsID := 0
+		if err := db.QueryRow(`SELECT COALESCE(job_deliveryservice, 0) FROM job WHERE id = $1`, id).Scan(&dsID); err != nil {
+			handleErr(errors.New("querying job delivery service: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if dsID != 0 {
+			if status, err := checkJobDSAccess(db.DB, r, dsID); err != nil {
+				handleErr(err, status)
+				return
+			}
+		}
+
+		if err := deleteJob(db.DB, id, dsID); err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		user, _ := auth.GetUserName(r.Context())
+		msg := "Deleted invalidate content request " + strconv.Itoa(id) + " for " + jobs[0].DeliveryService + " [ " + jobs[0].AssetURL + " - " + jobs[0].Parameters + " ]"
+		if err := logChange(db.DB, user, msg); err != nil {
+			log.Errorf("deleting job %v succeeded, but logging the change failed: %v\n", id, err)
+		}
+		writeJob(w, handleErr, jobs[0], msg)
+	}
+}
+
+func writeJobs(w http.ResponseWriter, handleErr func(error, int), jobs []tc.Job) {
+	respBts, err := json.Marshal(tc.JobsResponse{Response: jobs})
+	if err != nil {
+		handleErr(err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(tc.ContentType, tc.ApplicationJson)
+	fmt.Fprintf(w, "%s", respBts)
+}
+
+func writeJob(w http.ResponseWriter, handleErr func(error, int), job tc.Job, msg string) {
+	respBts, err := json.Marshal(tc.JobResponse{Response: job, Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+	if err != nil {
+		handleErr(err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(tc.ContentType, tc.ApplicationJson)
+	fmt.Fprintf(w, "%s", respBts)
+}
+
+func getPathJobID(r *http.Request) (int, int, error) {
+	pathParams, err := getPathParams(r.Context())
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	id, err := strconv.Atoi(pathParams["id"])
+	if err != nil {
+		return 0, http.StatusBadRequest, errors.New("job id must be an integer")
+	}
+	return id, http.StatusOK, nil
+}
+
+// validateJobRequest returns the start time of a job request, or an error listing everything wrong with it, the same as the Perl Traffic Ops: the TTL must be between 1 hour and maxDays, and the start time within two days of now.
+func validateJobRequest(req tc.InvalidationJobRequest, maxDays int, now time.Time) (time.Time, error) {
+	errs := []string{}
+	if req.DSID == 0 {
+		errs = append(errs, "dsId is required")
+	}
+	if req.Regex == "" {
+		errs = append(errs, "regex is required")
+	}
+	if req.TTL == 0 {
+		errs = append(errs, "ttl is required")
+	} else if maxHours := maxDays * 24; req.TTL < ats.MinJobTTLHours || req.TTL > maxHours {
+		errs = append(errs, fmt.Sprintf("ttl should be between %d and %d", ats.MinJobTTLHours, maxHours))
+	}
+
+	startTime := time.Time{}
+	if req.StartTime == "" {
+		errs = append(errs, "startTime is required")
+	} else if t, err := time.Parse(tc.JobStartTimeLayout, req.StartTime); err != nil {
+		errs = append(errs, "startTime has an invalid date format, should be in the form of YYYY-MM-DD HH:MM:SS")
+	} else if diff := t.Sub(now); diff > MaxJobStartTimeDistance || diff < -MaxJobStartTimeDistance {
+		errs = append(errs, "startTime needs to be within two days from now")
+	} else {
+		startTime = t
+	}
+
+	if len(errs) > 0 {
+		return time.Time{}, errors.New(strings.Join(errs, ", "))
+	}
+	return startTime, nil
+}
+
+// jobAssetURL returns the asset URL of a job, the regular expression of the URLs it invalidates: the path regex on the delivery service's origin.
+func jobAssetURL(orgServerFQDN string, regex string) string {
+	return strings.TrimSuffix(orgServerFQDN, "/") + "/" + strings.TrimPrefix(regex, "/")
+}
+
+// getJobDSWithAccess returns the delivery service with the given ID, and an error with the status to respond with if it doesn't exist or the current user may not access it.
+func getJobDSWithAccess(db *sql.DB, r *http.Request, dsID int) (jobDS, int, error) {
+	ds := jobDS{}
+	q := `SELECT xml_id, tenant_id, org_server_fqdn FROM deliveryservice WHERE id = $1`
+	if err := db.QueryRow(q, dsID).Scan(&ds.xmlID, &ds.tenantID, &ds.orgServerFQDN); err != nil {
+		if err == sql.ErrNoRows {
+			return jobDS{}, http.StatusNotFound, errors.New("delivery service not found")
+		}
+		return jobDS{}, http.StatusInternalServerError, errors.New("querying delivery service: " + err.Error())
+	}
+
+	user, err := auth.GetUserName(r.Context())
+	if err != nil {
+		return jobDS{}, http.StatusInternalServerError, err
+	}
+	privLevel, err := auth.GetPrivLevel(r.Context())
+	if err != nil {
+		return jobDS{}, http.StatusInternalServerError, err
+	}
+	ok, err := isDSAccessible(db, user, privLevel, dsID, ds.tenantID)
+	if err != nil {
+		return jobDS{}, http.StatusInternalServerError, err
+	}
+	if !ok {
+		return jobDS{}, http.StatusForbidden, errors.New("Forbidden. Delivery-service tenant is not available to the user.")
+	}
+	return ds, http.StatusOK, nil
+}
+
+// checkJobDSAccess returns an error with the status to respond with if the delivery service with the given ID doesn't exist or the current user may not access it.
+func checkJobDSAccess(db *sql.DB, r *http.Request, dsID int) (int, error) {
+	_, status, err := getJobDSWithAccess(db, r, dsID)
+	return status, err
+}
+
+// getJobAccessChecker returns a func which returns whether the current user may access the jobs of delivery services.
+func getJobAccessChecker(db *sql.DB, r *http.Request) (func(dsID int, dsTenant sql.NullInt64) bool, error) {
+	user, err := auth.GetUserName(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	privLevel, err := auth.GetPrivLevel(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	return getDSAccessChecker(db, user, privLevel)
+}
+
+// allJobsAccessible is the access checker of getJobs for jobs whose access has already been checked.
+func allJobsAccessible(int, sql.NullInt64) bool { return true }
+
+// getJobs returns the jobs matching all the given column values whose delivery services are accessible, newest first. Jobs without a delivery service are checked as delivery service 0 without a tenant.
+func getJobs(db *sql.DB, filters map[string]int, accessible func(dsID int, dsTenant sql.NullInt64) bool) ([]tc.Job, error) {
+	q := `
+SELECT j.id, j.asset_url, COALESCE(ds.xml_id, ''), j.keyword, COALESCE(j.parameters, ''), j.start_time, u.username, COALESCE(ds.id, 0), ds.tenant_id
+FROM job j
+LEFT JOIN deliveryservice ds ON ds.id = j.job_deliveryservice
+JOIN tm_user u ON u.id = j.job_user
+`
+	cols := []string{}
+	for col := range filters {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+	wheres := []string{}
+	args := []interface{}{}
+	for _, col := range cols {
+		args = append(args, filters[col])
+		wheres = append(wheres, col+" = $"+strconv.Itoa(len(args)))
+	}
+	if len(wheres) > 0 {
+		q += "WHERE " + strings.Join(wheres, " AND ") + "\n"
+	}
+	q += "ORDER BY j.start_time DESC"
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying jobs: " + err.Error())
+	}
+	defer rows.Close()
+
+	jobs := []tc.Job{}
+	for rows.Next() {
+		job := tc.Job{}
+		startTime := time.Time{}
+		dsID := 0
+		dsTenant := sql.NullInt64{}
+		if err := rows.Scan(&job.ID, &job.AssetURL, &job.DeliveryService, &job.Keyword, &job.Parameters, &startTime, &job.CreatedBy, &dsID, &dsTenant); err != nil {
+			return nil, errors.New("scanning jobs: " + err.Error())
+		}
+		if !accessible(dsID, dsTenant) {
+			continue
+		}
+		job.StartTime = startTime.UTC().Format(tc.TimeLayout)
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// createJob inserts an invalidation job as the given user, and sets the caches of the delivery service's CDN to revalidate, returning the new job's ID.
+func createJob(db *sql.DB, dsID int, assetURL string, params string, startTime time.Time, user string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, errors.New("beginning transaction: " + err.Error())
+	}
+	commit := false
+	defer func() {
+		if !commit {
+			tx.Rollback()
+		}
+	}()
+
+	q := `
+INSERT INTO job (agent, keyword, parameters, asset_url, asset_type, status, start_time, entered_time, job_user, job_deliveryservice)
+VALUES ($1, $2, $3, $4, $5, $6, $7, now(), (SELECT id FROM tm_user WHERE username = $8), $9)
+RETURNING id
+`
+	id := 0
+	if err := tx.QueryRow(q, JobAgentID, ats.JobKeywordPurge, params, assetURL, JobAssetType, JobStatusPendingID, startTime, user, dsID).Scan(&id); err != nil {
+		return 0, errors.New("inserting job: " + err.Error())
+	}
+	if err := setRevalPending(tx, dsID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.New("committing job: " + err.Error())
+	}
+	commit = true
+	return id, nil
+}
+
+// deleteJob deletes the given job, and sets the caches of its delivery service's CDN to revalidate. A dsID of 0 is a job without a delivery service.
+func deleteJob(db *sql.DB, id int, dsID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return errors.New("beginning transaction: " + err.Error())
+	}
+	commit := false
+	defer func() {
+		if !commit {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err := tx.Exec(`DELETE FROM job WHERE id = $1`, id); err != nil {
+		return errors.New("deleting job: " + err.Error())
+	}
+	if dsID != 0 {
+		if err := setRevalPending(tx, dsID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.New("committing job deletion: " + err.Error())
+	}
+	commit = true
+	return nil
+}
+
+// setRevalPending sets the caches of the given delivery service's CDN which get regex_revalidate.config from Traffic Ops to revalidate, the same as the Perl Traffic Ops: reval_pending if the use_reval_pending global parameter is set and not 0, otherwise upd_pending. Offline and pre-production caches are skipped.
+func setRevalPending(tx *sql.Tx, dsID int) error {
+	useRevalPending := false
+	q := `SELECT COALESCE((SELECT value <> '0' FROM parameter WHERE name = $1 AND config_file = 'global' LIMIT 1), FALSE)`
+	if err := tx.QueryRow(q, UseRevalPendingParamName).Scan(&useRevalPending); err != nil {
+		return errors.New("querying " + UseRevalPendingParamName + ": " + err.Error())
+	}
+
+	col := "upd_pending"
+	if useRevalPending {
+		col = "reval_pending"
+	}
+	q = `
+UPDATE server SET ` + col + ` = TRUE
+WHERE cdn_id = (SELECT cdn_id FROM deliveryservice WHERE id = $1)
+AND status NOT IN (SELECT id FROM status WHERE name IN ('OFFLINE', 'PRE_PROD'))
+AND profile IN (
+  SELECT pp.profile FROM profile_parameter pp
+  JOIN parameter pa ON pa.id = pp.parameter
+  WHERE pa.name = 'location' AND pa.config_file = $2
+)
+`
+	if _, err := tx.Exec(q, dsID, ats.RegexRevalidateConfigFile); err != nil {
+		return errors.New("setting servers " + col + ": " + err.Error())
+	}
+	return nil
+}
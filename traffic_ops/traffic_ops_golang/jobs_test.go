@@ -0,0 +1,93 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestValidateJobRequest(t *testing.T) {
+	now := time.Date(2017, 11, 1, 12, 0, 0, 0, time.UTC)
+	req := tc.InvalidationJobRequest{DSID: 1, Regex: "/foo/.*", TTL: 24, StartTime: "2017-11-02 11:00:00"}
+	actual, err := validateJobRequest(req, 7, now)
+	if err != nil {
+		t.Fatalf("validateJobRequest expected: nil error, actual: %v", err)
+	}
+	if expected := time.Date(2017, 11, 2, 11, 0, 0, 0, time.UTC); !actual.Equal(expected) {
+		t.Errorf("validateJobRequest expected: %v, actual: %v", expected, actual)
+	}
+
+	invalid := map[string]tc.InvalidationJobRequest{
+		"dsId is required, regex is required, ttl is required, startTime is required":        {},
+		"ttl should be between 1 and 168":                                                    {DSID: 1, Regex: "/foo", TTL: 169, StartTime: "2017-11-01 12:00:00"},
+		"startTime needs to be within two days from now":                                     {DSID: 1, Regex: "/foo", TTL: 1, StartTime: "2017-11-03 12:00:01"},
+		"startTime has an invalid date format, should be in the form of YYYY-MM-DD HH:MM:SS": {DSID: 1, Regex: "/foo", TTL: 1, StartTime: "2017-11-01T12:00:00Z"},
+	}
+	for expected, req := range invalid {
+		if _, err := validateJobRequest(req, 7, now); err == nil || err.Error() != expected {
+			t.Errorf("validateJobRequest %+v expected: error '%s', actual: %v", req, expected, err)
+		}
+	}
+}
+
+func TestJobAssetURL(t *testing.T) {
+	for regex, expected := range map[string]string{
+		"/foo/.*\\.jpg": "http://origin.example.net/foo/.*\\.jpg",
+		"foo/.*":        "http://origin.example.net/foo/.*",
+	} {
+		if actual := jobAssetURL("http://origin.example.net", regex); actual != expected {
+			t.Errorf("jobAssetURL(%s) expected: %s, actual: %s", regex, expected, actual)
+		}
+	}
+}
+
+func TestGetJobs(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	startTime := time.Date(2017, 11, 1, 12, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "asset_url", "xml_id", "keyword", "parameters", "start_time", "username", "ds_id", "tenant_id"}).
+		AddRow(2, "http://origin.example.net/foo/.*", "ds1", "PURGE", "TTL:24h", startTime, "admin", 1, 5).
+		AddRow(3, "http://origin.example.net/bar/.*", "ds1", "PURGE", "TTL:48h", startTime, "admin", 1, 6)
+	mock.ExpectQuery("SELECT j.id").WithArgs(1, 3).WillReturnRows(rows)
+
+	accessible := func(dsID int, dsTenant sql.NullInt64) bool { return dsTenant.Int64 == 5 }
+	actual, err := getJobs(mockDB, map[string]int{"j.job_user": 3, "j.job_deliveryservice": 1}, accessible)
+	if err != nil {
+		t.Fatalf("getJobs expected: nil error, actual: %v", err)
+	}
+	expected := []tc.Job{{ID: 2, AssetURL: "http://origin.example.net/foo/.*", DeliveryService: "ds1", Keyword: "PURGE", Parameters: "TTL:24h", StartTime: "2017-11-01 12:00:00+00", CreatedBy: "admin"}}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("getJobs expected: %+v, actual: %+v", expected, actual)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("getJobs expected: all queries run, actual: %v", err)
+	}
+}
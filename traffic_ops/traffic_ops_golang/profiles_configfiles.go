@@ -0,0 +1,80 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/ats"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// profileParamConfigHandler returns a handler which serves the profile scoped config file in the path generated from the parameters of the profile in the path, by name or ID. Config files which aren't generated from parameters are served by fallback.
+func profileParamConfigHandler(db *sqlx.DB, fallback http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		fileName := pathParams["file"]
+		if !ats.IsParamConfig(fileName) {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+
+		profile, ok, err := ats.GetProfileInfo(db.DB, pathParams["profile"])
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("profile not found"), http.StatusNotFound)
+			return
+		}
+
+		if status, err := checkParamConfig(db.DB, fileName, "", ats.ScopeProfiles); err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		params, err := ats.GetParamData(db.DB, profile.ID, fileName, ats.ProfileHostNamePlaceholder)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		nameVersion, err := ats.GetNameVersionString(db.DB)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(tc.ContentType, TextPlain)
+		fmt.Fprintf(w, "%s", ats.ParamConfig(fileName, params, ats.HeaderComment(profile.Name, nameVersion, time.Now())))
+	}
+}
@@ -22,6 +22,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
 	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
 	"github.com/basho/riak-go-client"
 	"github.com/jmoiron/sqlx"
@@ -39,6 +40,9 @@ const CDNURIKeysBucket = "cdn_uri_sig_keys"
 // SSLKeysBucket
 const SSLKeysBucket = "ssl"
 
+// DNSSECKeysBucket is the namespace or bucket used for the DNSSEC keys of CDNs, keyed by CDN name.
+const DNSSECKeysBucket = "dnssec"
+
 // 5 second timeout
 const timeOut = time.Second * 5
 
@@ -195,6 +199,23 @@ func getRiakCluster(db *sqlx.DB, cfg Config) (StorageCluster, error) {
 	return RiakStorageCluster{Cluster: cluster}, err
 }
 
+// withRiakCluster starts a cluster of the online riak servers, calls f with it, and stops it.
+func withRiakCluster(db *sqlx.DB, cfg Config, f func(StorageCluster) error) error {
+	cluster, err := getRiakCluster(db, cfg)
+	if err != nil {
+		return err
+	}
+	if err = cluster.Start(); err != nil {
+		return err
+	}
+	defer func() {
+		if err := cluster.Stop(); err != nil {
+			log.Errorf("%v\n", err)
+		}
+	}()
+	return f(cluster)
+}
+
 // validates URISigingKeyset json.
 func validateURIKeyset(msg map[string]URISignerKeyset) error {
 	var renewalKidFound int
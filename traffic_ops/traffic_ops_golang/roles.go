@@ -0,0 +1,433 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const RolesPrivLevel = auth.PrivLevelReadOnly
+const RolesWritePrivLevel = auth.PrivLevelAdmin
+
+// rolesHandler returns a handler which serves every role, with its capabilities.
+func rolesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		roles, err := getRoles(db.DB, 0)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.RolesResponse{Response: roles})
+	}
+}
+
+// createRoleHandler returns a handler which creates a role with the requested capabilities.
+func createRoleHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		role, err := decodeRole(r)
+		if err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+		id, status, err := saveRole(db.DB, 0, role)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		logRequestChange(db.DB, r, "Created Role: '"+role.Name+"' with capabilities ["+strings.Join(role.Capabilities, ", ")+"]")
+		writeRoleResp(db.DB, w, handleErr, id, "Role was created.")
+	}
+}
+
+// updateRoleHandler returns a handler which updates the role in the path, replacing its capabilities with the requested ones.
+func updateRoleHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathRoleID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		role, err := decodeRole(r)
+		if err != nil {
+			handleErr(err, http.StatusBadRequest)
+			return
+		}
+		if _, status, err := saveRole(db.DB, id, role); err != nil {
+			handleErr(err, status)
+			return
+		}
+		logRequestChange(db.DB, r, "Updated Role: '"+role.Name+"' with capabilities ["+strings.Join(role.Capabilities, ", ")+"]")
+		writeRoleResp(db.DB, w, handleErr, id, "Role was updated.")
+	}
+}
+
+// deleteRoleHandler returns a handler which deletes the role in the path, unless a user has it.
+func deleteRoleHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		id, status, err := getPathRoleID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		users := 0
+		q := `SELECT (SELECT COUNT(*) FROM tm_user WHERE role = $1) + (SELECT COUNT(*) FROM user_role WHERE role_id = $1)`
+		if err := db.QueryRow(q, id).Scan(&users); err != nil {
+			handleErr(errors.New("querying role users: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if users > 0 {
+			handleErr(errors.New("Role is assigned to "+strconv.Itoa(users)+" user(s). Deletion failed."), http.StatusBadRequest)
+			return
+		}
+
+		name := ""
+		if err := db.QueryRow(`DELETE FROM role WHERE id = $1 RETURNING name`, id).Scan(&name); err != nil {
+			if err == sql.ErrNoRows {
+				handleErr(errors.New("role not found"), http.StatusNotFound)
+				return
+			}
+			handleErr(errors.New("deleting role: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		logRequestChange(db.DB, r, "Deleted Role: '"+name+"'")
+		writeJSONResp(w, handleErr, tc.CreateAlerts(tc.SuccessLevel, "Role deleted."))
+	}
+}
+
+// userRolesHandler returns a handler which serves the roles of the user in the path, other than their primary role.
+func userRolesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		userID, status, err := getPathUserID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		exists := false
+		if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM tm_user WHERE id = $1)`, userID).Scan(&exists); err != nil {
+			handleErr(errors.New("querying user: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			handleErr(errors.New("user not found"), http.StatusNotFound)
+			return
+		}
+		roleIDs, err := getUserRoleIDs(db.DB, userID)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.UserRolesResponse{Response: tc.UserRoles{UserID: userID, RoleIDs: roleIDs}})
+	}
+}
+
+// setUserRolesHandler returns a handler which replaces the roles of the user in the path, other than their primary role.
+func setUserRolesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		userID, status, err := getPathUserID(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		req := tc.UserRoles{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if req.RoleIDs == nil {
+			handleErr(errors.New("Role IDs must be an array"), http.StatusBadRequest)
+			return
+		}
+		req.UserID = userID
+
+		userName, status, err := setUserRoles(db.DB, userID, req.RoleIDs)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		msg := strconv.Itoa(len(req.RoleIDs)) + " role(s) were assigned to user " + userName
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.SetUserRolesResponse{Response: req, Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+func decodeRole(r *http.Request) (tc.Role, error) {
+	role := tc.Role{}
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		return tc.Role{}, errors.New("malformed JSON: " + err.Error())
+	}
+	if err := validateRole(role); err != nil {
+		return tc.Role{}, err
+	}
+	return role, nil
+}
+
+func validateRole(role tc.Role) error {
+	if role.Name == "" {
+		return errors.New("Name is required.")
+	}
+	if role.PrivLevel < 0 || role.PrivLevel > auth.PrivLevelAdmin {
+		return errors.New("privLevel must be between 0 and " + strconv.Itoa(auth.PrivLevelAdmin) + ".")
+	}
+	seen := map[string]struct{}{}
+	for _, capability := range role.Capabilities {
+		if _, ok := seen[capability]; ok {
+			return errors.New("capability '" + capability + "' is listed more than once")
+		}
+		seen[capability] = struct{}{}
+	}
+	return nil
+}
+
+// writeRoleResp writes the role with the given ID, with the given success message.
+func writeRoleResp(db *sql.DB, w http.ResponseWriter, handleErr func(error, int), id int, msg string) {
+	roles, err := getRoles(db, id)
+	if err != nil {
+		handleErr(err, http.StatusInternalServerError)
+		return
+	}
+	if len(roles) == 0 {
+		handleErr(errors.New("role not found after save"), http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, handleErr, tc.RoleResponse{Response: roles[0], Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+}
+
+// getRoles returns every role or, if id isn't 0, the role with that ID.
+func getRoles(db *sql.DB, id int) ([]tc.Role, error) {
+	q := `
+SELECT r.id, r.name, r.description, r.priv_level, ARRAY_REMOVE(ARRAY_AGG(rc.cap_name ORDER BY rc.cap_name), NULL)
+FROM role r
+LEFT JOIN role_capability rc ON rc.role_id = r.id
+`
+	args := []interface{}{}
+	if id != 0 {
+		q += `WHERE r.id = $1
+`
+		args = append(args, id)
+	}
+	q += `GROUP BY r.id
+ORDER BY r.name
+`
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying roles: " + err.Error())
+	}
+	defer rows.Close()
+
+	roles := []tc.Role{}
+	for rows.Next() {
+		role := tc.Role{}
+		caps := []string{}
+		if err := rows.Scan(&role.ID, &role.Name, &role.Description, &role.PrivLevel, pq.Array(&caps)); err != nil {
+			return nil, errors.New("scanning roles: " + err.Error())
+		}
+		role.Capabilities = caps
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// saveRole creates the role if id is 0, and otherwise updates the role with that ID, replacing its capabilities. Returns the role ID, or an error with the status to respond with.
+func saveRole(db *sql.DB, id int, role tc.Role) (int, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, http.StatusInternalServerError, errors.New("beginning transaction: " + err.Error())
+	}
+	commit := false
+	defer func() {
+		if !commit {
+			tx.Rollback()
+		}
+	}()
+
+	nameExists := false
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM role WHERE name = $1 AND id <> $2)`, role.Name, id).Scan(&nameExists); err != nil {
+		return 0, http.StatusInternalServerError, errors.New("querying role names: " + err.Error())
+	}
+	if nameExists {
+		return 0, http.StatusBadRequest, errors.New("Role '" + role.Name + "' already exists.")
+	}
+
+	if len(role.Capabilities) > 0 {
+		missing := []string{}
+		q := `SELECT c FROM UNNEST($1::text[]) AS c WHERE NOT EXISTS (SELECT 1 FROM capability WHERE name = c)`
+		if err := tx.QueryRow(`SELECT ARRAY(`+q+`)`, pq.Array(role.Capabilities)).Scan(pq.Array(&missing)); err != nil {
+			return 0, http.StatusInternalServerError, errors.New("querying capabilities: " + err.Error())
+		}
+		if len(missing) > 0 {
+			return 0, http.StatusBadRequest, errors.New("capabilities do not exist: " + strings.Join(missing, ", "))
+		}
+	}
+
+	if id == 0 {
+		if err := tx.QueryRow(`INSERT INTO role (name, description, priv_level) VALUES ($1, $2, $3) RETURNING id`, role.Name, role.Description, role.PrivLevel).Scan(&id); err != nil {
+			return 0, http.StatusInternalServerError, errors.New("inserting role: " + err.Error())
+		}
+	} else {
+		result, err := tx.Exec(`UPDATE role SET name = $1, description = $2, priv_level = $3 WHERE id = $4`, role.Name, role.Description, role.PrivLevel, id)
+		if err != nil {
+			return 0, http.StatusInternalServerError, errors.New("updating role: " + err.Error())
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return 0, http.StatusInternalServerError, errors.New("getting updated roles: " + err.Error())
+		} else if rowsAffected == 0 {
+			return 0, http.StatusNotFound, errors.New("role not found")
+		}
+		if _, err := tx.Exec(`DELETE FROM role_capability WHERE role_id = $1`, id); err != nil {
+			return 0, http.StatusInternalServerError, errors.New("deleting role capabilities: " + err.Error())
+		}
+	}
+
+	if len(role.Capabilities) > 0 {
+		q := `INSERT INTO role_capability (role_id, cap_name) SELECT $1, UNNEST($2::text[])`
+		if _, err := tx.Exec(q, id, pq.Array(role.Capabilities)); err != nil {
+			return 0, http.StatusInternalServerError, errors.New("inserting role capabilities: " + err.Error())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, http.StatusInternalServerError, errors.New("committing role: " + err.Error())
+	}
+	commit = true
+	return id, http.StatusOK, nil
+}
+
+func getUserRoleIDs(db *sql.DB, userID int) ([]int, error) {
+	rows, err := db.Query(`SELECT role_id FROM user_role WHERE user_id = $1 ORDER BY role_id`, userID)
+	if err != nil {
+		return nil, errors.New("querying user roles: " + err.Error())
+	}
+	defer rows.Close()
+
+	roleIDs := []int{}
+	for rows.Next() {
+		roleID := 0
+		if err := rows.Scan(&roleID); err != nil {
+			return nil, errors.New("scanning user roles: " + err.Error())
+		}
+		roleIDs = append(roleIDs, roleID)
+	}
+	return roleIDs, nil
+}
+
+// setUserRoles replaces the roles of the given user, and returns the user's name. Returns an error with the status to respond with if the user or any role doesn't exist.
+func setUserRoles(db *sql.DB, userID int, roleIDs []int) (string, int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return "", http.StatusInternalServerError, errors.New("beginning transaction: " + err.Error())
+	}
+	commit := false
+	defer func() {
+		if !commit {
+			tx.Rollback()
+		}
+	}()
+
+	userName := ""
+	if err := tx.QueryRow(`SELECT username FROM tm_user WHERE id = $1`, userID).Scan(&userName); err != nil {
+		if err == sql.ErrNoRows {
+			return "", http.StatusNotFound, errors.New("user not found")
+		}
+		return "", http.StatusInternalServerError, errors.New("querying user: " + err.Error())
+	}
+
+	roles := 0
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM role WHERE id = ANY($1::bigint[])`, pq.Array(roleIDs)).Scan(&roles); err != nil {
+		return "", http.StatusInternalServerError, errors.New("querying roles: " + err.Error())
+	}
+	if roles != len(uniqueInts(roleIDs)) {
+		return "", http.StatusBadRequest, errors.New("one or more roles do not exist")
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_role WHERE user_id = $1`, userID); err != nil {
+		return "", http.StatusInternalServerError, errors.New("deleting user roles: " + err.Error())
+	}
+	if len(roleIDs) > 0 {
+		q := `INSERT INTO user_role (user_id, role_id) SELECT $1, r FROM (SELECT DISTINCT UNNEST($2::bigint[]) AS r) AS roles`
+		if _, err := tx.Exec(q, userID, pq.Array(roleIDs)); err != nil {
+			return "", http.StatusInternalServerError, errors.New("inserting user roles: " + err.Error())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", http.StatusInternalServerError, errors.New("committing user roles: " + err.Error())
+	}
+	commit = true
+	return userName, http.StatusOK, nil
+}
+
+func uniqueInts(is []int) []int {
+	seen := map[int]struct{}{}
+	unique := []int{}
+	for _, i := range is {
+		if _, ok := seen[i]; ok {
+			continue
+		}
+		seen[i] = struct{}{}
+		unique = append(unique, i)
+	}
+	return unique
+}
+
+// getPathRoleID returns the role ID in the path, and the status to respond with if it's missing or invalid.
+func getPathRoleID(r *http.Request) (int, int, error) {
+	pathParams, err := getPathParams(r.Context())
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	id, err := strconv.Atoi(pathParams["id"])
+	if err != nil {
+		return 0, http.StatusBadRequest, errors.New("role id must be an integer")
+	}
+	return id, http.StatusOK, nil
+}
+
+// getPathUserID returns the user ID in the path, and the status to respond with if it's missing or invalid.
+func getPathUserID(r *http.Request) (int, int, error) {
+	pathParams, err := getPathParams(r.Context())
+	if err != nil {
+		return 0, http.StatusInternalServerError, err
+	}
+	id, err := strconv.Atoi(pathParams["id"])
+	if err != nil {
+		return 0, http.StatusBadRequest, errors.New("user id must be an integer")
+	}
+	return id, http.StatusOK, nil
+}
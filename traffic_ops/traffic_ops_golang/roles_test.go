@@ -0,0 +1,77 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestValidateRole(t *testing.T) {
+	if err := validateRole(tc.Role{Name: "ops", PrivLevel: 20, Capabilities: []string{"cdn-read", "cdn-write"}}); err != nil {
+		t.Errorf("validateRole expected: nil error, actual: %v", err)
+	}
+
+	for _, test := range []struct {
+		role     tc.Role
+		expected string
+	}{
+		{tc.Role{PrivLevel: 10}, "Name is required"},
+		{tc.Role{Name: "ops", PrivLevel: -1}, "privLevel must be between"},
+		{tc.Role{Name: "ops", PrivLevel: 31}, "privLevel must be between"},
+		{tc.Role{Name: "ops", PrivLevel: 10, Capabilities: []string{"cdn-read", "cdn-read"}}, "'cdn-read' is listed more than once"},
+	} {
+		err := validateRole(test.role)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("validateRole %+v expected: error containing '%s', actual: %v", test.role, test.expected, err)
+		}
+	}
+}
+
+func TestGetRoles(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	description := "read only"
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "priv_level", "capabilities"})
+	rows = rows.AddRow(1, "admin", nil, 30, "{all-read,all-write}")
+	rows = rows.AddRow(2, "read-only", description, 10, "{}")
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+	roles, err := getRoles(db, 0)
+	if err != nil {
+		t.Fatalf("getRoles expected: nil error, actual: %v", err)
+	}
+	expected := []tc.Role{
+		{ID: 1, Name: "admin", PrivLevel: 30, Capabilities: []string{"all-read", "all-write"}},
+		{ID: 2, Name: "read-only", Description: &description, PrivLevel: 10, Capabilities: []string{}},
+	}
+	if !reflect.DeepEqual(expected, roles) {
+		t.Errorf("getRoles expected: %+v, actual: %+v", expected, roles)
+	}
+}
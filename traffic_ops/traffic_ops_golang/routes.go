@@ -29,6 +29,7 @@ import (
 	"time"
 
 	tclog "github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/ats"
 	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
 )
 
@@ -51,6 +52,27 @@ func Routes(d ServerData) ([]Route, http.Handler, error) {
 		//CDNs
 		{1.2, http.MethodGet, `cdns/?(\.json)?$`, cdnsHandler(d.DB), CDNsPrivLevel, Authenticated, nil},
 		{1.2, http.MethodGet, `cdns/{name}/configs/monitoring(\.json)?$`, monitoringHandler(d.DB), MonitoringPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/{name}/sslkeys/expirations/?(\.json)?$`, cdnSSLExpirationsHandler(d.DB, d.Config), SSLExpirationsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/{name}/snapshot/?(\.json)?$`, getSnapshotHandler(d.DB), SnapshotPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/{name}/snapshot/new/?(\.json)?$`, getNewSnapshotHandler(d.DB), SnapshotPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/{name}/snapshot/diff/?(\.json)?$`, snapshotDiffHandler(d.DB), SnapshotPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/{name}/snapshots/?(\.json)?$`, getSnapshotVersionsHandler(d.DB), SnapshotPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPut, `cdns/{name}/snapshot/?(\.json)?$`, snapshotHandler(d.DB, d.Config.SnapshotVersionsMax), SnapshotPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPut, `snapshot/{name}/?(\.json)?$`, snapshotHandler(d.DB, d.Config.SnapshotVersionsMax), SnapshotPrivLevel, Authenticated, nil},
+		// DNSSEC keys
+		{1.2, http.MethodGet, `cdns/name/{name}/dnsseckeys/?(\.json)?$`, cdnDNSSECKeysHandler(d.DB, d.Config), DNSSECKeysPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/name/{name}/dnsseckeys/delete/?(\.json)?$`, deleteDNSSECKeysHandler(d.DB, d.Config), DNSSECKeysPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPost, `cdns/dnsseckeys/generate/?(\.json)?$`, generateDNSSECKeysHandler(d.DB, d.Config), DNSSECKeysPrivLevel, Authenticated, nil},
+		{1.3, http.MethodGet, `cdns/name/{name}/dnsseckeys/ds/?(\.json)?$`, dnssecDSRecordsHandler(d.DB, d.Config), DNSSECKeysPrivLevel, Authenticated, nil},
+		// Config files
+		{1.2, http.MethodGet, `servers/{server}/configfiles/ats/remap\.config$`, serverConfigHandler(d.DB, ats.RemapDotConfig), ConfigFilesPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `servers/{server}/configfiles/ats/parent\.config$`, serverConfigHandler(d.DB, ats.ParentDotConfig), ConfigFilesPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `servers/{server}/configfiles/ats/ssl_multicert\.config$`, serverConfigHandler(d.DB, ats.SSLMultiCertDotConfig), ConfigFilesPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `servers/{server}/sslkeys/?(\.json)?$`, serverSSLKeysHandler(d.DB, d.Config), SSLBundlePrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `servers/{server}/configfiles/ats/{file}$`, serverParamConfigHandler(d.DB, proxyHandler), ConfigFilesPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `servers/{server}/configfiles/ats/?$`, serverConfigFilesHandler(d.DB), ConfigFilesPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `profiles/{profile}/configfiles/ats/{file}$`, profileParamConfigHandler(d.DB, proxyHandler), ConfigFilesPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/{cdn}/configfiles/ats/regex_revalidate\.config$`, cdnConfigHandler(d.DB, ats.RegexRevalidateDotConfig), ConfigFilesPrivLevel, Authenticated, nil},
 		// Delivery services
 		{1.3, http.MethodGet, `deliveryservices/{xmlID}/urisignkeys$`, getURIsignkeysHandler(d.DB, d.Config), auth.PrivLevelAdmin, Authenticated, nil},
 		{1.3, http.MethodPost, `deliveryservices/{xmlID}/urisignkeys$`, assignDeliveryServiceURIKeysHandler(d.DB, d.Config), auth.PrivLevelAdmin, Authenticated, nil},
@@ -58,8 +80,27 @@ func Routes(d ServerData) ([]Route, http.Handler, error) {
 		{1.3, http.MethodDelete, `deliveryservices/{xmlID}/urisignkeys$`, removeDeliveryServiceURIKeysHandler(d.DB, d.Config), auth.PrivLevelAdmin, Authenticated, nil},
 		//Divisions
 		{1.2, http.MethodGet, `divisions/?(\.json)?$`, divisionsHandler(d.DB), DivisionsPrivLevel, Authenticated, nil},
+		//Federations
+		{1.2, http.MethodGet, `cdns/{name}/federations/?(\.json)?$`, cdnFederationsHandler(d.DB), FederationsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPost, `cdns/{name}/federations/?(\.json)?$`, createCDNFederationHandler(d.DB), FederationsWritePrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/{name}/federations/{id}$`, cdnFederationHandler(d.DB), FederationsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPut, `cdns/{name}/federations/{id}$`, updateCDNFederationHandler(d.DB), FederationsWritePrivLevel, Authenticated, nil},
+		{1.2, http.MethodDelete, `cdns/{name}/federations/{id}$`, deleteCDNFederationHandler(d.DB), FederationsWritePrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `federations/{id}/deliveryservices/?(\.json)?$`, federationDSesHandler(d.DB), FederationsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPost, `federations/{id}/deliveryservices/?(\.json)?$`, assignFederationDSesHandler(d.DB), FederationsWritePrivLevel, Authenticated, nil},
+		{1.2, http.MethodDelete, `federations/{id}/deliveryservices/{dsID}$`, deleteFederationDSHandler(d.DB), FederationsWritePrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `federations/{id}/federation_resolvers/?(\.json)?$`, federationFederationResolversHandler(d.DB), FederationsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPost, `federations/{id}/federation_resolvers/?(\.json)?$`, assignFederationResolversHandler(d.DB), FederationsWritePrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `federation_resolvers/?(\.json)?$`, federationResolversHandler(d.DB), FederationsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPost, `federation_resolvers/?(\.json)?$`, createFederationResolverHandler(d.DB), FederationsWritePrivLevel, Authenticated, nil},
+		{1.2, http.MethodDelete, `federation_resolvers/{id}$`, deleteFederationResolverHandler(d.DB), FederationsWritePrivLevel, Authenticated, nil},
 		//HwInfo
 		{1.2, http.MethodGet, `hwinfo-wip/?(\.json)?$`, hwInfoHandler(d.DB), HWInfoPrivLevel, Authenticated, nil},
+		//Jobs
+		{1.2, http.MethodGet, `jobs/?(\.json)?$`, jobsHandler(d.DB), JobsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `jobs/{id}$`, jobHandler(d.DB), JobsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodDelete, `jobs/{id}$`, deleteJobHandler(d.DB), JobPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPost, `user/current/jobs/?(\.json)?$`, createJobHandler(d.DB), JobsPrivLevel, Authenticated, nil},
 		//Parameters
 		{1.2, http.MethodGet, `parameters/?(\.json)?$`, parametersHandler(d.DB), ParametersPrivLevel, Authenticated, nil},
 		//Regions
@@ -81,11 +122,39 @@ func Routes(d ServerData) ([]Route, http.Handler, error) {
 		{1.2, http.MethodGet, `deliveryservices-wip/xmlId/{xmlID}/sslkeys$`, getDeliveryServiceSSLKeysByXmlIDHandler(d.DB, d.Config), auth.PrivLevelAdmin, Authenticated, nil},
 		{1.2, http.MethodGet, `deliveryservices-wip/hostname/{hostName}/sslkeys$`, getDeliveryServiceSSLKeysByHostNameHandler(d.DB, d.Config), auth.PrivLevelAdmin, Authenticated, nil},
 		{1.2, http.MethodPut, `deliveryservices-wip/hostname/{hostName}/sslkeys$`, addDeliveryServiceSSLKeysHandler(d.DB, d.Config), auth.PrivLevelAdmin, Authenticated, nil},
+		//Static DNS entries
+		{1.2, http.MethodGet, `staticdnsentries/?(\.json)?$`, staticDNSEntriesHandler(d.DB), StaticDNSEntriesPrivLevel, Authenticated, nil},
 		//Statuses
 		{1.2, http.MethodGet, `statuses/?(\.json)?$`, statusesHandler(d.DB), StatusesPrivLevel, Authenticated, nil},
 		{1.2, http.MethodGet, `statuses/{id}$`, statusesHandler(d.DB), StatusesPrivLevel, Authenticated, nil},
 		//System
 		{1.2, http.MethodGet, `system/info/?(\.json)?$`, systemInfoHandler(d.DB), SystemInfoPrivLevel, Authenticated, nil},
+		//Topologies
+		{1.3, http.MethodGet, `topologies/?(\.json)?$`, topologiesHandler(d.DB), TopologiesPrivLevel, Authenticated, nil},
+		{1.3, http.MethodPost, `topologies/?(\.json)?$`, createTopologyHandler(d.DB), TopologiesWritePrivLevel, Authenticated, nil},
+		{1.3, http.MethodGet, `topologies/{name}$`, topologyHandler(d.DB), TopologiesPrivLevel, Authenticated, nil},
+		{1.3, http.MethodPut, `topologies/{name}$`, updateTopologyHandler(d.DB), TopologiesWritePrivLevel, Authenticated, nil},
+		{1.3, http.MethodDelete, `topologies/{name}$`, deleteTopologyHandler(d.DB), TopologiesWritePrivLevel, Authenticated, nil},
+		{1.3, http.MethodPut, `deliveryservices/{id}/topology$`, setDSTopologyHandler(d.DB), TopologiesWritePrivLevel, Authenticated, nil},
+		//Steering
+		{1.2, http.MethodGet, `steering/{id}/targets/?(\.json)?$`, steeringTargetsHandler(d.DB), SteeringTargetsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPost, `steering/{id}/targets/?(\.json)?$`, createSteeringTargetHandler(d.DB), SteeringPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `steering/{id}/targets/{targetID}$`, steeringTargetsHandler(d.DB), SteeringTargetsPrivLevel, Authenticated, nil},
+		{1.2, http.MethodPut, `steering/{id}/targets/{targetID}$`, updateSteeringTargetHandler(d.DB), SteeringPrivLevel, Authenticated, nil},
+		{1.2, http.MethodDelete, `steering/{id}/targets/{targetID}$`, deleteSteeringTargetHandler(d.DB), SteeringPrivLevel, Authenticated, nil},
+		//Capabilities
+		{1.3, http.MethodGet, `capabilities/?(\.json)?$`, capabilitiesHandler(d.DB), CapabilitiesPrivLevel, Authenticated, nil},
+		{1.3, http.MethodPost, `capabilities/?(\.json)?$`, createCapabilityHandler(d.DB), CapabilitiesWritePrivLevel, Authenticated, nil},
+		{1.3, http.MethodGet, `capabilities/{name}$`, capabilitiesHandler(d.DB), CapabilitiesPrivLevel, Authenticated, nil},
+		{1.3, http.MethodPut, `capabilities/{name}$`, updateCapabilityHandler(d.DB), CapabilitiesWritePrivLevel, Authenticated, nil},
+		{1.3, http.MethodDelete, `capabilities/{name}$`, deleteCapabilityHandler(d.DB), CapabilitiesWritePrivLevel, Authenticated, nil},
+		//Roles
+		{1.3, http.MethodGet, `roles/?(\.json)?$`, rolesHandler(d.DB), RolesPrivLevel, Authenticated, nil},
+		{1.3, http.MethodPost, `roles/?(\.json)?$`, createRoleHandler(d.DB), RolesWritePrivLevel, Authenticated, nil},
+		{1.3, http.MethodPut, `roles/{id}$`, updateRoleHandler(d.DB), RolesWritePrivLevel, Authenticated, nil},
+		{1.3, http.MethodDelete, `roles/{id}$`, deleteRoleHandler(d.DB), RolesWritePrivLevel, Authenticated, nil},
+		{1.3, http.MethodGet, `users/{id}/roles$`, userRolesHandler(d.DB), RolesPrivLevel, Authenticated, nil},
+		{1.3, http.MethodPut, `users/{id}/roles$`, setUserRolesHandler(d.DB), RolesWritePrivLevel, Authenticated, nil},
 
 		//Phys_Locations
 		{1.2, http.MethodGet, `phys_locations/?(\.json)?$`, physLocationsHandler(d.DB), PhysLocationsPrivLevel, Authenticated, nil},
@@ -94,6 +163,19 @@ func Routes(d ServerData) ([]Route, http.Handler, error) {
 	return routes, proxyHandler, nil
 }
 
+// InternalRoutes returns the routes for other Traffic Control components, which are under InternalRoutePrefix rather than RoutePrefix.
+func InternalRoutes(d ServerData) []Route {
+	return []Route{
+		// Traffic Router federation mappings
+		{1.2, http.MethodGet, `federations/?(\.json)?$`, federationMappingsHandler(d.DB), FederationMappingsPrivLevel, Authenticated, nil},
+		// Traffic Router steering
+		{1.2, http.MethodGet, `steering/?(\.json)?$`, steeringHandler(d.DB), SteeringPrivLevel, Authenticated, nil},
+		{1.2, http.MethodGet, `steering/{xmlID}/?(\.json)?$`, steeringHandler(d.DB), SteeringPrivLevel, Authenticated, nil},
+		// DNSSEC key rollovers
+		{1.2, http.MethodGet, `cdns/dnsseckeys/refresh/?(\.json)?$`, refreshDNSSECKeysHandler(d.DB, d.Config), DNSSECKeysPrivLevel, Authenticated, nil},
+	}
+}
+
 // RootHandler returns the / handler for the service, which reverse-proxies the old Perl Traffic Ops
 func rootHandler(d ServerData) http.Handler {
 	tr := &http.Transport{
@@ -22,6 +22,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"regexp"
@@ -30,6 +31,8 @@ import (
 	"strings"
 
 	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
 
 	"fmt"
 
@@ -38,6 +41,9 @@ import (
 
 const RoutePrefix = "api" // TODO config?
 
+// InternalRoutePrefix is the prefix of routes for other Traffic Control components, rather than users.
+const InternalRoutePrefix = "internal/" + RoutePrefix
+
 type Middleware func(handlerFunc http.HandlerFunc) http.HandlerFunc
 
 type Route struct {
@@ -77,6 +83,17 @@ func getPathParams(ctx context.Context) (PathParams, error) {
 	return nil, errors.New("no PathParams found in Context")
 }
 
+// writeJSONResp writes the given response as JSON, or the error if it can't be marshalled.
+func writeJSONResp(w http.ResponseWriter, handleErr func(error, int), resp interface{}) {
+	respBts, err := json.Marshal(resp)
+	if err != nil {
+		handleErr(err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(tc.ContentType, tc.ApplicationJson)
+	fmt.Fprintf(w, "%s", respBts)
+}
+
 type CompiledRoute struct {
 	Handler http.HandlerFunc
 	Regex   *regexp.Regexp
@@ -103,8 +120,12 @@ type PathHandler struct {
 
 // CreateRouteMap returns a map of methods to a slice of paths and handlers; wrapping the handlers in the appropriate middleware. Uses Semantic Versioning: routes are added to every subsequent minor version, but not subsequent major versions. For example, a 1.2 route is added to 1.3 but not 2.1. Also truncates '2.0' to '2', creating succinct major versions.
 func CreateRouteMap(rs []Route, authBase AuthBase) map[string][]PathHandler {
+	return createPrefixedRouteMap(rs, getSortedRouteVersions(rs), authBase, RoutePrefix)
+}
+
+// createPrefixedRouteMap is CreateRouteMap with the given versions, for routes with the given prefix.
+func createPrefixedRouteMap(rs []Route, versions []float64, authBase AuthBase, prefix string) map[string][]PathHandler {
 	// TODO strong types for method, path
-	versions := getSortedRouteVersions(rs)
 	m := map[string][]PathHandler{}
 	for _, r := range rs {
 		versionI := sort.SearchFloat64s(versions, r.Version)
@@ -114,7 +135,7 @@ func CreateRouteMap(rs []Route, authBase AuthBase) map[string][]PathHandler {
 				break
 			}
 			vstr := strconv.FormatFloat(version, 'f', -1, 64)
-			path := RoutePrefix + "/" + vstr + "/" + r.Path
+			path := prefix + "/" + vstr + "/" + r.Path
 
 			middlewares := r.Middlewares
 
@@ -122,7 +143,7 @@ func CreateRouteMap(rs []Route, authBase AuthBase) map[string][]PathHandler {
 				middlewares = getDefaultMiddleware()
 			}
 			if r.Authenticated { //a privLevel of zero is an unauthenticated endpoint.
-				authWrapper := authBase.GetWrapper(r.RequiredPrivLevel)
+				authWrapper := authBase.GetWrapper(r.RequiredPrivLevel, r.Method, capabilityRoute(prefix, r.Path))
 				middlewares = append([]Middleware{authWrapper}, middlewares...)
 			}
 
@@ -134,6 +155,82 @@ func CreateRouteMap(rs []Route, authBase AuthBase) map[string][]PathHandler {
 	return m
 }
 
+// capabilityRouteParamRegex matches path parameters and the regexes of optional trailing slashes and .json extensions in route paths.
+var capabilityRouteParamRegex = regexp.MustCompile(`\{[^}]*\}|/\?\(\\\.json\)\?|\(\\\.json\)\?|/\?`)
+
+// capabilityRoute returns the route with the given prefix and path as api_capability routes are written, with a wildcard for the version and each path parameter, e.g. cdns/{name}/federations/?(\.json)?$ with the prefix api is /api/*/cdns/*/federations.
+func capabilityRoute(prefix string, path string) string {
+	path = capabilityRouteParamRegex.ReplaceAllStringFunc(strings.TrimSuffix(path, "$"), func(match string) string {
+		if strings.HasPrefix(match, "{") {
+			return auth.CapabilityRouteWildcard
+		}
+		return ""
+	})
+	return "/" + prefix + "/" + auth.CapabilityRouteWildcard + "/" + strings.Replace(path, `\.`, ".", -1)
+}
+
+// getRoutesWithoutCapabilities returns the method and capability route of every authenticated route, with the given prefix, which no api_capability maps to a capability. The api_capability which matches every route doesn't count, since it's only meant for super-users.
+func getRoutesWithoutCapabilities(db *sql.DB, rs []Route, prefix string) ([]string, error) {
+	rows, err := db.Query(`SELECT http_method::text, route FROM api_capability`)
+	if err != nil {
+		return nil, errors.New("querying api capabilities: " + err.Error())
+	}
+	defer rows.Close()
+
+	patterns := map[string][]string{}
+	for rows.Next() {
+		method := ""
+		pattern := ""
+		if err := rows.Scan(&method, &pattern); err != nil {
+			return nil, errors.New("scanning api capabilities: " + err.Error())
+		}
+		if pattern == auth.CapabilityRouteAll {
+			continue
+		}
+		patterns[method] = append(patterns[method], pattern)
+	}
+
+	missing := []string{}
+	for _, r := range rs {
+		if !r.Authenticated {
+			continue
+		}
+		route := capabilityRoute(prefix, r.Path)
+		found := false
+		for _, pattern := range patterns[r.Method] {
+			if auth.CapabilityRouteMatches(pattern, route) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, r.Method+" "+route)
+		}
+	}
+	return missing, nil
+}
+
+// checkRouteCapabilities returns an error listing the routes no capability is mapped to, if capabilities are used to authorize users. Otherwise, the routes are only logged.
+func checkRouteCapabilities(db *sql.DB, useCapabilities bool, routes []Route, internalRoutes []Route) error {
+	missing, err := getRoutesWithoutCapabilities(db, routes, RoutePrefix)
+	if err != nil {
+		return err
+	}
+	missingInternal, err := getRoutesWithoutCapabilities(db, internalRoutes, InternalRoutePrefix)
+	if err != nil {
+		return err
+	}
+	missing = append(missing, missingInternal...)
+	if len(missing) == 0 {
+		return nil
+	}
+	if useCapabilities {
+		return errors.New("routes with no api_capability: " + strings.Join(missing, ", "))
+	}
+	log.Warnf("routes with no api_capability, which will be inaccessible to everyone but super-users if capability_authorization is enabled: %v\n", strings.Join(missing, ", "))
+	return nil
+}
+
 // CompiledRoutes takes a map of methods to paths and handlers, and returns a map of methods to CompiledRoutes.
 func CompileRoutes(routes map[string][]PathHandler) map[string][]CompiledRoute {
 	compiledRoutes := map[string][]CompiledRoute{}
@@ -199,8 +296,23 @@ func RegisterRoutes(d ServerData) error {
 		return fmt.Errorf("Error preparing db priv level query: %s", err)
 	}
 
-	authBase := AuthBase{d.Insecure, d.Config.Secrets[0], privLevelStmt, nil} //we know d.Config.Secrets is a slice of at least one or start up would fail.
+	authBase := AuthBase{d.Insecure, d.Config.Secrets[0], privLevelStmt, nil, nil} //we know d.Config.Secrets is a slice of at least one or start up would fail.
+	internalRouteSlice := InternalRoutes(d)
+	if err := checkRouteCapabilities(d.DB.DB, d.CapabilityAuthorization, routeSlice, internalRouteSlice); err != nil {
+		return err
+	}
+	if d.CapabilityAuthorization {
+		if authBase.capabilityStmt, err = d.DB.Prepare(auth.CapabilityRoutesQuery); err != nil {
+			return fmt.Errorf("Error preparing db capability query: %s", err)
+		}
+	}
+
 	routes := CreateRouteMap(routeSlice, authBase)
+	// internal routes go first, because route regexes aren't anchored at the start, and internal paths end with API paths
+	internalRoutes := createPrefixedRouteMap(internalRouteSlice, getSortedRouteVersions(append(routeSlice, internalRouteSlice...)), authBase, InternalRoutePrefix)
+	for method, pathHandlers := range internalRoutes {
+		routes[method] = append(pathHandlers, routes[method]...)
+	}
 	compiledRoutes := CompileRoutes(routes)
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		Handler(compiledRoutes, catchall, w, r)
@@ -28,6 +28,9 @@ import (
 	"bytes"
 	"context"
 	"net/http/httptest"
+	"reflect"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
 func TestCreateRouteMap(t *testing.T) {
@@ -36,7 +39,7 @@ func TestCreateRouteMap(t *testing.T) {
 			ctx := context.WithValue(r.Context(), "authWasCalled", "true")
 			handlerFunc(w, r.WithContext(ctx))
 		}
-	}}
+	}, nil}
 
 	PathOneHandler := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -110,3 +113,53 @@ func getAuthWasCalled(ctx context.Context) string {
 	}
 	return "false"
 }
+
+func TestCapabilityRoute(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		path     string
+		expected string
+	}{
+		{"api", `cdns/?(\.json)?$`, "/api/*/cdns"},
+		{"api", `cdns/{name}/federations/{id}$`, "/api/*/cdns/*/federations/*"},
+		{"api", `cdns/{name}/configs/monitoring(\.json)?$`, "/api/*/cdns/*/configs/monitoring"},
+		{"api", `servers/{server-name}/configfiles/ats/parent.config$`, "/api/*/servers/*/configfiles/ats/parent.config"},
+		{"internal/api", `federations/?(\.json)?$`, "/internal/api/*/federations"},
+	}
+	for _, test := range tests {
+		if actual := capabilityRoute(test.prefix, test.path); actual != test.expected {
+			t.Errorf("capabilityRoute(%v, %v) expected: %v, actual: %v", test.prefix, test.path, test.expected, actual)
+		}
+	}
+}
+
+func TestGetRoutesWithoutCapabilities(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"http_method", "route"})
+	rows = rows.AddRow("GET", "/")
+	rows = rows.AddRow("GET", "/api/*/cdns")
+	rows = rows.AddRow("GET", "/api/*/cdns/*/*")
+	rows = rows.AddRow("POST", "/api/*/cdns")
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+	routes := []Route{
+		{1.2, http.MethodGet, `cdns/?(\.json)?$`, nil, 0, Authenticated, nil},
+		{1.2, http.MethodGet, `cdns/{name}/federations/?(\.json)?$`, nil, 0, Authenticated, nil},
+		{1.2, http.MethodPut, `cdns/{name}$`, nil, 0, Authenticated, nil},
+		{1.2, http.MethodGet, `servers/?(\.json)?$`, nil, 0, Authenticated, nil},
+		{1.2, http.MethodGet, `ping$`, nil, 0, NoAuth, nil},
+	}
+	missing, err := getRoutesWithoutCapabilities(db, routes, RoutePrefix)
+	if err != nil {
+		t.Fatalf("getRoutesWithoutCapabilities expected: nil error, actual: %v", err)
+	}
+	expected := []string{"PUT /api/*/cdns/*", "GET /api/*/servers"}
+	if !reflect.DeepEqual(expected, missing) {
+		t.Errorf("getRoutesWithoutCapabilities expected: %v, actual: %v", expected, missing)
+	}
+}
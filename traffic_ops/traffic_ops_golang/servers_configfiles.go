@@ -0,0 +1,181 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/ats"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const ConfigFilesPrivLevel = auth.PrivLevelOperations
+
+const TextPlain = "text/plain"
+
+// serverConfigFunc generates the text of a config file for a server, starting with the given header.
+type serverConfigFunc func(db *sql.DB, server ats.ServerInfo, header string) (string, error)
+
+// serverConfigHandler returns a handler which serves the config file generated by makeConfig for the server in the path, by host name or ID.
+func serverConfigHandler(db *sqlx.DB, makeConfig serverConfigFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		server, status, err := getPathServer(db.DB, pathParams)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		nameVersion, err := ats.GetNameVersionString(db.DB)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		text, err := makeConfig(db.DB, server, ats.HeaderComment(server.HostName, nameVersion, time.Now()))
+		if err != nil {
+			handleErr(errors.New("generating config for "+server.HostName+": "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(tc.ContentType, TextPlain)
+		fmt.Fprintf(w, "%s", text)
+	}
+}
+
+// serverParamConfigHandler returns a handler which serves the server scoped config file in the path generated from the server's profile parameters. Config files which aren't generated from parameters are served by fallback.
+func serverParamConfigHandler(db *sqlx.DB, fallback http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		fileName := pathParams["file"]
+		if !ats.IsParamConfig(fileName) {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+
+		server, status, err := getPathServer(db.DB, pathParams)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		if status, err := checkParamConfig(db.DB, fileName, server.Type, ats.ScopeServers); err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		params, err := ats.GetParamData(db.DB, server.ProfileID, fileName, server.HostName+"."+server.DomainName)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		nameVersion, err := ats.GetNameVersionString(db.DB)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(tc.ContentType, TextPlain)
+		fmt.Fprintf(w, "%s", ats.ParamConfig(fileName, params, ats.HeaderComment(server.HostName, nameVersion, time.Now())))
+	}
+}
+
+// serverConfigFilesHandler returns a handler which serves the list of config files the server in the path needs, and where they go on the server.
+func serverConfigFilesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		server, status, err := getPathServer(db.DB, pathParams)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		meta, err := ats.GetConfigMetaData(db.DB, server)
+		if err != nil {
+			handleErr(errors.New("getting config files for "+server.HostName+": "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		bts, err := json.Marshal(meta)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, "%s", bts)
+	}
+}
+
+// getPathServer returns the server in the path, by host name or ID, or the HTTP status and error if it doesn't exist or can't be queried.
+func getPathServer(db *sql.DB, pathParams PathParams) (ats.ServerInfo, int, error) {
+	server, ok, err := ats.GetServerInfo(db, pathParams["server"])
+	if err != nil {
+		return ats.ServerInfo{}, http.StatusInternalServerError, err
+	}
+	if !ok {
+		return ats.ServerInfo{}, http.StatusNotFound, errors.New("server not found")
+	}
+	return server, http.StatusOK, nil
+}
+
+// checkParamConfig returns the HTTP status and error if the config file generated from parameters doesn't exist, or isn't in the given scope.
+func checkParamConfig(db *sql.DB, fileName string, serverType string, routeScope string) (int, error) {
+	scope, err := ats.GetScope(db, fileName, serverType)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if scope != routeScope {
+		return http.StatusBadRequest, errors.New("Error - incorrect file scope for route used.  Please use the " + scope + " route.")
+	}
+	exists, err := ats.ConfigFileExists(db, fileName)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !exists {
+		return http.StatusNotFound, errors.New("config file not found")
+	}
+	return http.StatusOK, nil
+}
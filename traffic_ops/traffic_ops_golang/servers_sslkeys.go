@@ -0,0 +1,301 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/ats"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SSLBundlePrivLevel is the privilege level to get the private keys of a server's delivery services, the same as the delivery service sslkeys routes.
+const SSLBundlePrivLevel = auth.PrivLevelAdmin
+const SSLExpirationsPrivLevel = auth.PrivLevelOperations
+
+// DefaultSSLExpirationDays is how far ahead the SSL expirations report looks, if the request has no days parameter.
+const DefaultSSLExpirationDays = 30
+
+// certVerifier verifies a base64 certificate chain, as stored in Riak, and returns the verified chain, like verifyAndEncodeCertificate.
+type certVerifier func(certificate string, rootCA string) (string, error)
+
+// serverSSLKeysHandler returns a handler which serves the verified certificates and keys of the HTTPS delivery services of the server in the path, which are the files in its ssl_multicert.config.
+func serverSSLKeysHandler(db *sqlx.DB, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		if !cfg.RiakEnabled {
+			handleErr(errors.New("The RIAK service is unavailable"), http.StatusServiceUnavailable)
+			return
+		}
+
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		server, status, err := getPathServer(db.DB, pathParams)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		hosts, err := ats.GetSSLHosts(db.DB, server)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		keys, err := getSSLKeys(db, cfg, hosts)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		bts, err := json.Marshal(tc.SSLCertBundleResponse{Response: makeSSLCertBundle(hosts, keys, verifyAndEncodeCertificate)})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, "%s", bts)
+	}
+}
+
+// cdnSSLExpirationsHandler returns a handler which serves the certificates of the HTTPS delivery services of the CDN in the path which expire within the days in the query, or DefaultSSLExpirationDays, including expired certificates.
+func cdnSSLExpirationsHandler(db *sqlx.DB, cfg Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		if !cfg.RiakEnabled {
+			handleErr(errors.New("The RIAK service is unavailable"), http.StatusServiceUnavailable)
+			return
+		}
+
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		days := DefaultSSLExpirationDays
+		if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+			if days, err = strconv.Atoi(daysStr); err != nil || days < 0 {
+				handleErr(errors.New("days must be a non-negative integer"), http.StatusBadRequest)
+				return
+			}
+		}
+
+		cdn, ok, err := getCDNName(db.DB, pathParams["name"])
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			handleErr(errors.New("cdn not found"), http.StatusNotFound)
+			return
+		}
+
+		hosts, err := ats.GetCDNSSLHosts(db.DB, cdn)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		keys, err := getSSLKeys(db, cfg, hosts)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		bts, err := json.Marshal(tc.SSLCertExpirationsResponse{Response: getSSLExpirations(hosts, keys, time.Now(), days)})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, "%s", bts)
+	}
+}
+
+// getSSLKeys returns the latest SSL keys in Riak of the delivery services of the given hosts, by XML ID. Delivery services without keys aren't in the map.
+func getSSLKeys(db *sqlx.DB, cfg Config, hosts []ats.SSLHost) (map[string]tc.DeliveryServiceSSLKeys, error) {
+	keys := map[string]tc.DeliveryServiceSSLKeys{}
+	if len(hosts) == 0 {
+		return keys, nil
+	}
+	cluster, err := getRiakCluster(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = cluster.Start(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := cluster.Stop(); err != nil {
+			log.Errorf("%v\n", err)
+		}
+	}()
+	return fetchSSLKeys(cluster, hosts)
+}
+
+func fetchSSLKeys(cluster StorageCluster, hosts []ats.SSLHost) (map[string]tc.DeliveryServiceSSLKeys, error) {
+	keys := map[string]tc.DeliveryServiceSSLKeys{}
+	for _, host := range hosts {
+		objs, err := fetchObjectValues(host.XMLID+"-latest", SSLKeysBucket, cluster)
+		if err != nil {
+			return nil, errors.New("fetching SSL keys for " + host.XMLID + ": " + err.Error())
+		}
+		if len(objs) == 0 {
+			continue
+		}
+		key := tc.DeliveryServiceSSLKeys{}
+		if err := json.Unmarshal(objs[0].Value, &key); err != nil {
+			return nil, errors.New("unmarshalling SSL keys for " + host.XMLID + ": " + err.Error())
+		}
+		keys[host.XMLID] = key
+	}
+	return keys, nil
+}
+
+// makeSSLCertBundle returns the certificate and key of each host. Certificates are verified with verify before they're added, and hosts without keys or whose certificates don't verify are added to the errors.
+func makeSSLCertBundle(hosts []ats.SSLHost, keys map[string]tc.DeliveryServiceSSLKeys, verify certVerifier) tc.SSLCertBundle {
+	bundle := tc.SSLCertBundle{Certificates: []tc.SSLCertificate{}, Errors: []tc.SSLCertificateError{}}
+	for _, host := range hosts {
+		cert, err := makeSSLCertificate(host, keys, verify)
+		if err != nil {
+			bundle.Errors = append(bundle.Errors, tc.SSLCertificateError{DeliveryService: host.XMLID, Error: err.Error()})
+			continue
+		}
+		bundle.Certificates = append(bundle.Certificates, cert)
+	}
+	return bundle
+}
+
+func makeSSLCertificate(host ats.SSLHost, keys map[string]tc.DeliveryServiceSSLKeys, verify certVerifier) (tc.SSLCertificate, error) {
+	key, ok := keys[host.XMLID]
+	if !ok {
+		return tc.SSLCertificate{}, errors.New("no SSL keys")
+	}
+	chain, err := verify(key.Certificate.Crt, "")
+	if err != nil {
+		return tc.SSLCertificate{}, errors.New("verifying certificate: " + strings.TrimSpace(err.Error()))
+	}
+	certPEM, err := decodeSSLKey(chain)
+	if err != nil {
+		return tc.SSLCertificate{}, errors.New("decoding certificate: " + err.Error())
+	}
+	expiration, err := certExpiration(certPEM)
+	if err != nil {
+		return tc.SSLCertificate{}, err
+	}
+	keyPEM, err := decodeSSLKey(key.Certificate.Key)
+	if err != nil {
+		return tc.SSLCertificate{}, errors.New("decoding private key: " + err.Error())
+	}
+	return tc.SSLCertificate{
+		DeliveryService: host.XMLID,
+		HostName:        host.HostName,
+		CertFileName:    host.CertFileName,
+		KeyFileName:     host.KeyFileName,
+		Cert:            certPEM,
+		Key:             keyPEM,
+		Expiration:      expiration,
+	}, nil
+}
+
+// getSSLExpirations returns the certificates of the given hosts which expire within the given days of now, sorted by expiration. Hosts without keys or with invalid certificates are skipped.
+func getSSLExpirations(hosts []ats.SSLHost, keys map[string]tc.DeliveryServiceSSLKeys, now time.Time, days int) []tc.SSLCertExpiration {
+	expirations := []tc.SSLCertExpiration{}
+	cutoff := now.Add(time.Duration(days) * 24 * time.Hour)
+	for _, host := range hosts {
+		key, ok := keys[host.XMLID]
+		if !ok {
+			continue
+		}
+		certPEM, err := decodeSSLKey(key.Certificate.Crt)
+		if err != nil {
+			log.Errorf("decoding certificate of %s: %v\n", host.XMLID, err)
+			continue
+		}
+		expiration, err := certExpiration(certPEM)
+		if err != nil {
+			log.Errorf("getting expiration of %s: %v\n", host.XMLID, err)
+			continue
+		}
+		if expiration.After(cutoff) {
+			continue
+		}
+		expirations = append(expirations, tc.SSLCertExpiration{
+			DeliveryService: host.XMLID,
+			HostName:        host.HostName,
+			Expiration:      expiration,
+			DaysLeft:        int(math.Floor(expiration.Sub(now).Hours() / 24)),
+		})
+	}
+	sort.Sort(sslCertExpirationsByExpiration(expirations))
+	return expirations
+}
+
+type sslCertExpirationsByExpiration []tc.SSLCertExpiration
+
+func (e sslCertExpirationsByExpiration) Len() int { return len(e) }
+func (e sslCertExpirationsByExpiration) Less(i, j int) bool {
+	return e[i].Expiration.Before(e[j].Expiration)
+}
+func (e sslCertExpirationsByExpiration) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+
+// decodeSSLKey decodes a base64 certificate or key as stored in Riak, which may contain escaped or real newlines.
+func decodeSSLKey(encoded string) (string, error) {
+	encoded = strings.Replace(encoded, `\n`, "", -1)
+	encoded = strings.Replace(encoded, "\n", "", -1)
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// certExpiration returns the expiration of the first certificate in the given PEM, which is the server certificate of a chain.
+func certExpiration(pemCerts string) (time.Time, error) {
+	block, _ := pem.Decode([]byte(pemCerts))
+	if block == nil {
+		return time.Time{}, errors.New("no PEM certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, errors.New("parsing certificate: " + err.Error())
+	}
+	return cert.NotAfter, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/ats"
+)
+
+var testSSLNow = time.Date(2017, 11, 1, 12, 0, 0, 0, time.UTC)
+
+// testSSLCert returns a base64 PEM self-signed certificate expiring at the given time, as stored in Riak.
+func testSSLCert(t *testing.T, host string, notAfter time.Time) string {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func testSSLHosts() []ats.SSLHost {
+	return []ats.SSLHost{
+		{XMLID: "ds1", HostName: "cdn.ds1.cdn1.example.net", CertFileName: "cdn_ds1_cdn1_example_net_cert.cer", KeyFileName: "cdn.ds1.cdn1.example.net.key"},
+		{XMLID: "ds2", HostName: "cdn.ds2.cdn1.example.net", CertFileName: "cdn_ds2_cdn1_example_net_cert.cer", KeyFileName: "cdn.ds2.cdn1.example.net.key"},
+		{XMLID: "ds3", HostName: "cdn.ds3.cdn1.example.net", CertFileName: "cdn_ds3_cdn1_example_net_cert.cer", KeyFileName: "cdn.ds3.cdn1.example.net.key"},
+	}
+}
+
+func TestMakeSSLCertBundle(t *testing.T) {
+	expiration := testSSLNow.Add(90 * 24 * time.Hour)
+	keys := map[string]tc.DeliveryServiceSSLKeys{
+		"ds1": {Certificate: tc.DeliveryServiceSSLKeysCertificate{Crt: testSSLCert(t, "cdn.ds1.cdn1.example.net", expiration), Key: base64.StdEncoding.EncodeToString([]byte("ds1 key"))}},
+		"ds2": {Certificate: tc.DeliveryServiceSSLKeysCertificate{Crt: "invalid"}},
+	}
+	verify := func(certificate string, rootCA string) (string, error) {
+		if certificate == "invalid" {
+			return "", errors.New("ERROR: no certificate chain to verify\n")
+		}
+		return certificate, nil
+	}
+
+	bundle := makeSSLCertBundle(testSSLHosts(), keys, verify)
+	if len(bundle.Certificates) != 1 {
+		t.Fatalf("makeSSLCertBundle certificates expected: 1, actual: %v", len(bundle.Certificates))
+	}
+	cert := bundle.Certificates[0]
+	if cert.DeliveryService != "ds1" || cert.CertFileName != "cdn_ds1_cdn1_example_net_cert.cer" || cert.Key != "ds1 key" || !cert.Expiration.Equal(expiration) {
+		t.Errorf("makeSSLCertBundle certificate expected: ds1 expiring %v, actual: %+v", expiration, cert)
+	}
+	if _, err := certExpiration(cert.Cert); err != nil {
+		t.Errorf("makeSSLCertBundle certificate expected: PEM, actual: %s", cert.Cert)
+	}
+
+	expectedErrs := []tc.SSLCertificateError{
+		{DeliveryService: "ds2", Error: "verifying certificate: ERROR: no certificate chain to verify"},
+		{DeliveryService: "ds3", Error: "no SSL keys"},
+	}
+	if len(bundle.Errors) != len(expectedErrs) {
+		t.Fatalf("makeSSLCertBundle errors expected: %+v, actual: %+v", expectedErrs, bundle.Errors)
+	}
+	for i, expected := range expectedErrs {
+		if bundle.Errors[i] != expected {
+			t.Errorf("makeSSLCertBundle error expected: %+v, actual: %+v", expected, bundle.Errors[i])
+		}
+	}
+}
+
+func TestGetSSLExpirations(t *testing.T) {
+	keys := map[string]tc.DeliveryServiceSSLKeys{
+		"ds1": {Certificate: tc.DeliveryServiceSSLKeysCertificate{Crt: testSSLCert(t, "cdn.ds1.cdn1.example.net", testSSLNow.Add(10*24*time.Hour+time.Hour))}},
+		"ds2": {Certificate: tc.DeliveryServiceSSLKeysCertificate{Crt: testSSLCert(t, "cdn.ds2.cdn1.example.net", testSSLNow.Add(90*24*time.Hour))}},
+		"ds3": {Certificate: tc.DeliveryServiceSSLKeysCertificate{Crt: testSSLCert(t, "cdn.ds3.cdn1.example.net", testSSLNow.Add(-36*time.Hour))}},
+	}
+	actual := getSSLExpirations(testSSLHosts(), keys, testSSLNow, 30)
+	if len(actual) != 2 {
+		t.Fatalf("getSSLExpirations expected: 2 expirations, actual: %+v", actual)
+	}
+	if actual[0].DeliveryService != "ds3" || actual[0].DaysLeft != -2 {
+		t.Errorf("getSSLExpirations [0] expected: ds3 -2 days, actual: %s %v days", actual[0].DeliveryService, actual[0].DaysLeft)
+	}
+	if actual[1].DeliveryService != "ds1" || actual[1].DaysLeft != 10 {
+		t.Errorf("getSSLExpirations [1] expected: ds1 10 days, actual: %s %v days", actual[1].DeliveryService, actual[1].DaysLeft)
+	}
+
+	if actual := getSSLExpirations(testSSLHosts(), keys, testSSLNow, 100); len(actual) != 3 {
+		t.Errorf("getSSLExpirations 100 days expected: 3 expirations, actual: %v", len(actual))
+	}
+}
+
+func TestDecodeSSLKey(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte("-----BEGIN CERTIFICATE-----\nfoo\n-----END CERTIFICATE-----\n"))
+	escaped := encoded[:10] + `\n` + encoded[10:20] + "\n" + encoded[20:]
+	actual, err := decodeSSLKey(escaped)
+	if err != nil {
+		t.Fatalf("decodeSSLKey expected: nil error, actual: %v", err)
+	}
+	if expected := "-----BEGIN CERTIFICATE-----\nfoo\n-----END CERTIFICATE-----\n"; actual != expected {
+		t.Errorf("decodeSSLKey expected: '%s', actual: '%s'", expected, actual)
+	}
+}
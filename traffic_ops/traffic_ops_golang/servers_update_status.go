@@ -65,12 +65,15 @@ func getServerUpdateStatus(hostName string, db *sqlx.DB) ([]tc.ServerUpdateStatu
 		`WITH parentservers AS (SELECT ps.id, ps.cachegroup, ps.cdn_id, ps.upd_pending, ps.reval_pending FROM server ps
          LEFT JOIN status AS pstatus ON pstatus.id = ps.status
          WHERE pstatus.name != 'OFFLINE' ),
-         use_reval AS (SELECT value::boolean FROM parameter WHERE name = 'use_reval_pending' AND config_file = 'global' UNION ALL SELECT FALSE FETCH FIRST 1 ROW ONLY)
+         use_reval AS (SELECT value::boolean FROM parameter WHERE name = 'use_reval_pending' AND config_file = 'global' UNION ALL SELECT FALSE FETCH FIRST 1 ROW ONLY),
+         topology_parents AS (SELECT DISTINCT tc.cachegroup, p.cachegroup AS parent FROM topology_cachegroup tc
+         JOIN topology_cachegroup_parents tcp ON tcp.child = tc.id
+         JOIN topology_cachegroup p ON p.id = tcp.parent)
          SELECT s.id, s.host_name, type.name AS type, (s.reval_pending::boolean AND use_reval.value) as combined_reval_pending, s.upd_pending, status.name AS status, COALESCE(bool_or(ps.upd_pending), FALSE) AS parent_upd_pending, COALESCE(bool_or(ps.reval_pending), FALSE) AS parent_reval_pending FROM use_reval, server s
          LEFT JOIN status ON s.status = status.id
          LEFT JOIN cachegroup cg ON s.cachegroup = cg.id
          LEFT JOIN type ON type.id = s.type
-         LEFT JOIN parentservers ps ON ps.cachegroup = cg.parent_cachegroup_id AND ps.cdn_id = s.cdn_id AND type.name = 'EDGE'` //remove the EDGE reference if other server types should have their parents processed
+         LEFT JOIN parentservers ps ON ps.cdn_id = s.cdn_id AND ((ps.cachegroup = cg.parent_cachegroup_id AND type.name = 'EDGE') OR ps.cachegroup IN (SELECT tp.parent FROM topology_parents tp WHERE tp.cachegroup = cg.id))` //remove the EDGE reference if other server types should have their parents processed. Servers at any tier of a topology have their topology parents processed.
 
 	groupBy := ` GROUP BY s.id, s.host_name, type.name, combined_reval_pending, s.upd_pending, status.name ORDER BY s.id;`
 
@@ -0,0 +1,103 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	tc "github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/jmoiron/sqlx"
+)
+
+const StaticDNSEntriesPrivLevel = 10
+
+func staticDNSEntriesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+
+		entries, err := getStaticDNSEntries(r.URL.Query(), db)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		respBts, err := json.Marshal(tc.StaticDNSEntriesResponse{Response: entries})
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(tc.ContentType, tc.ApplicationJson)
+		fmt.Fprintf(w, "%s", respBts)
+	}
+}
+
+// getStaticDNSEntries returns the static DNS entries matching the query parameters, ordered by delivery service unless the orderby parameter says otherwise, like the Perl Traffic Ops.
+func getStaticDNSEntries(v url.Values, db *sqlx.DB) ([]tc.StaticDNSEntry, error) {
+	// Query Parameters to Database Query column mappings
+	// see the fields mapped in the SQL query
+	queryParamsToSQLCols := map[string]string{
+		"deliveryservice": "ds.xml_id",
+		"host":            "sde.host",
+		"ttl":             "sde.ttl",
+		"address":         "sde.address",
+		"type":            "t.name",
+		"cachegroup":      "cg.name",
+	}
+	if _, ok := v["orderby"]; !ok {
+		v.Set("orderby", "deliveryservice")
+	}
+	query, queryValues := BuildQuery(v, selectStaticDNSEntriesQuery(), queryParamsToSQLCols)
+
+	rows, err := db.NamedQuery(query, queryValues)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []tc.StaticDNSEntry{}
+	for rows.Next() {
+		entry := tc.StaticDNSEntry{}
+		if err = rows.StructScan(&entry); err != nil {
+			return nil, fmt.Errorf("getting static dns entries: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func selectStaticDNSEntriesQuery() string {
+	query := `SELECT
+ds.xml_id AS deliveryservice,
+sde.host,
+sde.ttl,
+sde.address,
+t.name AS type,
+COALESCE(cg.name, '') AS cachegroup
+
+FROM staticdnsentry sde
+JOIN deliveryservice ds ON ds.id = sde.deliveryservice
+JOIN type t ON t.id = sde.type
+LEFT JOIN cachegroup cg ON cg.id = sde.cachegroup`
+	return query
+}
@@ -0,0 +1,59 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/test"
+	"github.com/jmoiron/sqlx"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestGetStaticDNSEntries(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	db := sqlx.NewDb(mockDB, "sqlmock")
+	defer db.Close()
+
+	cols := test.ColsFromStructByTag("db", tc.StaticDNSEntry{})
+	rows := sqlmock.NewRows(cols).
+		AddRow("ds1", "www", 3600, "192.0.2.1", "A_RECORD", "cg1").
+		AddRow("ds2", "alias", 60, "www.example.net.", "CNAME_RECORD", "")
+	mock.ExpectQuery("SELECT.*ORDER BY ds.xml_id").WillReturnRows(rows)
+
+	entries, err := getStaticDNSEntries(url.Values{}, db)
+	if err != nil {
+		t.Fatalf("getStaticDNSEntries expected: nil error, actual: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("getStaticDNSEntries expected: 2 entries, actual: %v", len(entries))
+	}
+	if entries[1].Type != "CNAME_RECORD" || entries[1].TTL != 60 || entries[1].CacheGroup != "" {
+		t.Errorf("getStaticDNSEntries expected: CNAME_RECORD entry, actual: %+v", entries[1])
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// steeringHandler returns a handler which serves the Traffic Router steering feed of every steering delivery service the user may access or, if the path has an xml_id, that delivery service's entry alone.
+func steeringHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		user, _ := auth.GetUserName(r.Context())
+		privLevel, _ := auth.GetPrivLevel(r.Context())
+		accessible, err := getDSAccessChecker(db.DB, user, privLevel)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+
+		xmlID, hasXMLID := pathParams["xmlID"]
+		steering, err := getSteering(db.DB, xmlID, accessible)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !hasXMLID {
+			writeJSONResp(w, handleErr, tc.SteeringResponse{Response: steering})
+			return
+		}
+		if len(steering) == 0 {
+			handleErr(errors.New("steering delivery service not found"), http.StatusNotFound)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.SteeringDeliveryServiceResponse{Response: steering[0]})
+	}
+}
+
+// getSteering returns the steering feed entries of every steering delivery service with targets which is accessible or, if xmlID isn't empty, of that delivery service.
+func getSteering(db *sql.DB, xmlID string, accessible func(dsID int, dsTenant sql.NullInt64) bool) ([]tc.Steering, error) {
+	filters, err := getSteeringFilters(db)
+	if err != nil {
+		return nil, err
+	}
+
+	q := `
+SELECT s.id, s.xml_id, s.tenant_id, stp.name, t.xml_id, st.value, tp.name
+FROM steering_target st
+JOIN deliveryservice s ON s.id = st.deliveryservice
+JOIN type stp ON stp.id = s.type
+JOIN deliveryservice t ON t.id = st.target
+JOIN type tp ON tp.id = st.type
+`
+	args := []interface{}{}
+	if xmlID != "" {
+		q += `WHERE s.xml_id = $1
+`
+		args = append(args, xmlID)
+	}
+	q += `ORDER BY s.xml_id, t.xml_id`
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying steering targets: " + err.Error())
+	}
+	defer rows.Close()
+
+	steering := []tc.Steering{}
+	for rows.Next() {
+		steeringID := 0
+		steeringXMLID := ""
+		steeringTenant := sql.NullInt64{}
+		steeringType := ""
+		targetXMLID := ""
+		value := 0
+		targetType := ""
+		if err := rows.Scan(&steeringID, &steeringXMLID, &steeringTenant, &steeringType, &targetXMLID, &value, &targetType); err != nil {
+			return nil, errors.New("scanning steering targets: " + err.Error())
+		}
+		if !accessible(steeringID, steeringTenant) {
+			continue
+		}
+		if len(steering) == 0 || steering[len(steering)-1].DeliveryService != steeringXMLID {
+			steering = append(steering, tc.Steering{
+				DeliveryService: steeringXMLID,
+				ClientSteering:  steeringType == "CLIENT_STEERING",
+				Targets:         []tc.SteeringFeedTarget{},
+				Filters:         []tc.SteeringFilter{},
+			})
+		}
+		s := &steering[len(steering)-1]
+
+		target := tc.SteeringFeedTarget{DeliveryService: targetXMLID}
+		if targetType == tc.SteeringTargetTypeOrder {
+			target.Order = value
+		} else {
+			target.Weight = value
+		}
+		s.Targets = append(s.Targets, target)
+		for _, pattern := range filters[targetXMLID] {
+			s.Filters = append(s.Filters, tc.SteeringFilter{DeliveryService: targetXMLID, Pattern: pattern})
+		}
+	}
+	return steering, nil
+}
+
+// getSteeringFilters returns the steering regex patterns of every delivery service, by xml_id.
+func getSteeringFilters(db *sql.DB) (map[string][]string, error) {
+	q := `
+SELECT ds.xml_id, r.pattern
+FROM regex r
+JOIN deliveryservice_regex dsr ON dsr.regex = r.id
+JOIN deliveryservice ds ON ds.id = dsr.deliveryservice
+JOIN type t ON t.id = r.type
+WHERE t.name = $1
+ORDER BY r.pattern
+`
+	rows, err := db.Query(q, tc.SteeringRegexType)
+	if err != nil {
+		return nil, errors.New("querying steering filters: " + err.Error())
+	}
+	defer rows.Close()
+
+	filters := map[string][]string{}
+	for rows.Next() {
+		xmlID := ""
+		pattern := ""
+		if err := rows.Scan(&xmlID, &pattern); err != nil {
+			return nil, errors.New("scanning steering filters: " + err.Error())
+		}
+		filters[xmlID] = append(filters[xmlID], pattern)
+	}
+	return filters, nil
+}
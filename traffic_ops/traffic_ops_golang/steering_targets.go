@@ -0,0 +1,328 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const SteeringTargetsPrivLevel = auth.PrivLevelReadOnly
+
+// SteeringPrivLevel is the priv level of the steering role, which may manage steering targets and read the steering feed, as in Perl.
+const SteeringPrivLevel = auth.PrivLevelSteering
+
+// steeringDS is a steering delivery service, or a target of one.
+type steeringDS struct {
+	xmlID    string
+	tenantID sql.NullInt64
+	cdnID    int
+	typeName string
+}
+
+// steeringTargetType is a type of the type table, which must be a steering target type for a steering target to use it.
+type steeringTargetType struct {
+	name       string
+	useInTable string
+}
+
+// steeringTargetsHandler returns a handler which serves the targets of the steering delivery service in the path or, if the path has a target ID, that target, in a list.
+func steeringTargetsHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		steeringID, targetID, status, err := getPathSteeringTargetIDs(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		if _, status, err := getAccessibleSteeringDS(db.DB, r, steeringID, "Steering delivery-service"); err != nil {
+			handleErr(err, status)
+			return
+		}
+		targets, err := getSteeringTargets(db.DB, steeringID, targetID)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if targetID != 0 && len(targets) == 0 {
+			handleErr(errors.New("steering target not found"), http.StatusNotFound)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.SteeringTargetsResponse{Response: targets})
+	}
+}
+
+// createSteeringTargetHandler returns a handler which adds a target to the steering delivery service in the path.
+func createSteeringTargetHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		steeringID, _, status, err := getPathSteeringTargetIDs(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		req := tc.SteeringTargetRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		if status, err := checkSteeringTarget(db.DB, r, steeringID, req); err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		q := `
+INSERT INTO steering_target (deliveryservice, target, value, type) VALUES ($1, $2, $3, $4)
+ON CONFLICT (deliveryservice, target) DO NOTHING
+RETURNING deliveryservice
+`
+		if err := db.QueryRow(q, steeringID, req.TargetID, *req.Value, *req.TypeID).Scan(&steeringID); err != nil {
+			if err == sql.ErrNoRows {
+				handleErr(errors.New("Steering target already exists"), http.StatusBadRequest)
+				return
+			}
+			handleErr(errors.New("inserting steering target: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		logRequestChange(db.DB, r, "Created steering target [ '"+strconv.Itoa(req.TargetID)+"' ] for delivery service: "+strconv.Itoa(steeringID))
+		writeSteeringTargetResp(db.DB, w, handleErr, steeringID, req.TargetID, "Delivery service target creation was successful.")
+	}
+}
+
+// updateSteeringTargetHandler returns a handler which updates the value and type of the steering target in the path.
+func updateSteeringTargetHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		steeringID, targetID, status, err := getPathSteeringTargetIDs(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		req := tc.SteeringTargetRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+		req.TargetID = targetID
+		if status, err := checkSteeringTarget(db.DB, r, steeringID, req); err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		result, err := db.Exec(`UPDATE steering_target SET value = $1, type = $2 WHERE deliveryservice = $3 AND target = $4`, *req.Value, *req.TypeID, steeringID, targetID)
+		if err != nil {
+			handleErr(errors.New("updating steering target: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			handleErr(errors.New("getting updated steering targets: "+err.Error()), http.StatusInternalServerError)
+			return
+		} else if rowsAffected == 0 {
+			handleErr(errors.New("steering target not found"), http.StatusNotFound)
+			return
+		}
+
+		logRequestChange(db.DB, r, "Updated steering target [ "+strconv.Itoa(targetID)+" ] for deliveryservice: "+strconv.Itoa(steeringID))
+		writeSteeringTargetResp(db.DB, w, handleErr, steeringID, targetID, "Delivery service steering target update was successful.")
+	}
+}
+
+// deleteSteeringTargetHandler returns a handler which removes the steering target in the path from its steering delivery service.
+func deleteSteeringTargetHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		steeringID, targetID, status, err := getPathSteeringTargetIDs(r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		if _, status, err := getAccessibleSteeringDS(db.DB, r, steeringID, "Steering delivery-service"); err != nil {
+			handleErr(err, status)
+			return
+		}
+		if _, status, err := getAccessibleSteeringDS(db.DB, r, targetID, "Steering target delivery-service"); err != nil {
+			handleErr(err, status)
+			return
+		}
+
+		result, err := db.Exec(`DELETE FROM steering_target WHERE deliveryservice = $1 AND target = $2`, steeringID, targetID)
+		if err != nil {
+			handleErr(errors.New("deleting steering target: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			handleErr(errors.New("getting deleted steering targets: "+err.Error()), http.StatusInternalServerError)
+			return
+		} else if rowsAffected == 0 {
+			handleErr(errors.New("steering target not found"), http.StatusNotFound)
+			return
+		}
+
+		logRequestChange(db.DB, r, "Deleted steering target [ "+strconv.Itoa(targetID)+" ] for deliveryservice: "+strconv.Itoa(steeringID))
+		writeJSONResp(w, handleErr, tc.CreateAlerts(tc.SuccessLevel, "Delivery service target delete was successful."))
+	}
+}
+
+// checkSteeringTarget returns an error, and the status to respond with, if either delivery service of the requested steering target doesn't exist or is outside the user's tenancy, or the target is invalid.
+func checkSteeringTarget(db *sql.DB, r *http.Request, steeringID int, req tc.SteeringTargetRequest) (int, error) {
+	steering, status, err := getAccessibleSteeringDS(db, r, steeringID, "Steering delivery-service")
+	if err != nil {
+		return status, err
+	}
+	target, status, err := getAccessibleSteeringDS(db, r, req.TargetID, "Steering target delivery-service")
+	if err != nil {
+		if status == http.StatusNotFound {
+			status = http.StatusBadRequest
+		}
+		return status, err
+	}
+	targetType := steeringTargetType{}
+	if req.TypeID != nil {
+		if err := db.QueryRow(`SELECT name, use_in_table FROM type WHERE id = $1`, *req.TypeID).Scan(&targetType.name, &targetType.useInTable); err != nil && err != sql.ErrNoRows {
+			return http.StatusInternalServerError, errors.New("querying steering target type: " + err.Error())
+		}
+	}
+	if err := validateSteeringTarget(req, steeringID, steering, target, targetType); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
+
+// validateSteeringTarget returns an error if the steering target isn't valid: the steering delivery service must be a steering type, the target must be another delivery service in the same CDN, and the target type must be a steering target type.
+func validateSteeringTarget(req tc.SteeringTargetRequest, steeringID int, steering steeringDS, target steeringDS, targetType steeringTargetType) error {
+	if req.Value == nil {
+		return errors.New("value is required")
+	}
+	if req.TypeID == nil {
+		return errors.New("typeId is required")
+	}
+	if steering.typeName != "STEERING" && steering.typeName != "CLIENT_STEERING" {
+		return errors.New("delivery service '" + steering.xmlID + "' is not a steering delivery service")
+	}
+	if req.TargetID == steeringID {
+		return errors.New("a steering delivery service cannot be its own target")
+	}
+	if target.cdnID != steering.cdnID {
+		return errors.New("target delivery service '" + target.xmlID + "' must be in the same CDN as the steering delivery service")
+	}
+	if targetType.useInTable != "steering_target" || !strings.HasPrefix(targetType.name, tc.SteeringTargetTypePrefix) {
+		return errors.New("Invalid target type")
+	}
+	return nil
+}
+
+// getAccessibleSteeringDS returns the delivery service with the given ID, described in errors as desc, and the status to respond with if it doesn't exist or is outside the user's tenancy.
+func getAccessibleSteeringDS(db *sql.DB, r *http.Request, id int, desc string) (steeringDS, int, error) {
+	ds := steeringDS{}
+	q := `SELECT ds.xml_id, ds.tenant_id, ds.cdn_id, t.name FROM deliveryservice ds JOIN type t ON t.id = ds.type WHERE ds.id = $1`
+	if err := db.QueryRow(q, id).Scan(&ds.xmlID, &ds.tenantID, &ds.cdnID, &ds.typeName); err != nil {
+		if err == sql.ErrNoRows {
+			return steeringDS{}, http.StatusNotFound, errors.New(desc + " not found")
+		}
+		return steeringDS{}, http.StatusInternalServerError, errors.New("querying steering delivery service: " + err.Error())
+	}
+	user, _ := auth.GetUserName(r.Context())
+	accessible, err := getDSTenantChecker(db, user)
+	if err != nil {
+		return steeringDS{}, http.StatusInternalServerError, err
+	}
+	if !accessible(ds.tenantID) {
+		return steeringDS{}, http.StatusForbidden, errors.New("Forbidden. " + desc + " tenant is not available to the user.")
+	}
+	return ds, http.StatusOK, nil
+}
+
+// writeSteeringTargetResp writes the given steering target, with the given success message.
+func writeSteeringTargetResp(db *sql.DB, w http.ResponseWriter, handleErr func(error, int), steeringID int, targetID int, msg string) {
+	targets, err := getSteeringTargets(db, steeringID, targetID)
+	if err != nil {
+		handleErr(err, http.StatusInternalServerError)
+		return
+	}
+	if len(targets) == 0 {
+		handleErr(errors.New("steering target not found after save"), http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, handleErr, tc.SteeringTargetResponse{Response: targets[0], Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+}
+
+// getSteeringTargets returns the targets of the given steering delivery service or, if targetID isn't 0, that target.
+func getSteeringTargets(db *sql.DB, steeringID int, targetID int) ([]tc.SteeringTarget, error) {
+	q := `
+SELECT s.id, s.xml_id, t.id, t.xml_id, st.value, tp.id, tp.name
+FROM steering_target st
+JOIN deliveryservice s ON s.id = st.deliveryservice
+JOIN deliveryservice t ON t.id = st.target
+JOIN type tp ON tp.id = st.type
+WHERE st.deliveryservice = $1
+`
+	args := []interface{}{steeringID}
+	if targetID != 0 {
+		q += `AND st.target = $2
+`
+		args = append(args, targetID)
+	}
+	q += `ORDER BY t.xml_id`
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying steering targets: " + err.Error())
+	}
+	defer rows.Close()
+
+	targets := []tc.SteeringTarget{}
+	for rows.Next() {
+		st := tc.SteeringTarget{}
+		if err := rows.Scan(&st.DeliveryServiceID, &st.DeliveryService, &st.TargetID, &st.Target, &st.Value, &st.TypeID, &st.Type); err != nil {
+			return nil, errors.New("scanning steering targets: " + err.Error())
+		}
+		targets = append(targets, st)
+	}
+	return targets, nil
+}
+
+// getPathSteeringTargetIDs returns the steering delivery service ID in the path, the target ID in the path or 0 if it has none, and the status to respond with if either is invalid.
+func getPathSteeringTargetIDs(r *http.Request) (int, int, int, error) {
+	pathParams, err := getPathParams(r.Context())
+	if err != nil {
+		return 0, 0, http.StatusInternalServerError, err
+	}
+	steeringID, err := strconv.Atoi(pathParams["id"])
+	if err != nil {
+		return 0, 0, http.StatusBadRequest, errors.New("steering delivery service id must be an integer")
+	}
+	targetID := 0
+	if targetIDStr, ok := pathParams["targetID"]; ok {
+		if targetID, err = strconv.Atoi(targetIDStr); err != nil {
+			return 0, 0, http.StatusBadRequest, errors.New("steering target id must be an integer")
+		}
+	}
+	return steeringID, targetID, http.StatusOK, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestValidateSteeringTarget(t *testing.T) {
+	value := 100
+	typeID := 40
+	steering := steeringDS{xmlID: "steering", cdnID: 1, typeName: "STEERING"}
+	target := steeringDS{xmlID: "target", cdnID: 1, typeName: "HTTP"}
+	weight := steeringTargetType{name: tc.SteeringTargetTypeWeight, useInTable: "steering_target"}
+	req := tc.SteeringTargetRequest{TargetID: 2, Value: &value, TypeID: &typeID}
+	if err := validateSteeringTarget(req, 1, steering, target, weight); err != nil {
+		t.Errorf("validateSteeringTarget expected: nil error, actual: %v", err)
+	}
+
+	for _, test := range []struct {
+		req        tc.SteeringTargetRequest
+		steering   steeringDS
+		target     steeringDS
+		targetType steeringTargetType
+		expected   string
+	}{
+		{tc.SteeringTargetRequest{TargetID: 2, TypeID: &typeID}, steering, target, weight, "value is required"},
+		{tc.SteeringTargetRequest{TargetID: 2, Value: &value}, steering, target, weight, "typeId is required"},
+		{req, steeringDS{xmlID: "http", cdnID: 1, typeName: "HTTP"}, target, weight, "'http' is not a steering delivery service"},
+		{tc.SteeringTargetRequest{TargetID: 1, Value: &value, TypeID: &typeID}, steering, target, weight, "cannot be its own target"},
+		{req, steering, steeringDS{xmlID: "other-cdn", cdnID: 2, typeName: "HTTP"}, weight, "'other-cdn' must be in the same CDN"},
+		{req, steering, target, steeringTargetType{name: "HTTP", useInTable: "deliveryservice"}, "Invalid target type"},
+		{req, steering, target, steeringTargetType{}, "Invalid target type"},
+	} {
+		err := validateSteeringTarget(test.req, 1, test.steering, test.target, test.targetType)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("validateSteeringTarget %+v expected: error containing '%s', actual: %v", test, test.expected, err)
+		}
+	}
+}
+
+func TestGetSteeringTargets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "xml_id", "id", "xml_id", "value", "id", "name"})
+	rows = rows.AddRow(1, "steering", 2, "target1", 100, 40, tc.SteeringTargetTypeWeight)
+	rows = rows.AddRow(1, "steering", 3, "target2", 1, 41, tc.SteeringTargetTypeOrder)
+	mock.ExpectQuery("SELECT").WithArgs(1).WillReturnRows(rows)
+
+	targets, err := getSteeringTargets(db, 1, 0)
+	if err != nil {
+		t.Fatalf("getSteeringTargets expected: nil error, actual: %v", err)
+	}
+	expected := []tc.SteeringTarget{
+		{DeliveryServiceID: 1, DeliveryService: "steering", TargetID: 2, Target: "target1", Value: 100, TypeID: 40, Type: tc.SteeringTargetTypeWeight},
+		{DeliveryServiceID: 1, DeliveryService: "steering", TargetID: 3, Target: "target2", Value: 1, TypeID: 41, Type: tc.SteeringTargetTypeOrder},
+	}
+	if !reflect.DeepEqual(expected, targets) {
+		t.Errorf("getSteeringTargets expected: %+v, actual: %+v", expected, targets)
+	}
+}
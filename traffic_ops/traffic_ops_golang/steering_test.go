@@ -0,0 +1,71 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestGetSteering(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	filterRows := sqlmock.NewRows([]string{"xml_id", "pattern"})
+	filterRows = filterRows.AddRow("target1", ".*/force-target1/.*")
+	mock.ExpectQuery("SELECT").WithArgs(tc.SteeringRegexType).WillReturnRows(filterRows)
+
+	rows := sqlmock.NewRows([]string{"id", "xml_id", "tenant_id", "name", "xml_id", "value", "name"})
+	rows = rows.AddRow(1, "client-steering", nil, "CLIENT_STEERING", "target1", 1, tc.SteeringTargetTypeOrder)
+	rows = rows.AddRow(1, "client-steering", nil, "CLIENT_STEERING", "target2", 2, tc.SteeringTargetTypeOrder)
+	rows = rows.AddRow(4, "other-tenant", 2, "STEERING", "target1", 100, tc.SteeringTargetTypeWeight)
+	rows = rows.AddRow(5, "steering", nil, "STEERING", "target2", 100, tc.SteeringTargetTypeWeight)
+	mock.ExpectQuery("SELECT").WillReturnRows(rows)
+
+	accessible := func(dsID int, dsTenant sql.NullInt64) bool { return !dsTenant.Valid }
+	steering, err := getSteering(db, "", accessible)
+	if err != nil {
+		t.Fatalf("getSteering expected: nil error, actual: %v", err)
+	}
+	expected := []tc.Steering{
+		{
+			DeliveryService: "client-steering",
+			ClientSteering:  true,
+			Targets:         []tc.SteeringFeedTarget{{DeliveryService: "target1", Order: 1}, {DeliveryService: "target2", Order: 2}},
+			Filters:         []tc.SteeringFilter{{DeliveryService: "target1", Pattern: ".*/force-target1/.*"}},
+		},
+		{
+			DeliveryService: "steering",
+			Targets:         []tc.SteeringFeedTarget{{DeliveryService: "target2", Weight: 100}},
+			Filters:         []tc.SteeringFilter{},
+		},
+	}
+	if !reflect.DeepEqual(expected, steering) {
+		t.Errorf("getSteering expected: %+v, actual: %+v", expected, steering)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+)
+
+// UseTenancyParamName is the global parameter which enables tenancy. Without it, users may only access delivery services assigned to them, unless they're operations or above.
+const UseTenancyParamName = "use_tenancy"
+
+// tenant is a node of the tenant tree. The root tenant has no parent.
+type tenant struct {
+	parentID sql.NullInt64
+	active   bool
+}
+
+// getUseTenancy returns whether the use_tenancy global parameter is set and not 0.
+func getUseTenancy(db *sql.DB) (bool, error) {
+	val := ""
+	q := `SELECT value FROM parameter WHERE name = $1 AND config_file = 'global' LIMIT 1`
+	if err := db.QueryRow(q, UseTenancyParamName).Scan(&val); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, errors.New("querying " + UseTenancyParamName + ": " + err.Error())
+	}
+	return val != "0", nil
+}
+
+// getTenants returns every tenant, by ID.
+func getTenants(db *sql.DB) (map[int]tenant, error) {
+	rows, err := db.Query(`SELECT id, parent_id, active FROM tenant`)
+	if err != nil {
+		return nil, errors.New("querying tenants: " + err.Error())
+	}
+	defer rows.Close()
+
+	tenants := map[int]tenant{}
+	for rows.Next() {
+		id := 0
+		t := tenant{}
+		if err := rows.Scan(&id, &t.parentID, &t.active); err != nil {
+			return nil, errors.New("scanning tenants: " + err.Error())
+		}
+		tenants[id] = t
+	}
+	return tenants, nil
+}
+
+// tenantAccessible returns whether resources of resourceTenant are accessible to users of userTenant, the same as the Perl Utils::Tenant: resources without a tenant are accessible to everyone, and resources with a tenant to users of that tenant or its ancestors, if the user's tenant is active. If the tree is broken, only users of a root tenant have access, so they can fix it.
+func tenantAccessible(tenants map[int]tenant, userTenant sql.NullInt64, resourceTenant sql.NullInt64) bool {
+	if userTenant.Valid && !tenants[int(userTenant.Int64)].active {
+		return false
+	}
+	if !resourceTenant.Valid {
+		return true
+	}
+	if !userTenant.Valid {
+		return false
+	}
+
+	userID := int(userTenant.Int64)
+	if _, ok := tenants[userID]; !ok {
+		return false
+	}
+	visited := map[int]struct{}{}
+	for id := int(resourceTenant.Int64); ; {
+		if id == userID {
+			return true
+		}
+		t, ok := tenants[id]
+		if _, loop := visited[id]; !ok || loop {
+			return !tenants[userID].parentID.Valid
+		}
+		if !t.parentID.Valid {
+			return false
+		}
+		visited[id] = struct{}{}
+		id = int(t.parentID.Int64)
+	}
+}
+
+// isDSAccessible returns whether the given user may access the given delivery service. With tenancy, the delivery service's tenant must be accessible to the user's tenant. Without it, the user must be operations or above, or have the delivery service assigned to them.
+func isDSAccessible(db *sql.DB, userName string, privLevel int, dsID int, dsTenant sql.NullInt64) (bool, error) {
+	useTenancy, err := getUseTenancy(db)
+	if err != nil {
+		return false, err
+	}
+	if !useTenancy {
+		if privLevel >= auth.PrivLevelOperations {
+			return true, nil
+		}
+		assigned := false
+		q := `SELECT EXISTS(SELECT 1 FROM deliveryservice_tmuser dsu JOIN tm_user u ON u.id = dsu.tm_user_id WHERE u.username = $1 AND dsu.deliveryservice = $2)`
+		if err := db.QueryRow(q, userName, dsID).Scan(&assigned); err != nil {
+			return false, errors.New("querying user delivery services: " + err.Error())
+		}
+		return assigned, nil
+	}
+
+	userTenant := sql.NullInt64{}
+	if err := db.QueryRow(`SELECT tenant_id FROM tm_user WHERE username = $1`, userName).Scan(&userTenant); err != nil && err != sql.ErrNoRows {
+		return false, errors.New("querying user tenant: " + err.Error())
+	}
+	tenants, err := getTenants(db)
+	if err != nil {
+		return false, err
+	}
+	return tenantAccessible(tenants, userTenant, dsTenant), nil
+}
+
+// getDSTenantChecker returns a func which returns whether delivery services of the given tenant are accessible to the given user, like the Perl is_ds_resource_accessible, for checking many delivery services at once. Without tenancy, every delivery service is accessible.
+func getDSTenantChecker(db *sql.DB, userName string) (func(dsTenant sql.NullInt64) bool, error) {
+	useTenancy, err := getUseTenancy(db)
+	if err != nil {
+		return nil, err
+	}
+	if !useTenancy {
+		return func(sql.NullInt64) bool { return true }, nil
+	}
+
+	userTenant := sql.NullInt64{}
+	if err := db.QueryRow(`SELECT tenant_id FROM tm_user WHERE username = $1`, userName).Scan(&userTenant); err != nil && err != sql.ErrNoRows {
+		return nil, errors.New("querying user tenant: " + err.Error())
+	}
+	tenants, err := getTenants(db)
+	if err != nil {
+		return nil, err
+	}
+	return func(dsTenant sql.NullInt64) bool { return tenantAccessible(tenants, userTenant, dsTenant) }, nil
+}
+
+// getDSAccessChecker returns a func which returns whether the given user may access delivery services, like isDSAccessible, for checking many delivery services at once.
+func getDSAccessChecker(db *sql.DB, userName string, privLevel int) (func(dsID int, dsTenant sql.NullInt64) bool, error) {
+	useTenancy, err := getUseTenancy(db)
+	if err != nil {
+		return nil, err
+	}
+	if useTenancy {
+		accessible, err := getDSTenantChecker(db, userName)
+		if err != nil {
+			return nil, err
+		}
+		return func(dsID int, dsTenant sql.NullInt64) bool { return accessible(dsTenant) }, nil
+	}
+	if privLevel >= auth.PrivLevelOperations {
+		return func(int, sql.NullInt64) bool { return true }, nil
+	}
+
+	rows, err := db.Query(`SELECT dsu.deliveryservice FROM deliveryservice_tmuser dsu JOIN tm_user u ON u.id = dsu.tm_user_id WHERE u.username = $1`, userName)
+	if err != nil {
+		return nil, errors.New("querying user delivery services: " + err.Error())
+	}
+	defer rows.Close()
+	assigned := map[int]struct{}{}
+	for rows.Next() {
+		dsID := 0
+		if err := rows.Scan(&dsID); err != nil {
+			return nil, errors.New("scanning user delivery services: " + err.Error())
+		}
+		assigned[dsID] = struct{}{}
+	}
+	return func(dsID int, dsTenant sql.NullInt64) bool {
+		_, ok := assigned[dsID]
+		return ok
+	}, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestTenantAccessible(t *testing.T) {
+	null := sql.NullInt64{}
+	id := func(i int64) sql.NullInt64 { return sql.NullInt64{Int64: i, Valid: true} }
+	tenants := map[int]tenant{
+		1: {active: true},
+		2: {parentID: id(1), active: true},
+		3: {parentID: id(2), active: true},
+		4: {parentID: id(1), active: false},
+		5: {parentID: id(6), active: true},
+		6: {parentID: id(5), active: true},
+	}
+
+	tests := []struct {
+		name     string
+		user     sql.NullInt64
+		resource sql.NullInt64
+		expected bool
+	}{
+		{"same tenant", id(2), id(2), true},
+		{"parent tenant", id(1), id(3), true},
+		{"child tenant", id(3), id(2), false},
+		{"sibling tenant", id(4), id(2), false},
+		{"inactive user tenant", id(4), id(4), false},
+		{"resource without tenant", id(3), null, true},
+		{"user without tenant", null, id(3), false},
+		{"user and resource without tenant", null, null, true},
+		{"loop, non-root user", id(2), id(5), false},
+		{"loop, root user", id(1), id(5), true},
+		{"missing resource tenant, root user", id(1), id(7), true},
+		{"missing user tenant", id(7), id(3), false},
+	}
+	for _, test := range tests {
+		if actual := tenantAccessible(tenants, test.user, test.resource); actual != test.expected {
+			t.Errorf("tenantAccessible %s expected: %v, actual: %v", test.name, test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,529 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/ats"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const TopologiesPrivLevel = auth.PrivLevelReadOnly
+const TopologiesWritePrivLevel = auth.PrivLevelOperations
+
+// TopologyMaxParents is the most parents a cachegroup can have in a topology, a parent and a secondary parent.
+const TopologyMaxParents = 2
+
+// topologiesHandler returns a handler which serves every topology.
+func topologiesHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		topologies, err := getTopologies(db.DB, "")
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.TopologiesResponse{Response: topologies})
+	}
+}
+
+// topologyHandler returns a handler which serves the topology in the path, in a list.
+func topologyHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		topologies, err := getTopologies(db.DB, pathParams["name"])
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if len(topologies) == 0 {
+			handleErr(errors.New("topology not found"), http.StatusNotFound)
+			return
+		}
+		writeJSONResp(w, handleErr, tc.TopologiesResponse{Response: topologies})
+	}
+}
+
+// createTopologyHandler returns a handler which creates a topology.
+func createTopologyHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		topology, status, err := decodeTopology(db.DB, r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		if status, err := saveTopology(db.DB, "", topology); err != nil {
+			handleErr(err, status)
+			return
+		}
+		writeTopologyResp(db.DB, w, r, handleErr, topology.Name, "Topology created [ name = "+topology.Name+" ]")
+	}
+}
+
+// updateTopologyHandler returns a handler which replaces the topology in the path, including its name.
+func updateTopologyHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		topology, status, err := decodeTopology(db.DB, r)
+		if err != nil {
+			handleErr(err, status)
+			return
+		}
+		if status, err := saveTopology(db.DB, pathParams["name"], topology); err != nil {
+			handleErr(err, status)
+			return
+		}
+		writeTopologyResp(db.DB, w, r, handleErr, topology.Name, "Topology updated [ name = "+topology.Name+" ]")
+	}
+}
+
+// deleteTopologyHandler returns a handler which deletes the topology in the path, unless delivery services use it.
+func deleteTopologyHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		name := pathParams["name"]
+		xmlIDs, err := getTopologyDSes(db.DB, name)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if len(xmlIDs) > 0 {
+			handleErr(errors.New("topology is used by delivery services: "+strings.Join(xmlIDs, ", ")), http.StatusBadRequest)
+			return
+		}
+		result, err := db.Exec(`DELETE FROM topology WHERE name = $1`, name)
+		if err != nil {
+			handleErr(errors.New("deleting topology: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			handleErr(errors.New("getting deleted topologies: "+err.Error()), http.StatusInternalServerError)
+			return
+		} else if rowsAffected == 0 {
+			handleErr(errors.New("topology not found"), http.StatusNotFound)
+			return
+		}
+
+		msg := "Topology deleted [ name = " + name + " ]"
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.CreateAlerts(tc.SuccessLevel, msg))
+	}
+}
+
+// setDSTopologyHandler returns a handler which sets or removes the topology of the delivery service in the path. It's forbidden if the delivery service is outside the user's tenancy.
+func setDSTopologyHandler(db *sqlx.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		handleErr := tc.GetHandleErrorFunc(w, r)
+		pathParams, err := getPathParams(r.Context())
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		dsID, err := strconv.Atoi(pathParams["id"])
+		if err != nil {
+			handleErr(errors.New("delivery service id must be an integer"), http.StatusBadRequest)
+			return
+		}
+		req := tc.DeliveryServiceTopologyRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			handleErr(errors.New("malformed JSON: "+err.Error()), http.StatusBadRequest)
+			return
+		}
+
+		xmlID := ""
+		tenantID := sql.NullInt64{}
+		if err := db.QueryRow(`SELECT xml_id, tenant_id FROM deliveryservice WHERE id = $1`, dsID).Scan(&xmlID, &tenantID); err != nil {
+			if err == sql.ErrNoRows {
+				handleErr(errors.New("delivery service not found"), http.StatusNotFound)
+				return
+			}
+			handleErr(errors.New("querying delivery service: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+		user, _ := auth.GetUserName(r.Context())
+		accessible, err := getDSTenantChecker(db.DB, user)
+		if err != nil {
+			handleErr(err, http.StatusInternalServerError)
+			return
+		}
+		if !accessible(tenantID) {
+			handleErr(errors.New("Forbidden. Delivery-service tenant is not available to the user."), http.StatusForbidden)
+			return
+		}
+
+		if req.Topology != nil {
+			exists := false
+			if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM topology WHERE name = $1)`, *req.Topology).Scan(&exists); err != nil {
+				handleErr(errors.New("querying topology: "+err.Error()), http.StatusInternalServerError)
+				return
+			}
+			if !exists {
+				handleErr(errors.New("topology not found"), http.StatusBadRequest)
+				return
+			}
+		}
+		if _, err := db.Exec(`UPDATE deliveryservice SET topology = $1 WHERE id = $2`, req.Topology, dsID); err != nil {
+			handleErr(errors.New("updating delivery service topology: "+err.Error()), http.StatusInternalServerError)
+			return
+		}
+
+		msg := "Removed topology from delivery service [ " + xmlID + " ]"
+		if req.Topology != nil {
+			msg = "Set topology of delivery service [ " + xmlID + " ] to [ " + *req.Topology + " ]"
+		}
+		logRequestChange(db.DB, r, msg)
+		writeJSONResp(w, handleErr, tc.DeliveryServiceTopologyResponse{Response: req, Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+	}
+}
+
+// decodeTopology returns the topology in the request body, and the status to respond with if it's malformed or invalid.
+func decodeTopology(db *sql.DB, r *http.Request) (tc.Topology, int, error) {
+	topology := tc.Topology{}
+	if err := json.NewDecoder(r.Body).Decode(&topology); err != nil {
+		return tc.Topology{}, http.StatusBadRequest, errors.New("malformed JSON: " + err.Error())
+	}
+	cacheGroupTypes, err := getCacheGroupTypes(db)
+	if err != nil {
+		return tc.Topology{}, http.StatusInternalServerError, err
+	}
+	if err := validateTopology(topology, cacheGroupTypes); err != nil {
+		return tc.Topology{}, http.StatusBadRequest, err
+	}
+	return topology, http.StatusOK, nil
+}
+
+// writeTopologyResp logs the change, and writes the saved topology with the given name and a success alert.
+func writeTopologyResp(db *sql.DB, w http.ResponseWriter, r *http.Request, handleErr func(error, int), name string, msg string) {
+	logRequestChange(db, r, msg)
+	topologies, err := getTopologies(db, name)
+	if err != nil {
+		handleErr(err, http.StatusInternalServerError)
+		return
+	}
+	if len(topologies) != 1 {
+		handleErr(errors.New("getting saved topology: not found"), http.StatusInternalServerError)
+		return
+	}
+	writeJSONResp(w, handleErr, &tc.TopologyResponse{Response: topologies[0], Alerts: tc.CreateAlerts(tc.SuccessLevel, msg)})
+}
+
+// validateTopology returns an error listing everything wrong with a topology, or nil if it's valid. The cacheGroupTypes are the type of every cachegroup, by name. Cachegroups must have at most a parent and secondary parent, origin cachegroups must have none and every other cachegroup must have at least one, and there must be no cycles, so every path through the topology reaches an origin.
+func validateTopology(topology tc.Topology, cacheGroupTypes map[string]string) error {
+	errs := []string{}
+	if topology.Name == "" {
+		errs = append(errs, "name is required")
+	}
+	if len(topology.Nodes) == 0 {
+		errs = append(errs, "a topology must have at least one cachegroup")
+	}
+	seen := map[string]struct{}{}
+	for i, node := range topology.Nodes {
+		if _, ok := seen[node.CacheGroup]; ok {
+			errs = append(errs, "cachegroup '"+node.CacheGroup+"' is in the topology more than once")
+		}
+		seen[node.CacheGroup] = struct{}{}
+
+		cgType, ok := cacheGroupTypes[node.CacheGroup]
+		if !ok {
+			errs = append(errs, "cachegroup '"+node.CacheGroup+"' does not exist")
+		} else if cgType == ats.OriginCacheGroupType && len(node.Parents) > 0 {
+			errs = append(errs, "origin cachegroup '"+node.CacheGroup+"' cannot have parents")
+		} else if cgType != ats.OriginCacheGroupType && len(node.Parents) == 0 {
+			errs = append(errs, "cachegroup '"+node.CacheGroup+"' has no parents, so does not reach an origin")
+		}
+		if len(node.Parents) > TopologyMaxParents {
+			errs = append(errs, "cachegroup '"+node.CacheGroup+"' has more than "+strconv.Itoa(TopologyMaxParents)+" parents")
+		}
+		for j, parent := range node.Parents {
+			if parent < 0 || parent >= len(topology.Nodes) {
+				errs = append(errs, "cachegroup '"+node.CacheGroup+"' has a parent "+strconv.Itoa(parent)+" which is not in the topology")
+			} else if parent == i {
+				errs = append(errs, "cachegroup '"+node.CacheGroup+"' cannot be its own parent")
+			} else if j > 0 && parent == node.Parents[0] {
+				errs = append(errs, "cachegroup '"+node.CacheGroup+"' has the same parent and secondary parent")
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+	if cycle := findTopologyCycle(topology.Nodes); len(cycle) > 0 {
+		return errors.New("topology has a cycle: " + strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// findTopologyCycle returns the cachegroups of a cycle of parents in the given nodes, starting and ending with the same cachegroup, or nil if there are no cycles. Node parents must be valid indexes.
+func findTopologyCycle(nodes []tc.TopologyNode) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	states := make([]int, len(nodes))
+	path := []int{}
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		states[i] = visiting
+		path = append(path, i)
+		for _, parent := range nodes[i].Parents {
+			switch states[parent] {
+			case visiting:
+				for j, node := range path {
+					if node == parent {
+						return append(append([]int{}, path[j:]...), parent)
+					}
+				}
+			case unvisited:
+				if cycle := visit(parent); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[i] = visited
+		return nil
+	}
+
+	for i := range nodes {
+		if states[i] != unvisited {
+			continue
+		}
+		if cycle := visit(i); cycle != nil {
+			names := []string{}
+			for _, node := range cycle {
+				names = append(names, nodes[node].CacheGroup)
+			}
+			return names
+		}
+	}
+	return nil
+}
+
+// getCacheGroupTypes returns the type name of every cachegroup, by cachegroup name.
+func getCacheGroupTypes(db *sql.DB) (map[string]string, error) {
+	rows, err := db.Query(`SELECT cg.name, t.name FROM cachegroup cg JOIN type t ON t.id = cg.type`)
+	if err != nil {
+		return nil, errors.New("querying cachegroups: " + err.Error())
+	}
+	defer rows.Close()
+
+	types := map[string]string{}
+	for rows.Next() {
+		name := ""
+		cgType := ""
+		if err := rows.Scan(&name, &cgType); err != nil {
+			return nil, errors.New("scanning cachegroups: " + err.Error())
+		}
+		types[name] = cgType
+	}
+	return types, nil
+}
+
+// getTopologies returns every topology or, if name isn't empty, the topology with the given name, with its nodes in the order they were saved.
+func getTopologies(db *sql.DB, name string) ([]tc.Topology, error) {
+	q := `
+SELECT t.name, t.description, t.last_updated, tc.id, cg.name
+FROM topology t
+LEFT JOIN topology_cachegroup tc ON tc.topology = t.name
+LEFT JOIN cachegroup cg ON cg.id = tc.cachegroup
+`
+	args := []interface{}{}
+	if name != "" {
+		q += "WHERE t.name = $1\n"
+		args = append(args, name)
+	}
+	q += "ORDER BY t.name, tc.id"
+
+	rows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying topologies: " + err.Error())
+	}
+	defer rows.Close()
+
+	topologies := []tc.Topology{}
+	// nodeIndexes is the topology and index in its nodes of each topology_cachegroup, by ID.
+	nodeIndexes := map[int64][2]int{}
+	for rows.Next() {
+		topology := tc.Topology{}
+		nodeID := sql.NullInt64{}
+		cacheGroup := sql.NullString{}
+		if err := rows.Scan(&topology.Name, &topology.Description, &topology.LastUpdated, &nodeID, &cacheGroup); err != nil {
+			return nil, errors.New("scanning topologies: " + err.Error())
+		}
+		if len(topologies) == 0 || topologies[len(topologies)-1].Name != topology.Name {
+			topology.Nodes = []tc.TopologyNode{}
+			topologies = append(topologies, topology)
+		}
+		if !nodeID.Valid {
+			continue
+		}
+		last := &topologies[len(topologies)-1]
+		nodeIndexes[nodeID.Int64] = [2]int{len(topologies) - 1, len(last.Nodes)}
+		last.Nodes = append(last.Nodes, tc.TopologyNode{CacheGroup: cacheGroup.String, Parents: []int{}})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.New("iterating topologies: " + err.Error())
+	}
+	if len(nodeIndexes) == 0 {
+		return topologies, nil
+	}
+
+	q = `
+SELECT tcp.child, tcp.parent
+FROM topology_cachegroup_parents tcp
+JOIN topology_cachegroup tc ON tc.id = tcp.child
+`
+	if name != "" {
+		q += "WHERE tc.topology = $1\n"
+	}
+	q += "ORDER BY tcp.child, tcp.rank"
+	parentRows, err := db.Query(q, args...)
+	if err != nil {
+		return nil, errors.New("querying topology parents: " + err.Error())
+	}
+	defer parentRows.Close()
+	for parentRows.Next() {
+		child := int64(0)
+		parent := int64(0)
+		if err := parentRows.Scan(&child, &parent); err != nil {
+			return nil, errors.New("scanning topology parents: " + err.Error())
+		}
+		childIndex, ok := nodeIndexes[child]
+		parentIndex, parentOK := nodeIndexes[parent]
+		if !ok || !parentOK {
+			continue
+		}
+		node := &topologies[childIndex[0]].Nodes[childIndex[1]]
+		node.Parents = append(node.Parents, parentIndex[1])
+	}
+	return topologies, nil
+}
+
+// saveTopology inserts the given valid topology or, if oldName isn't empty, replaces the topology with that name, and returns the status to respond with if it fails.
+func saveTopology(db *sql.DB, oldName string, topology tc.Topology) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return http.StatusInternalServerError, errors.New("beginning transaction: " + err.Error())
+	}
+	commit := false
+	defer func() {
+		if !commit {
+			tx.Rollback()
+		}
+	}()
+
+	if oldName != topology.Name {
+		exists := false
+		if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM topology WHERE name = $1)`, topology.Name).Scan(&exists); err != nil {
+			return http.StatusInternalServerError, errors.New("querying topology: " + err.Error())
+		}
+		if exists {
+			return http.StatusBadRequest, errors.New("a topology with name '" + topology.Name + "' already exists")
+		}
+	}
+
+	if oldName == "" {
+		if _, err := tx.Exec(`INSERT INTO topology (name, description) VALUES ($1, $2)`, topology.Name, topology.Description); err != nil {
+			return http.StatusInternalServerError, errors.New("inserting topology: " + err.Error())
+		}
+	} else {
+		result, err := tx.Exec(`UPDATE topology SET name = $1, description = $2 WHERE name = $3`, topology.Name, topology.Description, oldName)
+		if err != nil {
+			return http.StatusInternalServerError, errors.New("updating topology: " + err.Error())
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return http.StatusInternalServerError, errors.New("getting updated topologies: " + err.Error())
+		} else if rowsAffected == 0 {
+			return http.StatusNotFound, errors.New("topology not found")
+		}
+		if _, err := tx.Exec(`DELETE FROM topology_cachegroup WHERE topology = $1`, topology.Name); err != nil {
+			return http.StatusInternalServerError, errors.New("deleting topology cachegroups: " + err.Error())
+		}
+	}
+
+	nodeIDs := []int64{}
+	q := `INSERT INTO topology_cachegroup (topology, cachegroup) SELECT $1, id FROM cachegroup WHERE name = $2 RETURNING id`
+	for _, node := range topology.Nodes {
+		id := int64(0)
+		if err := tx.QueryRow(q, topology.Name, node.CacheGroup).Scan(&id); err != nil {
+			return http.StatusInternalServerError, errors.New("inserting topology cachegroup " + node.CacheGroup + ": " + err.Error())
+		}
+		nodeIDs = append(nodeIDs, id)
+	}
+	q = `INSERT INTO topology_cachegroup_parents (child, parent, rank) VALUES ($1, $2, $3)`
+	for i, node := range topology.Nodes {
+		for j, parent := range node.Parents {
+			if _, err := tx.Exec(q, nodeIDs[i], nodeIDs[parent], j+1); err != nil {
+				return http.StatusInternalServerError, errors.New("inserting topology parent of " + node.CacheGroup + ": " + err.Error())
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return http.StatusInternalServerError, errors.New("committing topology: " + err.Error())
+	}
+	commit = true
+	return http.StatusOK, nil
+}
+
+// getTopologyDSes returns the xml_id of every delivery service using the given topology.
+func getTopologyDSes(db *sql.DB, name string) ([]string, error) {
+	rows, err := db.Query(`SELECT xml_id FROM deliveryservice WHERE topology = $1 ORDER BY xml_id`, name)
+	if err != nil {
+		return nil, errors.New("querying topology delivery services: " + err.Error())
+	}
+	defer rows.Close()
+
+	xmlIDs := []string{}
+	for rows.Next() {
+		xmlID := ""
+		if err := rows.Scan(&xmlID); err != nil {
+			return nil, errors.New("scanning topology delivery services: " + err.Error())
+		}
+		xmlIDs = append(xmlIDs, xmlID)
+	}
+	return xmlIDs, nil
+}
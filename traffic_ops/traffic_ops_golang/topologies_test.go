@@ -0,0 +1,114 @@
+package main
+
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one
+ * or more contributor license agreements.  See the NOTICE file
+ * distributed with this work for additional information
+ * regarding copyright ownership.  The ASF licenses this file
+ * to you under the Apache License, Version 2.0 (the
+ * "License"); you may not use this file except in compliance
+ * with the License.  You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing,
+ * software distributed under the License is distributed on an
+ * "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+ * KIND, either express or implied.  See the License for the
+ * specific language governing permissions and limitations
+ * under the License.
+ */
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var testCacheGroupTypes = map[string]string{
+	"edge1": "EDGE_LOC",
+	"edge2": "EDGE_LOC",
+	"mid1":  "MID_LOC",
+	"mid2":  "MID_LOC",
+	"org1":  "ORG_LOC",
+}
+
+func TestValidateTopology(t *testing.T) {
+	valid := tc.Topology{Name: "three-tier", Nodes: []tc.TopologyNode{
+		{CacheGroup: "edge1", Parents: []int{2, 3}},
+		{CacheGroup: "edge2", Parents: []int{2}},
+		{CacheGroup: "mid1", Parents: []int{4}},
+		{CacheGroup: "mid2", Parents: []int{2}},
+		{CacheGroup: "org1"},
+	}}
+	if err := validateTopology(valid, testCacheGroupTypes); err != nil {
+		t.Errorf("validateTopology expected: nil error, actual: %v", err)
+	}
+
+	for _, test := range []struct {
+		nodes    []tc.TopologyNode
+		expected string
+	}{
+		{nil, "at least one cachegroup"},
+		{[]tc.TopologyNode{{CacheGroup: "nope"}}, "'nope' does not exist"},
+		{[]tc.TopologyNode{{CacheGroup: "org1"}, {CacheGroup: "org1"}}, "'org1' is in the topology more than once"},
+		{[]tc.TopologyNode{{CacheGroup: "edge1"}}, "'edge1' has no parents"},
+		{[]tc.TopologyNode{{CacheGroup: "org1", Parents: []int{1}}, {CacheGroup: "mid1", Parents: []int{0}}}, "origin cachegroup 'org1' cannot have parents"},
+		{[]tc.TopologyNode{{CacheGroup: "edge1", Parents: []int{1, 2, 3}}, {CacheGroup: "mid1", Parents: []int{3}}, {CacheGroup: "mid2", Parents: []int{3}}, {CacheGroup: "org1"}}, "more than 2 parents"},
+		{[]tc.TopologyNode{{CacheGroup: "edge1", Parents: []int{5}}}, "parent 5 which is not in the topology"},
+		{[]tc.TopologyNode{{CacheGroup: "edge1", Parents: []int{0}}}, "cannot be its own parent"},
+		{[]tc.TopologyNode{{CacheGroup: "edge1", Parents: []int{1, 1}}, {CacheGroup: "org1"}}, "same parent and secondary parent"},
+		{[]tc.TopologyNode{{CacheGroup: "edge1", Parents: []int{1}}, {CacheGroup: "mid1", Parents: []int{2}}, {CacheGroup: "mid2", Parents: []int{1, 3}}, {CacheGroup: "org1"}}, "cycle: mid1 -> mid2 -> mid1"},
+	} {
+		err := validateTopology(tc.Topology{Name: "test", Nodes: test.nodes}, testCacheGroupTypes)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("validateTopology %+v expected: error containing '%s', actual: %v", test.nodes, test.expected, err)
+		}
+	}
+
+	valid.Name = ""
+	if err := validateTopology(valid, testCacheGroupTypes); err == nil || !strings.Contains(err.Error(), "name is required") {
+		t.Errorf("validateTopology no name expected: name is required error, actual: %v", err)
+	}
+}
+
+func TestGetTopologies(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDB.Close()
+
+	lastUpdated := time.Date(2017, 11, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"name", "description", "last_updated", "id", "name"}).
+		AddRow("empty", "", lastUpdated, nil, nil).
+		AddRow("tiered", "edge to mid to origin", lastUpdated, 10, "org1").
+		AddRow("tiered", "edge to mid to origin", lastUpdated, 11, "mid1").
+		AddRow("tiered", "edge to mid to origin", lastUpdated, 12, "edge1")
+	mock.ExpectQuery("SELECT t.name").WillReturnRows(rows)
+	parentRows := sqlmock.NewRows([]string{"child", "parent"}).
+		AddRow(11, 10).
+		AddRow(12, 11)
+	mock.ExpectQuery("SELECT tcp.child").WillReturnRows(parentRows)
+
+	actual, err := getTopologies(mockDB, "")
+	if err != nil {
+		t.Fatalf("getTopologies expected: nil error, actual: %v", err)
+	}
+	expected := []tc.Topology{
+		{Name: "empty", Nodes: []tc.TopologyNode{}, LastUpdated: tc.Time{Time: lastUpdated, Valid: true}},
+		{Name: "tiered", Description: "edge to mid to origin", LastUpdated: tc.Time{Time: lastUpdated, Valid: true}, Nodes: []tc.TopologyNode{
+			{CacheGroup: "org1", Parents: []int{}},
+			{CacheGroup: "mid1", Parents: []int{0}},
+			{CacheGroup: "edge1", Parents: []int{1}},
+		}},
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("getTopologies expected: %+v, actual: %+v", expected, actual)
+	}
+}
@@ -108,6 +108,10 @@ func main() {
 		return
 	}
 
+	if cfg.RiakEnabled && cfg.DNSSECRefreshInterval > 0 {
+		go refreshDNSSECKeysEvery(db, cfg, time.Duration(cfg.DNSSECRefreshInterval)*time.Second)
+	}
+
 	log.Infof("Listening on " + cfg.Port)
 
 	server := &http.Server{
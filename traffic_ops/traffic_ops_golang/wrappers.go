@@ -45,9 +45,12 @@ type AuthBase struct {
 	secret        string
 	privLevelStmt *sql.Stmt
 	override      Middleware
+	// capabilityStmt is prepared from auth.CapabilityRoutesQuery. If it isn't nil, users are authorized by their capabilities rather than their priv level.
+	capabilityStmt *sql.Stmt
 }
 
-func (a AuthBase) GetWrapper(privLevelRequired int) Middleware {
+// GetWrapper returns middleware which authenticates the user, and authorizes them for the given method and capability route, e.g. GET /api/*/cdns/*, or if capabilities aren't used, the given priv level.
+func (a AuthBase) GetWrapper(privLevelRequired int, method string, capabilityRoute string) Middleware {
 	if a.override != nil {
 		return a.override
 	}
@@ -66,9 +69,7 @@ func (a AuthBase) GetWrapper(privLevelRequired int) Middleware {
 			iw := &Interceptor{w: w}
 			w = iw
 			username := "-"
-			defer func() {
-				log.EventfRaw(`%s - %s [%s] "%v %v HTTP/1.1" %v %v %v "%v"`, r.RemoteAddr, username, time.Now().Format(AccessLogTimeFormat), r.Method, r.URL.Path, iw.code, iw.byteCount, int(time.Now().Sub(start)/time.Millisecond), r.UserAgent())
-			}()
+			defer func() { logAccess(r, username, iw, start) }()
 
 			handleUnauthorized := func(reason string) {
 				status := http.StatusUnauthorized
@@ -96,7 +97,16 @@ func (a AuthBase) GetWrapper(privLevelRequired int) Middleware {
 
 			username = oldCookie.AuthData
 			privLevel := auth.PrivLevel(a.privLevelStmt, username)
-			if privLevel < privLevelRequired {
+			if a.capabilityStmt != nil {
+				hasCapability, err := auth.HasCapability(a.capabilityStmt, username, method, capabilityRoute)
+				if err != nil {
+					log.Errorf("checking user %v capabilities: %v\n", username, err)
+				}
+				if !hasCapability {
+					handleUnauthorized("missing capability for " + method + " " + capabilityRoute)
+					return
+				}
+			} else if privLevel < privLevelRequired {
 				handleUnauthorized("insufficient privileges")
 				return
 			}
@@ -116,7 +126,7 @@ func (a AuthBase) GetWrapper(privLevelRequired int) Middleware {
 func wrapHeaders(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Set-Cookie, Cookie")
+		w.Header().Set("Access-Control-Allow-Headers", "Origin, X-Requested-With, Content-Type, Accept, Set-Cookie, Cookie, "+tc.TraceIDHeader)
 		w.Header().Set("Access-Control-Allow-Methods", "POST,GET,OPTIONS,PUT,DELETE")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("X-Server-Name", ServerName)
@@ -145,13 +155,33 @@ func wrapAccessLog(secret string, h http.Handler) http.HandlerFunc {
 			}
 		}
 		start := time.Now()
-		defer func() {
-			log.EventfRaw(`%s - %s [%s] "%v %v HTTP/1.1" %v %v %v "%v"`, r.RemoteAddr, user, time.Now().Format(AccessLogTimeFormat), r.Method, r.URL.Path, iw.code, iw.byteCount, int(time.Now().Sub(start)/time.Millisecond), r.UserAgent())
-		}()
+		defer func() { logAccess(r, user, iw, start) }()
 		h.ServeHTTP(iw, r)
 	}
 }
 
+// logAccess writes the access log line of the given request, by the given user, whose response was written to the given Interceptor. The last field is the client's trace ID, from the tc.TraceIDHeader request header, or - if it has none.
+func logAccess(r *http.Request, user string, iw *Interceptor, start time.Time) {
+	log.EventfRaw(`%s - %s [%s] "%v %v HTTP/1.1" %v %v %v "%v" "%v"`, r.RemoteAddr, user, time.Now().Format(AccessLogTimeFormat), r.Method, r.URL.Path, iw.code, iw.byteCount, int(time.Now().Sub(start)/time.Millisecond), r.UserAgent(), accessLogTraceID(r))
+}
+
+// maxTraceIDLen is the longest trace ID which is logged. Longer IDs are assumed to be garbage, or an attempt to flood the log.
+const maxTraceIDLen = 128
+
+// accessLogTraceID returns the request's trace ID, or - if it has none, or it's too long or has characters which could corrupt the log line.
+func accessLogTraceID(r *http.Request) string {
+	traceID := r.Header.Get(tc.TraceIDHeader)
+	if traceID == "" || len(traceID) > maxTraceIDLen {
+		return "-"
+	}
+	for _, c := range traceID {
+		if c <= ' ' || c > '~' || c == '"' {
+			return "-"
+		}
+	}
+	return traceID
+}
+
 // gzipResponse takes a function which cannot error and returns only bytes, and wraps it as a http.HandlerFunc. The errContext is logged if the write fails, and should be enough information to trace the problem (function name, endpoint, request parameters, etc).
 func gzipResponse(w http.ResponseWriter, r *http.Request, bytes []byte) {
 
@@ -25,9 +25,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	stdlog "log"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-log"
 	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/auth"
 	"github.com/apache/incubator-trafficcontrol/traffic_ops/traffic_ops_golang/tocookie"
 	"github.com/jmoiron/sqlx"
@@ -140,7 +144,7 @@ func TestWrapAuth(t *testing.T) {
 		t.Fatalf("could not create priv statement: %v\n", err)
 	}
 
-	authBase := AuthBase{false, secret, sqlStatement, nil}
+	authBase := AuthBase{false, secret, sqlStatement, nil, nil}
 
 	cookie := tocookie.New(userName, time.Now().Add(time.Minute), secret)
 
@@ -172,7 +176,7 @@ func TestWrapAuth(t *testing.T) {
 		fmt.Fprintf(w, "%s", respBts)
 	}
 
-	authWrapper := authBase.GetWrapper(15)
+	authWrapper := authBase.GetWrapper(15, http.MethodGet, "/api/*/test")
 
 	f := authWrapper(handler)
 
@@ -219,4 +223,39 @@ func TestWrapAuth(t *testing.T) {
 	}
 }
 
-// TODO: TestWrapAccessLog
+func TestWrapAccessLog(t *testing.T) {
+	buf := &bytes.Buffer{}
+	oldEvent := log.Event
+	log.Event = stdlog.New(buf, "", 0)
+	defer func() { log.Event = oldEvent }()
+
+	f := wrapAccessLog("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	traceIDs := map[string]string{
+		"":                         "-",
+		"4bf92f3577b34da6a3ce929d": "4bf92f3577b34da6a3ce929d",
+		`evil" "injected`:          "-",
+		strings.Repeat("a", 129):   "-",
+	}
+	for traceID, expected := range traceIDs {
+		buf.Reset()
+		r, err := http.NewRequest("GET", "/api/1.2/cdns", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if traceID != "" {
+			r.Header.Set(tc.TraceIDHeader, traceID)
+		}
+		f(httptest.NewRecorder(), r)
+
+		line := strings.TrimSpace(buf.String())
+		if !strings.HasSuffix(line, `"`+expected+`"`) {
+			t.Errorf("trace ID %q expected access log to end with %q, actual %q", traceID, expected, line)
+		}
+		if !strings.Contains(line, `"GET /api/1.2/cdns HTTP/1.1" 200 2 `) {
+			t.Errorf("expected access log to have the request and response, actual %q", line)
+		}
+	}
+}
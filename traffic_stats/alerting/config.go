@@ -0,0 +1,155 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+// Package alerting detects anomalies in delivery service traffic, by comparing each new value with a rolling baseline, and sends alerts about them.
+package alerting
+
+import (
+	"time"
+)
+
+const (
+	defaultAlpha                = 0.05
+	defaultMinSamples           = 30
+	defaultMinSeasonalWeeks     = 3
+	defaultMinKbps              = 1000
+	defaultMinTPS               = 1
+	defaultDropPercent          = 50
+	defaultSpikePercent         = 200
+	defaultFiveXXRatioJump      = 0.05
+	defaultDedupIntervalSeconds = 3600
+	defaultNotifyTimeoutSeconds = 10
+	defaultNotifyQueueSize      = 100
+	defaultSyslogTag            = "traffic_stats"
+	defaultEmailRelay           = "localhost:25"
+	defaultEmailSubjectPrefix   = "[traffic_stats]"
+)
+
+// Config is the "alerting" section of the Traffic Stats config. Zero values are replaced with defaults by WithDefaults.
+type Config struct {
+	Enabled bool `json:"enabled"`
+	// Alpha is the EWMA smoothing factor of the baselines, between 0 and 1. Bigger values follow changes faster.
+	Alpha float64 `json:"alpha"`
+	// MinSamples is the number of values a baseline needs before anything is compared with it.
+	MinSamples int `json:"minSamples"`
+	// MinSeasonalWeeks is the number of different weeks an hour of the week needs samples from before its own baseline is used instead of the overall one.
+	MinSeasonalWeeks int `json:"minSeasonalWeeks"`
+	// MinKbps is the smallest bandwidth baseline which is alerted on, so small delivery services don't alert on noise.
+	MinKbps float64 `json:"minKbps"`
+	// MinTPS is the smallest tps_total the 5xx ratio is computed for.
+	MinTPS float64 `json:"minTps"`
+	// DropPercent alerts when a value falls this far below its baseline.
+	DropPercent float64 `json:"dropPercent"`
+	// SpikePercent alerts when a value rises this far above its baseline.
+	SpikePercent float64 `json:"spikePercent"`
+	// FiveXXRatioJump alerts when the fraction of 5xx responses rises this much above its baseline, e.g. 0.05 is 5 percentage points.
+	FiveXXRatioJump float64 `json:"fiveXXRatioJump"`
+	// DedupIntervalSeconds is how long the same alert is suppressed after it's sent.
+	DedupIntervalSeconds int `json:"dedupIntervalSeconds"`
+	// NotifyTimeoutSeconds is the timeout of each webhook request and SMTP session.
+	NotifyTimeoutSeconds int `json:"notifyTimeoutSeconds"`
+	// NotifyQueueSize is how many alerts may wait to be sent. Alerts raised while the queue is full are dropped, so slow notifiers never hold up polling.
+	NotifyQueueSize int           `json:"notifyQueueSize"`
+	Silences        []Silence     `json:"silences"`
+	Webhooks        []string      `json:"webhooks"`
+	Email           *EmailConfig  `json:"email"`
+	Syslog          *SyslogConfig `json:"syslog"`
+}
+
+// EmailConfig sends alerts through an unauthenticated SMTP relay, normally the local MTA.
+type EmailConfig struct {
+	Relay         string   `json:"relay"`
+	From          string   `json:"from"`
+	To            []string `json:"to"`
+	SubjectPrefix string   `json:"subjectPrefix"`
+}
+
+// SyslogConfig sends alerts to the local syslog daemon.
+type SyslogConfig struct {
+	Tag string `json:"tag"`
+}
+
+// Silence suppresses matching alerts until the given time, or forever if Until is zero. Empty fields match anything.
+type Silence struct {
+	DeliveryService string    `json:"deliveryService"`
+	CacheGroup      string    `json:"cacheGroup"`
+	Kind            string    `json:"kind"`
+	Until           time.Time `json:"until"`
+}
+
+// WithDefaults returns the config with every unset value replaced by its default.
+func (c Config) WithDefaults() Config {
+	if c.Alpha <= 0 || c.Alpha > 1 {
+		c.Alpha = defaultAlpha
+	}
+	if c.MinSamples <= 0 {
+		c.MinSamples = defaultMinSamples
+	}
+	if c.MinSeasonalWeeks <= 0 {
+		c.MinSeasonalWeeks = defaultMinSeasonalWeeks
+	}
+	if c.MinKbps <= 0 {
+		c.MinKbps = defaultMinKbps
+	}
+	if c.MinTPS <= 0 {
+		c.MinTPS = defaultMinTPS
+	}
+	if c.DropPercent <= 0 {
+		c.DropPercent = defaultDropPercent
+	}
+	if c.SpikePercent <= 0 {
+		c.SpikePercent = defaultSpikePercent
+	}
+	if c.FiveXXRatioJump <= 0 {
+		c.FiveXXRatioJump = defaultFiveXXRatioJump
+	}
+	if c.DedupIntervalSeconds <= 0 {
+		c.DedupIntervalSeconds = defaultDedupIntervalSeconds
+	}
+	if c.NotifyTimeoutSeconds <= 0 {
+		c.NotifyTimeoutSeconds = defaultNotifyTimeoutSeconds
+	}
+	if c.NotifyQueueSize <= 0 {
+		c.NotifyQueueSize = defaultNotifyQueueSize
+	}
+	if c.Email != nil {
+		email := *c.Email
+		if email.Relay == "" {
+			email.Relay = defaultEmailRelay
+		}
+		if email.SubjectPrefix == "" {
+			email.SubjectPrefix = defaultEmailSubjectPrefix
+		}
+		c.Email = &email
+	}
+	if c.Syslog != nil && c.Syslog.Tag == "" {
+		c.Syslog = &SyslogConfig{Tag: defaultSyslogTag}
+	}
+	return c
+}
+
+// Matches returns whether the silence applies to the alert at the given time.
+func (s Silence) Matches(a Alert, now time.Time) bool {
+	if !s.Until.IsZero() && now.After(s.Until) {
+		return false
+	}
+	return (s.DeliveryService == "" || s.DeliveryService == a.DeliveryService) &&
+		(s.CacheGroup == "" || s.CacheGroup == a.CacheGroup) &&
+		(s.Kind == "" || s.Kind == string(a.Kind))
+}
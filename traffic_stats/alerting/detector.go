@@ -0,0 +1,301 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package alerting
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AlertKind is the kind of anomaly an alert is about.
+type AlertKind string
+
+const (
+	KindDrop        = AlertKind("drop")
+	KindSpike       = AlertKind("spike")
+	KindFiveXXRatio = AlertKind("5xx_ratio")
+)
+
+const (
+	StatKbps     = "kbps"
+	StatTPSTotal = "tps_total"
+	StatTPS5xx   = "tps_5xx"
+	// StatFiveXXRatio is the derived tps_5xx / tps_total stat.
+	StatFiveXXRatio = "5xx_ratio"
+)
+
+// seasonalSlots is the number of seasonal baselines per series, one for each hour of each day of the week.
+const seasonalSlots = 7 * 24
+
+// Observation is a single delivery service stat value, as polled from Traffic Monitor.
+type Observation struct {
+	DeliveryService string
+	CacheGroup      string
+	Stat            string
+	Value           float64
+	Time            time.Time
+}
+
+// Alert is a detected anomaly.
+type Alert struct {
+	Kind            AlertKind `json:"kind"`
+	DeliveryService string    `json:"deliveryService"`
+	CacheGroup      string    `json:"cacheGroup"`
+	Stat            string    `json:"stat"`
+	Value           float64   `json:"value"`
+	Baseline        float64   `json:"baseline"`
+	Time            time.Time `json:"time"`
+}
+
+// Key identifies the alert for deduplication and silencing.
+func (a Alert) Key() string {
+	return fmt.Sprintf("%s|%s|%s|%s", a.Kind, a.DeliveryService, a.CacheGroup, a.Stat)
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("%s: delivery service %s cachegroup %s %s is %.2f, baseline %.2f at %s", a.Kind, a.DeliveryService, a.CacheGroup, a.Stat, a.Value, a.Baseline, a.Time.Format(time.RFC3339))
+}
+
+// ewma is an exponentially weighted moving average.
+type ewma struct {
+	mean    float64
+	samples int
+}
+
+func (e *ewma) add(v float64, alpha float64) {
+	if e.samples == 0 {
+		e.mean = v
+	} else {
+		e.mean = alpha*v + (1-alpha)*e.mean
+	}
+	e.samples++
+}
+
+// seasonalEWMA is the baseline of one hour of the week, which also counts the distinct weeks its samples came from.
+type seasonalEWMA struct {
+	ewma
+	weeks    int
+	lastWeek int64
+}
+
+func (e *seasonalEWMA) add(v float64, t time.Time, alpha float64) {
+	e.ewma.add(v, alpha)
+	if week := weekOf(t); e.weeks == 0 || week > e.lastWeek {
+		e.weeks++
+		e.lastWeek = week
+	}
+}
+
+// weekOf returns the number of whole weeks since the Unix epoch. Week boundaries fall on an hour, so every sample of an hour of the week falls in the same week.
+func weekOf(t time.Time) int64 {
+	return t.Unix() / int64(7*24*time.Hour/time.Second)
+}
+
+// baseline is the expected value of a single series. The seasonal baseline for the hour of the week is used once it has enough samples from enough different weeks, and until then the overall baseline is.
+type baseline struct {
+	overall  ewma
+	seasonal [seasonalSlots]seasonalEWMA
+	// anomalies is the number of anomalous samples in a row.
+	anomalies int
+}
+
+func seasonalSlot(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// expected returns the baseline value at the given time, whether it's the seasonal baseline, and false if there aren't enough samples yet.
+func (b *baseline) expected(t time.Time, minSamples int, minWeeks int) (float64, bool, bool) {
+	if s := b.seasonal[seasonalSlot(t)]; s.samples >= minSamples && s.weeks >= minWeeks {
+		return s.mean, true, true
+	}
+	if b.overall.samples >= minSamples {
+		return b.overall.mean, false, true
+	}
+	return 0, false, false
+}
+
+// add adds the value to the baselines. An anomalous value is left out of the baseline it was compared with, so an outage or a spike doesn't drag that baseline along with it, until minSamples anomalous values in a row show a lasting change. A seasonal baseline which isn't in use yet still learns from it, since it wasn't what the value was compared with.
+func (b *baseline) add(v float64, t time.Time, alpha float64, anomalous bool, seasonal bool, minSamples int) {
+	if anomalous {
+		b.anomalies++
+	} else {
+		b.anomalies = 0
+	}
+	if anomalous && b.anomalies < minSamples {
+		if !seasonal {
+			b.seasonal[seasonalSlot(t)].add(v, t, alpha)
+		}
+		return
+	}
+	b.overall.add(v, alpha)
+	b.seasonal[seasonalSlot(t)].add(v, t, alpha)
+}
+
+type seriesKey struct {
+	ds   string
+	cg   string
+	stat string
+}
+
+// Detector keeps a rolling baseline of every delivery service and cachegroup series, and detects values which stray too far from them. It is safe for concurrent use.
+type Detector struct {
+	m         sync.Mutex
+	cfg       Config
+	baselines map[seriesKey]*baseline
+	lastSent  map[string]time.Time
+}
+
+// NewDetector returns a Detector with no baselines.
+func NewDetector(cfg Config) *Detector {
+	return &Detector{
+		cfg:       cfg.WithDefaults(),
+		baselines: map[seriesKey]*baseline{},
+		lastSent:  map[string]time.Time{},
+	}
+}
+
+// SetConfig changes the config, keeping the baselines which have been learned.
+func (d *Detector) SetConfig(cfg Config) {
+	d.m.Lock()
+	defer d.m.Unlock()
+	d.cfg = cfg.WithDefaults()
+}
+
+// Config returns the config in use, with defaults applied.
+func (d *Detector) Config() Config {
+	d.m.Lock()
+	defer d.m.Unlock()
+	return d.cfg
+}
+
+// Observe compares a poll's observations with their baselines, adds them to the baselines, and returns the alerts which should be sent. Alerts which are silenced, or were already sent within the dedup interval, aren't returned. The 5xx ratio is derived from the tps_5xx and tps_total observations of the same delivery service and cachegroup, so both should be in the same call.
+func (d *Detector) Observe(obs []Observation) []Alert {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if !d.cfg.Enabled {
+		return nil
+	}
+
+	alerts := []Alert{}
+	tps := map[seriesKey]map[string]Observation{}
+	for _, o := range obs {
+		switch o.Stat {
+		case StatKbps:
+			if alert, ok := d.observe(o, d.checkKbps); ok {
+				alerts = append(alerts, alert)
+			}
+		case StatTPSTotal, StatTPS5xx:
+			key := seriesKey{ds: o.DeliveryService, cg: o.CacheGroup}
+			if tps[key] == nil {
+				tps[key] = map[string]Observation{}
+			}
+			tps[key][o.Stat] = o
+		}
+	}
+
+	for _, stats := range tps {
+		total, totalOK := stats[StatTPSTotal]
+		fiveXX, fiveXXOK := stats[StatTPS5xx]
+		if !totalOK || !fiveXXOK || total.Value < d.cfg.MinTPS {
+			continue
+		}
+		ratio := total
+		ratio.Stat = StatFiveXXRatio
+		ratio.Value = fiveXX.Value / total.Value
+		if alert, ok := d.observe(ratio, d.checkFiveXXRatio); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return d.filter(alerts, time.Now())
+}
+
+// observe checks the observation against its baseline with the given check, and then adds it to the baseline.
+func (d *Detector) observe(o Observation, check func(o Observation, expected float64) (AlertKind, bool)) (Alert, bool) {
+	key := seriesKey{ds: o.DeliveryService, cg: o.CacheGroup, stat: o.Stat}
+	b, ok := d.baselines[key]
+	if !ok {
+		b = &baseline{}
+		d.baselines[key] = b
+	}
+	alert := Alert{}
+	anomalous := false
+	expected, seasonal, ok := b.expected(o.Time, d.cfg.MinSamples, d.cfg.MinSeasonalWeeks)
+	if ok {
+		if kind, isAnomaly := check(o, expected); isAnomaly {
+			anomalous = true
+			alert = Alert{
+				Kind:            kind,
+				DeliveryService: o.DeliveryService,
+				CacheGroup:      o.CacheGroup,
+				Stat:            o.Stat,
+				Value:           o.Value,
+				Baseline:        expected,
+				Time:            o.Time,
+			}
+		}
+	}
+	b.add(o.Value, o.Time, d.cfg.Alpha, anomalous, seasonal, d.cfg.MinSamples)
+	return alert, anomalous
+}
+
+func (d *Detector) checkKbps(o Observation, expected float64) (AlertKind, bool) {
+	if expected < d.cfg.MinKbps {
+		return "", false
+	}
+	if o.Value < expected*(1-d.cfg.DropPercent/100) {
+		return KindDrop, true
+	}
+	if o.Value > expected*(1+d.cfg.SpikePercent/100) {
+		return KindSpike, true
+	}
+	return "", false
+}
+
+func (d *Detector) checkFiveXXRatio(o Observation, expected float64) (AlertKind, bool) {
+	return KindFiveXXRatio, o.Value-expected > d.cfg.FiveXXRatioJump
+}
+
+// filter removes silenced alerts and alerts sent within the dedup interval, and records when the rest were sent. Alerts sent before the dedup interval are forgotten, so the record doesn't grow with every alert ever sent.
+func (d *Detector) filter(alerts []Alert, now time.Time) []Alert {
+	filtered := []Alert{}
+	dedup := time.Duration(d.cfg.DedupIntervalSeconds) * time.Second
+	for key, last := range d.lastSent {
+		if now.Sub(last) >= dedup {
+			delete(d.lastSent, key)
+		}
+	}
+AlertLoop:
+	for _, alert := range alerts {
+		for _, silence := range d.cfg.Silences {
+			if silence.Matches(alert, now) {
+				continue AlertLoop
+			}
+		}
+		if _, ok := d.lastSent[alert.Key()]; ok {
+			continue
+		}
+		d.lastSent[alert.Key()] = now
+		filtered = append(filtered, alert)
+	}
+	return filtered
+}
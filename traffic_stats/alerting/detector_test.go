@@ -0,0 +1,195 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package alerting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/traffic_stats/assert"
+)
+
+var testStart = time.Date(2017, 10, 2, 12, 0, 0, 0, time.UTC)
+
+func testConfig() Config {
+	return Config{Enabled: true, MinSamples: 5, Alpha: 0.5}
+}
+
+func kbps(ds string, value float64, t time.Time) Observation {
+	return Observation{DeliveryService: ds, CacheGroup: "total", Stat: StatKbps, Value: value, Time: t}
+}
+
+// warm observes a steady kbps value for the given delivery service until its baseline has enough samples.
+func warm(t *testing.T, d *Detector, ds string, value float64) time.Time {
+	now := testStart
+	for i := 0; i < d.Config().MinSamples; i++ {
+		assert.Empty(t, d.Observe([]Observation{kbps(ds, value, now)}))
+		now = now.Add(10 * time.Second)
+	}
+	return now
+}
+
+func TestDetectorDropAndSpike(t *testing.T) {
+	d := NewDetector(testConfig())
+	now := warm(t, d, "ds1", 10000)
+
+	assert.Empty(t, d.Observe([]Observation{kbps("ds1", 9000, now)}))
+
+	alerts := d.Observe([]Observation{kbps("ds1", 2000, now)})
+	assert.Equal(t, len(alerts), 1)
+	assert.Equal(t, alerts[0].Kind, KindDrop)
+	assert.Equal(t, alerts[0].DeliveryService, "ds1")
+
+	d = NewDetector(testConfig())
+	now = warm(t, d, "ds1", 10000)
+	alerts = d.Observe([]Observation{kbps("ds1", 50000, now)})
+	assert.Equal(t, len(alerts), 1)
+	assert.Equal(t, alerts[0].Kind, KindSpike)
+}
+
+func TestDetectorNeedsSamplesAndTraffic(t *testing.T) {
+	d := NewDetector(testConfig())
+	// not enough samples for a baseline
+	assert.Empty(t, d.Observe([]Observation{kbps("ds1", 10000, testStart)}))
+	assert.Empty(t, d.Observe([]Observation{kbps("ds1", 0, testStart)}))
+
+	// baselines below MinKbps are never alerted on
+	now := warm(t, d, "small", 10)
+	assert.Empty(t, d.Observe([]Observation{kbps("small", 0, now)}))
+}
+
+// observeWeek observes a week's traffic of a delivery service which has little traffic in the test hour and much more in the hour after it.
+func observeWeek(d *Detector, week int) []Alert {
+	alerts := []Alert{}
+	start := testStart.AddDate(0, 0, 7*week)
+	for i := 0; i < 20; i++ {
+		alerts = append(alerts, d.Observe([]Observation{kbps("ds1", 20000, start.Add(time.Hour+time.Duration(i)*time.Minute))})...)
+	}
+	for i := 0; i < 2; i++ {
+		alerts = append(alerts, d.Observe([]Observation{kbps("ds1", 2000, start.Add(time.Duration(i)*time.Minute))})...)
+	}
+	return alerts
+}
+
+func TestDetectorSeasonalBaseline(t *testing.T) {
+	d := NewDetector(testConfig())
+	cfg := d.Config()
+	key := seriesKey{ds: "ds1", cg: "total", stat: StatKbps}
+	observeWeek(d, 0)
+	// one week of samples isn't enough for the seasonal baseline, so the overall baseline is used
+	expected, seasonal, ok := d.baselines[key].expected(testStart.AddDate(0, 0, 7), cfg.MinSamples, cfg.MinSeasonalWeeks)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, seasonal, false)
+	assert.Equal(t, expected, 20000.0)
+
+	d = NewDetector(testConfig())
+	for week := 0; week < cfg.MinSeasonalWeeks; week++ {
+		observeWeek(d, week)
+	}
+	// the same hour after enough weeks is compared with its seasonal baseline, not the overall one
+	next := testStart.AddDate(0, 0, 7*cfg.MinSeasonalWeeks)
+	expected, seasonal, _ = d.baselines[key].expected(next, cfg.MinSamples, cfg.MinSeasonalWeeks)
+	assert.Equal(t, seasonal, true)
+	assert.Equal(t, expected, 2000.0)
+	assert.Empty(t, d.Observe([]Observation{kbps("ds1", 2000, next)}))
+}
+
+func TestDetectorSkipsAnomalies(t *testing.T) {
+	d := NewDetector(testConfig())
+	now := warm(t, d, "ds1", 10000)
+	b := d.baselines[seriesKey{ds: "ds1", cg: "total", stat: StatKbps}]
+
+	// an outage doesn't drag the baseline down, and isn't mistaken for normal traffic
+	for i := 1; i < d.Config().MinSamples; i++ {
+		d.Observe([]Observation{kbps("ds1", 0, now)})
+		assert.Equal(t, b.overall.mean, 10000.0)
+	}
+	assert.Empty(t, d.Observe([]Observation{kbps("ds1", 10000, now)}))
+
+	// a lasting change is learned once it has been anomalous for MinSamples samples in a row
+	for i := 0; i < d.Config().MinSamples; i++ {
+		d.Observe([]Observation{kbps("ds1", 40000, now)})
+	}
+	assert.Equal(t, b.overall.mean, 25000.0)
+}
+
+func TestDetectorFiveXXRatio(t *testing.T) {
+	d := NewDetector(testConfig())
+	t0 := testStart
+	poll := func(total float64, fiveXX float64) []Alert {
+		t0 = t0.Add(10 * time.Second)
+		return d.Observe([]Observation{
+			{DeliveryService: "ds1", CacheGroup: "total", Stat: StatTPSTotal, Value: total, Time: t0},
+			{DeliveryService: "ds1", CacheGroup: "total", Stat: StatTPS5xx, Value: fiveXX, Time: t0},
+		})
+	}
+	for i := 0; i < 5; i++ {
+		assert.Empty(t, poll(100, 1))
+	}
+	// too little traffic to compute a ratio
+	assert.Empty(t, poll(0.5, 0.5))
+
+	alerts := poll(100, 20)
+	assert.Equal(t, len(alerts), 1)
+	assert.Equal(t, alerts[0].Kind, KindFiveXXRatio)
+	assert.Equal(t, alerts[0].Value, 0.2)
+}
+
+func TestDetectorDedupAndSilence(t *testing.T) {
+	d := NewDetector(testConfig())
+	now := warm(t, d, "ds1", 10000)
+	assert.Equal(t, len(d.Observe([]Observation{kbps("ds1", 0, now)})), 1)
+	// the same alert isn't sent again within the dedup interval
+	assert.Empty(t, d.Observe([]Observation{kbps("ds1", 0, now)}))
+
+	cfg := testConfig()
+	cfg.Silences = []Silence{{DeliveryService: "ds2", Kind: string(KindDrop)}}
+	d = NewDetector(cfg)
+	now = warm(t, d, "ds2", 10000)
+	assert.Empty(t, d.Observe([]Observation{kbps("ds2", 0, now)}))
+
+	cfg.Silences = []Silence{{DeliveryService: "ds2", Until: time.Now().Add(-time.Minute)}}
+	d.SetConfig(cfg)
+	assert.Equal(t, len(d.Observe([]Observation{kbps("ds2", 0, now)})), 1) // expired silence, baselines kept
+}
+
+func TestDetectorPrunesLastSent(t *testing.T) {
+	d := NewDetector(testConfig())
+	alert := Alert{Kind: KindDrop, DeliveryService: "ds1", CacheGroup: "total", Stat: StatKbps}
+	assert.Equal(t, len(d.filter([]Alert{alert}, testStart)), 1)
+
+	dedup := time.Duration(d.Config().DedupIntervalSeconds) * time.Second
+	other := alert
+	other.DeliveryService = "ds2"
+	assert.Equal(t, len(d.filter([]Alert{other}, testStart.Add(dedup))), 1)
+	assert.Equal(t, len(d.lastSent), 1)
+	_, ok := d.lastSent[other.Key()]
+	assert.Equal(t, ok, true)
+
+	// the pruned alert is sent again
+	assert.Equal(t, len(d.filter([]Alert{alert}, testStart.Add(dedup))), 1)
+}
+
+func TestDetectorDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.Enabled = false
+	d := NewDetector(cfg)
+	assert.Empty(t, d.Observe([]Observation{kbps("ds1", 0, testStart)}))
+}
@@ -0,0 +1,215 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package alerting
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Notifier sends an alert somewhere.
+type Notifier interface {
+	Notify(a Alert) error
+	String() string
+}
+
+// NewNotifiers returns a notifier for every target in the config.
+func NewNotifiers(cfg Config) []Notifier {
+	cfg = cfg.WithDefaults()
+	notifiers := []Notifier{}
+	timeout := time.Duration(cfg.NotifyTimeoutSeconds) * time.Second
+	client := &http.Client{Timeout: timeout}
+	for _, url := range cfg.Webhooks {
+		notifiers = append(notifiers, &WebhookNotifier{URL: url, Client: client})
+	}
+	if cfg.Email != nil && len(cfg.Email.To) > 0 {
+		notifiers = append(notifiers, &EmailNotifier{Config: *cfg.Email, Timeout: timeout})
+	}
+	if cfg.Syslog != nil {
+		notifiers = append(notifiers, &SyslogNotifier{Tag: cfg.Syslog.Tag})
+	}
+	return notifiers
+}
+
+// Notify sends the alert to every notifier, and returns the errors of those which failed.
+func Notify(notifiers []Notifier, a Alert) []error {
+	errs := []error{}
+	for _, n := range notifiers {
+		if err := n.Notify(a); err != nil {
+			errs = append(errs, fmt.Errorf("sending alert to %s: %v", n, err))
+		}
+	}
+	return errs
+}
+
+// Dispatcher sends alerts to notifiers from its own goroutine, so the caller never waits on a notifier. Alerts wait in a bounded queue, and are dropped if it's full.
+type Dispatcher struct {
+	queue   chan dispatch
+	onError func(error)
+}
+
+type dispatch struct {
+	notifiers []Notifier
+	alert     Alert
+}
+
+// NewDispatcher starts a dispatcher whose queue holds queueSize alerts. Each notifier error is passed to onError, from the dispatcher's goroutine.
+func NewDispatcher(queueSize int, onError func(error)) *Dispatcher {
+	d := &Dispatcher{queue: make(chan dispatch, queueSize), onError: onError}
+	go d.run()
+	return d
+}
+
+func (d *Dispatcher) run() {
+	for item := range d.queue {
+		for _, err := range Notify(item.notifiers, item.alert) {
+			d.onError(err)
+		}
+	}
+}
+
+// Send queues the alert to be sent to the notifiers, and returns false if the queue is full and the alert was dropped.
+func (d *Dispatcher) Send(notifiers []Notifier, a Alert) bool {
+	select {
+	case d.queue <- dispatch{notifiers: notifiers, alert: a}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops the dispatcher after the queued alerts are sent. Send must not be called after Close.
+func (d *Dispatcher) Close() {
+	close(d.queue)
+}
+
+// WebhookNotifier POSTs the alert as JSON.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func (n *WebhookNotifier) String() string { return "webhook " + n.URL }
+
+func (n *WebhookNotifier) Notify(a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("got status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier mails the alert through an SMTP relay. The whole SMTP session, including the dial, must finish within Timeout, if it isn't zero.
+type EmailNotifier struct {
+	Config  EmailConfig
+	Timeout time.Duration
+}
+
+func (n *EmailNotifier) String() string { return "email relay " + n.Config.Relay }
+
+// Notify sends the mail like smtp.SendMail, which has no timeout.
+func (n *EmailNotifier) Notify(a Alert) error {
+	conn, err := net.DialTimeout("tcp", n.Config.Relay, n.Timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if n.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(n.Timeout)); err != nil {
+			return err
+		}
+	}
+
+	host, _, err := net.SplitHostPort(n.Config.Relay)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.Config.From); err != nil {
+		return err
+	}
+	for _, to := range n.Config.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(emailMessage(n.Config, a)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func emailMessage(cfg EmailConfig, a Alert) []byte {
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(msg, "Subject: %s %s %s %s\r\n", cfg.SubjectPrefix, a.Kind, a.DeliveryService, a.CacheGroup)
+	fmt.Fprintf(msg, "Date: %s\r\n", a.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "\r\n%s\r\n", a)
+	return msg.Bytes()
+}
+
+// SyslogNotifier writes the alert to the local syslog as a warning.
+type SyslogNotifier struct {
+	Tag string
+}
+
+func (n *SyslogNotifier) String() string { return "syslog" }
+
+func (n *SyslogNotifier) Notify(a Alert) error {
+	w, err := syslog.New(syslog.LOG_WARNING|syslog.LOG_DAEMON, n.Tag)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	return w.Warning(a.String())
+}
@@ -0,0 +1,125 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package alerting
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/traffic_stats/assert"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	received := Alert{}
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	notifiers := NewNotifiers(Config{Webhooks: []string{ok.URL, failing.URL}})
+	assert.Equal(t, len(notifiers), 2)
+
+	alert := Alert{Kind: KindDrop, DeliveryService: "ds1", CacheGroup: "total", Stat: StatKbps, Value: 1, Baseline: 100, Time: testStart}
+	errs := Notify(notifiers, alert)
+	assert.Equal(t, len(errs), 1)
+	assert.Equal(t, received, alert)
+}
+
+func TestNewNotifiers(t *testing.T) {
+	assert.Empty(t, NewNotifiers(Config{}))
+	assert.Empty(t, NewNotifiers(Config{Email: &EmailConfig{From: "ts@example.net"}})) // no recipients
+
+	notifiers := NewNotifiers(Config{Email: &EmailConfig{To: []string{"noc@example.net"}}, Syslog: &SyslogConfig{}})
+	assert.Equal(t, len(notifiers), 2)
+	assert.Equal(t, notifiers[0].(*EmailNotifier).Config.Relay, defaultEmailRelay)
+	assert.Equal(t, notifiers[1].(*SyslogNotifier).Tag, defaultSyslogTag)
+}
+
+func TestEmailMessage(t *testing.T) {
+	cfg := EmailConfig{From: "ts@example.net", To: []string{"a@example.net", "b@example.net"}, SubjectPrefix: "[ts]"}
+	alert := Alert{Kind: KindSpike, DeliveryService: "ds1", CacheGroup: "cg1", Stat: StatKbps, Value: 300, Baseline: 100, Time: testStart}
+	msg := string(emailMessage(cfg, alert))
+	assert.Equal(t, strings.Contains(msg, "To: a@example.net, b@example.net\r\n"), true)
+	assert.Equal(t, strings.Contains(msg, "Subject: [ts] spike ds1 cg1\r\n"), true)
+	assert.Equal(t, strings.HasSuffix(msg, alert.String()+"\r\n"), true)
+}
+
+// blockingNotifier blocks each Notify until release is closed.
+type blockingNotifier struct {
+	release chan struct{}
+	sent    chan Alert
+}
+
+func (n *blockingNotifier) String() string { return "blocking" }
+
+func (n *blockingNotifier) Notify(a Alert) error {
+	<-n.release
+	n.sent <- a
+	return errors.New("failed")
+}
+
+func TestDispatcher(t *testing.T) {
+	n := &blockingNotifier{release: make(chan struct{}), sent: make(chan Alert, 3)}
+	errs := make(chan error, 3)
+	d := NewDispatcher(1, func(err error) { errs <- err })
+
+	alert := Alert{Kind: KindDrop, DeliveryService: "ds1", CacheGroup: "total", Stat: StatKbps, Time: testStart}
+	assert.Equal(t, d.Send([]Notifier{n}, alert), true) // taken by the dispatcher, which blocks in Notify
+	deadline := time.Now().Add(time.Second)
+	for !d.Send([]Notifier{n}, alert) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond) // until the first alert leaves the queue
+	}
+	assert.Equal(t, d.Send([]Notifier{n}, alert), false) // the queue is full
+
+	close(n.release)
+	d.Close()
+	assert.Equal(t, <-n.sent, alert)
+	assert.Equal(t, <-n.sent, alert)
+	assert.NotNil(t, <-errs)
+}
+
+func TestEmailNotifierTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	go func() {
+		// accept, but never send the SMTP greeting
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	n := &EmailNotifier{Config: EmailConfig{Relay: l.Addr().String(), To: []string{"noc@example.net"}}, Timeout: 50 * time.Millisecond}
+	start := time.Now()
+	assert.NotNil(t, n.Notify(Alert{Time: testStart}))
+	assert.Equal(t, time.Since(start) < 500*time.Millisecond, true)
+}
@@ -92,6 +92,8 @@ godir=src/github.com/apache/incubator-trafficcontrol/traffic_stats/influxdb_tool
   cp -r "$TC_DIR"/traffic_stats/influxdb_tools/* . && \
   go build sync/sync_ts_databases.go
   go build create/create_ts_databases.go
+  go build report/usage_report.go
+  go build schema/ts_schema.go
 ) || { echo "Could not build go program at $(pwd): $!"; exit 1; }
 
 %install
@@ -110,11 +112,14 @@ src=src/github.com/apache/incubator-trafficcontrol/traffic_stats
 cp -p bin/traffic_stats     "${RPM_BUILD_ROOT}"/opt/traffic_stats/bin/traffic_stats
 cp "$src"/traffic_stats.cfg        "${RPM_BUILD_ROOT}"/opt/traffic_stats/conf/traffic_stats.cfg
 cp "$src"/traffic_stats_seelog.xml "${RPM_BUILD_ROOT}"/opt/traffic_stats/conf/traffic_stats_seelog.xml
+cp "$src"/influxdb_schema.json     "${RPM_BUILD_ROOT}"/opt/traffic_stats/conf/influxdb_schema.json
 cp "$src"/traffic_stats.init       "${RPM_BUILD_ROOT}"/etc/init.d/traffic_stats
 cp "$src"/traffic_stats.logrotate  "${RPM_BUILD_ROOT}"/etc/logrotate.d/traffic_stats
 cp "$src"/grafana/*.js             "${RPM_BUILD_ROOT}"/usr/share/grafana/public/dashboards/
 cp "$src"/influxdb_tools/sync_ts_databases	"${RPM_BUILD_ROOT}"/opt/traffic_stats/influxdb_tools/
 cp "$src"/influxdb_tools/create_ts_databases	"${RPM_BUILD_ROOT}"/opt/traffic_stats/influxdb_tools/
+cp "$src"/influxdb_tools/usage_report	"${RPM_BUILD_ROOT}"/opt/traffic_stats/influxdb_tools/
+cp "$src"/influxdb_tools/ts_schema	"${RPM_BUILD_ROOT}"/opt/traffic_stats/influxdb_tools/
 
 
 %pre
@@ -158,6 +163,7 @@ fi
 
 %config(noreplace) /opt/traffic_stats/conf/traffic_stats.cfg
 %config(noreplace) /opt/traffic_stats/conf/traffic_stats_seelog.xml
+%config(noreplace) /opt/traffic_stats/conf/influxdb_schema.json
 %config(noreplace) /etc/logrotate.d/traffic_stats
 
 %dir /opt/traffic_stats
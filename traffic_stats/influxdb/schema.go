@@ -0,0 +1,402 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package influxdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	influx "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+)
+
+// Schema is the declarative definition of the databases, retention policies and continuous queries Traffic Stats needs.
+type Schema struct {
+	// Replication is the replication of every retention policy which doesn't set its own.
+	Replication int              `json:"replication"`
+	Databases   []DatabaseSchema `json:"databases"`
+}
+
+// DatabaseSchema is a single database, and the retention policies and continuous queries on it.
+type DatabaseSchema struct {
+	Name              string            `json:"name"`
+	RetentionPolicies []RetentionPolicy `json:"retentionPolicies"`
+	ContinuousQueries []ContinuousQuery `json:"continuousQueries"`
+}
+
+// RetentionPolicy is a retention policy. Duration is an influxql duration, e.g. 26h, 30d or INF.
+type RetentionPolicy struct {
+	Name        string `json:"name"`
+	Duration    string `json:"duration"`
+	Replication int    `json:"replication,omitempty"`
+	Default     bool   `json:"default,omitempty"`
+}
+
+// ContinuousQuery is a continuous query. Query is the complete CREATE CONTINUOUS QUERY statement.
+type ContinuousQuery struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// Change is a single difference between a schema and a live instance, and the statement which fixes it. Changes with an empty Command are drift which is only reported, such as objects the schema doesn't know about.
+type Change struct {
+	Description string
+	Command     string
+}
+
+// LoadSchema reads and validates a JSON schema file.
+func LoadSchema(path string) (Schema, error) {
+	schema := Schema{}
+	bts, err := ioutil.ReadFile(path)
+	if err != nil {
+		return schema, errors.Wrap(err, "reading schema")
+	}
+	if err := json.Unmarshal(bts, &schema); err != nil {
+		return schema, errors.Wrapf(err, "parsing schema %s", path)
+	}
+	return schema, schema.Validate()
+}
+
+// Validate returns an error if the schema is missing names, has unparseable durations, or doesn't have exactly one default retention policy per database.
+func (s Schema) Validate() error {
+	for _, db := range s.Databases {
+		if db.Name == "" {
+			return errors.New("database with no name")
+		}
+		defaults := 0
+		for _, rp := range db.RetentionPolicies {
+			if rp.Name == "" {
+				return fmt.Errorf("database %s: retention policy with no name", db.Name)
+			}
+			if _, err := ParseDuration(rp.Duration); err != nil {
+				return fmt.Errorf("database %s: retention policy %s: %v", db.Name, rp.Name, err)
+			}
+			if rp.Default {
+				defaults++
+			}
+		}
+		if defaults != 1 {
+			return fmt.Errorf("database %s: must have exactly one default retention policy, has %d", db.Name, defaults)
+		}
+		for _, cq := range db.ContinuousQueries {
+			if cq.Name == "" || cq.Query == "" {
+				return fmt.Errorf("database %s: continuous query must have a name and a query", db.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func (s Schema) replication(rp RetentionPolicy) int {
+	if rp.Replication > 0 {
+		return rp.Replication
+	}
+	if s.Replication > 0 {
+		return s.Replication
+	}
+	return 1
+}
+
+// CurrentSchema reads the databases, retention policies and continuous queries of a live instance.
+func CurrentSchema(client influx.Client) (Schema, error) {
+	schema := Schema{}
+	res, err := QueryDB(client, "SHOW DATABASES", "")
+	if err != nil {
+		return schema, err
+	}
+	dbs := map[string]*DatabaseSchema{}
+	for _, row := range seriesRows(res) {
+		for _, record := range row.Values {
+			name, _ := record[0].(string)
+			if name == "" || name == "_internal" {
+				continue
+			}
+			schema.Databases = append(schema.Databases, DatabaseSchema{Name: name})
+		}
+	}
+	for i := range schema.Databases {
+		dbs[schema.Databases[i].Name] = &schema.Databases[i]
+	}
+
+	for _, db := range schema.Databases {
+		res, err := QueryDB(client, fmt.Sprintf(`SHOW RETENTION POLICIES ON "%s"`, db.Name), db.Name)
+		if err != nil {
+			return schema, err
+		}
+		for _, row := range seriesRows(res) {
+			cols := columnIndexes(row.Columns)
+			for _, record := range row.Values {
+				rp := RetentionPolicy{}
+				rp.Name, _ = record[cols["name"]].(string)
+				rp.Duration, _ = record[cols["duration"]].(string)
+				rp.Default, _ = record[cols["default"]].(bool)
+				if replicaN, ok := record[cols["replicaN"]].(json.Number); ok {
+					n, _ := replicaN.Int64()
+					rp.Replication = int(n)
+				}
+				dbs[db.Name].RetentionPolicies = append(dbs[db.Name].RetentionPolicies, rp)
+			}
+		}
+	}
+
+	res, err = QueryDB(client, "SHOW CONTINUOUS QUERIES", "")
+	if err != nil {
+		return schema, err
+	}
+	for _, row := range seriesRows(res) {
+		db, ok := dbs[row.Name]
+		if !ok {
+			continue
+		}
+		cols := columnIndexes(row.Columns)
+		for _, record := range row.Values {
+			cq := ContinuousQuery{}
+			cq.Name, _ = record[cols["name"]].(string)
+			cq.Query, _ = record[cols["query"]].(string)
+			db.ContinuousQueries = append(db.ContinuousQueries, cq)
+		}
+	}
+	return schema, nil
+}
+
+// Plan returns the changes needed to make current match desired. Databases are never dropped. Retention policies and continuous queries which aren't in desired are only dropped if prune is true, and otherwise are reported as drift with no command.
+func Plan(desired Schema, current Schema, prune bool) []Change {
+	changes := []Change{}
+	currentDBs := map[string]DatabaseSchema{}
+	for _, db := range current.Databases {
+		currentDBs[db.Name] = db
+	}
+
+	for _, db := range desired.Databases {
+		cur, exists := currentDBs[db.Name]
+		if !exists {
+			// creating the database with its default retention policy keeps InfluxDB from creating an autogen policy
+			change := Change{
+				Description: fmt.Sprintf("create database %s", db.Name),
+				Command:     fmt.Sprintf(`CREATE DATABASE "%s"`, db.Name),
+			}
+			for _, rp := range db.RetentionPolicies {
+				if rp.Default {
+					rp.Replication = desired.replication(rp)
+					change.Description += fmt.Sprintf(" with default retention policy %s", rp.Name)
+					change.Command += fmt.Sprintf(` WITH DURATION %s REPLICATION %d NAME "%s"`, rp.Duration, rp.Replication, rp.Name)
+					cur.RetentionPolicies = []RetentionPolicy{rp}
+				}
+			}
+			changes = append(changes, change)
+		}
+		changes = append(changes, planRetentionPolicies(desired, db, cur, prune)...)
+		changes = append(changes, planContinuousQueries(db, cur, prune)...)
+	}
+
+	desiredDBs := map[string]struct{}{}
+	for _, db := range desired.Databases {
+		desiredDBs[db.Name] = struct{}{}
+	}
+	for _, db := range current.Databases {
+		if _, ok := desiredDBs[db.Name]; !ok {
+			changes = append(changes, Change{Description: fmt.Sprintf("database %s is not in the schema", db.Name)})
+		}
+	}
+	return changes
+}
+
+func planRetentionPolicies(desired Schema, db DatabaseSchema, cur DatabaseSchema, prune bool) []Change {
+	changes := []Change{}
+	currentRPs := map[string]RetentionPolicy{}
+	for _, rp := range cur.RetentionPolicies {
+		currentRPs[rp.Name] = rp
+	}
+	desiredRPs := map[string]struct{}{}
+	for _, rp := range db.RetentionPolicies {
+		desiredRPs[rp.Name] = struct{}{}
+		replication := desired.replication(rp)
+		spec := fmt.Sprintf("DURATION %s REPLICATION %d", rp.Duration, replication)
+		if rp.Default {
+			spec += " DEFAULT"
+		}
+		curRP, exists := currentRPs[rp.Name]
+		if !exists {
+			changes = append(changes, Change{
+				Description: fmt.Sprintf("create retention policy %s on %s", rp.Name, db.Name),
+				Command:     fmt.Sprintf(`CREATE RETENTION POLICY "%s" ON "%s" %s`, rp.Name, db.Name, spec),
+			})
+			continue
+		}
+		diffs := []string{}
+		if !sameDuration(rp.Duration, curRP.Duration) {
+			diffs = append(diffs, fmt.Sprintf("duration %s -> %s", curRP.Duration, rp.Duration))
+		}
+		if replication != curRP.Replication {
+			diffs = append(diffs, fmt.Sprintf("replication %d -> %d", curRP.Replication, replication))
+		}
+		if rp.Default && !curRP.Default {
+			diffs = append(diffs, "make default")
+		}
+		if len(diffs) > 0 {
+			changes = append(changes, Change{
+				Description: fmt.Sprintf("alter retention policy %s on %s: %s", rp.Name, db.Name, strings.Join(diffs, ", ")),
+				Command:     fmt.Sprintf(`ALTER RETENTION POLICY "%s" ON "%s" %s`, rp.Name, db.Name, spec),
+			})
+		}
+	}
+	for _, rp := range cur.RetentionPolicies {
+		if _, ok := desiredRPs[rp.Name]; ok {
+			continue
+		}
+		change := Change{Description: fmt.Sprintf("retention policy %s on %s is not in the schema", rp.Name, db.Name)}
+		if prune {
+			change.Description = fmt.Sprintf("drop retention policy %s on %s", rp.Name, db.Name)
+			change.Command = fmt.Sprintf(`DROP RETENTION POLICY "%s" ON "%s"`, rp.Name, db.Name)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func planContinuousQueries(db DatabaseSchema, cur DatabaseSchema, prune bool) []Change {
+	changes := []Change{}
+	currentCQs := map[string]ContinuousQuery{}
+	for _, cq := range cur.ContinuousQueries {
+		currentCQs[cq.Name] = cq
+	}
+	desiredCQs := map[string]struct{}{}
+	for _, cq := range db.ContinuousQueries {
+		desiredCQs[cq.Name] = struct{}{}
+		curCQ, exists := currentCQs[cq.Name]
+		if !exists {
+			changes = append(changes, Change{
+				Description: fmt.Sprintf("create continuous query %s on %s", cq.Name, db.Name),
+				Command:     cq.Query,
+			})
+			continue
+		}
+		if NormalizeQuery(cq.Query) == NormalizeQuery(curCQ.Query) {
+			continue
+		}
+		// continuous queries can't be altered, only dropped and recreated
+		changes = append(changes, Change{
+			Description: fmt.Sprintf("recreate continuous query %s on %s: query changed", cq.Name, db.Name),
+			Command:     fmt.Sprintf(`DROP CONTINUOUS QUERY "%s" ON "%s"; %s`, cq.Name, db.Name, cq.Query),
+		})
+	}
+	for _, cq := range cur.ContinuousQueries {
+		if _, ok := desiredCQs[cq.Name]; ok {
+			continue
+		}
+		change := Change{Description: fmt.Sprintf("continuous query %s on %s is not in the schema", cq.Name, db.Name)}
+		if prune {
+			change.Description = fmt.Sprintf("drop continuous query %s on %s", cq.Name, db.Name)
+			change.Command = fmt.Sprintf(`DROP CONTINUOUS QUERY "%s" ON "%s"`, cq.Name, db.Name)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// Apply runs the command of every change, in order, stopping at the first error.
+func Apply(client influx.Client, changes []Change) error {
+	for _, change := range changes {
+		if change.Command == "" {
+			continue
+		}
+		if err := Create(client, change.Command); err != nil {
+			return errors.Wrapf(err, "applying '%s'", change.Description)
+		}
+	}
+	return nil
+}
+
+var whitespaceRegexp = regexp.MustCompile(`\s+`)
+
+// NormalizeQuery returns the query with identifier quoting, letter case and whitespace removed, so a query from the schema can be compared with the rewritten query InfluxDB returns from SHOW CONTINUOUS QUERIES.
+func NormalizeQuery(q string) string {
+	q = strings.Replace(q, `"`, "", -1)
+	q = whitespaceRegexp.ReplaceAllString(q, " ")
+	return strings.ToLower(strings.TrimSpace(q))
+}
+
+func sameDuration(a, b string) bool {
+	da, errA := ParseDuration(a)
+	db, errB := ParseDuration(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return da == db
+}
+
+var durationRegexp = regexp.MustCompile(`^(\d+)(ns|u|µ|ms|s|m|h|d|w)`)
+
+// ParseDuration parses an influxql duration such as 30d or INF, or a duration as InfluxDB reports it such as 720h0m0s. Infinite durations are returned as 0, which is how InfluxDB reports them.
+func ParseDuration(s string) (time.Duration, error) {
+	if strings.ToUpper(s) == "INF" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, errors.New("empty duration")
+	}
+	units := map[string]time.Duration{
+		"ns": time.Nanosecond,
+		"u":  time.Microsecond,
+		"µ":  time.Microsecond,
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+	}
+	d := time.Duration(0)
+	for rest := s; rest != ""; {
+		match := durationRegexp.FindStringSubmatch(rest)
+		if match == nil {
+			return 0, fmt.Errorf("invalid duration '%s'", s)
+		}
+		n, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s': %v", s, err)
+		}
+		d += time.Duration(n) * units[match[2]]
+		rest = rest[len(match[0]):]
+	}
+	return d, nil
+}
+
+func seriesRows(res []influx.Result) []models.Row {
+	rows := []models.Row{}
+	for _, r := range res {
+		rows = append(rows, r.Series...)
+	}
+	return rows
+}
+
+func columnIndexes(cols []string) map[string]int {
+	idx := map[string]int{}
+	for i, col := range cols {
+		idx[col] = i
+	}
+	return idx
+}
@@ -0,0 +1,200 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package influxdb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/traffic_stats/assert"
+	influx "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"INF":      0,
+		"26h":      26 * time.Hour,
+		"30d":      30 * 24 * time.Hour,
+		"720h0m0s": 30 * 24 * time.Hour,
+		"1w2d":     9 * 24 * time.Hour,
+		"90m":      90 * time.Minute,
+		"500ms":    500 * time.Millisecond,
+		"0s":       0,
+	}
+	for s, expected := range tests {
+		d, err := ParseDuration(s)
+		assert.Nil(t, err)
+		assert.Equal(t, d, expected)
+	}
+	for _, s := range []string{"", "30", "30y", "d30"} {
+		_, err := ParseDuration(s)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	ours := `CREATE CONTINUOUS QUERY bandwidth_1min ON cache_stats RESAMPLE FOR 2m BEGIN SELECT mean(value) AS "value" INTO "cache_stats"."monthly"."bandwidth.1min" FROM "cache_stats"."daily".bandwidth GROUP BY time(1m), * END`
+	influxs := `CREATE CONTINUOUS QUERY bandwidth_1min ON cache_stats RESAMPLE FOR 2m BEGIN SELECT mean(value) AS value INTO cache_stats.monthly."bandwidth.1min" FROM cache_stats.daily.bandwidth GROUP BY time(1m), *  END`
+	assert.Equal(t, NormalizeQuery(ours), NormalizeQuery(influxs))
+	assert.Equal(t, NormalizeQuery(ours) == NormalizeQuery(strings.Replace(ours, "2m", "5m", 1)), false)
+}
+
+func testSchema() Schema {
+	return Schema{
+		Replication: 3,
+		Databases: []DatabaseSchema{
+			{
+				Name: "cache_stats",
+				RetentionPolicies: []RetentionPolicy{
+					{Name: "daily", Duration: "26h", Default: true},
+					{Name: "monthly", Duration: "30d"},
+				},
+				ContinuousQueries: []ContinuousQuery{
+					{Name: "cq1", Query: `CREATE CONTINUOUS QUERY cq1 ON cache_stats BEGIN SELECT mean(value) AS "value" INTO "cache_stats"."monthly"."a.1min" FROM "cache_stats"."daily".a GROUP BY time(1m), * END`},
+				},
+			},
+		},
+	}
+}
+
+func TestPlanCreatesMissingDatabase(t *testing.T) {
+	changes := Plan(testSchema(), Schema{}, false)
+	assert.Equal(t, len(changes), 3)
+	assert.Equal(t, changes[0].Command, `CREATE DATABASE "cache_stats" WITH DURATION 26h REPLICATION 3 NAME "daily"`)
+	assert.Equal(t, changes[1].Command, `CREATE RETENTION POLICY "monthly" ON "cache_stats" DURATION 30d REPLICATION 3`)
+	assert.Equal(t, strings.HasPrefix(changes[2].Command, "CREATE CONTINUOUS QUERY cq1"), true)
+}
+
+func TestPlanNoChanges(t *testing.T) {
+	current := Schema{
+		Databases: []DatabaseSchema{
+			{
+				Name: "cache_stats",
+				RetentionPolicies: []RetentionPolicy{
+					{Name: "daily", Duration: "26h0m0s", Replication: 3, Default: true},
+					{Name: "monthly", Duration: "720h0m0s", Replication: 3},
+				},
+				ContinuousQueries: []ContinuousQuery{
+					{Name: "cq1", Query: `CREATE CONTINUOUS QUERY cq1 ON cache_stats BEGIN SELECT mean(value) AS value INTO cache_stats.monthly."a.1min" FROM cache_stats.daily.a GROUP BY time(1m), * END`},
+				},
+			},
+		},
+	}
+	assert.Empty(t, Plan(testSchema(), current, false))
+}
+
+func TestPlanDrift(t *testing.T) {
+	current := Schema{
+		Databases: []DatabaseSchema{
+			{
+				Name: "cache_stats",
+				RetentionPolicies: []RetentionPolicy{
+					{Name: "daily", Duration: "24h0m0s", Replication: 3, Default: true},
+					{Name: "monthly", Duration: "720h0m0s", Replication: 1},
+					{Name: "autogen", Duration: "0s", Replication: 1},
+				},
+				ContinuousQueries: []ContinuousQuery{
+					{Name: "cq1", Query: `CREATE CONTINUOUS QUERY cq1 ON cache_stats BEGIN SELECT max(value) AS value INTO cache_stats.monthly."a.1min" FROM cache_stats.daily.a GROUP BY time(1m), * END`},
+					{Name: "old", Query: `CREATE CONTINUOUS QUERY old ON cache_stats BEGIN SELECT 1 END`},
+				},
+			},
+			{Name: "other"},
+		},
+	}
+
+	changes := Plan(testSchema(), current, false)
+	commands := []string{}
+	reported := 0
+	for _, change := range changes {
+		if change.Command == "" {
+			reported++
+			continue
+		}
+		commands = append(commands, change.Command)
+	}
+	assert.Equal(t, reported, 3) // autogen, old and other are only reported
+	assert.Equal(t, len(commands), 3)
+	assert.Equal(t, commands[0], `ALTER RETENTION POLICY "daily" ON "cache_stats" DURATION 26h REPLICATION 3 DEFAULT`)
+	assert.Equal(t, commands[1], `ALTER RETENTION POLICY "monthly" ON "cache_stats" DURATION 30d REPLICATION 3`)
+	assert.Equal(t, strings.HasPrefix(commands[2], `DROP CONTINUOUS QUERY "cq1" ON "cache_stats"; CREATE CONTINUOUS QUERY cq1`), true)
+
+	pruned := 0
+	for _, change := range Plan(testSchema(), current, true) {
+		if strings.HasPrefix(change.Command, "DROP RETENTION POLICY") || change.Command == `DROP CONTINUOUS QUERY "old" ON "cache_stats"` {
+			pruned++
+		}
+	}
+	assert.Equal(t, pruned, 2)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, testSchema().Validate())
+
+	noDefault := testSchema()
+	noDefault.Databases[0].RetentionPolicies[0].Default = false
+	assert.NotNil(t, noDefault.Validate())
+
+	badDuration := testSchema()
+	badDuration.Databases[0].RetentionPolicies[1].Duration = "a month"
+	assert.NotNil(t, badDuration.Validate())
+}
+
+func TestShippedSchema(t *testing.T) {
+	schema, err := LoadSchema("../influxdb_schema.json")
+	assert.Nil(t, err)
+	assert.Equal(t, len(schema.Databases), 3)
+}
+
+// fakeInflux answers the SHOW queries CurrentSchema makes.
+type fakeInflux struct {
+	responses map[string][]models.Row
+}
+
+func (f *fakeInflux) Ping(timeout time.Duration) (time.Duration, string, error) { return 0, "", nil }
+func (f *fakeInflux) Write(bp influx.BatchPoints) error                         { return nil }
+func (f *fakeInflux) Close() error                                              { return nil }
+func (f *fakeInflux) Query(q influx.Query) (*influx.Response, error) {
+	return &influx.Response{Results: []influx.Result{{Series: f.responses[q.Command]}}}, nil
+}
+
+func TestCurrentSchema(t *testing.T) {
+	client := &fakeInflux{responses: map[string][]models.Row{
+		"SHOW DATABASES": {{Name: "databases", Columns: []string{"name"}, Values: [][]interface{}{{"_internal"}, {"cache_stats"}}}},
+		`SHOW RETENTION POLICIES ON "cache_stats"`: {{
+			Columns: []string{"name", "duration", "shardGroupDuration", "replicaN", "default"},
+			Values:  [][]interface{}{{"daily", "26h0m0s", "1h0m0s", json.Number("3"), true}},
+		}},
+		"SHOW CONTINUOUS QUERIES": {
+			{Name: "_internal", Columns: []string{"name", "query"}},
+			{Name: "cache_stats", Columns: []string{"name", "query"}, Values: [][]interface{}{{"cq1", "CREATE CONTINUOUS QUERY cq1 ON cache_stats BEGIN SELECT 1 END"}}},
+		},
+	}}
+	schema, err := CurrentSchema(client)
+	assert.Nil(t, err)
+	assert.Equal(t, len(schema.Databases), 1)
+	db := schema.Databases[0]
+	assert.Equal(t, db.Name, "cache_stats")
+	assert.Equal(t, db.RetentionPolicies, []RetentionPolicy{{Name: "daily", Duration: "26h0m0s", Replication: 3, Default: true}})
+	assert.Equal(t, len(db.ContinuousQueries), 1)
+	assert.Equal(t, db.ContinuousQueries[0].Name, "cq1")
+}
@@ -0,0 +1,574 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+	"github.com/apache/incubator-trafficcontrol/traffic_stats/influxdb"
+	influx "github.com/influxdata/influxdb/client/v2"
+)
+
+const (
+	deliveryService = "deliveryservice_stats"
+	kbpsStat        = "kbps.ds.1min"
+	// dsUsageStat and tenantUsageStat hold the usage of each finished month, so it can still be reported once kbps.ds.1min has expired.
+	dsUsageStat     = "usage.ds.monthly"
+	tenantUsageStat = "usage.tenant.monthly"
+	userAgent       = "traffic-stats-report"
+	toTimeout       = time.Second * time.Duration(30)
+
+	// sampleSecs is the width of each kbps.ds.1min point.
+	sampleSecs = 60
+	// percentileBucketSecs is the width of the averaged samples the 95th percentile is taken over.
+	percentileBucketSecs = 300
+	monthFormat          = "2006-01"
+)
+
+// QueryWindow is a single time range queried from InfluxDB, recorded in the report so it can be reproduced.
+type QueryWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Query string    `json:"query"`
+}
+
+// Usage is the billing data for a single delivery service or tenant over the report month.
+type Usage struct {
+	Name        string  `json:"name"`
+	CDN         string  `json:"cdn,omitempty"`
+	Tenant      string  `json:"tenant,omitempty"`
+	BytesServed float64 `json:"bytesServed"`
+	P95Kbps     float64 `json:"p95Kbps"`
+	Samples     int     `json:"samples"`
+}
+
+// Report is the complete monthly usage report.
+type Report struct {
+	Month            string        `json:"month"`
+	Database         string        `json:"database"`
+	RetentionPolicy  string        `json:"retentionPolicy"`
+	Measurement      string        `json:"measurement"`
+	Windows          []QueryWindow `json:"windows"`
+	DeliveryServices []Usage       `json:"deliveryServices"`
+	Tenants          []Usage       `json:"tenants"`
+}
+
+// series maps the unix time of each 1 minute sample to its kbps value.
+type series map[int64]float64
+
+// dsKey identifies a delivery service series. The same xmlId may exist in more than one CDN.
+type dsKey struct {
+	cdn string
+	ds  string
+}
+
+func main() {
+	influxConfig := &influxdb.Config{}
+	influxConfig.Flags("")
+
+	var toURL, toUser, toPassword, month, format, output, rp, summaryRP string
+	var insecure, recompute bool
+	var window time.Duration
+	flag.StringVar(&toURL, "to-url", "https://localhost", "The Traffic Ops url")
+	flag.StringVar(&toUser, "to-user", "", "The Traffic Ops user")
+	flag.StringVar(&toPassword, "to-password", "", "The Traffic Ops password")
+	flag.BoolVar(&insecure, "insecure", false, "Skip Traffic Ops certificate verification")
+	flag.StringVar(&month, "month", lastMonth(time.Now()), "The month to report on, as YYYY-MM (default last month)")
+	flag.StringVar(&format, "format", "csv", "The output format, csv or json")
+	flag.StringVar(&output, "output", "", "The file to write the report to (default stdout)")
+	flag.StringVar(&rp, "retention-policy", "monthly", "The retention policy holding "+kbpsStat)
+	flag.StringVar(&summaryRP, "summary-retention-policy", "indefinite", "The retention policy the usage of each finished month is stored in, and reported from")
+	flag.BoolVar(&recompute, "recompute", false, "Compute the usage from "+kbpsStat+" and overwrite the stored usage of the month, even if it's stored")
+	flag.DurationVar(&window, "window", 24*time.Hour, "The time range of each InfluxDB query")
+	flag.Parse()
+
+	if format != "csv" && format != "json" {
+		fmt.Printf("Unknown format %s, must be csv or json\n", format)
+		os.Exit(1)
+	}
+
+	start, end, err := monthRange(month)
+	if err != nil {
+		fmt.Printf("Error parsing month: %v\n", err)
+		os.Exit(1)
+	}
+	windows, err := monthWindows(start, end, window, rp)
+	if err != nil {
+		fmt.Printf("Error computing query windows: %v\n", err)
+		os.Exit(1)
+	}
+
+	influxClient, err := influxConfig.NewHTTPClient()
+	if err != nil {
+		fmt.Printf("Error creating influx client: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := Report{}
+	stored := false
+	if !recompute {
+		report, stored, err = getStoredReport(influxClient, month, start, end, summaryRP)
+		if err != nil {
+			fmt.Printf("Error getting the stored usage: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if !stored {
+		report, err = computeReport(influxClient, toURL, toUser, toPassword, insecure, month, start, end, rp, windows)
+		if err != nil {
+			fmt.Printf("Error computing the usage: %v\n", err)
+			os.Exit(1)
+		}
+		if end.After(time.Now()) {
+			fmt.Fprintf(os.Stderr, "%s isn't over, so its usage isn't stored\n", month)
+		} else if err := storeReport(influxClient, report, start, summaryRP); err != nil {
+			fmt.Printf("Error storing the usage: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Printf("Error creating %s: %v\n", output, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == "json" {
+		err = writeJSON(w, report)
+	} else {
+		err = writeCSV(w, report)
+	}
+	if err != nil {
+		fmt.Printf("Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// lastMonth returns the month before the one of the given time, in UTC, as YYYY-MM.
+func lastMonth(now time.Time) string {
+	now = now.UTC()
+	// subtract from the first of the month, because AddDate normalizes e.g. March 31 minus a month to March 3
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format(monthFormat)
+}
+
+// computeReport computes the usage report of the month from the kbps.ds.1min samples in the given retention policy, and the delivery services and tenants in Traffic Ops.
+func computeReport(influxClient influx.Client, toURL string, toUser string, toPassword string, insecure bool, month string, start time.Time, end time.Time, rp string, windows []QueryWindow) (Report, error) {
+	to, _, err := client.LoginWithAgent(toURL, toUser, toPassword, insecure, userAgent, false, toTimeout)
+	if err != nil {
+		return Report{}, fmt.Errorf("logging in to %s: %v", toURL, err)
+	}
+	dses, _, err := to.GetDeliveryServices()
+	if err != nil {
+		return Report{}, fmt.Errorf("getting delivery services from %s: %v", toURL, err)
+	}
+	tenants, _, err := to.Tenants()
+	if err != nil {
+		return Report{}, fmt.Errorf("getting tenants from %s: %v", toURL, err)
+	}
+
+	dsSeries := map[dsKey]series{}
+	for _, w := range windows {
+		res, err := influxdb.QueryDB(influxClient, w.Query, deliveryService)
+		if err != nil {
+			return Report{}, fmt.Errorf("querying %s: %v", w.Query, err)
+		}
+		addKbpsResults(dsSeries, res)
+	}
+	return buildReport(month, start, end, rp, windows, dsSeries, dses, tenants), nil
+}
+
+// getStoredReport returns the usage report of the month stored by storeReport in the given retention policy, and whether there is one.
+func getStoredReport(influxClient influx.Client, month string, start time.Time, end time.Time, rp string) (Report, bool, error) {
+	report := Report{
+		Month:           month,
+		Database:        deliveryService,
+		RetentionPolicy: rp,
+		Measurement:     dsUsageStat,
+		Windows: []QueryWindow{
+			{Start: start, End: end, Query: storedUsageQuery(rp, dsUsageStat, start, "cdn, deliveryservice, tenant")},
+			{Start: start, End: end, Query: storedUsageQuery(rp, tenantUsageStat, start, "tenant")},
+		},
+	}
+	res, err := influxdb.QueryDB(influxClient, report.Windows[0].Query, deliveryService)
+	if err != nil {
+		return Report{}, false, fmt.Errorf("querying %s: %v", report.Windows[0].Query, err)
+	}
+	if report.DeliveryServices, err = parseStoredUsages(res, "deliveryservice"); err != nil {
+		return Report{}, false, err
+	}
+	res, err = influxdb.QueryDB(influxClient, report.Windows[1].Query, deliveryService)
+	if err != nil {
+		return Report{}, false, fmt.Errorf("querying %s: %v", report.Windows[1].Query, err)
+	}
+	if report.Tenants, err = parseStoredUsages(res, "tenant"); err != nil {
+		return Report{}, false, err
+	}
+	if len(report.DeliveryServices) == 0 && len(report.Tenants) == 0 {
+		return Report{}, false, nil
+	}
+	sort.Sort(UsageSlice(report.DeliveryServices))
+	sort.Sort(UsageSlice(report.Tenants))
+	return report, true, nil
+}
+
+func storedUsageQuery(rp string, measurement string, start time.Time, tags string) string {
+	return fmt.Sprintf(`select bytes_served, p95_kbps, samples from "%s"."%s" where time = '%s' group by %s`, rp, measurement, start.Format(time.RFC3339), tags)
+}
+
+// parseStoredUsages returns the usages in the results of a storedUsageQuery. The name of each usage is the value of nameTag. A tenant usage has no tenant of its own.
+func parseStoredUsages(res []influx.Result, nameTag string) ([]Usage, error) {
+	usages := []Usage{}
+	for _, result := range res {
+		for _, row := range result.Series {
+			cols := map[string]int{}
+			for i, col := range row.Columns {
+				cols[col] = i
+			}
+			for _, record := range row.Values {
+				usage := Usage{Name: row.Tags[nameTag], CDN: row.Tags["cdn"]}
+				if nameTag != "tenant" {
+					usage.Tenant = row.Tags["tenant"]
+				}
+				var err error
+				if usage.BytesServed, err = recordFloat(record, cols, "bytes_served"); err != nil {
+					return nil, err
+				}
+				if usage.P95Kbps, err = recordFloat(record, cols, "p95_kbps"); err != nil {
+					return nil, err
+				}
+				samples, err := recordFloat(record, cols, "samples")
+				if err != nil {
+					return nil, err
+				}
+				usage.Samples = int(samples)
+				usages = append(usages, usage)
+			}
+		}
+	}
+	return usages, nil
+}
+
+// recordFloat returns the numeric value of the given column of a query result record.
+func recordFloat(record []interface{}, cols map[string]int, col string) (float64, error) {
+	i, ok := cols[col]
+	if !ok || i >= len(record) {
+		return 0, fmt.Errorf("stored usage has no %s", col)
+	}
+	num, ok := record[i].(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("stored usage %s %v isn't a number", col, record[i])
+	}
+	return num.Float64()
+}
+
+// storeReport writes the usage of every delivery service and tenant in the report to the given retention policy, at the start of the month. Writing a month again overwrites its usage.
+func storeReport(influxClient influx.Client, report Report, start time.Time, rp string) error {
+	bps, err := usagePoints(report, start, rp)
+	if err != nil {
+		return err
+	}
+	return influxClient.Write(bps)
+}
+
+// usagePoints returns the points storeReport writes.
+func usagePoints(report Report, start time.Time, rp string) (influx.BatchPoints, error) {
+	bps, err := influx.NewBatchPoints(influx.BatchPointsConfig{
+		Database:        deliveryService,
+		Precision:       "s",
+		RetentionPolicy: rp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	add := func(measurement string, tags map[string]string, usage Usage) error {
+		for name, value := range tags {
+			if value == "" {
+				delete(tags, name) // InfluxDB doesn't store empty tags
+			}
+		}
+		fields := map[string]interface{}{
+			"bytes_served": usage.BytesServed,
+			"p95_kbps":     usage.P95Kbps,
+			"samples":      usage.Samples,
+		}
+		pt, err := influx.NewPoint(measurement, tags, fields, start)
+		if err != nil {
+			return err
+		}
+		bps.AddPoint(pt)
+		return nil
+	}
+	for _, usage := range report.DeliveryServices {
+		if err := add(dsUsageStat, map[string]string{"cdn": usage.CDN, "deliveryservice": usage.Name, "tenant": usage.Tenant}, usage); err != nil {
+			return nil, err
+		}
+	}
+	for _, usage := range report.Tenants {
+		if err := add(tenantUsageStat, map[string]string{"tenant": usage.Name}, usage); err != nil {
+			return nil, err
+		}
+	}
+	return bps, nil
+}
+
+// monthRange returns the start and end of the given YYYY-MM month, in UTC.
+func monthRange(month string) (time.Time, time.Time, error) {
+	start, err := time.Parse(monthFormat, month)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("parsing month '%s': %v", month, err)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// monthWindows splits the time from start to end into consecutive query windows no longer than the given duration.
+func monthWindows(start time.Time, end time.Time, window time.Duration, rp string) ([]QueryWindow, error) {
+	if window < time.Duration(sampleSecs)*time.Second {
+		return nil, errors.New("window must be at least one minute")
+	}
+	windows := []QueryWindow{}
+	for wStart := start; wStart.Before(end); wStart = wStart.Add(window) {
+		wEnd := wStart.Add(window)
+		if wEnd.After(end) {
+			wEnd = end
+		}
+		windows = append(windows, QueryWindow{
+			Start: wStart,
+			End:   wEnd,
+			Query: fmt.Sprintf(`select mean(value) from "%s"."%s" where time >= '%s' and time < '%s' group by time(1m), cdn, deliveryservice`, rp, kbpsStat, wStart.Format(time.RFC3339), wEnd.Format(time.RFC3339)),
+		})
+	}
+	return windows, nil
+}
+
+// addKbpsResults adds the per-minute kbps values in the given query results to the series map.
+func addKbpsResults(dsSeries map[dsKey]series, res []influx.Result) {
+	for _, result := range res {
+		for _, row := range result.Series {
+			key := dsKey{cdn: row.Tags["cdn"], ds: row.Tags["deliveryservice"]}
+			if key.ds == "" {
+				continue
+			}
+			if dsSeries[key] == nil {
+				dsSeries[key] = series{}
+			}
+			for _, record := range row.Values {
+				if record[1] == nil {
+					continue // no data for this minute
+				}
+				t, err := time.Parse(time.RFC3339, record[0].(string))
+				if err != nil {
+					fmt.Printf("Couldn't parse time from record %v\n", record)
+					continue
+				}
+				value, err := record[1].(json.Number).Float64()
+				if err != nil {
+					fmt.Printf("Couldn't parse value from record %v\n", record)
+					continue
+				}
+				dsSeries[key][t.Unix()] = value
+			}
+		}
+	}
+}
+
+// buildReport computes the usage of every delivery service with data from start to end, and rolls it up the tenant hierarchy. A tenant's usage includes that of all its descendants; its 95th percentile is computed over the summed series, not by summing percentiles.
+func buildReport(month string, start time.Time, end time.Time, rp string, windows []QueryWindow, dsSeries map[dsKey]series, dses []tc.DeliveryService, tenants []tc.Tenant) Report {
+	tenantNames := map[int]string{}
+	for _, tenant := range tenants {
+		tenantNames[tenant.ID] = tenant.Name
+	}
+	dsTenants := map[dsKey]int{}
+	for _, ds := range dses {
+		dsTenants[dsKey{cdn: ds.CDNName, ds: ds.XMLID}] = ds.TenantID
+	}
+
+	report := Report{
+		Month:            month,
+		Database:         deliveryService,
+		RetentionPolicy:  rp,
+		Measurement:      kbpsStat,
+		Windows:          windows,
+		DeliveryServices: []Usage{},
+		Tenants:          []Usage{},
+	}
+
+	tenantSeries := map[int][]series{}
+	for key, s := range dsSeries {
+		usage := summarize(key.ds, s, start, end)
+		usage.CDN = key.cdn
+		tenantID := dsTenants[key]
+		usage.Tenant = tenantNames[tenantID]
+		report.DeliveryServices = append(report.DeliveryServices, usage)
+		if tenantID == 0 {
+			continue
+		}
+		for _, ancestor := range tenantAncestors(tenantID, tenants) {
+			tenantSeries[ancestor] = append(tenantSeries[ancestor], s)
+		}
+	}
+
+	for tenantID, ss := range tenantSeries {
+		report.Tenants = append(report.Tenants, summarize(tenantNames[tenantID], sumSeries(ss), start, end))
+	}
+
+	sort.Sort(UsageSlice(report.DeliveryServices))
+	sort.Sort(UsageSlice(report.Tenants))
+	return report
+}
+
+// UsageSlice sorts Usages by CDN and then name.
+type UsageSlice []Usage
+
+func (s UsageSlice) Len() int      { return len(s) }
+func (s UsageSlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s UsageSlice) Less(i, j int) bool {
+	if s[i].CDN != s[j].CDN {
+		return s[i].CDN < s[j].CDN
+	}
+	return s[i].Name < s[j].Name
+}
+
+// tenantAncestors returns the given tenant and all its ancestors. Cycles in the hierarchy are ignored.
+func tenantAncestors(tenantID int, tenants []tc.Tenant) []int {
+	parents := map[int]int{}
+	for _, tenant := range tenants {
+		parents[tenant.ID] = tenant.ParentID
+	}
+	ancestors := []int{}
+	seen := map[int]struct{}{}
+	for id := tenantID; id != 0; id = parents[id] {
+		if _, ok := seen[id]; ok {
+			break
+		}
+		seen[id] = struct{}{}
+		ancestors = append(ancestors, id)
+	}
+	return ancestors
+}
+
+// sumSeries adds the given series together, minute by minute.
+func sumSeries(ss []series) series {
+	sum := series{}
+	for _, s := range ss {
+		for t, v := range s {
+			sum[t] += v
+		}
+	}
+	return sum
+}
+
+// summarize computes the bytes served and the 95th percentile of the 5 minute average kbps of the given series, from start to end.
+// Every 5 minute interval from start to end is counted, and minutes with no sample are 0 kbps, so intervals with little or no data lower the percentile rather than being ignored.
+func summarize(name string, s series, start time.Time, end time.Time) Usage {
+	bytesServed := float64(0)
+	samples := 0
+	first := start.Unix() - start.Unix()%percentileBucketSecs
+	// the kilobits of each interval, which are divided by its length to get its average
+	averages := make([]float64, (end.Unix()-first+percentileBucketSecs-1)/percentileBucketSecs)
+	for t, kbps := range s {
+		if t < start.Unix() || t >= end.Unix() {
+			continue
+		}
+		bytesServed += kbps * 1000 / 8 * sampleSecs
+		samples++
+		averages[(t-first)/percentileBucketSecs] += kbps * sampleSecs
+	}
+	for i := range averages {
+		averages[i] /= percentileBucketSecs
+	}
+	return Usage{
+		Name:        name,
+		BytesServed: bytesServed,
+		P95Kbps:     percentile(averages, 95),
+		Samples:     samples,
+	}
+}
+
+// percentile returns the nearest-rank pth percentile of the given values, or 0 if there are none.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func writeJSON(w io.Writer, report Report) error {
+	bts, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(bts, '\n'))
+	return err
+}
+
+// writeCSV writes one row per delivery service and tenant. The query windows are written first as '#' comment lines, so the report can be reproduced.
+func writeCSV(w io.Writer, report Report) error {
+	for _, window := range report.Windows {
+		if _, err := fmt.Fprintf(w, "# window %s %s: %s\n", window.Start.Format(time.RFC3339), window.End.Format(time.RFC3339), window.Query); err != nil {
+			return err
+		}
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"month", "type", "name", "cdn", "tenant", "bytes_served", "p95_kbps", "samples"})
+	for _, usage := range report.DeliveryServices {
+		cw.Write(usageRecord(report.Month, "deliveryservice", usage))
+	}
+	for _, usage := range report.Tenants {
+		cw.Write(usageRecord(report.Month, "tenant", usage))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func usageRecord(month string, usageType string, usage Usage) []string {
+	return []string{
+		month,
+		usageType,
+		usage.Name,
+		usage.CDN,
+		usage.Tenant,
+		strconv.FormatFloat(usage.BytesServed, 'f', 0, 64),
+		strconv.FormatFloat(usage.P95Kbps, 'f', 2, 64),
+		strconv.Itoa(usage.Samples),
+	}
+}
@@ -0,0 +1,288 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
+	"github.com/apache/incubator-trafficcontrol/traffic_stats/assert"
+	influx "github.com/influxdata/influxdb/client/v2"
+	"github.com/influxdata/influxdb/models"
+)
+
+func TestLastMonth(t *testing.T) {
+	assert.Equal(t, lastMonth(time.Date(2017, 3, 31, 12, 0, 0, 0, time.UTC)), "2017-02")
+	assert.Equal(t, lastMonth(time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)), "2017-02")
+	assert.Equal(t, lastMonth(time.Date(2017, 1, 15, 0, 0, 0, 0, time.UTC)), "2016-12")
+	assert.Equal(t, lastMonth(time.Date(2017, 5, 1, 1, 0, 0, 0, time.FixedZone("UTC+2", 2*60*60))), "2017-03")
+}
+
+func TestMonthWindows(t *testing.T) {
+	start, end, err := monthRange("2017-02")
+	assert.Nil(t, err)
+	assert.Equal(t, end, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC))
+	_, _, err = monthRange("February")
+	assert.NotNil(t, err)
+
+	windows, err := monthWindows(start, end, 24*time.Hour, "monthly")
+	assert.Nil(t, err)
+	assert.Equal(t, len(windows), 28)
+	assert.Equal(t, windows[0].Start, time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, windows[27].End, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC))
+	for i := 1; i < len(windows); i++ {
+		assert.Equal(t, windows[i].Start, windows[i-1].End)
+	}
+	assert.Equal(t, windows[0].Query, `select mean(value) from "monthly"."kbps.ds.1min" where time >= '2017-02-01T00:00:00Z' and time < '2017-02-02T00:00:00Z' group by time(1m), cdn, deliveryservice`)
+
+	// windows which don't evenly divide the month are truncated at the month end
+	windows, err = monthWindows(start, end, 10*24*time.Hour, "monthly")
+	assert.Nil(t, err)
+	assert.Equal(t, len(windows), 3)
+	assert.Equal(t, windows[2].End, time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	_, err = monthWindows(start, end, time.Second, "monthly")
+	assert.NotNil(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	assert.Equal(t, percentile(nil, 95), float64(0))
+
+	values := []float64{}
+	for i := 100; i > 0; i-- {
+		values = append(values, float64(i))
+	}
+	assert.Equal(t, percentile(values, 95), float64(95))
+	assert.Equal(t, percentile([]float64{7}, 95), float64(7))
+	assert.Equal(t, values[0], float64(100)) // input isn't reordered
+}
+
+func TestSummarize(t *testing.T) {
+	s := series{}
+	// 10 minutes at 8 kbps, then 10 minutes at 16 kbps
+	for i := int64(0); i < 20; i++ {
+		kbps := float64(8)
+		if i >= 10 {
+			kbps = 16
+		}
+		s[i*sampleSecs] = kbps
+	}
+	usage := summarize("ds1", s, time.Unix(0, 0), time.Unix(20*sampleSecs, 0))
+	assert.Equal(t, usage.Name, "ds1")
+	assert.Equal(t, usage.Samples, 20)
+	assert.Equal(t, usage.BytesServed, float64(10*1000*60+10*2000*60))
+	assert.Equal(t, usage.P95Kbps, float64(16))
+
+	// samples outside the range aren't counted
+	usage = summarize("ds1", s, time.Unix(0, 0), time.Unix(10*sampleSecs, 0))
+	assert.Equal(t, usage.Samples, 10)
+	assert.Equal(t, usage.P95Kbps, float64(8))
+}
+
+func TestSummarizeCountsEmptyIntervals(t *testing.T) {
+	s := series{}
+	// 20 busy minutes in 500, so 4 of 100 intervals have data
+	for i := int64(0); i < 20; i++ {
+		s[i*sampleSecs] = 100
+	}
+	usage := summarize("ds1", s, time.Unix(0, 0), time.Unix(500*sampleSecs, 0))
+	assert.Equal(t, usage.P95Kbps, float64(0))
+
+	// a minute missing from an interval is 0 kbps
+	s = series{0: 50}
+	usage = summarize("ds1", s, time.Unix(0, 0), time.Unix(5*sampleSecs, 0))
+	assert.Equal(t, usage.P95Kbps, float64(10))
+}
+
+func TestAddKbpsResults(t *testing.T) {
+	res := []influx.Result{
+		influx.Result{
+			Series: []models.Row{
+				models.Row{
+					Tags: map[string]string{"cdn": "cdn1", "deliveryservice": "ds1"},
+					Values: [][]interface{}{
+						[]interface{}{"2017-02-01T00:00:00Z", json.Number("10")},
+						[]interface{}{"2017-02-01T00:01:00Z", nil},
+						[]interface{}{"2017-02-01T00:02:00Z", json.Number("30")},
+					},
+				},
+				models.Row{
+					Tags:   map[string]string{"cdn": "cdn1"},
+					Values: [][]interface{}{[]interface{}{"2017-02-01T00:00:00Z", json.Number("10")}},
+				},
+			},
+		},
+	}
+	dsSeries := map[dsKey]series{}
+	addKbpsResults(dsSeries, res)
+	assert.Equal(t, len(dsSeries), 1)
+	assert.Equal(t, len(dsSeries[dsKey{cdn: "cdn1", ds: "ds1"}]), 2)
+}
+
+func TestBuildReport(t *testing.T) {
+	tenants := []tc.Tenant{
+		{ID: 1, Name: "root"},
+		{ID: 2, Name: "media", ParentID: 1},
+		{ID: 3, Name: "video", ParentID: 2},
+	}
+	dses := []tc.DeliveryService{
+		{XMLID: "ds1", CDNName: "cdn1", TenantID: 3},
+		{XMLID: "ds2", CDNName: "cdn1", TenantID: 2},
+		{XMLID: "ds3", CDNName: "cdn1"},
+	}
+	dsSeries := map[dsKey]series{
+		dsKey{cdn: "cdn1", ds: "ds1"}: series{0: 8, 60: 8, 120: 8, 180: 8, 240: 8},
+		dsKey{cdn: "cdn1", ds: "ds2"}: series{0: 16, 60: 16, 120: 16, 180: 16, 240: 16},
+		dsKey{cdn: "cdn1", ds: "ds3"}: series{0: 8},
+	}
+	report := buildReport("2017-02", time.Unix(0, 0), time.Unix(300, 0), "monthly", nil, dsSeries, dses, tenants)
+
+	assert.Equal(t, len(report.DeliveryServices), 3)
+	assert.Equal(t, report.DeliveryServices[0].Name, "ds1")
+	assert.Equal(t, report.DeliveryServices[0].Tenant, "video")
+	assert.Equal(t, report.DeliveryServices[2].Tenant, "")
+
+	// ds3 has no tenant, so it isn't rolled up
+	assert.Equal(t, len(report.Tenants), 3)
+	byName := map[string]Usage{}
+	for _, usage := range report.Tenants {
+		byName[usage.Name] = usage
+	}
+	assert.Equal(t, byName["video"].P95Kbps, float64(8))
+	assert.Equal(t, byName["media"].P95Kbps, float64(24))
+	assert.Equal(t, byName["root"].BytesServed, byName["media"].BytesServed)
+	assert.Equal(t, byName["media"].BytesServed, float64(5*1000*60+5*2000*60))
+}
+
+func TestBuildReportSameXMLIDInTwoCDNs(t *testing.T) {
+	tenants := []tc.Tenant{
+		{ID: 1, Name: "media"},
+		{ID: 2, Name: "video"},
+	}
+	dses := []tc.DeliveryService{
+		{XMLID: "ds1", CDNName: "cdn1", TenantID: 1},
+		{XMLID: "ds1", CDNName: "cdn2", TenantID: 2},
+	}
+	dsSeries := map[dsKey]series{
+		dsKey{cdn: "cdn1", ds: "ds1"}: series{0: 8},
+		dsKey{cdn: "cdn2", ds: "ds1"}: series{0: 16},
+	}
+	report := buildReport("2017-02", time.Unix(0, 0), time.Unix(300, 0), "monthly", nil, dsSeries, dses, tenants)
+
+	assert.Equal(t, len(report.DeliveryServices), 2)
+	for _, usage := range report.DeliveryServices {
+		switch usage.CDN {
+		case "cdn1":
+			assert.Equal(t, usage.Tenant, "media")
+		case "cdn2":
+			assert.Equal(t, usage.Tenant, "video")
+		default:
+			t.Errorf("unexpected cdn %s", usage.CDN)
+		}
+	}
+	assert.Equal(t, len(report.Tenants), 2)
+}
+
+func TestWriteCSV(t *testing.T) {
+	start, end, err := monthRange("2017-02")
+	assert.Nil(t, err)
+	windows, err := monthWindows(start, end, 24*time.Hour, "monthly")
+	assert.Nil(t, err)
+	report := Report{
+		Month:            "2017-02",
+		Windows:          windows,
+		DeliveryServices: []Usage{{Name: "ds1", CDN: "cdn1", Tenant: "root", BytesServed: 1e12, P95Kbps: 123.456, Samples: 3}},
+		Tenants:          []Usage{{Name: "root", BytesServed: 1e12, P95Kbps: 123.456, Samples: 3}},
+	}
+	buf := &bytes.Buffer{}
+	assert.Nil(t, writeCSV(buf, report))
+	assert.Equal(t, strings.Count(buf.String(), "# window "), 28)
+
+	r := csv.NewReader(buf)
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, len(records), 3)
+	assert.Equal(t, strings.Join(records[1], ","), "2017-02,deliveryservice,ds1,cdn1,root,1000000000000,123.46,3")
+	assert.Equal(t, strings.Join(records[2], ","), "2017-02,tenant,root,,,1000000000000,123.46,3")
+}
+
+func TestUsagePoints(t *testing.T) {
+	start := time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)
+	report := Report{
+		DeliveryServices: []Usage{{Name: "ds1", CDN: "cdn1", Tenant: "root", BytesServed: 1e12, P95Kbps: 123.456, Samples: 3}, {Name: "ds2", CDN: "cdn1"}},
+		Tenants:          []Usage{{Name: "root", BytesServed: 1e12, P95Kbps: 123.456, Samples: 3}},
+	}
+	bps, err := usagePoints(report, start, "indefinite")
+	assert.Nil(t, err)
+	assert.Equal(t, bps.Database(), deliveryService)
+	assert.Equal(t, bps.RetentionPolicy(), "indefinite")
+
+	points := bps.Points()
+	assert.Equal(t, len(points), 3)
+	assert.Equal(t, points[0].Name(), dsUsageStat)
+	assert.Equal(t, points[0].Time(), start)
+	assert.Equal(t, points[0].Tags()["deliveryservice"], "ds1")
+	assert.Equal(t, points[0].Tags()["tenant"], "root")
+	_, ok := points[1].Tags()["tenant"]
+	assert.Equal(t, ok, false)
+	fields, err := points[0].Fields()
+	assert.Nil(t, err)
+	assert.Equal(t, fields["p95_kbps"], 123.456)
+	assert.Equal(t, points[2].Name(), tenantUsageStat)
+	assert.Equal(t, points[2].Tags()["tenant"], "root")
+}
+
+func TestParseStoredUsages(t *testing.T) {
+	res := []influx.Result{
+		influx.Result{
+			Series: []models.Row{
+				models.Row{
+					Name:    dsUsageStat,
+					Tags:    map[string]string{"cdn": "cdn1", "deliveryservice": "ds1", "tenant": "root"},
+					Columns: []string{"time", "bytes_served", "p95_kbps", "samples"},
+					Values:  [][]interface{}{[]interface{}{"2017-02-01T00:00:00Z", json.Number("1000000000000"), json.Number("123.456"), json.Number("3")}},
+				},
+			},
+		},
+	}
+	usages, err := parseStoredUsages(res, "deliveryservice")
+	assert.Nil(t, err)
+	assert.Equal(t, len(usages), 1)
+	assert.Equal(t, usages[0], Usage{Name: "ds1", CDN: "cdn1", Tenant: "root", BytesServed: 1e12, P95Kbps: 123.456, Samples: 3})
+
+	usages, err = parseStoredUsages(res, "tenant")
+	assert.Nil(t, err)
+	assert.Equal(t, usages[0].Name, "root")
+	assert.Equal(t, usages[0].Tenant, "")
+
+	res[0].Series[0].Values[0][2] = nil
+	_, err = parseStoredUsages(res, "deliveryservice")
+	assert.NotNil(t, err)
+
+	usages, err = parseStoredUsages(nil, "deliveryservice")
+	assert.Nil(t, err)
+	assert.Equal(t, len(usages), 0)
+}
@@ -0,0 +1,98 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one
+or more contributor license agreements.  See the NOTICE file
+distributed with this work for additional information
+regarding copyright ownership.  The ASF licenses this file
+to you under the Apache License, Version 2.0 (the
+"License"); you may not use this file except in compliance
+with the License.  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing,
+software distributed under the License is distributed on an
+"AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+KIND, either express or implied.  See the License for the
+specific language governing permissions and limitations
+under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/apache/incubator-trafficcontrol/traffic_stats/influxdb"
+)
+
+func main() {
+	// get influx db flags
+	config := &influxdb.Config{}
+	config.Flags("")
+	var schemaPath string
+	var replication int
+	var prune bool
+	flag.StringVar(&schemaPath, "schema", "/opt/traffic_stats/conf/influxdb_schema.json", "The schema file")
+	flag.IntVar(&replication, "replication", 0, "The number of nodes in the cluster, overriding the schema replication if set")
+	flag.BoolVar(&prune, "prune", false, "Drop retention policies and continuous queries which aren't in the schema")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] plan|apply\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	command := flag.Arg(0)
+	if flag.NArg() != 1 || (command != "plan" && command != "apply") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	schema, err := influxdb.LoadSchema(schemaPath)
+	if err != nil {
+		fmt.Printf("Error loading schema: %v\n", err)
+		os.Exit(1)
+	}
+	if replication > 0 {
+		schema.Replication = replication
+	}
+
+	client, err := config.NewHTTPClient()
+	if err != nil {
+		fmt.Printf("Unable to run %s, failed to get influxdb client: %v\n", command, err)
+		os.Exit(1)
+	}
+
+	current, err := influxdb.CurrentSchema(client)
+	if err != nil {
+		fmt.Printf("Error reading the schema of %s: %v\n", config.URL, err)
+		os.Exit(1)
+	}
+
+	changes := influxdb.Plan(schema, current, prune)
+	pending := 0
+	for _, change := range changes {
+		if change.Command == "" {
+			fmt.Printf("  ! %s\n", change.Description)
+			continue
+		}
+		pending++
+		fmt.Printf("  + %s\n      %s\n", change.Description, change.Command)
+	}
+	if pending == 0 {
+		fmt.Printf("%s matches the schema, no changes needed\n", config.URL)
+		return
+	}
+
+	if command == "plan" {
+		fmt.Printf("%d change(s) needed, run apply to make them\n", pending)
+		return
+	}
+
+	if err := influxdb.Apply(client, changes); err != nil {
+		fmt.Printf("Error applying schema: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully applied %d change(s) to %s\n", pending, config.URL)
+}
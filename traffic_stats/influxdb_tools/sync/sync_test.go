@@ -22,7 +22,11 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/apache/incubator-trafficcontrol/traffic_stats/assert"
 	influx "github.com/influxdata/influxdb/client/v2"
@@ -180,6 +184,140 @@ func TestGetCacheStats(t *testing.T) {
 	assert.Equal(t, len(getCacheStatsMap), 6) // we get one cacheStats object per Values entry
 }
 
+func TestChunks(t *testing.T) {
+	start := time.Date(2017, 10, 1, 13, 30, 0, 0, time.UTC)
+	end := time.Date(2017, 10, 3, 6, 0, 0, 0, time.UTC)
+	cs := chunks(start, end, 24*time.Hour)
+	assert.Equal(t, len(cs), 3)
+	assert.Equal(t, cs[0].start, start)
+	assert.Equal(t, cs[0].end, time.Date(2017, 10, 2, 0, 0, 0, 0, time.UTC)) // chunks are aligned, so resumed runs get the same chunks
+	assert.Equal(t, cs[1].start, cs[0].end)
+	assert.Equal(t, cs[2].end, end)
+
+	assert.Empty(t, chunks(end, start, 24*time.Hour))
+}
+
+func TestDiffPoints(t *testing.T) {
+	from := map[string]statPoint{
+		"a": statPoint{value: 1},
+		"b": statPoint{value: 2},
+		"c": statPoint{value: 3},
+	}
+	to := map[string]statPoint{
+		"a": statPoint{value: 1},
+		"b": statPoint{value: 1},
+		"d": statPoint{value: 4},
+	}
+	diff := diffPoints(from, to, to)
+	assert.Equal(t, diff.fromPoints, 3)
+	assert.Equal(t, diff.toPoints, 2) // d isn't a point of from
+	assert.Equal(t, diff.missing, 1)
+	assert.Equal(t, diff.lower, 1)
+	assert.Equal(t, diff.verified(), false)
+
+	// a target with exactly the points of from verifies, whatever else it has
+	same := map[string]statPoint{"a": statPoint{value: 1}, "b": statPoint{value: 2}, "c": statPoint{value: 3}, "d": statPoint{value: 4}}
+	diff = diffPoints(from, to, same)
+	assert.Equal(t, diff.toPoints, 3)
+	assert.Equal(t, diff.wantChecksum, diff.toChecksum)
+	assert.Equal(t, diff.verified(), true)
+
+	// a value which was bigger in the target before syncing is left alone, so it verifies
+	bigger := map[string]statPoint{"a": statPoint{value: 1}, "b": statPoint{value: 5}, "c": statPoint{value: 3}}
+	assert.Equal(t, diffPoints(from, bigger, bigger).verified(), true)
+
+	// but a value which is bigger than both the source and the target before syncing doesn't
+	assert.Equal(t, diffPoints(from, to, bigger).verified(), false)
+	assert.Equal(t, diffPoints(from, to, bigger).lower, 0)
+}
+
+func TestSyncState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sync_test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	state, err := loadSyncState(path)
+	assert.Nil(t, err)
+	_, ok := state.get("a")
+	assert.Equal(t, ok, false)
+
+	completed := time.Date(2017, 10, 2, 0, 0, 0, 0, time.UTC)
+	assert.Nil(t, state.complete("a", completed))
+
+	state, err = loadSyncState(path)
+	assert.Nil(t, err)
+	got, ok := state.get("a")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, got.Equal(completed), true)
+}
+
+func TestStatDefs(t *testing.T) {
+	defs, err := statDefs("all")
+	assert.Nil(t, err)
+	assert.Equal(t, len(defs), len(cacheStatNames)+len(deliveryServiceStatNames)+len(dailyStatNames))
+
+	defs, err = statDefs(deliveryService)
+	assert.Nil(t, err)
+	for _, def := range defs {
+		if def.name == "max.kbps.ds.1day" {
+			assert.Equal(t, def.rp, "indefinite")
+		} else {
+			assert.Equal(t, def.rp, "monthly")
+		}
+	}
+
+	_, err = statDefs("nope")
+	assert.NotNil(t, err)
+}
+
+// fakeInflux is an in memory influx.Client for daily stats, which returns every point for any query.
+type fakeInflux struct {
+	values [][]interface{}
+}
+
+func (f *fakeInflux) Ping(timeout time.Duration) (time.Duration, string, error) { return 0, "", nil }
+func (f *fakeInflux) Close() error                                              { return nil }
+
+func (f *fakeInflux) Write(bp influx.BatchPoints) error {
+	for _, p := range bp.Points() {
+		fields, _ := p.Fields()
+		tags := p.Tags()
+		f.values = append(f.values, []interface{}{p.Time().UTC().Format(time.RFC3339), tags["cdn"], tags["deliveryservice"], json.Number(fmt.Sprintf("%v", fields["value"]))})
+	}
+	return nil
+}
+
+func (f *fakeInflux) Query(q influx.Query) (*influx.Response, error) {
+	return &influx.Response{Results: []influx.Result{{Series: []models.Row{{Values: f.values}}}}}, nil
+}
+
+func TestSyncChunk(t *testing.T) {
+	from := &fakeInflux{values: [][]interface{}{
+		{"2017-10-01T00:00:00Z", "cdn1", "all", json.Number("1")},
+		{"2017-10-02T00:00:00Z", "cdn1", "all", json.Number("2")},
+		{"2017-10-03T00:00:00Z", "cdn1", "all", json.Number("3")},
+	}}
+	to := &fakeInflux{values: [][]interface{}{
+		{"2017-10-01T00:00:00Z", "cdn1", "all", json.Number("1")},
+		{"2017-10-02T00:00:00Z", "cdn1", "all", json.Number("1")},
+	}}
+	dir := direction{name: "from -> to", from: from, to: to}
+	def := dailyStatDef("daily_maxgbps")
+	c := chunk{start: time.Date(2017, 10, 1, 0, 0, 0, 0, time.UTC), end: time.Date(2017, 10, 4, 0, 0, 0, 0, time.UTC)}
+
+	diff, err := syncChunk(dir, def, c, true)
+	assert.Nil(t, err)
+	assert.Equal(t, diff.missing, 1)
+	assert.Equal(t, diff.lower, 1)
+	assert.Equal(t, len(to.values), 2) // dry run doesn't write
+
+	diff, err = syncChunk(dir, def, c, false)
+	assert.Nil(t, err)
+	assert.Equal(t, diff.verified(), true)
+	assert.Equal(t, len(to.values), 4) // only the missing and lower points are written
+}
+
 func generateDailyValues(i int) [][]interface{} {
 	ret := make([][]interface{}, 1)
 	startIdx := i * 4 // this is to have the right amount of difference between the test numbers used
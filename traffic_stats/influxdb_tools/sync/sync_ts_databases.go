@@ -23,7 +23,13 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/apache/incubator-trafficcontrol/traffic_stats/influxdb"
@@ -59,6 +65,97 @@ type dailyStats struct {
 	value           float64
 }
 
+// statPoint is a single point of any stat, with the tags it is written with.
+type statPoint struct {
+	t     string //time
+	tags  map[string]string
+	value float64
+}
+
+// statDef describes where a stat lives, and how to read and write it.
+type statDef struct {
+	db        string
+	rp        string
+	name      string
+	precision string
+	columns   string // the columns selected, in the order parse expects them
+	parse     func([]influx.Result) map[string]statPoint
+}
+
+func (d statDef) String() string {
+	return fmt.Sprintf("%s.%s.%s", d.db, d.rp, d.name)
+}
+
+// query returns the query selecting every point of the stat within the chunk.
+func (d statDef) query(c chunk) string {
+	return fmt.Sprintf(`select %s from "%s"."%s" where time >= '%s' and time < '%s'`, d.columns, d.rp, d.name, c.start.Format(time.RFC3339Nano), c.end.Format(time.RFC3339Nano))
+}
+
+// chunk is a time range [start, end) which is synced and verified as a unit.
+type chunk struct {
+	start time.Time
+	end   time.Time
+}
+
+// chunkDiff compares a chunk of a stat on the instance being synced from with the instance being synced to.
+type chunkDiff struct {
+	fromPoints   int
+	toPoints     int    // points of from which are in to
+	wantChecksum uint64 // checksum of the points of from, with the values to should have
+	toChecksum   uint64 // checksum of the points of to which are in from
+	missing      int    // points which are in from, but not in to
+	lower        int    // points whose value in to is lower than in from
+}
+
+// verified returns whether to has every point of from, with the values it should have. As with an unchunked sync, a value which was bigger in to before syncing is left alone, so it's the value to should have.
+func (d chunkDiff) verified() bool {
+	return d.toPoints == d.fromPoints && d.toChecksum == d.wantChecksum
+}
+
+func (d chunkDiff) String() string {
+	return fmt.Sprintf("%d points (checksum %x) -> %d points (checksum %x), %d missing, %d lower", d.fromPoints, d.wantChecksum, d.toPoints, d.toChecksum, d.missing, d.lower)
+}
+
+// direction is a one way sync between two influx instances.
+type direction struct {
+	name string
+	from influx.Client
+	to   influx.Client
+}
+
+// syncState records, for each direction and stat, the time through which it has been synced and verified, so an interrupted sync can be resumed.
+type syncState struct {
+	path      string
+	m         sync.Mutex
+	Completed map[string]time.Time `json:"completed"`
+}
+
+var cacheStatNames = []string{
+	"bandwidth.cdn.1min",
+	"connections.cdn.1min",
+	"connections.cdn.type.1min",
+	"bandwidth.cdn.type.1min",
+	//these take a long time so do them last
+	"bandwidth.1min",
+	"connections.1min",
+}
+
+var deliveryServiceStatNames = []string{
+	"kbps.ds.1min",
+	"max.kbps.ds.1day",
+	"kbps.cg.1min",
+	"tps_2xx.ds.1min",
+	"tps_3xx.ds.1min",
+	"tps_4xx.ds.1min",
+	"tps_5xx.ds.1min",
+	"tps_total.ds.1min",
+}
+
+var dailyStatNames = []string{
+	"daily_bytesserved",
+	"daily_maxgbps",
+}
+
 func main() {
 	// get influx db flags for source influxdb
 	sourceConfig := &influxdb.Config{}
@@ -68,12 +165,32 @@ func main() {
 	targetConfig := &influxdb.Config{}
 	targetConfig.Flags("target")
 
-	var days int
-	var database string
+	var days, parallel int
+	var database, statePath string
+	var chunkSize time.Duration
+	var dryRun, bidirectional bool
 	flag.IntVar(&days, "days", 0, "Number of days in the past to sync (today - x days), 0 is all")
 	flag.StringVar(&database, "database", "all", "Sync a specific database")
+	flag.DurationVar(&chunkSize, "chunk", 24*time.Hour, "The time range synced and verified at once")
+	flag.IntVar(&parallel, "parallel", 2, "The maximum number of stats to sync at once")
+	flag.StringVar(&statePath, "state", "sync_state.json", "The file progress is recorded in, so an interrupted sync can be resumed. Empty disables resuming")
+	flag.BoolVar(&dryRun, "dry-run", false, "Report the points missing from each chunk without writing anything")
+	flag.BoolVar(&bidirectional, "bidirectional", false, "Also sync the target back to the source")
 	flag.Parse()
 
+	defs, err := statDefs(database)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if chunkSize < time.Minute {
+		fmt.Println("chunk must be at least 1m")
+		return
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
 	fmt.Printf("syncing %s to %s for %s database(s) for the past %d day(s)\n", sourceConfig.URL, targetConfig.URL, database, days)
 	sourceClient, err := sourceConfig.NewHTTPClient()
 	if err != nil {
@@ -87,288 +204,363 @@ func main() {
 		return
 	}
 
-	chSize := 1
-	if database == "all" {
-		chSize = 3
+	dirs := []direction{{name: sourceConfig.URL + " -> " + targetConfig.URL, from: sourceClient, to: targetClient}}
+	if bidirectional {
+		dirs = append(dirs, direction{name: targetConfig.URL + " -> " + sourceConfig.URL, from: targetClient, to: sourceClient})
 	}
 
-	ch := make(chan string)
-
-	switch database {
-	case "all":
-		go syncDailyDb(ch, sourceClient, targetClient, days)
-		go syncCsDb(ch, sourceClient, targetClient, days)
-		go syncDsDb(ch, sourceClient, targetClient, days)
-	case cache:
-		go syncCsDb(ch, sourceClient, targetClient, days)
-	case deliveryService:
-		go syncDsDb(ch, sourceClient, targetClient, days)
-	case daily:
-		go syncDailyDb(ch, sourceClient, targetClient, days)
-	default:
-		fmt.Println("No database selected")
+	state := &syncState{Completed: map[string]time.Time{}}
+	if statePath != "" && !dryRun {
+		if state, err = loadSyncState(statePath); err != nil {
+			fmt.Printf("Error loading sync state: %v\n", err)
+			return
+		}
 	}
 
-	for i := 1; i <= chSize; i++ {
-		fmt.Println(<-ch)
+	end := time.Now().UTC()
+	start := time.Time{}
+	if days > 0 {
+		start = end.AddDate(0, 0, -days)
+	}
+
+	failed := false
+	failedM := sync.Mutex{}
+	sem := make(chan struct{}, parallel)
+	wg := sync.WaitGroup{}
+	for _, def := range defs {
+		wg.Add(1)
+		go func(def statDef) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			for _, dir := range dirs {
+				if err := syncStat(dir, def, start, end, chunkSize, dryRun, state); err != nil {
+					fmt.Printf("Error syncing %s %s: %v\n", dir.name, def, err)
+					failedM.Lock()
+					failed = true
+					failedM.Unlock()
+					return
+				}
+			}
+			fmt.Printf("Done syncing %s\n", def)
+		}(def)
 	}
+	wg.Wait()
 
+	if failed {
+		fmt.Println("Traffic Stats sync failed, run it again to resume")
+		os.Exit(1)
+	}
+	if dryRun {
+		fmt.Println("Traffic Stats dry run complete, nothing was written")
+		return
+	}
 	fmt.Println("Traffic Stats have been synced!")
-	return
 }
 
-func syncCsDb(ch chan string, sourceClient influx.Client, targetClient influx.Client, days int) {
-	db := cache
-	fmt.Printf("Syncing %s database...\n", db)
-	stats := [...]string{
-		"bandwidth.cdn.1min",
-		"connections.cdn.1min",
-		"connections.cdn.type.1min",
-		"bandwidth.cdn.type.1min",
-		//these take a long time so do them last
-		"bandwidth.1min",
-		"connections.1min",
+// statDefs returns the stats to sync for the given database, or all databases.
+func statDefs(database string) ([]statDef, error) {
+	defs := []statDef{}
+	if database == "all" || database == daily {
+		for _, name := range dailyStatNames {
+			defs = append(defs, dailyStatDef(name))
+		}
 	}
-
-	for _, statName := range stats {
-		fmt.Printf("Syncing %s database with %s \n", db, statName)
-		syncCacheStat(sourceClient, targetClient, statName, days)
-		fmt.Printf("Done syncing %s\n", statName)
+	if database == "all" || database == cache {
+		for _, name := range cacheStatNames {
+			defs = append(defs, cacheStatDef(name))
+		}
 	}
-	ch <- fmt.Sprintf("Done syncing %s!\n", db)
-}
-
-func syncDsDb(ch chan string, sourceClient influx.Client, targetClient influx.Client, days int) {
-	db := deliveryService
-	fmt.Printf("Syncing %s database...\n", db)
-	stats := [...]string{
-		"kbps.ds.1min",
-		"max.kbps.ds.1day",
-		"kbps.cg.1min",
-		"tps_2xx.ds.1min",
-		"tps_3xx.ds.1min",
-		"tps_4xx.ds.1min",
-		"tps_5xx.ds.1min",
-		"tps_total.ds.1min",
+	if database == "all" || database == deliveryService {
+		for _, name := range deliveryServiceStatNames {
+			defs = append(defs, deliveryServiceStatDef(name))
+		}
 	}
-	for _, statName := range stats {
-		fmt.Printf("Syncing %s database with %s\n", db, statName)
-		syncDeliveryServiceStat(sourceClient, targetClient, statName, days)
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("No database selected, unknown database '%s'", database)
 	}
-	ch <- fmt.Sprintf("Done syncing %s!\n", db)
+	return defs, nil
 }
 
-func syncDailyDb(ch chan string, sourceClient influx.Client, targetClient influx.Client, days int) {
-	db := daily
-	fmt.Printf("Syncing %s database...\n", db)
-	stats := [...]string{
-		"daily_bytesserved",
-		"daily_maxgbps",
-	}
+func cacheStatDef(name string) statDef {
+	return statDef{db: cache, rp: "monthly", name: name, precision: "ms", columns: "time, cdn, hostname, type, value", parse: parseCacheStats}
+}
 
-	for _, statName := range stats {
-		fmt.Printf("Syncing %s database with %s\n", db, statName)
-		syncDailyStat(sourceClient, targetClient, statName, days)
+func deliveryServiceStatDef(name string) statDef {
+	rp := "monthly"
+	if strings.Contains(name, "1day") {
+		rp = "indefinite"
 	}
-	ch <- fmt.Sprintf("Done syncing %s!\n", db)
-
+	return statDef{db: deliveryService, rp: rp, name: name, precision: "ms", columns: "time, cachegroup, cdn, deliveryservice, value", parse: parseDeliveryServiceStats}
 }
 
-func syncCacheStat(sourceClient influx.Client, targetClient influx.Client, statName string, days int) {
-	//get records from source DB
-	db := cache
-
-	bps, _ := influx.NewBatchPoints(influx.BatchPointsConfig{
-		Database:        db,
-		Precision:       "ms",
-		RetentionPolicy: "monthly",
-	})
+func dailyStatDef(name string) statDef {
+	return statDef{db: daily, rp: "indefinite", name: name, precision: "s", columns: "time, cdn, deliveryservice, value", parse: parseDailyStats}
+}
 
-	queryString := fmt.Sprintf("select time, cdn, hostname, type, value from \"%s\".\"%s\"", "monthly", statName)
+// syncStat syncs a stat in one direction, chunk by chunk, from where the last run left off. Each chunk is verified after it's written, and the sync stops at the first chunk which fails verification, so a later run retries it. In a dry run, the difference for each chunk is reported and nothing is written.
+func syncStat(dir direction, def statDef, start time.Time, end time.Time, chunkSize time.Duration, dryRun bool, state *syncState) error {
+	stateKey := dir.name + " " + def.String()
+	if completed, ok := state.get(stateKey); ok && completed.After(start) {
+		fmt.Printf("Resuming %s %s from %s\n", dir.name, def, completed.Format(time.RFC3339))
+		start = completed
+	}
+	if start.IsZero() {
+		first, ok, err := firstPointTime(dir.from, def)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Printf("No %s data to sync from %s\n", def, dir.name)
+			return nil
+		}
+		start = first
+	}
 
-	if days > 0 {
-		queryString = fmt.Sprintf("%s where time > now() - %dd", queryString, days)
+	for _, c := range chunks(start, end, chunkSize) {
+		diff, err := syncChunk(dir, def, c, dryRun)
+		if err != nil {
+			return fmt.Errorf("chunk %s - %s: %v", c.start.Format(time.RFC3339), c.end.Format(time.RFC3339), err)
+		}
+		if dryRun {
+			if !diff.verified() {
+				fmt.Printf("Gap in %s %s from %s to %s: %s\n", dir.name, def, c.start.Format(time.RFC3339), c.end.Format(time.RFC3339), diff)
+			}
+			continue
+		}
+		if !diff.verified() {
+			return fmt.Errorf("chunk %s - %s failed verification: %s", c.start.Format(time.RFC3339), c.end.Format(time.RFC3339), diff)
+		}
+		fmt.Printf("Synced %s %s from %s to %s: %s\n", dir.name, def, c.start.Format(time.RFC3339), c.end.Format(time.RFC3339), diff)
+		if err := state.complete(stateKey, c.end); err != nil {
+			return fmt.Errorf("saving sync state: %v", err)
+		}
 	}
+	return nil
+}
 
-	fmt.Println("queryString ", queryString)
-	res, err := queryDB(sourceClient, queryString, db)
+// syncChunk writes every point of the chunk which is missing from, or lower in, the target, and returns the difference after writing. In a dry run, the difference before writing is returned.
+func syncChunk(dir direction, def statDef, c chunk, dryRun bool) (chunkDiff, error) {
+	q := def.query(c)
+	fromRes, err := queryDB(dir.from, q, def.db)
 	if err != nil {
-		fmt.Printf("An error occured getting %s records from sourceDb\n", statName)
-		return
+		return chunkDiff{}, fmt.Errorf("querying source: %v", err)
 	}
-	sourceStats := getCacheStats(res)
-
-	//get values from target DB
-	targetRes, err := queryDB(targetClient, queryString, db)
+	fromPoints := def.parse(fromRes)
+	toPoints, err := queryPoints(dir.to, def, q)
 	if err != nil {
-		fmt.Printf("An error occured getting %s record from target db: %v\n", statName, err)
-		return
+		return chunkDiff{}, err
 	}
-	targetStats := getCacheStats(targetRes)
 
-	for ssKey := range sourceStats {
-		ts := targetStats[ssKey]
-		ss := sourceStats[ssKey]
+	diff := diffPoints(fromPoints, toPoints, toPoints)
+	if dryRun || diff.verified() {
+		return diff, nil
+	}
 
-		if ts.value >= ss.value {
-			//fmt.Printf("target value %v is at least equal to source value %v\n", ts.value, ss.value)
-			continue //target value is bigger so leave it
-		}
-		statTime, _ := time.Parse(time.RFC3339, ss.t)
-		tags := map[string]string{"cdn": ss.cdn}
-		if ss.hostname != "" {
-			tags["hostname"] = ss.hostname
-		}
-		if ss.cacheType != "" {
-			tags["type"] = ss.cacheType
+	bps, err := influx.NewBatchPoints(influx.BatchPointsConfig{
+		Database:        def.db,
+		Precision:       def.precision,
+		RetentionPolicy: def.rp,
+	})
+	if err != nil {
+		return chunkDiff{}, err
+	}
+	for key, fp := range fromPoints {
+		if tp, ok := toPoints[key]; ok && tp.value >= fp.value {
+			continue //target value is bigger or equal so leave it
 		}
-		fields := map[string]interface{}{
-			"value": ss.value,
+		statTime, err := time.Parse(time.RFC3339, fp.t)
+		if err != nil {
+			fmt.Printf("error parsing time %s for %v...%v\n", fp.t, def, err)
+			continue
 		}
-		pt, err := influx.NewPoint(
-			statName,
-			tags,
-			fields,
-			statTime,
-		)
+		pt, err := influx.NewPoint(def.name, fp.tags, map[string]interface{}{"value": fp.value}, statTime)
 		if err != nil {
-			fmt.Printf("error adding creating point for %v...%v\n", statName, err)
+			fmt.Printf("error adding creating point for %v...%v\n", def, err)
 			continue
 		}
 		bps.AddPoint(pt)
 	}
-	err = targetClient.Write(bps)
+	if err := dir.to.Write(bps); err != nil {
+		return chunkDiff{}, fmt.Errorf("writing: %v", err)
+	}
+
+	written, err := queryPoints(dir.to, def, q)
 	if err != nil {
-		fmt.Println("Error writing stat - ", err)
+		return chunkDiff{}, err
 	}
+	return diffPoints(fromPoints, toPoints, written), nil
 }
 
-func syncDeliveryServiceStat(sourceClient influx.Client, targetClient influx.Client, statName string, days int) {
-
-	db := deliveryService
-	rp := "monthly"
-
-	if strings.Contains(statName, "1day") {
-		rp = "indefinite"
+func queryPoints(client influx.Client, def statDef, q string) (map[string]statPoint, error) {
+	res, err := queryDB(client, q, def.db)
+	if err != nil {
+		return nil, fmt.Errorf("querying target: %v", err)
 	}
+	return def.parse(res), nil
+}
 
-	bps, _ := influx.NewBatchPoints(influx.BatchPointsConfig{
-		Database:        db,
-		Precision:       "ms",
-		RetentionPolicy: rp,
-	})
-
-	queryString := fmt.Sprintf("select time, cachegroup, cdn, deliveryservice, value from \"%s\".\"%s\"", rp, statName)
-	if days > 0 {
-		queryString = fmt.Sprintf("%s where time > now() - %dd", queryString, days)
-	}
-	fmt.Println("queryString ", queryString)
-	res, err := queryDB(sourceClient, queryString, db)
+// firstPointTime returns the time of the oldest point of the stat, and false if there are no points.
+func firstPointTime(client influx.Client, def statDef) (time.Time, bool, error) {
+	res, err := queryDB(client, fmt.Sprintf(`select first(value) from "%s"."%s"`, def.rp, def.name), def.db)
 	if err != nil {
-		fmt.Printf("An error occured getting %s records from sourceDb: %v\n", statName, err)
-		return
+		return time.Time{}, false, err
+	}
+	if len(res) == 0 || len(res[0].Series) == 0 || len(res[0].Series[0].Values) == 0 {
+		return time.Time{}, false, nil
 	}
-	sourceStats := getDeliveryServiceStats(res)
-	// get value from target DB
-	targetRes, err := queryDB(targetClient, queryString, db)
+	t, err := time.Parse(time.RFC3339, res[0].Series[0].Values[0][0].(string))
 	if err != nil {
-		fmt.Printf("An error occured getting %s record from target db: %v\n", statName, err)
-		return
+		return time.Time{}, false, err
 	}
-	targetStats := getDeliveryServiceStats(targetRes)
+	return t, true, nil
+}
 
-	for ssKey := range sourceStats {
-		ts := targetStats[ssKey]
-		ss := sourceStats[ssKey]
-		if ts.value > ss.value {
-			//fmt.Printf("target value %v is at least equal to source value %v\n", ts.value, ss.value)
-			continue //target value is bigger so leave it
-		}
-		statTime, _ := time.Parse(time.RFC3339, ss.t)
-		tags := map[string]string{
-			"cdn":             ss.cdn,
-			"cachegroup":      ss.cacheGroup,
-			"deliveryservice": ss.deliveryService,
+// chunks splits [start, end) into chunks whose boundaries are multiples of size, so chunks are the same across runs.
+func chunks(start time.Time, end time.Time, size time.Duration) []chunk {
+	cs := []chunk{}
+	for cStart := start; cStart.Before(end); {
+		cEnd := cStart.Truncate(size).Add(size)
+		if cEnd.After(end) {
+			cEnd = end
 		}
-		fields := map[string]interface{}{
-			"value": ss.value,
+		cs = append(cs, chunk{start: cStart, end: cEnd})
+		cStart = cEnd
+	}
+	return cs
+}
+
+// diffPoints compares the points of from with those of to, over the keys of from. The value to should have for each key is the one in from, or the one in before, the target's points before syncing, if that's bigger.
+func diffPoints(from map[string]statPoint, before map[string]statPoint, to map[string]statPoint) chunkDiff {
+	want := map[string]statPoint{}
+	toCommon := map[string]statPoint{}
+	diff := chunkDiff{fromPoints: len(from)}
+	for key, fp := range from {
+		want[key] = fp
+		if bp, ok := before[key]; ok && bp.value > fp.value {
+			want[key] = bp
 		}
-		pt, err := influx.NewPoint(
-			statName,
-			tags,
-			fields,
-			statTime,
-		)
-		if err != nil {
-			fmt.Printf("error adding creating point for %v...%v\n", statName, err)
+		tp, ok := to[key]
+		if !ok {
+			diff.missing++
 			continue
 		}
-		bps.AddPoint(pt)
+		toCommon[key] = tp
+		if tp.value < fp.value {
+			diff.lower++
+		}
+	}
+	diff.toPoints = len(toCommon)
+	diff.wantChecksum = checksum(want)
+	diff.toChecksum = checksum(toCommon)
+	return diff
+}
+
+// checksum returns a hash of the keys and values of the points, independent of their order.
+func checksum(points map[string]statPoint) uint64 {
+	keys := make([]string, 0, len(points))
+	for key := range points {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := fnv.New64a()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s=%v\n", key, points[key].value)
+	}
+	return h.Sum64()
+}
+
+func loadSyncState(path string) (*syncState, error) {
+	state := &syncState{path: path, Completed: map[string]time.Time{}}
+	bts, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
 	}
-	err = targetClient.Write(bps)
 	if err != nil {
-		fmt.Println("Error writing stat - ", err)
+		return nil, err
+	}
+	if err := json.Unmarshal(bts, state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	if state.Completed == nil {
+		state.Completed = map[string]time.Time{}
 	}
+	return state, nil
 }
 
-func syncDailyStat(sourceClient influx.Client, targetClient influx.Client, statName string, days int) {
+func (s *syncState) get(key string) (time.Time, bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	t, ok := s.Completed[key]
+	return t, ok
+}
 
-	db := daily
-	bps, _ := influx.NewBatchPoints(influx.BatchPointsConfig{
-		Database:        db,
-		Precision:       "s",
-		RetentionPolicy: "indefinite",
-	})
-	//get records from source DB
-	queryString := fmt.Sprintf("select time, cdn, deliveryservice, value from \"%s\"", statName)
-	if days > 0 {
-		queryString = fmt.Sprintf("%s where time > now() - %dd", queryString, days)
+// complete records that the key has been synced through t, and saves the state if it has a path. The file is replaced atomically, so an interrupted save never loses progress.
+func (s *syncState) complete(key string, t time.Time) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.Completed[key] = t
+	if s.path == "" {
+		return nil
 	}
-	res, err := queryDB(sourceClient, queryString, db)
+	bts, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
-		fmt.Printf("An error occured getting %s records from sourceDb: %v\n", statName, err)
-		return
+		return err
 	}
-	sourceStats := getDailyStats(res)
-	// get value from target DB
-	targetRes, err := queryDB(targetClient, queryString, db)
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path))
 	if err != nil {
-		fmt.Printf("An error occured getting %s record from target db: %v\n", statName, err)
-		return
+		return err
 	}
-	targetStats := getDailyStats(targetRes)
+	if _, err := tmp.Write(bts); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
 
-	for ssKey := range sourceStats {
-		ts := targetStats[ssKey]
-		ss := sourceStats[ssKey]
-		if ts.value >= ss.value {
-			//fmt.Printf("target value %v is at least equal to source value %v\n", ts.value, ss.value)
-			continue //target value is bigger or equal so leave it
+func parseCacheStats(res []influx.Result) map[string]statPoint {
+	points := map[string]statPoint{}
+	for key, ss := range getCacheStats(res) {
+		tags := map[string]string{"cdn": ss.cdn}
+		if ss.hostname != "" {
+			tags["hostname"] = ss.hostname
+		}
+		if ss.cacheType != "" {
+			tags["type"] = ss.cacheType
 		}
-		statTime, _ := time.Parse(time.RFC3339, ss.t)
+		points[key] = statPoint{t: ss.t, tags: tags, value: ss.value}
+	}
+	return points
+}
+
+func parseDeliveryServiceStats(res []influx.Result) map[string]statPoint {
+	points := map[string]statPoint{}
+	for key, ss := range getDeliveryServiceStats(res) {
 		tags := map[string]string{
 			"cdn":             ss.cdn,
+			"cachegroup":      ss.cacheGroup,
 			"deliveryservice": ss.deliveryService,
 		}
-		fields := map[string]interface{}{
-			"value": ss.value,
-		}
-		pt, err := influx.NewPoint(
-			statName,
-			tags,
-			fields,
-			statTime,
-		)
-		if err != nil {
-			fmt.Printf("error adding creating point for %v...%v\n", statName, err)
-			continue
-		}
-		bps.AddPoint(pt)
+		points[key] = statPoint{t: ss.t, tags: tags, value: ss.value}
 	}
-	err = targetClient.Write(bps)
-	if err != nil {
-		fmt.Println("Error writing stat - ", err)
+	return points
+}
+
+func parseDailyStats(res []influx.Result) map[string]statPoint {
+	points := map[string]statPoint{}
+	for key, ss := range getDailyStats(res) {
+		tags := map[string]string{
+			"cdn":             ss.cdn,
+			"deliveryservice": ss.deliveryService,
+		}
+		points[key] = statPoint{t: ss.t, tags: tags, value: ss.value}
 	}
+	return points
 }
 
 func getCacheStats(res []influx.Result) map[string]cacheStats {
@@ -474,11 +666,12 @@ func queryDB(client influx.Client, cmd string, dbs ...string) (res []influx.Resu
 		Command:  cmd,
 		Database: db,
 	}
-	if response, err := client.Query(q); err == nil {
-		if response.Error() != nil {
-			return res, response.Error()
-		}
-		res = response.Results
+	response, err := client.Query(q)
+	if err != nil {
+		return res, err
+	}
+	if response.Error() != nil {
+		return res, response.Error()
 	}
-	return res, nil
+	return response.Results, nil
 }
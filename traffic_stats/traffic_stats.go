@@ -37,6 +37,8 @@ import (
 
 	"github.com/apache/incubator-trafficcontrol/lib/go-tc"
 	"github.com/apache/incubator-trafficcontrol/traffic_ops/client"
+	"github.com/apache/incubator-trafficcontrol/traffic_stats/alerting"
+	"github.com/apache/incubator-trafficcontrol/traffic_stats/influxdb"
 	log "github.com/cihub/seelog"
 	influx "github.com/influxdata/influxdb/client/v2"
 )
@@ -63,24 +65,29 @@ const (
 
 // StartupConfig contains all fields necessary to create a traffic stats session.
 type StartupConfig struct {
-	ToUser                      string   `json:"toUser"`
-	ToPasswd                    string   `json:"toPasswd"`
-	ToURL                       string   `json:"toUrl"`
-	InfluxUser                  string   `json:"influxUser"`
-	InfluxPassword              string   `json:"influxPassword"`
-	InfluxURLs                  []string `json:"influxUrls"`
-	PollingInterval             int      `json:"pollingInterval"`
-	DailySummaryPollingInterval int      `json:"dailySummaryPollingInterval"`
-	PublishingInterval          int      `json:"publishingInterval"`
-	ConfigInterval              int      `json:"configInterval"`
-	MaxPublishSize              int      `json:"maxPublishSize"`
-	StatusToMon                 string   `json:"statusToMon"`
-	SeelogConfig                string   `json:"seelogConfig"`
-	CacheRetentionPolicy        string   `json:"cacheRetentionPolicy"`
-	DsRetentionPolicy           string   `json:"dsRetentionPolicy"`
-	DailySummaryRetentionPolicy string   `json:"dailySummaryRetentionPolicy"`
+	ToUser                      string          `json:"toUser"`
+	ToPasswd                    string          `json:"toPasswd"`
+	ToURL                       string          `json:"toUrl"`
+	InfluxUser                  string          `json:"influxUser"`
+	InfluxPassword              string          `json:"influxPassword"`
+	InfluxURLs                  []string        `json:"influxUrls"`
+	PollingInterval             int             `json:"pollingInterval"`
+	DailySummaryPollingInterval int             `json:"dailySummaryPollingInterval"`
+	PublishingInterval          int             `json:"publishingInterval"`
+	ConfigInterval              int             `json:"configInterval"`
+	MaxPublishSize              int             `json:"maxPublishSize"`
+	StatusToMon                 string          `json:"statusToMon"`
+	SeelogConfig                string          `json:"seelogConfig"`
+	CacheRetentionPolicy        string          `json:"cacheRetentionPolicy"`
+	DsRetentionPolicy           string          `json:"dsRetentionPolicy"`
+	DailySummaryRetentionPolicy string          `json:"dailySummaryRetentionPolicy"`
+	InfluxSchema                string          `json:"influxSchema"`
+	Alerting                    alerting.Config `json:"alerting"`
 	BpsChan                     chan influx.BatchPoints
 	InfluxDBs                   []*InfluxDBProps
+	Detector                    *alerting.Detector
+	Notifiers                   []alerting.Notifier
+	Dispatcher                  *alerting.Dispatcher
 }
 
 // RunningConfig is used to store runtime configuration for Traffic Stats.  This includes information
@@ -125,6 +132,8 @@ func main() {
 
 	defer log.Flush()
 
+	checkSchema(config)
+
 	configChan := make(chan RunningConfig)
 	go getToData(config, true, configChan)
 	runningConfig := <-configChan
@@ -219,6 +228,20 @@ func loadStartupConfig(configFile string, oldConfig StartupConfig) (StartupConfi
 
 	config.BpsChan = oldConfig.BpsChan
 
+	// keep the baselines learned so far across config reloads
+	config.Detector = oldConfig.Detector
+	if config.Detector == nil {
+		config.Detector = alerting.NewDetector(config.Alerting)
+	} else {
+		config.Detector.SetConfig(config.Alerting)
+	}
+	config.Notifiers = alerting.NewNotifiers(config.Alerting)
+	// the queue size of a running dispatcher doesn't change on reload, so queued alerts aren't lost
+	config.Dispatcher = oldConfig.Dispatcher
+	if config.Dispatcher == nil {
+		config.Dispatcher = alerting.NewDispatcher(config.Alerting.WithDefaults().NotifyQueueSize, func(err error) { errHndlr(err, ERROR) })
+	}
+
 	if config.PollingInterval == 0 {
 		config.PollingInterval = defaultPollingInterval
 	}
@@ -262,6 +285,35 @@ func loadStartupConfig(configFile string, oldConfig StartupConfig) (StartupConfi
 	return config, nil
 }
 
+// checkSchema warns about any difference between the InfluxDB schema file and the live databases. Traffic Stats doesn't change the schema itself, that's done with influxdb_tools/schema.
+func checkSchema(config StartupConfig) {
+	if config.InfluxSchema == "" {
+		return
+	}
+	schema, err := influxdb.LoadSchema(config.InfluxSchema)
+	if err != nil {
+		errHndlr(fmt.Errorf("Could not check InfluxDB schema: %v", err), WARN)
+		return
+	}
+	influxClient, err := influxConnect(config)
+	if err != nil {
+		errHndlr(fmt.Errorf("Could not connect to InfluxDB to check schema: %v", err), WARN)
+		return
+	}
+	current, err := influxdb.CurrentSchema(influxClient)
+	if err != nil {
+		errHndlr(fmt.Errorf("Could not read InfluxDB schema: %v", err), WARN)
+		return
+	}
+	changes := influxdb.Plan(schema, current, false)
+	for _, change := range changes {
+		log.Warnf("InfluxDB schema drift from %s: %s", config.InfluxSchema, change.Description)
+	}
+	if len(changes) == 0 {
+		log.Info("InfluxDB schema matches ", config.InfluxSchema)
+	}
+}
+
 func calcDailySummary(now time.Time, config StartupConfig, runningConfig RunningConfig) {
 	log.Infof("lastSummaryTime is %v", runningConfig.LastSummaryTime)
 	if runningConfig.LastSummaryTime.Day() != now.Day() {
@@ -570,6 +622,7 @@ func calcDsValues(rascalData []byte, cdnName string, sampleTime int64, config St
 	}
 
 	statCount := 0
+	observations := []alerting.Observation{}
 	bps, _ := influx.NewBatchPoints(influx.BatchPointsConfig{
 		Database:        "deliveryservice_stats",
 		Precision:       "ms",
@@ -632,13 +685,37 @@ func calcDsValues(rascalData []byte, cdnName string, sampleTime int64, config St
 			}
 			bps.AddPoint(pt)
 			statCount++
+
+			if _, isType := tags["type"]; !isType {
+				observations = append(observations, alerting.Observation{
+					DeliveryService: dsName,
+					CacheGroup:      cachegroup,
+					Stat:            statName,
+					Value:           statFloatValue,
+					Time:            newTime,
+				})
+			}
 		}
 	}
 	config.BpsChan <- bps
 	log.Info("Collected ", statCount, " deliveryservice stats values for ", cdnName, " @ ", sampleTime)
+	checkAlerts(config, observations)
 	return nil
 }
 
+// checkAlerts compares the polled delivery service stats with their baselines, and queues an alert for each anomaly. The alerts are sent by the dispatcher, so polling doesn't wait on notifiers.
+func checkAlerts(config StartupConfig, observations []alerting.Observation) {
+	if config.Detector == nil || config.Dispatcher == nil {
+		return
+	}
+	for _, alert := range config.Detector.Observe(observations) {
+		log.Warn("Delivery service traffic anomaly - ", alert)
+		if !config.Dispatcher.Send(config.Notifiers, alert) {
+			log.Error("Alert queue full, dropping alert - ", alert)
+		}
+	}
+}
+
 func calcCacheValues(trafmonData []byte, cdnName string, sampleTime int64, cacheMap map[string]tc.Server, config StartupConfig) error {
 
 	type CacheStatsJSON struct {